@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/backtest"
+	"github.com/tathienbao/quant-bot/internal/config"
+	"github.com/tathienbao/quant-bot/internal/persistence"
+)
+
+// openBacktestLog opens the SQLite store that holds the backtest run log.
+func openBacktestLog(cfg *config.Config) (*persistence.SQLiteRepository, error) {
+	if !cfg.Persistence.Enabled || cfg.Persistence.Type != "sqlite" {
+		return nil, fmt.Errorf("backtest log requires persistence.enabled with type sqlite")
+	}
+	return persistence.NewSQLiteRepository(cfg.Persistence.Path)
+}
+
+// saveBacktestRun appends a backtest's summary to the run log.
+func saveBacktestRun(cfg *config.Config, strategyName, dataPath string, result *backtest.Result, metrics *backtest.Metrics) error {
+	repo, err := openBacktestLog(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = repo.Close() }()
+
+	return repo.SaveBacktestRun(context.Background(), persistence.BacktestRun{
+		RunAt:       time.Now(),
+		Strategy:    strategyName,
+		DataFile:    dataPath,
+		ConfigHash:  cfg.Fingerprint(),
+		TotalReturn: result.TotalReturn,
+		MaxDrawdown: result.MaxDrawdown,
+		SharpeRatio: metrics.SharpeRatio(),
+		TotalTrades: result.TotalTrades,
+		WinRate:     result.WinRate,
+	})
+}
+
+// backtestRunOrder ranks runs for a --sort key; the best run sorts first.
+var backtestRunOrder = map[string]func(a, b persistence.BacktestRun) bool{
+	"time":     func(a, b persistence.BacktestRun) bool { return a.RunAt.After(b.RunAt) },
+	"return":   func(a, b persistence.BacktestRun) bool { return a.TotalReturn.GreaterThan(b.TotalReturn) },
+	"drawdown": func(a, b persistence.BacktestRun) bool { return a.MaxDrawdown.LessThan(b.MaxDrawdown) },
+	"sharpe":   func(a, b persistence.BacktestRun) bool { return a.SharpeRatio.GreaterThan(b.SharpeRatio) },
+	"trades":   func(a, b persistence.BacktestRun) bool { return a.TotalTrades > b.TotalTrades },
+	"win_rate": func(a, b persistence.BacktestRun) bool { return a.WinRate.GreaterThan(b.WinRate) },
+}
+
+func cmdBacktests(args []string) {
+	fs := flag.NewFlagSet("backtests", flag.ExitOnError)
+	configPath := configFlag(fs)
+	sortBy := fs.String("sort", "time", "Order: time, return, drawdown, sharpe, trades or win_rate")
+	strategyName := fs.String("strategy", "", "Only show runs of this strategy")
+	limit := fs.Int("limit", 20, "Number of runs to print (0 = all)")
+	_ = fs.Parse(args) // ExitOnError handles parse errors
+
+	less, ok := backtestRunOrder[*sortBy]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "invalid --sort %q (want time, return, drawdown, sharpe, trades or win_rate)\n", *sortBy)
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig(configPath, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	repo, err := openBacktestLog(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open backtest log: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = repo.Close() }()
+
+	runs, err := repo.GetBacktestRuns(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load backtest runs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *strategyName != "" {
+		filtered := runs[:0]
+		for _, run := range runs {
+			if run.Strategy == *strategyName {
+				filtered = append(filtered, run)
+			}
+		}
+		runs = filtered
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return less(runs[i], runs[j]) })
+
+	printBacktestRuns(runs, *sortBy, *limit)
+}
+
+func printBacktestRuns(runs []persistence.BacktestRun, sortBy string, limit int) {
+	fmt.Printf("=== BACKTEST RUNS (%d, by %s) ===\n", len(runs), sortBy)
+	if len(runs) == 0 {
+		fmt.Println("No runs recorded; use quant-bot backtest --save")
+		return
+	}
+	fmt.Printf("%-16s %-18s %9s %9s %8s %7s %8s  %-12s %s\n", "Run At", "Strategy", "Return", "MaxDD", "Sharpe", "Trades", "WinRate", "Config", "Data")
+
+	hundred := decimal.NewFromInt(100)
+	for i, run := range runs {
+		if limit > 0 && i >= limit {
+			break
+		}
+		fmt.Printf("%-16s %-18s %8.2f%% %8.2f%% %8.2f %7d %7.2f%%  %-12s %s\n",
+			run.RunAt.Local().Format("2006-01-02 15:04"),
+			run.Strategy,
+			run.TotalReturn.Mul(hundred).InexactFloat64(),
+			run.MaxDrawdown.Mul(hundred).InexactFloat64(),
+			run.SharpeRatio.InexactFloat64(),
+			run.TotalTrades,
+			run.WinRate.Mul(hundred).InexactFloat64(),
+			run.ConfigHash,
+			filepath.Base(run.DataFile),
+		)
+	}
+}
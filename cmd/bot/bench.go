@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/tathienbao/quant-bot/internal/alerting"
+	"github.com/tathienbao/quant-bot/internal/broker/paper"
+	"github.com/tathienbao/quant-bot/internal/engine"
+	"github.com/tathienbao/quant-bot/internal/observer"
+	"github.com/tathienbao/quant-bot/internal/risk"
+	"github.com/tathienbao/quant-bot/internal/strategy"
+)
+
+func cmdBench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	configPath := configFlag(fs)
+	configOverride := fs.String("config-override", "", "Config file merged over --config (e.g. live.yaml)")
+	dataPath := fs.String("data", "", "CSV file of bars to stream through the engine")
+	strategyName := fs.String("strategy", "", "Strategy to run")
+	_ = fs.Parse(args) // ExitOnError handles parse errors
+
+	if *dataPath == "" || *strategyName == "" {
+		fmt.Fprintln(os.Stderr, "--data and --strategy are required")
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig(configPath, *configOverride)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Load bars up front so file parsing isn't part of the measurement
+	file, err := os.Open(*dataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open data: %v\n", err)
+		os.Exit(1)
+	}
+	events, err := observer.ParseCSVStrict(file, cfg.Market.InstrumentPrimary)
+	_ = file.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse data: %v\n", err)
+		os.Exit(1)
+	}
+	if len(events) == 0 {
+		fmt.Fprintf(os.Stderr, "no bars in %s\n", *dataPath)
+		os.Exit(1)
+	}
+
+	// Warnings only: per-order info logs would swamp the report
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	strat, err := strategy.New(*strategyName, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create strategy: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Same broker and engine settings as `run --paper`, but fills happen
+	// inside PlaceOrder so no goroutine or delay skews the timing
+	paperCfg := paperConfig(cfg)
+	paperCfg.SyncFills = true
+	paperCfg.FillDelay = 0
+	paperBroker := paper.NewBroker(paperCfg, logger)
+	if err := paperBroker.Connect(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect paper broker: %v\n", err)
+		os.Exit(1)
+	}
+
+	tradingEngine := engine.NewEngine(
+		engineConfig(cfg),
+		paperBroker,
+		risk.NewEngine(cfg.ToRiskConfig(), cfg.StartingEquityDecimal(), logger),
+		strat,
+		observer.NewCalculator(cfg.CalculatorConfig(cfg.Market.InstrumentPrimary)),
+		alerting.NewConsoleAlerter(logger), // Real channels would time the network, not the engine
+		logger,
+	)
+
+	// Events go straight into the engine instead of through the market data
+	// channel, which drops bars when the loop falls behind
+	latencies := make([]time.Duration, 0, len(events))
+	start := time.Now()
+	for _, event := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		paperBroker.SimulateMarketData(event)
+
+		eventStart := time.Now()
+		if err := tradingEngine.ProcessEvent(ctx, event); err != nil {
+			logger.Error("failed to process market event", "err", err)
+		}
+		latencies = append(latencies, time.Since(eventStart))
+	}
+	elapsed := time.Since(start)
+
+	slices.Sort(latencies)
+	fmt.Printf("=== ENGINE BENCHMARK (%s, %s) ===\n", *strategyName, *dataPath)
+	fmt.Printf("Bars:        %d in %s\n", len(latencies), elapsed.Round(time.Millisecond))
+	fmt.Printf("Throughput:  %.0f bars/s\n", float64(len(latencies))/elapsed.Seconds())
+	fmt.Printf("Latency p50: %s\n", percentile(latencies, 0.50))
+	fmt.Printf("Latency p99: %s\n", percentile(latencies, 0.99))
+	if len(latencies) > 0 {
+		fmt.Printf("Latency max: %s\n", latencies[len(latencies)-1])
+	}
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tathienbao/quant-bot/internal/backtest"
+	"github.com/tathienbao/quant-bot/internal/config"
+	"github.com/tathienbao/quant-bot/internal/observer"
+	"github.com/tathienbao/quant-bot/internal/strategy"
+)
+
+// compareStrategies backtests every registered strategy on the same bars
+// and prints one row per strategy. The feed is read once into memory.
+func compareStrategies(cfg *config.Config, feed observer.MarketDataFeed, dataPath string, riskFreeRate float64) error {
+	ctx := context.Background()
+	symbol := cfg.Market.InstrumentPrimary
+
+	events, err := observer.LastBars(ctx, feed, symbol, 0)
+	_ = feed.Close()
+	if err != nil {
+		return fmt.Errorf("read %s: %w", dataPath, err)
+	}
+
+	entries := strategy.Registered()
+	fmt.Printf("Comparing %d strategies on %s (%d bars)...\n", len(entries), dataPath, len(events))
+
+	rf := perBarRiskFreeRate(cfg, riskFreeRate)
+	format := displayFormat(cfg)
+
+	fmt.Println("\n=== STRATEGY COMPARISON ===")
+	fmt.Printf("%-20s %9s %9s %8s %7s %8s\n", "Strategy", "Return", "MaxDD", "Sharpe", "Trades", "WinRate")
+	for _, entry := range entries {
+		runner := backtest.NewRunner(
+			backtestConfig(cfg),
+			observer.NewMemoryFeed(events, symbol),
+			observer.NewCalculator(cfg.CalculatorConfig(symbol)),
+			entry.New(cfg),
+			cfg.ToRiskConfig(),
+			simulatedConfig(cfg),
+		)
+		result, err := runner.Run(ctx)
+		if err != nil {
+			fmt.Printf("%-20s failed: %v\n", entry.Name, err)
+			continue
+		}
+
+		fmt.Printf("%-20s %9s %9s %8.2f %7d %8s\n",
+			entry.Name,
+			format.Percent(result.TotalReturn),
+			format.Percent(result.MaxDrawdown),
+			backtest.NewMetrics(result, rf).SharpeRatio().InexactFloat64(),
+			result.TotalTrades,
+			format.Percent(result.WinRate),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/tathienbao/quant-bot/internal/broker/ibkr"
+	"github.com/tathienbao/quant-bot/internal/config"
+)
+
+// doctorCheck is one line of the preflight checklist. A failed critical
+// check makes doctor exit nonzero; other failures are warnings.
+type doctorCheck struct {
+	name     string
+	ok       bool
+	critical bool
+	detail   string
+}
+
+func cmdDoctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	configPath := configFlag(fs)
+	configOverride := fs.String("config-override", "", "Config file merged over --config (e.g. live.yaml)")
+	dataDir := fs.String("data-dir", "data", "Directory of CSV data for paper trading and backtests")
+	timeout := fs.Duration("timeout", 3*time.Second, "Timeout for the IBKR connection check")
+	_ = fs.Parse(args) // ExitOnError handles parse errors
+
+	fmt.Println("Preflight checks:")
+
+	cfg, err := loadConfig(configPath, *configOverride)
+	if err != nil {
+		printDoctorChecks([]doctorCheck{{name: "Config", critical: true, detail: err.Error()}})
+		os.Exit(1)
+	}
+
+	checks := []doctorCheck{
+		{name: "Config", ok: true, critical: true, detail: configPath.String() + " is valid"},
+		checkDataDir(*dataDir),
+		checkIBKR(cfg, *timeout),
+		checkPersistence(cfg),
+	}
+	checks = append(checks, checkAlertChannels(cfg)...)
+
+	if !printDoctorChecks(checks) {
+		os.Exit(1)
+	}
+}
+
+// printDoctorChecks prints the checklist and reports whether every critical
+// check passed.
+func printDoctorChecks(checks []doctorCheck) bool {
+	passed := true
+	for _, c := range checks {
+		status := "PASS"
+		switch {
+		case !c.ok && c.critical:
+			status = "FAIL"
+			passed = false
+		case !c.ok:
+			status = "WARN"
+		}
+		fmt.Printf("  [%s] %-12s %s\n", status, c.name, c.detail)
+	}
+
+	if passed {
+		fmt.Println("\nReady.")
+	} else {
+		fmt.Println("\nNot ready: fix the failed checks above.")
+	}
+	return passed
+}
+
+// checkDataDir checks the CSV directory paper trading and backtests read.
+// Live trading doesn't need it, so a missing directory is only a warning.
+func checkDataDir(dir string) doctorCheck {
+	check := doctorCheck{name: "Data dir"}
+	info, err := os.Stat(dir)
+	switch {
+	case err != nil:
+		check.detail = fmt.Sprintf("%s: %v", dir, err)
+	case !info.IsDir():
+		check.detail = dir + " is not a directory"
+	default:
+		files, _ := filepath.Glob(filepath.Join(dir, "*.csv"))
+		check.ok = true
+		check.detail = fmt.Sprintf("%s (%d CSV files)", dir, len(files))
+	}
+	return check
+}
+
+// checkIBKR dials the configured TWS/Gateway address. It is critical only
+// when the broker is IBKR; paper trading on CSV data never connects.
+func checkIBKR(cfg *config.Config, timeout time.Duration) doctorCheck {
+	ibCfg := ibkr.DefaultConfig()
+	if cfg.Broker.Host != "" {
+		ibCfg.Host = cfg.Broker.Host
+	}
+	if cfg.Broker.Port != 0 {
+		ibCfg.Port = cfg.Broker.Port
+	}
+	addr := net.JoinHostPort(ibCfg.Host, strconv.Itoa(ibCfg.Port))
+
+	check := doctorCheck{name: "IBKR", critical: cfg.Broker.Type == "ibkr"}
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		check.detail = fmt.Sprintf("%s unreachable: %v", addr, err)
+		return check
+	}
+	_ = conn.Close()
+	check.ok = true
+	check.detail = addr + " reachable"
+	return check
+}
+
+// checkPersistence checks the SQLite database can be created or written.
+func checkPersistence(cfg *config.Config) doctorCheck {
+	check := doctorCheck{name: "Persistence", critical: true}
+	if !cfg.Persistence.Enabled {
+		check.ok = true
+		check.detail = "disabled"
+		return check
+	}
+	if cfg.Persistence.Type != "sqlite" {
+		check.ok = true
+		check.detail = cfg.Persistence.Type + " (not checked)"
+		return check
+	}
+
+	path := cfg.Persistence.Path
+	if info, err := os.Stat(path); err == nil {
+		// Open for writing without truncating the database
+		f, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			check.detail = fmt.Sprintf("%s not writable: %v", path, err)
+			return check
+		}
+		_ = f.Close()
+		check.ok = true
+		check.detail = fmt.Sprintf("%s writable (%d bytes)", path, info.Size())
+		return check
+	}
+
+	// A new database needs a writable directory
+	f, err := os.CreateTemp(filepath.Dir(path), ".doctor-*")
+	if err != nil {
+		check.detail = fmt.Sprintf("cannot create %s: %v", path, err)
+		return check
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+	check.ok = true
+	check.detail = path + " will be created"
+	return check
+}
+
+// checkAlertChannels checks every configured alert channel has the
+// credentials createAlerter needs.
+func checkAlertChannels(cfg *config.Config) []doctorCheck {
+	if !cfg.Alerting.Enabled {
+		return []doctorCheck{{name: "Alerts", ok: true, detail: "disabled (console only)"}}
+	}
+	if len(cfg.Alerting.Channels) == 0 {
+		return []doctorCheck{{name: "Alerts", ok: true, detail: "no channels (console only)"}}
+	}
+	if err := cfg.CheckAlertingEnv(); err != nil {
+		return []doctorCheck{{name: "Alerts", critical: true, detail: err.Error()}}
+	}
+
+	checks := make([]doctorCheck, 0, len(cfg.Alerting.Channels))
+	for _, ch := range cfg.Alerting.Channels {
+		check := doctorCheck{name: "Alerts", critical: true}
+		switch {
+		case ch.Type != "telegram":
+			check.detail = fmt.Sprintf("unsupported channel type %q", ch.Type)
+		case ch.BotToken == "" || ch.ChatID == "":
+			check.detail = "telegram channel missing bot_token or chat_id"
+		default:
+			check.ok = true
+			check.detail = "telegram chat " + ch.ChatID
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
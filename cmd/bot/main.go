@@ -2,7 +2,6 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"flag"
 	"fmt"
@@ -10,22 +9,29 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/manifoldco/promptui"
 	"github.com/shopspring/decimal"
 	"github.com/tathienbao/quant-bot/internal/alerting"
+	"github.com/tathienbao/quant-bot/internal/audit"
 	"github.com/tathienbao/quant-bot/internal/backtest"
+	"github.com/tathienbao/quant-bot/internal/broker/ibkr"
 	"github.com/tathienbao/quant-bot/internal/broker/paper"
 	"github.com/tathienbao/quant-bot/internal/config"
 	"github.com/tathienbao/quant-bot/internal/engine"
 	"github.com/tathienbao/quant-bot/internal/execution"
+	"github.com/tathienbao/quant-bot/internal/logging"
 	"github.com/tathienbao/quant-bot/internal/metrics"
 	"github.com/tathienbao/quant-bot/internal/observer"
 	"github.com/tathienbao/quant-bot/internal/persistence"
+	"github.com/tathienbao/quant-bot/internal/reconcile"
 	"github.com/tathienbao/quant-bot/internal/risk"
 	"github.com/tathienbao/quant-bot/internal/strategy"
+	"github.com/tathienbao/quant-bot/internal/types"
 	"github.com/tathienbao/quant-bot/internal/ui"
 )
 
@@ -50,10 +56,24 @@ func main() {
 		printUsage()
 	case "backtest":
 		cmdBacktest(os.Args[2:])
+	case "backtests":
+		cmdBacktests(os.Args[2:])
+	case "optimize":
+		cmdOptimize(os.Args[2:])
 	case "run":
 		cmdRun(os.Args[2:])
 	case "validate":
 		cmdValidate(os.Args[2:])
+	case "doctor":
+		cmdDoctor(os.Args[2:])
+	case "report":
+		cmdReport(os.Args[2:])
+	case "size":
+		cmdSize(os.Args[2:])
+	case "resample":
+		cmdResample(os.Args[2:])
+	case "bench":
+		cmdBench(os.Args[2:])
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", os.Args[1])
 		printUsage()
@@ -70,59 +90,39 @@ Usage:
 Commands:
   run        Start the trading bot (live or paper)
   backtest   Run a backtest simulation
+  backtests  List recorded backtest runs for comparison
+  optimize   Sweep strategy parameters and rank the backtests
   validate   Validate configuration file
+  doctor     Check the environment is ready to trade
+  report     Summarize persisted trade history
+  size       Preview the position size for a hypothetical signal
+  resample   Aggregate a CSV of bars into a higher timeframe
+  bench      Measure engine throughput and per-bar latency on a CSV
   version    Show version information
   help       Show this help message
 
 Examples:
   quant-bot run --config config.yaml
+  quant-bot run --paper --live-data --strategy grid
   quant-bot backtest --config config.yaml --data data/MES_5m.csv
+  quant-bot backtest --data data/MES_5m.csv --strategy grid --save
+  quant-bot backtest --synthetic --seed 42 --bars 5000 --strategy grid
+  quant-bot backtests --sort sharpe
+  quant-bot optimize --data data/MES_5m.csv --strategy grid --param rebound_pct=0.1,0.15,0.2
   quant-bot validate --config config.yaml
+  quant-bot validate --config base.yaml --config-override live.yaml --dump
+  quant-bot doctor --config config.yaml
+  quant-bot bench --data data/MES_1m.csv --strategy grid
+  quant-bot report --config config.yaml --since 2024-01-01
+  quant-bot size --stop-ticks 10 --equity 10000
+  quant-bot resample --in data/MGC_1m.csv --out data/MGC_5m.csv --from 1m --to 5m
 
 Use "quant-bot <command> --help" for more information about a command.`)
 }
 
-// strategyOption represents a strategy choice in the menu.
-type strategyOption struct {
-	Name        string
-	Description string
-	Return      string
-	WinRate     string
-	Recommended bool
-}
-
-// selectStrategy shows an interactive menu to select a strategy.
+// selectStrategy shows an interactive menu of the registered strategies.
 func selectStrategy() string {
-	options := []strategyOption{
-		{
-			Name:        "grid",
-			Description: "Grid/Rebound - High Frequency (max return)",
-			Return:      "+51.94%",
-			WinRate:     "91.05%",
-			Recommended: true,
-		},
-		{
-			Name:        "grid-conservative",
-			Description: "Grid/Rebound - Conservative (low risk)",
-			Return:      "+33.54%",
-			WinRate:     "85.41%",
-			Recommended: false,
-		},
-		{
-			Name:        "breakout",
-			Description: "Range Breakout (không khuyến nghị)",
-			Return:      "-11.59%",
-			WinRate:     "0%",
-			Recommended: false,
-		},
-		{
-			Name:        "meanrev",
-			Description: "Mean Reversion (không khuyến nghị)",
-			Return:      "-3.62%",
-			WinRate:     "20%",
-			Recommended: false,
-		},
-	}
+	options := strategy.Registered()
 
 	templates := &promptui.SelectTemplates{
 		Label:    "{{ . }}",
@@ -198,115 +198,196 @@ func cmdVersion() {
 	fmt.Printf("  Git commit: %s\n", GitCommit)
 }
 
+// configFiles is a repeatable --config flag: each use adds a file merged
+// over the ones before it. The first use replaces the default.
+type configFiles struct {
+	paths []string
+	set   bool
+}
+
+// configFlag registers --config on fs with config.yaml as the default.
+func configFlag(fs *flag.FlagSet) *configFiles {
+	files := &configFiles{paths: []string{"config.yaml"}}
+	fs.Var(files, "config", "Configuration file; repeat to merge later files over earlier ones")
+	return files
+}
+
+func (f *configFiles) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(f.paths, " ")
+}
+
+func (f *configFiles) Set(path string) error {
+	if !f.set {
+		f.paths = nil
+		f.set = true
+	}
+	f.paths = append(f.paths, path)
+	return nil
+}
+
+// loadConfig loads the --config files in order, with override merged over
+// them when set.
+func loadConfig(files *configFiles, override string) (*config.Config, error) {
+	return config.LoadFiles(append(append([]string(nil), files.paths...), override)...)
+}
+
 func cmdValidate(args []string) {
 	fs := flag.NewFlagSet("validate", flag.ExitOnError)
-	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	configPath := configFlag(fs)
+	configOverride := fs.String("config-override", "", "Config file merged over --config (e.g. live.yaml)")
+	dump := fs.Bool("dump", false, "Print the fully-resolved config (env vars expanded, defaults applied)")
+	asJSON := fs.Bool("json", false, "Dump as JSON instead of YAML (with --dump)")
+	showSecrets := fs.Bool("show-secrets", false, "Include tokens and passwords in the dump")
 	_ = fs.Parse(args) // ExitOnError handles parse errors
 
-	cfg, err := config.Load(*configPath)
+	cfg, err := loadConfig(configPath, *configOverride)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
 		os.Exit(1)
 	}
 
+	if *dump {
+		out := cfg
+		if !*showSecrets {
+			out = cfg.Redacted()
+		}
+		data, err := out.Dump(*asJSON)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to dump config: %v\n", err)
+			os.Exit(1)
+		}
+		_, _ = os.Stdout.Write(data)
+		return
+	}
+
 	fmt.Println("Configuration is valid!")
 	fmt.Printf("  Starting equity: $%.2f\n", cfg.Account.StartingEquity)
 	fmt.Printf("  Primary instrument: %s\n", cfg.Market.InstrumentPrimary)
 	fmt.Printf("  Max drawdown: %.1f%%\n", cfg.Account.MaxGlobalDrawdownPct*100)
 	fmt.Printf("  Risk per trade: %.1f%%\n", cfg.Account.RiskPerTradePct*100)
+	for _, warning := range cfg.Warnings() {
+		fmt.Printf("  Warning: %s\n", warning)
+	}
 }
 
 func cmdBacktest(args []string) {
 	fs := flag.NewFlagSet("backtest", flag.ExitOnError)
-	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	configPath := configFlag(fs)
 	dataPath := fs.String("data", "", "Path to CSV data file (interactive if empty)")
 	strategyName := fs.String("strategy", "", "Strategy (interactive if empty)")
 	verbose := fs.Bool("verbose", false, "Verbose output")
 	interactive := fs.Bool("i", false, "Force interactive mode")
 	showUI := fs.Bool("ui", true, "Show live chart UI (default: true)")
+	progress := fs.Bool("progress", false, "Print percentage and ETA to stderr instead of the chart UI")
+	riskFreeRate := fs.Float64("risk-free-rate", 0, "Annual risk-free rate for Sharpe/Sortino (e.g. 0.05 for 5%)")
+	save := fs.Bool("save", false, "Record the results in the backtest log (requires sqlite persistence)")
+	synthetic := fs.Bool("synthetic", false, "Generate a random-walk price series instead of reading --data")
+	seed := fs.Int64("seed", 1, "Random seed for --synthetic (same seed, same bars)")
+	bars := fs.Int("bars", 5000, "Number of bars for --synthetic")
+	drift := fs.Float64("drift", 0, "Expected return per bar for --synthetic")
+	volatility := fs.Float64("volatility", 0.001, "Std dev of returns per bar for --synthetic")
+	compareAll := fs.Bool("compare-all", false, "Run every registered strategy on the same data and print a comparison table")
+	noCosts := fs.Bool("no-costs", false, "Set commission and slippage to zero to see the strategy's raw edge")
 	_ = fs.Parse(args) // ExitOnError handles parse errors
 
 	// Interactive mode for data file
-	if *dataPath == "" || *interactive {
+	if *synthetic {
+		*dataPath = fmt.Sprintf("synthetic:seed=%d,bars=%d", *seed, *bars)
+	} else if *dataPath == "" || *interactive {
 		*dataPath = selectDataFile()
 	}
 
 	// Interactive mode for strategy
-	if *strategyName == "" || *interactive {
+	if !*compareAll && (*strategyName == "" || *interactive) {
 		*strategyName = selectStrategy()
 	}
 
+	// The chart UI garbles pipes and CI logs; report plain progress there
+	if *progress || !ui.IsTerminal(os.Stdout) {
+		*progress = *progress || *showUI
+		*showUI = false
+	}
+
 	// Setup logging - suppress if UI enabled
 	logLevel := slog.LevelInfo
 	if *verbose {
 		logLevel = slog.LevelDebug
 	}
-	if *showUI {
-		logLevel = slog.LevelError // Suppress logs when UI active
+	if *showUI || (*compareAll && !*verbose) {
+		logLevel = slog.LevelError // Suppress logs when UI active or they'd bury the table
 	}
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+	logOutput := os.Stdout
+	if *compareAll {
+		logOutput = os.Stderr // Keep the comparison table readable
+	}
+	logger := slog.New(slog.NewTextHandler(logOutput, &slog.HandlerOptions{
 		Level: logLevel,
 	}))
 	slog.SetDefault(logger)
 
 	// Load config
-	cfg, err := config.Load(*configPath)
+	cfg, err := loadConfig(configPath, "")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
 		os.Exit(1)
 	}
-
-	// Count total bars for progress
-	totalBars := countCSVLines(*dataPath)
+	if *noCosts {
+		cfg.DisableCosts()
+	}
 
 	// Create feed
-	feed := observer.NewBacktestFeed(*dataPath, cfg.Market.InstrumentPrimary)
+	var feed observer.MarketDataFeed
+	if *synthetic {
+		synthCfg := observer.DefaultSyntheticConfig()
+		synthCfg.Symbol = cfg.Market.InstrumentPrimary
+		synthCfg.Seed = *seed
+		synthCfg.Bars = *bars
+		synthCfg.Drift = *drift
+		synthCfg.Volatility = *volatility
+		synthCfg.Interval = cfg.TimeframeDuration()
+		feed = observer.NewSyntheticFeed(synthCfg)
+	} else {
+		csvFeed := observer.NewBacktestFeed(*dataPath, cfg.Market.InstrumentPrimary)
+		csvFeed.SetBackAdjust(cfg.Backtest.BackAdjustRolls)
+		csvFeed.SetSkipInvalidBars(cfg.Backtest.SkipInvalidBars, slog.Default())
+		feed = csvFeed
+	}
+
+	if *compareAll {
+		if *noCosts {
+			printNoCostsNotice()
+		}
+		if err := compareStrategies(cfg, feed, *dataPath, *riskFreeRate); err != nil {
+			fmt.Fprintf(os.Stderr, "compare failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Total bars for progress; 0 shows indeterminate progress
+	totalBars, _ := observer.EstimateBars(feed)
 
 	// Create calculator
-	calculator := observer.NewCalculator(observer.CalculatorConfig{
-		ATRPeriod:    cfg.Risk.VolatilityLookbackBars,
-		StdDevPeriod: 20,
-	})
+	calculator := observer.NewCalculator(cfg.CalculatorConfig(cfg.Market.InstrumentPrimary))
 
 	// Create strategy
-	var strat strategy.Strategy
-	switch *strategyName {
-	case "breakout":
-		strat = strategy.NewBreakout(strategy.BreakoutConfig{
-			LookbackBars:   cfg.Risk.VolatilityLookbackBars,
-			ATRMultiplier:  decimal.NewFromFloat(cfg.Risk.StopLossATRMultiple),
-			BreakoutBuffer: decimal.Zero,
-		})
-	case "meanrev":
-		strat = strategy.NewMeanReversion(strategy.MeanRevConfig{
-			SMAPeriod:     20,
-			StdDevPeriod:  20,
-			EntryStdDev:   decimal.RequireFromString("2.0"),
-			ATRMultiplier: decimal.NewFromFloat(cfg.Risk.StopLossATRMultiple),
-		})
-	case "grid":
-		strat = strategy.NewGrid(strategy.OriginalGridConfig())
-	case "grid-conservative":
-		strat = strategy.NewGrid(strategy.ConservativeGridConfig())
-	default:
-		fmt.Fprintf(os.Stderr, "unknown strategy: %s\n", *strategyName)
+	strat, err := strategy.New(*strategyName, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
 
-	// Create execution config
-	execCfg := execution.SimulatedConfig{
-		SlippageTicks:     cfg.Backtest.SlippageTicks,
-		CommissionPerSide: decimal.NewFromFloat(cfg.Backtest.CommissionPerContract / 2),
-	}
-
 	// Create runner
 	runner := backtest.NewRunner(
-		backtest.Config{InitialEquity: cfg.StartingEquityDecimal()},
+		backtestConfig(cfg),
 		feed,
 		calculator,
 		strat,
 		cfg.ToRiskConfig(),
-		execCfg,
+		simulatedConfig(cfg),
 	)
 	runner.SetTotalBars(totalBars)
 
@@ -314,6 +395,7 @@ func cmdBacktest(args []string) {
 	var backtestUI *ui.BacktestUI
 	if *showUI {
 		backtestUI = ui.NewBacktestUI(totalBars, cfg.StartingEquityDecimal())
+		backtestUI.SetFormat(displayFormat(cfg))
 		backtestUI.Start()
 		defer backtestUI.Stop()
 
@@ -342,17 +424,33 @@ func cmdBacktest(args []string) {
 			"data", *dataPath,
 			"strategy", *strategyName,
 			"equity", cfg.Account.StartingEquity,
+			"no_costs", *noCosts,
 		)
 	}
 
+	var progressPrinter *ui.ProgressPrinter
+	if *progress {
+		progressPrinter = ui.NewProgressPrinter(totalBars)
+		runner.SetProgressCallback(func(update backtest.ProgressUpdate) {
+			progressPrinter.Update(update.Bar, update.Equity)
+		})
+	}
+
 	// Run backtest
 	ctx := context.Background()
 	result, err := runner.Run(ctx)
 	if err != nil {
+		if backtestUI != nil {
+			backtestUI.Stop() // os.Exit skips the deferred Stop
+		}
 		fmt.Fprintf(os.Stderr, "backtest failed: %v\n", err)
 		os.Exit(1)
 	}
 
+	if progressPrinter != nil {
+		progressPrinter.Finish(len(result.EquityCurve), result.EndEquity)
+	}
+
 	// Final render with chart
 	if backtestUI != nil {
 		backtestUI.UpdateStats(
@@ -365,45 +463,116 @@ func cmdBacktest(args []string) {
 	}
 
 	// Print results
-	printBacktestResults(result, cfg.Account.StartingEquity)
+	if *noCosts {
+		printNoCostsNotice()
+	}
+	printResults("BACKTEST RESULTS", result, displayFormat(cfg))
+	if cfg.SpreadEstimator() != nil {
+		fmt.Println("\nNote: bid/ask estimated from ATR (backtest.spread_atr_fraction); spread-dependent results are approximate")
+	}
 
-	// Calculate metrics
-	metrics := backtest.NewMetrics(result, decimal.Zero)
+	// Calculate metrics; the equity curve has one point per bar
+	metrics := backtest.NewMetrics(result, perBarRiskFreeRate(cfg, *riskFreeRate))
 	printMetrics(metrics)
-}
 
-// countCSVLines counts the number of data lines in a CSV file
-func countCSVLines(path string) int {
-	file, err := os.Open(path)
-	if err != nil {
-		return 0
+	if *save {
+		if err := saveBacktestRun(cfg, *strategyName, *dataPath, result, metrics); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to save backtest run: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nSaved to backtest log (config %s)\n", cfg.Fingerprint())
 	}
-	defer func() { _ = file.Close() }()
+}
 
-	scanner := bufio.NewScanner(file)
-	count := 0
-	for scanner.Scan() {
-		count++
+// backtestConfig returns the runner settings for a backtest of cfg.
+func backtestConfig(cfg *config.Config) backtest.Config {
+	return backtest.Config{
+		InitialEquity:     cfg.StartingEquityDecimal(),
+		WarmupBars:        cfg.Backtest.WarmupBars,
+		MinSignalStrength: decimal.NewFromFloat(cfg.Execution.MinSignalStrength),
+		SpreadEstimator:   cfg.SpreadEstimator(),
+		MaxSpreadTicks:    cfg.Execution.MaxSpreadTicks,
+		SplitSessions:     cfg.Backtest.SplitSessions,
+		SessionLocation:   cfg.MarketLocation(),
+		SessionStartTime:  cfg.SessionStartOffset(),
 	}
-	// Subtract 1 for header
-	if count > 0 {
-		count--
+}
+
+// simulatedConfig returns the simulated executor settings for a backtest of cfg.
+func simulatedConfig(cfg *config.Config) execution.SimulatedConfig {
+	return execution.SimulatedConfig{
+		SlippageTicks:     cfg.Backtest.SlippageTicks,
+		CommissionPerSide: decimal.NewFromFloat(cfg.Backtest.CommissionPerContract / 2),
+		CommissionModel:   cfg.CommissionModel(),
+		MakerCommission:   cfg.MakerCommissionModel(),
+		SlippageModel:     cfg.SlippageModel(),
+
+		EntrySlippageTicks: cfg.Backtest.EntrySlippageTicks,
+		ExitSlippageTicks:  cfg.Backtest.ExitSlippageTicks,
+		StopSlippageTicks:  cfg.Backtest.StopSlippageTicks,
+
+		BreakevenTriggerTicks: cfg.Backtest.BreakevenTriggerTicks,
+		BreakevenOffsetTicks:  cfg.Backtest.BreakevenOffsetTicks,
+		AmbiguousBarPolicy:    cfg.AmbiguousBarPolicy(),
+		GapFillAtOpen:         cfg.Backtest.GapFillAtOpen,
+		FillNextBarOpen:       cfg.FillAtNextOpen(),
+		MaxHoldBars:           cfg.Backtest.MaxHoldBars,
+		MaxVolumeFraction:     decimal.NewFromFloat(cfg.Backtest.MaxVolumeFraction),
 	}
-	return count
 }
 
-func printBacktestResults(result *backtest.Result, startingEquity float64) {
-	fmt.Println("\n=== BACKTEST RESULTS ===")
+// perBarRiskFreeRate converts an annual risk-free rate to the rate per bar
+// of the configured timeframe, as the equity curve has one point per bar.
+func perBarRiskFreeRate(cfg *config.Config, annual float64) decimal.Decimal {
+	return backtest.PerBarRiskFreeRate(decimal.NewFromFloat(annual), cfg.TimeframeDuration())
+}
+
+// printNoCostsNotice labels a --no-costs run so it isn't mistaken for a
+// realistic result.
+func printNoCostsNotice() {
+	fmt.Println("\n*** COST-FREE RUN: commission and slippage are zero; results show raw edge, not expected P&L ***")
+}
+
+// displayFormat returns the print precision for the primary instrument.
+func displayFormat(cfg *config.Config) ui.Format {
+	return cfg.DisplayFormat(cfg.Market.InstrumentPrimary)
+}
+
+// printResults prints a result summary under a header naming its source.
+func printResults(title string, result *backtest.Result, format ui.Format) {
+	fmt.Printf("\n=== %s ===\n", title)
 	fmt.Printf("Starting Equity:  $%.2f\n", result.StartEquity.InexactFloat64())
 	fmt.Printf("Ending Equity:    $%.2f\n", result.EndEquity.InexactFloat64())
-	fmt.Printf("Total Return:     %.2f%%\n", result.TotalReturn.Mul(decimal.NewFromInt(100)).InexactFloat64())
-	fmt.Printf("Max Drawdown:     %.2f%%\n", result.MaxDrawdown.Mul(decimal.NewFromInt(100)).InexactFloat64())
+	fmt.Printf("Total Return:     %s\n", format.Percent(result.TotalReturn))
+	fmt.Printf("Max Drawdown:     %s\n", format.Percent(result.MaxDrawdown))
 	fmt.Println()
 	fmt.Printf("Total Trades:     %d\n", result.TotalTrades)
 	fmt.Printf("Winning Trades:   %d\n", result.WinningTrades)
 	fmt.Printf("Losing Trades:    %d\n", result.LosingTrades)
-	fmt.Printf("Win Rate:         %.2f%%\n", result.WinRate.Mul(decimal.NewFromInt(100)).InexactFloat64())
+	fmt.Printf("Win Rate:         %s\n", format.Percent(result.WinRate))
 	fmt.Printf("Profit Factor:    %.2f\n", result.ProfitFactor.InexactFloat64())
+	fmt.Println()
+	fmt.Printf("Total Commissions:   $%.2f\n", result.TotalCommission.InexactFloat64())
+	if result.TotalRebates.IsPositive() {
+		fmt.Printf("Total Rebates:      -$%.2f\n", result.TotalRebates.InexactFloat64())
+	}
+	fmt.Printf("Total Slippage Cost: $%.2f\n", result.TotalSlippage.InexactFloat64())
+	if result.NetToGross.IsZero() {
+		fmt.Println("Net/Gross Ratio:     n/a (no profit before costs)")
+	} else {
+		fmt.Printf("Net/Gross Ratio:     %s\n", format.Percent(result.NetToGross))
+	}
+
+	if s := result.Sessions; s != nil {
+		fmt.Println()
+		fmt.Printf("Intraday P&L:     $%.2f (%d trades)\n", s.IntradayPL.InexactFloat64(), s.IntradayTrades)
+		fmt.Printf("Overnight P&L:    $%.2f (%d trades)\n", s.OvernightPL.InexactFloat64(), s.OvernightTrades)
+		if s.WorstGap.IsNegative() {
+			fmt.Printf("Worst Gap:        $%.2f (%s)\n", s.WorstGap.InexactFloat64(), s.WorstGapTime.Format("2006-01-02 15:04"))
+		} else {
+			fmt.Println("Worst Gap:        none against an open position")
+		}
+	}
 }
 
 func printMetrics(m *backtest.Metrics) {
@@ -414,15 +583,101 @@ func printMetrics(m *backtest.Metrics) {
 	fmt.Printf("Expectancy:       $%.2f\n", m.Expectancy().InexactFloat64())
 	fmt.Printf("Avg Win:          $%.2f\n", m.AverageWin().InexactFloat64())
 	fmt.Printf("Avg Loss:         $%.2f\n", m.AverageLoss().InexactFloat64())
+	fmt.Printf("Avg R:            %.2fR\n", m.AverageR().InexactFloat64())
+}
+
+func cmdReport(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	configPath := configFlag(fs)
+	sinceStr := fs.String("since", "", "Start date YYYY-MM-DD (default: all history)")
+	untilStr := fs.String("until", "", "End date YYYY-MM-DD, inclusive (default: now)")
+	_ = fs.Parse(args) // ExitOnError handles parse errors
+
+	cfg, err := loadConfig(configPath, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !cfg.Persistence.Enabled || cfg.Persistence.Type != "sqlite" {
+		fmt.Fprintln(os.Stderr, "report requires persistence.enabled with type sqlite")
+		os.Exit(1)
+	}
+
+	since := time.Unix(0, 0).UTC()
+	if *sinceStr != "" {
+		since, err = time.Parse("2006-01-02", *sinceStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --since %q: %v\n", *sinceStr, err)
+			os.Exit(1)
+		}
+	}
+	until := time.Now()
+	if *untilStr != "" {
+		day, err := time.Parse("2006-01-02", *untilStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --until %q: %v\n", *untilStr, err)
+			os.Exit(1)
+		}
+		until = day.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	repo, err := persistence.NewSQLiteRepository(cfg.Persistence.Path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open persistence: %v\n", err)
+		os.Exit(1)
+	}
+	defer func() { _ = repo.Close() }()
+
+	ctx := context.Background()
+
+	trades, err := repo.GetTrades(ctx, since, until)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load trades: %v\n", err)
+		os.Exit(1)
+	}
+	// Repository returns newest first; metrics expect chronological order
+	sort.Slice(trades, func(i, j int) bool {
+		return trades[i].ExitTime.Before(trades[j].ExitTime)
+	})
+
+	snapshots, err := repo.GetEquityHistory(ctx, since, until)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load equity history: %v\n", err)
+		os.Exit(1)
+	}
+
+	equityCurve := make([]backtest.EquityPoint, 0, len(snapshots))
+	for _, s := range snapshots {
+		equityCurve = append(equityCurve, backtest.EquityPoint{
+			Timestamp: s.Timestamp,
+			Equity:    s.Equity,
+			Drawdown:  s.Drawdown,
+		})
+	}
+
+	// Start from the first snapshot in range so returns reflect the period
+	startEquity := cfg.StartingEquityDecimal()
+	if len(snapshots) > 0 {
+		startEquity = snapshots[0].Equity
+	}
+
+	result := backtest.Summarize(startEquity, trades, equityCurve)
+
+	printResults(fmt.Sprintf("TRADE REPORT (%s to %s)", since.Format("2006-01-02"), until.Format("2006-01-02")), result, displayFormat(cfg))
+	fmt.Printf("Equity Snapshots: %d\n", len(snapshots))
+	printMetrics(backtest.NewMetrics(result, decimal.Zero))
 }
 
 func cmdRun(args []string) {
 	fs := flag.NewFlagSet("run", flag.ExitOnError)
-	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	configPath := configFlag(fs)
+	configOverride := fs.String("config-override", "", "Config file merged over --config (e.g. live.yaml)")
 	paperMode := fs.Bool("paper", true, "Paper trading mode (default: true)")
 	dataPath := fs.String("data", "", "Path to CSV data file (interactive if empty)")
 	strategyName := fs.String("strategy", "", "Strategy (interactive if empty)")
 	barDelay := fs.Duration("bar-delay", 100*time.Millisecond, "Delay between bars in simulation")
+	liveData := fs.Bool("live-data", false, "Paper trade on live IBKR market data instead of a CSV file")
 	interactive := fs.Bool("i", false, "Force interactive mode")
 	_ = fs.Parse(args) // ExitOnError handles parse errors
 
@@ -431,8 +686,13 @@ func cmdRun(args []string) {
 		*strategyName = selectStrategy()
 	}
 
-	// Interactive mode for data file (paper mode only)
-	if *paperMode && (*dataPath == "" || *interactive) {
+	if *liveData && !*paperMode {
+		fmt.Fprintln(os.Stderr, "Error: --live-data requires --paper")
+		os.Exit(1)
+	}
+
+	// Interactive mode for data file (paper mode with CSV data only)
+	if *paperMode && !*liveData && (*dataPath == "" || *interactive) {
 		*dataPath = selectDataFile()
 	}
 
@@ -443,12 +703,21 @@ func cmdRun(args []string) {
 	slog.SetDefault(logger)
 
 	// Load config
-	cfg, err := config.Load(*configPath)
+	cfg, err := loadConfig(configPath, *configOverride)
 	if err != nil {
 		slog.Error("failed to load config", "err", err)
 		os.Exit(1)
 	}
 
+	// Switch to the configured format and file
+	logger, logFile, err := logging.New(cfg.ToLoggingConfig(slog.LevelInfo), os.Stdout)
+	if err != nil {
+		slog.Error("failed to set up logging", "err", err)
+		os.Exit(1)
+	}
+	defer func() { _ = logFile.Close() }()
+	slog.SetDefault(logger)
+
 	// Setup signal handling for graceful shutdown
 	ctx, stop := signal.NotifyContext(context.Background(),
 		syscall.SIGINT, syscall.SIGTERM)
@@ -457,6 +726,8 @@ func cmdRun(args []string) {
 	mode := "paper"
 	if !*paperMode {
 		mode = "live"
+	} else if *liveData {
+		mode = "paper-live-data"
 	}
 
 	slog.Info("quant-bot starting",
@@ -489,7 +760,24 @@ func cmdRun(args []string) {
 		}
 	}
 
+	// Initialize audit trail if enabled
+	var auditRecorder *audit.Recorder
+	if cfg.Audit.Enabled {
+		auditRecorder, err = audit.NewRecorder(cfg.ToAuditConfig(), logger)
+		if err != nil {
+			slog.Error("failed to open audit log", "err", err)
+			os.Exit(1)
+		}
+		defer func() { _ = auditRecorder.Close() }()
+
+		slog.Info("audit trail enabled", "path", cfg.Audit.Path)
+	}
+
 	// Initialize alerter
+	if err := cfg.CheckAlertingEnv(); err != nil {
+		slog.Error("failed to set up alerting", "err", err)
+		os.Exit(1)
+	}
 	alerter := createAlerter(cfg, logger)
 
 	// Initialize risk engine
@@ -505,10 +793,21 @@ func cmdRun(args []string) {
 	var metricsServer *metrics.Server
 	if cfg.Metrics.Enabled {
 		metricsCfg := metrics.ServerConfig{
+			Host:        cfg.Metrics.Host,
 			Port:        cfg.Metrics.Port,
 			MetricsPath: cfg.Metrics.Path,
 			HealthPath:  "/health",
 		}
+		if metricsCfg.Host == "" {
+			metricsCfg.Host = metrics.DefaultServerConfig().Host
+		}
+		if cfg.Metrics.StreamEnabled {
+			metricsCfg.StreamOrigins = cfg.Metrics.StreamOrigins
+			metricsCfg.StreamPath = cfg.Metrics.StreamPath
+			if metricsCfg.StreamPath == "" {
+				metricsCfg.StreamPath = "/stream"
+			}
+		}
 		metricsServer = metrics.NewServer(metricsCfg, logger)
 
 		// Register health checks
@@ -556,59 +855,37 @@ func cmdRun(args []string) {
 	}
 
 	// Initialize strategy
-	var strat strategy.Strategy
-	switch *strategyName {
-	case "breakout":
-		strat = strategy.NewBreakout(strategy.BreakoutConfig{
-			LookbackBars:   cfg.Risk.VolatilityLookbackBars,
-			ATRMultiplier:  decimal.NewFromFloat(cfg.Risk.StopLossATRMultiple),
-			BreakoutBuffer: decimal.Zero,
-		})
-	case "meanrev":
-		strat = strategy.NewMeanReversion(strategy.MeanRevConfig{
-			SMAPeriod:     20,
-			StdDevPeriod:  20,
-			EntryStdDev:   decimal.RequireFromString("2.0"),
-			ATRMultiplier: decimal.NewFromFloat(cfg.Risk.StopLossATRMultiple),
-		})
-	case "grid":
-		strat = strategy.NewGrid(strategy.OriginalGridConfig())
-	case "grid-conservative":
-		strat = strategy.NewGrid(strategy.ConservativeGridConfig())
-	default:
-		slog.Error("unknown strategy", "name", *strategyName)
+	strat, err := strategy.New(*strategyName, cfg)
+	if err != nil {
+		slog.Error("failed to create strategy", "err", err)
 		os.Exit(1)
 	}
 
-	// Initialize calculator
-	calculator := observer.NewCalculator(observer.CalculatorConfig{
-		ATRPeriod:    cfg.Risk.VolatilityLookbackBars,
-		StdDevPeriod: 20,
-	})
+	// Initialize calculator; symbols with their own atr_period get their own
+	calculator := observer.NewCalculator(cfg.CalculatorConfig(cfg.Market.InstrumentPrimary))
 
 	// Initialize broker
 	var tradingEngine *engine.Engine
 	if *paperMode {
 		// Paper trading mode
-		paperCfg := paper.Config{
-			InitialEquity:     cfg.StartingEquityDecimal(),
-			SlippageTicks:     cfg.Backtest.SlippageTicks,
-			CommissionPerSide: decimal.NewFromFloat(cfg.Backtest.CommissionPerContract / 2),
-			FillDelay:         50 * time.Millisecond,
-		}
-		paperBroker := paper.NewBroker(paperCfg, logger)
+		paperBroker := paper.NewBroker(paperConfig(cfg), logger)
 
 		if err := paperBroker.Connect(ctx); err != nil {
 			slog.Error("failed to connect paper broker", "err", err)
 			os.Exit(1)
 		}
 
-		// Create engine
-		engineCfg := engine.Config{
-			Symbol:               cfg.Market.InstrumentPrimary,
-			Timeframe:            5 * time.Minute,
-			EquityUpdateInterval: 1 * time.Minute,
+		// Reconcile persisted positions with what the broker holds
+		if repo != nil {
+			reconciler := reconcile.NewReconciler(repo, paperBroker, riskEngine, alerter, logger)
+			if _, err := reconciler.Run(ctx); err != nil {
+				slog.Error("position reconciliation failed", "err", err)
+			}
 		}
+
+		// Create engine
+		engineCfg := engineConfig(cfg)
+		engineCfg.CheckDataLag = *liveData // Replayed bars carry historical timestamps
 		tradingEngine = engine.NewEngine(
 			engineCfg,
 			paperBroker,
@@ -618,6 +895,21 @@ func cmdRun(args []string) {
 			alerter,
 			logger,
 		)
+		tradingEngine.SetAuditRecorder(auditRecorder)
+		if cfg.Market.WarmStart {
+			warmStart(ctx, cfg, tradingEngine, logger)
+		}
+		if repo != nil {
+			tradingEngine.SetSnapshotStore(repo)
+			tradingEngine.SetOrderStore(repo)
+		}
+
+		if metricsServer != nil {
+			metricsServer.RegisterHealthCheck("market_data", tradingEngine.HealthCheck)
+			metricsServer.RegisterHealthCheck("broker", tradingEngine.BrokerHealthCheck)
+			metricsServer.RegisterHealthCheck("trading_loop", tradingEngine.LoopHealthCheck)
+			tradingEngine.SetStateHub(metricsServer.Hub())
+		}
 
 		// Start engine
 		if err := tradingEngine.Start(ctx); err != nil {
@@ -625,8 +917,20 @@ func cmdRun(args []string) {
 			os.Exit(1)
 		}
 
-		// If data file provided, stream it to the paper broker
-		if *dataPath != "" {
+		// Market data source: live IBKR ticks aggregated into bars, or CSV replay
+		if *liveData {
+			bars, closeLiveData, err := subscribeLiveData(ctx, cfg, engineCfg.Timeframe, logger)
+			if err != nil {
+				slog.Error("failed to subscribe to live market data", "err", err)
+				os.Exit(1)
+			}
+			defer closeLiveData()
+
+			go func() {
+				n := paperBroker.StreamMarketData(ctx, bars)
+				logger.Info("live data stream stopped", "bars_sent", n)
+			}()
+		} else if *dataPath != "" {
 			go streamDataToPaperBroker(ctx, *dataPath, cfg.Market.InstrumentPrimary, paperBroker, *barDelay, logger)
 		} else {
 			slog.Warn("no data file provided, paper broker will wait for market data")
@@ -662,7 +966,7 @@ func cmdRun(args []string) {
 	}
 
 	// Perform shutdown tasks
-	if err := shutdownWithPersistence(shutdownCtx, cfg, repo, riskEngine, alerter); err != nil {
+	if err := shutdownWithPersistence(shutdownCtx, cfg, repo, riskEngine, tradingEngine, alerter); err != nil {
 		slog.Error("shutdown error", "err", err)
 	}
 
@@ -682,8 +986,55 @@ func cmdRun(args []string) {
 	slog.Info("quant-bot shutdown complete")
 }
 
-func shutdownWithPersistence(ctx context.Context, cfg *config.Config, repo *persistence.SQLiteRepository, riskEngine *risk.Engine, alerter alerting.Alerter) error {
-	_ = alerter // Available for future use in shutdown steps
+// paperConfig returns the paper broker settings for cfg, matching the
+// backtest costs and exit rules.
+func paperConfig(cfg *config.Config) paper.Config {
+	return paper.Config{
+		InitialEquity:     cfg.StartingEquityDecimal(),
+		SlippageTicks:     cfg.Backtest.SlippageTicks,
+		CommissionPerSide: decimal.NewFromFloat(cfg.Backtest.CommissionPerContract / 2),
+		CommissionModel:   cfg.CommissionModel(),
+		MakerCommission:   cfg.MakerCommissionModel(),
+		SlippageModel:     cfg.SlippageModel(),
+		FillDelay:         50 * time.Millisecond,
+
+		AmbiguousBarPolicy: cfg.AmbiguousBarPolicy(),
+		GapFillAtOpen:      cfg.Backtest.GapFillAtOpen,
+		MaxHoldBars:        cfg.Backtest.MaxHoldBars,
+	}
+}
+
+// engineConfig returns the trading engine settings for cfg.
+func engineConfig(cfg *config.Config) engine.Config {
+	return engine.Config{
+		Symbol:               cfg.Market.InstrumentPrimary,
+		Timeframe:            cfg.TimeframeDuration(),
+		EquityUpdateInterval: 1 * time.Minute,
+		FlattenOnDailyLoss:   cfg.Account.FlattenOnDailyLoss,
+		ConfirmationBars:     cfg.Execution.ConfirmationBars,
+		FlattenOnKillSwitch:  cfg.Shutdown.ClosePositionsOnShutdown,
+		FlattenTimeout:       cfg.ShutdownTimeout(),
+		MaxSpreadTicks:       cfg.Execution.MaxSpreadTicks,
+		MinSignalStrength:    decimal.NewFromFloat(cfg.Execution.MinSignalStrength),
+		StaleDataThreshold:   cfg.DataStalenessThreshold(),
+		HeartbeatInterval:    cfg.HeartbeatInterval(),
+		MaxMissedHeartbeats:  cfg.Health.MaxMissedHeartbeats,
+		FlattenOnStaleData:   cfg.Health.FlattenOnStaleData,
+		SnapshotInterval:     cfg.SnapshotInterval(),
+		OrderTimeout:         cfg.OrderTimeout(),
+		MaxRetries:           cfg.Execution.MaxRetries,
+		RetryDelay:           cfg.RetryDelay(),
+		PositionReadout:      cfg.Logging.PositionReadout,
+		PositionReadoutAlert: cfg.Logging.PositionReadoutAlert,
+		Calculators:          cfg.SymbolCalculatorConfigs(),
+		CalculatorDefaults:   cfg.CalculatorConfig(""),
+
+		ReverseOnOppositeSignal: cfg.Execution.OppositeSignal == "close" || cfg.Execution.OppositeSignal == "flip",
+		FlipOnOppositeSignal:    cfg.Execution.OppositeSignal == "flip",
+	}
+}
+
+func shutdownWithPersistence(ctx context.Context, cfg *config.Config, repo *persistence.SQLiteRepository, riskEngine *risk.Engine, tradingEngine *engine.Engine, alerter alerting.Alerter) error {
 	slog.Info("starting graceful shutdown",
 		"timeout", cfg.ShutdownTimeout(),
 	)
@@ -701,6 +1052,35 @@ func shutdownWithPersistence(ctx context.Context, cfg *config.Config, repo *pers
 			// TODO: Cancel any pending orders
 			return nil
 		}},
+		{"close positions", func() error {
+			if !cfg.Shutdown.ClosePositionsOnShutdown || tradingEngine == nil {
+				return nil
+			}
+
+			trades, flattenErr := tradingEngine.FlattenForShutdown(ctx)
+
+			// Record whatever closed, even if some positions did not
+			if repo != nil {
+				for _, trade := range trades {
+					if err := repo.SaveTrade(context.Background(), trade); err != nil {
+						slog.Error("failed to save shutdown trade", "symbol", trade.Symbol, "err", err)
+					}
+				}
+			}
+			slog.Info("positions closed on shutdown", "trades", len(trades))
+
+			if flattenErr != nil {
+				alertCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := alerter.Alert(alertCtx, alerting.SeverityCritical, "Could not flatten positions on shutdown",
+					"error", flattenErr.Error(),
+				); err != nil {
+					slog.Warn("failed to send shutdown flatten alert", "err", err)
+				}
+				return fmt.Errorf("close positions: %w", flattenErr)
+			}
+			return nil
+		}},
 		{"save state", func() error {
 			if repo == nil {
 				return nil
@@ -780,6 +1160,85 @@ func createAlerter(cfg *config.Config, logger *slog.Logger) alerting.Alerter {
 	return alerting.NewMultiAlerter(logger, alerters...)
 }
 
+// warmStart seeds the engine's indicators with the last bars of
+// market.warm_start_data. A missing or bad file only costs the warm-up.
+func warmStart(ctx context.Context, cfg *config.Config, eng *engine.Engine, logger *slog.Logger) {
+	feed := observer.NewBacktestFeed(cfg.Market.WarmStartData, cfg.Market.InstrumentPrimary)
+	defer func() { _ = feed.Close() }()
+
+	bars, err := observer.LastBars(ctx, feed, cfg.Market.InstrumentPrimary, cfg.Market.WarmStartBars)
+	if err != nil {
+		logger.Warn("warm start skipped, indicators start empty", "path", cfg.Market.WarmStartData, "err", err)
+		return
+	}
+	if eng.WarmUp(bars) == 0 {
+		logger.Warn("warm start found no bars", "path", cfg.Market.WarmStartData)
+	}
+}
+
+// subscribeLiveData connects to IBKR for market data only and returns bars
+// aggregated from live ticks plus a func that unsubscribes and disconnects.
+// Orders are never routed to IBKR.
+func subscribeLiveData(ctx context.Context, cfg *config.Config, timeframe time.Duration, logger *slog.Logger) (<-chan types.MarketEvent, func(), error) {
+	ibCfg := ibkr.DefaultConfig()
+	if cfg.Broker.Host != "" {
+		ibCfg.Host = cfg.Broker.Host
+	}
+	if cfg.Broker.Port != 0 {
+		ibCfg.Port = cfg.Broker.Port
+	}
+	if cfg.Broker.ClientID != 0 {
+		ibCfg.ClientID = cfg.Broker.ClientID
+	}
+
+	client := ibkr.NewClient(ibCfg, logger)
+	if err := client.Connect(ctx); err != nil {
+		return nil, nil, fmt.Errorf("connect ibkr: %w", err)
+	}
+
+	// Optionally tee completed bars to disk so the session can be replayed
+	var feed observer.MarketDataFeed = observer.NewLiveFeed(client, timeframe, logger)
+	var recorder *observer.MarketRecorder
+	if cfg.Market.RecordPath != "" {
+		var err error
+		recorder, err = observer.NewMarketRecorder(cfg.Market.RecordPath, logger)
+		if err != nil {
+			_ = client.Disconnect()
+			return nil, nil, err
+		}
+		feed = observer.NewRecordingFeed(feed, recorder, logger)
+		logger.Info("recording live market data", "path", cfg.Market.RecordPath)
+	}
+
+	bars, err := feed.Subscribe(ctx, cfg.Market.InstrumentPrimary)
+	if err != nil {
+		_ = client.Disconnect()
+		if recorder != nil {
+			_ = recorder.Close()
+		}
+		return nil, nil, err
+	}
+
+	logger.Info("paper trading on live market data",
+		"host", ibCfg.Host,
+		"port", ibCfg.Port,
+		"symbol", cfg.Market.InstrumentPrimary,
+		"timeframe", timeframe,
+	)
+
+	closeFn := func() {
+		_ = feed.Close()
+		_ = client.Disconnect()
+		if recorder != nil {
+			if err := recorder.Close(); err != nil {
+				logger.Error("failed to close market recording", "err", err)
+			}
+		}
+	}
+
+	return bars, closeFn, nil
+}
+
 // streamDataToPaperBroker streams CSV data to the paper broker for simulation.
 func streamDataToPaperBroker(ctx context.Context, dataPath, symbol string, broker *paper.Broker, delay time.Duration, logger *slog.Logger) {
 	feed := observer.NewBacktestFeed(dataPath, symbol)
@@ -804,6 +1263,10 @@ func streamDataToPaperBroker(ctx context.Context, dataPath, symbol string, broke
 			return
 		case event, ok := <-eventCh:
 			if !ok {
+				if err := feed.Err(); err != nil {
+					logger.Error("data stream stopped on bad data", "bars_sent", barCount, "err", err)
+					return
+				}
 				logger.Info("data stream completed", "bars_sent", barCount)
 				return
 			}
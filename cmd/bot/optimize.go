@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/backtest"
+	"github.com/tathienbao/quant-bot/internal/observer"
+	"github.com/tathienbao/quant-bot/internal/strategy"
+	"gopkg.in/yaml.v3"
+)
+
+// paramFlags collects repeated --param name=v1,v2,v3 flags.
+type paramFlags backtest.ParamGrid
+
+func (p paramFlags) String() string { return "" }
+
+func (p paramFlags) Set(s string) error {
+	name, list, ok := strings.Cut(s, "=")
+	if !ok || name == "" || list == "" {
+		return fmt.Errorf("want name=v1,v2,... got %q", s)
+	}
+	for _, field := range strings.Split(list, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return fmt.Errorf("parameter %s: %w", name, err)
+		}
+		p[name] = append(p[name], v)
+	}
+	return nil
+}
+
+func cmdOptimize(args []string) {
+	fs := flag.NewFlagSet("optimize", flag.ExitOnError)
+	configPath := configFlag(fs)
+	dataPath := fs.String("data", "", "Path to CSV data file")
+	strategyName := fs.String("strategy", "grid", "Strategy to tune")
+	paramsPath := fs.String("params", "", "YAML file mapping parameter names to value lists")
+	metricName := fs.String("metric", "return", "Ranking metric: return, sharpe or profit_factor")
+	top := fs.Int("top", 10, "Number of ranked results to print (0 = all)")
+	workers := fs.Int("workers", 0, "Concurrent backtests (0 = GOMAXPROCS)")
+	verbose := fs.Bool("verbose", false, "Show per-run logs")
+	grid := paramFlags{}
+	fs.Var(grid, "param", "Parameter values to sweep, e.g. grid_spacing_pct=0.002,0.003 (repeatable)")
+	_ = fs.Parse(args) // ExitOnError handles parse errors
+
+	if *dataPath == "" {
+		fmt.Fprintln(os.Stderr, "--data is required")
+		os.Exit(1)
+	}
+
+	// Per-run risk logs would bury the ranking table
+	logLevel := slog.LevelError
+	if *verbose {
+		logLevel = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: logLevel,
+	})))
+
+	metric, err := backtest.ParseOptimizeMetric(*metricName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid --metric: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *paramsPath != "" {
+		if err := loadParamGrid(*paramsPath, backtest.ParamGrid(grid)); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load params: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if len(grid) == 0 {
+		fmt.Fprintln(os.Stderr, "no parameters to sweep: use --param or --params")
+		os.Exit(1)
+	}
+
+	cfg, err := loadConfig(configPath, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Parameters apply on top of the registered strategy's configuration;
+	// building the first combination catches unknown names before the sweep
+	if _, err := strategy.NewWithParams(*strategyName, cfg, backtest.ParamGrid(grid).Combinations()[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	factory := func(params backtest.ParamSet) (strategy.Strategy, error) {
+		return strategy.NewWithParams(*strategyName, cfg, params)
+	}
+
+	// Load bars once; every run streams the same slice from memory
+	file, err := os.Open(*dataPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open data: %v\n", err)
+		os.Exit(1)
+	}
+	events, err := observer.ParseCSVStrict(file, cfg.Market.InstrumentPrimary)
+	_ = file.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse data: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.Backtest.BackAdjustRolls {
+		events = observer.BackAdjust(events, observer.DetectRolls(events))
+	}
+
+	optCfg := backtest.OptimizeConfig{
+		Backtest:   backtestConfig(cfg),
+		Risk:       cfg.ToRiskConfig(),
+		Execution:  simulatedConfig(cfg),
+		Calculator: cfg.CalculatorConfig(cfg.Market.InstrumentPrimary),
+		Metric:     metric,
+		Workers:    *workers,
+	}
+
+	combos := len(backtest.ParamGrid(grid).Combinations())
+	fmt.Printf("Optimizing %s over %d combinations (%d bars)...\n", *strategyName, combos, len(events))
+
+	runs, err := backtest.Optimize(context.Background(), optCfg, events, backtest.ParamGrid(grid), factory)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "optimize failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	printOptimizeResults(runs, metric, *top)
+}
+
+// loadParamGrid merges a YAML file of name: [values] into grid.
+func loadParamGrid(path string, grid backtest.ParamGrid) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var fileGrid map[string][]float64
+	if err := yaml.Unmarshal(data, &fileGrid); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	for name, values := range fileGrid {
+		grid[name] = append(grid[name], values...)
+	}
+	return nil
+}
+
+func printOptimizeResults(runs []backtest.OptimizeRun, metric backtest.OptimizeMetric, top int) {
+	fmt.Printf("\n=== OPTIMIZATION RESULTS (ranked by %s) ===\n", metric)
+	fmt.Printf("%-5s %9s %9s %7s %8s %7s %8s  %s\n", "Rank", "Return", "MaxDD", "Trades", "WinRate", "PF", "Sharpe", "Params")
+
+	hundred := decimal.NewFromInt(100)
+	failed := 0
+	for i, run := range runs {
+		if run.Err != nil {
+			failed++
+			continue
+		}
+		if top > 0 && i >= top {
+			continue
+		}
+		r := run.Result
+		sharpe := backtest.NewMetrics(r, decimal.Zero).SharpeRatio()
+		fmt.Printf("%-5d %8.2f%% %8.2f%% %7d %7.2f%% %7.2f %8.2f  %s\n",
+			i+1,
+			r.TotalReturn.Mul(hundred).InexactFloat64(),
+			r.MaxDrawdown.Mul(hundred).InexactFloat64(),
+			r.TotalTrades,
+			r.WinRate.Mul(hundred).InexactFloat64(),
+			r.ProfitFactor.InexactFloat64(),
+			sharpe.InexactFloat64(),
+			run.Params,
+		)
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n%d combinations failed:\n", failed)
+		for _, run := range runs {
+			if run.Err != nil {
+				fmt.Printf("  %s: %v\n", run.Params, run.Err)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tathienbao/quant-bot/internal/config"
+	"github.com/tathienbao/quant-bot/internal/observer"
+)
+
+func cmdResample(args []string) {
+	fs := flag.NewFlagSet("resample", flag.ExitOnError)
+	inPath := fs.String("in", "", "CSV file of bars to resample")
+	outPath := fs.String("out", "", "CSV file to write the resampled bars to")
+	from := fs.String("from", "1m", "Timeframe of the input bars: 1m, 5m, 15m or 1h")
+	to := fs.String("to", "5m", "Timeframe to resample to: 5m, 15m or 1h")
+	keepPartial := fs.Bool("keep-partial", false, "Keep an incomplete last bar instead of dropping it")
+	_ = fs.Parse(args) // ExitOnError handles parse errors
+
+	if *inPath == "" || *outPath == "" {
+		fmt.Fprintln(os.Stderr, "--in and --out are required")
+		os.Exit(1)
+	}
+	fromTF, err := config.ParseTimeframe(*from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "--from: %v\n", err)
+		os.Exit(1)
+	}
+	toTF, err := config.ParseTimeframe(*to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "--to: %v\n", err)
+		os.Exit(1)
+	}
+
+	in, err := os.Open(*inPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open input: %v\n", err)
+		os.Exit(1)
+	}
+	// Same parser as backtests, so bad rows fail here rather than later
+	bars, err := observer.ParseCSVStrict(in, "")
+	_ = in.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "read %s: %v\n", *inPath, err)
+		os.Exit(1)
+	}
+
+	result, err := observer.Resample(bars, fromTF, toTF, *keepPartial)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "resample: %v\n", err)
+		os.Exit(1)
+	}
+
+	out, err := os.Create(*outPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create output: %v\n", err)
+		os.Exit(1)
+	}
+	if err := observer.WriteCSV(out, result.Bars); err != nil {
+		_ = out.Close()
+		fmt.Fprintf(os.Stderr, "write %s: %v\n", *outPath, err)
+		os.Exit(1)
+	}
+	if err := out.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "write %s: %v\n", *outPath, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Resampled %d %s bars into %d %s bars: %s\n", len(bars), *from, len(result.Bars), *to, *outPath)
+	if result.Gaps > 0 {
+		fmt.Printf("  %d bars have missing %s bars (gaps or session edges)\n", result.Gaps, *from)
+	}
+	if result.DroppedPartial {
+		fmt.Println("  Dropped the incomplete last bar (use --keep-partial to keep it)")
+	}
+}
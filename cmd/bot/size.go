@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/risk"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func cmdSize(args []string) {
+	fs := flag.NewFlagSet("size", flag.ExitOnError)
+	configPath := configFlag(fs)
+	symbol := fs.String("symbol", "", "Instrument (default: market.instrument_primary)")
+	side := fs.String("side", "long", "Trade direction: long or short")
+	stopTicks := fs.Int("stop-ticks", 0, "Stop distance in ticks (0 = use --atr)")
+	atr := fs.Float64("atr", 0, "ATR in points, used when --stop-ticks is 0")
+	price := fs.Float64("price", 5000, "Entry price")
+	equity := fs.Float64("equity", 0, "Account equity (default: account.starting_equity)")
+	_ = fs.Parse(args) // ExitOnError handles parse errors
+
+	cfg, err := loadConfig(configPath, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *symbol == "" {
+		*symbol = cfg.Market.InstrumentPrimary
+	}
+
+	var direction types.Side
+	switch strings.ToLower(*side) {
+	case "long":
+		direction = types.SideLong
+	case "short":
+		direction = types.SideShort
+	default:
+		fmt.Fprintf(os.Stderr, "invalid --side %q (want long or short)\n", *side)
+		os.Exit(1)
+	}
+
+	startEquity := cfg.StartingEquityDecimal()
+	if *equity > 0 {
+		startEquity = decimal.NewFromFloat(*equity)
+	}
+
+	// Sizing rejections are reported below; the engine's logs would repeat them
+	riskEngine := risk.NewEngine(cfg.ToRiskConfig(), startEquity, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	signal := types.Signal{
+		ID:        "preview",
+		Symbol:    *symbol,
+		Direction: direction,
+		StopTicks: *stopTicks,
+	}
+	event := types.MarketEvent{
+		Symbol:    *symbol,
+		Timestamp: time.Now(),
+		Close:     decimal.NewFromFloat(*price),
+		ATR:       decimal.NewFromFloat(*atr),
+	}
+
+	format := cfg.DisplayFormat(*symbol)
+	fmt.Printf("Sizing preview: %s %s @ %s, equity %s\n", direction, *symbol, format.Price(event.Close), startEquity.StringFixed(2))
+
+	intent, err := riskEngine.PreviewSize(signal, event)
+	if err != nil {
+		fmt.Printf("  Rejected:     %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("  Contracts:    %d\n", intent.Contracts)
+	fmt.Printf("  Stop loss:    %s\n", format.Price(intent.StopLoss))
+	fmt.Printf("  Take profit:  %s\n", format.Price(intent.TakeProfit))
+	fmt.Printf("  Risk amount:  $%s (%s of equity)\n",
+		intent.RiskAmount.StringFixed(2),
+		format.Percent(intent.RiskAmount.Div(startEquity)),
+	)
+}
@@ -93,6 +93,10 @@ type AlertEvent string
 const (
 	// EventKillSwitchActivated is sent when kill switch is triggered.
 	EventKillSwitchActivated AlertEvent = "kill_switch_activated"
+	// EventDailyLossLimit is sent when the daily loss limit is reached.
+	EventDailyLossLimit AlertEvent = "daily_loss_limit"
+	// EventDailyTargetReached is sent when the daily profit target is reached.
+	EventDailyTargetReached AlertEvent = "daily_target_reached"
 	// EventSafeModeEntered is sent when safe mode is entered.
 	EventSafeModeEntered AlertEvent = "safe_mode_entered"
 	// EventSafeModeExited is sent when safe mode is exited.
@@ -122,13 +126,13 @@ func EventSeverity(event AlertEvent) Severity {
 	switch event {
 	case EventKillSwitchActivated:
 		return SeverityCritical
-	case EventSafeModeEntered, EventSafeModeExited:
+	case EventSafeModeEntered, EventSafeModeExited, EventDailyLossLimit:
 		return SeverityHigh
 	case EventOrderRejected, EventConnectionLost:
 		return SeverityWarning
 	case EventOrderFilled, EventPositionOpened, EventPositionClosed:
 		return SeverityInfo
-	case EventDailySummary, EventBotStarted, EventBotStopped, EventConnectionRestored:
+	case EventDailySummary, EventDailyTargetReached, EventBotStarted, EventBotStopped, EventConnectionRestored:
 		return SeverityInfo
 	default:
 		return SeverityInfo
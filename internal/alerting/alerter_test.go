@@ -92,12 +92,14 @@ func TestEventSeverity(t *testing.T) {
 		{EventKillSwitchActivated, SeverityCritical},
 		{EventSafeModeEntered, SeverityHigh},
 		{EventSafeModeExited, SeverityHigh},
+		{EventDailyLossLimit, SeverityHigh},
 		{EventOrderRejected, SeverityWarning},
 		{EventConnectionLost, SeverityWarning},
 		{EventOrderFilled, SeverityInfo},
 		{EventPositionOpened, SeverityInfo},
 		{EventPositionClosed, SeverityInfo},
 		{EventDailySummary, SeverityInfo},
+		{EventDailyTargetReached, SeverityInfo},
 		{EventBotStarted, SeverityInfo},
 		{EventBotStopped, SeverityInfo},
 		{AlertEvent("unknown"), SeverityInfo},
@@ -0,0 +1,276 @@
+// Package audit writes an append-only trail of trading decisions.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/broker"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// RecordType identifies what an audit record describes.
+type RecordType string
+
+// Audit record types, in the order they occur for one trade.
+const (
+	TypeSignal RecordType = "signal"
+	TypeIntent RecordType = "intent"
+	TypeOrder  RecordType = "order"
+	TypeFill   RecordType = "fill"
+)
+
+// Record is one line of the audit log.
+type Record struct {
+	Timestamp time.Time  `json:"timestamp"`
+	Type      RecordType `json:"type"`
+	Payload   any        `json:"payload"`
+}
+
+// Config holds audit log settings.
+type Config struct {
+	Path    string // NDJSON file; rotated files get a timestamp suffix
+	MaxSize int64  // Rotate once the file reaches this many bytes (0 = never)
+}
+
+// Recorder appends newline-delimited JSON records to a file. A nil
+// *Recorder is valid and records nothing.
+type Recorder struct {
+	cfg    Config
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewRecorder opens (or creates) the audit log for appending.
+func NewRecorder(cfg Config, logger *slog.Logger) (*Recorder, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("%w: audit path is required", types.ErrInvalidConfig)
+	}
+
+	r := &Recorder{
+		cfg:    cfg,
+		logger: logger,
+		now:    time.Now,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the log file, creating its directory if needed.
+func (r *Recorder) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.cfg.Path), 0o755); err != nil {
+		return fmt.Errorf("create audit dir: %w", err)
+	}
+
+	file, err := os.OpenFile(r.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat audit log: %w", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// Record appends one record. Records are written in full or not at all; a
+// record that would push the file past MaxSize goes into a new file.
+func (r *Recorder) Record(recordType RecordType, payload any) error {
+	if r == nil {
+		return nil
+	}
+
+	line, err := json.Marshal(Record{
+		Timestamp: r.now().UTC(),
+		Type:      recordType,
+		Payload:   payload,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal audit record: %w", err)
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return fmt.Errorf("audit log closed")
+	}
+
+	if r.cfg.MaxSize > 0 && r.size > 0 && r.size+int64(len(line)) > r.cfg.MaxSize {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := r.file.Write(line)
+	r.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write audit record: %w", err)
+	}
+	return nil
+}
+
+// rotate renames the current file aside and starts a new one. Rotated files
+// are never deleted; retention is left to the operator.
+func (r *Recorder) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("close audit log: %w", err)
+	}
+	r.file = nil
+
+	rotated := r.cfg.Path + "." + r.now().UTC().Format("20060102T150405.000000000")
+	if err := os.Rename(r.cfg.Path, rotated); err != nil {
+		return fmt.Errorf("rotate audit log: %w", err)
+	}
+	r.logger.Info("audit log rotated", "path", rotated)
+
+	return r.open()
+}
+
+// Close closes the audit log.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// SignalRecord is the payload of a signal record.
+type SignalRecord struct {
+	SignalID  string          `json:"signal_id"`
+	BarTime   time.Time       `json:"bar_time"`
+	Symbol    string          `json:"symbol"`
+	Direction string          `json:"direction"`
+	Strength  decimal.Decimal `json:"strength"`
+	StopTicks int             `json:"stop_ticks"`
+	Strategy  string          `json:"strategy"`
+	Reason    string          `json:"reason,omitempty"`
+}
+
+// IntentRecord is the payload of an order intent record.
+type IntentRecord struct {
+	IntentID      string          `json:"intent_id"`
+	ClientOrderID string          `json:"client_order_id"`
+	SignalID      string          `json:"signal_id"`
+	Symbol        string          `json:"symbol"`
+	Side          string          `json:"side"`
+	Contracts     int             `json:"contracts"`
+	EntryPrice    decimal.Decimal `json:"entry_price"`
+	StopLoss      decimal.Decimal `json:"stop_loss"`
+	TakeProfit    decimal.Decimal `json:"take_profit"`
+	RiskAmount    decimal.Decimal `json:"risk_amount"`
+}
+
+// OrderRecord is the payload of an order submission record.
+type OrderRecord struct {
+	OrderID       string `json:"order_id,omitempty"`
+	ClientOrderID string `json:"client_order_id"`
+	Symbol        string `json:"symbol"`
+	Side          string `json:"side"`
+	Contracts     int    `json:"contracts"`
+	Status        string `json:"status"`
+	Error         string `json:"error,omitempty"`
+}
+
+// FillRecord is the payload of a fill record.
+type FillRecord struct {
+	OrderID       string          `json:"order_id"`
+	ClientOrderID string          `json:"client_order_id"`
+	Symbol        string          `json:"symbol"`
+	Side          string          `json:"side"`
+	Contracts     int             `json:"contracts"`
+	Price         decimal.Decimal `json:"price"`
+	Commission    decimal.Decimal `json:"commission"`
+}
+
+// Signal records a strategy signal.
+func (r *Recorder) Signal(signal types.Signal) error {
+	return r.Record(TypeSignal, SignalRecord{
+		SignalID:  signal.ID,
+		BarTime:   signal.Timestamp,
+		Symbol:    signal.Symbol,
+		Direction: signal.Direction.String(),
+		Strength:  signal.Strength,
+		StopTicks: signal.StopTicks,
+		Strategy:  signal.StrategyName,
+		Reason:    signal.Reason,
+	})
+}
+
+// Intent records a sized order intent approved by the risk engine.
+func (r *Recorder) Intent(intent types.OrderIntent) error {
+	return r.Record(TypeIntent, IntentRecord{
+		IntentID:      intent.ID,
+		ClientOrderID: intent.ClientOrderID,
+		SignalID:      intent.SignalID,
+		Symbol:        intent.Symbol,
+		Side:          intent.Side.String(),
+		Contracts:     intent.Contracts,
+		EntryPrice:    intent.EntryPrice,
+		StopLoss:      intent.StopLoss,
+		TakeProfit:    intent.TakeProfit,
+		RiskAmount:    intent.RiskAmount,
+	})
+}
+
+// Order records an order submission. result is nil when the broker
+// rejected the order with submitErr.
+func (r *Recorder) Order(intent types.OrderIntent, result *broker.OrderResult, submitErr error) error {
+	rec := OrderRecord{
+		ClientOrderID: intent.ClientOrderID,
+		Symbol:        intent.Symbol,
+		Side:          intent.Side.String(),
+		Contracts:     intent.Contracts,
+		Status:        string(broker.OrderStatusRejected),
+	}
+	if result != nil {
+		rec.OrderID = result.OrderID
+		rec.Status = string(result.Status)
+	}
+	if submitErr != nil {
+		rec.Error = submitErr.Error()
+	}
+	return r.Record(TypeOrder, rec)
+}
+
+// Fill records a broker fill.
+func (r *Recorder) Fill(order broker.Order) error {
+	return r.Record(TypeFill, FillRecord{
+		OrderID:       order.OrderID,
+		ClientOrderID: order.ClientOrderID,
+		Symbol:        order.Symbol,
+		Side:          order.Side.String(),
+		Contracts:     order.FilledQty,
+		Price:         order.AvgFillPrice,
+		Commission:    order.Commission,
+	})
+}
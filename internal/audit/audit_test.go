@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/broker"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// readRecords decodes every line of an audit log.
+func readRecords(t *testing.T, path string) []map[string]any {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	var records []map[string]any
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var rec map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("unmarshal %q: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestRecorder_NilSafe(t *testing.T) {
+	var r *Recorder
+	if err := r.Signal(types.Signal{ID: "s1"}); err != nil {
+		t.Errorf("nil Signal() error = %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("nil Close() error = %v", err)
+	}
+}
+
+func TestRecorder_WritesNDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.jsonl")
+	r, err := NewRecorder(Config{Path: path}, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+
+	intent := types.OrderIntent{
+		ClientOrderID: "c1",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     2,
+		EntryPrice:    decimal.NewFromInt(5000),
+	}
+	if err := r.Signal(types.Signal{ID: "s1", Symbol: "MES", Direction: types.SideLong}); err != nil {
+		t.Fatalf("Signal() error = %v", err)
+	}
+	if err := r.Intent(intent); err != nil {
+		t.Fatalf("Intent() error = %v", err)
+	}
+	if err := r.Order(intent, nil, errors.New("rejected by broker")); err != nil {
+		t.Fatalf("Order() error = %v", err)
+	}
+	if err := r.Fill(broker.Order{OrderID: "o1", Side: types.SideLong, FilledQty: 2, AvgFillPrice: decimal.NewFromInt(5001)}); err != nil {
+		t.Fatalf("Fill() error = %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	records := readRecords(t, path)
+	if len(records) != 4 {
+		t.Fatalf("records = %d, want 4", len(records))
+	}
+
+	wantTypes := []string{"signal", "intent", "order", "fill"}
+	for i, want := range wantTypes {
+		if records[i]["type"] != want {
+			t.Errorf("record %d type = %v, want %s", i, records[i]["type"], want)
+		}
+	}
+
+	order := records[2]["payload"].(map[string]any)
+	if order["status"] != "rejected" || order["error"] != "rejected by broker" {
+		t.Errorf("order payload = %v, want rejected with error", order)
+	}
+	fill := records[3]["payload"].(map[string]any)
+	if fill["side"] != "LONG" || fill["price"] != "5001" {
+		t.Errorf("fill payload = %v, want LONG at 5001", fill)
+	}
+
+	// Reopening appends rather than truncating
+	r, err = NewRecorder(Config{Path: path}, nil)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	_ = r.Signal(types.Signal{ID: "s2"})
+	_ = r.Close()
+	if got := len(readRecords(t, path)); got != 5 {
+		t.Errorf("records after reopen = %d, want 5", got)
+	}
+}
+
+func TestRecorder_RotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.jsonl")
+	r, err := NewRecorder(Config{Path: path, MaxSize: 200}, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	defer r.Close()
+
+	// Distinct timestamps keep rotated file names unique
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := r.Signal(types.Signal{ID: "signal", Symbol: "MES"}); err != nil {
+			t.Fatalf("Signal() error = %v", err)
+		}
+	}
+
+	rotated, _ := filepath.Glob(path + ".*")
+	if len(rotated) == 0 {
+		t.Fatal("expected rotated files")
+	}
+
+	total := len(readRecords(t, path))
+	for _, file := range rotated {
+		info, _ := os.Stat(file)
+		if info.Size() > 200 {
+			t.Errorf("%s size = %d, want <= 200", file, info.Size())
+		}
+		total += len(readRecords(t, file))
+	}
+	if total != 5 {
+		t.Errorf("records across files = %d, want 5", total)
+	}
+}
@@ -2,6 +2,7 @@ package backtest
 
 import (
 	"math"
+	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/tathienbao/quant-bot/internal/types"
@@ -11,10 +12,35 @@ import (
 type Metrics struct {
 	trades      []types.Trade
 	equityCurve []EquityPoint
-	riskFreeRate decimal.Decimal // Annual risk-free rate (e.g., 0.05 for 5%)
+	riskFreeRate decimal.Decimal // Risk-free return per equity curve period
 }
 
-// NewMetrics creates a new metrics calculator.
+// Annualization assumptions for converting rates to per-bar returns.
+const (
+	TradingDaysPerYear = 252
+	TradingHoursPerDay = 23 // CME Globex session
+)
+
+// PerBarRiskFreeRate converts an annual risk-free rate (e.g. 0.05 for 5%) to
+// the return earned over one bar of the given timeframe. Intraday bars assume
+// a 23 hour trading day; daily and longer bars use calendar days.
+func PerBarRiskFreeRate(annual decimal.Decimal, timeframe time.Duration) decimal.Decimal {
+	if annual.IsZero() || timeframe <= 0 {
+		return decimal.Zero
+	}
+
+	day := TradingHoursPerDay * time.Hour
+	if timeframe >= 24*time.Hour {
+		day = 24 * time.Hour
+	}
+	barsPerDay := decimal.NewFromInt(int64(day)).Div(decimal.NewFromInt(int64(timeframe)))
+	barsPerYear := barsPerDay.Mul(decimal.NewFromInt(TradingDaysPerYear))
+
+	return annual.Div(barsPerYear)
+}
+
+// NewMetrics creates a new metrics calculator. riskFreeRate is the risk-free
+// return per equity curve period (see PerBarRiskFreeRate).
 func NewMetrics(result *Result, riskFreeRate decimal.Decimal) *Metrics {
 	return &Metrics{
 		trades:       result.Trades,
@@ -24,50 +50,43 @@ func NewMetrics(result *Result, riskFreeRate decimal.Decimal) *Metrics {
 }
 
 // SharpeRatio calculates the annualized Sharpe ratio.
-// Sharpe = (mean_return - risk_free) / std_dev_returns * sqrt(252)
+// Sharpe = mean(return - risk_free) / std_dev(return - risk_free) * sqrt(252)
 func (m *Metrics) SharpeRatio() decimal.Decimal {
-	returns := m.calculateReturns()
+	returns := m.excessReturns()
 	if len(returns) < 2 {
 		return decimal.Zero
 	}
 
-	meanReturn := mean(returns)
+	excessReturn := mean(returns)
 	stdDev := standardDeviation(returns)
 
 	if stdDev.IsZero() {
 		return decimal.Zero
 	}
 
-	// Daily risk-free rate (assuming 252 trading days)
-	dailyRf := m.riskFreeRate.Div(decimal.NewFromInt(252))
-	excessReturn := meanReturn.Sub(dailyRf)
-
 	// Annualize: multiply by sqrt(252)
-	sqrt252 := decimal.NewFromFloat(math.Sqrt(252))
+	sqrt252 := decimal.NewFromFloat(math.Sqrt(TradingDaysPerYear))
 	sharpe := excessReturn.Div(stdDev).Mul(sqrt252)
 
 	return sharpe
 }
 
 // SortinoRatio calculates the Sortino ratio (uses downside deviation).
-// Sortino = (mean_return - risk_free) / downside_deviation * sqrt(252)
+// Sortino = mean(return - risk_free) / downside_deviation * sqrt(252)
 func (m *Metrics) SortinoRatio() decimal.Decimal {
-	returns := m.calculateReturns()
+	returns := m.excessReturns()
 	if len(returns) < 2 {
 		return decimal.Zero
 	}
 
-	meanReturn := mean(returns)
+	excessReturn := mean(returns)
 	downsideDev := downsideDeviation(returns, decimal.Zero)
 
 	if downsideDev.IsZero() {
 		return decimal.Zero
 	}
 
-	dailyRf := m.riskFreeRate.Div(decimal.NewFromInt(252))
-	excessReturn := meanReturn.Sub(dailyRf)
-
-	sqrt252 := decimal.NewFromFloat(math.Sqrt(252))
+	sqrt252 := decimal.NewFromFloat(math.Sqrt(TradingDaysPerYear))
 	sortino := excessReturn.Div(downsideDev).Mul(sqrt252)
 
 	return sortino
@@ -143,86 +162,49 @@ func (m *Metrics) AnnualizedReturn() decimal.Decimal {
 
 // WinRate returns the win rate as a ratio.
 func (m *Metrics) WinRate() decimal.Decimal {
-	if len(m.trades) == 0 {
-		return decimal.Zero
-	}
-
-	wins := 0
-	for _, trade := range m.trades {
-		if trade.NetPL.IsPositive() {
-			wins++
-		}
-	}
-
-	return decimal.NewFromInt(int64(wins)).Div(decimal.NewFromInt(int64(len(m.trades))))
+	return types.NewTradeStats(m.trades).WinRate()
 }
 
 // ProfitFactor calculates gross profit / gross loss.
 func (m *Metrics) ProfitFactor() decimal.Decimal {
-	grossProfit := decimal.Zero
-	grossLoss := decimal.Zero
-
-	for _, trade := range m.trades {
-		if trade.NetPL.IsPositive() {
-			grossProfit = grossProfit.Add(trade.NetPL)
-		} else {
-			grossLoss = grossLoss.Add(trade.NetPL.Abs())
-		}
-	}
-
-	if grossLoss.IsZero() {
-		return decimal.Zero
-	}
-
-	return grossProfit.Div(grossLoss)
+	return types.NewTradeStats(m.trades).ProfitFactor()
 }
 
 // AverageWin returns the average winning trade P&L.
 func (m *Metrics) AverageWin() decimal.Decimal {
-	totalWin := decimal.Zero
-	winCount := 0
-
-	for _, trade := range m.trades {
-		if trade.NetPL.IsPositive() {
-			totalWin = totalWin.Add(trade.NetPL)
-			winCount++
-		}
-	}
-
-	if winCount == 0 {
-		return decimal.Zero
-	}
-
-	return totalWin.Div(decimal.NewFromInt(int64(winCount)))
+	return types.NewTradeStats(m.trades).AverageWin()
 }
 
 // AverageLoss returns the average losing trade P&L.
 func (m *Metrics) AverageLoss() decimal.Decimal {
-	totalLoss := decimal.Zero
-	lossCount := 0
+	return types.NewTradeStats(m.trades).AverageLoss()
+}
+
+// AverageR returns the mean R-multiple of trades that had a stop.
+// Trades without a known initial risk (RMultiple zero) are excluded.
+func (m *Metrics) AverageR() decimal.Decimal {
+	total := decimal.Zero
+	count := 0
 
 	for _, trade := range m.trades {
-		if trade.NetPL.IsNegative() {
-			totalLoss = totalLoss.Add(trade.NetPL)
-			lossCount++
+		if trade.RMultiple.IsZero() {
+			continue
 		}
+		total = total.Add(trade.RMultiple)
+		count++
 	}
 
-	if lossCount == 0 {
+	if count == 0 {
 		return decimal.Zero
 	}
 
-	return totalLoss.Div(decimal.NewFromInt(int64(lossCount)))
+	return total.Div(decimal.NewFromInt(int64(count)))
 }
 
 // Expectancy calculates expected value per trade.
 // Expectancy = (WinRate * AvgWin) + ((1 - WinRate) * AvgLoss)
 func (m *Metrics) Expectancy() decimal.Decimal {
-	winRate := m.WinRate()
-	avgWin := m.AverageWin()
-	avgLoss := m.AverageLoss() // Negative
-
-	return winRate.Mul(avgWin).Add(decimal.NewFromInt(1).Sub(winRate).Mul(avgLoss))
+	return types.NewTradeStats(m.trades).Expectancy()
 }
 
 // calculateReturns computes daily returns from equity curve.
@@ -247,6 +229,19 @@ func (m *Metrics) calculateReturns() []decimal.Decimal {
 	return returns
 }
 
+// excessReturns computes per-period returns less the risk-free return.
+func (m *Metrics) excessReturns() []decimal.Decimal {
+	returns := m.calculateReturns()
+	if m.riskFreeRate.IsZero() {
+		return returns
+	}
+
+	for i, ret := range returns {
+		returns[i] = ret.Sub(m.riskFreeRate)
+	}
+	return returns
+}
+
 // Helper: mean of decimal slice.
 func mean(values []decimal.Decimal) decimal.Decimal {
 	if len(values) == 0 {
@@ -71,6 +71,22 @@ func TestMetrics_AverageWinLoss(t *testing.T) {
 	}
 }
 
+func TestMetrics_AverageR(t *testing.T) {
+	trades := []types.Trade{
+		{NetPL: decimal.NewFromInt(200), RMultiple: decimal.NewFromInt(2)},
+		{NetPL: decimal.NewFromInt(-50), RMultiple: decimal.NewFromInt(-1)},
+		{NetPL: decimal.NewFromInt(30)}, // No stop: excluded
+	}
+
+	metrics := NewMetrics(&Result{Trades: trades}, decimal.Zero)
+
+	avgR := metrics.AverageR()
+	expected := decimal.RequireFromString("0.5") // (2 + -1) / 2
+	if !avgR.Equal(expected) {
+		t.Errorf("AverageR = %s, want %s", avgR, expected)
+	}
+}
+
 func TestMetrics_MaxDrawdown(t *testing.T) {
 	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	equityCurve := []EquityPoint{
@@ -141,6 +157,50 @@ func TestMetrics_SharpeRatio(t *testing.T) {
 	}
 }
 
+func TestMetrics_RiskFreeRateLowersSharpe(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	equityValues := []int64{10000, 10100, 10050, 10200, 10150, 10300, 10250, 10400}
+
+	equityCurve := make([]EquityPoint, len(equityValues))
+	for i, val := range equityValues {
+		equityCurve[i] = EquityPoint{
+			Timestamp: baseTime.Add(time.Duration(i) * 24 * time.Hour),
+			Equity:    decimal.NewFromInt(val),
+		}
+	}
+	result := &Result{EquityCurve: equityCurve}
+
+	rf := PerBarRiskFreeRate(decimal.RequireFromString("0.05"), 24*time.Hour)
+	base := NewMetrics(result, decimal.Zero)
+	withRf := NewMetrics(result, rf)
+
+	if !withRf.SharpeRatio().LessThan(base.SharpeRatio()) {
+		t.Errorf("SharpeRatio with rf = %s, want below %s", withRf.SharpeRatio(), base.SharpeRatio())
+	}
+	if !withRf.SortinoRatio().LessThan(base.SortinoRatio()) {
+		t.Errorf("SortinoRatio with rf = %s, want below %s", withRf.SortinoRatio(), base.SortinoRatio())
+	}
+}
+
+func TestPerBarRiskFreeRate(t *testing.T) {
+	annual := decimal.RequireFromString("0.0504")
+
+	// Daily bars: 252 per year
+	if got := PerBarRiskFreeRate(annual, 24*time.Hour); !got.Equal(decimal.RequireFromString("0.0002")) {
+		t.Errorf("daily = %s, want 0.0002", got)
+	}
+
+	// Hourly bars: 23 per day
+	want := annual.Div(decimal.NewFromInt(252 * 23))
+	if got := PerBarRiskFreeRate(annual, time.Hour); !got.Equal(want) {
+		t.Errorf("hourly = %s, want %s", got, want)
+	}
+
+	if got := PerBarRiskFreeRate(annual, 0); !got.IsZero() {
+		t.Errorf("zero timeframe = %s, want 0", got)
+	}
+}
+
 func TestMetrics_NoTrades(t *testing.T) {
 	result := &Result{Trades: []types.Trade{}}
 	metrics := NewMetrics(result, decimal.Zero)
@@ -208,3 +268,25 @@ func TestMetrics_OnlyWinningTrades(t *testing.T) {
 		t.Errorf("AverageLoss should be 0 when no losses, got %s", avgLoss)
 	}
 }
+
+func TestMetrics_MatchesRunningTradeStats(t *testing.T) {
+	trades := []types.Trade{
+		{NetPL: decimal.NewFromInt(300)},
+		{NetPL: decimal.NewFromInt(-100)},
+		{NetPL: decimal.Zero},
+		{NetPL: decimal.NewFromInt(100)},
+		{NetPL: decimal.NewFromInt(-200)},
+	}
+
+	// Fed one trade at a time, as the live engine does
+	var stats types.TradeStats
+	for _, trade := range trades {
+		stats.Add(trade.NetPL)
+	}
+
+	m := NewMetrics(&Result{Trades: trades}, decimal.Zero)
+	if !m.ProfitFactor().Equal(stats.ProfitFactor()) || !m.Expectancy().Equal(stats.Expectancy()) {
+		t.Errorf("Metrics = %s / %s, want the running tally's %s / %s",
+			m.ProfitFactor(), m.Expectancy(), stats.ProfitFactor(), stats.Expectancy())
+	}
+}
@@ -0,0 +1,218 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/execution"
+	"github.com/tathienbao/quant-bot/internal/observer"
+	"github.com/tathienbao/quant-bot/internal/risk"
+	"github.com/tathienbao/quant-bot/internal/strategy"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// OptimizeMetric selects how parameter sweep results are ranked.
+type OptimizeMetric string
+
+// Supported ranking metrics.
+const (
+	MetricReturn       OptimizeMetric = "return"
+	MetricSharpe       OptimizeMetric = "sharpe"
+	MetricProfitFactor OptimizeMetric = "profit_factor"
+)
+
+// ParseOptimizeMetric parses a ranking metric name.
+func ParseOptimizeMetric(s string) (OptimizeMetric, error) {
+	switch m := OptimizeMetric(strings.ToLower(s)); m {
+	case MetricReturn, MetricSharpe, MetricProfitFactor:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown metric %q (want return, sharpe or profit_factor)", s)
+	}
+}
+
+// ParamSet is one combination of strategy parameters.
+type ParamSet map[string]float64
+
+// String formats the parameters as "name=value" pairs sorted by name.
+func (p ParamSet) String() string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + strconv.FormatFloat(p[name], 'f', -1, 64)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParamGrid maps parameter names to the values to sweep.
+type ParamGrid map[string][]float64
+
+// Combinations returns the cartesian product of the grid in a stable order
+// (parameters sorted by name, values in the order given).
+func (g ParamGrid) Combinations() []ParamSet {
+	names := make([]string, 0, len(g))
+	for name, values := range g {
+		if len(values) == 0 {
+			return nil
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	combos := []ParamSet{{}}
+	for _, name := range names {
+		next := make([]ParamSet, 0, len(combos)*len(g[name]))
+		for _, combo := range combos {
+			for _, value := range g[name] {
+				set := make(ParamSet, len(combo)+1)
+				for k, v := range combo {
+					set[k] = v
+				}
+				set[name] = value
+				next = append(next, set)
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// StrategyFactory builds a fresh strategy for one parameter combination.
+type StrategyFactory func(params ParamSet) (strategy.Strategy, error)
+
+// OptimizeConfig holds parameter sweep configuration.
+type OptimizeConfig struct {
+	Backtest   Config
+	Risk       risk.Config
+	Execution  execution.SimulatedConfig
+	Calculator observer.CalculatorConfig
+	Metric     OptimizeMetric
+	Workers    int // Concurrent backtests (0 = GOMAXPROCS)
+}
+
+// OptimizeRun is the outcome of one parameter combination.
+type OptimizeRun struct {
+	Params ParamSet
+	Result *Result
+	Score  decimal.Decimal // Value of the ranking metric
+	Err    error
+}
+
+// Optimize runs a backtest for every combination in the grid and returns the
+// runs ranked best first by cfg.Metric. Failed runs sort last with Err set.
+// Each worker reuses one Runner, resetting it between combinations.
+func Optimize(ctx context.Context, cfg OptimizeConfig, events []types.MarketEvent, grid ParamGrid, factory StrategyFactory) ([]OptimizeRun, error) {
+	combos := grid.Combinations()
+	if len(combos) == 0 {
+		return nil, fmt.Errorf("%w: parameter grid is empty", types.ErrInvalidConfig)
+	}
+	if cfg.Metric == "" {
+		cfg.Metric = MetricReturn
+	}
+
+	// Build every strategy up front so a bad parameter fails before any run
+	strategies := make([]strategy.Strategy, len(combos))
+	for i, params := range combos {
+		strat, err := factory(params)
+		if err != nil {
+			return nil, fmt.Errorf("params %s: %w", params, err)
+		}
+		strategies[i] = strat
+	}
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(combos) {
+		workers = len(combos)
+	}
+
+	runs := make([]OptimizeRun, len(combos))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Tiered volume is per run; don't share it between workers
+			execCfg := cfg.Execution
+			if tiered, ok := execCfg.CommissionModel.(*execution.TieredCommission); ok {
+				execCfg.CommissionModel = tiered.Clone()
+			}
+
+			var runner *Runner
+			for i := range jobs {
+				if runner == nil {
+					runner = NewRunner(
+						cfg.Backtest,
+						observer.NewMemoryFeed(events, ""),
+						observer.NewCalculator(cfg.Calculator),
+						strategies[i],
+						cfg.Risk,
+						execCfg,
+					)
+				} else {
+					runner.SetStrategy(strategies[i])
+					runner.Reset()
+				}
+
+				run := OptimizeRun{Params: combos[i]}
+				run.Result, run.Err = runner.Run(ctx)
+				if run.Err == nil {
+					run.Score = score(run.Result, cfg.Metric)
+				}
+				runs[i] = run
+			}
+		}()
+	}
+
+dispatch:
+	for i := range combos {
+		select {
+		case <-ctx.Done():
+			break dispatch
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		if (runs[i].Err == nil) != (runs[j].Err == nil) {
+			return runs[i].Err == nil
+		}
+		return runs[i].Score.GreaterThan(runs[j].Score)
+	})
+
+	return runs, nil
+}
+
+// score returns the value of the ranking metric for a result.
+func score(result *Result, metric OptimizeMetric) decimal.Decimal {
+	switch metric {
+	case MetricSharpe:
+		return NewMetrics(result, decimal.Zero).SharpeRatio()
+	case MetricProfitFactor:
+		return result.ProfitFactor
+	default:
+		return result.TotalReturn
+	}
+}
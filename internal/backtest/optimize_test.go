@@ -0,0 +1,140 @@
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/execution"
+	"github.com/tathienbao/quant-bot/internal/observer"
+	"github.com/tathienbao/quant-bot/internal/risk"
+	"github.com/tathienbao/quant-bot/internal/strategy"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func TestParamGrid_Combinations(t *testing.T) {
+	grid := ParamGrid{
+		"b": {1, 2},
+		"a": {10, 20, 30},
+	}
+
+	combos := grid.Combinations()
+	if len(combos) != 6 {
+		t.Fatalf("combinations = %d, want 6", len(combos))
+	}
+	if got := combos[0].String(); got != "a=10 b=1" {
+		t.Errorf("first = %q, want %q", got, "a=10 b=1")
+	}
+	if got := combos[5].String(); got != "a=30 b=2" {
+		t.Errorf("last = %q, want %q", got, "a=30 b=2")
+	}
+
+	if combos := (ParamGrid{"a": {1}, "b": nil}).Combinations(); len(combos) != 0 {
+		t.Errorf("empty value list should yield no combinations, got %d", len(combos))
+	}
+}
+
+// directionStrategy enters once in a fixed direction on the second bar.
+type directionStrategy struct {
+	strategy.Base
+	side types.Side
+	bars int
+}
+
+func (s *directionStrategy) OnMarketEvent(ctx context.Context, event types.MarketEvent) []types.Signal {
+	s.bars++
+	if s.bars != 2 {
+		return nil
+	}
+	return []types.Signal{{
+		ID:           fmt.Sprintf("dir-%d", s.bars),
+		Timestamp:    event.Timestamp,
+		Symbol:       event.Symbol,
+		Direction:    s.side,
+		StopTicks:    40,
+		StrategyName: s.Name(),
+	}}
+}
+
+func (s *directionStrategy) Name() string { return "direction" }
+func (s *directionStrategy) Reset()       { s.bars = 0 }
+
+func TestOptimize_RanksByMetric(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	events := make([]types.MarketEvent, 0)
+
+	// Steady uptrend: long wins, short loses
+	for i := 0; i < 20; i++ {
+		price := decimal.NewFromInt(5000 + int64(i))
+		events = append(events, types.MarketEvent{
+			Symbol:    "MES",
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Open:      price,
+			High:      price.Add(decimal.RequireFromString("0.5")),
+			Low:       price.Sub(decimal.RequireFromString("0.5")),
+			Close:     price,
+		})
+	}
+
+	factory := func(params ParamSet) (strategy.Strategy, error) {
+		switch params["side"] {
+		case 1:
+			return &directionStrategy{side: types.SideLong}, nil
+		case -1:
+			return &directionStrategy{side: types.SideShort}, nil
+		default:
+			return nil, errors.New("side must be 1 or -1")
+		}
+	}
+
+	cfg := OptimizeConfig{
+		Backtest:   Config{InitialEquity: decimal.NewFromInt(10000)},
+		Risk:       risk.DefaultConfig(),
+		Execution:  execution.DefaultSimulatedConfig(),
+		Calculator: observer.DefaultCalculatorConfig(),
+		Metric:     MetricReturn,
+		Workers:    1, // Forces Runner reuse across combinations
+	}
+
+	runs, err := Optimize(context.Background(), cfg, events, ParamGrid{"side": {-1, 1}}, factory)
+	if err != nil {
+		t.Fatalf("Optimize() error = %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("runs = %d, want 2", len(runs))
+	}
+	for _, run := range runs {
+		if run.Err != nil {
+			t.Fatalf("run %s error = %v", run.Params, run.Err)
+		}
+	}
+
+	if runs[0].Params["side"] != 1 {
+		t.Errorf("best run = %s, want side=1", runs[0].Params)
+	}
+	if !runs[0].Score.GreaterThan(runs[1].Score) {
+		t.Errorf("scores not ranked: %s then %s", runs[0].Score, runs[1].Score)
+	}
+	if !runs[0].Score.Equal(runs[0].Result.TotalReturn) {
+		t.Errorf("Score = %s, want TotalReturn %s", runs[0].Score, runs[0].Result.TotalReturn)
+	}
+
+	// A bad parameter fails before any backtest runs
+	if _, err := Optimize(context.Background(), cfg, events, ParamGrid{"side": {0}}, factory); err == nil {
+		t.Error("expected error for invalid parameter")
+	}
+}
+
+func TestParseOptimizeMetric(t *testing.T) {
+	for _, name := range []string{"return", "sharpe", "profit_factor", "Sharpe"} {
+		if _, err := ParseOptimizeMetric(name); err != nil {
+			t.Errorf("ParseOptimizeMetric(%q) error = %v", name, err)
+		}
+	}
+	if _, err := ParseOptimizeMetric("calmar"); err == nil {
+		t.Error("expected error for unknown metric")
+	}
+}
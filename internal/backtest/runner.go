@@ -33,22 +33,50 @@ type Config struct {
 	InitialEquity decimal.Decimal
 	StartTime     time.Time
 	EndTime       time.Time
+
+	// WarmupBars are fed to the calculator, and to strategies implementing
+	// strategy.WarmUpper, so indicator state builds up; nothing trades on
+	// them. Warmup bars still count toward indicator periods and appear in
+	// the equity curve.
+	WarmupBars int
+
+	// MinSignalStrength drops entry signals with a lower Strength, as the
+	// live engine does (0 = accept all).
+	MinSignalStrength decimal.Decimal
+
+	// SpreadEstimator gives bars without quotes an approximate bid/ask
+	// from ATR (nil = off); MaxSpreadTicks then drops entry signals on bars
+	// with a wider spread, as the live spread guard does (0 = off).
+	SpreadEstimator *observer.SpreadEstimator
+	MaxSpreadTicks  int
+
+	// SplitSessions tags trades as intraday or overnight and reports P&L
+	// for each, using the trading day that starts SessionStartTime after
+	// midnight in SessionLocation (nil = UTC).
+	SplitSessions    bool
+	SessionLocation  *time.Location
+	SessionStartTime time.Duration
 }
 
 // Result holds backtest results.
 type Result struct {
-	StartEquity   decimal.Decimal
-	EndEquity     decimal.Decimal
-	TotalReturn   decimal.Decimal // As ratio (0.15 = 15%)
-	MaxDrawdown   decimal.Decimal // As ratio
-	TotalTrades   int
-	WinningTrades int
-	LosingTrades  int
-	WinRate       decimal.Decimal // As ratio
-	ProfitFactor  decimal.Decimal // Gross profit / Gross loss
-	SharpeRatio   decimal.Decimal
-	Trades        []types.Trade
-	EquityCurve   []EquityPoint
+	StartEquity     decimal.Decimal
+	EndEquity       decimal.Decimal
+	TotalReturn     decimal.Decimal // As ratio (0.15 = 15%)
+	MaxDrawdown     decimal.Decimal // As ratio
+	TotalTrades     int
+	WinningTrades   int
+	LosingTrades    int
+	WinRate         decimal.Decimal // As ratio
+	ProfitFactor    decimal.Decimal // Gross profit / Gross loss
+	SharpeRatio     decimal.Decimal
+	TotalCommission decimal.Decimal // Commission charged on closed trades
+	TotalRebates    decimal.Decimal // Rebates (negative commission) credited on closed trades, as a positive amount
+	TotalSlippage   decimal.Decimal // Dollar cost of slippage on every fill (backtests only)
+	NetToGross      decimal.Decimal // Net P&L / P&L before commission and slippage (0 if that is not positive)
+	Sessions        *SessionSplit   // Intraday vs overnight breakdown (nil unless Config.SplitSessions)
+	Trades          []types.Trade
+	EquityCurve     []EquityPoint
 }
 
 // EquityPoint represents equity at a point in time.
@@ -64,11 +92,23 @@ type Runner struct {
 	feed       observer.MarketDataFeed
 	calculator *observer.Calculator
 	strategy   strategy.Strategy
+	riskCfg    risk.Config
 	riskEngine *risk.Engine
 	executor   *execution.SimulatedExecutor
 
-	equityCurve []EquityPoint
-	highWater   decimal.Decimal
+	equityCurve  []EquityPoint
+	highWater    decimal.Decimal
+	tradesSeen   int               // Executor trades already applied to equity
+	slippageCost decimal.Decimal   // Dollar slippage across all fills
+	openedBy     map[string]string // Symbol -> strategy of the latest entry, for risk buckets
+
+	// Entry orders queued for a later fill, by client order ID
+	pendingEntries map[string]types.Signal
+
+	// Overnight gap tracking (Config.SplitSessions)
+	lastBar      map[string]types.MarketEvent
+	worstGap     decimal.Decimal
+	worstGapTime time.Time
 
 	// UI callback
 	progressCb ProgressCallback
@@ -95,10 +135,15 @@ func NewRunner(
 		feed:        feed,
 		calculator:  calculator,
 		strategy:    strat,
+		riskCfg:     riskCfg,
 		riskEngine:  riskEngine,
 		executor:    executor,
 		equityCurve: make([]EquityPoint, 0),
 		highWater:   cfg.InitialEquity,
+		openedBy:    make(map[string]string),
+		lastBar:     make(map[string]types.MarketEvent),
+
+		pendingEntries: make(map[string]types.Signal),
 	}
 }
 
@@ -107,7 +152,14 @@ func (r *Runner) SetProgressCallback(cb ProgressCallback) {
 	r.progressCb = cb
 }
 
-// SetTotalBars sets the expected total number of bars (for progress display)
+// SetStrategy replaces the strategy for the next run. Call Reset before
+// running again so the new strategy starts from a clean state.
+func (r *Runner) SetStrategy(strat strategy.Strategy) {
+	r.strategy = strat
+}
+
+// SetTotalBars sets the expected total number of bars (for progress display).
+// Without it the runner asks the feed for an estimate.
 func (r *Runner) SetTotalBars(total int) {
 	r.totalBars = total
 }
@@ -119,6 +171,10 @@ func (r *Runner) Run(ctx context.Context) (*Result, error) {
 
 // RunSymbol executes the backtest for a specific symbol.
 func (r *Runner) RunSymbol(ctx context.Context, symbol string) (*Result, error) {
+	if r.totalBars == 0 {
+		r.totalBars, _ = observer.EstimateBars(r.feed)
+	}
+
 	// Subscribe to market data
 	eventCh, err := r.feed.Subscribe(ctx, symbol)
 	if err != nil {
@@ -134,6 +190,12 @@ func (r *Runner) RunSymbol(ctx context.Context, symbol string) (*Result, error)
 
 		case event, ok := <-eventCh:
 			if !ok {
+				// Feed closed; distinguish end of data from a feed that gave up
+				if reporter, ok := r.feed.(observer.ErrorReporter); ok {
+					if err := reporter.Err(); err != nil {
+						return nil, fmt.Errorf("feed %s stopped after %d bars: %w", r.feed.Name(), r.barCount, err)
+					}
+				}
 				// Feed closed, backtest complete
 				return r.calculateResults(), nil
 			}
@@ -152,22 +214,56 @@ func (r *Runner) RunSymbol(ctx context.Context, symbol string) (*Result, error)
 			if r.calculator != nil {
 				event = r.calculator.OnBar(event)
 			}
+			if r.cfg.SpreadEstimator != nil {
+				event = r.cfg.SpreadEstimator.Apply(event)
+			}
+
+			if r.cfg.SplitSessions {
+				r.trackGap(event)
+			}
 
 			// Update executor with market data (check stops/TPs)
 			fills := r.executor.UpdateMarket(event)
+			if len(fills) > 0 {
+				r.trackPosition(ctx, event.Symbol)
+			}
 			for _, fill := range fills {
+				r.recordSlippage(event.Symbol, fill)
+				if signal, ok := r.pendingEntries[fill.ClientOrderID]; ok {
+					delete(r.pendingEntries, fill.ClientOrderID)
+					r.entryFilled(signal, fill)
+				}
 				currentEquity = r.updateEquity(currentEquity, fill, event.Timestamp)
 			}
 
-			// Generate signals from strategy
-			signals := r.strategy.OnMarketEvent(ctx, event)
+			// Generate signals from strategy; indicators are unreliable
+			// until warmup completes, so warmup bars only build state
+			var signals []types.Signal
+			if r.barCount <= r.cfg.WarmupBars {
+				strategy.WarmUp(r.strategy, event)
+			} else {
+				signals = r.strategy.OnMarketEvent(ctx, event)
+			}
 			var lastSignal string
 
 			// Process each signal through risk engine
 			for _, signal := range signals {
-				orderIntent, err := r.riskEngine.ValidateAndSize(ctx, signal, event)
+				if signal.WeakerThan(r.cfg.MinSignalStrength) {
+					continue
+				}
+
+				var orderIntent *types.OrderIntent
+				var err error
+				if signal.Direction == types.SideFlat {
+					orderIntent, err = r.exitIntent(ctx, signal, event)
+				} else {
+					if r.spreadTooWide(event) {
+						continue
+					}
+					orderIntent, err = r.riskEngine.ValidateAndSize(ctx, signal, event)
+				}
 				if err != nil {
-					// Signal rejected by risk engine (expected behavior)
+					// Signal rejected by risk engine, or nothing to exit (expected behavior)
 					continue
 				}
 
@@ -178,15 +274,24 @@ func (r *Runner) RunSymbol(ctx context.Context, symbol string) (*Result, error)
 				}
 
 				// Update equity if order resulted in a trade close
-				if result.Status == types.OrderStatusFilled {
-					// For opening orders, no immediate PnL
-					// PnL realized on close via UpdateMarket
+				if result.Status == types.OrderStatusPending && signal.Direction != types.SideFlat {
+					r.pendingEntries[result.ClientOrderID] = signal
+				}
+				if result.Status == types.OrderStatusFilled || result.Status == types.OrderStatusPartialFill {
+					r.trackPosition(ctx, orderIntent.Symbol)
+					r.recordSlippage(orderIntent.Symbol, *result)
+					if signal.Direction != types.SideFlat {
+						r.entryFilled(signal, *result)
+					}
+
+					// Opening orders have no immediate PnL; updateEquity is a no-op
+					currentEquity = r.updateEquity(currentEquity, *result, event.Timestamp)
 					lastSignal = signal.Direction.String()
 				}
 			}
 
 			// Record equity point
-			r.recordEquity(event.Timestamp, currentEquity)
+			r.recordEquity(ctx, event.Timestamp, currentEquity)
 
 			// Call progress callback for UI
 			if r.progressCb != nil {
@@ -220,19 +325,75 @@ func (r *Runner) RunSymbol(ctx context.Context, symbol string) (*Result, error)
 	}
 }
 
+// exitIntent turns a flat signal into a reduce-only close of the symbol's
+// open position. Exits aren't sized by the risk engine.
+func (r *Runner) exitIntent(ctx context.Context, signal types.Signal, event types.MarketEvent) (*types.OrderIntent, error) {
+	pos, err := r.executor.GetPosition(ctx, signal.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if pos == nil || pos.Contracts <= 0 {
+		return nil, fmt.Errorf("%w: no %s position to exit", types.ErrNotReducing, signal.Symbol)
+	}
+	intent := risk.ExitIntent(signal, pos.Side, pos.Contracts, event.Close)
+	return &intent, nil
+}
+
+// trackPosition passes the executor's position in symbol to the risk
+// engine after a fill, so its open position, margin and exposure limits
+// count what is held.
+func (r *Runner) trackPosition(ctx context.Context, symbol string) {
+	pos, _ := r.executor.GetPosition(ctx, symbol) // The simulator never fails here
+	if pos == nil {
+		pos = &types.Position{Symbol: symbol}
+	}
+	r.riskEngine.UpdatePosition(pos)
+}
+
+// entryFilled records the strategy behind a filled entry and tells it
+// how many contracts filled.
+func (r *Runner) entryFilled(signal types.Signal, fill types.OrderResult) {
+	r.openedBy[signal.Symbol] = signal.StrategyName
+	if observer, ok := r.strategy.(strategy.EntryObserver); ok {
+		observer.OnEntryFilled(signal, fill.FilledQty)
+	}
+}
+
+// spreadTooWide reports whether the bar's bid/ask spread exceeds
+// MaxSpreadTicks. Bars without quotes pass, as they do live.
+func (r *Runner) spreadTooWide(event types.MarketEvent) bool {
+	if r.cfg.MaxSpreadTicks <= 0 || !event.Bid.IsPositive() || !event.Ask.IsPositive() {
+		return false
+	}
+	spec, ok := types.GetInstrumentSpec(event.Symbol)
+	if !ok || !spec.TickSize.IsPositive() {
+		return false
+	}
+	ticks := event.Ask.Sub(event.Bid).Div(spec.TickSize)
+	return ticks.GreaterThan(decimal.NewFromInt(int64(r.cfg.MaxSpreadTicks)))
+}
+
 // updateEquity updates equity after a fill.
 func (r *Runner) updateEquity(currentEquity decimal.Decimal, fill types.OrderResult, timestamp time.Time) decimal.Decimal {
-	// Find the trade that matches this fill
+	// Apply trades closed since the last update; one bar can produce several
+	// fills (scale-outs followed by a stop), so don't assume one trade per fill
 	trades := r.executor.GetTrades()
-	if len(trades) == 0 {
+	if len(trades) <= r.tradesSeen {
 		return currentEquity
 	}
 
-	lastTrade := trades[len(trades)-1]
-	newEquity := currentEquity.Add(lastTrade.NetPL)
+	newEquity := currentEquity
+	for _, trade := range trades[r.tradesSeen:] {
+		newEquity = newEquity.Add(trade.NetPL)
+
+		// Update risk engine (daily P&L first so the session starts from pre-trade equity)
+		r.riskEngine.RecordRealizedPnL(trade.NetPL, timestamp)
+		r.riskEngine.RecordBucketPnL(r.openedBy[trade.Symbol], trade.NetPL)
+		r.riskEngine.UpdateEquity(newEquity)
 
-	// Update risk engine
-	r.riskEngine.UpdateEquity(newEquity)
+		r.strategy.OnTradeClosed(trade)
+	}
+	r.tradesSeen = len(trades)
 
 	// Update high water mark
 	if newEquity.GreaterThan(r.highWater) {
@@ -242,8 +403,22 @@ func (r *Runner) updateEquity(currentEquity decimal.Decimal, fill types.OrderRes
 	return newEquity
 }
 
-// recordEquity records an equity point.
-func (r *Runner) recordEquity(timestamp time.Time, equity decimal.Decimal) {
+// recordSlippage adds a fill's slippage, converted to dollars, to the
+// running cost total.
+func (r *Runner) recordSlippage(symbol string, fill types.OrderResult) {
+	spec, _ := types.GetInstrumentSpec(symbol)
+	cost := fill.Slippage.Mul(spec.PointValue).Mul(decimal.NewFromInt(int64(fill.FilledQty)))
+	r.slippageCost = r.slippageCost.Add(cost)
+}
+
+// recordEquity records an equity point: realized equity plus open positions
+// marked at the latest close, so drawdown includes intra-trade excursions.
+func (r *Runner) recordEquity(ctx context.Context, timestamp time.Time, realized decimal.Decimal) {
+	equity := realized.Add(r.unrealizedPL(ctx))
+	if equity.GreaterThan(r.highWater) {
+		r.highWater = equity
+	}
+
 	var drawdown decimal.Decimal
 	if r.highWater.IsPositive() {
 		drawdown = r.highWater.Sub(equity).Div(r.highWater)
@@ -256,22 +431,65 @@ func (r *Runner) recordEquity(timestamp time.Time, equity decimal.Decimal) {
 	})
 }
 
+// unrealizedPL sums the P&L of open positions at each symbol's latest close.
+func (r *Runner) unrealizedPL(ctx context.Context) decimal.Decimal {
+	total := decimal.Zero
+	for symbol := range r.executor.GetPositions() {
+		if pos, _ := r.executor.GetPosition(ctx, symbol); pos != nil {
+			total = total.Add(pos.UnrealizedPL)
+		}
+	}
+	return total
+}
+
 // calculateResults computes final backtest results.
 func (r *Runner) calculateResults() *Result {
 	trades := r.executor.GetTrades()
+	var sessions *SessionSplit
+	if r.cfg.SplitSessions {
+		sessions = r.splitSessions(trades)
+	}
+
+	result := Summarize(r.cfg.InitialEquity, trades, r.equityCurve)
+	result.Sessions = sessions
+	result.TotalSlippage = r.slippageCost
+	result.NetToGross = netToGross(result)
+	return result
+}
 
+// netToGross returns the share of pre-cost P&L kept after commission and
+// slippage (less any rebates), or zero when there was no pre-cost profit to keep.
+func netToGross(result *Result) decimal.Decimal {
+	net := result.EndEquity.Sub(result.StartEquity)
+	gross := net.Add(result.TotalCommission).Sub(result.TotalRebates).Add(result.TotalSlippage)
+	if !gross.IsPositive() {
+		return decimal.Zero
+	}
+	return net.Div(gross)
+}
+
+// Summarize computes results from a trade list and equity curve.
+// Used for backtests and for persisted live/paper history so the math matches.
+func Summarize(initialEquity decimal.Decimal, trades []types.Trade, equityCurve []EquityPoint) *Result {
 	var (
-		endEquity     = r.cfg.InitialEquity
+		endEquity     = initialEquity
 		maxDrawdown   = decimal.Zero
 		winningTrades = 0
 		losingTrades  = 0
 		grossProfit   = decimal.Zero
 		grossLoss     = decimal.Zero
+		commission    = decimal.Zero
+		rebates       = decimal.Zero
 	)
 
 	// Calculate end equity from trades
 	for _, trade := range trades {
 		endEquity = endEquity.Add(trade.NetPL)
+		if trade.Commission.IsNegative() {
+			rebates = rebates.Sub(trade.Commission)
+		} else {
+			commission = commission.Add(trade.Commission)
+		}
 		if trade.NetPL.IsPositive() {
 			winningTrades++
 			grossProfit = grossProfit.Add(trade.NetPL)
@@ -282,11 +500,14 @@ func (r *Runner) calculateResults() *Result {
 	}
 
 	// Calculate max drawdown from equity curve
-	hwm := r.cfg.InitialEquity
-	for _, point := range r.equityCurve {
+	hwm := initialEquity
+	for _, point := range equityCurve {
 		if point.Equity.GreaterThan(hwm) {
 			hwm = point.Equity
 		}
+		if !hwm.IsPositive() {
+			continue
+		}
 		dd := hwm.Sub(point.Equity).Div(hwm)
 		if dd.GreaterThan(maxDrawdown) {
 			maxDrawdown = dd
@@ -295,8 +516,8 @@ func (r *Runner) calculateResults() *Result {
 
 	// Calculate metrics
 	totalReturn := decimal.Zero
-	if r.cfg.InitialEquity.IsPositive() {
-		totalReturn = endEquity.Sub(r.cfg.InitialEquity).Div(r.cfg.InitialEquity)
+	if initialEquity.IsPositive() {
+		totalReturn = endEquity.Sub(initialEquity).Div(initialEquity)
 	}
 
 	winRate := decimal.Zero
@@ -310,17 +531,19 @@ func (r *Runner) calculateResults() *Result {
 	}
 
 	return &Result{
-		StartEquity:   r.cfg.InitialEquity,
-		EndEquity:     endEquity,
-		TotalReturn:   totalReturn,
-		MaxDrawdown:   maxDrawdown,
-		TotalTrades:   len(trades),
-		WinningTrades: winningTrades,
-		LosingTrades:  losingTrades,
-		WinRate:       winRate,
-		ProfitFactor:  profitFactor,
-		Trades:        trades,
-		EquityCurve:   r.equityCurve,
+		StartEquity:     initialEquity,
+		EndEquity:       endEquity,
+		TotalReturn:     totalReturn,
+		MaxDrawdown:     maxDrawdown,
+		TotalTrades:     len(trades),
+		WinningTrades:   winningTrades,
+		LosingTrades:    losingTrades,
+		WinRate:         winRate,
+		ProfitFactor:    profitFactor,
+		TotalCommission: commission,
+		TotalRebates:    rebates,
+		Trades:          trades,
+		EquityCurve:     equityCurve,
 	}
 }
 
@@ -330,8 +553,20 @@ func (r *Runner) Reset() {
 	r.strategy.Reset()
 	r.equityCurve = make([]EquityPoint, 0)
 	r.highWater = r.cfg.InitialEquity
+	r.tradesSeen = 0
+	r.slippageCost = decimal.Zero
+	r.openedBy = make(map[string]string)
+	r.pendingEntries = make(map[string]types.Signal)
+	r.lastBar = make(map[string]types.MarketEvent)
+	r.worstGap = decimal.Zero
+	r.worstGapTime = time.Time{}
 	r.barCount = 0
 
-	// Reset risk engine to initial equity
-	r.riskEngine.UpdateEquity(r.cfg.InitialEquity)
+	if r.calculator != nil {
+		r.calculator.Reset()
+	}
+
+	// Fresh risk engine: high water mark, daily P&L and safe mode from the
+	// previous run must not leak into the next one
+	r.riskEngine = risk.NewEngine(r.riskCfg, r.cfg.InitialEquity, nil)
 }
@@ -1,11 +1,20 @@
 package backtest
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/broker/paper"
 	"github.com/tathienbao/quant-bot/internal/execution"
 	"github.com/tathienbao/quant-bot/internal/observer"
 	"github.com/tathienbao/quant-bot/internal/risk"
@@ -73,6 +82,8 @@ func TestRunner_BasicBacktest(t *testing.T) {
 		MaxTotalExposurePct:     decimal.RequireFromString("1.00"),
 		StopLossATRMultiple:     decimal.RequireFromString("2.0"),
 		TakeProfitATRMultiple:   decimal.RequireFromString("3.0"),
+		AllowLong:               true,
+		AllowShort:              true,
 	}
 
 	execCfg := execution.SimulatedConfig{
@@ -158,6 +169,43 @@ func TestRunner_NoTrades(t *testing.T) {
 	}
 }
 
+func TestRunner_TotalBarsFromFeed(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	events := make([]types.MarketEvent, 0)
+	for i := 0; i < 5; i++ {
+		events = append(events, types.MarketEvent{
+			Symbol:    "MES",
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Open:      decimal.NewFromInt(100),
+			High:      decimal.NewFromInt(101),
+			Low:       decimal.NewFromInt(99),
+			Close:     decimal.NewFromInt(100),
+		})
+	}
+
+	runner := NewRunner(
+		Config{InitialEquity: decimal.NewFromInt(10000)},
+		observer.NewMemoryFeed(events, "MES"),
+		observer.NewCalculator(observer.DefaultCalculatorConfig()),
+		strategy.NewBreakout(strategy.DefaultBreakoutConfig()),
+		risk.DefaultConfig(),
+		execution.DefaultSimulatedConfig(),
+	)
+
+	var totals []int
+	runner.SetProgressCallback(func(update ProgressUpdate) {
+		totals = append(totals, update.TotalBars)
+	})
+	if _, err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// No SetTotalBars: the total comes from the feed's estimate
+	if len(totals) == 0 || totals[0] != len(events) {
+		t.Errorf("progress TotalBars = %v, want %d", totals, len(events))
+	}
+}
+
 func TestRunner_Reset(t *testing.T) {
 	events := []types.MarketEvent{
 		{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(100)},
@@ -264,3 +312,736 @@ func TestRunner_TimeFilters(t *testing.T) {
 		}
 	}
 }
+
+// everyBarStrategy flips between long and short on every bar and counts the
+// bars it sees, and the warm-up bars separately.
+type everyBarStrategy struct {
+	bars   int
+	warmed int
+	closed []types.Trade
+}
+
+func (s *everyBarStrategy) OnMarketEvent(ctx context.Context, event types.MarketEvent) []types.Signal {
+	s.bars++
+	direction := types.SideLong
+	if s.bars%2 == 0 {
+		direction = types.SideShort
+	}
+	return []types.Signal{{
+		ID:           fmt.Sprintf("bar-%d", s.bars),
+		Timestamp:    event.Timestamp,
+		Symbol:       event.Symbol,
+		Direction:    direction,
+		StopTicks:    10,
+		StrategyName: s.Name(),
+	}}
+}
+
+func (s *everyBarStrategy) WarmUp(event types.MarketEvent)  { s.warmed++ }
+func (s *everyBarStrategy) OnTradeClosed(trade types.Trade) { s.closed = append(s.closed, trade) }
+func (s *everyBarStrategy) Name() string                    { return "every_bar" }
+func (s *everyBarStrategy) Reset()                          { s.bars = 0 }
+
+func TestRunner_WarmupBars(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	events := make([]types.MarketEvent, 0)
+
+	for i := 0; i < 15; i++ {
+		events = append(events, types.MarketEvent{
+			Symbol:    "MES",
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Open:      decimal.NewFromInt(5000),
+			High:      decimal.NewFromInt(5001),
+			Low:       decimal.NewFromInt(4999),
+			Close:     decimal.NewFromInt(5000),
+		})
+	}
+
+	strat := &everyBarStrategy{}
+	runner := NewRunner(
+		Config{InitialEquity: decimal.NewFromInt(10000), WarmupBars: 10},
+		observer.NewMemoryFeed(events, "MES"),
+		observer.NewCalculator(observer.DefaultCalculatorConfig()),
+		strat,
+		risk.DefaultConfig(),
+		execution.DefaultSimulatedConfig(),
+	)
+
+	result, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// Warmup bars only build strategy state
+	if strat.warmed != 10 || strat.bars != 5 {
+		t.Errorf("strategy warmed up on %d bars and traded %d, want 10 and 5", strat.warmed, strat.bars)
+	}
+	if len(result.EquityCurve) != 15 {
+		t.Errorf("equity points = %d, want 15", len(result.EquityCurve))
+	}
+
+	// First entry happens on the bar after warmup
+	if len(result.Trades) == 0 {
+		t.Fatal("expected trades after warmup")
+	}
+	if want := baseTime.Add(10 * time.Minute); !result.Trades[0].EntryTime.Equal(want) {
+		t.Errorf("first EntryTime = %v, want %v", result.Trades[0].EntryTime, want)
+	}
+}
+
+func TestRunner_NotifiesStrategyOfClosedTrades(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	events := make([]types.MarketEvent, 0)
+
+	for i := 0; i < 10; i++ {
+		events = append(events, types.MarketEvent{
+			Symbol:    "MES",
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Open:      decimal.NewFromInt(5000),
+			High:      decimal.NewFromInt(5001),
+			Low:       decimal.NewFromInt(4999),
+			Close:     decimal.NewFromInt(5000),
+		})
+	}
+
+	strat := &everyBarStrategy{}
+	runner := NewRunner(
+		Config{InitialEquity: decimal.NewFromInt(10000)},
+		observer.NewMemoryFeed(events, "MES"),
+		observer.NewCalculator(observer.DefaultCalculatorConfig()),
+		strat,
+		risk.DefaultConfig(),
+		execution.DefaultSimulatedConfig(),
+	)
+
+	result, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(result.Trades) == 0 {
+		t.Fatal("expected trades")
+	}
+	if len(strat.closed) != len(result.Trades) {
+		t.Fatalf("strategy saw %d closed trades, want %d", len(strat.closed), len(result.Trades))
+	}
+	for i, trade := range result.Trades {
+		if strat.closed[i].ID != trade.ID {
+			t.Errorf("closed[%d] = %s, want %s", i, strat.closed[i].ID, trade.ID)
+		}
+	}
+}
+
+func TestRunner_FeedError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "truncated.csv")
+	data := "timestamp,open,high,low,close,volume\n" +
+		"2024-01-01 09:30:00,5000,5010,4990,5005,1000\n" +
+		"2024-01-01 09:35:00,5005,5015,5000,5010,1200\n" +
+		"2024-01-01 09:40:00,5010,5020,oops,5015,900\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+
+	runner := NewRunner(
+		Config{InitialEquity: decimal.NewFromInt(10000)},
+		observer.NewBacktestFeed(path, "MES"),
+		observer.NewCalculator(observer.DefaultCalculatorConfig()),
+		&everyBarStrategy{},
+		risk.DefaultConfig(),
+		execution.DefaultSimulatedConfig(),
+	)
+
+	_, err := runner.Run(context.Background())
+	if !errors.Is(err, types.ErrInvalidData) {
+		t.Fatalf("Run() error = %v, want ErrInvalidData", err)
+	}
+	if !strings.Contains(err.Error(), "after 2 bars") || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("Run() error = %v, want bar count and line number", err)
+	}
+}
+
+func TestRunner_DeterministicTrades(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	events := make([]types.MarketEvent, 0)
+	for i := 0; i < 40; i++ {
+		price := decimal.NewFromInt(5000 + int64(i%7)*3 - int64(i%3)*2)
+		events = append(events, types.MarketEvent{
+			Symbol:    "MES",
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Open:      price,
+			High:      price.Add(decimal.NewFromInt(4)),
+			Low:       price.Sub(decimal.NewFromInt(4)),
+			Close:     price.Add(decimal.NewFromInt(1)),
+		})
+	}
+
+	run := func() []byte {
+		runner := NewRunner(
+			Config{InitialEquity: decimal.NewFromInt(10000)},
+			observer.NewMemoryFeed(events, "MES"),
+			observer.NewCalculator(observer.DefaultCalculatorConfig()),
+			&everyBarStrategy{},
+			risk.DefaultConfig(),
+			execution.DefaultSimulatedConfig(),
+		)
+		result, err := runner.Run(context.Background())
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if len(result.Trades) == 0 {
+			t.Fatal("expected trades")
+		}
+		data, err := json.Marshal(result.Trades)
+		if err != nil {
+			t.Fatalf("marshal trades: %v", err)
+		}
+		return data
+	}
+
+	first, second := run(), run()
+	if !bytes.Equal(first, second) {
+		t.Errorf("identical backtests produced different trades:\n%s\n%s", first, second)
+	}
+}
+
+func TestRunner_CostBreakdown(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	events := make([]types.MarketEvent, 0)
+	for i := 0; i < 40; i++ {
+		price := decimal.NewFromInt(5000 + int64(i%7)*3 - int64(i%3)*2)
+		events = append(events, types.MarketEvent{
+			Symbol:    "MES",
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Open:      price,
+			High:      price.Add(decimal.NewFromInt(4)),
+			Low:       price.Sub(decimal.NewFromInt(4)),
+			Close:     price.Add(decimal.NewFromInt(1)),
+		})
+	}
+
+	riskCfg := risk.DefaultConfig()
+	riskCfg.MaxContractsPerOrder = 1
+	runner := NewRunner(
+		Config{InitialEquity: decimal.NewFromInt(10000)},
+		observer.NewMemoryFeed(events, "MES"),
+		observer.NewCalculator(observer.DefaultCalculatorConfig()),
+		&everyBarStrategy{},
+		riskCfg,
+		execution.SimulatedConfig{SlippageTicks: 1, CommissionPerSide: decimal.RequireFromString("0.62")},
+	)
+	result, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.TotalTrades == 0 {
+		t.Fatal("expected trades")
+	}
+
+	// Commission is charged once per closed trade
+	wantCommission := decimal.RequireFromString("0.62").Mul(decimal.NewFromInt(int64(result.TotalTrades)))
+	if !result.TotalCommission.Equal(wantCommission) {
+		t.Errorf("TotalCommission = %s, want %s", result.TotalCommission, wantCommission)
+	}
+
+	// One tick ($1.25) per single-contract fill: entry and exit of every
+	// trade, plus the entry of a position still open at the end
+	fills := result.TotalSlippage.Div(decimal.RequireFromString("1.25"))
+	if !fills.IsInteger() || fills.IntPart() < int64(2*result.TotalTrades) || fills.IntPart() > int64(2*result.TotalTrades+1) {
+		t.Errorf("TotalSlippage = %s, want $1.25 per fill for %d trades", result.TotalSlippage, result.TotalTrades)
+	}
+
+	net := result.EndEquity.Sub(result.StartEquity)
+	if gross := net.Add(result.TotalCommission).Add(result.TotalSlippage); gross.IsPositive() {
+		if want := net.Div(gross); !result.NetToGross.Equal(want) {
+			t.Errorf("NetToGross = %s, want %s", result.NetToGross, want)
+		}
+	} else if !result.NetToGross.IsZero() {
+		t.Errorf("NetToGross = %s, want 0 without pre-cost profit", result.NetToGross)
+	}
+}
+
+func TestRunner_EquityIncludesUnrealizedPL(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	bar := func(i int, close int64) types.MarketEvent {
+		price := decimal.NewFromInt(close)
+		return types.MarketEvent{
+			Symbol:    "MES",
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Open:      price,
+			High:      price.Add(decimal.NewFromInt(1)),
+			Low:       price.Sub(decimal.NewFromInt(1)),
+			Close:     price,
+		}
+	}
+	// Long entry on bar 2, then price falls without reaching the 10 pt stop
+	events := []types.MarketEvent{bar(0, 5000), bar(1, 5000), bar(2, 4995), bar(3, 5002)}
+
+	runner := NewRunner(
+		Config{InitialEquity: decimal.NewFromInt(10000)},
+		observer.NewMemoryFeed(events, "MES"),
+		observer.NewCalculator(observer.DefaultCalculatorConfig()),
+		&directionStrategy{side: types.SideLong},
+		risk.DefaultConfig(),
+		execution.SimulatedConfig{SlippageTicks: 0, CommissionPerSide: decimal.Zero},
+	)
+
+	result, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.TotalTrades != 0 {
+		t.Fatalf("TotalTrades = %d, want 0 (position still open)", result.TotalTrades)
+	}
+
+	// $100 risk / (40 ticks * $1.25) = 2 contracts; -5 pts * $5 * 2 = -$50
+	if want := decimal.NewFromInt(9950); !result.EquityCurve[2].Equity.Equal(want) {
+		t.Errorf("equity while underwater = %s, want %s", result.EquityCurve[2].Equity, want)
+	}
+	if want := decimal.RequireFromString("0.005"); !result.MaxDrawdown.Equal(want) {
+		t.Errorf("MaxDrawdown = %s, want %s", result.MaxDrawdown, want)
+	}
+	if want := decimal.NewFromInt(10020); !result.EquityCurve[3].Equity.Equal(want) {
+		t.Errorf("equity after recovery = %s, want %s", result.EquityCurve[3].Equity, want)
+	}
+}
+
+func TestSummarize_PersistedHistory(t *testing.T) {
+	baseTime := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	trades := []types.Trade{
+		{NetPL: decimal.NewFromInt(300), ExitTime: baseTime},
+		{NetPL: decimal.NewFromInt(-100), ExitTime: baseTime.Add(time.Hour)},
+		{NetPL: decimal.NewFromInt(-100), ExitTime: baseTime.Add(2 * time.Hour)},
+	}
+	curve := []EquityPoint{
+		{Timestamp: baseTime, Equity: decimal.NewFromInt(10300)},
+		{Timestamp: baseTime.Add(time.Hour), Equity: decimal.NewFromInt(10200)},
+		{Timestamp: baseTime.Add(2 * time.Hour), Equity: decimal.NewFromInt(10094)},
+	}
+
+	result := Summarize(decimal.NewFromInt(10000), trades, curve)
+
+	if !result.EndEquity.Equal(decimal.NewFromInt(10100)) {
+		t.Errorf("EndEquity = %s, want 10100", result.EndEquity)
+	}
+	if result.WinningTrades != 1 || result.LosingTrades != 2 {
+		t.Errorf("wins/losses = %d/%d, want 1/2", result.WinningTrades, result.LosingTrades)
+	}
+	if !result.ProfitFactor.Equal(decimal.NewFromFloat(1.5)) {
+		t.Errorf("ProfitFactor = %s, want 1.5", result.ProfitFactor)
+	}
+	// Peak 10300 -> trough 10094 = 2%
+	if !result.MaxDrawdown.Equal(decimal.NewFromFloat(0.02)) {
+		t.Errorf("MaxDrawdown = %s, want 0.02", result.MaxDrawdown)
+	}
+
+	metrics := NewMetrics(result, decimal.Zero)
+	if !metrics.Expectancy().Round(2).Equal(decimal.NewFromFloat(33.33)) {
+		t.Errorf("Expectancy = %s, want 33.33", metrics.Expectancy())
+	}
+}
+
+func TestSummarize_Rebates(t *testing.T) {
+	trades := []types.Trade{
+		{GrossPL: decimal.NewFromInt(50), Commission: decimal.RequireFromString("1.24"), NetPL: decimal.RequireFromString("48.76")},
+		// Maker rebate on a resting limit fill
+		{GrossPL: decimal.NewFromInt(20), Commission: decimal.RequireFromString("-0.40"), NetPL: decimal.RequireFromString("20.40")},
+	}
+
+	result := Summarize(decimal.NewFromInt(10000), trades, nil)
+
+	if !result.TotalCommission.Equal(decimal.RequireFromString("1.24")) {
+		t.Errorf("TotalCommission = %s, want 1.24", result.TotalCommission)
+	}
+	if !result.TotalRebates.Equal(decimal.RequireFromString("0.40")) {
+		t.Errorf("TotalRebates = %s, want 0.40", result.TotalRebates)
+	}
+
+	// Gross before costs is the trades' 70: net 69.16 + 1.24 paid - 0.40 earned
+	if want := decimal.RequireFromString("69.16").Div(decimal.NewFromInt(70)); !netToGross(result).Equal(want) {
+		t.Errorf("netToGross = %s, want %s", netToGross(result), want)
+	}
+}
+
+// TestRunner_MatchesPaperReplay replays the same CSV through the backtest
+// runner and the paper broker and checks both realize the same P&L.
+func TestRunner_MatchesPaperReplay(t *testing.T) {
+	var csv strings.Builder
+	csv.WriteString("timestamp,open,high,low,close,volume\n")
+	baseTime := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	prev := decimal.NewFromInt(5000)
+	for i := 0; i < 200; i++ {
+		closePrice := decimal.NewFromInt(5000).Add(decimal.NewFromFloat(float64((i*7)%13-6) * 0.75))
+		high := decimal.Max(prev, closePrice).Add(decimal.NewFromFloat(float64(i%4+1) * 0.5))
+		low := decimal.Min(prev, closePrice).Sub(decimal.NewFromFloat(float64((i+2)%4+1) * 0.5))
+		fmt.Fprintf(&csv, "%s,%s,%s,%s,%s,100\n",
+			baseTime.Add(time.Duration(i)*time.Minute).Format(time.RFC3339), prev, high, low, closePrice)
+		prev = closePrice
+	}
+
+	events, err := observer.ParseCSVStrict(strings.NewReader(csv.String()), "MES")
+	if err != nil {
+		t.Fatalf("ParseCSVStrict() error = %v", err)
+	}
+
+	initial := decimal.NewFromInt(10000)
+	riskCfg := risk.DefaultConfig()
+	riskCfg.MaxContractsPerOrder = 1
+	slippageTicks := 1
+	commission := decimal.RequireFromString("0.62")
+
+	runner := NewRunner(
+		Config{InitialEquity: initial},
+		observer.NewMemoryFeed(events, "MES"),
+		observer.NewCalculator(observer.DefaultCalculatorConfig()),
+		&everyBarStrategy{},
+		riskCfg,
+		execution.SimulatedConfig{SlippageTicks: slippageTicks, CommissionPerSide: commission},
+	)
+	result, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.TotalTrades < 10 {
+		t.Fatalf("TotalTrades = %d, want enough trades to compare", result.TotalTrades)
+	}
+
+	// Paper replay mirrors the runner loop with the paper broker filling
+	ctx := context.Background()
+	quiet := slog.New(slog.DiscardHandler)
+	b := paper.NewBroker(paper.Config{
+		InitialEquity:     initial,
+		SlippageTicks:     slippageTicks,
+		CommissionPerSide: commission,
+		SyncFills:         true,
+	}, quiet)
+	if err := b.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	riskEngine := risk.NewEngine(riskCfg, initial, quiet)
+	calculator := observer.NewCalculator(observer.DefaultCalculatorConfig())
+	strat := &everyBarStrategy{}
+
+	realized := decimal.Zero
+	syncRisk := func(at time.Time) {
+		summary, _ := b.GetAccountSummary(ctx)
+		if delta := summary.RealizedPnL.Sub(realized); !delta.IsZero() {
+			realized = summary.RealizedPnL
+			riskEngine.RecordRealizedPnL(delta, at)
+			riskEngine.UpdateEquity(initial.Add(realized))
+		}
+	}
+	for _, event := range events {
+		event = calculator.OnBar(event)
+		b.SimulateMarketData(event)
+		syncRisk(event.Timestamp)
+
+		for _, signal := range strat.OnMarketEvent(ctx, event) {
+			intent, err := riskEngine.ValidateAndSize(ctx, signal, event)
+			if err != nil {
+				continue
+			}
+			if _, err := b.PlaceOrder(ctx, *intent); err != nil {
+				t.Fatalf("PlaceOrder() error = %v", err)
+			}
+			syncRisk(event.Timestamp)
+		}
+	}
+
+	// Both leave the last position open; only closed trades are compared
+	backtestPL := result.EndEquity.Sub(initial)
+	if diff := backtestPL.Sub(realized).Abs(); diff.GreaterThan(decimal.RequireFromString("0.01")) {
+		t.Errorf("paper realized P&L = %s, backtest = %s (diff %s)", realized, backtestPL, diff)
+	}
+}
+
+func TestRunner_EstimatedSpreadGuard(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	events := make([]types.MarketEvent, 0)
+	for i := 0; i < 5; i++ {
+		events = append(events, types.MarketEvent{
+			Symbol:    "MES",
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Open:      decimal.NewFromInt(5000),
+			High:      decimal.NewFromInt(5001),
+			Low:       decimal.NewFromInt(4999),
+			Close:     decimal.NewFromInt(5000),
+		})
+	}
+
+	// A 3 tick estimated spread against guards of 2 and 3 ticks
+	tests := []struct {
+		maxSpread int
+		wantOpen  bool
+	}{
+		{2, false},
+		{3, true},
+		{0, true},
+	}
+
+	for _, tt := range tests {
+		runner := NewRunner(
+			Config{
+				InitialEquity:   decimal.NewFromInt(10000),
+				SpreadEstimator: observer.NewSpreadEstimator(decimal.Zero, 3),
+				MaxSpreadTicks:  tt.maxSpread,
+			},
+			observer.NewMemoryFeed(events, "MES"),
+			observer.NewCalculator(observer.DefaultCalculatorConfig()),
+			&directionStrategy{side: types.SideLong},
+			risk.DefaultConfig(),
+			execution.DefaultSimulatedConfig(),
+		)
+		if _, err := runner.Run(context.Background()); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+
+		_, open := runner.executor.GetPositions()["MES"]
+		if open != tt.wantOpen {
+			t.Errorf("max_spread_ticks %d: position open = %v, want %v", tt.maxSpread, open, tt.wantOpen)
+		}
+	}
+}
+
+func TestRunner_GridMaxLevelAgeClosesPosition(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	var events []types.MarketEvent
+	bar := func(o, h, l, c int64) {
+		events = append(events, types.MarketEvent{
+			Symbol:    "MES",
+			Timestamp: baseTime.Add(time.Duration(len(events)) * time.Minute),
+			Open:      decimal.NewFromInt(o),
+			High:      decimal.NewFromInt(h),
+			Low:       decimal.NewFromInt(l),
+			Close:     decimal.NewFromInt(c),
+		})
+	}
+
+	// A 100-110 range, a drop into one grid level, then a quiet market that
+	// hits neither stop nor target
+	for i := 0; i < 9; i++ {
+		bar(105, 110, 100, 105)
+	}
+	bar(103, 103, 101, 102)
+	for i := 0; i < 8; i++ {
+		bar(102, 102, 102, 102)
+	}
+
+	gridCfg := strategy.DefaultGridConfig()
+	gridCfg.LookbackBars = 10
+	gridCfg.GridSpacingPct = decimal.RequireFromString("0.02")
+	gridCfg.MinMovePoints = decimal.NewFromInt(1)
+	gridCfg.MaxLevelAgeBars = 3
+	grid := strategy.NewGrid(gridCfg)
+
+	riskCfg := risk.DefaultConfig()
+	riskCfg.SizingMode = risk.SizingFixedContracts
+	riskCfg.FixedContracts = 2
+
+	runner := NewRunner(
+		Config{InitialEquity: decimal.NewFromInt(10000)},
+		observer.NewMemoryFeed(events, "MES"),
+		observer.NewCalculator(observer.DefaultCalculatorConfig()),
+		grid,
+		riskCfg,
+		execution.DefaultSimulatedConfig(),
+	)
+
+	result, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if len(result.Trades) != 1 {
+		t.Fatalf("trades = %d, want 1", len(result.Trades))
+	}
+	trade := result.Trades[0]
+	if trade.Side != types.SideLong || trade.Contracts != 2 {
+		t.Errorf("trade = %s %d, want LONG 2", trade.Side, trade.Contracts)
+	}
+	if trade.ExitReason != execution.ExitSignal {
+		t.Errorf("ExitReason = %s, want %s", trade.ExitReason, execution.ExitSignal)
+	}
+	if positions := runner.executor.GetPositions(); len(positions) != 0 {
+		t.Errorf("open positions = %v, want none", positions)
+	}
+	if levels := grid.OpenLevels(); len(levels) != 0 {
+		t.Errorf("grid levels = %v, want none", levels)
+	}
+}
+
+// firstBarStrategy enters long on the first bar of each symbol.
+type firstBarStrategy struct {
+	entered map[string]bool
+}
+
+func (s *firstBarStrategy) OnMarketEvent(ctx context.Context, event types.MarketEvent) []types.Signal {
+	if s.entered == nil {
+		s.entered = make(map[string]bool)
+	}
+	if s.entered[event.Symbol] {
+		return nil
+	}
+	s.entered[event.Symbol] = true
+	return []types.Signal{{
+		ID:           "entry-" + event.Symbol,
+		Timestamp:    event.Timestamp,
+		Symbol:       event.Symbol,
+		Direction:    types.SideLong,
+		StopTicks:    10,
+		StrategyName: s.Name(),
+	}}
+}
+
+func (s *firstBarStrategy) OnTradeClosed(trade types.Trade) {}
+func (s *firstBarStrategy) Name() string                    { return "first_bar" }
+func (s *firstBarStrategy) Reset()                          { s.entered = nil }
+
+// runFirstBarEntries runs firstBarStrategy over flat bars of symbols, taking
+// turns, and returns the symbols left with an open position.
+func runFirstBarEntries(t *testing.T, equity int64, riskCfg risk.Config, symbols ...string) []string {
+	t.Helper()
+
+	prices := map[string]int64{"MES": 5000, "ES": 5000, "MGC": 2000}
+	baseTime := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	var events []types.MarketEvent
+	for i := 0; i < 3; i++ {
+		for _, symbol := range symbols {
+			price := decimal.NewFromInt(prices[symbol])
+			events = append(events, types.MarketEvent{
+				Symbol:    symbol,
+				Timestamp: baseTime.Add(time.Duration(len(events)) * time.Minute),
+				Open:      price,
+				High:      price,
+				Low:       price,
+				Close:     price,
+			})
+		}
+	}
+
+	runner := NewRunner(
+		Config{InitialEquity: decimal.NewFromInt(equity)},
+		observer.NewMemoryFeed(events, ""),
+		nil,
+		&firstBarStrategy{},
+		riskCfg,
+		execution.DefaultSimulatedConfig(),
+	)
+	if _, err := runner.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	var open []string
+	for _, symbol := range symbols {
+		if pos, _ := runner.executor.GetPosition(context.Background(), symbol); pos != nil {
+			open = append(open, symbol)
+		}
+	}
+	return open
+}
+
+// fixedOneLot returns a risk config trading one contract per entry with
+// room for every symbol's margin.
+func fixedOneLot() risk.Config {
+	cfg := risk.DefaultConfig()
+	cfg.SizingMode = risk.SizingFixedContracts
+	cfg.FixedContracts = 1
+	cfg.MaxExposurePerSymbolPct = decimal.NewFromInt(1)
+	cfg.MaxTotalExposurePct = decimal.NewFromInt(10)
+	return cfg
+}
+
+func TestRunner_MaxOpenPositions(t *testing.T) {
+	riskCfg := fixedOneLot()
+	riskCfg.MaxOpenPositions = 1
+
+	open := runFirstBarEntries(t, 100000, riskCfg, "MES", "MGC")
+	if len(open) != 1 || open[0] != "MES" {
+		t.Errorf("open positions = %v, want [MES]", open)
+	}
+
+	riskCfg.MaxOpenPositions = 2
+	if open := runFirstBarEntries(t, 100000, riskCfg, "MES", "MGC"); len(open) != 2 {
+		t.Errorf("open positions = %v, want MES and MGC", open)
+	}
+}
+
+func TestRunner_FreeMargin(t *testing.T) {
+	riskCfg := fixedOneLot()
+	riskCfg.MarginPolicy = risk.MarginReject
+
+	// MES ties up 50 of margin, leaving too little for MGC's 550
+	if open := runFirstBarEntries(t, 580, riskCfg, "MES", "MGC"); len(open) != 1 || open[0] != "MES" {
+		t.Errorf("open positions = %v, want [MES]", open)
+	}
+	if open := runFirstBarEntries(t, 700, riskCfg, "MES", "MGC"); len(open) != 2 {
+		t.Errorf("open positions = %v, want MES and MGC", open)
+	}
+}
+
+// runReversals runs everyBarStrategy over flat MES bars, so each signal
+// after the first closes the position the one before opened.
+func runReversals(t *testing.T, equity int64, riskCfg risk.Config) *Result {
+	t.Helper()
+
+	baseTime := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	var events []types.MarketEvent
+	for i := 0; i < 4; i++ {
+		events = append(events, types.MarketEvent{
+			Symbol:    "MES",
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Open:      decimal.NewFromInt(5000),
+			High:      decimal.NewFromInt(5000),
+			Low:       decimal.NewFromInt(5000),
+			Close:     decimal.NewFromInt(5000),
+		})
+	}
+
+	runner := NewRunner(
+		Config{InitialEquity: decimal.NewFromInt(equity)},
+		observer.NewMemoryFeed(events, "MES"),
+		nil,
+		&everyBarStrategy{},
+		riskCfg,
+		execution.DefaultSimulatedConfig(),
+	)
+
+	result, err := runner.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	return result
+}
+
+func TestRunner_FreeMarginAllowsClosing(t *testing.T) {
+	// One MES contract holds most of the account's margin; the opposite
+	// signal closing it must not need margin of its own
+	riskCfg := fixedOneLot()
+	riskCfg.MarginPolicy = risk.MarginReject
+	if result := runReversals(t, 60, riskCfg); len(result.Trades) == 0 {
+		t.Fatal("opposite signal should close the position")
+	}
+}
+
+func TestRunner_MaxMiniEquivalent(t *testing.T) {
+	riskCfg := fixedOneLot()
+
+	// One MES is 0.1 ES, so one ES on top of it goes over a cap of 1
+	riskCfg.MaxMiniEquivalent = decimal.NewFromInt(1)
+	if open := runFirstBarEntries(t, 100000, riskCfg, "MES", "ES"); len(open) != 1 || open[0] != "MES" {
+		t.Errorf("open positions = %v, want [MES]", open)
+	}
+
+	riskCfg.MaxMiniEquivalent = decimal.NewFromFloat(1.1)
+	if open := runFirstBarEntries(t, 100000, riskCfg, "MES", "ES"); len(open) != 2 {
+		t.Errorf("open positions = %v, want MES and ES", open)
+	}
+}
+
+func TestRunner_MaxMiniEquivalentAllowsClosing(t *testing.T) {
+	// One MES fills a 0.1 ES cap; the opposite signal nets it off
+	riskCfg := fixedOneLot()
+	riskCfg.MaxMiniEquivalent = decimal.NewFromFloat(0.1)
+	if result := runReversals(t, 100000, riskCfg); len(result.Trades) == 0 {
+		t.Fatal("opposite signal should close the position")
+	}
+}
@@ -0,0 +1,68 @@
+package backtest
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/execution"
+	"github.com/tathienbao/quant-bot/internal/risk"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// SessionSplit breaks a backtest's P&L down by whether trades were closed
+// in the session they opened in or held through a session close.
+type SessionSplit struct {
+	IntradayTrades  int
+	IntradayPL      decimal.Decimal
+	OvernightTrades int
+	OvernightPL     decimal.Decimal
+
+	// WorstGap is the largest loss from a session's first open against the
+	// previous session's last close on a held position (zero if none lost).
+	WorstGap     decimal.Decimal
+	WorstGapTime time.Time
+}
+
+// sessionStart returns the start of the trading day containing t.
+func (r *Runner) sessionStart(t time.Time) time.Time {
+	return risk.SessionStart(t, r.cfg.SessionLocation, r.cfg.SessionStartTime)
+}
+
+// trackGap records the gap a new session's first bar opens against a
+// position held through the close. Call before the executor sees the bar.
+func (r *Runner) trackGap(event types.MarketEvent) {
+	prev, ok := r.lastBar[event.Symbol]
+	r.lastBar[event.Symbol] = event
+	if !ok || !r.sessionStart(event.Timestamp).After(r.sessionStart(prev.Timestamp)) {
+		return
+	}
+
+	pos, ok := r.executor.GetPositions()[event.Symbol]
+	if !ok || pos.Contracts == 0 {
+		return
+	}
+
+	gap := execution.GrossPL(event.Symbol, pos.Side, prev.Close, event.Open, pos.Contracts)
+	if gap.LessThan(r.worstGap) {
+		r.worstGap = gap
+		r.worstGapTime = event.Timestamp
+	}
+}
+
+// splitSessions tags trades held through a session close as overnight and
+// totals P&L for each kind.
+func (r *Runner) splitSessions(trades []types.Trade) *SessionSplit {
+	split := &SessionSplit{WorstGap: r.worstGap, WorstGapTime: r.worstGapTime}
+	for i := range trades {
+		t := &trades[i]
+		t.Overnight = r.sessionStart(t.ExitTime).After(r.sessionStart(t.EntryTime))
+		if t.Overnight {
+			split.OvernightTrades++
+			split.OvernightPL = split.OvernightPL.Add(t.NetPL)
+		} else {
+			split.IntradayTrades++
+			split.IntradayPL = split.IntradayPL.Add(t.NetPL)
+		}
+	}
+	return split
+}
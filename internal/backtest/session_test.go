@@ -0,0 +1,110 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/execution"
+	"github.com/tathienbao/quant-bot/internal/observer"
+	"github.com/tathienbao/quant-bot/internal/risk"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func TestRunner_SplitSessions_WorstGap(t *testing.T) {
+	// Session starts at 17:00 UTC; the long opened at 16:59 is held through it
+	baseTime := time.Date(2024, 1, 2, 16, 58, 0, 0, time.UTC)
+	bar := func(i int, open, close int64) types.MarketEvent {
+		return types.MarketEvent{
+			Symbol:    "MES",
+			Timestamp: baseTime.Add(time.Duration(i) * time.Minute),
+			Open:      decimal.NewFromInt(open),
+			High:      decimal.NewFromInt(max(open, close) + 1),
+			Low:       decimal.NewFromInt(min(open, close) - 1),
+			Close:     decimal.NewFromInt(close),
+		}
+	}
+	events := []types.MarketEvent{bar(0, 5000, 5000), bar(1, 5000, 5000), bar(2, 4996, 4997), bar(3, 4997, 4994)}
+
+	run := func(split bool) *Result {
+		runner := NewRunner(
+			Config{
+				InitialEquity:    decimal.NewFromInt(10000),
+				SplitSessions:    split,
+				SessionStartTime: 17 * time.Hour,
+			},
+			observer.NewMemoryFeed(events, "MES"),
+			observer.NewCalculator(observer.DefaultCalculatorConfig()),
+			&directionStrategy{side: types.SideLong},
+			risk.DefaultConfig(),
+			execution.SimulatedConfig{SlippageTicks: 0, CommissionPerSide: decimal.Zero},
+		)
+		result, err := runner.Run(context.Background())
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		return result
+	}
+
+	if result := run(false); result.Sessions != nil {
+		t.Errorf("Sessions = %+v, want nil when not splitting", result.Sessions)
+	}
+
+	// 2 contracts long from 5000; the 17:00 bar opens 4 pts lower: -4 * $5 * 2.
+	// The later drop to 4994 is intraday and doesn't count
+	sessions := run(true).Sessions
+	if sessions == nil {
+		t.Fatal("Sessions = nil, want a split")
+	}
+	if want := decimal.NewFromInt(-40); !sessions.WorstGap.Equal(want) {
+		t.Errorf("WorstGap = %s, want %s", sessions.WorstGap, want)
+	}
+	if want := events[2].Timestamp; !sessions.WorstGapTime.Equal(want) {
+		t.Errorf("WorstGapTime = %s, want %s", sessions.WorstGapTime, want)
+	}
+}
+
+func TestRunner_SplitSessions_TagsTrades(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	runner := NewRunner(
+		Config{
+			InitialEquity:    decimal.NewFromInt(10000),
+			SplitSessions:    true,
+			SessionLocation:  ny,
+			SessionStartTime: 18 * time.Hour,
+		},
+		observer.NewMemoryFeed(nil, "MES"),
+		nil,
+		&directionStrategy{},
+		risk.DefaultConfig(),
+		execution.DefaultSimulatedConfig(),
+	)
+
+	at := func(day, hour int) time.Time { return time.Date(2024, 1, day, hour, 0, 0, 0, ny) }
+	trades := []types.Trade{
+		{EntryTime: at(2, 10), ExitTime: at(2, 15), NetPL: decimal.NewFromInt(50)},
+		{EntryTime: at(2, 19), ExitTime: at(3, 10), NetPL: decimal.NewFromInt(30)},  // Evening open is the same trading day
+		{EntryTime: at(2, 15), ExitTime: at(2, 19), NetPL: decimal.NewFromInt(-80)}, // Held through the 18:00 roll
+		{EntryTime: at(2, 10), ExitTime: at(4, 10), NetPL: decimal.NewFromInt(20)},
+	}
+
+	split := runner.splitSessions(trades)
+
+	wantOvernight := []bool{false, false, true, true}
+	for i, trade := range trades {
+		if trade.Overnight != wantOvernight[i] {
+			t.Errorf("trades[%d].Overnight = %v, want %v", i, trade.Overnight, wantOvernight[i])
+		}
+	}
+	if split.IntradayTrades != 2 || !split.IntradayPL.Equal(decimal.NewFromInt(80)) {
+		t.Errorf("intraday = %d trades, %s; want 2 trades, 80", split.IntradayTrades, split.IntradayPL)
+	}
+	if split.OvernightTrades != 2 || !split.OvernightPL.Equal(decimal.NewFromInt(-60)) {
+		t.Errorf("overnight = %d trades, %s; want 2 trades, -60", split.OvernightTrades, split.OvernightPL)
+	}
+}
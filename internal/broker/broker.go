@@ -4,6 +4,7 @@ package broker
 import (
 	"context"
 	"errors"
+	"syscall"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -18,8 +19,38 @@ var (
 	ErrInvalidContract   = errors.New("invalid contract")
 	ErrRateLimited       = errors.New("rate limited by broker")
 	ErrMarketClosed      = errors.New("market closed")
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrOrderNotWorking   = errors.New("order is no longer working")
 )
 
+// transientErrors are failures where the same request may succeed if sent
+// again shortly.
+var transientErrors = []error{
+	ErrNotConnected,
+	ErrConnectionTimeout,
+	ErrRateLimited,
+	types.ErrConnectionLost,
+	types.ErrRateLimitExceeded,
+	syscall.ECONNRESET,
+	syscall.ECONNREFUSED,
+	syscall.EPIPE,
+}
+
+// IsTransient reports whether err is a connectivity or rate-limit failure
+// worth retrying. Rejections (margin, duplicate order, invalid contract) and
+// timeouts, where the order state is unknown, are not transient.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, target := range transientErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
 // ConnectionState represents the broker connection state.
 type ConnectionState int
 
@@ -63,6 +94,10 @@ type Broker interface {
 	// Order execution
 	PlaceOrder(ctx context.Context, order types.OrderIntent) (*OrderResult, error)
 	CancelOrder(ctx context.Context, orderID string) error
+	// ModifyOrder reprices and resizes a working order in place, so it
+	// keeps its place without a cancel/resubmit gap. A zero newPrice keeps
+	// the current price.
+	ModifyOrder(ctx context.Context, orderID string, newPrice decimal.Decimal, newQty int) error
 	GetOpenOrders(ctx context.Context) ([]Order, error)
 
 	// Position management
@@ -73,6 +108,51 @@ type Broker interface {
 	Shutdown(ctx context.Context) error
 }
 
+// Flattener is implemented by brokers that can close every open position
+// in one call. Orders are submitted; fills are confirmed via GetPositions.
+type Flattener interface {
+	FlattenAll(ctx context.Context) error
+}
+
+// SpreadProvider is implemented by brokers that stream bid/ask quotes.
+// Spread returns ask minus bid; ok is false until both sides are known.
+type SpreadProvider interface {
+	Spread(symbol string) (spread decimal.Decimal, ok bool)
+}
+
+// FillHandler receives a copy of an order each time it fills.
+type FillHandler func(order Order)
+
+// FillNotifier is implemented by brokers that report fills as they happen.
+// The handler runs on the broker's goroutine and must not block.
+type FillNotifier interface {
+	SetFillHandler(handler FillHandler)
+}
+
+// OrderReconciliation lists how tracked orders differed from the open
+// orders the broker reported after a reconnect.
+type OrderReconciliation struct {
+	Removed []Order // Tracked as working but no longer reported; dropped
+	Updated []Order // Reported with a different status, quantity or price
+	Unknown []Order // Reported but never placed by this client; not tracked
+}
+
+// Changed reports whether reconciliation found any difference.
+func (r OrderReconciliation) Changed() bool {
+	return len(r.Removed) > 0 || len(r.Updated) > 0 || len(r.Unknown) > 0
+}
+
+// ReconcileHandler receives the differences found by an open-order
+// reconciliation.
+type ReconcileHandler func(rec OrderReconciliation)
+
+// ReconcileNotifier is implemented by brokers that reconcile their tracked
+// orders with the exchange after reconnecting. The handler runs on the
+// broker's goroutine and must not block.
+type ReconcileNotifier interface {
+	SetReconcileHandler(handler ReconcileHandler)
+}
+
 // AccountSummary contains account information.
 type AccountSummary struct {
 	AccountID        string
@@ -190,25 +270,45 @@ func MGCContract(expiry string) Contract {
 }
 
 // GetFrontMonthExpiry returns the front month expiry in YYYYMM format.
-// Futures typically expire on the 3rd Friday of Mar, Jun, Sep, Dec.
+// Quarterly index futures expire on the 3rd Friday of Mar, Jun, Sep, Dec,
+// but liquidity moves to the next quarter on the roll date, so the front
+// month switches there rather than at expiry.
 func GetFrontMonthExpiry(now time.Time) string {
-	year := now.Year()
-	month := now.Month()
+	year, month := ActiveContract(now)
+	return formatExpiry(year, month)
+}
+
+// RollDaysBeforeExpiry is how many calendar days before expiry CME equity
+// index futures roll to the next quarter (the Thursday of the prior week).
+const RollDaysBeforeExpiry = 8
+
+// QuarterlyExpiry returns the expiry date (3rd Friday) of the contract
+// expiring in year/month.
+func QuarterlyExpiry(year int, month time.Month) time.Time {
+	return getThirdFriday(year, month)
+}
+
+// RollDate returns the date trading moves from the contract expiring in
+// year/month to the next quarter.
+func RollDate(year int, month time.Month) time.Time {
+	return getThirdFriday(year, month).AddDate(0, 0, -RollDaysBeforeExpiry)
+}
+
+// ActiveContract returns the expiry year and month of the quarterly contract
+// that is front month on t's calendar date.
+func ActiveContract(t time.Time) (int, time.Month) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 
-	// Find the next quarterly expiry month
+	// Find the next quarterly expiry month whose roll date hasn't passed
 	quarterlyMonths := []time.Month{3, 6, 9, 12}
 	for _, qm := range quarterlyMonths {
-		if month <= qm {
-			// Check if we're past the 3rd Friday of this month
-			thirdFriday := getThirdFriday(year, qm)
-			if now.Before(thirdFriday) {
-				return formatExpiry(year, qm)
-			}
+		if day.Before(RollDate(t.Year(), qm)) {
+			return t.Year(), qm
 		}
 	}
 
 	// Roll to next year's March
-	return formatExpiry(year+1, 3)
+	return t.Year() + 1, 3
 }
 
 func getThirdFriday(year int, month time.Month) time.Time {
@@ -1,8 +1,13 @@
 package broker
 
 import (
+	"errors"
+	"fmt"
+	"syscall"
 	"testing"
 	"time"
+
+	"github.com/tathienbao/quant-bot/internal/types"
 )
 
 func TestConnectionState_String(t *testing.T) {
@@ -94,6 +99,36 @@ func TestGetFrontMonthExpiry(t *testing.T) {
 			date: time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC),
 			want: "202603", // Next year March
 		},
+		{
+			name: "day before march roll",
+			date: time.Date(2025, 3, 12, 23, 59, 0, 0, time.UTC),
+			want: "202503", // Roll is Thu Mar 13, expiry Fri Mar 21
+		},
+		{
+			name: "march roll date",
+			date: time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC),
+			want: "202506", // Before expiry, but volume has moved to June
+		},
+		{
+			name: "june roll date",
+			date: time.Date(2025, 6, 12, 9, 30, 0, 0, time.UTC),
+			want: "202509", // Expiry Fri Jun 20
+		},
+		{
+			name: "september before roll",
+			date: time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC),
+			want: "202509", // Roll is Thu Sep 11
+		},
+		{
+			name: "december roll date",
+			date: time.Date(2025, 12, 11, 0, 0, 0, 0, time.UTC),
+			want: "202603", // Expiry Fri Dec 19
+		},
+		{
+			name: "non-quarterly month",
+			date: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC),
+			want: "202509",
+		},
 	}
 
 	for _, tt := range tests {
@@ -105,3 +140,57 @@ func TestGetFrontMonthExpiry(t *testing.T) {
 		})
 	}
 }
+
+func TestRollDate(t *testing.T) {
+	tests := []struct {
+		year   int
+		month  time.Month
+		expiry string
+		roll   string
+	}{
+		{2024, 3, "2024-03-15", "2024-03-07"},
+		{2024, 12, "2024-12-20", "2024-12-12"},
+		{2025, 6, "2025-06-20", "2025-06-12"},
+		{2026, 3, "2026-03-20", "2026-03-12"},
+	}
+
+	for _, tt := range tests {
+		expiry := QuarterlyExpiry(tt.year, tt.month)
+		if got := expiry.Format("2006-01-02"); got != tt.expiry {
+			t.Errorf("QuarterlyExpiry(%d, %d) = %s, want %s", tt.year, tt.month, got, tt.expiry)
+		}
+		if expiry.Weekday() != time.Friday {
+			t.Errorf("QuarterlyExpiry(%d, %d) is a %s", tt.year, tt.month, expiry.Weekday())
+		}
+		roll := RollDate(tt.year, tt.month)
+		if got := roll.Format("2006-01-02"); got != tt.roll {
+			t.Errorf("RollDate(%d, %d) = %s, want %s", tt.year, tt.month, got, tt.roll)
+		}
+		if roll.Weekday() != time.Thursday {
+			t.Errorf("RollDate(%d, %d) is a %s", tt.year, tt.month, roll.Weekday())
+		}
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{ErrNotConnected, true},
+		{fmt.Errorf("place order: %w", ErrRateLimited), true},
+		{fmt.Errorf("write tcp: %w", syscall.ECONNRESET), true},
+		{types.ErrConnectionLost, true},
+		{ErrOrderRejected, false},
+		{types.ErrDuplicateOrder, false},
+		{types.ErrOrderTimeout, false},
+		{errors.New("insufficient margin"), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
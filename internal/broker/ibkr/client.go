@@ -21,12 +21,20 @@ import (
 const (
 	msgTickPrice        = 1
 	msgTickSize         = 2
+	msgOrderStatus      = 3
+	msgOpenOrder        = 5
+	msgHistoricalData   = 17
 	msgAccountSummary   = 63
 	msgAccountSummaryEnd = 64
 	msgPosition         = 61
 	msgPositionEnd      = 62
+	msgOpenOrderEnd     = 53
 )
 
+// openOrdersReqID tracks the REQ_OPEN_ORDERS reply, which carries no
+// request id of its own. Generated ids start above it.
+const openOrdersReqID = 0
+
 // Client implements the broker.Broker interface for IBKR.
 type Client struct {
 	cfg    Config
@@ -44,7 +52,8 @@ type Client struct {
 
 	// Request tracking
 	nextReqID atomic.Int64
-	requests  map[int64]chan any
+	reqMu     sync.Mutex
+	requests  map[int64]chan any // reqID -> reply for request/response calls
 
 	// Market data subscriptions
 	mdMu          sync.RWMutex
@@ -61,6 +70,8 @@ type Client struct {
 	// Orders
 	ordersMu sync.RWMutex
 	orders   map[string]*broker.Order
+	reported map[string]broker.Order // Open orders by order id while a reconciliation collects them
+	onReconcile broker.ReconcileHandler
 
 	// Shutdown
 	done     chan struct{}
@@ -72,6 +83,9 @@ type marketDataSubscription struct {
 	tickerID  int64
 	ch        chan types.MarketEvent
 	lastEvent types.MarketEvent
+	bid       decimal.Decimal
+	ask       decimal.Decimal
+	stop      func() bool // Detaches the subscriber context watch
 }
 
 // NewClient creates a new IBKR client.
@@ -267,6 +281,14 @@ func (c *Client) processMessage(data []byte) {
 		c.handleAccountSummary(fields)
 	case msgPosition:
 		c.handlePosition(fields)
+	case msgHistoricalData:
+		c.handleHistoricalData(fields)
+	case msgOpenOrder:
+		c.handleOpenOrder(fields)
+	case msgOrderStatus:
+		c.handleOrderStatus(fields)
+	case msgOpenOrderEnd:
+		c.handleOpenOrderEnd()
 	default:
 		c.logger.Debug("unhandled message type", "msg_id", msgID)
 	}
@@ -294,6 +316,9 @@ func (c *Client) handleTickPrice(fields [][]byte) {
 	}
 
 	switch tickType {
+	case 1, 2: // Bid/ask only update the quote
+		c.updateQuote(tickerID, tickType, price)
+		return
 	case 4: // Last price
 		event.Close = price
 	case 6: // High
@@ -307,6 +332,46 @@ func (c *Client) handleTickPrice(fields [][]byte) {
 	c.publishMarketData(tickerID, event)
 }
 
+// updateQuote records the latest bid or ask for a subscription.
+func (c *Client) updateQuote(tickerID int64, tickType int, price decimal.Decimal) {
+	c.mdMu.Lock()
+	defer c.mdMu.Unlock()
+
+	for _, sub := range c.mdSubscriptions {
+		if sub.tickerID != tickerID {
+			continue
+		}
+		if tickType == 1 {
+			sub.bid = price
+		} else {
+			sub.ask = price
+		}
+		return
+	}
+}
+
+// Quote returns the latest bid and ask for a subscribed symbol.
+// ok is false until both sides have been received.
+func (c *Client) Quote(symbol string) (bid, ask decimal.Decimal, ok bool) {
+	c.mdMu.RLock()
+	defer c.mdMu.RUnlock()
+
+	sub, found := c.mdSubscriptions[symbol]
+	if !found || !sub.bid.IsPositive() || !sub.ask.IsPositive() {
+		return decimal.Zero, decimal.Zero, false
+	}
+	return sub.bid, sub.ask, true
+}
+
+// Spread returns the current ask minus bid for a subscribed symbol.
+func (c *Client) Spread(symbol string) (decimal.Decimal, bool) {
+	bid, ask, ok := c.Quote(symbol)
+	if !ok {
+		return decimal.Zero, false
+	}
+	return ask.Sub(bid), true
+}
+
 // handleTickSize handles tick size messages.
 func (c *Client) handleTickSize(fields [][]byte) {
 	// Format: msgID, version, tickerID, tickType, size
@@ -432,6 +497,7 @@ func (c *Client) reconnectLoop() {
 
 		if err == nil {
 			c.logger.Info("reconnected successfully")
+			c.reconcileAfterReconnect()
 			return
 		}
 
@@ -441,6 +507,255 @@ func (c *Client) reconnectLoop() {
 	c.logger.Error("max reconnect attempts reached")
 }
 
+// reconcileAfterReconnect brings the tracked orders in line with what the
+// exchange reports, so the bot doesn't act on orders that filled, were
+// cancelled or never arrived while it was disconnected.
+func (c *Client) reconcileAfterReconnect() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.RequestTimeout)
+	defer cancel()
+
+	rec, err := c.ReconcileOpenOrders(ctx)
+	if err != nil {
+		c.logger.Warn("open order reconciliation failed", "err", err)
+		return
+	}
+	if !rec.Changed() {
+		return
+	}
+
+	c.logger.Warn("open orders reconciled after reconnect",
+		"removed", len(rec.Removed),
+		"updated", len(rec.Updated),
+		"unknown", len(rec.Unknown),
+	)
+
+	c.ordersMu.RLock()
+	handler := c.onReconcile
+	c.ordersMu.RUnlock()
+	if handler != nil {
+		handler(rec)
+	}
+}
+
+// ReconcileOpenOrders requests the open orders from TWS and reconciles the
+// tracked orders with them: working orders TWS no longer reports are
+// dropped and the rest take the reported status, quantity and price.
+func (c *Client) ReconcileOpenOrders(ctx context.Context) (broker.OrderReconciliation, error) {
+	if !c.IsConnected() {
+		return broker.OrderReconciliation{}, broker.ErrNotConnected
+	}
+
+	reply := c.trackRequest(openOrdersReqID)
+	defer c.untrackRequest(openOrdersReqID)
+
+	c.ordersMu.Lock()
+	c.reported = make(map[string]broker.Order)
+	c.ordersMu.Unlock()
+
+	// REQ_OPEN_ORDERS = 5
+	if err := c.sendMessage("5\x001\x00"); err != nil {
+		c.ordersMu.Lock()
+		c.reported = nil
+		c.ordersMu.Unlock()
+		return broker.OrderReconciliation{}, fmt.Errorf("request open orders: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		c.ordersMu.Lock()
+		c.reported = nil
+		c.ordersMu.Unlock()
+		return broker.OrderReconciliation{}, fmt.Errorf("open orders: %w", ctx.Err())
+	case result := <-reply:
+		reported, ok := result.(map[string]broker.Order)
+		if !ok {
+			return broker.OrderReconciliation{}, fmt.Errorf("open orders: unexpected reply %T", result)
+		}
+		return c.applyOpenOrders(reported), nil
+	}
+}
+
+// applyOpenOrders reconciles the tracked orders with the open orders TWS
+// reported, keyed by order id.
+func (c *Client) applyOpenOrders(reported map[string]broker.Order) broker.OrderReconciliation {
+	c.ordersMu.Lock()
+	defer c.ordersMu.Unlock()
+
+	var rec broker.OrderReconciliation
+	seen := make(map[string]bool, len(reported))
+	for key, tracked := range c.orders {
+		if tracked.Status != broker.OrderStatusSubmitted && tracked.Status != broker.OrderStatusPartial {
+			continue
+		}
+		open, ok := reported[tracked.OrderID]
+		if !ok {
+			rec.Removed = append(rec.Removed, *tracked)
+			delete(c.orders, key)
+			continue
+		}
+		seen[tracked.OrderID] = true
+		if open.Status == tracked.Status && open.Quantity == tracked.Quantity &&
+			open.FilledQty == tracked.FilledQty && open.LimitPrice.Equal(tracked.LimitPrice) {
+			continue
+		}
+		tracked.Status = open.Status
+		tracked.Quantity = open.Quantity
+		tracked.FilledQty = open.FilledQty
+		tracked.LimitPrice = open.LimitPrice
+		tracked.UpdatedAt = time.Now()
+		rec.Updated = append(rec.Updated, *tracked)
+	}
+	for id, open := range reported {
+		if !seen[id] {
+			rec.Unknown = append(rec.Unknown, open)
+		}
+	}
+	return rec
+}
+
+// SetReconcileHandler registers a callback for the differences found when
+// open orders are reconciled after a reconnect.
+func (c *Client) SetReconcileHandler(handler broker.ReconcileHandler) {
+	c.ordersMu.Lock()
+	defer c.ordersMu.Unlock()
+	c.onReconcile = handler
+}
+
+// handleOpenOrder collects an OPEN_ORDER message for a running
+// reconciliation.
+func (c *Client) handleOpenOrder(fields [][]byte) {
+	order, err := parseOpenOrder(fields)
+	if err != nil {
+		c.logger.Warn("invalid open order", "err", err)
+		return
+	}
+
+	c.ordersMu.Lock()
+	defer c.ordersMu.Unlock()
+	if c.reported == nil {
+		return // Unsolicited, e.g. after placing an order
+	}
+	if prev, ok := c.reported[order.OrderID]; ok {
+		order.Status = prev.Status // ORDER_STATUS may arrive first
+		order.FilledQty = prev.FilledQty
+	}
+	c.reported[order.OrderID] = order
+}
+
+// handleOrderStatus applies an ORDER_STATUS message to the tracked order
+// and to a running reconciliation.
+func (c *Client) handleOrderStatus(fields [][]byte) {
+	orderID, status, filled, err := parseOrderStatus(fields)
+	if err != nil {
+		c.logger.Warn("invalid order status", "err", err)
+		return
+	}
+
+	c.ordersMu.Lock()
+	defer c.ordersMu.Unlock()
+	for _, o := range c.orders {
+		if o.OrderID == orderID {
+			o.Status = status
+			o.FilledQty = filled
+			o.UpdatedAt = time.Now()
+			break
+		}
+	}
+	if c.reported != nil {
+		open := c.reported[orderID]
+		open.OrderID = orderID
+		open.Status = status
+		open.FilledQty = filled
+		c.reported[orderID] = open
+	}
+}
+
+// handleOpenOrderEnd hands the collected open orders to the waiting
+// reconciliation.
+func (c *Client) handleOpenOrderEnd() {
+	c.ordersMu.Lock()
+	reported := c.reported
+	c.reported = nil
+	c.ordersMu.Unlock()
+
+	if reported != nil {
+		c.deliver(openOrdersReqID, reported)
+	}
+}
+
+// parseOpenOrder parses the leading fields of an OPEN_ORDER message.
+// Format: msgID, version, orderId, conId, symbol, secType, lastTradeDate,
+// strike, right, multiplier, exchange, currency, localSymbol, tradingClass,
+// action, totalQuantity, orderType, lmtPrice, auxPrice, tif, ocaGroup,
+// account, openClose, origin, orderRef, then fields the bot doesn't use.
+// The status comes from the ORDER_STATUS message that follows.
+func parseOpenOrder(fields [][]byte) (broker.Order, error) {
+	const orderRef = 24
+	if len(fields) <= orderRef {
+		return broker.Order{}, fmt.Errorf("short message: %d fields", len(fields))
+	}
+	orderID := string(fields[2])
+	if _, err := strconv.ParseInt(orderID, 10, 64); err != nil {
+		return broker.Order{}, fmt.Errorf("order id %q: %w", fields[2], err)
+	}
+	quantity, err := decimal.NewFromString(string(fields[15]))
+	if err != nil {
+		return broker.Order{}, fmt.Errorf("order %s quantity %q: %w", orderID, fields[15], err)
+	}
+
+	side := types.SideLong
+	if string(fields[14]) == "SELL" {
+		side = types.SideShort
+	}
+	order := broker.Order{
+		OrderID:       orderID,
+		ClientOrderID: string(fields[orderRef]),
+		Symbol:        string(fields[4]),
+		Side:          side,
+		Quantity:      int(quantity.IntPart()),
+		OrderType:     broker.OrderType(fields[16]),
+		Status:        broker.OrderStatusSubmitted,
+		UpdatedAt:     time.Now(),
+	}
+	if order.OrderType == broker.OrderTypeLimit {
+		order.LimitPrice, _ = decimal.NewFromString(string(fields[17])) // Zero if unset
+	}
+	return order, nil
+}
+
+// parseOrderStatus parses an ORDER_STATUS message.
+// Format: msgID, version, orderId, status, filled, remaining, avgFillPrice,
+// then fields the bot doesn't use.
+func parseOrderStatus(fields [][]byte) (orderID string, status broker.OrderStatus, filled int, err error) {
+	if len(fields) < 6 {
+		return "", "", 0, fmt.Errorf("short message: %d fields", len(fields))
+	}
+	orderID = string(fields[2])
+	filledQty, err := decimal.NewFromString(string(fields[4]))
+	if err != nil {
+		return orderID, "", 0, fmt.Errorf("order %s filled %q: %w", orderID, fields[4], err)
+	}
+	remaining, err := decimal.NewFromString(string(fields[5]))
+	if err != nil {
+		return orderID, "", 0, fmt.Errorf("order %s remaining %q: %w", orderID, fields[5], err)
+	}
+
+	switch string(fields[3]) {
+	case "Filled":
+		status = broker.OrderStatusFilled
+	case "Cancelled", "ApiCancelled":
+		status = broker.OrderStatusCancelled
+	case "Inactive":
+		status = broker.OrderStatusRejected
+	default: // PendingSubmit, PreSubmitted, Submitted, PendingCancel
+		status = broker.OrderStatusSubmitted
+		if filledQty.IsPositive() && remaining.IsPositive() {
+			status = broker.OrderStatusPartial
+		}
+	}
+	return orderID, status, int(filledQty.IntPart()), nil
+}
+
 // requestInitialData requests account and position data.
 func (c *Client) requestInitialData(ctx context.Context) error {
 	// Request account summary
@@ -543,7 +858,9 @@ func (c *Client) GetAccountSummary(ctx context.Context) (*broker.AccountSummary,
 	return c.account, nil
 }
 
-// SubscribeMarketData subscribes to market data for a symbol.
+// SubscribeMarketData subscribes to market data for a symbol. The
+// subscription ends, closing the channel, on UnsubscribeMarketData or when
+// ctx is done.
 func (c *Client) SubscribeMarketData(ctx context.Context, symbol string) (<-chan types.MarketEvent, error) {
 	if !c.IsConnected() {
 		return nil, broker.ErrNotConnected
@@ -576,6 +893,14 @@ func (c *Client) SubscribeMarketData(ctx context.Context, symbol string) (<-chan
 		return nil, err
 	}
 
+	sub.stop = context.AfterFunc(ctx, func() {
+		c.mdMu.Lock()
+		defer c.mdMu.Unlock()
+		if err := c.endSubscription(sub); err != nil {
+			c.logger.Warn("failed to cancel market data", "symbol", symbol, "err", err)
+		}
+	})
+
 	c.logger.Info("subscribed to market data",
 		"symbol", symbol,
 		"ticker_id", tickerID,
@@ -613,7 +938,9 @@ func (c *Client) requestMarketData(tickerID int64, symbol string) error {
 	return c.sendMessage(msg)
 }
 
-// UnsubscribeMarketData unsubscribes from market data.
+// UnsubscribeMarketData unsubscribes from market data. Events already
+// buffered stay readable; the channel reports closed once they are drained.
+// The channel is closed even if the cancel request can't be sent.
 func (c *Client) UnsubscribeMarketData(symbol string) error {
 	c.mdMu.Lock()
 	defer c.mdMu.Unlock()
@@ -622,56 +949,58 @@ func (c *Client) UnsubscribeMarketData(symbol string) error {
 	if !ok {
 		return nil
 	}
+	return c.endSubscription(sub)
+}
+
+// endSubscription cancels sub with the gateway, removes it and closes its
+// channel, unless it was already replaced or ended. publishMarketData sends
+// only while holding mdMu, so the caller must hold mdMu.Lock to keep a send
+// from racing the close.
+func (c *Client) endSubscription(sub *marketDataSubscription) error {
+	if c.mdSubscriptions[sub.symbol] != sub {
+		return nil
+	}
+	if sub.stop != nil {
+		sub.stop()
+	}
+	delete(c.mdSubscriptions, sub.symbol)
+	close(sub.ch)
 
 	// CANCEL_MKT_DATA = 2
 	msg := fmt.Sprintf("2\x001\x00%d\x00", sub.tickerID)
 	if err := c.sendMessage(msg); err != nil {
-		return err
+		return fmt.Errorf("cancel market data %s: %w", sub.symbol, err)
 	}
 
-	close(sub.ch)
-	delete(c.mdSubscriptions, symbol)
-
-	c.logger.Info("unsubscribed from market data", "symbol", symbol)
+	c.logger.Info("unsubscribed from market data", "symbol", sub.symbol)
 	return nil
 }
 
-// PlaceOrder places an order.
+// PlaceOrder places an order. A client order ID already placed is refused
+// with types.ErrDuplicateOrder; one whose send failed can be placed again.
 func (c *Client) PlaceOrder(ctx context.Context, intent types.OrderIntent) (*broker.OrderResult, error) {
 	if !c.IsConnected() {
 		return nil, broker.ErrNotConnected
 	}
 
-	orderID := c.nextReqID.Add(1)
-
-	// Get contract
-	expiry := broker.GetFrontMonthExpiry(time.Now())
-	var contract broker.Contract
-
-	switch intent.Symbol {
-	case "MES":
-		contract = broker.MESContract(expiry)
-	case "MGC":
-		contract = broker.MGCContract(expiry)
-	default:
-		return nil, broker.ErrInvalidContract
+	// Reduce-only is enforced against the positions TWS last reported
+	if intent.ReduceOnly {
+		contracts, err := c.reduceOnlyContracts(intent)
+		if err != nil {
+			return nil, err
+		}
+		intent.Contracts = contracts
 	}
 
-	// Build order message
-	action := "BUY"
-	if intent.Side == types.SideShort {
-		action = "SELL"
-	}
+	orderID := c.nextReqID.Add(1)
 
-	// PLACE_ORDER = 3
-	msg := c.buildPlaceOrderMessage(orderID, contract, action, intent)
-	if err := c.sendMessage(msg); err != nil {
-		return nil, fmt.Errorf("send order: %w", err)
+	// Get contract
+	contract, err := frontMonthContract(intent.Symbol)
+	if err != nil {
+		return nil, err
 	}
 
-	// Track order
-	c.ordersMu.Lock()
-	c.orders[intent.ClientOrderID] = &broker.Order{
+	order := &broker.Order{
 		OrderID:       fmt.Sprintf("%d", orderID),
 		ClientOrderID: intent.ClientOrderID,
 		Symbol:        intent.Symbol,
@@ -682,8 +1011,26 @@ func (c *Client) PlaceOrder(ctx context.Context, intent types.OrderIntent) (*bro
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
+
+	// Reserve the client order ID before sending, so a retry of an order
+	// that already went out is refused instead of sent twice
+	c.ordersMu.Lock()
+	if _, ok := c.orders[intent.ClientOrderID]; ok {
+		c.ordersMu.Unlock()
+		return nil, fmt.Errorf("%w: %s", types.ErrDuplicateOrder, intent.ClientOrderID)
+	}
+	c.orders[intent.ClientOrderID] = order
 	c.ordersMu.Unlock()
 
+	// PLACE_ORDER = 3
+	msg := c.buildPlaceOrderMessage(orderID, contract, *order)
+	if err := c.sendMessage(msg); err != nil {
+		c.ordersMu.Lock()
+		delete(c.orders, intent.ClientOrderID)
+		c.ordersMu.Unlock()
+		return nil, fmt.Errorf("send order: %w", err)
+	}
+
 	c.logger.Info("order placed",
 		"order_id", orderID,
 		"client_order_id", intent.ClientOrderID,
@@ -700,10 +1047,45 @@ func (c *Client) PlaceOrder(ctx context.Context, intent types.OrderIntent) (*bro
 	}, nil
 }
 
+// reduceOnlyContracts caps a reduce-only intent at the opposite position
+// held.
+func (c *Client) reduceOnlyContracts(intent types.OrderIntent) (int, error) {
+	c.positionsMu.RLock()
+	defer c.positionsMu.RUnlock()
+
+	pos, ok := c.positions[intent.Symbol]
+	if !ok {
+		return intent.ReduceOnlyContracts(intent.Side, 0)
+	}
+	return intent.ReduceOnlyContracts(pos.Side, pos.Contracts)
+}
+
+// frontMonthContract returns the front-month contract for symbol.
+func frontMonthContract(symbol string) (broker.Contract, error) {
+	expiry := broker.GetFrontMonthExpiry(time.Now())
+	switch symbol {
+	case "MES":
+		return broker.MESContract(expiry), nil
+	case "MGC":
+		return broker.MGCContract(expiry), nil
+	default:
+		return broker.Contract{}, broker.ErrInvalidContract
+	}
+}
+
 // buildPlaceOrderMessage builds a PLACE_ORDER message.
-func (c *Client) buildPlaceOrderMessage(orderID int64, contract broker.Contract, action string, intent types.OrderIntent) string {
+func (c *Client) buildPlaceOrderMessage(orderID int64, contract broker.Contract, order broker.Order) string {
+	action := "BUY"
+	if order.Side == types.SideShort {
+		action = "SELL"
+	}
+	var limitPrice string
+	if order.OrderType == broker.OrderTypeLimit {
+		limitPrice = order.LimitPrice.String()
+	}
+
 	// Simplified order message - real implementation needs all fields
-	return fmt.Sprintf("3\x0045\x00%d\x000\x00%s\x00%s\x00\x00%s\x00%s\x00%s\x00%d\x00\x00\x00%s\x00%d\x00MKT\x00\x00\x00\x00\x00\x00\x00\x00\x00DAY\x00\x00\x00\x000\x000\x00%s\x000\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
+	return fmt.Sprintf("3\x0045\x00%d\x000\x00%s\x00%s\x00\x00%s\x00%s\x00%s\x00%d\x00\x00\x00%s\x00%d\x00%s\x00%s\x00\x00\x00\x00\x00\x00\x00DAY\x00\x00\x00\x000\x000\x00%s\x000\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
 		orderID,
 		contract.Symbol,
 		contract.SecType,
@@ -712,8 +1094,10 @@ func (c *Client) buildPlaceOrderMessage(orderID int64, contract broker.Contract,
 		contract.Currency,
 		contract.Multiplier,
 		action,
-		intent.Contracts,
-		intent.ClientOrderID,
+		order.Quantity,
+		order.OrderType,
+		limitPrice,
+		order.ClientOrderID,
 	)
 }
 
@@ -733,6 +1117,76 @@ func (c *Client) CancelOrder(ctx context.Context, orderID string) error {
 	return nil
 }
 
+// ModifyOrder reprices or resizes a working order. IB treats PLACE_ORDER
+// with an existing order id as a modification, so the tracked order is
+// resent under its id, keeping its order type, with the new quantity; a
+// positive newPrice becomes the limit price, which only limit orders have.
+func (c *Client) ModifyOrder(ctx context.Context, orderID string, newPrice decimal.Decimal, newQty int) error {
+	if !c.IsConnected() {
+		return broker.ErrNotConnected
+	}
+	if newQty <= 0 {
+		return fmt.Errorf("modify order %s: quantity must be positive, got %d", orderID, newQty)
+	}
+	id, err := strconv.ParseInt(orderID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %s", broker.ErrOrderNotFound, orderID)
+	}
+
+	c.ordersMu.RLock()
+	var tracked *broker.Order
+	for _, o := range c.orders {
+		if o.OrderID == orderID {
+			tracked = o
+			break
+		}
+	}
+	var modified broker.Order
+	if tracked != nil {
+		modified = *tracked
+	}
+	c.ordersMu.RUnlock()
+
+	if tracked == nil {
+		return fmt.Errorf("%w: %s", broker.ErrOrderNotFound, orderID)
+	}
+	if modified.Status != broker.OrderStatusSubmitted && modified.Status != broker.OrderStatusPartial {
+		return fmt.Errorf("%w: %s is %s", broker.ErrOrderNotWorking, orderID, modified.Status)
+	}
+
+	if newPrice.IsPositive() && modified.OrderType != broker.OrderTypeLimit {
+		return fmt.Errorf("modify order %s: %s order has no limit price to change", orderID, modified.OrderType)
+	}
+
+	contract, err := frontMonthContract(modified.Symbol)
+	if err != nil {
+		return err
+	}
+	modified.Quantity = newQty
+	if newPrice.IsPositive() {
+		modified.LimitPrice = newPrice
+	}
+	modified.UpdatedAt = time.Now()
+
+	// PLACE_ORDER = 3, same order id
+	if err := c.sendMessage(c.buildPlaceOrderMessage(id, contract, modified)); err != nil {
+		return fmt.Errorf("send order modification: %w", err)
+	}
+
+	c.ordersMu.Lock()
+	tracked.Quantity = modified.Quantity
+	tracked.LimitPrice = modified.LimitPrice
+	tracked.UpdatedAt = modified.UpdatedAt
+	c.ordersMu.Unlock()
+
+	c.logger.Info("order modification requested",
+		"order_id", orderID,
+		"price", modified.LimitPrice,
+		"contracts", newQty,
+	)
+	return nil
+}
+
 // GetOpenOrders returns open orders.
 func (c *Client) GetOpenOrders(ctx context.Context) ([]broker.Order, error) {
 	if !c.IsConnected() {
@@ -786,6 +1240,177 @@ func (c *Client) GetPosition(ctx context.Context, symbol string) (*broker.Positi
 	return pos, nil
 }
 
+// GetHistoricalBars requests bars ending now for the symbol's front-month
+// contract. duration and barSize use IB's syntax, e.g. "1 D" and "5 mins".
+// Bars come back oldest first without indicators.
+func (c *Client) GetHistoricalBars(ctx context.Context, symbol string, duration, barSize string) ([]types.MarketEvent, error) {
+	if !c.IsConnected() {
+		return nil, broker.ErrNotConnected
+	}
+
+	contract, err := frontMonthContract(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	reqID := c.nextReqID.Add(1)
+	reply := c.trackRequest(reqID)
+	defer c.untrackRequest(reqID)
+
+	if err := c.sendMessage(buildHistoricalDataMessage(reqID, contract, duration, barSize)); err != nil {
+		return nil, fmt.Errorf("request historical data: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.RequestTimeout)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("historical data for %s: %w", symbol, ctx.Err())
+	case result := <-reply:
+		if err, ok := result.(error); ok {
+			return nil, fmt.Errorf("historical data for %s: %w", symbol, err)
+		}
+		bars, ok := result.([]types.MarketEvent)
+		if !ok {
+			return nil, fmt.Errorf("historical data for %s: unexpected reply %T", symbol, result)
+		}
+		for i := range bars {
+			bars[i].Symbol = symbol
+		}
+		c.logger.Info("historical bars received", "symbol", symbol, "bars", len(bars), "duration", duration, "bar_size", barSize)
+		return bars, nil
+	}
+}
+
+// trackRequest registers a reply channel for reqID.
+func (c *Client) trackRequest(reqID int64) chan any {
+	reply := make(chan any, 1)
+	c.reqMu.Lock()
+	c.requests[reqID] = reply
+	c.reqMu.Unlock()
+	return reply
+}
+
+// untrackRequest forgets reqID; late replies are dropped.
+func (c *Client) untrackRequest(reqID int64) {
+	c.reqMu.Lock()
+	delete(c.requests, reqID)
+	c.reqMu.Unlock()
+}
+
+// deliver hands a reply to the caller waiting on reqID, if any.
+func (c *Client) deliver(reqID int64, result any) {
+	c.reqMu.Lock()
+	reply, ok := c.requests[reqID]
+	c.reqMu.Unlock()
+	if !ok {
+		c.logger.Debug("reply for unknown request", "req_id", reqID)
+		return
+	}
+	select {
+	case reply <- result:
+	default: // Already answered
+	}
+}
+
+// buildHistoricalDataMessage builds a REQ_HISTORICAL_DATA message for
+// trade bars ending now, outside regular hours included, dated in epoch
+// seconds.
+func buildHistoricalDataMessage(reqID int64, contract broker.Contract, duration, barSize string) string {
+	// REQ_HISTORICAL_DATA = 20
+	return fmt.Sprintf("20\x006\x00%d\x000\x00%s\x00%s\x00%s\x00\x00\x00%d\x00%s\x00\x00%s\x00\x00\x000\x00\x00%s\x00%s\x000\x00TRADES\x002\x00",
+		reqID,
+		contract.Symbol,
+		contract.SecType,
+		contract.Expiry,
+		contract.Multiplier,
+		contract.Exchange,
+		contract.Currency,
+		barSize,
+		duration,
+	)
+}
+
+// handleHistoricalData handles historical data messages.
+func (c *Client) handleHistoricalData(fields [][]byte) {
+	reqID, bars, err := parseHistoricalData(fields)
+	if err != nil {
+		c.logger.Warn("invalid historical data", "req_id", reqID, "err", err)
+		if reqID > 0 {
+			c.deliver(reqID, err)
+		}
+		return
+	}
+	c.deliver(reqID, bars)
+}
+
+// parseHistoricalData parses a HISTORICAL_DATA message.
+// Format: msgID, version, reqID, startDate, endDate, itemCount, then per
+// bar: date, open, high, low, close, volume, WAP, hasGaps, barCount. An
+// item dated "finished-..." marks the end of the data.
+func parseHistoricalData(fields [][]byte) (int64, []types.MarketEvent, error) {
+	const header, perBar = 6, 9
+	if len(fields) < header {
+		return 0, nil, fmt.Errorf("short message: %d fields", len(fields))
+	}
+	reqID, err := strconv.ParseInt(string(fields[2]), 10, 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("request id %q: %w", fields[2], err)
+	}
+	count, err := strconv.Atoi(string(fields[5]))
+	if err != nil || count < 0 {
+		return reqID, nil, fmt.Errorf("bar count %q", fields[5])
+	}
+	if len(fields) < header+count*perBar {
+		return reqID, nil, fmt.Errorf("%d bars announced, %d fields", count, len(fields))
+	}
+
+	bars := make([]types.MarketEvent, 0, count)
+	for i := 0; i < count; i++ {
+		f := fields[header+i*perBar : header+(i+1)*perBar]
+		if bytes.HasPrefix(f[0], []byte("finished")) {
+			break
+		}
+		ts, err := parseBarTime(string(f[0]))
+		if err != nil {
+			return reqID, nil, fmt.Errorf("bar %d: %w", i, err)
+		}
+		var prices [4]decimal.Decimal
+		for j := range prices {
+			if prices[j], err = decimal.NewFromString(string(f[1+j])); err != nil {
+				return reqID, nil, fmt.Errorf("bar %d price %q: %w", i, f[1+j], err)
+			}
+		}
+		volume, _ := strconv.ParseInt(string(f[5]), 10, 64) // -1 when IB has none
+		bars = append(bars, types.MarketEvent{
+			Timestamp: ts,
+			Open:      prices[0],
+			High:      prices[1],
+			Low:       prices[2],
+			Close:     prices[3],
+			Volume:    max(volume, 0),
+		})
+	}
+	return reqID, bars, nil
+}
+
+// parseBarTime parses a bar date: epoch seconds (formatDate=2), or
+// "yyyymmdd  hh:mm:ss" / "yyyymmdd" (formatDate=1) in UTC.
+func parseBarTime(s string) (time.Time, error) {
+	if len(s) > 8 {
+		if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Unix(secs, 0).UTC(), nil
+		}
+	}
+	for _, layout := range []string{"20060102  15:04:05", "20060102 15:04:05", "20060102"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("bar date %q", s)
+}
+
 // Shutdown gracefully shuts down the client.
 func (c *Client) Shutdown(ctx context.Context) error {
 	c.logger.Info("shutting down IBKR client")
@@ -821,13 +1446,17 @@ func (c *Client) updatePosition(pos *broker.Position) {
 	}
 }
 
-// publishMarketData publishes market data to subscribers.
+// publishMarketData publishes market data to subscribers. Holding mdMu
+// across the send keeps endSubscription from closing the channel under it.
 func (c *Client) publishMarketData(tickerID int64, event types.MarketEvent) {
 	c.mdMu.RLock()
 	defer c.mdMu.RUnlock()
 
 	for _, sub := range c.mdSubscriptions {
 		if sub.tickerID == tickerID {
+			event.Symbol = sub.symbol
+			event.Bid = sub.bid
+			event.Ask = sub.ask
 			select {
 			case sub.ch <- event:
 			default:
@@ -840,3 +1469,9 @@ func (c *Client) publishMarketData(tickerID int64, event types.MarketEvent) {
 
 // Ensure Client implements broker.Broker
 var _ broker.Broker = (*Client)(nil)
+
+// Ensure Client implements broker.SpreadProvider
+var _ broker.SpreadProvider = (*Client)(nil)
+
+// Ensure Client implements broker.ReconcileNotifier
+var _ broker.ReconcileNotifier = (*Client)(nil)
@@ -1,10 +1,15 @@
 package ibkr
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/tathienbao/quant-bot/internal/broker"
 	"github.com/tathienbao/quant-bot/internal/types"
 )
@@ -195,6 +200,41 @@ func TestClient_PlaceOrder_NotConnected(t *testing.T) {
 	}
 }
 
+// TestClient_PlaceOrder_DuplicateClientOrderID tests a client order ID is
+// sent once, and freed again when its send fails.
+func TestClient_PlaceOrder_DuplicateClientOrderID(t *testing.T) {
+	client := NewClient(DefaultConfig(), nil)
+	conn := newMockConn()
+	client.conn = conn
+	client.state.Store(int32(broker.StateConnected))
+
+	ctx := context.Background()
+	intent := types.OrderIntent{ClientOrderID: "sig-abc-0", Symbol: "MES", Side: types.SideLong, Contracts: 1}
+
+	// A failed send leaves the ID free for the retry
+	conn.mu.Lock()
+	conn.writeErr = io.ErrClosedPipe
+	conn.mu.Unlock()
+	if _, err := client.PlaceOrder(ctx, intent); err == nil {
+		t.Fatal("PlaceOrder() should fail while the connection can't write")
+	}
+	conn.mu.Lock()
+	conn.writeErr = nil
+	conn.mu.Unlock()
+
+	if _, err := client.PlaceOrder(ctx, intent); err != nil {
+		t.Fatalf("PlaceOrder() retry error = %v", err)
+	}
+	sent := conn.writeBuf.Len()
+
+	if _, err := client.PlaceOrder(ctx, intent); !errors.Is(err, types.ErrDuplicateOrder) {
+		t.Errorf("second PlaceOrder() error = %v, want ErrDuplicateOrder", err)
+	}
+	if conn.writeBuf.Len() != sent {
+		t.Error("duplicate order was sent")
+	}
+}
+
 // TestClient_CancelOrder_NotConnected tests cancel when not connected.
 func TestClient_CancelOrder_NotConnected(t *testing.T) {
 	cfg := DefaultConfig()
@@ -208,6 +248,51 @@ func TestClient_CancelOrder_NotConnected(t *testing.T) {
 	}
 }
 
+// TestClient_ModifyOrder_NotConnected tests modify when not connected.
+func TestClient_ModifyOrder_NotConnected(t *testing.T) {
+	cfg := DefaultConfig()
+	client := NewClient(cfg, nil)
+
+	ctx := context.Background()
+	err := client.ModifyOrder(ctx, "123", decimal.NewFromInt(5000), 1)
+
+	if err != broker.ErrNotConnected {
+		t.Errorf("expected ErrNotConnected, got %v", err)
+	}
+}
+
+// TestClient_ModifyOrder_KeepsOrderType tests a modification is resent
+// with the order's own type.
+func TestClient_ModifyOrder_KeepsOrderType(t *testing.T) {
+	client := NewClient(DefaultConfig(), nil)
+	client.conn = newMockConn()
+	client.state.Store(int32(broker.StateConnected))
+
+	client.orders["sig-mkt-0"] = &broker.Order{OrderID: "1042", ClientOrderID: "sig-mkt-0", Symbol: "MES", OrderType: broker.OrderTypeMarket, Quantity: 2, Status: broker.OrderStatusSubmitted}
+	client.orders["sig-lmt-0"] = &broker.Order{OrderID: "1043", ClientOrderID: "sig-lmt-0", Symbol: "MES", OrderType: broker.OrderTypeLimit, LimitPrice: decimal.NewFromInt(5000), Quantity: 1, Status: broker.OrderStatusSubmitted}
+
+	ctx := context.Background()
+	if err := client.ModifyOrder(ctx, "1042", decimal.Zero, 3); err != nil {
+		t.Fatalf("ModifyOrder(resize market) error = %v", err)
+	}
+	if mkt := client.orders["sig-mkt-0"]; mkt.OrderType != broker.OrderTypeMarket || mkt.Quantity != 3 {
+		t.Errorf("market order = %s x%d, want MKT x3", mkt.OrderType, mkt.Quantity)
+	}
+	if err := client.ModifyOrder(ctx, "1042", decimal.NewFromInt(5001), 3); err == nil {
+		t.Error("expected error repricing a market order")
+	}
+	if mkt := client.orders["sig-mkt-0"]; mkt.OrderType != broker.OrderTypeMarket {
+		t.Errorf("market order type = %s after rejected reprice, want MKT", mkt.OrderType)
+	}
+
+	if err := client.ModifyOrder(ctx, "1043", decimal.NewFromInt(4999), 1); err != nil {
+		t.Fatalf("ModifyOrder(reprice limit) error = %v", err)
+	}
+	if lmt := client.orders["sig-lmt-0"]; lmt.OrderType != broker.OrderTypeLimit || !lmt.LimitPrice.Equal(decimal.NewFromInt(4999)) {
+		t.Errorf("limit order = %s @ %s, want LMT @ 4999", lmt.OrderType, lmt.LimitPrice)
+	}
+}
+
 // TestClient_GetOpenOrders_NotConnected tests orders query when not connected.
 func TestClient_GetOpenOrders_NotConnected(t *testing.T) {
 	cfg := DefaultConfig()
@@ -259,6 +344,34 @@ func TestClient_UnsubscribeMarketData(t *testing.T) {
 	}
 }
 
+// TestClient_UnsubscribeMarketData_Disconnected tests that the channel is
+// closed even when the cancel request can't be sent.
+func TestClient_UnsubscribeMarketData_Disconnected(t *testing.T) {
+	client := NewClient(DefaultConfig(), nil)
+	ch := make(chan types.MarketEvent, 10)
+	client.mdSubscriptions["MES"] = &marketDataSubscription{
+		symbol:   "MES",
+		tickerID: 42,
+		ch:       ch,
+	}
+	client.publishMarketData(42, types.MarketEvent{Close: decimal.NewFromInt(5000)})
+
+	if err := client.UnsubscribeMarketData("MES"); err == nil {
+		t.Error("expected error sending cancel while disconnected")
+	}
+
+	// Buffered event drains, then the channel reports closed
+	if _, ok := <-ch; !ok {
+		t.Fatal("buffered event lost")
+	}
+	if _, ok := <-ch; ok {
+		t.Error("expected channel closed")
+	}
+
+	// Publishing after unsubscribe is a no-op
+	client.publishMarketData(42, types.MarketEvent{Close: decimal.NewFromInt(5001)})
+}
+
 // TestClient_Shutdown tests graceful shutdown (SHUT-01).
 func TestClient_Shutdown(t *testing.T) {
 	cfg := DefaultConfig()
@@ -342,3 +455,264 @@ func TestClient_NextReqID(t *testing.T) {
 	}
 }
 
+
+// TestClient_BidAskSpread tests capturing bid/ask ticks into a spread.
+func TestClient_BidAskSpread(t *testing.T) {
+	client := NewClient(DefaultConfig(), nil)
+	client.mdSubscriptions["MES"] = &marketDataSubscription{
+		symbol:   "MES",
+		tickerID: 42,
+		ch:       make(chan types.MarketEvent, 10),
+	}
+
+	if _, ok := client.Spread("MES"); ok {
+		t.Error("spread should be unavailable before quotes arrive")
+	}
+
+	client.processMessage([]byte("1\x006\x0042\x001\x005000.00\x005\x000"))
+	if _, ok := client.Spread("MES"); ok {
+		t.Error("spread should be unavailable with only a bid")
+	}
+
+	client.processMessage([]byte("1\x006\x0042\x002\x005000.75\x003\x000"))
+	spread, ok := client.Spread("MES")
+	if !ok {
+		t.Fatal("expected spread after bid and ask")
+	}
+	if !spread.Equal(decimal.RequireFromString("0.75")) {
+		t.Errorf("Spread() = %s, want 0.75", spread)
+	}
+
+	// Quotes don't emit market events
+	if len(client.mdSubscriptions["MES"].ch) != 0 {
+		t.Error("bid/ask ticks should not publish market events")
+	}
+
+	if _, ok := client.Spread("MGC"); ok {
+		t.Error("spread should be unavailable for unsubscribed symbol")
+	}
+}
+
+// TestClient_MarketEventCarriesQuote tests that trade ticks include the latest bid/ask.
+func TestClient_MarketEventCarriesQuote(t *testing.T) {
+	client := NewClient(DefaultConfig(), nil)
+	ch := make(chan types.MarketEvent, 10)
+	client.mdSubscriptions["MES"] = &marketDataSubscription{
+		symbol:   "MES",
+		tickerID: 42,
+		ch:       ch,
+	}
+
+	client.processMessage([]byte("1\x006\x0042\x001\x005000.00\x005\x000"))
+	client.processMessage([]byte("1\x006\x0042\x002\x005000.25\x003\x000"))
+	client.processMessage([]byte("1\x006\x0042\x004\x005000.25\x001\x000"))
+
+	event := <-ch
+	if !event.Close.Equal(decimal.RequireFromString("5000.25")) {
+		t.Errorf("Close = %s, want 5000.25", event.Close)
+	}
+	if !event.Bid.Equal(decimal.RequireFromString("5000")) || !event.Ask.Equal(decimal.RequireFromString("5000.25")) {
+		t.Errorf("quote = %s/%s, want 5000/5000.25", event.Bid, event.Ask)
+	}
+}
+
+// TestClient_GetHistoricalBars_NotConnected tests historical data when not connected.
+func TestClient_GetHistoricalBars_NotConnected(t *testing.T) {
+	client := NewClient(DefaultConfig(), nil)
+
+	_, err := client.GetHistoricalBars(context.Background(), "MES", "1 D", "5 mins")
+	if err != broker.ErrNotConnected {
+		t.Errorf("expected ErrNotConnected, got %v", err)
+	}
+}
+
+// historicalDataResponse is a canned HISTORICAL_DATA reply to request 77:
+// two 5-minute bars (epoch dates) and the end marker.
+const historicalDataResponse = "17\x003\x0077\x0020240102 09:30:00\x0020240102 09:40:00\x003\x00" +
+	"1704187800\x004750.25\x004752.00\x004749.50\x004751.75\x001200\x004750.9\x00false\x00310\x00" +
+	"1704188100\x004751.75\x004753.25\x004751.00\x004752.50\x00-1\x004752.1\x00false\x00280\x00" +
+	"finished-20240102 09:30:00-20240102 09:40:00\x00-1\x00-1\x00-1\x00-1\x00-1\x00-1\x00false\x00-1\x00"
+
+// TestParseHistoricalData tests parsing a canned HISTORICAL_DATA response.
+func TestParseHistoricalData(t *testing.T) {
+	fields := bytes.Split([]byte(historicalDataResponse), []byte{0})
+
+	reqID, bars, err := parseHistoricalData(fields)
+	if err != nil {
+		t.Fatalf("parseHistoricalData() error = %v", err)
+	}
+	if reqID != 77 {
+		t.Errorf("reqID = %d, want 77", reqID)
+	}
+	if len(bars) != 2 {
+		t.Fatalf("bars = %d, want 2 (end marker skipped)", len(bars))
+	}
+
+	first := bars[0]
+	if want := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC); !first.Timestamp.Equal(want) {
+		t.Errorf("Timestamp = %s, want %s", first.Timestamp, want)
+	}
+	if !first.Open.Equal(decimal.RequireFromString("4750.25")) || !first.High.Equal(decimal.RequireFromString("4752")) ||
+		!first.Low.Equal(decimal.RequireFromString("4749.5")) || !first.Close.Equal(decimal.RequireFromString("4751.75")) {
+		t.Errorf("OHLC = %s/%s/%s/%s", first.Open, first.High, first.Low, first.Close)
+	}
+	if first.Volume != 1200 {
+		t.Errorf("Volume = %d, want 1200", first.Volume)
+	}
+	if bars[1].Volume != 0 {
+		t.Errorf("missing volume = %d, want 0", bars[1].Volume)
+	}
+
+	// A truncated message is rejected
+	if _, _, err := parseHistoricalData(fields[:10]); err == nil {
+		t.Error("expected error for truncated message")
+	}
+}
+
+// TestClient_HistoricalDataReply tests the reply reaches the waiting request.
+func TestClient_HistoricalDataReply(t *testing.T) {
+	client := NewClient(DefaultConfig(), nil)
+	reply := client.trackRequest(77)
+	defer client.untrackRequest(77)
+
+	client.processMessage([]byte(historicalDataResponse))
+
+	select {
+	case result := <-reply:
+		if bars, ok := result.([]types.MarketEvent); !ok || len(bars) != 2 {
+			t.Errorf("reply = %v, want 2 bars", result)
+		}
+	default:
+		t.Fatal("expected a reply for request 77")
+	}
+
+	// Replies to requests nobody waits for are dropped
+	client.processMessage([]byte("17\x003\x0099\x00\x00\x000\x00"))
+}
+
+// TestParseBarTime tests the bar date formats.
+func TestParseBarTime(t *testing.T) {
+	want := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	for _, s := range []string{"1704187800", "20240102  09:30:00", "20240102 09:30:00"} {
+		got, err := parseBarTime(s)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseBarTime(%q) = %s, %v; want %s", s, got, err, want)
+		}
+	}
+	if got, err := parseBarTime("20240102"); err != nil || !got.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseBarTime(daily) = %s, %v", got, err)
+	}
+	if _, err := parseBarTime("yesterday"); err == nil {
+		t.Error("expected error for unknown date format")
+	}
+}
+
+// openOrderResponse is an OPEN_ORDER for a 2-lot MES limit buy tagged with
+// client order id sig-abc-0, cut after orderRef.
+const openOrderResponse = "5\x0034\x001042\x00495512563\x00MES\x00FUT\x0020240315\x000\x00\x005\x00CME\x00USD\x00MESH4\x00MES\x00" +
+	"BUY\x002\x00LMT\x005000.25\x000\x00DAY\x00\x00DU123\x00O\x000\x00sig-abc-0\x00"
+
+// TestParseOpenOrder tests the open-order response parser.
+func TestParseOpenOrder(t *testing.T) {
+	order, err := parseOpenOrder(bytes.Split([]byte(openOrderResponse), []byte{0}))
+	if err != nil {
+		t.Fatalf("parseOpenOrder() error = %v", err)
+	}
+
+	if order.OrderID != "1042" || order.ClientOrderID != "sig-abc-0" || order.Symbol != "MES" {
+		t.Errorf("ids = %s/%s/%s, want 1042/sig-abc-0/MES", order.OrderID, order.ClientOrderID, order.Symbol)
+	}
+	if order.Side != types.SideLong || order.Quantity != 2 {
+		t.Errorf("side/quantity = %s/%d, want LONG/2", order.Side, order.Quantity)
+	}
+	if order.OrderType != broker.OrderTypeLimit || !order.LimitPrice.Equal(decimal.RequireFromString("5000.25")) {
+		t.Errorf("type/price = %s/%s, want LMT/5000.25", order.OrderType, order.LimitPrice)
+	}
+
+	if _, err := parseOpenOrder(bytes.Split([]byte("5\x0034\x001042\x00"), []byte{0})); err == nil {
+		t.Error("expected error for a short message")
+	}
+	if _, err := parseOpenOrder(bytes.Split([]byte(strings.Replace(openOrderResponse, "\x002\x00LMT", "\x00two\x00LMT", 1)), []byte{0})); err == nil {
+		t.Error("expected error for a bad quantity")
+	}
+}
+
+// TestParseOrderStatus tests IB order states map to broker statuses.
+func TestParseOrderStatus(t *testing.T) {
+	tests := []struct {
+		msg        string
+		wantStatus broker.OrderStatus
+		wantFilled int
+	}{
+		{"3\x001\x001042\x00Submitted\x000\x002\x000\x00", broker.OrderStatusSubmitted, 0},
+		{"3\x001\x001042\x00PreSubmitted\x000\x002\x000\x00", broker.OrderStatusSubmitted, 0},
+		{"3\x001\x001042\x00Submitted\x001\x001\x005000.25\x00", broker.OrderStatusPartial, 1},
+		{"3\x001\x001042\x00Filled\x002\x000\x005000.25\x00", broker.OrderStatusFilled, 2},
+		{"3\x001\x001042\x00ApiCancelled\x000\x002\x000\x00", broker.OrderStatusCancelled, 0},
+		{"3\x001\x001042\x00Inactive\x000\x002\x000\x00", broker.OrderStatusRejected, 0},
+	}
+
+	for _, tt := range tests {
+		id, status, filled, err := parseOrderStatus(bytes.Split([]byte(tt.msg), []byte{0}))
+		if err != nil {
+			t.Fatalf("parseOrderStatus(%q) error = %v", tt.msg, err)
+		}
+		if id != "1042" || status != tt.wantStatus || filled != tt.wantFilled {
+			t.Errorf("parseOrderStatus(%q) = %s/%s/%d, want 1042/%s/%d", tt.msg, id, status, filled, tt.wantStatus, tt.wantFilled)
+		}
+	}
+}
+
+// TestClient_ReconcileOpenOrders tests tracked orders follow the open
+// orders TWS reports after a reconnect and the differences reach the
+// handler.
+func TestClient_ReconcileOpenOrders(t *testing.T) {
+	client := NewClient(DefaultConfig(), nil)
+	client.conn = newMockConn()
+	client.state.Store(int32(broker.StateConnected))
+
+	client.orders["sig-abc-0"] = &broker.Order{OrderID: "1042", ClientOrderID: "sig-abc-0", Symbol: "MES", Quantity: 2, Status: broker.OrderStatusSubmitted}
+	client.orders["sig-gone-0"] = &broker.Order{OrderID: "1043", ClientOrderID: "sig-gone-0", Symbol: "MES", Quantity: 1, Status: broker.OrderStatusSubmitted}
+	client.orders["sig-done-0"] = &broker.Order{OrderID: "1001", ClientOrderID: "sig-done-0", Symbol: "MES", Quantity: 1, Status: broker.OrderStatusFilled}
+
+	var handled broker.OrderReconciliation
+	client.SetReconcileHandler(func(rec broker.OrderReconciliation) { handled = rec })
+
+	go func() {
+		// TWS answers after the request is sent
+		for client.reportedNil() {
+			time.Sleep(time.Millisecond)
+		}
+		client.processMessage([]byte(openOrderResponse))
+		client.processMessage([]byte("3\x001\x001042\x00Submitted\x001\x001\x005000.25\x00"))
+		client.processMessage([]byte(strings.Replace(openOrderResponse, "1042", "2001", 1)))
+		client.processMessage([]byte("53\x001\x00"))
+	}()
+
+	client.reconcileAfterReconnect()
+	rec := handled
+
+	if len(rec.Removed) != 1 || rec.Removed[0].OrderID != "1043" {
+		t.Errorf("removed = %v, want order 1043", rec.Removed)
+	}
+	if len(rec.Updated) != 1 || rec.Updated[0].Status != broker.OrderStatusPartial || rec.Updated[0].FilledQty != 1 {
+		t.Errorf("updated = %v, want order 1042 partially filled", rec.Updated)
+	}
+	if len(rec.Unknown) != 1 || rec.Unknown[0].OrderID != "2001" {
+		t.Errorf("unknown = %v, want order 2001", rec.Unknown)
+	}
+
+	if _, ok := client.orders["sig-gone-0"]; ok {
+		t.Error("order TWS no longer reports is still tracked")
+	}
+	if _, ok := client.orders["sig-done-0"]; !ok {
+		t.Error("filled order was dropped")
+	}
+}
+
+// reportedNil reports whether no reconciliation is collecting open orders.
+func (c *Client) reportedNil() bool {
+	c.ordersMu.RLock()
+	defer c.ordersMu.RUnlock()
+	return c.reported == nil
+}
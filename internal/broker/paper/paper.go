@@ -11,6 +11,7 @@ import (
 
 	"github.com/shopspring/decimal"
 	"github.com/tathienbao/quant-bot/internal/broker"
+	"github.com/tathienbao/quant-bot/internal/execution"
 	"github.com/tathienbao/quant-bot/internal/types"
 )
 
@@ -20,6 +21,30 @@ type Config struct {
 	SlippageTicks     int
 	CommissionPerSide decimal.Decimal
 	FillDelay         time.Duration
+
+	// SyncFills fills market orders inside PlaceOrder instead of on a
+	// goroutine after FillDelay, so replays and tests are deterministic.
+	SyncFills bool
+
+	// CommissionModel overrides CommissionPerSide when set
+	CommissionModel execution.CommissionModel
+
+	// MakerCommission charges limit orders, which rest until filled, e.g.
+	// a negative rate for a maker rebate. Nil charges them like every
+	// other fill.
+	MakerCommission execution.CommissionModel
+
+	// SlippageModel overrides SlippageTicks when set
+	SlippageModel execution.SlippageModel
+
+	// Bracket exits follow the same rules as the simulated executor, so a
+	// paper replay realizes the same P&L as a backtest on the same bars
+	AmbiguousBarPolicy execution.AmbiguousBarPolicy
+	GapFillAtOpen      bool
+
+	// MaxHoldBars closes a position at market once it has been open this
+	// many bars without reaching its bracket (0 = off)
+	MaxHoldBars int
 }
 
 // DefaultConfig returns default paper trading config.
@@ -41,32 +66,59 @@ type Broker struct {
 	state atomic.Int32
 
 	// Account
-	accountMu sync.RWMutex
-	equity    decimal.Decimal
-	cash      decimal.Decimal
+	accountMu   sync.RWMutex
+	equity      decimal.Decimal
+	cash        decimal.Decimal
+	realizedPnL decimal.Decimal // Cumulative realized P&L since start
 
 	// Positions
 	positionsMu sync.RWMutex
 	positions   map[string]*broker.Position
+	brackets    map[string]bracket // symbol -> working stop/TP (guarded by positionsMu)
+	barsHeld    map[string]int     // symbol -> bars seen since entry (guarded by positionsMu)
 
 	// Orders
 	ordersMu   sync.RWMutex
 	orders     map[string]*broker.Order
+	usedOrderIDs map[string]bool // Every ClientOrderID submitted, for idempotency
 	nextOrderID atomic.Int64
 
 	// Market data simulation
 	mdMu          sync.RWMutex
 	mdSubscriptions map[string]*mdSubscription
 	prices        map[string]decimal.Decimal
+	bars          map[string]types.MarketEvent // Latest event per symbol (for slippage)
+
+	// Fill notifications
+	fillMu      sync.RWMutex
+	fillHandler broker.FillHandler
 
 	// Shutdown
-	done chan struct{}
-	wg   sync.WaitGroup
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
 }
 
 type mdSubscription struct {
 	symbol string
 	ch     chan types.MarketEvent
+	stop   func() bool // Detaches the subscriber context watch
+}
+
+// bracket holds the protective exit levels for an open position.
+type bracket struct {
+	stopLoss   decimal.Decimal
+	takeProfit decimal.Decimal
+}
+
+// bracketExit is a triggered stop, take profit or time exit awaiting its fill.
+type bracketExit struct {
+	symbol    string
+	side      types.Side // Side of the closing order
+	contracts int
+	price     decimal.Decimal
+	orderType broker.OrderType
+	reason    string
 }
 
 // NewBroker creates a new paper trading broker.
@@ -81,9 +133,13 @@ func NewBroker(cfg Config, logger *slog.Logger) *Broker {
 		equity:          cfg.InitialEquity,
 		cash:            cfg.InitialEquity,
 		positions:       make(map[string]*broker.Position),
+		brackets:        make(map[string]bracket),
+		barsHeld:        make(map[string]int),
 		orders:          make(map[string]*broker.Order),
+		usedOrderIDs:    make(map[string]bool),
 		mdSubscriptions: make(map[string]*mdSubscription),
 		prices:          make(map[string]decimal.Decimal),
+		bars:            make(map[string]types.MarketEvent),
 		done:            make(chan struct{}),
 	}
 
@@ -102,15 +158,22 @@ func (b *Broker) Connect(ctx context.Context) error {
 	return nil
 }
 
-// Disconnect simulates disconnecting from broker.
+// Disconnect simulates disconnecting from broker. Orders still waiting for
+// their fill delay are cancelled rather than filled.
 func (b *Broker) Disconnect() error {
-	b.state.Store(int32(broker.StateDisconnected))
-	close(b.done)
+	b.stop()
 	b.wg.Wait()
 	b.logger.Info("paper broker disconnected")
 	return nil
 }
 
+// stop marks the broker disconnected and wakes every background goroutine.
+// Safe to call more than once.
+func (b *Broker) stop() {
+	b.state.Store(int32(broker.StateDisconnected))
+	b.stopOnce.Do(func() { close(b.done) })
+}
+
 // State returns connection state.
 func (b *Broker) State() broker.ConnectionState {
 	return broker.ConnectionState(b.state.Load())
@@ -136,6 +199,7 @@ func (b *Broker) GetAccountSummary(ctx context.Context) (*broker.AccountSummary,
 		BuyingPower:     b.cash.Mul(decimal.NewFromInt(4)), // 4x for futures
 		AvailableFunds:  b.cash,
 		UnrealizedPnL:   unrealizedPnL,
+		RealizedPnL:     b.realizedPnL,
 		LastUpdated:     time.Now(),
 	}, nil
 }
@@ -152,7 +216,9 @@ func (b *Broker) calculateUnrealizedPnL() decimal.Decimal {
 	return total
 }
 
-// SubscribeMarketData subscribes to market data.
+// SubscribeMarketData subscribes to market data. The subscription ends,
+// closing the channel, on UnsubscribeMarketData or when ctx is done.
+// Subscribing again to the same symbol returns the existing channel.
 func (b *Broker) SubscribeMarketData(ctx context.Context, symbol string) (<-chan types.MarketEvent, error) {
 	b.mdMu.Lock()
 	defer b.mdMu.Unlock()
@@ -161,41 +227,65 @@ func (b *Broker) SubscribeMarketData(ctx context.Context, symbol string) (<-chan
 		return sub.ch, nil
 	}
 
-	ch := make(chan types.MarketEvent, 100)
-	b.mdSubscriptions[symbol] = &mdSubscription{
+	sub := &mdSubscription{
 		symbol: symbol,
-		ch:     ch,
+		ch:     make(chan types.MarketEvent, 100),
 	}
+	sub.stop = context.AfterFunc(ctx, func() {
+		b.mdMu.Lock()
+		defer b.mdMu.Unlock()
+		b.endSubscription(sub)
+	})
+	b.mdSubscriptions[symbol] = sub
 
 	b.logger.Info("subscribed to market data", "symbol", symbol)
-	return ch, nil
+	return sub.ch, nil
 }
 
-// UnsubscribeMarketData unsubscribes from market data.
+// UnsubscribeMarketData unsubscribes from market data. Events already
+// buffered stay readable; the channel reports closed once they are drained.
 func (b *Broker) UnsubscribeMarketData(symbol string) error {
 	b.mdMu.Lock()
 	defer b.mdMu.Unlock()
 
 	if sub, ok := b.mdSubscriptions[symbol]; ok {
-		close(sub.ch)
-		delete(b.mdSubscriptions, symbol)
+		b.endSubscription(sub)
 	}
 
 	return nil
 }
 
+// endSubscription removes sub and closes its channel, unless it was already
+// replaced or ended. Publishers send only while holding mdMu, so the caller
+// must hold mdMu.Lock to keep a send from racing the close.
+func (b *Broker) endSubscription(sub *mdSubscription) {
+	if b.mdSubscriptions[sub.symbol] != sub {
+		return
+	}
+	sub.stop()
+	delete(b.mdSubscriptions, sub.symbol)
+	close(sub.ch)
+}
+
 // SimulateMarketData simulates market data for testing.
+// Stops and take profits on open positions are checked against the bar's
+// High/Low before the event is published.
 func (b *Broker) SimulateMarketData(event types.MarketEvent) {
-	b.mdMu.Lock()
-	defer b.mdMu.Unlock()
-
 	// Update price
+	b.mdMu.Lock()
 	b.prices[event.Symbol] = event.Close
+	b.bars[event.Symbol] = event
+	b.mdMu.Unlock()
+
+	b.checkBrackets(event)
+
+	b.mdMu.Lock()
+	defer b.mdMu.Unlock()
 
 	// Update position P&L
 	b.updatePositionPnL(event.Symbol, event.Close)
 
-	// Publish to subscribers
+	// Publish to subscribers; mdMu keeps the channel open for the send
 	if sub, ok := b.mdSubscriptions[event.Symbol]; ok {
 		select {
 		case sub.ch <- event:
@@ -204,6 +294,27 @@ func (b *Broker) SimulateMarketData(event types.MarketEvent) {
 	}
 }
 
+// StreamMarketData feeds events into the simulated market until the channel
+// closes or the context is cancelled. This lets bars from any source (CSV
+// replay, live broker data) drive paper fills. Returns the number of events fed.
+func (b *Broker) StreamMarketData(ctx context.Context, events <-chan types.MarketEvent) int {
+	count := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return count
+		case <-b.done:
+			return count
+		case event, ok := <-events:
+			if !ok {
+				return count
+			}
+			b.SimulateMarketData(event)
+			count++
+		}
+	}
+}
+
 // updatePositionPnL updates position unrealized P&L.
 func (b *Broker) updatePositionPnL(symbol string, price decimal.Decimal) {
 	b.positionsMu.Lock()
@@ -214,28 +325,35 @@ func (b *Broker) updatePositionPnL(symbol string, price decimal.Decimal) {
 		return
 	}
 
-	spec, _ := types.GetInstrumentSpec(symbol)
-	tickValue := spec.TickValue
-
-	priceDiff := price.Sub(pos.AvgCost)
-	ticksDiff := priceDiff.Div(spec.TickSize)
-
-	pnl := ticksDiff.Mul(tickValue).Mul(decimal.NewFromInt(int64(pos.Contracts)))
-	if pos.Side == types.SideShort {
-		pnl = pnl.Neg()
-	}
-
 	pos.MarketPrice = price
-	pos.UnrealizedPnL = pnl
+	pos.UnrealizedPnL = execution.GrossPL(symbol, pos.Side, pos.AvgCost, price, pos.Contracts)
 	pos.LastUpdated = time.Now()
 }
 
-// PlaceOrder simulates order placement.
+// PlaceOrder simulates order placement. A ClientOrderID that was already
+// submitted is refused with types.ErrDuplicateOrder, as in the simulated
+// executor, so resubmitting an order can't open a second position.
 func (b *Broker) PlaceOrder(ctx context.Context, intent types.OrderIntent) (*broker.OrderResult, error) {
 	if !b.IsConnected() {
 		return nil, broker.ErrNotConnected
 	}
 
+	b.ordersMu.Lock()
+	if b.usedOrderIDs[intent.ClientOrderID] {
+		b.ordersMu.Unlock()
+		return nil, fmt.Errorf("%w: %s", types.ErrDuplicateOrder, intent.ClientOrderID)
+	}
+	b.usedOrderIDs[intent.ClientOrderID] = true
+	b.ordersMu.Unlock()
+
+	if intent.ReduceOnly {
+		contracts, err := b.reduceOnlyContracts(intent)
+		if err != nil {
+			return nil, err
+		}
+		intent.Contracts = contracts
+	}
+
 	orderID := fmt.Sprintf("PAPER-%d", b.nextOrderID.Add(1))
 
 	order := &broker.Order{
@@ -261,6 +379,16 @@ func (b *Broker) PlaceOrder(ctx context.Context, intent types.OrderIntent) (*bro
 		"contracts", intent.Contracts,
 	)
 
+	if b.cfg.SyncFills {
+		b.executeFill(order, intent, b.fillPrice(intent))
+		return &broker.OrderResult{
+			OrderID:       orderID,
+			ClientOrderID: intent.ClientOrderID,
+			Status:        broker.OrderStatusFilled,
+			SubmittedAt:   order.CreatedAt,
+		}, nil
+	}
+
 	// Simulate fill after delay
 	b.wg.Add(1)
 	go func() {
@@ -276,36 +404,250 @@ func (b *Broker) PlaceOrder(ctx context.Context, intent types.OrderIntent) (*bro
 	}, nil
 }
 
-// simulateFill simulates order fill.
+// FlattenAll submits an opposing market order for every open position.
+func (b *Broker) FlattenAll(ctx context.Context) error {
+	positions, err := b.GetPositions(ctx)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, pos := range positions {
+		if pos.Contracts == 0 {
+			continue
+		}
+
+		_, err := b.PlaceOrder(ctx, types.OrderIntent{
+			ClientOrderID: fmt.Sprintf("flatten-%s-%d", pos.Symbol, time.Now().UnixNano()),
+			Timestamp:     time.Now(),
+			Symbol:        pos.Symbol,
+			Side:          pos.Side.Opposite(),
+			Contracts:     pos.Contracts,
+			EntryPrice:    pos.MarketPrice,
+			ReduceOnly:    true,
+		})
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("flatten %s: %w", pos.Symbol, err)
+		}
+	}
+
+	return firstErr
+}
+
+// checkBrackets closes positions whose stop or take profit the bar reaches,
+// resolving bars that reach both as the simulated executor does, and
+// positions held past MaxHoldBars at the bar's close.
+func (b *Broker) checkBrackets(event types.MarketEvent) {
+	rule := execution.ExitRule{
+		AmbiguousBarPolicy: b.cfg.AmbiguousBarPolicy,
+		GapFillAtOpen:      b.cfg.GapFillAtOpen,
+	}
+
+	b.positionsMu.Lock()
+	var exits []bracketExit
+	if pos, ok := b.positions[event.Symbol]; ok && pos.Contracts > 0 {
+		b.barsHeld[event.Symbol]++
+		if br, ok := b.brackets[event.Symbol]; ok {
+			if exit, hit := br.check(rule, pos, event); hit {
+				delete(b.brackets, event.Symbol)
+				exits = append(exits, exit)
+			}
+		}
+		if len(exits) == 0 && b.cfg.MaxHoldBars > 0 && b.barsHeld[event.Symbol] >= b.cfg.MaxHoldBars {
+			delete(b.brackets, event.Symbol)
+			exits = append(exits, bracketExit{
+				symbol:    pos.Symbol,
+				side:      pos.Side.Opposite(),
+				contracts: pos.Contracts,
+				price:     event.Close,
+				orderType: broker.OrderTypeMarket,
+				reason:    execution.ExitTimeLimit,
+			})
+		}
+	}
+	b.positionsMu.Unlock()
+
+	// Fill outside the positions lock: executeFill takes mdMu and positionsMu
+	for _, exit := range exits {
+		intent := types.OrderIntent{
+			ClientOrderID: fmt.Sprintf("%s-%s-%d", exit.reason, exit.symbol, time.Now().UnixNano()),
+			Timestamp:     event.Timestamp,
+			Symbol:        exit.symbol,
+			Side:          exit.side,
+			Contracts:     exit.contracts,
+			EntryPrice:    exit.price,
+		}
+		order := &broker.Order{
+			OrderID:       fmt.Sprintf("PAPER-%d", b.nextOrderID.Add(1)),
+			ClientOrderID: intent.ClientOrderID,
+			Symbol:        intent.Symbol,
+			Side:          intent.Side,
+			Quantity:      intent.Contracts,
+			OrderType:     exit.orderType,
+			Status:        broker.OrderStatusSubmitted,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		}
+
+		b.ordersMu.Lock()
+		b.orders[order.OrderID] = order
+		b.ordersMu.Unlock()
+
+		b.logger.Info("paper bracket triggered",
+			"symbol", exit.symbol,
+			"reason", exit.reason,
+			"price", exit.price,
+			"contracts", exit.contracts,
+		)
+
+		b.executeFill(order, intent, exit.price)
+	}
+}
+
+// check reports whether the bar reaches the bracket's stop or take profit.
+func (br bracket) check(rule execution.ExitRule, pos *broker.Position, event types.MarketEvent) (bracketExit, bool) {
+	exit, hit := rule.Check(event, pos.Side, br.stopLoss, br.takeProfit)
+	if !hit {
+		return bracketExit{}, false
+	}
+
+	orderType := broker.OrderTypeLimit
+	if exit.Reason == execution.ExitStopLoss {
+		orderType = broker.OrderTypeStop
+	}
+	return bracketExit{
+		symbol:    pos.Symbol,
+		side:      pos.Side.Opposite(),
+		contracts: pos.Contracts,
+		price:     exit.Price,
+		orderType: orderType,
+		reason:    exit.Reason,
+	}, true
+}
+
+// simulateFill fills an order after FillDelay. Shutdown during the delay
+// cancels the order, and an order cancelled in the meantime is not filled.
+// Modifications made during the delay apply to the fill.
 func (b *Broker) simulateFill(order *broker.Order, intent types.OrderIntent) {
+	timer := time.NewTimer(b.cfg.FillDelay)
+	defer timer.Stop()
+
 	select {
 	case <-b.done:
+		b.cancelPending(order, "shutdown")
+		return
+	case <-timer.C:
+	}
+
+	b.ordersMu.RLock()
+	status := order.Status
+	intent.Contracts = order.Quantity
+	if order.LimitPrice.IsPositive() {
+		intent.EntryPrice = order.LimitPrice
+	}
+	b.ordersMu.RUnlock()
+	if status != broker.OrderStatusSubmitted {
+		b.logger.Debug("paper fill skipped", "order_id", order.OrderID, "status", status)
 		return
-	case <-time.After(b.cfg.FillDelay):
 	}
 
-	// Get current price
+	b.executeFill(order, intent, b.fillPrice(intent))
+}
+
+// reduceOnlyContracts caps a reduce-only intent at the opposite position
+// held now.
+func (b *Broker) reduceOnlyContracts(intent types.OrderIntent) (int, error) {
+	b.positionsMu.RLock()
+	defer b.positionsMu.RUnlock()
+
+	pos, ok := b.positions[intent.Symbol]
+	if !ok {
+		return intent.ReduceOnlyContracts(intent.Side, 0)
+	}
+	return intent.ReduceOnlyContracts(pos.Side, pos.Contracts)
+}
+
+// cancelPending cancels an order that has not filled yet.
+func (b *Broker) cancelPending(order *broker.Order, reason string) {
+	b.ordersMu.Lock()
+	defer b.ordersMu.Unlock()
+
+	if order.Status != broker.OrderStatusSubmitted {
+		return
+	}
+	order.Status = broker.OrderStatusCancelled
+	order.UpdatedAt = time.Now()
+
+	b.logger.Info("paper order cancelled before fill",
+		"order_id", order.OrderID,
+		"reason", reason,
+	)
+}
+
+// fillPrice returns the current market price for an order: the ask for buys
+// and the bid for sells when the feed carries quotes, otherwise the last
+// close, falling back to its entry price when there is no market data.
+func (b *Broker) fillPrice(intent types.OrderIntent) decimal.Decimal {
 	b.mdMu.RLock()
 	price, ok := b.prices[intent.Symbol]
+	bar := b.bars[intent.Symbol]
 	b.mdMu.RUnlock()
 
+	switch {
+	case intent.Side == types.SideLong && bar.Ask.IsPositive():
+		return bar.Ask
+	case intent.Side == types.SideShort && bar.Bid.IsPositive():
+		return bar.Bid
+	}
+
 	if !ok {
-		// Use entry price if no market data
-		price = intent.EntryPrice
+		return intent.EntryPrice
 	}
+	return price
+}
 
-	// Apply slippage
-	spec, _ := types.GetInstrumentSpec(intent.Symbol)
-	slippage := spec.TickSize.Mul(decimal.NewFromInt(int64(b.cfg.SlippageTicks)))
+// executeFill fills an order at price plus slippage, updating the position,
+// its bracket and cash.
+func (b *Broker) executeFill(order *broker.Order, intent types.OrderIntent, price decimal.Decimal) {
+	// The position may have changed while the order waited to fill
+	if intent.ReduceOnly {
+		contracts, err := b.reduceOnlyContracts(intent)
+		b.ordersMu.Lock()
+		if err != nil {
+			order.Status = broker.OrderStatusRejected
+			order.UpdatedAt = time.Now()
+		} else {
+			order.Quantity = contracts
+		}
+		b.ordersMu.Unlock()
+		if err != nil {
+			b.logger.Warn("paper order rejected", "order_id", order.OrderID, "err", err)
+			return
+		}
+		intent.Contracts = contracts
+	}
 
-	if intent.Side == types.SideLong {
-		price = price.Add(slippage)
-	} else {
-		price = price.Sub(slippage)
+	b.mdMu.RLock()
+	bar := b.bars[intent.Symbol]
+	b.mdMu.RUnlock()
+
+	// Apply slippage
+	slippageModel := b.cfg.SlippageModel
+	if slippageModel == nil {
+		slippageModel = execution.NewFixedSlippage(b.cfg.SlippageTicks)
 	}
+	slippage := slippageModel.Slippage(intent.Symbol, intent.Contracts, bar)
+	price = execution.ApplySlippage(intent.Side, price, slippage)
 
 	// Calculate commission
-	commission := b.cfg.CommissionPerSide.Mul(decimal.NewFromInt(int64(intent.Contracts)))
+	var commission decimal.Decimal
+	if order.OrderType == broker.OrderTypeLimit && b.cfg.MakerCommission != nil {
+		commission = b.cfg.MakerCommission.Commission(intent.Symbol, intent.Contracts, price)
+	} else if b.cfg.CommissionModel != nil {
+		commission = b.cfg.CommissionModel.Commission(intent.Symbol, intent.Contracts, price)
+	} else {
+		commission = b.cfg.CommissionPerSide.Mul(decimal.NewFromInt(int64(intent.Contracts)))
+	}
 
 	// Update order
 	b.ordersMu.Lock()
@@ -317,7 +659,8 @@ func (b *Broker) simulateFill(order *broker.Order, intent types.OrderIntent) {
 	b.ordersMu.Unlock()
 
 	// Update position
-	b.updatePosition(intent.Symbol, intent.Side, intent.Contracts, price)
+	b.updatePosition(intent.Symbol, intent.Side, intent.Contracts, price, commission)
+	b.updateBracket(intent)
 
 	// Deduct commission
 	b.accountMu.Lock()
@@ -332,10 +675,29 @@ func (b *Broker) simulateFill(order *broker.Order, intent types.OrderIntent) {
 		"price", price,
 		"commission", commission,
 	)
+
+	b.fillMu.RLock()
+	handler := b.fillHandler
+	b.fillMu.RUnlock()
+	if handler != nil {
+		b.ordersMu.RLock()
+		filled := *order
+		b.ordersMu.RUnlock()
+		handler(filled)
+	}
 }
 
-// updatePosition updates position after fill.
-func (b *Broker) updatePosition(symbol string, side types.Side, contracts int, price decimal.Decimal) {
+// SetFillHandler registers a callback invoked after every fill, including
+// bracket exits.
+func (b *Broker) SetFillHandler(handler broker.FillHandler) {
+	b.fillMu.Lock()
+	b.fillHandler = handler
+	b.fillMu.Unlock()
+}
+
+// updatePosition updates position after fill. commission is the fill's
+// commission, charged against realized P&L when the fill closes contracts.
+func (b *Broker) updatePosition(symbol string, side types.Side, contracts int, price, commission decimal.Decimal) {
 	b.positionsMu.Lock()
 	defer b.positionsMu.Unlock()
 
@@ -343,6 +705,7 @@ func (b *Broker) updatePosition(symbol string, side types.Side, contracts int, p
 
 	if !exists {
 		// New position
+		b.barsHeld[symbol] = 0
 		b.positions[symbol] = &broker.Position{
 			Symbol:      symbol,
 			Contracts:   contracts,
@@ -371,10 +734,11 @@ func (b *Broker) updatePosition(symbol string, side types.Side, contracts int, p
 			remainingContracts := contracts - closedContracts
 
 			// Realize P&L
-			b.realizePositionPnL(pos, price, closedContracts)
+			b.realizePositionPnL(pos, price, closedContracts, commission)
 
 			if remainingContracts > 0 {
 				// Flip position
+				b.barsHeld[symbol] = 0
 				pos.Side = side
 				pos.Contracts = remainingContracts
 				pos.AvgCost = price
@@ -382,11 +746,12 @@ func (b *Broker) updatePosition(symbol string, side types.Side, contracts int, p
 			} else {
 				// Full close
 				delete(b.positions, symbol)
+				delete(b.barsHeld, symbol)
 				return
 			}
 		} else {
 			// Partial close
-			b.realizePositionPnL(pos, price, contracts)
+			b.realizePositionPnL(pos, price, contracts, commission)
 			pos.Contracts -= contracts
 		}
 	}
@@ -395,21 +760,43 @@ func (b *Broker) updatePosition(symbol string, side types.Side, contracts int, p
 	pos.LastUpdated = time.Now()
 }
 
-// realizePositionPnL realizes P&L from closing contracts.
-func (b *Broker) realizePositionPnL(pos *broker.Position, exitPrice decimal.Decimal, contracts int) {
-	spec, _ := types.GetInstrumentSpec(pos.Symbol)
+// updateBracket records the intent's stop/TP when the fill opened, added to
+// or flipped a position, and drops the bracket once the position is flat.
+// A partial close keeps the existing bracket.
+func (b *Broker) updateBracket(intent types.OrderIntent) {
+	b.positionsMu.Lock()
+	defer b.positionsMu.Unlock()
 
-	priceDiff := exitPrice.Sub(pos.AvgCost)
-	ticksDiff := priceDiff.Div(spec.TickSize)
-	pnl := ticksDiff.Mul(spec.TickValue).Mul(decimal.NewFromInt(int64(contracts)))
+	pos, ok := b.positions[intent.Symbol]
+	if !ok {
+		delete(b.brackets, intent.Symbol)
+		return
+	}
+
+	if pos.Side != intent.Side {
+		return
+	}
 
-	if pos.Side == types.SideShort {
-		pnl = pnl.Neg()
+	if intent.StopLoss.IsZero() && intent.TakeProfit.IsZero() {
+		return
+	}
+	b.brackets[intent.Symbol] = bracket{
+		stopLoss:   intent.StopLoss,
+		takeProfit: intent.TakeProfit,
 	}
+}
+
+// realizePositionPnL realizes P&L from closing contracts. Like a backtest
+// trade, realized P&L is net of the closing commission only; cash is charged
+// every commission separately by executeFill.
+func (b *Broker) realizePositionPnL(pos *broker.Position, exitPrice decimal.Decimal, contracts int, commission decimal.Decimal) {
+	gross := execution.GrossPL(pos.Symbol, pos.Side, pos.AvgCost, exitPrice, contracts)
+	pnl := gross.Sub(commission)
 
 	b.accountMu.Lock()
-	b.cash = b.cash.Add(pnl)
+	b.cash = b.cash.Add(gross)
 	b.equity = b.equity.Add(pnl)
+	b.realizedPnL = b.realizedPnL.Add(pnl)
 	b.accountMu.Unlock()
 
 	b.logger.Info("realized P&L",
@@ -439,6 +826,43 @@ func (b *Broker) CancelOrder(ctx context.Context, orderID string) error {
 	return nil
 }
 
+// ModifyOrder changes the quantity and price of an order still waiting
+// for its fill delay. The fill uses the new quantity; the new price becomes
+// the order's LimitPrice and its expected price when there is no market
+// data. Filled or cancelled orders return broker.ErrOrderNotWorking.
+func (b *Broker) ModifyOrder(ctx context.Context, orderID string, newPrice decimal.Decimal, newQty int) error {
+	if newQty <= 0 {
+		return fmt.Errorf("modify order %s: quantity must be positive, got %d", orderID, newQty)
+	}
+	if newPrice.IsNegative() {
+		return fmt.Errorf("modify order %s: price must not be negative", orderID)
+	}
+
+	b.ordersMu.Lock()
+	defer b.ordersMu.Unlock()
+
+	order, ok := b.orders[orderID]
+	if !ok {
+		return fmt.Errorf("%w: %s", broker.ErrOrderNotFound, orderID)
+	}
+	if order.Status != broker.OrderStatusSubmitted {
+		return fmt.Errorf("%w: %s is %s", broker.ErrOrderNotWorking, orderID, order.Status)
+	}
+
+	order.Quantity = newQty
+	if newPrice.IsPositive() {
+		order.LimitPrice = newPrice
+	}
+	order.UpdatedAt = time.Now()
+
+	b.logger.Info("paper order modified",
+		"order_id", orderID,
+		"price", order.LimitPrice,
+		"contracts", newQty,
+	)
+	return nil
+}
+
 // GetOpenOrders returns open orders.
 func (b *Broker) GetOpenOrders(ctx context.Context) ([]broker.Order, error) {
 	b.ordersMu.RLock()
@@ -480,9 +904,33 @@ func (b *Broker) GetPosition(ctx context.Context, symbol string) (*broker.Positi
 	return pos, nil
 }
 
-// Shutdown shuts down the broker.
+// Shutdown shuts down the broker, giving up waiting for background
+// goroutines when ctx is done.
 func (b *Broker) Shutdown(ctx context.Context) error {
-	return b.Disconnect()
+	b.stop()
+
+	stopped := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return fmt.Errorf("paper broker shutdown: %w", ctx.Err())
+	}
+
+	b.logger.Info("paper broker disconnected")
+	return nil
+}
+
+// LastPrice returns the last simulated price for symbol.
+func (b *Broker) LastPrice(symbol string) (decimal.Decimal, bool) {
+	b.mdMu.RLock()
+	defer b.mdMu.RUnlock()
+	price, ok := b.prices[symbol]
+	return price, ok
 }
 
 // GetEquity returns current equity.
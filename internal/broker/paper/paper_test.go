@@ -2,6 +2,8 @@ package paper
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -64,6 +66,88 @@ func TestBroker_SubscribeMarketData(t *testing.T) {
 	}
 }
 
+func TestBroker_UnsubscribeWhilePublishing(t *testing.T) {
+	b := NewBroker(DefaultConfig(), nil)
+	b.Connect(context.Background())
+
+	for round := 0; round < 20; round++ {
+		ch, err := b.SubscribeMarketData(context.Background(), "MES")
+		if err != nil {
+			t.Fatalf("SubscribeMarketData() error = %v", err)
+		}
+
+		var wg sync.WaitGroup
+		for p := 0; p < 4; p++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+				}
+			}()
+		}
+
+		drained := make(chan int)
+		go func() {
+			n := 0
+			for range ch {
+				n++
+			}
+			drained <- n
+		}()
+
+		// Publishing after unsubscribe must not panic on the closed channel
+		if err := b.UnsubscribeMarketData("MES"); err != nil {
+			t.Fatalf("UnsubscribeMarketData() error = %v", err)
+		}
+		wg.Wait()
+
+		select {
+		case <-drained:
+		case <-time.After(time.Second):
+			t.Fatal("channel not closed after unsubscribe")
+		}
+	}
+}
+
+func TestBroker_SubscriptionEndsWithContext(t *testing.T) {
+	b := NewBroker(DefaultConfig(), nil)
+	b.Connect(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := b.SubscribeMarketData(ctx, "MES")
+	if err != nil {
+		t.Fatalf("SubscribeMarketData() error = %v", err)
+	}
+
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	cancel()
+
+	// The buffered event is still delivered before the close
+	select {
+	case _, ok := <-ch:
+		if !ok {
+			t.Fatal("buffered event lost")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected buffered event")
+	}
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("expected channel closed after context cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after context cancel")
+	}
+
+	// A new subscription is unaffected by the old context
+	if _, err := b.SubscribeMarketData(context.Background(), "MES"); err != nil {
+		t.Fatalf("resubscribe error = %v", err)
+	}
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5001)})
+}
+
 func TestBroker_SimulateMarketData(t *testing.T) {
 	b := NewBroker(DefaultConfig(), nil)
 	b.Connect(context.Background())
@@ -145,6 +229,49 @@ func TestBroker_PlaceOrder(t *testing.T) {
 	}
 }
 
+func TestBroker_DuplicateOrder(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SyncFills = true
+	b := NewBroker(cfg, nil)
+	b.Connect(context.Background())
+
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+
+	order := types.OrderIntent{
+		ClientOrderID: "duplicate-order",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     1,
+	}
+
+	// First order should succeed
+	if _, err := b.PlaceOrder(context.Background(), order); err != nil {
+		t.Fatalf("First order failed: %v", err)
+	}
+
+	// Close the position first
+	closeOrder := types.OrderIntent{
+		ClientOrderID: "close-order",
+		Symbol:        "MES",
+		Side:          types.SideShort,
+		Contracts:     1,
+	}
+	if _, err := b.PlaceOrder(context.Background(), closeOrder); err != nil {
+		t.Fatalf("Close order failed: %v", err)
+	}
+
+	// Duplicate order should fail without opening a position
+	if _, err := b.PlaceOrder(context.Background(), order); !errors.Is(err, types.ErrDuplicateOrder) {
+		t.Errorf("Expected ErrDuplicateOrder, got %v", err)
+	}
+	if pos, _ := b.GetPosition(context.Background(), "MES"); pos != nil {
+		t.Errorf("duplicate order opened a position: %+v", pos)
+	}
+	if orders, _ := b.GetOpenOrders(context.Background()); len(orders) != 0 {
+		t.Errorf("open orders = %d, want 0", len(orders))
+	}
+}
+
 func TestBroker_ClosePosition(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.FillDelay = 10 * time.Millisecond
@@ -199,6 +326,147 @@ func TestBroker_ClosePosition(t *testing.T) {
 	}
 }
 
+func TestBroker_FlattenAll(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FillDelay = 10 * time.Millisecond
+	b := NewBroker(cfg, nil)
+	b.Connect(context.Background())
+
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MGC", Close: decimal.NewFromInt(2000)})
+
+	b.PlaceOrder(context.Background(), types.OrderIntent{ClientOrderID: "mes-long", Symbol: "MES", Side: types.SideLong, Contracts: 2})
+	b.PlaceOrder(context.Background(), types.OrderIntent{ClientOrderID: "mgc-short", Symbol: "MGC", Side: types.SideShort, Contracts: 1})
+	time.Sleep(50 * time.Millisecond)
+
+	if err := b.FlattenAll(context.Background()); err != nil {
+		t.Fatalf("FlattenAll() error = %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	positions, _ := b.GetPositions(context.Background())
+	if len(positions) != 0 {
+		t.Errorf("expected no positions after FlattenAll, got %+v", positions)
+	}
+}
+
+func TestBroker_BracketTakeProfit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FillDelay = 10 * time.Millisecond
+	cfg.SlippageTicks = 0
+	cfg.CommissionPerSide = decimal.Zero
+	cfg.InitialEquity = decimal.NewFromInt(10000)
+	b := NewBroker(cfg, nil)
+	b.Connect(context.Background())
+
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	b.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "bracket-long",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     2,
+		StopLoss:      decimal.NewFromInt(4990),
+		TakeProfit:    decimal.NewFromInt(5020),
+	})
+	time.Sleep(50 * time.Millisecond)
+
+	// Bar inside the bracket: still open
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5010), High: decimal.NewFromInt(5015), Low: decimal.NewFromInt(4995)})
+	if pos, _ := b.GetPosition(context.Background(), "MES"); pos == nil {
+		t.Fatal("expected position to stay open inside the bracket")
+	}
+
+	// High reaches the take profit
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5018), High: decimal.NewFromInt(5022), Low: decimal.NewFromInt(5008)})
+	if pos, _ := b.GetPosition(context.Background(), "MES"); pos != nil {
+		t.Fatalf("expected position closed at take profit, got %+v", pos)
+	}
+
+	// 20 points * $5 * 2 contracts
+	want := decimal.NewFromInt(10200)
+	if equity := b.GetEquity(); !equity.Equal(want) {
+		t.Errorf("Equity = %s, want %s", equity, want)
+	}
+}
+
+func TestBroker_MaxHoldBars(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SyncFills = true
+	cfg.SlippageTicks = 0
+	cfg.CommissionPerSide = decimal.Zero
+	cfg.InitialEquity = decimal.NewFromInt(10000)
+	cfg.MaxHoldBars = 2
+	b := NewBroker(cfg, nil)
+	b.Connect(context.Background())
+
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	b.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "time-limit-short",
+		Symbol:        "MES",
+		Side:          types.SideShort,
+		Contracts:     1,
+		StopLoss:      decimal.NewFromInt(5020),
+		TakeProfit:    decimal.NewFromInt(4970),
+	})
+
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(4995), High: decimal.NewFromInt(5002), Low: decimal.NewFromInt(4993)})
+	if pos, _ := b.GetPosition(context.Background(), "MES"); pos == nil {
+		t.Fatal("expected position to stay open before the time limit")
+	}
+
+	// Second bar hits the limit inside the bracket
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(4990), High: decimal.NewFromInt(4996), Low: decimal.NewFromInt(4988)})
+	if pos, _ := b.GetPosition(context.Background(), "MES"); pos != nil {
+		t.Fatalf("expected position closed at the time limit, got %+v", pos)
+	}
+
+	// 10 points * $5
+	want := decimal.NewFromInt(10050)
+	if equity := b.GetEquity(); !equity.Equal(want) {
+		t.Errorf("Equity = %s, want %s", equity, want)
+	}
+}
+
+func TestBroker_BracketStopLoss_Short(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FillDelay = 10 * time.Millisecond
+	cfg.SlippageTicks = 0
+	cfg.CommissionPerSide = decimal.Zero
+	cfg.InitialEquity = decimal.NewFromInt(10000)
+	b := NewBroker(cfg, nil)
+	b.Connect(context.Background())
+
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	b.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "bracket-short",
+		Symbol:        "MES",
+		Side:          types.SideShort,
+		Contracts:     1,
+		StopLoss:      decimal.NewFromInt(5010),
+		TakeProfit:    decimal.NewFromInt(4980),
+	})
+	time.Sleep(50 * time.Millisecond)
+
+	// Both levels inside the bar: stop wins
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(4990), High: decimal.NewFromInt(5012), Low: decimal.NewFromInt(4978)})
+	if pos, _ := b.GetPosition(context.Background(), "MES"); pos != nil {
+		t.Fatalf("expected position closed at stop, got %+v", pos)
+	}
+
+	want := decimal.NewFromInt(9950) // -10 points * $5
+	if equity := b.GetEquity(); !equity.Equal(want) {
+		t.Errorf("Equity = %s, want %s", equity, want)
+	}
+
+	// Bracket is gone: a new position without levels is not auto-closed
+	b.PlaceOrder(context.Background(), types.OrderIntent{ClientOrderID: "plain-long", Symbol: "MES", Side: types.SideLong, Contracts: 1})
+	time.Sleep(50 * time.Millisecond)
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(4900), High: decimal.NewFromInt(5100), Low: decimal.NewFromInt(4900)})
+	if pos, _ := b.GetPosition(context.Background(), "MES"); pos == nil {
+		t.Error("position without stop/TP should not be auto-closed")
+	}
+}
+
 func TestBroker_GetOpenOrders(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.FillDelay = 1 * time.Second // Long delay to keep order open
@@ -253,6 +521,53 @@ func TestBroker_CancelOrder(t *testing.T) {
 	}
 }
 
+func TestBroker_ModifyOrder(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FillDelay = 50 * time.Millisecond
+	b := NewBroker(cfg, nil)
+	b.Connect(context.Background())
+	ctx := context.Background()
+
+	result, _ := b.PlaceOrder(ctx, types.OrderIntent{
+		ClientOrderID: "modify-test",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     1,
+		EntryPrice:    decimal.NewFromInt(5000),
+	})
+
+	if err := b.ModifyOrder(ctx, result.OrderID, decimal.NewFromInt(4990), 0); err == nil {
+		t.Error("expected error for zero quantity")
+	}
+	if err := b.ModifyOrder(ctx, "PAPER-999", decimal.Zero, 1); !errors.Is(err, broker.ErrOrderNotFound) {
+		t.Errorf("ModifyOrder(unknown) error = %v, want ErrOrderNotFound", err)
+	}
+
+	if err := b.ModifyOrder(ctx, result.OrderID, decimal.NewFromInt(4990), 3); err != nil {
+		t.Fatalf("ModifyOrder() error = %v", err)
+	}
+	orders, _ := b.GetOpenOrders(ctx)
+	if len(orders) != 1 || orders[0].Quantity != 3 || !orders[0].LimitPrice.Equal(decimal.NewFromInt(4990)) {
+		t.Fatalf("open orders = %+v, want 3 @ 4990", orders)
+	}
+
+	// The delayed fill uses the modified order: no market data, so the new
+	// price plus one tick of slippage
+	deadline := time.Now().Add(2 * time.Second)
+	var pos *broker.Position
+	for pos == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		pos, _ = b.GetPosition(ctx, "MES")
+	}
+	if pos == nil || pos.Contracts != 3 || !pos.AvgCost.Equal(decimal.RequireFromString("4990.25")) {
+		t.Fatalf("position = %+v, want 3 @ 4990.25", pos)
+	}
+
+	if err := b.ModifyOrder(ctx, result.OrderID, decimal.Zero, 1); !errors.Is(err, broker.ErrOrderNotWorking) {
+		t.Errorf("ModifyOrder(filled) error = %v, want ErrOrderNotWorking", err)
+	}
+}
+
 func TestBroker_Disconnect(t *testing.T) {
 	b := NewBroker(DefaultConfig(), nil)
 	b.Connect(context.Background())
@@ -266,6 +581,71 @@ func TestBroker_Disconnect(t *testing.T) {
 	}
 }
 
+func TestBroker_DisconnectCancelsPendingFill(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FillDelay = time.Hour
+	b := NewBroker(cfg, nil)
+	b.Connect(context.Background())
+
+	result, err := b.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "slow-fill",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     1,
+		EntryPrice:    decimal.NewFromInt(5000),
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+
+	start := time.Now()
+	if err := b.Disconnect(); err != nil {
+		t.Fatalf("Disconnect() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Disconnect() took %v, want prompt return", elapsed)
+	}
+
+	b.ordersMu.RLock()
+	status := b.orders[result.OrderID].Status
+	b.ordersMu.RUnlock()
+	if status != broker.OrderStatusCancelled {
+		t.Errorf("pending order status = %s, want cancelled", status)
+	}
+	if pos, _ := b.GetPosition(context.Background(), "MES"); pos != nil {
+		t.Errorf("expected no position, got %+v", pos)
+	}
+
+	// Second shutdown is a no-op
+	if err := b.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() after Disconnect error = %v", err)
+	}
+}
+
+func TestBroker_CancelledOrderNotFilled(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FillDelay = 20 * time.Millisecond
+	b := NewBroker(cfg, nil)
+	b.Connect(context.Background())
+
+	result, _ := b.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "cancel-before-fill",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     1,
+		EntryPrice:    decimal.NewFromInt(5000),
+	})
+	if err := b.CancelOrder(context.Background(), result.OrderID); err != nil {
+		t.Fatalf("CancelOrder() error = %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if pos, _ := b.GetPosition(context.Background(), "MES"); pos != nil {
+		t.Errorf("cancelled order filled: %+v", pos)
+	}
+}
+
 func TestBroker_NotConnected(t *testing.T) {
 	b := NewBroker(DefaultConfig(), nil)
 
@@ -275,3 +655,188 @@ func TestBroker_NotConnected(t *testing.T) {
 		t.Errorf("PlaceOrder() error = %v, want ErrNotConnected", err)
 	}
 }
+
+func TestBroker_SyncFills(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SyncFills = true
+	b := NewBroker(cfg, nil)
+	b.Connect(context.Background())
+
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+
+	result, err := b.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "sync-order",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     1,
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	if result.Status != broker.OrderStatusFilled {
+		t.Errorf("Status = %v, want filled", result.Status)
+	}
+
+	// No sleep: the position exists as soon as PlaceOrder returns
+	pos, _ := b.GetPosition(context.Background(), "MES")
+	if pos == nil || pos.Contracts != 1 {
+		t.Fatalf("expected 1 contract position immediately, got %+v", pos)
+	}
+}
+
+func TestBroker_FillHandler(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SyncFills = true
+	b := NewBroker(cfg, nil)
+	b.Connect(context.Background())
+
+	var fills []broker.Order
+	b.SetFillHandler(func(order broker.Order) {
+		fills = append(fills, order)
+	})
+
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	result, err := b.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "notify-order",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     1,
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+
+	if len(fills) != 1 {
+		t.Fatalf("fills = %d, want 1", len(fills))
+	}
+	if fills[0].OrderID != result.OrderID || fills[0].Status != broker.OrderStatusFilled || fills[0].FilledQty != 1 {
+		t.Errorf("fill = %+v, want filled order %s", fills[0], result.OrderID)
+	}
+}
+
+func TestBroker_FillsAtBidAsk(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SyncFills = true
+	cfg.SlippageTicks = 1
+	b := NewBroker(cfg, nil)
+	b.Connect(context.Background())
+
+	b.SimulateMarketData(types.MarketEvent{
+		Symbol: "MES",
+		Close:  decimal.NewFromInt(5000),
+		Bid:    decimal.RequireFromString("4999.75"),
+		Ask:    decimal.RequireFromString("5000.50"),
+	})
+
+	var fills []broker.Order
+	b.SetFillHandler(func(order broker.Order) {
+		fills = append(fills, order)
+	})
+
+	for _, side := range []types.Side{types.SideLong, types.SideShort} {
+		if _, err := b.PlaceOrder(context.Background(), types.OrderIntent{
+			ClientOrderID: "quote-" + side.String(),
+			Symbol:        "MES",
+			Side:          side,
+			Contracts:     1,
+		}); err != nil {
+			t.Fatalf("PlaceOrder(%s) error = %v", side, err)
+		}
+	}
+
+	// Ask/bid plus one tick of slippage
+	if want := decimal.RequireFromString("5000.75"); !fills[0].AvgFillPrice.Equal(want) {
+		t.Errorf("buy fill = %s, want %s", fills[0].AvgFillPrice, want)
+	}
+	if want := decimal.RequireFromString("4999.50"); !fills[1].AvgFillPrice.Equal(want) {
+		t.Errorf("sell fill = %s, want %s", fills[1].AvgFillPrice, want)
+	}
+
+	// Close-only data falls back to close-based pricing
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5010)})
+	if _, err := b.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "close-only",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     1,
+	}); err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	if want := decimal.RequireFromString("5010.25"); !fills[2].AvgFillPrice.Equal(want) {
+		t.Errorf("close-only fill = %s, want %s", fills[2].AvgFillPrice, want)
+	}
+}
+
+func TestBroker_ReduceOnly(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SyncFills = true
+	b := NewBroker(cfg, nil)
+	b.Connect(context.Background())
+	ctx := context.Background()
+
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+
+	// Nothing to reduce yet
+	_, err := b.PlaceOrder(ctx, types.OrderIntent{
+		ClientOrderID: "reduce-flat", Symbol: "MES", Side: types.SideShort, Contracts: 1, ReduceOnly: true,
+	})
+	if !errors.Is(err, types.ErrNotReducing) {
+		t.Fatalf("PlaceOrder(flat) error = %v, want ErrNotReducing", err)
+	}
+
+	if _, err := b.PlaceOrder(ctx, types.OrderIntent{
+		ClientOrderID: "open", Symbol: "MES", Side: types.SideLong, Contracts: 1,
+	}); err != nil {
+		t.Fatalf("PlaceOrder(open) error = %v", err)
+	}
+
+	// Same side would add to the position
+	_, err = b.PlaceOrder(ctx, types.OrderIntent{
+		ClientOrderID: "reduce-add", Symbol: "MES", Side: types.SideLong, Contracts: 1, ReduceOnly: true,
+	})
+	if !errors.Is(err, types.ErrNotReducing) {
+		t.Errorf("PlaceOrder(same side) error = %v, want ErrNotReducing", err)
+	}
+
+	// 3 contracts against a 1-lot only close it
+	if _, err := b.PlaceOrder(ctx, types.OrderIntent{
+		ClientOrderID: "reduce-close", Symbol: "MES", Side: types.SideShort, Contracts: 3, ReduceOnly: true,
+	}); err != nil {
+		t.Fatalf("PlaceOrder(reduce) error = %v", err)
+	}
+	if pos, _ := b.GetPosition(ctx, "MES"); pos != nil {
+		t.Errorf("position = %+v, want flat rather than flipped short", pos)
+	}
+}
+
+func TestBroker_ReduceOnlyCheckedAtFill(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SyncFills = true
+	b := NewBroker(cfg, nil)
+	b.Connect(context.Background())
+	ctx := context.Background()
+
+	b.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	if _, err := b.PlaceOrder(ctx, types.OrderIntent{
+		ClientOrderID: "open", Symbol: "MES", Side: types.SideLong, Contracts: 2,
+	}); err != nil {
+		t.Fatalf("PlaceOrder(open) error = %v", err)
+	}
+
+	// Two flattens race: the second finds the position already closed
+	intent := types.OrderIntent{Symbol: "MES", Side: types.SideShort, Contracts: 2, ReduceOnly: true}
+	first := &broker.Order{OrderID: "PAPER-A", Status: broker.OrderStatusSubmitted}
+	second := &broker.Order{OrderID: "PAPER-B", Status: broker.OrderStatusSubmitted}
+	b.executeFill(first, intent, decimal.NewFromInt(5000))
+	b.executeFill(second, intent, decimal.NewFromInt(5000))
+
+	if first.Status != broker.OrderStatusFilled {
+		t.Errorf("first flatten = %s, want filled", first.Status)
+	}
+	if second.Status != broker.OrderStatusRejected {
+		t.Errorf("second flatten = %s, want rejected", second.Status)
+	}
+	if pos, _ := b.GetPosition(ctx, "MES"); pos != nil {
+		t.Errorf("position = %+v, want flat", pos)
+	}
+}
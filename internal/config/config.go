@@ -2,14 +2,24 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"log/slog"
 	"os"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/audit"
+	"github.com/tathienbao/quant-bot/internal/execution"
+	"github.com/tathienbao/quant-bot/internal/logging"
+	"github.com/tathienbao/quant-bot/internal/observer"
 	"github.com/tathienbao/quant-bot/internal/risk"
 	"github.com/tathienbao/quant-bot/internal/types"
+	"github.com/tathienbao/quant-bot/internal/ui"
 	"gopkg.in/yaml.v3"
 )
 
@@ -22,17 +32,27 @@ type Config struct {
 	Health      HealthConfig      `yaml:"health"`
 	Shutdown    ShutdownConfig    `yaml:"shutdown"`
 	Persistence PersistenceConfig `yaml:"persistence"`
+	Audit       AuditConfig       `yaml:"audit"`
+	Logging     LoggingConfig     `yaml:"logging"`
 	Alerting    AlertingConfig    `yaml:"alerting"`
 	Metrics     MetricsConfig     `yaml:"metrics"`
 	Backtest    BacktestConfig    `yaml:"backtest"`
 	Broker      BrokerConfig      `yaml:"broker"`
+	Display     DisplayConfig     `yaml:"display"`
+
+	// Unset environment variables referenced by alerting channels
+	unsetAlertingEnv []string
 }
 
 // AccountConfig holds account-related settings.
 type AccountConfig struct {
-	StartingEquity       float64 `yaml:"starting_equity"`
-	MaxGlobalDrawdownPct float64 `yaml:"max_global_drawdown_pct"`
-	RiskPerTradePct      float64 `yaml:"risk_per_trade_pct"`
+	StartingEquity       float64  `yaml:"starting_equity"`
+	MaxGlobalDrawdownPct float64  `yaml:"max_global_drawdown_pct"`
+	DrawdownWarnPct      *float64 `yaml:"drawdown_warn_pct"` // Share of the drawdown limit that sends a warning (unset = 0.75, 0 = disabled)
+	RiskPerTradePct      float64  `yaml:"risk_per_trade_pct"`
+	MaxDailyLossPct      float64  `yaml:"max_daily_loss_pct"`      // 0 = disabled
+	FlattenOnDailyLoss   bool     `yaml:"flatten_on_daily_loss"`   // Close positions when the daily limit is hit
+	DailyProfitTargetPct float64  `yaml:"daily_profit_target_pct"` // 0 = disabled
 }
 
 // MarketConfig holds market-related settings.
@@ -46,6 +66,30 @@ type MarketConfig struct {
 	DailyBreakStart       string `yaml:"daily_break_start"`
 	DailyBreakEnd         string `yaml:"daily_break_end"`
 	SessionCloseCutoffMin int    `yaml:"session_close_cutoff_min"`
+	RecordPath            string `yaml:"record_path"` // CSV of every live bar for replay (empty = off)
+
+	// Warm start: seed indicators from recent bars so ATR is valid on the first live bar
+	WarmStart     bool   `yaml:"warm_start"`
+	WarmStartData string `yaml:"warm_start_data"` // CSV of bars leading up to startup
+	WarmStartBars int    `yaml:"warm_start_bars"` // Most recent bars of the CSV used (0 = all)
+
+	// Per-symbol overrides merged over the built-in instrument specs
+	Instruments map[string]InstrumentConfig `yaml:"instruments"`
+}
+
+// InstrumentConfig overrides an instrument's contract specification. Zero
+// fields keep the built-in value; symbols without a built-in spec must set
+// tick_size, tick_value and point_value.
+type InstrumentConfig struct {
+	TickSize       float64 `yaml:"tick_size"`
+	TickValue      float64 `yaml:"tick_value"`
+	PointValue     float64 `yaml:"point_value"`
+	MarginInitial  float64 `yaml:"margin_initial"`
+	MarginIntraday float64 `yaml:"margin_intraday"`
+	ExchangeFee    float64 `yaml:"exchange_fee"`    // Per contract per side
+	ATRPeriod      int     `yaml:"atr_period"`      // ATR lookback for this symbol (0 = risk.volatility_lookback_bars)
+	MiniSymbol     string  `yaml:"mini_symbol"`     // Full-size sibling of a micro contract (e.g. ES for MES)
+	MicrosPerMini  int     `yaml:"micros_per_mini"` // Micro contracts per mini_symbol contract (e.g. 10)
 }
 
 // RiskConfig holds risk management settings.
@@ -55,21 +99,64 @@ type RiskConfig struct {
 	TakeProfitATRMultiple   float64 `yaml:"take_profit_atr_multiple"`
 	MaxExposurePerSymbolPct float64 `yaml:"max_exposure_per_symbol_pct"`
 	MaxTotalExposurePct     float64 `yaml:"max_total_exposure_pct"`
+	MinNetProfitPerContract float64 `yaml:"min_net_profit_per_contract"` // USD target gain after costs (0 = disabled)
+	MinStopTicks            int     `yaml:"min_stop_ticks"`              // Widen tighter stops to this many ticks (0 = disabled)
+	MaxStopTicks            int     `yaml:"max_stop_ticks"`              // Reject signals with wider stops (0 = disabled)
+	MaxContractsPerOrder    int     `yaml:"max_contracts_per_order"`     // Hard cap on order size (0 = unlimited)
+	MaxOpenPositions        int     `yaml:"max_open_positions"`          // Cap on symbols held at once (0 = unlimited)
+	MaxMiniEquivalent       float64 `yaml:"max_mini_equivalent"`         // Cap across a micro/mini pair in full-size contracts (0 = unlimited)
+	StrictRewardRisk        bool    `yaml:"strict_reward_risk"`          // Reject take-profit multiples <= stop multiples instead of warning
+	AllowedDirection        string  `yaml:"allowed_direction"`           // both (default) | long | short
+	DrawdownRiskFloor       float64 `yaml:"drawdown_risk_floor"`         // Share of risk per trade kept at max drawdown, scaled linearly (0 = off)
+	MarginCheck             string  `yaml:"margin_check"`                // off (default) | reject | downsize: orders beyond equity minus open-position margin
+	PartialCloseAccounting  string  `yaml:"partial_close_accounting"`    // average (default) | fifo: entry price left after a position is reduced
+	SizingMode              string  `yaml:"sizing_mode"`                 // risk_based (default) | fixed_contracts | fixed_notional
+	FixedContracts          int     `yaml:"fixed_contracts"`             // Contracts per order with sizing_mode fixed_contracts
+	FixedNotional           float64 `yaml:"fixed_notional"`              // USD notional per order with sizing_mode fixed_notional
+
+	// Cooldown after the kill switch is reset by hand
+	PostKillSwitchCooldownMin int     `yaml:"post_kill_switch_cooldown_min"` // Minutes of reduced risk after leaving safe mode (0 = off)
+	PostKillSwitchRiskFactor  float64 `yaml:"post_kill_switch_risk_factor"`  // Share of risk per trade during the cooldown (0 = no new entries)
+
+	// Per-strategy drawdown budgets inside the account
+	Buckets []RiskBucketConfig `yaml:"buckets"`
+
+	// Partial exits before take_profit_atr_multiple (backtests)
+	TakeProfitTranches []TrancheConfig `yaml:"take_profit_tranches"`
+}
+
+// TrancheConfig closes part of each position at a nearer target.
+type TrancheConfig struct {
+	ATRMultiple float64 `yaml:"atr_multiple"` // Target distance, like take_profit_atr_multiple
+	Fraction    float64 `yaml:"fraction"`     // Share of the order's contracts closed there, 0-1
+}
+
+// RiskBucketConfig gives one strategy its own drawdown limit.
+type RiskBucketConfig struct {
+	Strategy       string  `yaml:"strategy"`         // Strategy name, as in --strategy
+	Allocation     float64 `yaml:"allocation"`       // USD the drawdown is measured against (0 = starting_equity)
+	MaxDrawdownPct float64 `yaml:"max_drawdown_pct"` // Stop the strategy at this drawdown from its own peak
 }
 
 // ExecutionConfig holds execution settings.
 type ExecutionConfig struct {
-	OrderTimeoutSec     int `yaml:"order_timeout_sec"`
-	MaxRetries          int `yaml:"max_retries"`
-	RetryDelayMs        int `yaml:"retry_delay_ms"`
-	RateLimitPerSecond  int `yaml:"rate_limit_per_second"`
+	OrderTimeoutSec    int     `yaml:"order_timeout_sec"`
+	MaxRetries         int     `yaml:"max_retries"`
+	RetryDelayMs       int     `yaml:"retry_delay_ms"`
+	RateLimitPerSecond int     `yaml:"rate_limit_per_second"`
+	SignalValiditySec  int     `yaml:"signal_validity_sec"`  // Default order expiry (0 = 5 minutes)
+	ConfirmationBars   int     `yaml:"confirmation_bars"`    // Consecutive same-direction signals before entry (0/1 = off)
+	MaxSpreadTicks     int     `yaml:"max_spread_ticks"`     // Reject signals when bid/ask spread is wider (0 = off)
+	MinSignalStrength  float64 `yaml:"min_signal_strength"`  // Drop entry signals weaker than this, 0-1 (0 = accept all)
+	OppositeSignal     string  `yaml:"opposite_signal"`      // entry (default) | close | flip: what a signal against an open position does
 }
 
 // HealthConfig holds health check settings.
 type HealthConfig struct {
 	HeartbeatIntervalSec      int `yaml:"heartbeat_interval_sec"`
-	MaxMissedHeartbeats       int `yaml:"max_missed_heartbeats"`
+	MaxMissedHeartbeats       int `yaml:"max_missed_heartbeats"` // Trading loop heartbeats missed before it is unhealthy (0 = 3)
 	DataStalenessThresholdSec int `yaml:"data_staleness_threshold_sec"`
+	FlattenOnStaleData        bool `yaml:"flatten_on_stale_data"` // Close positions when data goes stale
 }
 
 // ShutdownConfig holds shutdown settings.
@@ -87,6 +174,26 @@ type PersistenceConfig struct {
 	SnapshotIntervalSec int    `yaml:"snapshot_interval_sec"`
 }
 
+// AuditConfig holds audit trail settings.
+type AuditConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	Path      string `yaml:"path"`        // NDJSON file of signals, intents, orders and fills
+	MaxSizeMB int    `yaml:"max_size_mb"` // Rotate at this size (0 = never)
+}
+
+// LoggingConfig holds log output settings.
+type LoggingConfig struct {
+	Format     string `yaml:"format"`       // json | text (default json)
+	Path       string `yaml:"path"`         // Log file (empty = stdout)
+	MaxSizeMB  int    `yaml:"max_size_mb"`  // Rotate at this size (0 = never)
+	MaxAgeDays int    `yaml:"max_age_days"` // Delete rotated files older than this (0 = keep)
+	MaxBackups int    `yaml:"max_backups"`  // Rotated files to keep (0 = all)
+
+	// Periodic per-position P&L and distance to stop/TP during live and paper runs
+	PositionReadout      bool `yaml:"position_readout"`
+	PositionReadoutAlert bool `yaml:"position_readout_alert"` // Also send it as an alert
+}
+
 // AlertingConfig holds alerting settings.
 type AlertingConfig struct {
 	Enabled  bool            `yaml:"enabled"`
@@ -105,14 +212,57 @@ type ChannelConfig struct {
 // MetricsConfig holds metrics settings.
 type MetricsConfig struct {
 	Enabled bool   `yaml:"enabled"`
+	Host    string `yaml:"host"` // Interface to listen on (default 127.0.0.1, 0.0.0.0 = all)
 	Port    int    `yaml:"port"`
 	Path    string `yaml:"path"`
+
+	// WebSocket stream of live state for dashboards, served on the metrics port
+	StreamEnabled bool     `yaml:"stream_enabled"`
+	StreamPath    string   `yaml:"stream_path"`    // Default /stream
+	StreamOrigins []string `yaml:"stream_origins"` // Browser origins allowed besides the metrics host
 }
 
 // BacktestConfig holds backtest settings.
 type BacktestConfig struct {
 	SlippageTicks         int     `yaml:"slippage_ticks"`
-	CommissionPerContract float64 `yaml:"commission_per_contract"`
+	SlippageATRFraction   float64 `yaml:"slippage_atr_fraction"` // Scale slippage with ATR (0 = fixed slippage_ticks)
+	EntrySlippageTicks    int     `yaml:"entry_slippage_ticks"`  // Fixed slippage on entries (0 = slippage_ticks / ATR model)
+	ExitSlippageTicks     int     `yaml:"exit_slippage_ticks"`   // Fixed slippage on exits (0 = slippage_ticks / ATR model)
+	StopSlippageTicks     int     `yaml:"stop_slippage_ticks"`   // Extra slippage on stop exits, on top of exit slippage
+	SpreadATRFraction     float64 `yaml:"spread_atr_fraction"`   // Approximate bid/ask on quoteless bars as a fraction of ATR (0 = off)
+	SpreadMinTicks        int     `yaml:"spread_min_ticks"`      // Floor for the estimated spread in ticks
+	CommissionPerContract float64 `yaml:"commission_per_contract"` // Round trip
+	WarmupBars            int     `yaml:"warmup_bars"` // Bars fed to indicators before trading starts
+	BreakevenTriggerTicks int     `yaml:"breakeven_trigger_ticks"` // Profit in ticks before stop moves to entry (0 = off)
+	BreakevenOffsetTicks  int     `yaml:"breakeven_offset_ticks"`  // Ticks beyond entry for the breakeven stop
+	AmbiguousBarPolicy    string  `yaml:"ambiguous_bar_policy"`    // stop_first | tp_first | open_proximity
+	GapFillAtOpen         bool    `yaml:"gap_fill_at_open"`        // Fill gapped stops at the bar open instead of the stop price
+	FillNextBarOpen       bool    `yaml:"fill_next_bar_open"`      // Fill orders at the next bar's open instead of the signal bar's close
+	EntryPriceSource      string  `yaml:"entry_price_source"`      // close | next_open | mid: price orders are sized and bracketed from
+	BackAdjustRolls       bool    `yaml:"back_adjust_rolls"`       // Remove quarterly roll gaps from continuous futures data
+	SkipInvalidBars       bool    `yaml:"skip_invalid_bars"`       // Skip bars with inconsistent OHLC (with a warning) instead of stopping
+	MaxHoldBars           int     `yaml:"max_hold_bars"`           // Close positions open this many bars at market (0 = off)
+	MaxVolumeFraction     float64 `yaml:"max_volume_fraction"`     // Cap fills at this share of the bar's volume (0 = off)
+	SplitSessions         bool    `yaml:"split_sessions"`          // Report intraday vs overnight P&L using market session times
+
+	// Tiered per-side commission; overrides commission_per_contract when set
+	CommissionTiers []CommissionTierConfig `yaml:"commission_tiers"`
+
+	// Per-side commission on resting limit fills (take profits, scale-out
+	// targets); negative for a maker rebate. Unset charges them like other fills.
+	MakerCommissionPerSide *float64 `yaml:"maker_commission_per_side"`
+}
+
+// CommissionTierConfig holds one band of a tiered commission schedule.
+type CommissionTierConfig struct {
+	UpToContracts int     `yaml:"up_to_contracts"` // Monthly volume cap for this tier (0 = unlimited)
+	PerContract   float64 `yaml:"per_contract"`    // USD per contract per side
+}
+
+// DisplayConfig holds the precision of printed results.
+type DisplayConfig struct {
+	PricePrecision   *int `yaml:"price_precision"`   // Decimals for prices (unset = the instrument's tick precision)
+	PercentPrecision *int `yaml:"percent_precision"` // Decimals for percentages (unset = 2)
 }
 
 // BrokerConfig holds broker settings.
@@ -125,40 +275,205 @@ type BrokerConfig struct {
 
 // Load loads configuration from a YAML file.
 func Load(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("read config file: %w", err)
+	return LoadFiles(path)
+}
+
+// LoadFiles loads and merges YAML files in order, e.g. a shared base
+// followed by per-environment overrides. See LoadFromBytes for the merge
+// rules. Empty paths are skipped.
+func LoadFiles(paths ...string) (*Config, error) {
+	var docs [][]byte
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config file: %w", err)
+		}
+		docs = append(docs, data)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("%w: no config file given", types.ErrInvalidConfig)
 	}
 
+	return LoadFromBytes(docs...)
+}
+
+// LoadFromBytes loads configuration from one or more YAML documents. Later
+// documents override earlier ones: scalars are replaced, mappings are merged
+// key by key, and a list replaces the earlier list as a whole. Only the
+// merged result is validated, so an override may complete a base that is
+// invalid on its own.
+func LoadFromBytes(docs ...[]byte) (*Config, error) {
 	// Expand environment variables
-	expanded := os.ExpandEnv(string(data))
+	var missing []string
+	expanded := make([]string, len(docs))
+	for i, data := range docs {
+		var docMissing []string
+		expanded[i], docMissing = expandEnv(string(data))
+		for _, name := range docMissing {
+			if !slices.Contains(missing, name) {
+				missing = append(missing, name)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		slog.Warn("config references unset environment variables",
+			"vars", strings.Join(missing, ", "),
+			"hint", "use ${VAR:-default} for optional values")
+	}
+
+	merged, err := mergeDocuments(expanded)
+	if err != nil {
+		return nil, err
+	}
 
 	var cfg Config
-	if err := yaml.Unmarshal([]byte(expanded), &cfg); err != nil {
+	if err := yaml.Unmarshal([]byte(merged), &cfg); err != nil {
 		return nil, fmt.Errorf("parse config: %w", err)
 	}
 
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("validate config: %w", err)
 	}
+	for _, warning := range cfg.Warnings() {
+		slog.Warn("config warning", "warning", warning)
+	}
+
+	cfg.unsetAlertingEnv = unsetChannelEnv(docs, missing)
+
+	specs, _ := cfg.InstrumentSpecs() // validated above
+	for _, spec := range specs {
+		if err := types.RegisterInstrumentSpec(spec); err != nil {
+			return nil, fmt.Errorf("register instrument: %w", err)
+		}
+	}
 
 	return &cfg, nil
 }
 
-// LoadFromBytes loads configuration from YAML bytes.
-func LoadFromBytes(data []byte) (*Config, error) {
-	expanded := os.ExpandEnv(string(data))
+// mergeDocuments deep-merges YAML documents into one. A single document is
+// returned as is so parse errors keep its line numbers.
+func mergeDocuments(docs []string) (string, error) {
+	if len(docs) == 1 {
+		return docs[0], nil
+	}
 
-	var cfg Config
-	if err := yaml.Unmarshal([]byte(expanded), &cfg); err != nil {
-		return nil, fmt.Errorf("parse config: %w", err)
+	merged := map[string]any{}
+	for i, doc := range docs {
+		var layer map[string]any
+		if err := yaml.Unmarshal([]byte(doc), &layer); err != nil {
+			return "", fmt.Errorf("parse config file %d: %w", i+1, err)
+		}
+		mergeMaps(merged, layer)
 	}
 
-	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("validate config: %w", err)
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return "", fmt.Errorf("merge config: %w", err)
 	}
+	return string(data), nil
+}
 
-	return &cfg, nil
+// mergeMaps merges src into dst: nested mappings merge recursively, any
+// other value (scalar, list or null) replaces dst's.
+func mergeMaps(dst, src map[string]any) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeMaps(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// unsetChannelEnv returns the missing variables that alerting channels
+// reference, read from the documents before expansion. If the channels
+// can't be read, every missing variable is assumed to be theirs.
+func unsetChannelEnv(docs [][]byte, missing []string) []string {
+	if len(missing) == 0 {
+		return nil
+	}
+
+	raw := make([]string, len(docs))
+	for i, data := range docs {
+		raw[i] = string(data)
+	}
+	merged, err := mergeDocuments(raw)
+	if err != nil {
+		return missing
+	}
+	var section struct {
+		Alerting struct {
+			Channels []ChannelConfig `yaml:"channels"`
+		} `yaml:"alerting"`
+	}
+	if err := yaml.Unmarshal([]byte(merged), &section); err != nil {
+		return missing
+	}
+
+	var unset []string
+	for _, ch := range section.Alerting.Channels {
+		for _, value := range []string{ch.Type, ch.BotToken, ch.ChatID, ch.WebhookURL} {
+			_, refs := expandEnv(value)
+			for _, name := range refs {
+				if slices.Contains(missing, name) && !slices.Contains(unset, name) {
+					unset = append(unset, name)
+				}
+			}
+		}
+	}
+	return unset
+}
+
+// CheckAlertingEnv returns an error naming the unset environment variables
+// that enabled alerting channels reference. Commands that send alerts call
+// it so a missing secret fails at startup; the others only log a warning
+// at load.
+func (c *Config) CheckAlertingEnv() error {
+	if !c.Alerting.Enabled || len(c.unsetAlertingEnv) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: unset environment variables for alerting: %s (use ${VAR:-default} for optional values)",
+		types.ErrInvalidConfig, strings.Join(c.unsetAlertingEnv, ", "))
+}
+
+// expandEnv expands $VAR and ${VAR} references, returning the names of
+// referenced variables that are not set. ${VAR:-default} marks a value as
+// optional and uses default when VAR is unset or empty. Comment lines are
+// left untouched so commented-out examples don't require their variables.
+func expandEnv(s string) (string, []string) {
+	var missing []string
+	seen := make(map[string]bool)
+
+	mapping := func(ref string) string {
+		if name, def, optional := strings.Cut(ref, ":-"); optional {
+			if v := os.Getenv(name); v != "" {
+				return v
+			}
+			return def
+		}
+		v, ok := os.LookupEnv(ref)
+		if !ok && !seen[ref] {
+			seen[ref] = true
+			missing = append(missing, ref)
+		}
+		return v
+	}
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		lines[i] = os.Expand(line, mapping)
+	}
+
+	return strings.Join(lines, "\n"), missing
 }
 
 // Validate validates the configuration.
@@ -172,17 +487,49 @@ func (c *Config) Validate() error {
 	if c.Account.MaxGlobalDrawdownPct <= 0 || c.Account.MaxGlobalDrawdownPct > 1 {
 		errs = append(errs, "account.max_global_drawdown_pct must be between 0 and 1")
 	}
+	if p := c.Account.DrawdownWarnPct; p != nil && (*p < 0 || *p >= 1) {
+		errs = append(errs, "account.drawdown_warn_pct must be between 0 and 1")
+	}
 	if c.Account.RiskPerTradePct <= 0 || c.Account.RiskPerTradePct > 0.1 {
 		errs = append(errs, "account.risk_per_trade_pct must be between 0 and 0.1 (10%)")
 	}
+	if c.Account.MaxDailyLossPct < 0 || c.Account.MaxDailyLossPct > 1 {
+		errs = append(errs, "account.max_daily_loss_pct must be between 0 and 1")
+	}
+	if c.Account.DailyProfitTargetPct < 0 || c.Account.DailyProfitTargetPct > 1 {
+		errs = append(errs, "account.daily_profit_target_pct must be between 0 and 1")
+	}
 
 	// Market validation
 	if c.Market.InstrumentPrimary == "" {
 		errs = append(errs, "market.instrument_primary is required")
 	}
-	if _, ok := types.GetInstrumentSpec(c.Market.InstrumentPrimary); !ok && c.Market.InstrumentPrimary != "" {
+	specs, err := c.InstrumentSpecs()
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	if _, ok := specs[c.Market.InstrumentPrimary]; !ok && c.Market.InstrumentPrimary != "" {
 		errs = append(errs, fmt.Sprintf("market.instrument_primary '%s' is not supported", c.Market.InstrumentPrimary))
 	}
+	if _, err := ParseTimeframe(c.Market.Timeframe); err != nil {
+		errs = append(errs, fmt.Sprintf("market.timeframe: %v", err))
+	}
+	if c.Market.Timezone != "" {
+		if _, err := time.LoadLocation(c.Market.Timezone); err != nil {
+			errs = append(errs, fmt.Sprintf("market.timezone '%s' is invalid", c.Market.Timezone))
+		}
+	}
+	if c.Market.SessionStart != "" {
+		if _, err := parseClock(c.Market.SessionStart); err != nil {
+			errs = append(errs, fmt.Sprintf("market.session_start '%s' must be HH:MM", c.Market.SessionStart))
+		}
+	}
+	if c.Market.WarmStart && c.Market.WarmStartData == "" {
+		errs = append(errs, "market.warm_start_data is required when market.warm_start is enabled")
+	}
+	if c.Market.WarmStartBars < 0 {
+		errs = append(errs, "market.warm_start_bars must not be negative")
+	}
 
 	// Risk validation
 	if c.Risk.StopLossATRMultiple <= 0 {
@@ -191,12 +538,90 @@ func (c *Config) Validate() error {
 	if c.Risk.TakeProfitATRMultiple <= 0 {
 		errs = append(errs, "risk.take_profit_atr_multiple must be positive")
 	}
+	if c.Risk.StrictRewardRisk && c.lowRewardRisk() {
+		errs = append(errs, "risk.take_profit_atr_multiple must exceed risk.stop_loss_atr_multiple (strict_reward_risk)")
+	}
 	if c.Risk.MaxExposurePerSymbolPct <= 0 || c.Risk.MaxExposurePerSymbolPct > 1 {
 		errs = append(errs, "risk.max_exposure_per_symbol_pct must be between 0 and 1")
 	}
 	if c.Risk.MaxTotalExposurePct <= 0 || c.Risk.MaxTotalExposurePct > 2 {
 		errs = append(errs, "risk.max_total_exposure_pct must be between 0 and 2")
 	}
+	if c.Risk.MinNetProfitPerContract < 0 {
+		errs = append(errs, "risk.min_net_profit_per_contract must not be negative")
+	}
+	if c.Risk.MinStopTicks < 0 || c.Risk.MaxStopTicks < 0 {
+		errs = append(errs, "risk.min_stop_ticks and risk.max_stop_ticks must not be negative")
+	}
+	if c.Risk.MaxStopTicks > 0 && c.Risk.MinStopTicks > c.Risk.MaxStopTicks {
+		errs = append(errs, "risk.min_stop_ticks must not exceed risk.max_stop_ticks")
+	}
+	if c.Risk.MaxContractsPerOrder < 0 {
+		errs = append(errs, "risk.max_contracts_per_order must not be negative")
+	}
+	if c.Risk.MaxOpenPositions < 0 {
+		errs = append(errs, "risk.max_open_positions must not be negative")
+	}
+	if c.Risk.MaxMiniEquivalent < 0 {
+		errs = append(errs, "risk.max_mini_equivalent must not be negative")
+	}
+	if c.Risk.DrawdownRiskFloor < 0 || c.Risk.DrawdownRiskFloor > 1 {
+		errs = append(errs, "risk.drawdown_risk_floor must be between 0 and 1")
+	}
+	if c.Risk.PostKillSwitchCooldownMin < 0 {
+		errs = append(errs, "risk.post_kill_switch_cooldown_min must not be negative")
+	}
+	if c.Risk.PostKillSwitchRiskFactor < 0 || c.Risk.PostKillSwitchRiskFactor > 1 {
+		errs = append(errs, "risk.post_kill_switch_risk_factor must be between 0 and 1")
+	}
+	switch c.Risk.AllowedDirection {
+	case "", "both", "long", "short":
+	default:
+		errs = append(errs, "risk.allowed_direction must be both, long or short")
+	}
+	if _, err := risk.ParseMarginPolicy(c.Risk.MarginCheck); err != nil {
+		errs = append(errs, "risk.margin_check must be off, reject or downsize")
+	}
+	if _, err := risk.ParseCostBasis(c.Risk.PartialCloseAccounting); err != nil {
+		errs = append(errs, "risk.partial_close_accounting must be average or fifo")
+	}
+	switch mode, err := risk.ParseSizingMode(c.Risk.SizingMode); {
+	case err != nil:
+		errs = append(errs, "risk.sizing_mode must be risk_based, fixed_contracts or fixed_notional")
+	case mode == risk.SizingFixedContracts && c.Risk.FixedContracts <= 0:
+		errs = append(errs, "risk.fixed_contracts must be positive with sizing_mode fixed_contracts")
+	case mode == risk.SizingFixedNotional && c.Risk.FixedNotional <= 0:
+		errs = append(errs, "risk.fixed_notional must be positive with sizing_mode fixed_notional")
+	}
+	seenBuckets := make(map[string]bool)
+	for i, b := range c.Risk.Buckets {
+		switch {
+		case b.Strategy == "":
+			errs = append(errs, fmt.Sprintf("risk.buckets[%d].strategy is required", i))
+		case seenBuckets[b.Strategy]:
+			errs = append(errs, fmt.Sprintf("risk.buckets[%d]: duplicate strategy %q", i, b.Strategy))
+		}
+		seenBuckets[b.Strategy] = true
+		if b.Allocation < 0 {
+			errs = append(errs, fmt.Sprintf("risk.buckets[%d].allocation must not be negative", i))
+		}
+		if b.MaxDrawdownPct <= 0 || b.MaxDrawdownPct > 1 {
+			errs = append(errs, fmt.Sprintf("risk.buckets[%d].max_drawdown_pct must be between 0 and 1", i))
+		}
+	}
+	trancheTotal := 0.0
+	for i, t := range c.Risk.TakeProfitTranches {
+		if t.ATRMultiple <= 0 {
+			errs = append(errs, fmt.Sprintf("risk.take_profit_tranches[%d].atr_multiple must be positive", i))
+		}
+		if t.Fraction <= 0 || t.Fraction > 1 {
+			errs = append(errs, fmt.Sprintf("risk.take_profit_tranches[%d].fraction must be between 0 and 1", i))
+		}
+		trancheTotal += t.Fraction
+	}
+	if trancheTotal > 1+1e-9 {
+		errs = append(errs, "risk.take_profit_tranches fractions must sum to at most 1")
+	}
 
 	// Execution validation
 	if c.Execution.OrderTimeoutSec <= 0 {
@@ -205,6 +630,84 @@ func (c *Config) Validate() error {
 	if c.Execution.MaxRetries < 0 {
 		c.Execution.MaxRetries = 2 // default
 	}
+	if c.Execution.SignalValiditySec < 0 {
+		errs = append(errs, "execution.signal_validity_sec must not be negative")
+	}
+	if c.Execution.ConfirmationBars < 0 {
+		errs = append(errs, "execution.confirmation_bars must not be negative")
+	}
+	if c.Execution.MaxSpreadTicks < 0 {
+		errs = append(errs, "execution.max_spread_ticks must not be negative")
+	}
+	if c.Execution.MinSignalStrength < 0 || c.Execution.MinSignalStrength > 1 {
+		errs = append(errs, "execution.min_signal_strength must be between 0 and 1")
+	}
+	switch c.Execution.OppositeSignal {
+	case "", "entry", "close", "flip":
+	default:
+		errs = append(errs, "execution.opposite_signal must be entry, close or flip")
+	}
+
+	// Health validation
+	if c.Health.MaxMissedHeartbeats < 0 {
+		errs = append(errs, "health.max_missed_heartbeats must not be negative")
+	}
+
+	// Backtest validation
+	if c.Backtest.SlippageATRFraction < 0 || c.Backtest.SlippageATRFraction > 1 {
+		errs = append(errs, "backtest.slippage_atr_fraction must be between 0 and 1")
+	}
+	if c.Backtest.EntrySlippageTicks < 0 || c.Backtest.ExitSlippageTicks < 0 || c.Backtest.StopSlippageTicks < 0 {
+		errs = append(errs, "backtest entry/exit/stop slippage ticks must not be negative")
+	}
+	if c.Backtest.SpreadATRFraction < 0 || c.Backtest.SpreadATRFraction > 1 {
+		errs = append(errs, "backtest.spread_atr_fraction must be between 0 and 1")
+	}
+	if c.Backtest.SpreadMinTicks < 0 {
+		errs = append(errs, "backtest.spread_min_ticks must not be negative")
+	}
+	if c.Backtest.WarmupBars < 0 {
+		errs = append(errs, "backtest.warmup_bars must not be negative")
+	}
+	if c.Backtest.BreakevenTriggerTicks < 0 {
+		errs = append(errs, "backtest.breakeven_trigger_ticks must not be negative")
+	}
+	if c.Backtest.BreakevenTriggerTicks > 0 && c.Backtest.BreakevenOffsetTicks >= c.Backtest.BreakevenTriggerTicks {
+		errs = append(errs, "backtest.breakeven_offset_ticks must be less than breakeven_trigger_ticks")
+	}
+	if c.Backtest.MaxHoldBars < 0 {
+		errs = append(errs, "backtest.max_hold_bars must not be negative")
+	}
+	if c.Backtest.MaxVolumeFraction < 0 || c.Backtest.MaxVolumeFraction > 1 {
+		errs = append(errs, "backtest.max_volume_fraction must be between 0 and 1")
+	}
+	if p := c.Display.PricePrecision; p != nil && (*p < 0 || *p > 10) {
+		errs = append(errs, "display.price_precision must be between 0 and 10")
+	}
+	if p := c.Display.PercentPrecision; p != nil && (*p < 0 || *p > 10) {
+		errs = append(errs, "display.percent_precision must be between 0 and 10")
+	}
+	if _, err := risk.ParseEntryPriceSource(c.Backtest.EntryPriceSource); err != nil {
+		errs = append(errs, "backtest.entry_price_source must be close, next_open or mid")
+	}
+	if _, err := execution.ParseAmbiguousBarPolicy(c.Backtest.AmbiguousBarPolicy); err != nil {
+		errs = append(errs, "backtest.ambiguous_bar_policy must be stop_first, tp_first or open_proximity")
+	}
+	if c.Backtest.CommissionPerContract < 0 {
+		errs = append(errs, "backtest.commission_per_contract must not be negative (use maker_commission_per_side for rebates)")
+	}
+	prevTier := 0
+	for i, tier := range c.Backtest.CommissionTiers {
+		if tier.PerContract < 0 {
+			errs = append(errs, fmt.Sprintf("backtest.commission_tiers[%d].per_contract must not be negative (use maker_commission_per_side for rebates)", i))
+		}
+		last := i == len(c.Backtest.CommissionTiers)-1
+		if !last && tier.UpToContracts <= prevTier {
+			errs = append(errs, "backtest.commission_tiers must have increasing up_to_contracts (0 only on the last tier)")
+			break
+		}
+		prevTier = tier.UpToContracts
+	}
 
 	// Persistence validation
 	if c.Persistence.Enabled {
@@ -217,6 +720,32 @@ func (c *Config) Validate() error {
 		if c.Persistence.Type == "postgres" && c.Persistence.DSN == "" {
 			errs = append(errs, "persistence.dsn is required for postgres")
 		}
+		if c.Persistence.SnapshotIntervalSec < 0 {
+			errs = append(errs, "persistence.snapshot_interval_sec must not be negative")
+		}
+	}
+
+	// Per-symbol indicator validation
+	for symbol, inst := range c.Market.Instruments {
+		if inst.ATRPeriod < 0 {
+			errs = append(errs, fmt.Sprintf("market.instruments.%s.atr_period must not be negative", symbol))
+		}
+	}
+
+	// Audit validation
+	if c.Audit.Enabled && c.Audit.Path == "" {
+		errs = append(errs, "audit.path is required when audit is enabled")
+	}
+	if c.Audit.MaxSizeMB < 0 {
+		errs = append(errs, "audit.max_size_mb must not be negative")
+	}
+
+	// Logging validation
+	if !logging.ValidFormat(c.Logging.Format) {
+		errs = append(errs, "logging.format must be json or text")
+	}
+	if c.Logging.MaxSizeMB < 0 || c.Logging.MaxAgeDays < 0 || c.Logging.MaxBackups < 0 {
+		errs = append(errs, "logging.max_size_mb, max_age_days and max_backups must not be negative")
 	}
 
 	if len(errs) > 0 {
@@ -226,16 +755,407 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// InstrumentSpecs returns the built-in instrument specs with the
+// market.instruments overrides applied. On error the map still holds every
+// spec that validated.
+func (c *Config) InstrumentSpecs() (map[string]types.InstrumentSpec, error) {
+	specs := make(map[string]types.InstrumentSpec)
+	for _, spec := range []types.InstrumentSpec{types.InstrumentMES, types.InstrumentES, types.InstrumentMGC} {
+		specs[spec.Symbol] = spec
+	}
+
+	var errs []string
+	for symbol, override := range c.Market.Instruments {
+		spec, ok := specs[symbol]
+		if !ok {
+			spec = types.InstrumentSpec{Symbol: symbol}
+		}
+		if override.TickSize != 0 {
+			spec.TickSize = decimal.NewFromFloat(override.TickSize)
+		}
+		if override.TickValue != 0 {
+			spec.TickValue = decimal.NewFromFloat(override.TickValue)
+		}
+		if override.PointValue != 0 {
+			spec.PointValue = decimal.NewFromFloat(override.PointValue)
+		}
+		if override.MarginInitial != 0 {
+			spec.MarginInitial = decimal.NewFromFloat(override.MarginInitial)
+		}
+		if override.MarginIntraday != 0 {
+			spec.MarginIntra = decimal.NewFromFloat(override.MarginIntraday)
+		}
+		if override.ExchangeFee != 0 {
+			spec.ExchangeFee = decimal.NewFromFloat(override.ExchangeFee)
+		}
+		if override.MiniSymbol != "" {
+			spec.MiniSymbol = override.MiniSymbol
+		}
+		if override.MicrosPerMini != 0 {
+			spec.MicrosPerMini = override.MicrosPerMini
+		}
+
+		if err := spec.Validate(); err != nil {
+			errs = append(errs, fmt.Sprintf("market.instruments.%s: %v", symbol, err))
+			continue
+		}
+		specs[symbol] = spec
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return specs, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return specs, nil
+}
+
+// Warnings returns settings that are valid but probably mistaken.
+func (c *Config) Warnings() []string {
+	var warnings []string
+	if !c.Risk.StrictRewardRisk && c.lowRewardRisk() {
+		warnings = append(warnings, fmt.Sprintf(
+			"risk.take_profit_atr_multiple (%g) <= risk.stop_loss_atr_multiple (%g): reward:risk is below 1:1",
+			c.Risk.TakeProfitATRMultiple, c.Risk.StopLossATRMultiple))
+	}
+	return warnings
+}
+
+// lowRewardRisk reports whether the take-profit distance is no larger than
+// the stop distance.
+func (c *Config) lowRewardRisk() bool {
+	return c.Risk.StopLossATRMultiple > 0 && c.Risk.TakeProfitATRMultiple > 0 &&
+		c.Risk.TakeProfitATRMultiple <= c.Risk.StopLossATRMultiple
+}
+
 // ToRiskConfig converts to risk.Config.
 func (c *Config) ToRiskConfig() risk.Config {
 	return risk.Config{
 		MaxGlobalDrawdownPct:    decimal.NewFromFloat(c.Account.MaxGlobalDrawdownPct),
+		DrawdownWarnPct:         c.drawdownWarnPct(),
 		RiskPerTradePct:         decimal.NewFromFloat(c.Account.RiskPerTradePct),
 		MaxExposurePerSymbolPct: decimal.NewFromFloat(c.Risk.MaxExposurePerSymbolPct),
 		MaxTotalExposurePct:     decimal.NewFromFloat(c.Risk.MaxTotalExposurePct),
 		StopLossATRMultiple:     decimal.NewFromFloat(c.Risk.StopLossATRMultiple),
 		TakeProfitATRMultiple:   decimal.NewFromFloat(c.Risk.TakeProfitATRMultiple),
+		MinStopTicks:            c.Risk.MinStopTicks,
+		MaxStopTicks:            c.Risk.MaxStopTicks,
+		MaxContractsPerOrder:    c.Risk.MaxContractsPerOrder,
+		MaxOpenPositions:        c.Risk.MaxOpenPositions,
+		MaxMiniEquivalent:       decimal.NewFromFloat(c.Risk.MaxMiniEquivalent),
+		AllowLong:               c.Risk.AllowedDirection != "short",
+		AllowShort:              c.Risk.AllowedDirection != "long",
+		SignalValidity:          time.Duration(c.Execution.SignalValiditySec) * time.Second,
+		MinNetProfitPerContract: decimal.NewFromFloat(c.Risk.MinNetProfitPerContract),
+		CommissionPerSide:       c.commissionPerSideEstimate(),
+		ItemizedFees:            len(c.Backtest.CommissionTiers) > 0,
+		SlippageTicks:           c.Backtest.SlippageTicks,
+		MaxDailyLossPct:         decimal.NewFromFloat(c.Account.MaxDailyLossPct),
+		DailyProfitTargetPct:    decimal.NewFromFloat(c.Account.DailyProfitTargetPct),
+		SessionLocation:         c.MarketLocation(),
+		SessionStartTime:        c.SessionStartOffset(),
+		RiskScalingCurve:        c.riskScalingCurve(),
+		MarginPolicy:            c.marginPolicy(),
+		CostBasis:               c.costBasis(),
+		SizingMode:              c.sizingMode(),
+		FixedContracts:          c.Risk.FixedContracts,
+		FixedNotional:           decimal.NewFromFloat(c.Risk.FixedNotional),
+		EntryPriceSource:        c.entryPriceSource(),
+		Buckets:                 c.riskBuckets(),
+		TakeProfitTranches:      c.takeProfitTranches(),
+
+		PostKillSwitchCooldown:   time.Duration(c.Risk.PostKillSwitchCooldownMin) * time.Minute,
+		PostKillSwitchRiskFactor: decimal.NewFromFloat(c.Risk.PostKillSwitchRiskFactor),
+	}
+}
+
+// commissionPerSideEstimate returns the per-side commission the risk engine
+// weighs targets against: the flat all-in rate, or the first tier's broker
+// rate before exchange fees.
+func (c *Config) commissionPerSideEstimate() decimal.Decimal {
+	if len(c.Backtest.CommissionTiers) > 0 {
+		return decimal.NewFromFloat(c.Backtest.CommissionTiers[0].PerContract)
+	}
+	return decimal.NewFromFloat(c.Backtest.CommissionPerContract / 2)
+}
+
+// riskBuckets converts the per-strategy drawdown budgets.
+func (c *Config) riskBuckets() []risk.BucketConfig {
+	if len(c.Risk.Buckets) == 0 {
+		return nil
+	}
+	buckets := make([]risk.BucketConfig, len(c.Risk.Buckets))
+	for i, b := range c.Risk.Buckets {
+		allocation := b.Allocation
+		if allocation == 0 {
+			allocation = c.Account.StartingEquity
+		}
+		buckets[i] = risk.BucketConfig{
+			Name:           b.Strategy,
+			Allocation:     decimal.NewFromFloat(allocation),
+			MaxDrawdownPct: decimal.NewFromFloat(b.MaxDrawdownPct),
+		}
+	}
+	return buckets
+}
+
+// takeProfitTranches converts the scale-out targets.
+func (c *Config) takeProfitTranches() []risk.TrancheConfig {
+	if len(c.Risk.TakeProfitTranches) == 0 {
+		return nil
+	}
+	tranches := make([]risk.TrancheConfig, len(c.Risk.TakeProfitTranches))
+	for i, t := range c.Risk.TakeProfitTranches {
+		tranches[i] = risk.TrancheConfig{
+			ATRMultiple: decimal.NewFromFloat(t.ATRMultiple),
+			Fraction:    decimal.NewFromFloat(t.Fraction),
+		}
+	}
+	return tranches
+}
+
+// marginPolicy returns the free-margin check for new orders.
+func (c *Config) marginPolicy() risk.MarginPolicy {
+	policy, _ := risk.ParseMarginPolicy(c.Risk.MarginCheck) // Checked by Validate
+	return policy
+}
+
+// costBasis returns how the risk engine accounts for partial closes.
+func (c *Config) costBasis() risk.CostBasis {
+	basis, _ := risk.ParseCostBasis(c.Risk.PartialCloseAccounting) // Checked by Validate
+	return basis
+}
+
+// sizingMode returns how the risk engine counts contracts.
+func (c *Config) sizingMode() risk.SizingMode {
+	mode, _ := risk.ParseSizingMode(c.Risk.SizingMode) // Checked by Validate
+	return mode
+}
+
+// entryPriceSource returns the price the risk engine sizes orders from.
+func (c *Config) entryPriceSource() risk.EntryPriceSource {
+	source, _ := risk.ParseEntryPriceSource(c.Backtest.EntryPriceSource) // Checked by Validate
+	return source
+}
+
+// FillAtNextOpen reports whether simulated orders fill at the next bar's
+// open, either set directly or implied by entry_price_source: next_open.
+func (c *Config) FillAtNextOpen() bool {
+	return c.Backtest.FillNextBarOpen || c.entryPriceSource() == risk.EntryAtNextOpen
+}
+
+// riskScalingCurve returns the drawdown risk scaling, or nil when off.
+func (c *Config) riskScalingCurve() risk.RiskScalingCurve {
+	if c.Risk.DrawdownRiskFloor <= 0 {
+		return nil
 	}
+	return risk.LinearRiskScaling{Floor: decimal.NewFromFloat(c.Risk.DrawdownRiskFloor)}
+}
+
+// CalculatorConfig returns the indicator settings for symbol, using its
+// market.instruments atr_period when set.
+func (c *Config) CalculatorConfig(symbol string) observer.CalculatorConfig {
+	atrPeriod := c.Risk.VolatilityLookbackBars
+	if inst, ok := c.Market.Instruments[symbol]; ok && inst.ATRPeriod > 0 {
+		atrPeriod = inst.ATRPeriod
+	}
+	return observer.CalculatorConfig{
+		ATRPeriod:    atrPeriod,
+		StdDevPeriod: 20,
+	}
+}
+
+// SymbolCalculatorConfigs returns indicator settings for each symbol with
+// its own atr_period. Other symbols use CalculatorConfig's default.
+func (c *Config) SymbolCalculatorConfigs() map[string]observer.CalculatorConfig {
+	configs := make(map[string]observer.CalculatorConfig)
+	for symbol, inst := range c.Market.Instruments {
+		if inst.ATRPeriod > 0 {
+			configs[symbol] = c.CalculatorConfig(symbol)
+		}
+	}
+	return configs
+}
+
+// ToAuditConfig converts to audit.Config.
+func (c *Config) ToAuditConfig() audit.Config {
+	return audit.Config{
+		Path:    c.Audit.Path,
+		MaxSize: int64(c.Audit.MaxSizeMB) << 20,
+	}
+}
+
+// ToLoggingConfig converts to logging.Config at the given level.
+func (c *Config) ToLoggingConfig(level slog.Level) logging.Config {
+	return logging.Config{
+		Format:     c.Logging.Format,
+		Level:      level,
+		Path:       c.Logging.Path,
+		MaxSize:    int64(c.Logging.MaxSizeMB) << 20,
+		MaxAge:     time.Duration(c.Logging.MaxAgeDays) * 24 * time.Hour,
+		MaxBackups: c.Logging.MaxBackups,
+	}
+}
+
+// MarketLocation returns the market timezone, or UTC if unset or invalid.
+func (c *Config) MarketLocation() *time.Location {
+	if c.Market.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(c.Market.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// SessionStartOffset returns the session start as an offset from midnight.
+func (c *Config) SessionStartOffset() time.Duration {
+	d, err := parseClock(c.Market.SessionStart)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// parseClock parses an "HH:MM" time of day into an offset from midnight.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// drawdownWarnPct returns the share of the drawdown limit that sends a
+// warning, 75% unless configured. An explicit zero turns the warning off.
+func (c *Config) drawdownWarnPct() decimal.Decimal {
+	if c.Account.DrawdownWarnPct == nil {
+		return decimal.RequireFromString("0.75")
+	}
+	return decimal.NewFromFloat(*c.Account.DrawdownWarnPct)
+}
+
+// timeframes are the bar timeframes the feeds and engine support.
+var timeframes = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+}
+
+// DefaultTimeframe is the bar timeframe used when market.timeframe is empty.
+const DefaultTimeframe = 5 * time.Minute
+
+// ParseTimeframe parses a bar timeframe: 1m, 5m, 15m or 1h. Empty means
+// DefaultTimeframe.
+func ParseTimeframe(s string) (time.Duration, error) {
+	if s == "" {
+		return DefaultTimeframe, nil
+	}
+	d, ok := timeframes[s]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q (want 1m, 5m, 15m or 1h)", types.ErrInvalidTimeframe, s)
+	}
+	return d, nil
+}
+
+// TimeframeDuration returns the bar timeframe as a duration.
+func (c *Config) TimeframeDuration() time.Duration {
+	d, _ := ParseTimeframe(c.Market.Timeframe) // Checked by Validate
+	return d
+}
+
+// CommissionModel returns the configured commission model.
+// Flat per-contract commission is used unless commission tiers are set.
+func (c *Config) CommissionModel() execution.CommissionModel {
+	if len(c.Backtest.CommissionTiers) == 0 {
+		return execution.NewFlatCommission(decimal.NewFromFloat(c.Backtest.CommissionPerContract / 2))
+	}
+
+	tiers := make([]execution.CommissionTier, 0, len(c.Backtest.CommissionTiers))
+	for _, t := range c.Backtest.CommissionTiers {
+		tiers = append(tiers, execution.CommissionTier{
+			UpToContracts: t.UpToContracts,
+			PerContract:   decimal.NewFromFloat(t.PerContract),
+		})
+	}
+	return execution.NewTieredCommission(tiers)
+}
+
+// MakerCommissionModel returns the commission model for resting limit
+// fills, or nil when they pay the same as other fills.
+func (c *Config) MakerCommissionModel() execution.CommissionModel {
+	if c.Backtest.MakerCommissionPerSide == nil {
+		return nil
+	}
+	return execution.NewFlatCommission(decimal.NewFromFloat(*c.Backtest.MakerCommissionPerSide))
+}
+
+// SlippageModel returns the configured slippage model.
+// Fixed tick slippage is used unless an ATR fraction is set, in which case
+// slippage_ticks becomes the floor.
+func (c *Config) SlippageModel() execution.SlippageModel {
+	if c.Backtest.SlippageATRFraction <= 0 {
+		return execution.NewFixedSlippage(c.Backtest.SlippageTicks)
+	}
+	return execution.NewATRSlippage(decimal.NewFromFloat(c.Backtest.SlippageATRFraction), c.Backtest.SlippageTicks)
+}
+
+// SpreadEstimator returns the backtest bid/ask approximation, or nil when
+// neither an ATR fraction nor a minimum spread is set.
+func (c *Config) SpreadEstimator() *observer.SpreadEstimator {
+	if c.Backtest.SpreadATRFraction <= 0 && c.Backtest.SpreadMinTicks <= 0 {
+		return nil
+	}
+	return observer.NewSpreadEstimator(decimal.NewFromFloat(c.Backtest.SpreadATRFraction), c.Backtest.SpreadMinTicks)
+}
+
+// AmbiguousBarPolicy returns the backtest same-bar stop/target resolution.
+func (c *Config) AmbiguousBarPolicy() execution.AmbiguousBarPolicy {
+	policy, _ := execution.ParseAmbiguousBarPolicy(c.Backtest.AmbiguousBarPolicy) // Checked by Validate
+	return policy
+}
+
+// Fingerprint returns a short hash of the settings that change backtest
+// results (account, market, risk and backtest sections), so runs with the
+// same fingerprint are comparable. Alerting, broker and other operational
+// settings are left out.
+func (c *Config) Fingerprint() string {
+	data, _ := yaml.Marshal(struct {
+		Account  AccountConfig
+		Market   MarketConfig
+		Risk     RiskConfig
+		Backtest BacktestConfig
+	}{c.Account, c.Market, c.Risk, c.Backtest}) // Plain structs always marshal
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// DisableCosts zeroes backtest commission and slippage, including the
+// tiered and ATR-scaled models, for a theoretical run of raw edge. The
+// change shows in Fingerprint, so cost-free runs aren't compared with real
+// ones.
+func (c *Config) DisableCosts() {
+	c.Backtest.CommissionPerContract = 0
+	c.Backtest.CommissionTiers = nil
+	c.Backtest.MakerCommissionPerSide = nil
+	c.Backtest.SlippageTicks = 0
+	c.Backtest.SlippageATRFraction = 0
+	c.Backtest.EntrySlippageTicks = 0
+	c.Backtest.ExitSlippageTicks = 0
+	c.Backtest.StopSlippageTicks = 0
+}
+
+// DisplayFormat returns the print precision for symbol.
+func (c *Config) DisplayFormat(symbol string) ui.Format {
+	price, percent := ui.AutoDecimals, ui.AutoDecimals
+	if p := c.Display.PricePrecision; p != nil {
+		price = int32(*p)
+	}
+	if p := c.Display.PercentPrecision; p != nil {
+		percent = int32(*p)
+	}
+	return ui.NewFormat(symbol, price, percent)
 }
 
 // StartingEquityDecimal returns starting equity as decimal.
@@ -258,6 +1178,16 @@ func (c *Config) ShutdownTimeout() time.Duration {
 	return time.Duration(c.Shutdown.TimeoutSec) * time.Second
 }
 
+// HeartbeatInterval returns the health check interval duration.
+func (c *Config) HeartbeatInterval() time.Duration {
+	return time.Duration(c.Health.HeartbeatIntervalSec) * time.Second
+}
+
+// DataStalenessThreshold returns the max market data age before degraded.
+func (c *Config) DataStalenessThreshold() time.Duration {
+	return time.Duration(c.Health.DataStalenessThresholdSec) * time.Second
+}
+
 // SnapshotInterval returns the snapshot interval duration.
 func (c *Config) SnapshotInterval() time.Duration {
 	return time.Duration(c.Persistence.SnapshotIntervalSec) * time.Second
@@ -1,11 +1,16 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/risk"
+	"github.com/tathienbao/quant-bot/internal/types"
 )
 
 func TestLoadFromBytes_Valid(t *testing.T) {
@@ -106,6 +111,122 @@ risk:
 `,
 			wantErr: "max_global_drawdown_pct must be between 0 and 1",
 		},
+		{
+			name: "daily loss too high",
+			yaml: `
+account:
+  starting_equity: 1000
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+  max_daily_loss_pct: 1.5
+market:
+  instrument_primary: "MES"
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+`,
+			wantErr: "max_daily_loss_pct must be between 0 and 1",
+		},
+		{
+			name: "invalid timezone",
+			yaml: `
+account:
+  starting_equity: 1000
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+market:
+  instrument_primary: "MES"
+  timezone: "Mars/Olympus"
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+`,
+			wantErr: "market.timezone 'Mars/Olympus' is invalid",
+		},
+		{
+			name: "unsupported timeframe",
+			yaml: `
+account:
+  starting_equity: 1000
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+market:
+  instrument_primary: "MES"
+  timeframe: "3m"
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+`,
+			wantErr: `market.timeframe: invalid timeframe: "3m"`,
+		},
+		{
+			name: "warm start without data",
+			yaml: `
+account:
+  starting_equity: 1000
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+market:
+  instrument_primary: "MES"
+  warm_start: true
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+`,
+			wantErr: "market.warm_start_data is required when market.warm_start is enabled",
+		},
+		{
+			name: "commission tiers out of order",
+			yaml: `
+account:
+  starting_equity: 1000
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+market:
+  instrument_primary: "MES"
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+backtest:
+  commission_tiers:
+    - up_to_contracts: 1000
+      per_contract: 0.25
+    - up_to_contracts: 500
+      per_contract: 0.20
+    - up_to_contracts: 0
+      per_contract: 0.15
+`,
+			wantErr: "backtest.commission_tiers must have increasing up_to_contracts",
+		},
+		{
+			name: "rebate on every fill",
+			yaml: `
+account:
+  starting_equity: 1000
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+market:
+  instrument_primary: "MES"
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+backtest:
+  commission_per_contract: -0.4
+`,
+			wantErr: "backtest.commission_per_contract must not be negative",
+		},
 		{
 			name: "risk too high",
 			yaml: `
@@ -197,6 +318,142 @@ persistence:
 `,
 			wantErr: "persistence.path is required for sqlite",
 		},
+		{
+			name: "min stop above max stop",
+			yaml: `
+account:
+  starting_equity: 1000
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+market:
+  instrument_primary: "MES"
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+  min_stop_ticks: 40
+  max_stop_ticks: 20
+`,
+			wantErr: "risk.min_stop_ticks must not exceed risk.max_stop_ticks",
+		},
+		{
+			name: "audit without path",
+			yaml: `
+account:
+  starting_equity: 1000
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+market:
+  instrument_primary: "MES"
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+audit:
+  enabled: true
+`,
+			wantErr: "audit.path is required when audit is enabled",
+		},
+		{
+			name: "inconsistent instrument override",
+			yaml: `
+account:
+  starting_equity: 1000
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+market:
+  instrument_primary: "MES"
+  instruments:
+    MES:
+      tick_value: 2.50
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+`,
+			wantErr: "market.instruments.MES: tick value 2.5 must equal tick size 0.25 * point value 5",
+		},
+		{
+			name: "strict reward risk",
+			yaml: `
+account:
+  starting_equity: 1000
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+market:
+  instrument_primary: "MES"
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 2.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+  strict_reward_risk: true
+`,
+			wantErr: "risk.take_profit_atr_multiple must exceed risk.stop_loss_atr_multiple",
+		},
+		{
+			name: "unknown log format",
+			yaml: `
+account:
+  starting_equity: 1000
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+market:
+  instrument_primary: "MES"
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+logging:
+  format: "xml"
+`,
+			wantErr: "logging.format must be json or text",
+		},
+		{
+			name: "cooldown risk factor above 1",
+			yaml: `
+account:
+  starting_equity: 1000
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+market:
+  instrument_primary: "MES"
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+  post_kill_switch_cooldown_min: 60
+  post_kill_switch_risk_factor: 1.5
+`,
+			wantErr: "risk.post_kill_switch_risk_factor must be between 0 and 1",
+		},
+		{
+			name: "take profit tranches over-allocated",
+			yaml: `
+account:
+  starting_equity: 1000
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+market:
+  instrument_primary: "MES"
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+  take_profit_tranches:
+    - atr_multiple: 1.0
+      fraction: 0.6
+    - atr_multiple: 2.0
+      fraction: 0.6
+`,
+			wantErr: "risk.take_profit_tranches fractions must sum to at most 1",
+		},
 	}
 
 	for _, tt := range tests {
@@ -213,6 +470,108 @@ persistence:
 	}
 }
 
+func TestConfig_Warnings(t *testing.T) {
+	cfg := &Config{Risk: RiskConfig{StopLossATRMultiple: 2, TakeProfitATRMultiple: 3}}
+	if warnings := cfg.Warnings(); len(warnings) != 0 {
+		t.Errorf("Warnings() = %v, want none", warnings)
+	}
+
+	cfg.Risk.TakeProfitATRMultiple = 1.5
+	warnings := cfg.Warnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "reward:risk is below 1:1") {
+		t.Errorf("Warnings() = %v, want reward:risk warning", warnings)
+	}
+
+	// Strict mode turns the warning into a validation error
+	cfg.Risk.StrictRewardRisk = true
+	if warnings := cfg.Warnings(); len(warnings) != 0 {
+		t.Errorf("strict Warnings() = %v, want none", warnings)
+	}
+}
+
+func TestConfig_InstrumentSpecs(t *testing.T) {
+	cfg := &Config{Market: MarketConfig{Instruments: map[string]InstrumentConfig{
+		"MES": {MarginInitial: 2000, ExchangeFee: 0.40},
+		"MNQ": {TickSize: 0.25, TickValue: 0.50, PointValue: 2},
+	}}}
+
+	specs, err := cfg.InstrumentSpecs()
+	if err != nil {
+		t.Fatalf("InstrumentSpecs() error = %v", err)
+	}
+
+	mes := specs["MES"]
+	if !mes.MarginInitial.Equal(decimal.NewFromInt(2000)) || !mes.ExchangeFee.Equal(decimal.RequireFromString("0.40")) {
+		t.Errorf("MES margin/fee = %s/%s, want 2000/0.40", mes.MarginInitial, mes.ExchangeFee)
+	}
+	if !mes.TickValue.Equal(types.InstrumentMES.TickValue) {
+		t.Errorf("MES tick value = %s, want built-in %s", mes.TickValue, types.InstrumentMES.TickValue)
+	}
+	if _, ok := specs["MGC"]; !ok {
+		t.Error("expected built-in MGC spec")
+	}
+	if mnq, ok := specs["MNQ"]; !ok || !mnq.PointValue.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("MNQ spec = %+v, %v", mnq, ok)
+	}
+
+	// A new symbol needs a complete spec
+	cfg.Market.Instruments = map[string]InstrumentConfig{"MYM": {TickSize: 1}}
+	if _, err := cfg.InstrumentSpecs(); err == nil {
+		t.Error("expected error for incomplete MYM spec")
+	}
+}
+
+func TestConfig_CalculatorConfig(t *testing.T) {
+	cfg := &Config{
+		Risk: RiskConfig{VolatilityLookbackBars: 20},
+		Market: MarketConfig{Instruments: map[string]InstrumentConfig{
+			"MGC": {ATRPeriod: 10},
+			"MES": {ExchangeFee: 0.40},
+		}},
+	}
+
+	if got := cfg.CalculatorConfig("MGC").ATRPeriod; got != 10 {
+		t.Errorf("MGC ATRPeriod = %d, want 10", got)
+	}
+	if got := cfg.CalculatorConfig("MES").ATRPeriod; got != 20 {
+		t.Errorf("MES ATRPeriod = %d, want default 20", got)
+	}
+
+	configs := cfg.SymbolCalculatorConfigs()
+	if len(configs) != 1 || configs["MGC"].ATRPeriod != 10 {
+		t.Errorf("SymbolCalculatorConfigs() = %+v, want only MGC", configs)
+	}
+}
+
+func TestLoadFromBytes_RegistersInstruments(t *testing.T) {
+	yaml := `
+account:
+  starting_equity: 1000
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+market:
+  instrument_primary: "M2K"
+  instruments:
+    M2K:
+      tick_size: 0.1
+      tick_value: 0.5
+      point_value: 5
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+`
+	if _, err := LoadFromBytes([]byte(yaml)); err != nil {
+		t.Fatalf("LoadFromBytes() error = %v", err)
+	}
+
+	spec, ok := types.GetInstrumentSpec("M2K")
+	if !ok || !spec.TickSize.Equal(decimal.RequireFromString("0.1")) {
+		t.Errorf("GetInstrumentSpec(M2K) = %+v, %v", spec, ok)
+	}
+}
+
 func TestConfig_ToRiskConfig(t *testing.T) {
 	cfg := &Config{
 		Account: AccountConfig{
@@ -240,6 +599,91 @@ func TestConfig_ToRiskConfig(t *testing.T) {
 	if !riskCfg.StopLossATRMultiple.Equal(decimal.RequireFromString("2")) {
 		t.Errorf("StopLossATRMultiple = %s, want 2", riskCfg.StopLossATRMultiple)
 	}
+	if !riskCfg.AllowLong || !riskCfg.AllowShort {
+		t.Errorf("AllowLong/AllowShort = %v/%v, want both allowed by default", riskCfg.AllowLong, riskCfg.AllowShort)
+	}
+
+	if riskCfg.RiskScalingCurve != nil {
+		t.Errorf("RiskScalingCurve = %v, want nil by default", riskCfg.RiskScalingCurve)
+	}
+
+	cfg.Risk.DrawdownRiskFloor = 0.25
+	if curve, ok := cfg.ToRiskConfig().RiskScalingCurve.(risk.LinearRiskScaling); !ok || !curve.Floor.Equal(decimal.RequireFromString("0.25")) {
+		t.Errorf("RiskScalingCurve = %v, want linear with floor 0.25", cfg.ToRiskConfig().RiskScalingCurve)
+	}
+
+	if riskCfg.MarginPolicy != risk.MarginUnchecked {
+		t.Errorf("MarginPolicy = %s, want off by default", riskCfg.MarginPolicy)
+	}
+	cfg.Risk.MarginCheck = "downsize"
+	if got := cfg.ToRiskConfig().MarginPolicy; got != risk.MarginDownsize {
+		t.Errorf("MarginPolicy = %s, want downsize", got)
+	}
+	cfg.Risk.PartialCloseAccounting = "fifo"
+	if got := cfg.ToRiskConfig().CostBasis; got != risk.CostFIFO {
+		t.Errorf("CostBasis = %s, want fifo", got)
+	}
+	if cfg.FillAtNextOpen() {
+		t.Error("FillAtNextOpen should be off by default")
+	}
+	cfg.Backtest.EntryPriceSource = "next_open"
+	if got := cfg.ToRiskConfig().EntryPriceSource; got != risk.EntryAtNextOpen {
+		t.Errorf("EntryPriceSource = %s, want next_open", got)
+	}
+	if !cfg.FillAtNextOpen() {
+		t.Error("next_open entries should fill at the next bar's open")
+	}
+
+	if got := cfg.ToRiskConfig().DrawdownWarnPct; !got.Equal(decimal.RequireFromString("0.75")) {
+		t.Errorf("DrawdownWarnPct = %s, want 0.75 by default", got)
+	}
+	warnPct := 0.5
+	cfg.Account.DrawdownWarnPct = &warnPct
+	if got := cfg.ToRiskConfig().DrawdownWarnPct; !got.Equal(decimal.RequireFromString("0.5")) {
+		t.Errorf("DrawdownWarnPct = %s, want 0.5", got)
+	}
+	warnPct = 0
+	if got := cfg.ToRiskConfig().DrawdownWarnPct; !got.IsZero() {
+		t.Errorf("DrawdownWarnPct = %s, want 0 to disable the warning", got)
+	}
+
+	cfg.Risk.MaxMiniEquivalent = 2
+	if got := cfg.ToRiskConfig().MaxMiniEquivalent; !got.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("MaxMiniEquivalent = %s, want 2", got)
+	}
+
+	cfg.Risk.SizingMode = "fixed_contracts"
+	cfg.Risk.FixedContracts = 2
+	if got := cfg.ToRiskConfig(); got.SizingMode != risk.SizingFixedContracts || got.FixedContracts != 2 {
+		t.Errorf("sizing = %s with %d contracts, want fixed_contracts with 2", got.SizingMode, got.FixedContracts)
+	}
+	cfg.Risk.SizingMode = ""
+
+	cfg.Risk.PostKillSwitchCooldownMin = 90
+	cfg.Risk.PostKillSwitchRiskFactor = 0.5
+	riskCfg = cfg.ToRiskConfig()
+	if riskCfg.PostKillSwitchCooldown != 90*time.Minute || !riskCfg.PostKillSwitchRiskFactor.Equal(decimal.RequireFromString("0.5")) {
+		t.Errorf("cooldown = %s at %s, want 1h30m at 0.5", riskCfg.PostKillSwitchCooldown, riskCfg.PostKillSwitchRiskFactor)
+	}
+
+	cfg.Account.StartingEquity = 10000
+	cfg.Risk.Buckets = []RiskBucketConfig{
+		{Strategy: "grid", Allocation: 4000, MaxDrawdownPct: 0.1},
+		{Strategy: "breakout", MaxDrawdownPct: 0.05},
+	}
+	buckets := cfg.ToRiskConfig().Buckets
+	if len(buckets) != 2 || buckets[0].Name != "grid" || !buckets[0].Allocation.Equal(decimal.NewFromInt(4000)) {
+		t.Fatalf("Buckets = %+v, want grid with 4000 allocation first", buckets)
+	}
+	if !buckets[1].Allocation.Equal(decimal.NewFromInt(10000)) {
+		t.Errorf("breakout Allocation = %s, want starting equity 10000", buckets[1].Allocation)
+	}
+
+	cfg.Risk.AllowedDirection = "long"
+	riskCfg = cfg.ToRiskConfig()
+	if !riskCfg.AllowLong || riskCfg.AllowShort {
+		t.Errorf("long only: AllowLong/AllowShort = %v/%v, want true/false", riskCfg.AllowLong, riskCfg.AllowShort)
+	}
 }
 
 func TestConfig_Durations(t *testing.T) {
@@ -273,6 +717,128 @@ func TestConfig_Durations(t *testing.T) {
 	}
 }
 
+func TestParseTimeframe(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"1m", time.Minute, false},
+		{"5m", 5 * time.Minute, false},
+		{"15m", 15 * time.Minute, false},
+		{"1h", time.Hour, false},
+		{"", DefaultTimeframe, false},
+		{"3m", 0, true},
+		{"60m", 0, true},
+		{"5M", 0, true},
+		{"daily", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseTimeframe(tt.in)
+			if tt.wantErr {
+				if !errors.Is(err, types.ErrInvalidTimeframe) {
+					t.Errorf("ParseTimeframe(%q) error = %v, want ErrInvalidTimeframe", tt.in, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTimeframe(%q) error = %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTimeframe(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_TimeframeDuration(t *testing.T) {
+	cfg := &Config{Market: MarketConfig{Timeframe: "15m"}}
+	if got := cfg.TimeframeDuration(); got != 15*time.Minute {
+		t.Errorf("TimeframeDuration() = %v, want 15m", got)
+	}
+}
+
+func TestConfig_DisplayFormat(t *testing.T) {
+	price := decimal.RequireFromString("5000.25")
+
+	cfg := &Config{}
+	if got := cfg.DisplayFormat("MES").Price(price); got != "5000.25" {
+		t.Errorf("unset precision Price() = %q, want 5000.25", got)
+	}
+
+	zero := 0
+	cfg.Display.PricePrecision = &zero
+	cfg.Display.PercentPrecision = &zero
+	format := cfg.DisplayFormat("MES")
+	if got := format.Price(price); got != "5000" {
+		t.Errorf("zero precision Price() = %q, want 5000", got)
+	}
+	if got := format.Percent(decimal.RequireFromString("0.0123")); got != "1%" {
+		t.Errorf("zero precision Percent() = %q, want 1%%", got)
+	}
+}
+
+func TestConfig_Fingerprint(t *testing.T) {
+	base := &Config{
+		Account: AccountConfig{StartingEquity: 10000},
+		Risk:    RiskConfig{StopLossATRMultiple: 2},
+	}
+	same := *base
+	same.Alerting.Enabled = true // Operational settings don't affect results
+
+	if base.Fingerprint() != same.Fingerprint() {
+		t.Error("alerting change altered the fingerprint")
+	}
+
+	changed := *base
+	changed.Risk.StopLossATRMultiple = 3
+	if base.Fingerprint() == changed.Fingerprint() {
+		t.Error("risk change did not alter the fingerprint")
+	}
+}
+
+func TestConfig_DisableCosts(t *testing.T) {
+	cfg := &Config{
+		Market: MarketConfig{InstrumentPrimary: "MES"},
+		Backtest: BacktestConfig{
+			SlippageTicks:         2,
+			SlippageATRFraction:   0.1,
+			ExitSlippageTicks:     1,
+			StopSlippageTicks:     3,
+			CommissionPerContract: 1.24,
+			CommissionTiers:       []CommissionTierConfig{{UpToContracts: 1000, PerContract: 0.85}},
+		},
+	}
+	rebate := -0.2
+	cfg.Backtest.MakerCommissionPerSide = &rebate
+	before := cfg.Fingerprint()
+
+	cfg.DisableCosts()
+
+	bar := types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000), ATR: decimal.NewFromInt(10)}
+	if got := cfg.CommissionModel().Commission("MES", 10, bar.Close); !got.IsZero() {
+		t.Errorf("commission = %s, want 0", got)
+	}
+	if got := cfg.SlippageModel().Slippage("MES", 10, bar); !got.IsZero() {
+		t.Errorf("slippage = %s, want 0", got)
+	}
+	if cfg.MakerCommissionModel() != nil {
+		t.Error("maker rebate should be disabled with the other costs")
+	}
+	if cfg.Backtest.ExitSlippageTicks != 0 || cfg.Backtest.StopSlippageTicks != 0 {
+		t.Errorf("exit/stop slippage = %d/%d ticks, want zero", cfg.Backtest.ExitSlippageTicks, cfg.Backtest.StopSlippageTicks)
+	}
+	riskCfg := cfg.ToRiskConfig()
+	if !riskCfg.CommissionPerSide.IsZero() || riskCfg.SlippageTicks != 0 {
+		t.Errorf("risk config costs = %s/%d ticks, want zero", riskCfg.CommissionPerSide, riskCfg.SlippageTicks)
+	}
+	if cfg.Fingerprint() == before {
+		t.Error("disabling costs did not alter the fingerprint")
+	}
+}
+
 func TestLoad_FromFile(t *testing.T) {
 	// Create temp config file
 	tmpDir := t.TempDir()
@@ -308,6 +874,112 @@ risk:
 	}
 }
 
+func TestLoadFiles_Merge(t *testing.T) {
+	tmpDir := t.TempDir()
+	basePath := filepath.Join(tmpDir, "base.yaml")
+	overridePath := filepath.Join(tmpDir, "live.yaml")
+
+	base := `
+account:
+  starting_equity: 2000.0
+  max_global_drawdown_pct: 0.15
+  risk_per_trade_pct: 0.02
+
+market:
+  instrument_primary: "MES"
+
+risk:
+  stop_loss_atr_multiple: 2.5
+  take_profit_atr_multiple: 3.5
+  max_exposure_per_symbol_pct: 0.4
+  max_total_exposure_pct: 0.8
+
+broker:
+  host: "127.0.0.1"
+  port: 7497
+
+alerting:
+  events: ["kill_switch", "daily_loss"]
+`
+	// Overrides one nested field, adds another and replaces a list
+	override := `
+account:
+  starting_equity: 5000.0
+
+broker:
+  port: 7496
+  client_id: 7
+
+alerting:
+  events: ["order_timeout"]
+`
+	if err := os.WriteFile(basePath, []byte(base), 0644); err != nil {
+		t.Fatalf("write base: %v", err)
+	}
+	if err := os.WriteFile(overridePath, []byte(override), 0644); err != nil {
+		t.Fatalf("write override: %v", err)
+	}
+
+	cfg, err := LoadFiles(basePath, overridePath)
+	if err != nil {
+		t.Fatalf("LoadFiles() error = %v", err)
+	}
+
+	// Scalar override
+	if cfg.Account.StartingEquity != 5000 {
+		t.Errorf("StartingEquity = %v, want 5000", cfg.Account.StartingEquity)
+	}
+	// Nested struct merge keeps untouched siblings
+	if cfg.Account.RiskPerTradePct != 0.02 {
+		t.Errorf("RiskPerTradePct = %v, want 0.02 from base", cfg.Account.RiskPerTradePct)
+	}
+	if cfg.Broker.Host != "127.0.0.1" || cfg.Broker.Port != 7496 || cfg.Broker.ClientID != 7 {
+		t.Errorf("Broker = %+v, want base host with overridden port and client id", cfg.Broker)
+	}
+	if cfg.Risk.StopLossATRMultiple != 2.5 {
+		t.Errorf("StopLossATRMultiple = %v, want 2.5 from base", cfg.Risk.StopLossATRMultiple)
+	}
+	// Lists are replaced, not appended
+	if len(cfg.Alerting.Events) != 1 || cfg.Alerting.Events[0] != "order_timeout" {
+		t.Errorf("Events = %v, want [order_timeout]", cfg.Alerting.Events)
+	}
+}
+
+func TestLoadFromBytes_ValidatesMergedResult(t *testing.T) {
+	// Invalid alone: no starting equity
+	base := []byte(`
+market:
+  instrument_primary: "MES"
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.4
+  max_total_exposure_pct: 0.8
+`)
+	if _, err := LoadFromBytes(base); err == nil {
+		t.Fatal("expected base alone to be invalid")
+	}
+
+	override := []byte(`
+account:
+  starting_equity: 2000.0
+  max_global_drawdown_pct: 0.2
+  risk_per_trade_pct: 0.01
+`)
+	if _, err := LoadFromBytes(base, override); err != nil {
+		t.Errorf("merged config error = %v", err)
+	}
+
+	// An invalid override fails even over a valid base
+	bad := []byte(`
+account:
+  risk_per_trade_pct: 5
+`)
+	if _, err := LoadFromBytes(base, override, bad); err == nil {
+		t.Error("expected invalid override to fail validation")
+	}
+}
+
 func TestLoad_FileNotFound(t *testing.T) {
 	_, err := Load("/nonexistent/path/config.yaml")
 	if err == nil {
@@ -369,3 +1041,157 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestLoad_UnsetEnvironmentVariables(t *testing.T) {
+	os.Unsetenv("QUANT_BOT_TEST_UNSET_TOKEN")
+	os.Unsetenv("QUANT_BOT_TEST_UNSET_OPTIONAL")
+	os.Unsetenv("QUANT_BOT_TEST_UNSET_PATH")
+
+	yaml := `
+account:
+  starting_equity: 1000.0
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+
+market:
+  instrument_primary: "MES"
+
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+
+audit:
+  path: "${QUANT_BOT_TEST_UNSET_PATH}"
+
+alerting:
+  enabled: true
+  channels:
+    - type: telegram
+      bot_token: "${QUANT_BOT_TEST_UNSET_TOKEN}"
+      chat_id: "${QUANT_BOT_TEST_UNSET_OPTIONAL:-12345}"
+    # - type: discord
+    #   webhook_url: "${QUANT_BOT_TEST_COMMENTED}"
+`
+
+	// Unset variables only warn at load
+	cfg, err := LoadFromBytes([]byte(yaml))
+	if err != nil {
+		t.Fatalf("LoadFromBytes() error = %v", err)
+	}
+
+	// Commands that alert fail on the channel's unset variable
+	err = cfg.CheckAlertingEnv()
+	if !errors.Is(err, types.ErrInvalidConfig) {
+		t.Fatalf("CheckAlertingEnv() error = %v, want ErrInvalidConfig", err)
+	}
+	if !strings.Contains(err.Error(), "QUANT_BOT_TEST_UNSET_TOKEN") {
+		t.Errorf("error should name the unset variable, got %v", err)
+	}
+	for _, name := range []string{"QUANT_BOT_TEST_UNSET_OPTIONAL", "QUANT_BOT_TEST_COMMENTED", "QUANT_BOT_TEST_UNSET_PATH"} {
+		if strings.Contains(err.Error(), name) {
+			t.Errorf("error should not name %s, got %v", name, err)
+		}
+	}
+
+	cfg.Alerting.Enabled = false
+	if err := cfg.CheckAlertingEnv(); err != nil {
+		t.Errorf("CheckAlertingEnv() with alerting disabled = %v, want nil", err)
+	}
+
+	// Once set, the optional default applies and the check passes
+	os.Setenv("QUANT_BOT_TEST_UNSET_TOKEN", "token")
+	defer os.Unsetenv("QUANT_BOT_TEST_UNSET_TOKEN")
+
+	cfg, err = LoadFromBytes([]byte(yaml))
+	if err != nil {
+		t.Fatalf("LoadFromBytes() error = %v", err)
+	}
+	if err := cfg.CheckAlertingEnv(); err != nil {
+		t.Errorf("CheckAlertingEnv() = %v, want nil", err)
+	}
+	if cfg.Alerting.Channels[0].ChatID != "12345" {
+		t.Errorf("ChatID = %s, want default 12345", cfg.Alerting.Channels[0].ChatID)
+	}
+}
+
+func TestConfig_DumpRedacted(t *testing.T) {
+	cfg, err := LoadFromBytes([]byte(`
+account:
+  starting_equity: 1000.0
+  max_global_drawdown_pct: 0.20
+  risk_per_trade_pct: 0.01
+
+market:
+  instrument_primary: "MES"
+
+risk:
+  stop_loss_atr_multiple: 2.0
+  take_profit_atr_multiple: 3.0
+  max_exposure_per_symbol_pct: 0.5
+  max_total_exposure_pct: 1.0
+
+persistence:
+  dsn: "postgres://bot:hunter2@db:5432/quant"
+
+alerting:
+  channels:
+    - type: telegram
+      bot_token: "123:secret"
+      chat_id: "42"
+`))
+	if err != nil {
+		t.Fatalf("LoadFromBytes() error = %v", err)
+	}
+
+	data, err := cfg.Redacted().Dump(false)
+	if err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+	out := string(data)
+
+	for _, secret := range []string{"hunter2", "123:secret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("dump contains secret %q", secret)
+		}
+	}
+	if !strings.Contains(out, "chat_id: \"42\"") {
+		t.Errorf("dump missing non-secret field, got:\n%s", out)
+	}
+	// Defaults filled in by Validate are included
+	if !strings.Contains(out, "order_timeout_sec: 5") {
+		t.Errorf("dump missing defaulted order_timeout_sec, got:\n%s", out)
+	}
+
+	// Original config is untouched
+	if cfg.Alerting.Channels[0].BotToken != "123:secret" {
+		t.Error("Redacted() modified the original config")
+	}
+
+	jsonData, err := cfg.Dump(true)
+	if err != nil {
+		t.Fatalf("Dump(json) error = %v", err)
+	}
+	if !strings.Contains(string(jsonData), `"bot_token": "123:secret"`) {
+		t.Errorf("JSON dump should use yaml keys and keep secrets when not redacted, got:\n%s", jsonData)
+	}
+}
+
+func TestRedactDSN(t *testing.T) {
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{"", ""},
+		{"postgres://bot:hunter2@db:5432/quant", "postgres://bot:REDACTED@db:5432/quant"},
+		{"host=db user=bot password=hunter2 dbname=quant", "host=db user=bot password=REDACTED dbname=quant"},
+		{"postgres://db:5432/quant", "postgres://db:5432/quant"},
+	}
+
+	for _, tt := range tests {
+		if got := redactDSN(tt.dsn); got != tt.want {
+			t.Errorf("redactDSN(%q) = %q, want %q", tt.dsn, got, tt.want)
+		}
+	}
+}
@@ -2,17 +2,24 @@
 package engine
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/tathienbao/quant-bot/internal/alerting"
+	"github.com/tathienbao/quant-bot/internal/audit"
 	"github.com/tathienbao/quant-bot/internal/broker"
+	"github.com/tathienbao/quant-bot/internal/execution"
 	"github.com/tathienbao/quant-bot/internal/metrics"
 	"github.com/tathienbao/quant-bot/internal/observer"
+	"github.com/tathienbao/quant-bot/internal/persistence"
 	"github.com/tathienbao/quant-bot/internal/risk"
 	"github.com/tathienbao/quant-bot/internal/strategy"
 	"github.com/tathienbao/quant-bot/internal/types"
@@ -23,8 +30,61 @@ type Config struct {
 	Symbol           string
 	Timeframe        time.Duration
 	EquityUpdateInterval time.Duration
+	FlattenOnDailyLoss   bool // Close open positions when the daily loss limit is hit
+	ConfirmationBars     int  // Consecutive same-direction signals required before entry (0/1 = first signal)
+	FlattenOnKillSwitch  bool // Close open positions when the kill switch fires
+	FlattenTimeout       time.Duration // Max wait for flatten fills (0 = 10s)
+	MaxSpreadTicks       int  // Reject signals when bid/ask spread exceeds this (0 = disabled)
+	MinSignalStrength    decimal.Decimal // Drop entry signals with a lower Strength (0 = accept all)
+	StaleDataThreshold   time.Duration // Max delay past the expected next bar before degraded (0 = disabled)
+	HeartbeatInterval    time.Duration // How often the watchdog checks data age (0 = threshold/2)
+	MaxMissedHeartbeats  int             // Loop heartbeats missed before LoopHealthCheck fails (0 = 3)
+	FlattenOnStaleData   bool // Close open positions when market data goes stale
+	CheckDataLag         bool // Skip bars that end more than StaleDataThreshold before wall-clock time (live data only)
+	SnapshotInterval     time.Duration // How often to persist equity snapshots (0 = disabled)
+	OrderTimeout         time.Duration // Deadline for each broker order, cancel and account call (0 = 5s)
+	MaxRetries           int           // Extra attempts after a transient order error (0 = no retry)
+	RetryDelay           time.Duration // Wait before the first retry; doubles on each further retry
+	PositionReadout      bool          // Log each open position's P&L and distance to stop/TP every EquityUpdateInterval
+	PositionReadoutAlert bool          // Also send the readout as an info alert
+
+	// Symbols lists further symbols traded alongside Symbol. Signals for any
+	// other symbol are dropped before they reach the risk engine.
+	Symbols []string
+
+	// Calculators gives symbols their own indicator settings, so each
+	// symbol's ATR is measured independently. Symbol uses the calculator
+	// passed to NewEngine unless listed here; every other symbol gets its
+	// own calculator built from CalculatorDefaults.
+	Calculators        map[string]observer.CalculatorConfig
+	CalculatorDefaults observer.CalculatorConfig // Zero = observer.DefaultCalculatorConfig()
+
+	// Opposite signals: by default a signal against an open position is sized
+	// as a new entry. ReverseOnOppositeSignal closes the position instead, and
+	// FlipOnOppositeSignal then also enters the signal's direction.
+	ReverseOnOppositeSignal bool
+	FlipOnOppositeSignal    bool
 }
 
+// DefaultFlattenTimeout is how long FlattenAll waits for positions to close
+// when no timeout is configured.
+const DefaultFlattenTimeout = 10 * time.Second
+
+// DefaultOrderTimeout bounds a single broker call when no timeout is configured.
+const DefaultOrderTimeout = 5 * time.Second
+
+// DefaultMaxMissedHeartbeats is how many loop heartbeats may be missed
+// before the loop counts as stalled when no limit is configured.
+const DefaultMaxMissedHeartbeats = 3
+
+// DefaultLoopHeartbeat is how often an idle trading loop reports itself
+// alive when no heartbeat interval is configured.
+const DefaultLoopHeartbeat = 10 * time.Second
+
+// reverseAttempt is the order attempt closing a position on an opposite
+// signal; the signal's own entry is attempt 0.
+const reverseAttempt = 1
+
 // DefaultConfig returns default engine config.
 func DefaultConfig() Config {
 	return Config{
@@ -41,27 +101,99 @@ type Engine struct {
 	broker     broker.Broker
 	riskEngine *risk.Engine
 	strategy   strategy.Strategy
-	calculator *observer.Calculator
+	calculator observer.Indicators
+	perSymbol  map[string]observer.Indicators // symbol -> own indicators (guarded by mu)
 	alerter    alerting.Alerter
 	recorder   *metrics.Recorder
+	audit      *audit.Recorder // Optional; nil disables the audit trail
+	snapshots  SnapshotStore   // Optional; nil disables equity snapshots
+	orders     OrderStore      // Optional; nil keeps client order IDs in the broker's memory only
+	hub        *metrics.Hub    // Optional; nil disables the dashboard state stream
 
 	// State
 	mu        sync.RWMutex
 	running   bool
 	lastEvent types.MarketEvent
 
+	// Data staleness watchdog (guarded by mu)
+	startedAt   time.Time
+	lastEventAt time.Time // Wall-clock receive time of lastEvent
+	degraded    bool
+
+	// Trading loop liveness (guarded by mu)
+	loopBeatAt time.Time // Last loop iteration or idle heartbeat
+	loopExited bool
+
+	// Entry confirmation (owned by the trading loop)
+	barCount      map[string]int
+	confirmations map[confirmKey]confirmation
+
+	// Symbol -> strategy of the latest entry; closed trades are charged to its risk bucket (guarded by mu)
+	openedBy map[string]string
+
+	// Position readout: latest close and entry bracket per symbol (guarded by mu)
+	lastPrice map[string]decimal.Decimal
+	brackets  map[string]entryBracket
+
+	// Dashboard state: latest signal and broker positions (guarded by mu)
+	lastSignal      *types.Signal
+	streamPositions []broker.Position
+
+	// Daily loss tracking (owned by the equity update loop)
+	lastRealizedPnL    decimal.Decimal
+	dailyLossHandled   bool
+	dailyTargetHandled bool
+	drawdownWarned     bool
+
+	// Position tracking runs on fills and equity polls; trackMu keeps one
+	// at a time so each close is booked once. tradeStats is the closed trade
+	// tally behind the live profit factor/expectancy, entryCommission the
+	// commission paid opening each position, charged to its closes, and
+	// closeLog collects booked trades while set (all guarded by trackMu).
+	trackMu         sync.Mutex
+	tradeStats      types.TradeStats
+	entryCommission map[string]decimal.Decimal
+	closeLog        *[]types.Trade
+
+	// Closed trades passed to the strategy through the trading loop
+	closedTrades chan types.Trade
+
+	// Entry orders awaiting their fill, by client order ID (owned by the
+	// trading loop). Broker fill callbacks reach the loop through fills.
+	pendingEntries map[string]pendingEntry
+	fills          chan broker.Order
+
 	// Channels
 	done chan struct{}
 	wg   sync.WaitGroup
 }
 
+// pendingEntry is an entry signal whose order hasn't filled yet.
+type pendingEntry struct {
+	signal    types.Signal
+	expiresAt time.Time
+}
+
+// confirmKey identifies a confirmation streak.
+type confirmKey struct {
+	symbol    string
+	direction types.Side
+}
+
+// confirmation tracks consecutive same-direction signals.
+type confirmation struct {
+	count   int
+	lastBar int
+	lastAt  time.Time
+}
+
 // NewEngine creates a new trading engine.
 func NewEngine(
 	cfg Config,
 	brk broker.Broker,
 	riskEngine *risk.Engine,
 	strat strategy.Strategy,
-	calculator *observer.Calculator,
+	calculator observer.Indicators,
 	alerter alerting.Alerter,
 	logger *slog.Logger,
 ) *Engine {
@@ -76,10 +208,122 @@ func NewEngine(
 		riskEngine: riskEngine,
 		strategy:   strat,
 		calculator: calculator,
+		perSymbol:  newSymbolIndicators(cfg),
 		alerter:    alerter,
 		recorder:   metrics.NewRecorder(),
 		done:       make(chan struct{}),
+
+		barCount:      make(map[string]int),
+		confirmations: make(map[confirmKey]confirmation),
+		closedTrades:  make(chan types.Trade, 16),
+		lastPrice:     make(map[string]decimal.Decimal),
+		brackets:      make(map[string]entryBracket),
+		openedBy:      make(map[string]string),
+
+		entryCommission: make(map[string]decimal.Decimal),
+
+		pendingEntries: make(map[string]pendingEntry),
+		fills:          make(chan broker.Order, 64),
+	}
+}
+
+// newSymbolIndicators creates a calculator per configured symbol and per
+// further traded symbol.
+func newSymbolIndicators(cfg Config) map[string]observer.Indicators {
+	indicators := make(map[string]observer.Indicators, len(cfg.Calculators)+len(cfg.Symbols))
+	for symbol, calcCfg := range cfg.Calculators {
+		indicators[symbol] = observer.NewCalculator(calcCfg)
+	}
+	for _, symbol := range cfg.Symbols {
+		if _, ok := indicators[symbol]; !ok {
+			indicators[symbol] = observer.NewCalculator(cfg.calculatorDefaults())
+		}
 	}
+	return indicators
+}
+
+// calculatorDefaults returns the indicator settings for symbols without
+// their own.
+func (c Config) calculatorDefaults() observer.CalculatorConfig {
+	if c.CalculatorDefaults == (observer.CalculatorConfig{}) {
+		return observer.DefaultCalculatorConfig()
+	}
+	return c.CalculatorDefaults
+}
+
+// indicatorsFor returns the symbol's own indicators. Symbol uses the
+// calculator passed to NewEngine unless it has its own; any other symbol
+// gets a calculator from the defaults on its first bar, so no two symbols
+// share ATR.
+func (e *Engine) indicatorsFor(symbol string) observer.Indicators {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if ind, ok := e.perSymbol[symbol]; ok {
+		return ind
+	}
+	if symbol == e.cfg.Symbol {
+		return e.calculator
+	}
+	ind := observer.NewCalculator(e.cfg.calculatorDefaults())
+	e.perSymbol[symbol] = ind
+	return ind
+}
+
+// WarmUp feeds historical bars to the indicators so ATR is valid from the
+// first live bar. Only strategies implementing strategy.WarmUpper see them,
+// and nothing trades on them. Call before Start; returns the number of bars used.
+func (e *Engine) WarmUp(bars []types.MarketEvent) int {
+	for _, bar := range bars {
+		strategy.WarmUp(e.strategy, e.indicatorsFor(bar.Symbol).OnBar(bar))
+	}
+	if len(bars) > 0 {
+		last := bars[len(bars)-1]
+		e.logger.Info("indicators warmed up",
+			"bars", len(bars),
+			"through", last.Timestamp,
+			"atr", e.indicatorsFor(last.Symbol).CurrentATR(),
+		)
+	}
+	return len(bars)
+}
+
+// SnapshotStore persists equity snapshots. persistence.Repository
+// implementations satisfy it.
+type SnapshotStore interface {
+	SaveEquitySnapshot(ctx context.Context, snapshot persistence.EquitySnapshot) error
+}
+
+// SetSnapshotStore enables periodic equity snapshots every
+// Config.SnapshotInterval. Call before Start.
+func (e *Engine) SetSnapshotStore(store SnapshotStore) {
+	e.snapshots = store
+}
+
+// OrderStore records orders before they are sent. persistence.Repository
+// implementations satisfy it; a client order ID saved before must be
+// refused with types.ErrDuplicateOrder.
+type OrderStore interface {
+	SaveOrder(ctx context.Context, order persistence.OrderRecord) error
+}
+
+// SetOrderStore persists every client order ID before its order is sent,
+// so after a restart an order submitted by the previous run is refused
+// instead of sent again. Call before Start.
+func (e *Engine) SetOrderStore(store OrderStore) {
+	e.orders = store
+}
+
+// SetAuditRecorder enables the audit trail of signals, intents, orders and
+// fills. Call before Start.
+func (e *Engine) SetAuditRecorder(recorder *audit.Recorder) {
+	e.audit = recorder
+}
+
+// SetStateHub streams equity, positions, the latest signal and safe mode
+// to dashboard clients through hub. Call before Start.
+func (e *Engine) SetStateHub(hub *metrics.Hub) {
+	e.hub = hub
 }
 
 // Start starts the trading engine.
@@ -90,6 +334,7 @@ func (e *Engine) Start(ctx context.Context) error {
 		return fmt.Errorf("engine already running")
 	}
 	e.running = true
+	e.startedAt = time.Now()
 	e.mu.Unlock()
 
 	e.logger.Info("starting trading engine",
@@ -97,6 +342,26 @@ func (e *Engine) Start(ctx context.Context) error {
 		"strategy", e.strategy.Name(),
 	)
 
+	// Fills arrive asynchronously from brokers that report them
+	if notifier, ok := e.broker.(broker.FillNotifier); ok {
+		notifier.SetFillHandler(e.onFill)
+	}
+
+	// Orders the broker dropped or changed across a reconnect need a human look
+	if notifier, ok := e.broker.(broker.ReconcileNotifier); ok && e.alerter != nil {
+		notifier.SetReconcileHandler(func(rec broker.OrderReconciliation) {
+			go func() { // The handler must not block the broker
+				if err := e.alerter.Alert(ctx, alerting.SeverityWarning, "Open orders reconciled after reconnect",
+					"removed", len(rec.Removed),
+					"updated", len(rec.Updated),
+					"unknown", len(rec.Unknown),
+				); err != nil {
+					e.logger.Warn("failed to send reconciliation alert", "err", err)
+				}
+			}()
+		})
+	}
+
 	// Subscribe to market data
 	marketDataCh, err := e.broker.SubscribeMarketData(ctx, e.cfg.Symbol)
 	if err != nil {
@@ -111,6 +376,18 @@ func (e *Engine) Start(ctx context.Context) error {
 	e.wg.Add(1)
 	go e.equityUpdateLoop(ctx)
 
+	// Start equity snapshot loop
+	if e.snapshots != nil && e.cfg.SnapshotInterval > 0 {
+		e.wg.Add(1)
+		go e.snapshotLoop(ctx)
+	}
+
+	// Start data staleness watchdog
+	if e.cfg.StaleDataThreshold > 0 {
+		e.wg.Add(1)
+		go e.watchdogLoop(ctx)
+	}
+
 	// Send start alert
 	if e.alerter != nil {
 		if err := e.alerter.Alert(ctx, alerting.SeverityInfo, "Trading engine started",
@@ -127,10 +404,16 @@ func (e *Engine) Start(ctx context.Context) error {
 // tradingLoop is the main trading loop.
 func (e *Engine) tradingLoop(ctx context.Context, marketDataCh <-chan types.MarketEvent) {
 	defer e.wg.Done()
+	defer e.loopExit()
 
 	e.logger.Info("trading loop started")
 
+	heartbeat := time.NewTicker(e.loopHeartbeat())
+	defer heartbeat.Stop()
+
 	for {
+		e.loopBeat()
+
 		select {
 		case <-ctx.Done():
 			e.logger.Info("trading loop stopped: context cancelled")
@@ -148,20 +431,291 @@ func (e *Engine) tradingLoop(ctx context.Context, marketDataCh <-chan types.Mark
 				e.logger.Error("failed to process market event", "err", err)
 				e.recorder.RecordError("process_event")
 			}
+		case trade := <-e.closedTrades:
+			e.strategy.OnTradeClosed(trade)
+		case fill := <-e.fills:
+			e.handleFill(ctx, fill)
+		case <-heartbeat.C:
+		}
+	}
+}
+
+// loopHeartbeat returns how often the trading loop reports itself alive.
+func (e *Engine) loopHeartbeat() time.Duration {
+	if e.cfg.HeartbeatInterval > 0 {
+		return e.cfg.HeartbeatInterval
+	}
+	return DefaultLoopHeartbeat
+}
+
+// loopBeat records that the trading loop is alive.
+func (e *Engine) loopBeat() {
+	e.mu.Lock()
+	e.loopBeatAt = time.Now()
+	e.loopExited = false
+	e.mu.Unlock()
+}
+
+// loopExit records that the trading loop has returned.
+func (e *Engine) loopExit() {
+	e.mu.Lock()
+	e.loopExited = true
+	e.mu.Unlock()
+}
+
+// ProcessEvent runs one market event through the engine on the caller's
+// goroutine, bypassing the market data channel, then applies the fills
+// reported so far. It is meant for benchmarks and must not be used while
+// the trading loop from Start is running.
+func (e *Engine) ProcessEvent(ctx context.Context, event types.MarketEvent) error {
+	err := e.processMarketEvent(ctx, event)
+	e.drainFills(ctx)
+	return err
+}
+
+// onFill is the broker's fill handler. It runs on the broker's goroutine,
+// so it only records the fill and queues it for the trading loop.
+func (e *Engine) onFill(order broker.Order) {
+	e.auditErr(e.audit.Fill(order))
+
+	select {
+	case e.fills <- order:
+	default:
+		e.logger.Warn("fill not delivered to trading loop: queue full",
+			"client_order_id", order.ClientOrderID,
+		)
+	}
+}
+
+// drainFills applies the fills queued so far.
+func (e *Engine) drainFills(ctx context.Context) {
+	for {
+		select {
+		case fill := <-e.fills:
+			e.handleFill(ctx, fill)
+		default:
+			return
+		}
+	}
+}
+
+// handleFill passes the new positions to the risk engine and tells the
+// strategy about a filled entry order.
+func (e *Engine) handleFill(ctx context.Context, order broker.Order) {
+	e.trackPositions(ctx, &order)
+
+	entry, ok := e.pendingEntries[order.ClientOrderID]
+	if !ok || order.Status != broker.OrderStatusFilled {
+		return
+	}
+	delete(e.pendingEntries, order.ClientOrderID)
+	e.entryFilled(entry.signal, order.FilledQty)
+}
+
+// expectEntryFill registers an entry order so its fill reaches the
+// strategy, dropping entries whose orders expired unfilled. It reports
+// false when the broker doesn't report fills.
+func (e *Engine) expectEntryFill(signal types.Signal, intent types.OrderIntent) bool {
+	if _, ok := e.broker.(broker.FillNotifier); !ok {
+		return false
+	}
+
+	now := time.Now()
+	for id, entry := range e.pendingEntries {
+		if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+			delete(e.pendingEntries, id)
+		}
+	}
+	e.pendingEntries[intent.ClientOrderID] = pendingEntry{signal: signal, expiresAt: intent.ExpiresAt}
+	return true
+}
+
+// trackPositions passes the broker's open positions to the risk engine, so
+// its open position, margin and exposure limits count what is held. Traded
+// symbols the broker no longer holds are cleared. Contracts gone since the
+// last call are booked as a closed trade; fill is the order that moved the
+// position, or nil when polling.
+func (e *Engine) trackPositions(ctx context.Context, fill *broker.Order) {
+	positions, err := callBroker(ctx, e.orderTimeout(), "get positions", e.openPositions)
+	if err != nil {
+		e.logger.Warn("failed to get positions for risk tracking", "err", err)
+		return
+	}
+
+	e.trackMu.Lock()
+	defer e.trackMu.Unlock()
+
+	held := make(map[string]broker.Position, len(positions))
+	for _, pos := range positions {
+		held[pos.Symbol] = pos
+	}
+	track := func(symbol string, update types.Position) {
+		tracked, ok := e.riskEngine.GetPosition(symbol)
+		closed := 0
+		if ok {
+			closed = closedContracts(*tracked, update)
+		}
+		exitCommission, entryCommission := splitFillCommission(fill, symbol, closed)
+		if closed > 0 {
+			e.bookClose(*tracked, closed, fill, exitCommission)
+		}
+
+		if update.Contracts <= 0 {
+			e.riskEngine.UpdatePosition(&update)
+			delete(e.entryCommission, symbol)
+			e.mu.Lock()
+			delete(e.openedBy, symbol)
+			e.mu.Unlock()
+			return
+		}
+		// The position keeps its entry time until it is closed or flipped
+		if ok && tracked.Side == update.Side && tracked.Contracts > closed && !tracked.EntryTime.IsZero() {
+			update.EntryTime = tracked.EntryTime
+		} else {
+			update.EntryTime = fillTime(fill, symbol)
+			if !ok || tracked.Side != update.Side {
+				delete(e.entryCommission, symbol)
+			}
+		}
+		e.entryCommission[symbol] = e.entryCommission[symbol].Add(entryCommission)
+		e.riskEngine.UpdatePosition(&update)
+	}
+
+	for _, pos := range positions {
+		track(pos.Symbol, types.Position{
+			Symbol:     pos.Symbol,
+			Side:       pos.Side,
+			Contracts:  pos.Contracts,
+			EntryPrice: pos.AvgCost,
+		})
+	}
+	for _, symbol := range e.symbols() {
+		if _, ok := held[symbol]; !ok {
+			track(symbol, types.Position{Symbol: symbol})
 		}
 	}
 }
 
+// closedContracts returns how many of the tracked contracts the updated
+// position no longer holds.
+func closedContracts(tracked, updated types.Position) int {
+	if updated.Contracts <= 0 || updated.Side != tracked.Side {
+		return tracked.Contracts
+	}
+	return max(tracked.Contracts-updated.Contracts, 0)
+}
+
+// splitFillCommission splits a fill's commission between the contracts it
+// closed on symbol and the contracts it opened. Both are zero unless fill
+// is for symbol.
+func splitFillCommission(fill *broker.Order, symbol string, closed int) (exit, entry decimal.Decimal) {
+	if fill == nil || fill.Symbol != symbol {
+		return decimal.Zero, decimal.Zero
+	}
+	if closed <= 0 || fill.FilledQty <= 0 {
+		return decimal.Zero, fill.Commission
+	}
+	share := decimal.NewFromInt(int64(min(closed, fill.FilledQty))).Div(decimal.NewFromInt(int64(fill.FilledQty)))
+	exit = fill.Commission.Mul(share)
+	return exit, fill.Commission.Sub(exit)
+}
+
+// fillTime returns when fill executed on symbol, or now when the position
+// changed without a fill report.
+func fillTime(fill *broker.Order, symbol string) time.Time {
+	if fill != nil && fill.Symbol == symbol && !fill.UpdatedAt.IsZero() {
+		return fill.UpdatedAt
+	}
+	return time.Now()
+}
+
+// bookClose records contracts closed out of the tracked position as one
+// trade: it counts toward the live trade stats and the risk bucket of the
+// strategy that opened the position, and reaches the strategy.
+// The exit is the fill's price when a fill closed them, else the latest
+// close. The trade is charged exitCommission plus the closed contracts'
+// share of the commission paid opening the position. Caller must hold
+// e.trackMu.
+func (e *Engine) bookClose(tracked types.Position, contracts int, fill *broker.Order, exitCommission decimal.Decimal) {
+	e.mu.RLock()
+	exit := e.lastPrice[tracked.Symbol]
+	e.mu.RUnlock()
+	if fill != nil && fill.Symbol == tracked.Symbol && fill.AvgFillPrice.IsPositive() {
+		exit = fill.AvgFillPrice
+	}
+
+	entryCommission := e.entryCommission[tracked.Symbol]
+	if tracked.Contracts > contracts {
+		entryCommission = entryCommission.Mul(decimal.NewFromInt(int64(contracts))).Div(decimal.NewFromInt(int64(tracked.Contracts)))
+	}
+	e.entryCommission[tracked.Symbol] = e.entryCommission[tracked.Symbol].Sub(entryCommission)
+	commission := entryCommission.Add(exitCommission)
+
+	if !exit.IsPositive() || !tracked.EntryPrice.IsPositive() {
+		e.logger.Warn("closed contracts without entry and exit prices, trade not booked",
+			"symbol", tracked.Symbol,
+			"contracts", contracts,
+		)
+		return
+	}
+
+	// Credit the strategy that opened the position, not the one running now
+	e.mu.RLock()
+	openedBy := e.openedBy[tracked.Symbol]
+	e.mu.RUnlock()
+	strategyName := openedBy
+	if strategyName == "" {
+		strategyName = e.strategy.Name()
+	}
+
+	now := time.Now()
+	gross := execution.GrossPL(tracked.Symbol, tracked.Side, tracked.EntryPrice, exit, contracts)
+	trade := types.Trade{
+		ID:           fmt.Sprintf("closed-%s-%d", tracked.Symbol, now.UnixNano()),
+		Symbol:       tracked.Symbol,
+		Side:         tracked.Side,
+		Contracts:    contracts,
+		EntryPrice:   tracked.EntryPrice,
+		ExitPrice:    exit,
+		EntryTime:    tracked.EntryTime,
+		ExitTime:     now,
+		GrossPL:      gross,
+		Commission:   commission,
+		NetPL:        gross.Sub(commission),
+		StrategyName: strategyName,
+	}
+
+	e.riskEngine.RecordBucketPnL(openedBy, trade.NetPL)
+
+	e.tradeStats.Add(trade.NetPL)
+	e.recorder.RecordTradeStats(e.tradeStats.ProfitFactor(), e.tradeStats.Expectancy())
+	if e.closeLog != nil {
+		*e.closeLog = append(*e.closeLog, trade)
+	}
+	e.notifyTradeClosed(trade)
+}
+
+// entryFilled passes a filled entry to strategies that track their entries.
+func (e *Engine) entryFilled(signal types.Signal, contracts int) {
+	if observer, ok := e.strategy.(strategy.EntryObserver); ok {
+		observer.OnEntryFilled(signal, contracts)
+	}
+}
+
 // processMarketEvent processes a single market event.
 func (e *Engine) processMarketEvent(ctx context.Context, event types.MarketEvent) error {
 	timer := metrics.NewTimer()
+	defer timer.ObserveEvent()
 
 	e.mu.Lock()
 	e.lastEvent = event
+	e.lastEventAt = time.Now()
+	e.lastPrice[event.Symbol] = event.Close
 	e.mu.Unlock()
 
 	// Update calculator
-	e.calculator.OnBar(event)
+	calculator := e.indicatorsFor(event.Symbol)
+	calculator.OnBar(event)
 
 	// Get calculated values
 	calcEvent := types.MarketEvent{
@@ -172,51 +726,241 @@ func (e *Engine) processMarketEvent(ctx context.Context, event types.MarketEvent
 		Low:       event.Low,
 		Close:     event.Close,
 		Volume:    event.Volume,
-		ATR:       e.calculator.CurrentATR(),
+		Bid:       event.Bid,
+		Ask:       event.Ask,
+		ATR:       calculator.CurrentATR(),
 	}
 
 	// Record heartbeat
 	e.recorder.RecordHeartbeat()
 
+	e.barCount[event.Symbol]++
+
 	// Generate signals
 	signals := e.strategy.OnMarketEvent(ctx, calcEvent)
 
 	timer.ObserveStrategy(e.strategy.Name())
 
+	// Indicators still see a lagging bar, but nothing trades on it
+	if e.isLagging(event) {
+		if len(signals) > 0 {
+			e.recorder.RecordSignalRejected(types.RejectStaleData)
+		}
+		return nil
+	}
+
 	// Process signals
 	for _, signal := range signals {
 		e.recorder.RecordSignal(e.strategy.Name(), signal.Direction.String())
+		e.auditErr(e.audit.Signal(signal))
+
+		if !e.tradesSymbol(signal.Symbol) {
+			e.recorder.RecordSignalRejected(types.RejectInvalidSymbol)
+			e.logger.Warn("signal for a symbol the engine is not trading, dropped",
+				"signal_id", signal.ID,
+				"signal_symbol", signal.Symbol,
+				"strategy", e.strategy.Name(),
+				"symbols", e.symbols(),
+			)
+			continue
+		}
+		e.setLastSignal(signal)
+
+		// Weak signals don't count toward confirmation either
+		if signal.WeakerThan(e.cfg.MinSignalStrength) {
+			e.recorder.RecordSignalRejected(types.RejectWeakSignal)
+			e.logger.Debug("signal below minimum strength",
+				"signal_id", signal.ID,
+				"strength", signal.Strength,
+				"min_strength", e.cfg.MinSignalStrength,
+			)
+			continue
+		}
+
+		if !e.confirmSignal(ctx, signal, calcEvent) {
+			e.recorder.RecordSignalRejected(types.RejectAwaitingConfirmation)
+			e.logger.Debug("signal awaiting confirmation",
+				"signal_id", signal.ID,
+				"direction", signal.Direction,
+				"required", e.cfg.ConfirmationBars,
+			)
+			continue
+		}
 
 		if err := e.processSignal(ctx, signal, calcEvent); err != nil {
 			e.logger.Warn("signal rejected",
 				"signal_id", signal.ID,
-				"reason", err,
+				"reason", types.ReasonFor(err),
+				"err", err,
 			)
 		}
+
+		// Fills already reported count against the next signal's limits
+		e.drainFills(ctx)
+	}
+
+	if len(signals) > 0 {
+		e.publishState(ctx, false)
 	}
 
 	return nil
 }
 
+// symbols returns every symbol the engine trades.
+func (e *Engine) symbols() []string {
+	return append([]string{e.cfg.Symbol}, e.cfg.Symbols...)
+}
+
+// tradesSymbol reports whether symbol is one the engine is configured to trade.
+func (e *Engine) tradesSymbol(symbol string) bool {
+	return slices.Contains(e.symbols(), symbol)
+}
+
+// isLagging reports whether the bar closed more than StaleDataThreshold
+// before wall-clock time, recording the lag either way. Bars are stamped
+// with their open time, so the bar's close is Timestamp plus Timeframe.
+// time.Time compares instants, so feed and local time zones don't matter.
+// A bar closing well after wall-clock time means the local clock is behind
+// the feed; that is logged but not skipped.
+func (e *Engine) isLagging(event types.MarketEvent) bool {
+	if !e.cfg.CheckDataLag || e.cfg.StaleDataThreshold <= 0 || event.Timestamp.IsZero() {
+		return false
+	}
+
+	lag := time.Since(event.Timestamp.Add(e.cfg.Timeframe))
+	e.recorder.RecordDataLag(lag)
+
+	switch {
+	case lag > e.cfg.StaleDataThreshold:
+		e.recorder.RecordError("data_lag")
+		e.logger.Warn("market data lagging, skipping bar",
+			"symbol", event.Symbol,
+			"bar_time", event.Timestamp,
+			"lag", lag.Round(time.Millisecond),
+			"threshold", e.cfg.StaleDataThreshold,
+		)
+		return true
+	case -lag > e.cfg.StaleDataThreshold:
+		e.logger.Warn("bar timestamp ahead of wall clock, check clock sync",
+			"symbol", event.Symbol,
+			"bar_time", event.Timestamp,
+			"skew", (-lag).Round(time.Millisecond),
+		)
+	}
+	return false
+}
+
+// confirmSignal reports whether an entry signal has been seen on enough
+// consecutive bars. An opposing signal, a bar without the signal, or a data
+// gap resets the streak. Signals that would close an open position are not delayed.
+func (e *Engine) confirmSignal(ctx context.Context, signal types.Signal, event types.MarketEvent) bool {
+	if e.cfg.ConfirmationBars <= 1 || signal.Direction == types.SideFlat {
+		return true
+	}
+
+	// Opposing signal breaks the other direction's streak
+	delete(e.confirmations, confirmKey{symbol: signal.Symbol, direction: signal.Direction.Opposite()})
+
+	// Without the position, the signal waits for confirmation like an entry
+	pos, err := e.position(ctx, signal.Symbol)
+	if err != nil {
+		e.logger.Warn("confirmation: position unavailable", "symbol", signal.Symbol, "err", err)
+	} else if pos != nil && pos.Side == signal.Direction.Opposite() {
+		return true
+	}
+
+	key := confirmKey{symbol: signal.Symbol, direction: signal.Direction}
+	bar := e.barCount[event.Symbol]
+
+	c, ok := e.confirmations[key]
+	switch {
+	case !ok:
+		c = confirmation{}
+	case c.lastBar == bar:
+		// Duplicate signal on the same bar doesn't count twice
+		return false
+	case c.lastBar != bar-1:
+		c = confirmation{}
+	case e.cfg.Timeframe > 0 && event.Timestamp.Sub(c.lastAt) > 2*e.cfg.Timeframe:
+		c = confirmation{}
+	}
+
+	c.count++
+	c.lastBar = bar
+	c.lastAt = event.Timestamp
+
+	if c.count < e.cfg.ConfirmationBars {
+		e.confirmations[key] = c
+		return false
+	}
+
+	// Confirmed: the next entry needs a fresh streak
+	delete(e.confirmations, key)
+	return true
+}
+
 // processSignal processes a trading signal.
 func (e *Engine) processSignal(ctx context.Context, signal types.Signal, event types.MarketEvent) error {
+	// Exits reduce risk, so they go ahead even in safe mode
+	if signal.Direction == types.SideFlat {
+		return e.exitOnFlatSignal(ctx, signal, event)
+	}
+	if e.cfg.ReverseOnOppositeSignal {
+		closed, err := e.closeOnOppositeSignal(ctx, signal, event)
+		if err != nil {
+			return err
+		}
+		if closed && !e.cfg.FlipOnOppositeSignal {
+			return nil
+		}
+	}
+
 	// Check if in safe mode
 	if e.riskEngine.IsInSafeMode() {
-		e.recorder.RecordSignalRejected("safe_mode")
+		e.recorder.RecordSignalRejected(types.RejectSafeMode)
 		return types.ErrKillSwitchActive
 	}
 
+	if err := e.checkSpread(signal.Symbol); err != nil {
+		e.recorder.RecordSignalRejected(types.ReasonFor(err))
+		return err
+	}
+
 	// Validate and size with risk engine
 	orderIntent, err := e.riskEngine.ValidateAndSize(ctx, signal, event)
 	if err != nil {
-		e.recorder.RecordSignalRejected(err.Error())
+		e.recorder.RecordSignalRejected(types.ReasonFor(err))
 		return err
 	}
+	e.auditErr(e.audit.Intent(*orderIntent))
 
-	// Place order
+	// Place order; a fill can be reported before placeOrder returns
+	fillReported := e.expectEntryFill(signal, *orderIntent)
 	timer := metrics.NewTimer()
-	result, err := e.broker.PlaceOrder(ctx, *orderIntent)
+	result, err := e.placeOrder(ctx, *orderIntent)
 	timer.ObserveOrder()
+	e.auditErr(e.audit.Order(*orderIntent, result, err))
+	if err != nil {
+		delete(e.pendingEntries, orderIntent.ClientOrderID)
+	}
+
+	if errors.Is(err, types.ErrOrderTimeout) {
+		e.recorder.RecordOrder(signal.Symbol, signal.Direction.String(), "timeout")
+
+		// The broker may still have accepted the order
+		if e.alerter != nil {
+			if alertErr := e.alerter.Alert(ctx, alerting.SeverityCritical, "ORDER TIMEOUT - broker not responding, order state unknown",
+				"symbol", signal.Symbol,
+				"side", signal.Direction,
+				"client_order_id", orderIntent.ClientOrderID,
+				"timeout", e.orderTimeout().String(),
+			); alertErr != nil {
+				e.logger.Warn("failed to send order timeout alert", "err", alertErr)
+			}
+		}
+
+		return err
+	}
 
 	if err != nil {
 		e.recorder.RecordOrder(signal.Symbol, signal.Direction.String(), "rejected")
@@ -237,6 +981,16 @@ func (e *Engine) processSignal(ctx context.Context, signal types.Signal, event t
 
 	e.recorder.RecordOrder(signal.Symbol, signal.Direction.String(), "submitted")
 
+	e.mu.Lock()
+	e.openedBy[signal.Symbol] = signal.StrategyName
+	e.brackets[orderIntent.Symbol] = entryBracket{stop: orderIntent.StopLoss, target: orderIntent.TakeProfit}
+	e.mu.Unlock()
+
+	// Without fill reports, a submitted entry counts as filled
+	if !fillReported {
+		e.entryFilled(signal, orderIntent.Contracts)
+	}
+
 	e.logger.Info("order placed",
 		"order_id", result.OrderID,
 		"client_order_id", result.ClientOrderID,
@@ -264,45 +1018,811 @@ func (e *Engine) processSignal(ctx context.Context, signal types.Signal, event t
 	return nil
 }
 
-// equityUpdateLoop periodically updates equity metrics.
-func (e *Engine) equityUpdateLoop(ctx context.Context) {
-	defer e.wg.Done()
-
-	ticker := time.NewTicker(e.cfg.EquityUpdateInterval)
-	defer ticker.Stop()
+// exitOnFlatSignal closes the position a flat signal exits: the signal's
+// Contracts, or the whole position when it names none. Without a position
+// there is nothing to close.
+func (e *Engine) exitOnFlatSignal(ctx context.Context, signal types.Signal, event types.MarketEvent) error {
+	pos, err := e.position(ctx, signal.Symbol)
+	if err != nil {
+		return fmt.Errorf("get position: %w", err)
+	}
+	if pos == nil || pos.Contracts == 0 {
+		e.logger.Debug("exit signal without an open position",
+			"signal_id", signal.ID,
+			"symbol", signal.Symbol,
+		)
+		return nil
+	}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-e.done:
-			return
-		case <-ticker.C:
-			e.updateEquity(ctx)
-		}
+	intent := risk.ExitIntent(signal, pos.Side, pos.Contracts, event.Close)
+	e.auditErr(e.audit.Intent(intent))
+	result, err := e.placeOrder(ctx, intent)
+	e.auditErr(e.audit.Order(intent, result, err))
+	if err != nil {
+		e.recorder.RecordOrder(intent.Symbol, intent.Side.String(), "rejected")
+		return fmt.Errorf("exit on flat signal: %w", err)
 	}
+	e.recorder.RecordOrder(intent.Symbol, intent.Side.String(), "submitted")
+
+	e.logger.Info("closing position on exit signal",
+		"signal_id", signal.ID,
+		"symbol", signal.Symbol,
+		"position_side", pos.Side,
+		"contracts", intent.Contracts,
+		"held", pos.Contracts,
+	)
+	return nil
 }
 
-// updateEquity updates equity from broker.
-func (e *Engine) updateEquity(ctx context.Context) {
-	summary, err := e.broker.GetAccountSummary(ctx)
+// position returns the broker's position in symbol, or nil when flat.
+func (e *Engine) position(ctx context.Context, symbol string) (*broker.Position, error) {
+	return callBroker(ctx, e.orderTimeout(), "get position", func(ctx context.Context) (*broker.Position, error) {
+		return e.broker.GetPosition(ctx, symbol)
+	})
+}
+
+// closeOnOppositeSignal closes the symbol's open position if the signal
+// points the other way. Reports whether a closing order was placed.
+func (e *Engine) closeOnOppositeSignal(ctx context.Context, signal types.Signal, event types.MarketEvent) (bool, error) {
+	pos, err := e.position(ctx, signal.Symbol)
 	if err != nil {
-		e.logger.Warn("failed to get account summary", "err", err)
-		return
+		return false, fmt.Errorf("get position: %w", err)
+	}
+	if pos == nil || pos.Contracts == 0 || pos.Side != signal.Direction.Opposite() {
+		return false, nil
 	}
 
-	// Update risk engine
-	e.riskEngine.UpdateEquity(summary.NetLiquidation)
+	// A flip's entry keeps attempt 0, so the close must not reuse it
+	intent := risk.ExitIntent(signal, pos.Side, pos.Contracts, event.Close)
+	intent.ClientOrderID = risk.ClientOrderID(signal.ID, reverseAttempt)
+	e.auditErr(e.audit.Intent(intent))
+	result, err := e.placeOrder(ctx, intent)
+	e.auditErr(e.audit.Order(intent, result, err))
+	if err != nil {
+		e.recorder.RecordOrder(intent.Symbol, intent.Side.String(), "rejected")
+		return false, fmt.Errorf("close on opposite signal: %w", err)
+	}
+	e.recorder.RecordOrder(intent.Symbol, intent.Side.String(), "submitted")
+
+	e.logger.Info("closing position on opposite signal",
+		"signal_id", signal.ID,
+		"symbol", signal.Symbol,
+		"position_side", pos.Side,
+		"contracts", pos.Contracts,
+		"flip", e.cfg.FlipOnOppositeSignal,
+	)
+	return true, nil
+}
+
+// orderTimeout returns the per-call broker deadline.
+func (e *Engine) orderTimeout() time.Duration {
+	if e.cfg.OrderTimeout > 0 {
+		return e.cfg.OrderTimeout
+	}
+	return DefaultOrderTimeout
+}
+
+// placeOrder submits an order, retrying transient broker errors up to
+// MaxRetries times with doubling delays. Every attempt reuses the intent's
+// ClientOrderID, so if an attempt that looked failed actually reached the
+// broker, the retry is refused as a duplicate rather than opening a second
+// position; that refusal is reported as the order being submitted.
+func (e *Engine) placeOrder(ctx context.Context, intent types.OrderIntent) (*broker.OrderResult, error) {
+	if err := e.reserveOrder(ctx, intent); err != nil {
+		return nil, err
+	}
+
+	delay := e.cfg.RetryDelay
+	for attempt := 0; ; attempt++ {
+		result, err := callBroker(ctx, e.orderTimeout(), "place order", func(ctx context.Context) (*broker.OrderResult, error) {
+			return e.broker.PlaceOrder(ctx, intent)
+		})
+
+		if attempt > 0 && errors.Is(err, types.ErrDuplicateOrder) {
+			e.logger.Warn("retried order already accepted by broker",
+				"client_order_id", intent.ClientOrderID,
+				"attempt", attempt+1,
+			)
+			return &broker.OrderResult{
+				ClientOrderID: intent.ClientOrderID,
+				Status:        broker.OrderStatusSubmitted,
+				Message:       "accepted on an earlier attempt",
+				SubmittedAt:   time.Now(),
+			}, nil
+		}
+		if err == nil || attempt >= e.cfg.MaxRetries || !broker.IsTransient(err) {
+			return result, err
+		}
+
+		e.logger.Warn("order attempt failed, retrying",
+			"client_order_id", intent.ClientOrderID,
+			"attempt", attempt+1,
+			"max_retries", e.cfg.MaxRetries,
+			"delay", delay,
+			"err", err,
+		)
+		e.recorder.RecordOrder(intent.Symbol, intent.Side.String(), "retry")
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// reserveOrder saves the intent to the order store before it is sent. A
+// client order ID the store already holds was sent before a restart and is
+// refused with types.ErrDuplicateOrder. If the store fails, entries are
+// refused; exits still go out, since a reduce-only order can't add to a
+// position.
+func (e *Engine) reserveOrder(ctx context.Context, intent types.OrderIntent) error {
+	if e.orders == nil {
+		return nil
+	}
+
+	err := e.orders.SaveOrder(ctx, persistence.OrderRecord{
+		ClientOrderID: intent.ClientOrderID,
+		Symbol:        intent.Symbol,
+		Side:          intent.Side,
+		Contracts:     intent.Contracts,
+		EntryPrice:    intent.EntryPrice,
+		StopLoss:      intent.StopLoss,
+		TakeProfit:    intent.TakeProfit,
+		Status:        types.OrderStatusPending,
+		SignalID:      intent.SignalID,
+	})
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, types.ErrDuplicateOrder):
+		e.logger.Warn("order already sent before restart",
+			"client_order_id", intent.ClientOrderID,
+			"signal_id", intent.SignalID,
+		)
+		return err
+	case intent.ReduceOnly:
+		e.logger.Warn("failed to persist exit order, sending anyway",
+			"client_order_id", intent.ClientOrderID,
+			"err", err,
+		)
+		return nil
+	default:
+		return fmt.Errorf("persist order: %w", err)
+	}
+}
+
+// callBroker runs a broker call with its own deadline so a hung connection
+// can't block the caller. If the broker ignores the deadline, callBroker
+// still returns on time and leaves the call to finish in the background.
+// A missed deadline is reported as types.ErrOrderTimeout.
+func callBroker[T any](ctx context.Context, timeout time.Duration, op string, call func(context.Context) (T, error)) (T, error) {
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		value T
+		err   error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		value, err := call(callCtx)
+		done <- outcome{value, err}
+	}()
+
+	select {
+	case out := <-done:
+		if errors.Is(out.err, context.DeadlineExceeded) && ctx.Err() == nil {
+			return out.value, fmt.Errorf("%w: %s after %s", types.ErrOrderTimeout, op, timeout)
+		}
+		return out.value, out.err
+	case <-callCtx.Done():
+		var zero T
+		if ctx.Err() != nil {
+			return zero, ctx.Err()
+		}
+		return zero, fmt.Errorf("%w: %s after %s", types.ErrOrderTimeout, op, timeout)
+	}
+}
+
+// auditErr logs a failed audit write. Trading continues; the metrics error
+// counter makes the gap visible.
+func (e *Engine) auditErr(err error) {
+	if err != nil {
+		e.logger.Error("failed to write audit record", "err", err)
+		e.recorder.RecordError("audit")
+	}
+}
+
+// checkSpread rejects trading into a wide bid/ask spread. The guard is a
+// no-op when disabled or when the broker doesn't stream quotes (paper/sim).
+func (e *Engine) checkSpread(symbol string) error {
+	if e.cfg.MaxSpreadTicks <= 0 {
+		return nil
+	}
+
+	provider, ok := e.broker.(broker.SpreadProvider)
+	if !ok {
+		return nil
+	}
+
+	spread, ok := provider.Spread(symbol)
+	if !ok {
+		return nil
+	}
+
+	spec, ok := types.GetInstrumentSpec(symbol)
+	if !ok || !spec.TickSize.IsPositive() {
+		return nil
+	}
+
+	ticks := spread.Div(spec.TickSize)
+	if ticks.GreaterThan(decimal.NewFromInt(int64(e.cfg.MaxSpreadTicks))) {
+		return fmt.Errorf("%w: %s ticks exceeds max %d", types.ErrSpreadTooWide, ticks.StringFixed(1), e.cfg.MaxSpreadTicks)
+	}
+
+	return nil
+}
+
+// equityUpdateLoop periodically updates equity metrics.
+func (e *Engine) equityUpdateLoop(ctx context.Context) {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.cfg.EquityUpdateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.done:
+			return
+		case <-ticker.C:
+			e.updateEquity(ctx)
+		}
+	}
+}
+
+// updateEquity updates equity from broker.
+func (e *Engine) updateEquity(ctx context.Context) {
+	summary, err := callBroker(ctx, e.orderTimeout(), "get account summary", e.broker.GetAccountSummary)
+	if err != nil {
+		e.logger.Warn("failed to get account summary", "err", err)
+		return
+	}
+
+	// Feed newly realized P&L into the daily loss tracking
+	if realized := summary.RealizedPnL.Sub(e.lastRealizedPnL); !realized.IsZero() {
+		e.riskEngine.RecordRealizedPnL(realized, time.Now())
+		e.lastRealizedPnL = summary.RealizedPnL
+	}
+
+	// Update risk engine; positions are refreshed here too for brokers
+	// that don't report fills
+	e.riskEngine.UpdateEquity(summary.NetLiquidation)
+	e.trackPositions(ctx, nil)
 
 	// Update metrics
 	snapshot := e.riskEngine.GetSnapshot()
 	e.recorder.RecordEquity(snapshot.Equity, snapshot.HighWaterMark, snapshot.Drawdown)
 	e.recorder.RecordSafeMode(e.riskEngine.IsInSafeMode())
+	e.publishState(ctx, true)
 
 	// Check for kill switch activation
 	if e.riskEngine.IsInSafeMode() {
 		e.handleKillSwitch(ctx)
 	}
+
+	// Warn once per approach to the drawdown limit
+	if e.riskEngine.IsDrawdownWarning() {
+		if !e.drawdownWarned {
+			e.drawdownWarned = true
+			e.handleDrawdownWarning(ctx, snapshot.Drawdown)
+		}
+	} else {
+		e.drawdownWarned = false
+	}
+
+	// Check daily loss limit (handled once per trading day)
+	if e.riskEngine.IsDailyLossLimitHit() {
+		if !e.dailyLossHandled {
+			e.dailyLossHandled = true
+			e.handleDailyLossLimit(ctx)
+		}
+	} else {
+		e.dailyLossHandled = false
+	}
+
+	// Check daily profit target (open positions keep their stops/TPs)
+	if e.riskEngine.IsDailyTargetReached() {
+		if !e.dailyTargetHandled {
+			e.dailyTargetHandled = true
+			e.handleDailyTarget(ctx)
+		}
+	} else {
+		e.dailyTargetHandled = false
+	}
+
+	if e.cfg.PositionReadout {
+		e.readOutPositions(ctx)
+	}
+}
+
+// entryBracket is the stop and take profit an entry order was sent with.
+type entryBracket struct {
+	stop   decimal.Decimal
+	target decimal.Decimal
+}
+
+// positionReadout is one open position's risk at the latest price.
+type positionReadout struct {
+	pos         broker.Position
+	price       decimal.Decimal
+	pnl         decimal.Decimal
+	stopTicks   decimal.Decimal // Ticks the price can move against the position before the stop; negative = through it
+	targetTicks decimal.Decimal // Ticks left to the take profit
+	hasBracket  bool
+}
+
+// newPositionReadout measures pos at price against the bracket it was
+// entered with.
+func newPositionReadout(pos broker.Position, price decimal.Decimal, br entryBracket, hasBracket bool) positionReadout {
+	r := positionReadout{
+		pos:   pos,
+		price: price,
+		pnl:   execution.GrossPL(pos.Symbol, pos.Side, pos.AvgCost, price, pos.Contracts),
+	}
+	spec, ok := types.GetInstrumentSpec(pos.Symbol)
+	if !hasBracket || !ok || !br.stop.IsPositive() || !br.target.IsPositive() {
+		return r
+	}
+
+	r.hasBracket = true
+	r.stopTicks = price.Sub(br.stop).Div(spec.TickSize)
+	r.targetTicks = br.target.Sub(price).Div(spec.TickSize)
+	if pos.Side == types.SideShort {
+		r.stopTicks = r.stopTicks.Neg()
+		r.targetTicks = r.targetTicks.Neg()
+	}
+	return r
+}
+
+// String formats the readout, e.g. "MES LONG 2 @ 5010.25 pnl +51.25 stop 41t tp 59t".
+func (r positionReadout) String() string {
+	pnl := r.pnl.StringFixed(2)
+	if r.pnl.IsPositive() {
+		pnl = "+" + pnl
+	}
+	s := fmt.Sprintf("%s %s %d @ %s pnl %s", r.pos.Symbol, r.pos.Side, r.pos.Contracts, r.price, pnl)
+	if !r.hasBracket {
+		return s + " stop/tp unknown"
+	}
+	return fmt.Sprintf("%s stop %st tp %st", s, r.stopTicks.Round(0), r.targetTicks.Round(0))
+}
+
+// readOutPositions logs, and optionally alerts, each open position's
+// unrealized P&L and distance to its stop and take profit. Positions
+// opened before this run have no known bracket.
+func (e *Engine) readOutPositions(ctx context.Context) {
+	positions, err := callBroker(ctx, e.orderTimeout(), "get positions", e.openPositions)
+	if err != nil {
+		e.logger.Warn("failed to get positions for readout", "err", err)
+		return
+	}
+	if len(positions) == 0 {
+		return
+	}
+	slices.SortFunc(positions, func(a, b broker.Position) int { return cmp.Compare(a.Symbol, b.Symbol) })
+
+	lines := make([]string, 0, len(positions))
+	e.mu.RLock()
+	for _, pos := range positions {
+		price, ok := e.lastPrice[pos.Symbol]
+		if !ok {
+			price = pos.MarketPrice
+		}
+		br, hasBracket := e.brackets[pos.Symbol]
+		lines = append(lines, newPositionReadout(pos, price, br, hasBracket).String())
+	}
+	e.mu.RUnlock()
+
+	readout := strings.Join(lines, "; ")
+	e.logger.Info("position readout", "positions", len(positions), "detail", readout)
+
+	if e.cfg.PositionReadoutAlert && e.alerter != nil {
+		if err := e.alerter.Alert(ctx, alerting.SeverityInfo, "Open positions",
+			"detail", readout,
+		); err != nil {
+			e.logger.Warn("failed to send position readout alert", "err", err)
+		}
+	}
+}
+
+// setLastSignal records the latest signal for the state stream.
+func (e *Engine) setLastSignal(signal types.Signal) {
+	if e.hub == nil {
+		return
+	}
+	e.mu.Lock()
+	e.lastSignal = &signal
+	e.mu.Unlock()
+}
+
+// publishState pushes the current state to dashboard clients. refresh
+// re-reads positions from the broker; otherwise the last read is reused.
+func (e *Engine) publishState(ctx context.Context, refresh bool) {
+	if e.hub == nil {
+		return
+	}
+
+	if refresh {
+		positions, err := callBroker(ctx, e.orderTimeout(), "get positions", e.openPositions)
+		if err != nil {
+			e.logger.Warn("failed to get positions for state stream", "err", err)
+		} else {
+			e.mu.Lock()
+			e.streamPositions = positions
+			e.mu.Unlock()
+		}
+	}
+
+	snapshot := e.riskEngine.GetSnapshot()
+	update := metrics.StateUpdate{
+		Time:          snapshot.Timestamp,
+		Equity:        snapshot.Equity,
+		HighWaterMark: snapshot.HighWaterMark,
+		Drawdown:      snapshot.Drawdown,
+		DailyPL:       snapshot.DailyPL,
+		SafeMode:      e.riskEngine.IsInSafeMode(),
+		Positions:     []metrics.PositionState{},
+	}
+
+	e.mu.RLock()
+	for _, pos := range e.streamPositions {
+		update.Positions = append(update.Positions, e.positionState(pos))
+	}
+	if s := e.lastSignal; s != nil {
+		update.LastSignal = &metrics.SignalState{
+			ID:        s.ID,
+			Time:      s.Timestamp,
+			Symbol:    s.Symbol,
+			Direction: s.Direction.String(),
+			Strategy:  s.StrategyName,
+			Reason:    s.Reason,
+		}
+	}
+	e.mu.RUnlock()
+
+	e.hub.Publish(update)
+}
+
+// positionState reports a broker position with the entry price the risk
+// engine tracks for it, so a partially closed position shows the cost of
+// the contracts left under the configured partial close accounting rather
+// than the broker's running average.
+func (e *Engine) positionState(pos broker.Position) metrics.PositionState {
+	state := metrics.PositionState{
+		Symbol:        pos.Symbol,
+		Side:          pos.Side.String(),
+		Contracts:     pos.Contracts,
+		AvgCost:       pos.AvgCost,
+		MarketPrice:   pos.MarketPrice,
+		UnrealizedPnL: pos.UnrealizedPnL,
+	}
+	tracked, ok := e.riskEngine.GetPosition(pos.Symbol)
+	if !ok || tracked.Side != pos.Side || tracked.Contracts != pos.Contracts || !tracked.EntryPrice.IsPositive() {
+		return state
+	}
+	state.AvgCost = tracked.EntryPrice
+	if pos.MarketPrice.IsPositive() {
+		state.UnrealizedPnL = execution.GrossPL(pos.Symbol, pos.Side, tracked.EntryPrice, pos.MarketPrice, pos.Contracts)
+	}
+	return state
+}
+
+// notifyTradeClosed hands a closed trade to the trading loop, which owns the
+// strategy.
+func (e *Engine) notifyTradeClosed(trade types.Trade) {
+	select {
+	case e.closedTrades <- trade:
+	default:
+		e.logger.Warn("closed trade not delivered to strategy: queue full", "net_pl", trade.NetPL)
+	}
+}
+
+// snapshotLoop periodically persists the equity state, giving a continuous
+// equity history between trades.
+func (e *Engine) snapshotLoop(ctx context.Context) {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.cfg.SnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.done:
+			return
+		case <-ticker.C:
+			if err := e.saveSnapshot(ctx); err != nil {
+				e.logger.Warn("failed to save equity snapshot", "err", err)
+				e.recorder.RecordError("equity_snapshot")
+			}
+		}
+	}
+}
+
+// saveSnapshot persists the risk engine's equity state with the broker's
+// open position count.
+func (e *Engine) saveSnapshot(ctx context.Context) error {
+	snapshot := e.riskEngine.GetSnapshot()
+
+	positions, err := e.openPositions(ctx)
+	if err != nil {
+		return fmt.Errorf("get positions: %w", err)
+	}
+
+	return e.snapshots.SaveEquitySnapshot(ctx, persistence.EquitySnapshot{
+		Timestamp:     snapshot.Timestamp,
+		Equity:        snapshot.Equity,
+		HighWaterMark: snapshot.HighWaterMark,
+		Drawdown:      snapshot.Drawdown,
+		OpenPositions: len(positions),
+		DailyPL:       snapshot.DailyPL,
+	})
+}
+
+// handleDailyTarget handles the daily profit target being reached.
+func (e *Engine) handleDailyTarget(ctx context.Context) {
+	dailyPL := e.riskEngine.DailyPnL()
+
+	e.logger.Info("daily profit target reached, no new entries until next session",
+		"daily_pl", dailyPL,
+	)
+
+	if e.alerter != nil {
+		if err := e.alerter.Alert(ctx, alerting.SeverityInfo, "Daily profit target reached",
+			"daily_pl", dailyPL.StringFixed(2),
+		); err != nil {
+			e.logger.Warn("failed to send daily target alert", "err", err)
+		}
+	}
+}
+
+// handleDrawdownWarning sends a heads-up that drawdown is nearing the
+// kill switch limit.
+func (e *Engine) handleDrawdownWarning(ctx context.Context, drawdown decimal.Decimal) {
+	if e.alerter == nil {
+		return
+	}
+	if err := e.alerter.Alert(ctx, alerting.SeverityWarning, "Drawdown approaching kill switch limit",
+		"drawdown", drawdown.Mul(decimal.NewFromInt(100)).StringFixed(2)+"%",
+	); err != nil {
+		e.logger.Warn("failed to send drawdown warning alert", "err", err)
+	}
+}
+
+// handleDailyLossLimit handles the daily loss limit being reached.
+func (e *Engine) handleDailyLossLimit(ctx context.Context) {
+	dailyPL := e.riskEngine.DailyPnL()
+
+	e.logger.Error("DAILY LOSS LIMIT REACHED",
+		"daily_pl", dailyPL,
+		"flatten", e.cfg.FlattenOnDailyLoss,
+	)
+
+	if e.alerter != nil {
+		if err := e.alerter.Alert(ctx, alerting.SeverityHigh, "DAILY LOSS LIMIT REACHED",
+			"daily_pl", dailyPL.StringFixed(2),
+			"flatten", e.cfg.FlattenOnDailyLoss,
+		); err != nil {
+			e.logger.Error("failed to send daily loss alert", "err", err)
+		}
+	}
+
+	e.cancelAllOrders(ctx)
+
+	if e.cfg.FlattenOnDailyLoss {
+		if err := e.flattenAll(ctx, "daily_loss_limit"); err != nil {
+			e.logger.Error("failed to flatten on daily loss limit", "err", err)
+		}
+	}
+}
+
+// FlattenAll closes every open broker position at market, waits for the
+// broker to confirm the positions are gone, and alerts on the outcome.
+// Returns an error if positions remain open when the timeout or ctx expires.
+func (e *Engine) FlattenAll(ctx context.Context) error {
+	return e.flattenAll(ctx, "flatten_all")
+}
+
+// flattenOrderID derives the client order ID closing pos for reason, so a
+// restarted bot flattening the same position reuses the ID it sent.
+func flattenOrderID(reason string, pos broker.Position) string {
+	key := fmt.Sprintf("%s-%s-%s-%d-%s", reason, pos.Symbol, pos.Side, pos.Contracts, pos.AvgCost)
+	return risk.ClientOrderID(key, 0)
+}
+
+// flattenAll implements FlattenAll, tagging logs and alerts with reason.
+func (e *Engine) flattenAll(ctx context.Context, reason string) error {
+	positions, err := e.openPositions(ctx)
+	if err != nil {
+		return fmt.Errorf("get positions: %w", err)
+	}
+	if len(positions) == 0 {
+		return nil
+	}
+
+	e.logger.Warn("flattening all positions",
+		"positions", len(positions),
+		"reason", reason,
+	)
+
+	// Submit closing orders
+	if flattener, ok := e.broker.(broker.Flattener); ok {
+		_, err := callBroker(ctx, e.orderTimeout(), "flatten", func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, flattener.FlattenAll(ctx)
+		})
+		if err != nil {
+			e.logger.Error("broker flatten failed", "reason", reason, "err", err)
+		}
+	} else {
+		for _, pos := range positions {
+			intent := types.OrderIntent{
+				ClientOrderID: flattenOrderID(reason, pos),
+				Timestamp:     time.Now(),
+				Symbol:        pos.Symbol,
+				Side:          pos.Side.Opposite(),
+				Contracts:     pos.Contracts,
+				EntryPrice:    pos.MarketPrice,
+				ReduceOnly:    true,
+			}
+
+			_, err := e.placeOrder(ctx, intent)
+			if err != nil {
+				e.logger.Error("failed to close position",
+					"symbol", pos.Symbol,
+					"reason", reason,
+					"err", err,
+				)
+			}
+		}
+	}
+
+	// Wait for the broker to confirm
+	remaining, err := e.waitForFlat(ctx)
+	if err != nil {
+		e.logger.Error("FLATTEN INCOMPLETE",
+			"open_positions", len(remaining),
+			"reason", reason,
+			"err", err,
+		)
+		if e.alerter != nil {
+			if alertErr := e.alerter.Alert(ctx, alerting.SeverityCritical, "FLATTEN INCOMPLETE - positions still open",
+				"open_positions", len(remaining),
+				"reason", reason,
+			); alertErr != nil {
+				e.logger.Error("failed to send flatten alert", "err", alertErr)
+			}
+		}
+		return err
+	}
+
+	e.logger.Warn("all positions flattened",
+		"closed", len(positions),
+		"reason", reason,
+	)
+	if e.alerter != nil {
+		if err := e.alerter.Alert(ctx, alerting.SeverityHigh, "All positions flattened",
+			"closed", len(positions),
+			"reason", reason,
+		); err != nil {
+			e.logger.Warn("failed to send flatten alert", "err", err)
+		}
+	}
+
+	return nil
+}
+
+// FlattenForShutdown closes all open positions as part of graceful shutdown
+// and returns the trades booked for the positions that closed, with the
+// tracked position's entry time and commission and the closing fill's price
+// and commission. Closes the broker didn't report a fill for exit at the
+// last broker mark. The wait for fills stops at the ctx deadline; positions
+// still open are reported through the returned error. It applies queued
+// fills itself, so the trading loop must have stopped.
+func (e *Engine) FlattenForShutdown(ctx context.Context) ([]types.Trade, error) {
+	positions, err := e.openPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get positions: %w", err)
+	}
+	if len(positions) == 0 {
+		return nil, nil
+	}
+
+	e.mu.Lock()
+	for _, pos := range positions {
+		if pos.MarketPrice.IsPositive() {
+			e.lastPrice[pos.Symbol] = pos.MarketPrice
+		}
+	}
+	e.mu.Unlock()
+
+	// Track the positions as they stand, then collect what the flatten closes
+	e.drainFills(ctx)
+	e.trackPositions(ctx, nil)
+
+	var trades []types.Trade
+	e.trackMu.Lock()
+	e.closeLog = &trades
+	e.trackMu.Unlock()
+	defer func() {
+		e.trackMu.Lock()
+		e.closeLog = nil
+		e.trackMu.Unlock()
+	}()
+
+	flattenErr := e.flattenAll(ctx, "shutdown")
+	e.drainFills(context.Background())
+	e.trackPositions(context.Background(), nil)
+
+	for i := range trades {
+		trades[i].SignalID = "shutdown"
+		trades[i].ExitReason = execution.ExitFlatten
+	}
+	return trades, flattenErr
+}
+
+// waitForFlat polls the broker until no positions remain open.
+// Returns the positions still open when the timeout or ctx expires.
+func (e *Engine) waitForFlat(ctx context.Context) ([]broker.Position, error) {
+	timeout := e.cfg.FlattenTimeout
+	if timeout <= 0 {
+		timeout = DefaultFlattenTimeout
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	poll := time.NewTicker(50 * time.Millisecond)
+	defer poll.Stop()
+
+	var remaining []broker.Position
+	for {
+		select {
+		case <-ctx.Done():
+			return remaining, fmt.Errorf("%w: %d positions still open: %v", types.ErrOrderTimeout, len(remaining), ctx.Err())
+		case <-deadline.C:
+			return remaining, fmt.Errorf("%w: %d positions still open after %s", types.ErrOrderTimeout, len(remaining), timeout)
+		case <-poll.C:
+			positions, err := e.openPositions(ctx)
+			if err != nil {
+				e.logger.Warn("failed to get positions", "err", err)
+				continue
+			}
+			if len(positions) == 0 {
+				return nil, nil
+			}
+			remaining = positions
+		}
+	}
+}
+
+// openPositions returns broker positions with non-zero size.
+func (e *Engine) openPositions(ctx context.Context) ([]broker.Position, error) {
+	positions, err := e.broker.GetPositions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	open := positions[:0:0]
+	for _, pos := range positions {
+		if pos.Contracts != 0 {
+			open = append(open, pos)
+		}
+	}
+	return open, nil
 }
 
 // handleKillSwitch handles kill switch activation.
@@ -323,18 +1843,28 @@ func (e *Engine) handleKillSwitch(ctx context.Context) {
 
 	// Cancel all open orders
 	e.cancelAllOrders(ctx)
+
+	// Close positions if configured
+	if e.cfg.FlattenOnKillSwitch {
+		if err := e.flattenAll(ctx, "kill_switch"); err != nil {
+			e.logger.Error("failed to flatten on kill switch", "err", err)
+		}
+	}
 }
 
 // cancelAllOrders cancels all open orders.
 func (e *Engine) cancelAllOrders(ctx context.Context) {
-	orders, err := e.broker.GetOpenOrders(ctx)
+	orders, err := callBroker(ctx, e.orderTimeout(), "get open orders", e.broker.GetOpenOrders)
 	if err != nil {
 		e.logger.Error("failed to get open orders", "err", err)
 		return
 	}
 
 	for _, order := range orders {
-		if err := e.broker.CancelOrder(ctx, order.OrderID); err != nil {
+		_, err := callBroker(ctx, e.orderTimeout(), "cancel order", func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, e.broker.CancelOrder(ctx, order.OrderID)
+		})
+		if err != nil {
 			e.logger.Error("failed to cancel order",
 				"order_id", order.OrderID,
 				"err", err,
@@ -343,6 +1873,150 @@ func (e *Engine) cancelAllOrders(ctx context.Context) {
 	}
 }
 
+// watchdogLoop periodically checks that market data is still arriving.
+func (e *Engine) watchdogLoop(ctx context.Context) {
+	defer e.wg.Done()
+
+	interval := e.cfg.HeartbeatInterval
+	if interval <= 0 {
+		interval = e.cfg.StaleDataThreshold / 2
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.done:
+			return
+		case now := <-ticker.C:
+			e.checkStaleness(ctx, now)
+		}
+	}
+}
+
+// checkStaleness enters the degraded state when no market event has arrived
+// within one Timeframe plus StaleDataThreshold, and leaves it once data resumes.
+// Events are bars, so a quiet period shorter than a bar is normal.
+func (e *Engine) checkStaleness(ctx context.Context, now time.Time) {
+	maxAge := e.cfg.Timeframe + e.cfg.StaleDataThreshold
+
+	e.mu.Lock()
+	last := e.lastEventAt
+	if last.IsZero() {
+		last = e.startedAt
+	}
+	age := now.Sub(last)
+	stale := age > maxAge
+	wasDegraded := e.degraded
+	e.degraded = stale
+	e.mu.Unlock()
+
+	switch {
+	case stale && !wasDegraded:
+		e.logger.Error("market data stale, entering degraded state",
+			"symbol", e.cfg.Symbol,
+			"age", age,
+			"max_age", maxAge,
+		)
+		e.recorder.RecordError("stale_data")
+
+		if e.alerter != nil {
+			if err := e.alerter.Alert(ctx, alerting.SeverityCritical, "MARKET DATA STALE",
+				"symbol", e.cfg.Symbol,
+				"age", age.String(),
+				"max_age", maxAge.String(),
+			); err != nil {
+				e.logger.Warn("failed to send stale data alert", "err", err)
+			}
+		}
+
+		if e.cfg.FlattenOnStaleData {
+			if err := e.flattenAll(ctx, "stale market data"); err != nil {
+				e.logger.Error("failed to flatten on stale data", "err", err)
+			}
+		}
+
+	case !stale && wasDegraded:
+		e.logger.Info("market data resumed", "symbol", e.cfg.Symbol)
+
+		if e.alerter != nil {
+			if err := e.alerter.Alert(ctx, alerting.SeverityInfo, "Market data resumed",
+				"symbol", e.cfg.Symbol,
+			); err != nil {
+				e.logger.Warn("failed to send data resumed alert", "err", err)
+			}
+		}
+	}
+}
+
+// IsDegraded returns true while market data is stale.
+func (e *Engine) IsDegraded() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.degraded
+}
+
+// HealthCheck reports market data freshness for the health endpoint:
+// degraded once the watchdog finds data stale, unhealthy when the last
+// event is more than twice the allowed age old.
+func (e *Engine) HealthCheck() metrics.Check {
+	maxAge := e.cfg.Timeframe + e.cfg.StaleDataThreshold
+
+	e.mu.RLock()
+	degraded := e.degraded
+	last := e.lastEventAt
+	if last.IsZero() {
+		last = e.startedAt
+	}
+	e.mu.RUnlock()
+
+	if !degraded {
+		return metrics.Check{Status: "healthy"}
+	}
+	age := time.Since(last).Round(time.Second)
+	if age > 2*maxAge {
+		return metrics.Check{Status: "unhealthy", Message: fmt.Sprintf("no market data for %s", age)}
+	}
+	return metrics.Check{Status: "degraded", Message: fmt.Sprintf("market data stale (%s old)", age)}
+}
+
+// BrokerHealthCheck reports broker connectivity for the health endpoint.
+func (e *Engine) BrokerHealthCheck() metrics.Check {
+	if !e.broker.IsConnected() {
+		return metrics.Check{Status: "unhealthy", Message: "broker disconnected"}
+	}
+	return metrics.Check{Status: "healthy"}
+}
+
+// LoopHealthCheck reports whether the trading loop is alive: unhealthy once
+// it has returned or missed Config.MaxMissedHeartbeats heartbeats.
+func (e *Engine) LoopHealthCheck() metrics.Check {
+	e.mu.RLock()
+	running, exited, beatAt := e.running, e.loopExited, e.loopBeatAt
+	e.mu.RUnlock()
+
+	switch {
+	case !running:
+		return metrics.Check{Status: "unhealthy", Message: "engine not running"}
+	case exited:
+		return metrics.Check{Status: "unhealthy", Message: "trading loop stopped"}
+	case beatAt.IsZero():
+		return metrics.Check{Status: "degraded", Message: "trading loop starting"}
+	}
+
+	missed := e.cfg.MaxMissedHeartbeats
+	if missed <= 0 {
+		missed = DefaultMaxMissedHeartbeats
+	}
+	if since := time.Since(beatAt); since > time.Duration(missed)*e.loopHeartbeat() {
+		return metrics.Check{Status: "unhealthy", Message: fmt.Sprintf("trading loop stalled for %s", since.Round(time.Second))}
+	}
+	return metrics.Check{Status: "healthy"}
+}
+
 // Stop stops the trading engine.
 func (e *Engine) Stop(ctx context.Context) error {
 	e.mu.Lock()
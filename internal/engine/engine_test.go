@@ -2,14 +2,26 @@ package engine
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/shopspring/decimal"
 	"github.com/tathienbao/quant-bot/internal/alerting"
+	"github.com/tathienbao/quant-bot/internal/audit"
+	"github.com/tathienbao/quant-bot/internal/broker"
 	"github.com/tathienbao/quant-bot/internal/broker/paper"
+	"github.com/tathienbao/quant-bot/internal/execution"
+	"github.com/tathienbao/quant-bot/internal/metrics"
 	"github.com/tathienbao/quant-bot/internal/observer"
+	"github.com/tathienbao/quant-bot/internal/persistence"
 	"github.com/tathienbao/quant-bot/internal/risk"
 	"github.com/tathienbao/quant-bot/internal/types"
 )
@@ -20,6 +32,7 @@ type mockStrategy struct {
 	name     string
 	signals  []types.Signal
 	callCount int
+	closed   []types.Trade
 }
 
 func newMockStrategy(name string) *mockStrategy {
@@ -42,6 +55,12 @@ func (m *mockStrategy) OnMarketEvent(_ context.Context, _ types.MarketEvent) []t
 	return nil
 }
 
+func (m *mockStrategy) OnTradeClosed(trade types.Trade) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = append(m.closed, trade)
+}
+
 func (m *mockStrategy) Name() string {
 	return m.name
 }
@@ -381,6 +400,35 @@ func TestEngine_ProcessMarketEvent(t *testing.T) {
 	}
 }
 
+// TestEngine_ProcessEvent tests synchronous processing without Start.
+func TestEngine_ProcessEvent(t *testing.T) {
+	engine, _, strat, _ := createTestEngine(t)
+	ctx := context.Background()
+
+	for i := range 3 {
+		event := types.MarketEvent{
+			Timestamp: time.Now(),
+			Symbol:    "MES",
+			Open:      decimal.NewFromInt(5000),
+			High:      decimal.NewFromInt(5010),
+			Low:       decimal.NewFromInt(4990),
+			Close:     decimal.NewFromInt(int64(5000 + i)),
+			Volume:    1000,
+		}
+		if err := engine.ProcessEvent(ctx, event); err != nil {
+			t.Fatalf("ProcessEvent() error = %v", err)
+		}
+	}
+
+	// Every event reaches the strategy before ProcessEvent returns
+	if strat.CallCount() != 3 {
+		t.Errorf("strategy calls = %d, want 3", strat.CallCount())
+	}
+	if got := engine.GetLastEvent().Close; !got.Equal(decimal.NewFromInt(5002)) {
+		t.Errorf("last close = %s, want 5002", got)
+	}
+}
+
 // TestEngine_ProcessMarketEvent_WithSignal tests market event with signal generation.
 func TestEngine_ProcessMarketEvent_WithSignal(t *testing.T) {
 	engine, brk, strat, _ := createTestEngine(t)
@@ -514,3 +562,1617 @@ func TestEngine_KillSwitch_Recovery(t *testing.T) {
 		t.Error("expected safe mode to remain ON after recovery (KS-03)")
 	}
 }
+
+// TestEngine_DailyLossLimit_Flatten tests the daily loss limit alert and flatten.
+func TestEngine_DailyLossLimit_Flatten(t *testing.T) {
+	engine, brk, _, mockAlerter := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+
+	riskCfg := risk.DefaultConfig()
+	riskCfg.MaxDailyLossPct = decimal.RequireFromString("0.03")
+	engine.riskEngine = risk.NewEngine(riskCfg, decimal.NewFromInt(10000), nil)
+	engine.cfg.FlattenOnDailyLoss = true
+
+	placeAndWait := func(id string, side types.Side) {
+		t.Helper()
+		if _, err := brk.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: id, Symbol: "MES", Side: side, Contracts: 1}); err != nil {
+			t.Fatalf("PlaceOrder() error = %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// Lose 100 points on MES ($500 > 3% of $10k)
+	brk.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	placeAndWait("dl-open", types.SideLong)
+	brk.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(4900)})
+	placeAndWait("dl-close", types.SideShort)
+
+	// Open another position that should be flattened
+	placeAndWait("dl-reopen", types.SideLong)
+
+	mockAlerter.Clear()
+	engine.updateEquity(ctx)
+
+	if !engine.riskEngine.IsDailyLossLimitHit() {
+		t.Fatal("expected daily loss limit to be hit")
+	}
+	if !mockAlerter.HasAlertContaining("DAILY LOSS LIMIT") {
+		t.Error("expected daily loss limit alert")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	pos, err := brk.GetPosition(ctx, "MES")
+	if err != nil {
+		t.Fatalf("GetPosition() error = %v", err)
+	}
+	if pos != nil {
+		t.Errorf("expected position to be flattened, got %d contracts", pos.Contracts)
+	}
+
+	// Alert fires once per trading day
+	mockAlerter.Clear()
+	engine.updateEquity(ctx)
+	if mockAlerter.HasAlertContaining("DAILY LOSS LIMIT") {
+		t.Error("daily loss alert should only fire once")
+	}
+}
+
+// TestEngine_NotifiesStrategyOfClosedTrades tests that realized P&L reaches
+// the strategy through the trading loop.
+func TestEngine_NotifiesStrategyOfClosedTrades(t *testing.T) {
+	engine, brk, strat, _ := createTestEngine(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+	if err := engine.Start(ctx); err != nil {
+		t.Fatalf("failed to start engine: %v", err)
+	}
+	defer engine.Stop(ctx)
+
+	brk.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	if _, err := brk.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: "ct-open", Symbol: "MES", Side: types.SideLong, Contracts: 1}); err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	brk.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(4990)})
+	if _, err := brk.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: "ct-close", Symbol: "MES", Side: types.SideShort, Contracts: 1}); err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		strat.mu.Lock()
+		closed := append([]types.Trade(nil), strat.closed...)
+		strat.mu.Unlock()
+
+		if len(closed) > 0 {
+			if !closed[0].NetPL.IsNegative() {
+				t.Errorf("NetPL = %s, want a loss", closed[0].NetPL)
+			}
+			if closed[0].StrategyName != "test_strategy" {
+				t.Errorf("StrategyName = %s, want test_strategy", closed[0].StrategyName)
+			}
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("strategy was not notified of the closed trade")
+}
+
+// TestEngine_TradeStatsMetrics tests that each closed trade updates the
+// live profit factor and expectancy gauges once, however many close
+// between equity updates.
+func TestEngine_TradeStatsMetrics(t *testing.T) {
+	engine, brk, _, _ := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+	brk.SetFillHandler(engine.onFill)
+
+	roundTrip := func(id string, entry, exit int64) {
+		t.Helper()
+		brk.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(entry)})
+		if _, err := brk.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: id + "-open", Symbol: "MES", Side: types.SideLong, Contracts: 1}); err != nil {
+			t.Fatalf("PlaceOrder() error = %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		engine.drainFills(ctx)
+		brk.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(exit)})
+		if _, err := brk.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: id + "-close", Symbol: "MES", Side: types.SideShort, Contracts: 1}); err != nil {
+			t.Fatalf("PlaceOrder() error = %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		engine.drainFills(ctx)
+	}
+
+	// Both trades close before the equity update polls realized P&L
+	roundTrip("ts-loss", 5000, 4990)
+	roundTrip("ts-win", 5000, 5030)
+	engine.updateEquity(ctx)
+
+	stats := engine.tradeStats
+	if stats.Trades != 2 || stats.Wins != 1 || stats.Losses != 1 {
+		t.Fatalf("tradeStats = %+v, want one win and one loss", stats)
+	}
+	if !stats.ProfitFactor().GreaterThan(decimal.NewFromInt(1)) {
+		t.Errorf("ProfitFactor = %s, want > 1 for a 30 pt win against a 10 pt loss", stats.ProfitFactor())
+	}
+	if got, want := testutil.ToFloat64(metrics.ProfitFactor), stats.ProfitFactor().InexactFloat64(); got != want {
+		t.Errorf("profit_factor gauge = %v, want %v", got, want)
+	}
+	if got, want := testutil.ToFloat64(metrics.Expectancy), stats.Expectancy().InexactFloat64(); got != want {
+		t.Errorf("expectancy gauge = %v, want %v", got, want)
+	}
+}
+
+// TestEngine_BucketPnLPerSymbol tests that a closed trade is charged to the
+// bucket of the strategy that opened that symbol, not the latest entry.
+func TestEngine_BucketPnLPerSymbol(t *testing.T) {
+	engine, brk, _, _ := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+	brk.SetFillHandler(engine.onFill)
+
+	riskCfg := risk.DefaultConfig()
+	riskCfg.Buckets = []risk.BucketConfig{
+		{Name: "alpha", Allocation: decimal.NewFromInt(5000), MaxDrawdownPct: decimal.RequireFromString("0.5")},
+		{Name: "beta", Allocation: decimal.NewFromInt(5000), MaxDrawdownPct: decimal.RequireFromString("0.5")},
+	}
+	engine.riskEngine = risk.NewEngine(riskCfg, decimal.NewFromInt(10000), nil)
+
+	place := func(id, symbol string, side types.Side, price int64) {
+		t.Helper()
+		brk.SimulateMarketData(types.MarketEvent{Symbol: symbol, High: decimal.NewFromInt(price), Low: decimal.NewFromInt(price), Close: decimal.NewFromInt(price)})
+		if _, err := brk.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: id, Symbol: symbol, Side: side, Contracts: 1}); err != nil {
+			t.Fatalf("PlaceOrder() error = %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		engine.drainFills(ctx)
+	}
+
+	// alpha opens MES, then beta opens MNQ
+	engine.openedBy["MES"] = "alpha"
+	place("alpha-open", "MES", types.SideLong, 5000)
+	engine.openedBy["MNQ"] = "beta"
+	place("beta-open", "MNQ", types.SideLong, 18000)
+
+	// Closing MES at a loss charges alpha
+	place("alpha-close", "MES", types.SideShort, 4990)
+
+	buckets := make(map[string]risk.BucketSnapshot)
+	for _, b := range engine.riskEngine.BucketSnapshots() {
+		buckets[b.Name] = b
+	}
+	if !buckets["alpha"].Equity.LessThan(decimal.NewFromInt(5000)) {
+		t.Errorf("alpha equity = %s, want the MES loss", buckets["alpha"].Equity)
+	}
+	if !buckets["beta"].Equity.Equal(decimal.NewFromInt(5000)) {
+		t.Errorf("beta equity = %s, want 5000 untouched", buckets["beta"].Equity)
+	}
+	select {
+	case trade := <-engine.closedTrades:
+		if trade.StrategyName != "alpha" {
+			t.Errorf("StrategyName = %q, want alpha (the opening strategy)", trade.StrategyName)
+		}
+	default:
+		t.Error("expected the MES close to be booked")
+	}
+	if _, ok := engine.openedBy["MES"]; ok {
+		t.Error("openedBy should be cleared once MES is flat")
+	}
+	if engine.openedBy["MNQ"] != "beta" {
+		t.Errorf("openedBy[MNQ] = %q, want beta", engine.openedBy["MNQ"])
+	}
+}
+
+// TestEngine_DailyTarget_Alert tests the info alert when the daily target is reached.
+func TestEngine_DailyTarget_Alert(t *testing.T) {
+	engine, brk, _, mockAlerter := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+
+	riskCfg := risk.DefaultConfig()
+	riskCfg.DailyProfitTargetPct = decimal.RequireFromString("0.01")
+	engine.riskEngine = risk.NewEngine(riskCfg, decimal.NewFromInt(10000), nil)
+	engine.riskEngine.RecordRealizedPnL(decimal.NewFromInt(150), time.Now())
+
+	mockAlerter.Clear()
+	engine.updateEquity(ctx)
+
+	if !mockAlerter.HasAlertWithSeverity(alerting.SeverityInfo) {
+		t.Error("expected info alert for daily target")
+	}
+	if !mockAlerter.HasAlertContaining("Daily profit target") {
+		t.Error("expected alert to mention daily profit target")
+	}
+}
+
+// TestEngine_DrawdownWarning_Alert tests one warning alert per approach to
+// the drawdown limit.
+func TestEngine_DrawdownWarning_Alert(t *testing.T) {
+	engine, brk, _, mockAlerter := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+
+	engine.riskEngine = risk.NewEngine(risk.DefaultConfig(), decimal.NewFromInt(10000), nil)
+	engine.riskEngine.UpdateEquity(decimal.NewFromInt(11765)) // Paper equity 10000 is a 15% drawdown
+
+	mockAlerter.Clear()
+	engine.updateEquity(ctx)
+	engine.updateEquity(ctx)
+
+	warnings := 0
+	for _, a := range mockAlerter.Alerts() {
+		if strings.Contains(a.Message, "Drawdown approaching") {
+			warnings++
+		}
+	}
+	if warnings != 1 {
+		t.Errorf("drawdown warning alerts = %d, want 1", warnings)
+	}
+	if !mockAlerter.HasAlertWithSeverity(alerting.SeverityWarning) {
+		t.Error("expected warning severity")
+	}
+	if engine.riskEngine.IsInSafeMode() {
+		t.Error("warning level should not trigger the kill switch")
+	}
+}
+
+// TestPositionReadout tests P&L and tick distances for both sides.
+func TestPositionReadout(t *testing.T) {
+	long := broker.Position{Symbol: "MES", Side: types.SideLong, Contracts: 2, AvgCost: decimal.RequireFromString("5000")}
+	br := entryBracket{stop: decimal.RequireFromString("4990"), target: decimal.RequireFromString("5015")}
+
+	got := newPositionReadout(long, decimal.RequireFromString("5005.25"), br, true).String()
+	if want := "MES LONG 2 @ 5005.25 pnl +52.50 stop 61t tp 39t"; got != want {
+		t.Errorf("long readout = %q, want %q", got, want)
+	}
+
+	short := broker.Position{Symbol: "MES", Side: types.SideShort, Contracts: 1, AvgCost: decimal.RequireFromString("5000")}
+	br = entryBracket{stop: decimal.RequireFromString("5010"), target: decimal.RequireFromString("4985")}
+	got = newPositionReadout(short, decimal.RequireFromString("5002"), br, true).String()
+	if want := "MES SHORT 1 @ 5002 pnl -10.00 stop 32t tp 68t"; got != want {
+		t.Errorf("short readout = %q, want %q", got, want)
+	}
+
+	got = newPositionReadout(short, decimal.RequireFromString("5002"), entryBracket{}, false).String()
+	if want := "MES SHORT 1 @ 5002 pnl -10.00 stop/tp unknown"; got != want {
+		t.Errorf("readout without bracket = %q, want %q", got, want)
+	}
+}
+
+// TestEngine_PositionReadout_Alert tests the readout runs with the equity
+// update only when enabled.
+func TestEngine_PositionReadout_Alert(t *testing.T) {
+	engine, brk, _, mockAlerter := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+	brk.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	if _, err := brk.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: "ro-open", Symbol: "MES", Side: types.SideLong, Contracts: 1}); err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	engine.cfg.PositionReadoutAlert = true
+	mockAlerter.Clear()
+	engine.updateEquity(ctx)
+	if mockAlerter.HasAlertContaining("Open positions") {
+		t.Error("readout should be off unless PositionReadout is set")
+	}
+
+	engine.cfg.PositionReadout = true
+	engine.mu.Lock()
+	engine.brackets["MES"] = entryBracket{stop: decimal.NewFromInt(4990), target: decimal.NewFromInt(5020)}
+	engine.mu.Unlock()
+	engine.updateEquity(ctx)
+
+	alert := mockAlerter.LastAlert()
+	if alert == nil || alert.Message != "Open positions" {
+		t.Fatalf("last alert = %+v, want the position readout", alert)
+	}
+	if detail := fmt.Sprint(alert.Fields...); !strings.Contains(detail, "MES LONG 1") || !strings.Contains(detail, "stop") {
+		t.Errorf("readout detail = %q, want the MES position with its stop", detail)
+	}
+}
+
+// TestEngine_MinSignalStrength tests that weak entry signals are dropped
+// while strong ones and exits go through.
+func TestEngine_MinSignalStrength(t *testing.T) {
+	engine, brk, strat, mockAlerter := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+	engine.cfg.MinSignalStrength = decimal.RequireFromString("0.5")
+
+	weakRejected := metrics.SignalsRejected.WithLabelValues(string(types.RejectWeakSignal))
+	rejectedBefore := testutil.ToFloat64(weakRejected)
+
+	base := time.Now()
+	bar := 0
+	nextBar := func(id string, dir types.Side, strength string) {
+		t.Helper()
+		strat.AddSignal(types.Signal{
+			ID:           id,
+			Symbol:       "MES",
+			Direction:    dir,
+			Strength:     decimal.RequireFromString(strength),
+			StopTicks:    10,
+			StrategyName: "test_strategy",
+		})
+		event := types.MarketEvent{
+			Timestamp: base.Add(time.Duration(bar) * engine.cfg.Timeframe),
+			Symbol:    "MES",
+			Open:      decimal.NewFromInt(5000),
+			High:      decimal.NewFromInt(5010),
+			Low:       decimal.NewFromInt(4990),
+			Close:     decimal.NewFromInt(5005),
+			Volume:    1000,
+		}
+		bar++
+		if err := engine.processMarketEvent(ctx, event); err != nil {
+			t.Fatalf("processMarketEvent() error = %v", err)
+		}
+	}
+
+	mockAlerter.Clear()
+
+	// Grid L1 of 4 is too weak
+	nextBar("weak", types.SideLong, "0.25")
+	if mockAlerter.HasAlertContaining("Order placed") {
+		t.Fatal("weak signal should not place an order")
+	}
+	if got := testutil.ToFloat64(weakRejected) - rejectedBefore; got != 1 {
+		t.Errorf("weak_signal rejections = %v, want 1", got)
+	}
+
+	// At the threshold the signal trades
+	nextBar("strong", types.SideLong, "0.5")
+	if !mockAlerter.HasAlertContaining("Order placed") {
+		t.Fatal("signal at the minimum strength should place an order")
+	}
+
+	// Exits carry no strength and must never be filtered
+	nextBar("exit", types.SideFlat, "0")
+	if got := testutil.ToFloat64(weakRejected) - rejectedBefore; got != 1 {
+		t.Errorf("weak_signal rejections after exit = %v, want 1", got)
+	}
+}
+
+func TestEngine_DropsSignalForUntradedSymbol(t *testing.T) {
+	engine, brk, strat, mockAlerter := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+	engine.cfg.Symbols = []string{"MGC"}
+
+	// Room for an MGC contract's margin
+	riskCfg := risk.DefaultConfig()
+	riskCfg.MaxExposurePerSymbolPct = decimal.NewFromInt(100)
+	riskCfg.MaxTotalExposurePct = decimal.NewFromInt(100)
+	engine.riskEngine = risk.NewEngine(riskCfg, decimal.NewFromInt(10000), nil)
+
+	invalidRejected := metrics.SignalsRejected.WithLabelValues(string(types.RejectInvalidSymbol))
+	rejectedBefore := testutil.ToFloat64(invalidRejected)
+
+	barFor := func(symbol string, price int64) types.MarketEvent {
+		return types.MarketEvent{
+			Timestamp: time.Now(),
+			Symbol:    symbol,
+			Open:      decimal.NewFromInt(price),
+			High:      decimal.NewFromInt(price + 10),
+			Low:       decimal.NewFromInt(price - 10),
+			Close:     decimal.NewFromInt(price),
+			Volume:    1000,
+		}
+	}
+	mockAlerter.Clear()
+
+	// A MES bar producing a signal for a symbol nobody trades
+	strat.AddSignal(types.Signal{ID: "bogus", Symbol: "MNQ", Direction: types.SideLong, StopTicks: 10})
+	if err := engine.processMarketEvent(ctx, barFor("MES", 5000)); err != nil {
+		t.Fatalf("processMarketEvent() error = %v", err)
+	}
+	if mockAlerter.HasAlertContaining("Order placed") {
+		t.Fatal("signal for an untraded symbol should not place an order")
+	}
+	if got := testutil.ToFloat64(invalidRejected) - rejectedBefore; got != 1 {
+		t.Errorf("invalid_symbol rejections = %v, want 1", got)
+	}
+
+	// Symbols traded alongside the primary one go through
+	strat.AddSignal(types.Signal{ID: "gold", Symbol: "MGC", Direction: types.SideLong, StopTicks: 10})
+	if err := engine.processMarketEvent(ctx, barFor("MGC", 2000)); err != nil {
+		t.Fatalf("processMarketEvent() error = %v", err)
+	}
+	if !mockAlerter.HasAlertContaining("Order placed") {
+		t.Error("signal for a configured symbol should place an order")
+	}
+	if got := testutil.ToFloat64(invalidRejected) - rejectedBefore; got != 1 {
+		t.Errorf("invalid_symbol rejections = %v, want 1", got)
+	}
+}
+
+// TestEngine_EntryConfirmation tests the two-consecutive-signal entry requirement.
+func TestEngine_EntryConfirmation(t *testing.T) {
+	engine, brk, strat, mockAlerter := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+	engine.cfg.ConfirmationBars = 2
+
+	base := time.Now()
+	bar := 0
+	nextBar := func(sig *types.Signal) {
+		t.Helper()
+		if sig != nil {
+			strat.AddSignal(*sig)
+		}
+		event := types.MarketEvent{
+			Timestamp: base.Add(time.Duration(bar) * engine.cfg.Timeframe),
+			Symbol:    "MES",
+			Open:      decimal.NewFromInt(5000),
+			High:      decimal.NewFromInt(5010),
+			Low:       decimal.NewFromInt(4990),
+			Close:     decimal.NewFromInt(5005),
+			Volume:    1000,
+		}
+		bar++
+		if err := engine.processMarketEvent(ctx, event); err != nil {
+			t.Fatalf("processMarketEvent() error = %v", err)
+		}
+	}
+	signal := func(id string, dir types.Side) *types.Signal {
+		return &types.Signal{ID: id, Symbol: "MES", Direction: dir, StopTicks: 10, StrategyName: "test_strategy"}
+	}
+
+	mockAlerter.Clear()
+
+	// Single signal does not trade
+	nextBar(signal("c-1", types.SideLong))
+	if mockAlerter.HasAlertContaining("Order placed") {
+		t.Fatal("single signal should not place an order")
+	}
+
+	// Opposite signal resets the long streak (and starts a short one)
+	nextBar(signal("c-2", types.SideShort))
+	nextBar(signal("c-3", types.SideLong))
+	if mockAlerter.HasAlertContaining("Order placed") {
+		t.Fatal("signal after an opposing signal should not place an order")
+	}
+
+	// A bar without a signal breaks the streak
+	nextBar(nil)
+	nextBar(signal("c-4", types.SideLong))
+	if mockAlerter.HasAlertContaining("Order placed") {
+		t.Fatal("non-consecutive signals should not place an order")
+	}
+
+	// Second consecutive same-direction signal trades
+	nextBar(signal("c-5", types.SideLong))
+	if !mockAlerter.HasAlertContaining("Order placed") {
+		t.Error("two consecutive signals should place an order")
+	}
+}
+
+// TestEngine_OppositeSignal tests closing and flipping a position on a
+// signal in the opposite direction.
+func TestEngine_OppositeSignal(t *testing.T) {
+	tests := []struct {
+		name     string
+		flip     bool
+		safeMode bool
+		wantSide types.Side // SideFlat = no position
+	}{
+		{name: "close only", wantSide: types.SideFlat},
+		{name: "close in safe mode", safeMode: true, wantSide: types.SideFlat},
+		{name: "flip", flip: true, wantSide: types.SideShort},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			brokerCfg := paper.DefaultConfig()
+			brokerCfg.SyncFills = true
+			brk := paper.NewBroker(brokerCfg, nil)
+			if err := brk.Connect(ctx); err != nil {
+				t.Fatalf("failed to connect broker: %v", err)
+			}
+
+			riskEngine := risk.NewEngine(risk.DefaultConfig(), decimal.NewFromInt(10000), nil)
+			cfg := Config{
+				Symbol:                  "MES",
+				ReverseOnOppositeSignal: true,
+				FlipOnOppositeSignal:    tt.flip,
+			}
+			engine := NewEngine(cfg, brk, riskEngine, newMockStrategy("test"), observer.NewCalculator(observer.DefaultCalculatorConfig()), alerting.NewMockAlerter(), nil)
+
+			event := types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(5000)}
+			brk.SimulateMarketData(event)
+
+			long := types.Signal{ID: "long", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+			if err := engine.processSignal(ctx, long, event); err != nil {
+				t.Fatalf("entry error = %v", err)
+			}
+			if pos, _ := brk.GetPosition(ctx, "MES"); pos == nil || pos.Side != types.SideLong {
+				t.Fatalf("expected long position, got %+v", pos)
+			}
+
+			if tt.safeMode {
+				riskEngine.EnterSafeMode("test")
+			}
+
+			short := types.Signal{ID: "short", Symbol: "MES", Direction: types.SideShort, StopTicks: 10}
+			if err := engine.processSignal(ctx, short, event); err != nil {
+				t.Fatalf("opposite signal error = %v", err)
+			}
+
+			pos, _ := brk.GetPosition(ctx, "MES")
+			switch {
+			case tt.wantSide == types.SideFlat && pos != nil:
+				t.Errorf("expected flat, got %+v", pos)
+			case tt.wantSide != types.SideFlat && (pos == nil || pos.Side != tt.wantSide):
+				t.Errorf("expected %s position, got %+v", tt.wantSide, pos)
+			}
+		})
+	}
+}
+
+// observingStrategy is a mockStrategy that records filled entries.
+type observingStrategy struct {
+	*mockStrategy
+	filled map[string]int // Signal ID -> contracts
+}
+
+func (s *observingStrategy) OnEntryFilled(signal types.Signal, contracts int) {
+	s.filled[signal.ID] = contracts
+}
+
+// TestEngine_FlatSignalClosesPosition tests that an exit signal closes the
+// open position with a reduce-only order and the trade reaches the strategy.
+func TestEngine_FlatSignalClosesPosition(t *testing.T) {
+	ctx := context.Background()
+
+	brokerCfg := paper.DefaultConfig()
+	brokerCfg.SyncFills = true
+	brk := paper.NewBroker(brokerCfg, nil)
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+
+	strat := &observingStrategy{mockStrategy: newMockStrategy("test"), filled: make(map[string]int)}
+	riskEngine := risk.NewEngine(risk.DefaultConfig(), decimal.NewFromInt(10000), nil)
+	engine := NewEngine(Config{Symbol: "MES"}, brk, riskEngine, strat, observer.NewCalculator(observer.DefaultCalculatorConfig()), alerting.NewMockAlerter(), nil)
+	brk.SetFillHandler(engine.onFill)
+
+	event := types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(5000)}
+	brk.SimulateMarketData(event)
+
+	// Exiting without a position does nothing
+	exit := types.Signal{ID: "exit-early", Symbol: "MES", Direction: types.SideFlat}
+	if err := engine.processSignal(ctx, exit, event); err != nil {
+		t.Fatalf("exit without position error = %v", err)
+	}
+
+	long := types.Signal{ID: "long", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+	if err := engine.processSignal(ctx, long, event); err != nil {
+		t.Fatalf("entry error = %v", err)
+	}
+	engine.drainFills(ctx)
+	pos, _ := brk.GetPosition(ctx, "MES")
+	if pos == nil || pos.Side != types.SideLong {
+		t.Fatalf("expected long position, got %+v", pos)
+	}
+	if strat.filled["long"] != pos.Contracts {
+		t.Errorf("entry fill reported %d contracts, want %d", strat.filled["long"], pos.Contracts)
+	}
+
+	// Exits go ahead in safe mode
+	riskEngine.EnterSafeMode("test")
+	exit = types.Signal{ID: "exit", Symbol: "MES", Direction: types.SideFlat}
+	if err := engine.processSignal(ctx, exit, event); err != nil {
+		t.Fatalf("exit error = %v", err)
+	}
+	engine.drainFills(ctx)
+	if pos, _ := brk.GetPosition(ctx, "MES"); pos != nil {
+		t.Fatalf("expected flat, got %+v", pos)
+	}
+	if _, ok := strat.filled["exit"]; ok {
+		t.Error("exit fill should not be reported as an entry")
+	}
+
+	engine.updateEquity(ctx)
+	select {
+	case trade := <-engine.closedTrades:
+		if trade.NetPL.IsZero() {
+			t.Error("closed trade has no P&L")
+		}
+	default:
+		t.Fatal("expected a closed trade")
+	}
+}
+
+// TestEngine_FlatSignalPartialExit tests that an exit signal naming
+// contracts closes only those.
+func TestEngine_FlatSignalPartialExit(t *testing.T) {
+	ctx := context.Background()
+
+	brokerCfg := paper.DefaultConfig()
+	brokerCfg.SyncFills = true
+	brk := paper.NewBroker(brokerCfg, nil)
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+
+	riskCfg := risk.DefaultConfig()
+	riskCfg.SizingMode = risk.SizingFixedContracts
+	riskCfg.FixedContracts = 3
+	riskEngine := risk.NewEngine(riskCfg, decimal.NewFromInt(100000), nil)
+	engine := NewEngine(Config{Symbol: "MES"}, brk, riskEngine, newMockStrategy("test"), observer.NewCalculator(observer.DefaultCalculatorConfig()), alerting.NewMockAlerter(), nil)
+
+	event := types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(5000)}
+	brk.SimulateMarketData(event)
+
+	long := types.Signal{ID: "long", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+	if err := engine.processSignal(ctx, long, event); err != nil {
+		t.Fatalf("entry error = %v", err)
+	}
+
+	exit := types.Signal{ID: "exit", Symbol: "MES", Direction: types.SideFlat, Contracts: 1}
+	if err := engine.processSignal(ctx, exit, event); err != nil {
+		t.Fatalf("exit error = %v", err)
+	}
+
+	pos, _ := brk.GetPosition(ctx, "MES")
+	if pos == nil || pos.Side != types.SideLong || pos.Contracts != 2 {
+		t.Fatalf("expected LONG 2 after the partial exit, got %+v", pos)
+	}
+}
+
+// TestEngine_TracksPositionsForRisk tests that fills reach the risk
+// engine, so its open position cap sees what the broker holds.
+func TestEngine_TracksPositionsForRisk(t *testing.T) {
+	ctx := context.Background()
+
+	brokerCfg := paper.DefaultConfig()
+	brokerCfg.SyncFills = true
+	brk := paper.NewBroker(brokerCfg, nil)
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+
+	riskCfg := risk.DefaultConfig()
+	riskCfg.SizingMode = risk.SizingFixedContracts
+	riskCfg.FixedContracts = 1
+	riskCfg.MaxExposurePerSymbolPct = decimal.NewFromInt(1)
+	riskCfg.MaxTotalExposurePct = decimal.NewFromInt(10)
+	riskCfg.MaxOpenPositions = 1
+	riskEngine := risk.NewEngine(riskCfg, decimal.NewFromInt(100000), nil)
+	engine := NewEngine(Config{Symbol: "MES", Symbols: []string{"MGC"}}, brk, riskEngine, newMockStrategy("test"), observer.NewCalculator(observer.DefaultCalculatorConfig()), alerting.NewMockAlerter(), nil)
+	brk.SetFillHandler(engine.onFill)
+
+	mes := types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(5000)}
+	mgc := types.MarketEvent{Symbol: "MGC", Timestamp: time.Now(), Close: decimal.NewFromInt(2000)}
+	brk.SimulateMarketData(mes)
+	brk.SimulateMarketData(mgc)
+
+	if err := engine.processSignal(ctx, types.Signal{ID: "mes", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}, mes); err != nil {
+		t.Fatalf("MES entry error = %v", err)
+	}
+	engine.drainFills(ctx)
+	if _, ok := riskEngine.GetPosition("MES"); !ok {
+		t.Fatal("risk engine should track the MES position after its fill")
+	}
+
+	err := engine.processSignal(ctx, types.Signal{ID: "mgc", Symbol: "MGC", Direction: types.SideLong, StopTicks: 10}, mgc)
+	if !errors.Is(err, types.ErrMaxPositionsReached) {
+		t.Fatalf("MGC entry error = %v, want ErrMaxPositionsReached", err)
+	}
+
+	// Once MES is closed the slot frees up
+	if err := engine.processSignal(ctx, types.Signal{ID: "exit", Symbol: "MES", Direction: types.SideFlat}, mes); err != nil {
+		t.Fatalf("MES exit error = %v", err)
+	}
+	engine.drainFills(ctx)
+	if _, ok := riskEngine.GetPosition("MES"); ok {
+		t.Fatal("risk engine still tracks MES after it closed")
+	}
+	if err := engine.processSignal(ctx, types.Signal{ID: "mgc-2", Symbol: "MGC", Direction: types.SideLong, StopTicks: 10}, mgc); err != nil {
+		t.Fatalf("MGC entry after MES closed error = %v", err)
+	}
+}
+
+// TestEngine_PartialCloseAccounting tests that the dashboard shows the
+// entry price left after a partial close under the configured accounting.
+func TestEngine_PartialCloseAccounting(t *testing.T) {
+	tests := []struct {
+		name  string
+		basis risk.CostBasis
+		want  int64
+	}{
+		{"average keeps the blended cost", risk.CostAverage, 5015},
+		{"fifo closes the oldest contract", risk.CostFIFO, 5030},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			brokerCfg := paper.DefaultConfig()
+			brokerCfg.SyncFills = true
+			brokerCfg.SlippageTicks = 0
+			brk := paper.NewBroker(brokerCfg, nil)
+			if err := brk.Connect(ctx); err != nil {
+				t.Fatalf("failed to connect broker: %v", err)
+			}
+
+			riskCfg := risk.DefaultConfig()
+			riskCfg.SizingMode = risk.SizingFixedContracts
+			riskCfg.FixedContracts = 1
+			riskCfg.MaxExposurePerSymbolPct = decimal.NewFromInt(1)
+			riskCfg.MaxTotalExposurePct = decimal.NewFromInt(10)
+			riskCfg.CostBasis = tt.basis
+			riskEngine := risk.NewEngine(riskCfg, decimal.NewFromInt(100000), nil)
+			engine := NewEngine(Config{Symbol: "MES"}, brk, riskEngine, newMockStrategy("test"), observer.NewCalculator(observer.DefaultCalculatorConfig()), alerting.NewMockAlerter(), nil)
+			brk.SetFillHandler(engine.onFill)
+
+			// One contract at 5000, another at 5030, then close one; the
+			// stops and targets stay out of reach
+			var event types.MarketEvent
+			for i, signal := range []types.Signal{
+				{ID: "first", Symbol: "MES", Direction: types.SideLong, StopTicks: 200},
+				{ID: "second", Symbol: "MES", Direction: types.SideLong, StopTicks: 200},
+				{ID: "exit", Symbol: "MES", Direction: types.SideFlat, Contracts: 1},
+			} {
+				price := decimal.NewFromInt(5000 + 30*int64(min(i, 1)))
+				event = types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), High: price, Low: price, Close: price}
+				brk.SimulateMarketData(event)
+				if err := engine.processSignal(ctx, signal, event); err != nil {
+					t.Fatalf("%s error = %v", signal.ID, err)
+				}
+				engine.drainFills(ctx)
+			}
+
+			pos, _ := brk.GetPosition(ctx, "MES")
+			if pos == nil || pos.Contracts != 1 {
+				t.Fatalf("expected one contract left, got %+v", pos)
+			}
+			state := engine.positionState(*pos)
+			if !state.AvgCost.Equal(decimal.NewFromInt(tt.want)) {
+				t.Errorf("AvgCost = %s, want %d", state.AvgCost, tt.want)
+			}
+			wantPnL := execution.GrossPL("MES", types.SideLong, decimal.NewFromInt(tt.want), event.Close, 1)
+			if !state.UnrealizedPnL.Equal(wantPnL) {
+				t.Errorf("UnrealizedPnL = %s, want %s", state.UnrealizedPnL, wantPnL)
+			}
+		})
+	}
+}
+
+// TestEngine_OrderStoreRefusesResubmitAfterRestart tests that a signal
+// replayed by a restarted bot isn't sent again once its order was stored.
+func TestEngine_OrderStoreRefusesResubmitAfterRestart(t *testing.T) {
+	ctx := context.Background()
+
+	repo, err := persistence.NewSQLiteRepository(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository() error = %v", err)
+	}
+	defer repo.Close()
+
+	event := types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(5000)}
+	signal := types.Signal{ID: "breakout-MES-1-LONG", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+
+	// Each run gets a fresh broker connection; only the store survives
+	run := func() (*paper.Broker, error) {
+		brokerCfg := paper.DefaultConfig()
+		brokerCfg.SyncFills = true
+		brk := paper.NewBroker(brokerCfg, nil)
+		if err := brk.Connect(ctx); err != nil {
+			t.Fatalf("failed to connect broker: %v", err)
+		}
+		brk.SimulateMarketData(event)
+
+		riskEngine := risk.NewEngine(risk.DefaultConfig(), decimal.NewFromInt(100000), nil)
+		engine := NewEngine(Config{Symbol: "MES"}, brk, riskEngine, newMockStrategy("test"), observer.NewCalculator(observer.DefaultCalculatorConfig()), alerting.NewMockAlerter(), nil)
+		engine.SetOrderStore(repo)
+		return brk, engine.processSignal(ctx, signal, event)
+	}
+
+	if _, err := run(); err != nil {
+		t.Fatalf("first run error = %v", err)
+	}
+	brk, err := run()
+	if !errors.Is(err, types.ErrDuplicateOrder) {
+		t.Fatalf("restarted run error = %v, want ErrDuplicateOrder", err)
+	}
+	if pos, _ := brk.GetPosition(ctx, "MES"); pos != nil {
+		t.Errorf("restarted run opened %+v", pos)
+	}
+}
+
+// TestEngine_FlattenAll tests closing all positions with confirmation.
+func TestEngine_FlattenAll(t *testing.T) {
+	engine, brk, _, mockAlerter := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+
+	brk.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	if _, err := brk.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: "fa-open", Symbol: "MES", Side: types.SideLong, Contracts: 2}); err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	mockAlerter.Clear()
+	if err := engine.FlattenAll(ctx); err != nil {
+		t.Fatalf("FlattenAll() error = %v", err)
+	}
+
+	pos, err := brk.GetPosition(ctx, "MES")
+	if err != nil {
+		t.Fatalf("GetPosition() error = %v", err)
+	}
+	if pos != nil {
+		t.Errorf("expected position to be flattened, got %d contracts", pos.Contracts)
+	}
+	if !mockAlerter.HasAlertContaining("All positions flattened") {
+		t.Error("expected flatten alert")
+	}
+
+	// Nothing open: no-op without alert
+	mockAlerter.Clear()
+	if err := engine.FlattenAll(ctx); err != nil {
+		t.Fatalf("FlattenAll() error = %v", err)
+	}
+	if mockAlerter.HasAlertContaining("flattened") {
+		t.Error("flatten with no positions should not alert")
+	}
+}
+
+// TestEngine_FlattenAll_OrderIDsSurviveRestart tests that closing orders
+// get the same client order ID when a restarted bot flattens the same
+// position for the same reason.
+func TestEngine_FlattenAll_OrderIDsSurviveRestart(t *testing.T) {
+	ctx := context.Background()
+
+	flatten := func(reason string) string {
+		brk := newMockFailingBroker()
+		brk.positions = []broker.Position{{Symbol: "MES", Side: types.SideLong, Contracts: 2, AvgCost: decimal.NewFromInt(5000)}}
+		riskEngine := risk.NewEngine(risk.DefaultConfig(), decimal.NewFromInt(10000), nil)
+		engine := NewEngine(Config{Symbol: "MES", FlattenTimeout: 10 * time.Millisecond}, brk, riskEngine, newMockStrategy("test"), observer.NewCalculator(observer.DefaultCalculatorConfig()), alerting.NewMockAlerter(), nil)
+
+		// The mock never closes the position, so the flatten times out
+		_ = engine.flattenAll(ctx, reason)
+		if len(brk.placedIDs) != 1 {
+			t.Fatalf("placed %d orders, want 1", len(brk.placedIDs))
+		}
+		return brk.placedIDs[0]
+	}
+
+	first := flatten("kill_switch")
+	if again := flatten("kill_switch"); again != first {
+		t.Errorf("restarted flatten sent %s, want %s", again, first)
+	}
+	if other := flatten("shutdown"); other == first {
+		t.Errorf("flatten for another reason reused %s", first)
+	}
+}
+
+// TestEngine_FlattenAll_Timeout tests the alert when positions stay open.
+func TestEngine_FlattenAll_Timeout(t *testing.T) {
+	engine, brk, _, mockAlerter := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+
+	brk.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	if _, err := brk.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: "fa-open", Symbol: "MES", Side: types.SideLong, Contracts: 1}); err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// Disconnected broker rejects the closing order
+	_ = brk.Disconnect()
+	engine.cfg.FlattenTimeout = 100 * time.Millisecond
+
+	mockAlerter.Clear()
+	err := engine.FlattenAll(ctx)
+	if !errors.Is(err, types.ErrOrderTimeout) {
+		t.Errorf("FlattenAll() error = %v, want ErrOrderTimeout", err)
+	}
+	if !mockAlerter.HasAlertWithSeverity(alerting.SeverityCritical) {
+		t.Error("expected critical alert when flatten times out")
+	}
+}
+
+// TestEngine_KillSwitch_Flatten tests closing positions on kill switch when configured.
+func TestEngine_KillSwitch_Flatten(t *testing.T) {
+	engine, brk, _, _ := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+	engine.cfg.FlattenOnKillSwitch = true
+
+	brk.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	if _, err := brk.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: "ks-open", Symbol: "MES", Side: types.SideShort, Contracts: 1}); err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	engine.handleKillSwitch(ctx)
+
+	pos, err := brk.GetPosition(ctx, "MES")
+	if err != nil {
+		t.Fatalf("GetPosition() error = %v", err)
+	}
+	if pos != nil {
+		t.Errorf("expected position to be closed on kill switch, got %d contracts", pos.Contracts)
+	}
+}
+
+// TestEngine_FlattenForShutdown tests closing positions during graceful shutdown.
+func TestEngine_FlattenForShutdown(t *testing.T) {
+	engine, brk, _, _ := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+	brk.SetFillHandler(engine.onFill)
+
+	opened := time.Now()
+	brk.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	if _, err := brk.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: "sd-open", Symbol: "MES", Side: types.SideLong, Contracts: 1}); err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	brk.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5010), High: decimal.NewFromInt(5010), Low: decimal.NewFromInt(5010)})
+
+	if err := engine.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	trades, err := engine.FlattenForShutdown(shutdownCtx)
+	if err != nil {
+		t.Fatalf("FlattenForShutdown() error = %v", err)
+	}
+
+	pos, err := brk.GetPosition(ctx, "MES")
+	if err != nil {
+		t.Fatalf("GetPosition() error = %v", err)
+	}
+	if pos != nil {
+		t.Errorf("expected position to be closed on shutdown, got %d contracts", pos.Contracts)
+	}
+
+	if len(trades) != 1 {
+		t.Fatalf("trades = %d, want 1", len(trades))
+	}
+	trade := trades[0]
+	if trade.Symbol != "MES" || trade.Side != types.SideLong {
+		t.Errorf("trade = %s %s, want MES LONG", trade.Symbol, trade.Side)
+	}
+	// Closing fill at 5010 less a tick of slippage
+	if !trade.ExitPrice.Equal(decimal.RequireFromString("5009.75")) {
+		t.Errorf("ExitPrice = %s, want 5009.75", trade.ExitPrice)
+	}
+	if trade.EntryTime.Before(opened) || !trade.EntryTime.Before(trade.ExitTime) {
+		t.Errorf("EntryTime = %s, want the entry fill's time before exit %s", trade.EntryTime, trade.ExitTime)
+	}
+	// Both sides' commission
+	if !trade.Commission.Equal(decimal.RequireFromString("1.24")) {
+		t.Errorf("Commission = %s, want 1.24", trade.Commission)
+	}
+	if !trade.NetPL.Equal(trade.GrossPL.Sub(trade.Commission)) {
+		t.Errorf("NetPL = %s, want GrossPL %s - Commission %s", trade.NetPL, trade.GrossPL, trade.Commission)
+	}
+	if trade.ExitReason != execution.ExitFlatten {
+		t.Errorf("ExitReason = %q, want %q", trade.ExitReason, execution.ExitFlatten)
+	}
+}
+
+// quotingBroker adds a fixed bid/ask spread to the paper broker.
+type quotingBroker struct {
+	*paper.Broker
+	spread decimal.Decimal
+}
+
+func (b *quotingBroker) Spread(symbol string) (decimal.Decimal, bool) {
+	return b.spread, true
+}
+
+// TestEngine_MaxSpreadGuard tests rejecting signals when the spread is too wide.
+func TestEngine_MaxSpreadGuard(t *testing.T) {
+	engine, brk, _, _ := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+	brk.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+
+	event := types.MarketEvent{
+		Timestamp: time.Now(),
+		Symbol:    "MES",
+		Close:     decimal.NewFromInt(5000),
+		ATR:       decimal.NewFromInt(10),
+	}
+	signal := types.Signal{ID: "spread-1", Symbol: "MES", Direction: types.SideLong, StopTicks: 10, StrategyName: "test_strategy"}
+
+	engine.cfg.MaxSpreadTicks = 2
+
+	// Paper broker has no quotes: guard disabled
+	if err := engine.processSignal(ctx, signal, event); errors.Is(err, types.ErrSpreadTooWide) {
+		t.Fatal("spread guard should be disabled without bid/ask quotes")
+	}
+
+	// 4 ticks wide (1.00 on MES) exceeds the 2 tick max
+	quoting := &quotingBroker{Broker: brk, spread: decimal.RequireFromString("1.00")}
+	engine.broker = quoting
+	signal.ID = "spread-2"
+	if err := engine.processSignal(ctx, signal, event); !errors.Is(err, types.ErrSpreadTooWide) {
+		t.Errorf("processSignal() error = %v, want ErrSpreadTooWide", err)
+	}
+
+	// 2 ticks is within the limit
+	quoting.spread = decimal.RequireFromString("0.50")
+	signal.ID = "spread-3"
+	if err := engine.processSignal(ctx, signal, event); errors.Is(err, types.ErrSpreadTooWide) {
+		t.Errorf("processSignal() error = %v, want spread accepted", err)
+	}
+}
+
+// TestEngine_StaleDataWatchdog tests entering and leaving the degraded state.
+func TestEngine_StaleDataWatchdog(t *testing.T) {
+	engine, brk, _, mockAlerter := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+	engine.cfg.StaleDataThreshold = 10 * time.Second
+	engine.cfg.FlattenOnStaleData = true
+
+	brk.SimulateMarketData(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	if _, err := brk.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: "stale-open", Symbol: "MES", Side: types.SideLong, Contracts: 1}); err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	received := time.Now()
+	engine.mu.Lock()
+	engine.lastEventAt = received
+	engine.mu.Unlock()
+	mockAlerter.Clear()
+
+	// Within one bar plus threshold: healthy
+	engine.checkStaleness(ctx, received.Add(engine.cfg.Timeframe))
+	if engine.IsDegraded() {
+		t.Fatal("should not be degraded within timeframe + threshold")
+	}
+
+	// Past the threshold: degraded, alert, flatten
+	engine.checkStaleness(ctx, received.Add(engine.cfg.Timeframe+11*time.Second))
+	if !engine.IsDegraded() {
+		t.Fatal("expected degraded state after data goes stale")
+	}
+	if !mockAlerter.HasAlertWithSeverity(alerting.SeverityCritical) {
+		t.Error("expected critical stale data alert")
+	}
+	if check := engine.HealthCheck(); check.Status != "degraded" {
+		t.Errorf("HealthCheck().Status = %s, want degraded", check.Status)
+	}
+	if pos, _ := brk.GetPosition(ctx, "MES"); pos != nil {
+		t.Error("expected position to be flattened on stale data")
+	}
+
+	// Alert only once while degraded
+	mockAlerter.Clear()
+	engine.checkStaleness(ctx, received.Add(engine.cfg.Timeframe+20*time.Second))
+	if mockAlerter.HasAlertContaining("STALE") {
+		t.Error("stale alert should not repeat while degraded")
+	}
+
+	// Fresh data recovers
+	now := time.Now()
+	engine.mu.Lock()
+	engine.lastEventAt = now
+	engine.mu.Unlock()
+	engine.checkStaleness(ctx, now.Add(time.Second))
+	if engine.IsDegraded() {
+		t.Error("expected recovery after data resumes")
+	}
+	if !mockAlerter.HasAlertContaining("Market data resumed") {
+		t.Error("expected data resumed alert")
+	}
+}
+
+// TestEngine_StaleDataWatchdog_Loop tests the watchdog goroutine and its cleanup on Stop.
+func TestEngine_StaleDataWatchdog_Loop(t *testing.T) {
+	engine, brk, _, mockAlerter := createTestEngine(t)
+	ctx := context.Background()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+	engine.cfg.Timeframe = 20 * time.Millisecond
+	engine.cfg.StaleDataThreshold = 20 * time.Millisecond
+	engine.cfg.HeartbeatInterval = 10 * time.Millisecond
+
+	if err := engine.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if !engine.IsDegraded() {
+		t.Error("expected degraded state without market data")
+	}
+	if !mockAlerter.HasAlertContaining("MARKET DATA STALE") {
+		t.Error("expected stale data alert")
+	}
+
+	// Stop waits for the watchdog to exit
+	if err := engine.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+}
+
+// TestEngine_HealthChecks tests the broker, trading loop and market data checks.
+func TestEngine_HealthChecks(t *testing.T) {
+	engine, brk, _, _ := createTestEngine(t)
+	ctx := context.Background()
+
+	if check := engine.BrokerHealthCheck(); check.Status != "unhealthy" {
+		t.Errorf("BrokerHealthCheck() before connect = %s, want unhealthy", check.Status)
+	}
+	if check := engine.LoopHealthCheck(); check.Status != "unhealthy" {
+		t.Errorf("LoopHealthCheck() before start = %s, want unhealthy", check.Status)
+	}
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+	engine.cfg.HeartbeatInterval = time.Minute // No idle beats during the test
+	if err := engine.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if check := engine.BrokerHealthCheck(); check.Status != "healthy" {
+		t.Errorf("BrokerHealthCheck() = %s, want healthy", check.Status)
+	}
+	if check := engine.LoopHealthCheck(); check.Status != "healthy" {
+		t.Errorf("LoopHealthCheck() = %+v, want healthy", check)
+	}
+
+	// A loop that missed its heartbeats is stalled
+	engine.mu.Lock()
+	engine.loopBeatAt = time.Now().Add(-4 * time.Minute)
+	engine.mu.Unlock()
+	if check := engine.LoopHealthCheck(); check.Status != "unhealthy" {
+		t.Errorf("LoopHealthCheck() after missed heartbeats = %s, want unhealthy", check.Status)
+	}
+	engine.cfg.MaxMissedHeartbeats = 5
+	if check := engine.LoopHealthCheck(); check.Status != "healthy" {
+		t.Errorf("LoopHealthCheck() with 5 allowed misses = %s, want healthy", check.Status)
+	}
+	engine.cfg.MaxMissedHeartbeats = 0
+
+	// Stale data escalates from degraded to unhealthy at twice the allowed age
+	maxAge := engine.cfg.Timeframe + engine.cfg.StaleDataThreshold
+	engine.mu.Lock()
+	engine.degraded = true
+	engine.lastEventAt = time.Now().Add(-maxAge - time.Minute)
+	engine.mu.Unlock()
+	if check := engine.HealthCheck(); check.Status != "degraded" {
+		t.Errorf("HealthCheck() = %s, want degraded", check.Status)
+	}
+	engine.mu.Lock()
+	engine.lastEventAt = time.Now().Add(-2*maxAge - time.Minute)
+	engine.mu.Unlock()
+	if check := engine.HealthCheck(); check.Status != "unhealthy" {
+		t.Errorf("HealthCheck() = %s, want unhealthy", check.Status)
+	}
+
+	if err := engine.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if check := engine.LoopHealthCheck(); check.Status != "unhealthy" {
+		t.Errorf("LoopHealthCheck() after Stop = %s, want unhealthy", check.Status)
+	}
+}
+
+// fixedIndicators reports a constant ATR so sizing doesn't wait on warm-up.
+type fixedIndicators struct {
+	atr decimal.Decimal
+}
+
+func (f fixedIndicators) OnBar(event types.MarketEvent) types.MarketEvent {
+	event.ATR = f.atr
+	return event
+}
+
+func (f fixedIndicators) CurrentATR() decimal.Decimal  { return f.atr }
+func (f fixedIndicators) CurrentVWAP() decimal.Decimal { return decimal.Zero }
+
+func TestEngine_SizesFromInjectedIndicators(t *testing.T) {
+	ctx := context.Background()
+
+	brokerCfg := paper.DefaultConfig()
+	brokerCfg.SyncFills = true
+	brk := paper.NewBroker(brokerCfg, nil)
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+
+	strat := newMockStrategy("test")
+	riskEngine := risk.NewEngine(risk.DefaultConfig(), decimal.NewFromInt(10000), nil)
+	engine := NewEngine(Config{Symbol: "MES"}, brk, riskEngine, strat, fixedIndicators{atr: decimal.NewFromInt(4)}, alerting.NewMockAlerter(), nil)
+
+	event := types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(5000)}
+	brk.SimulateMarketData(event)
+
+	// No StopTicks: the stop comes from ATR on the very first bar
+	strat.AddSignal(types.Signal{ID: "atr", Symbol: "MES", Direction: types.SideLong})
+	if err := engine.processMarketEvent(ctx, event); err != nil {
+		t.Fatalf("processMarketEvent() error = %v", err)
+	}
+
+	// $100 risk / (2 x 4 points x $5) = 2 contracts
+	pos, _ := brk.GetPosition(ctx, "MES")
+	if pos == nil || pos.Contracts != 2 {
+		t.Fatalf("position = %+v, want 2 contracts", pos)
+	}
+}
+
+func TestEngine_DataLag(t *testing.T) {
+	ctx := context.Background()
+
+	brokerCfg := paper.DefaultConfig()
+	brokerCfg.SyncFills = true
+	brk := paper.NewBroker(brokerCfg, nil)
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+
+	strat := newMockStrategy("test")
+	cfg := Config{
+		Symbol:             "MES",
+		Timeframe:          5 * time.Minute,
+		StaleDataThreshold: 30 * time.Second,
+		CheckDataLag:       true,
+	}
+	riskEngine := risk.NewEngine(risk.DefaultConfig(), decimal.NewFromInt(10000), nil)
+	engine := NewEngine(cfg, brk, riskEngine, strat, observer.NewCalculator(observer.DefaultCalculatorConfig()), alerting.NewMockAlerter(), nil)
+
+	// Feed clocks in another zone must not look like lag
+	feedZone := time.FixedZone("feed", -5*60*60)
+	signal := types.Signal{ID: "long", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+	barAt := func(ts time.Time) types.MarketEvent {
+		return types.MarketEvent{Symbol: "MES", Timestamp: ts, Close: decimal.NewFromInt(5000), ATR: decimal.NewFromInt(10)}
+	}
+
+	old := barAt(time.Now().Add(-10 * time.Minute).In(feedZone))
+	brk.SimulateMarketData(old)
+	strat.AddSignal(signal)
+	if err := engine.processMarketEvent(ctx, old); err != nil {
+		t.Fatalf("processMarketEvent() error = %v", err)
+	}
+	if pos, _ := brk.GetPosition(ctx, "MES"); pos != nil {
+		t.Fatalf("traded on a lagging bar: %+v", pos)
+	}
+
+	// The bar that just closed is current
+	fresh := barAt(time.Now().Add(-cfg.Timeframe).In(feedZone))
+	strat.AddSignal(signal)
+	if err := engine.processMarketEvent(ctx, fresh); err != nil {
+		t.Fatalf("processMarketEvent() error = %v", err)
+	}
+	if pos, _ := brk.GetPosition(ctx, "MES"); pos == nil {
+		t.Error("expected a position from the current bar")
+	}
+
+	// Replay: historical timestamps are not checked
+	engine.cfg.CheckDataLag = false
+	if engine.isLagging(old) {
+		t.Error("lag check should be disabled")
+	}
+}
+
+func TestEngine_AuditTrail(t *testing.T) {
+	engine, brk, strat, _ := createTestEngine(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	rec, err := audit.NewRecorder(audit.Config{Path: path}, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	defer rec.Close()
+	engine.SetAuditRecorder(rec)
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+	if err := engine.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer engine.Stop(context.Background())
+
+	strat.AddSignal(types.Signal{
+		ID:           "audit-signal",
+		Symbol:       "MES",
+		Direction:    types.SideLong,
+		StopTicks:    10,
+		StrategyName: "test_strategy",
+	})
+	event := types.MarketEvent{
+		Timestamp: time.Now(),
+		Symbol:    "MES",
+		Open:      decimal.NewFromInt(5000),
+		High:      decimal.NewFromInt(5010),
+		Low:       decimal.NewFromInt(4990),
+		Close:     decimal.NewFromInt(5005),
+		ATR:       decimal.NewFromInt(10),
+	}
+	if err := engine.processMarketEvent(ctx, event); err != nil {
+		t.Fatalf("processMarketEvent() error = %v", err)
+	}
+
+	// The fill arrives after the paper broker's fill delay
+	want := []audit.RecordType{audit.TypeSignal, audit.TypeIntent, audit.TypeOrder, audit.TypeFill}
+	var got []audit.RecordType
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		got = readAuditTypes(t, path)
+		if len(got) >= len(want) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("audit records = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("record %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+// readAuditTypes returns the type of each record in an audit log.
+func readAuditTypes(t *testing.T, path string) []audit.RecordType {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+
+	var recordTypes []audit.RecordType
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec audit.Record
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("unmarshal %q: %v", line, err)
+		}
+		recordTypes = append(recordTypes, rec.Type)
+	}
+	return recordTypes
+}
+
+// snapshotStore records saved equity snapshots.
+type snapshotStore struct {
+	mu        sync.Mutex
+	snapshots []persistence.EquitySnapshot
+}
+
+func (s *snapshotStore) SaveEquitySnapshot(_ context.Context, snapshot persistence.EquitySnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots = append(s.snapshots, snapshot)
+	return nil
+}
+
+func (s *snapshotStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.snapshots)
+}
+
+func TestEngine_SnapshotLoop(t *testing.T) {
+	engine, brk, _, _ := createTestEngine(t)
+	engine.cfg.SnapshotInterval = 20 * time.Millisecond
+	store := &snapshotStore{}
+	engine.SetSnapshotStore(store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+	if err := engine.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	time.Sleep(110 * time.Millisecond)
+	cancel()
+	if err := engine.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	saved := store.count()
+	if saved < 2 {
+		t.Fatalf("snapshots = %d, want at least 2", saved)
+	}
+
+	first := store.snapshots[0]
+	if !first.Equity.Equal(decimal.NewFromInt(10000)) || !first.HighWaterMark.Equal(decimal.NewFromInt(10000)) {
+		t.Errorf("snapshot = %+v, want equity and HWM 10000", first)
+	}
+	if first.OpenPositions != 0 {
+		t.Errorf("OpenPositions = %d, want 0", first.OpenPositions)
+	}
+
+	// No snapshots after shutdown
+	time.Sleep(50 * time.Millisecond)
+	if got := store.count(); got != saved {
+		t.Errorf("snapshots after stop = %d, want %d", got, saved)
+	}
+}
+
+func TestEngine_PerSymbolIndicators(t *testing.T) {
+	ctx := context.Background()
+
+	brokerCfg := paper.DefaultConfig()
+	brokerCfg.SyncFills = true
+	brk := paper.NewBroker(brokerCfg, nil)
+	if err := brk.Connect(ctx); err != nil {
+		t.Fatalf("failed to connect broker: %v", err)
+	}
+
+	cfg := Config{
+		Symbol: "MES",
+		Calculators: map[string]observer.CalculatorConfig{
+			"MES": {ATRPeriod: 5},
+			"MGC": {ATRPeriod: 5},
+		},
+	}
+	shared := observer.NewCalculator(observer.DefaultCalculatorConfig())
+	riskEngine := risk.NewEngine(risk.DefaultConfig(), decimal.NewFromInt(10000), nil)
+	engine := NewEngine(cfg, brk, riskEngine, newMockStrategy("test"), shared, alerting.NewMockAlerter(), nil)
+
+	// Interleave a quiet MES (2-point bars) with a volatile MGC (10-point bars)
+	start := time.Now().Add(-time.Hour)
+	bar := func(symbol string, i int, price, rng int64) types.MarketEvent {
+		return types.MarketEvent{
+			Symbol:    symbol,
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Open:      decimal.NewFromInt(price),
+			High:      decimal.NewFromInt(price + rng/2),
+			Low:       decimal.NewFromInt(price - rng/2),
+			Close:     decimal.NewFromInt(price),
+		}
+	}
+	for i := 0; i < 10; i++ {
+		if err := engine.processMarketEvent(ctx, bar("MES", i, 5000, 2)); err != nil {
+			t.Fatalf("processMarketEvent(MES) error = %v", err)
+		}
+		if err := engine.processMarketEvent(ctx, bar("MGC", i, 2000, 10)); err != nil {
+			t.Fatalf("processMarketEvent(MGC) error = %v", err)
+		}
+	}
+
+	mesATR := engine.indicatorsFor("MES").CurrentATR()
+	mgcATR := engine.indicatorsFor("MGC").CurrentATR()
+	if !mesATR.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("MES ATR = %s, want 2", mesATR)
+	}
+	if !mgcATR.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("MGC ATR = %s, want 10", mgcATR)
+	}
+	if !shared.CurrentATR().IsZero() {
+		t.Errorf("shared calculator ATR = %s, want untouched", shared.CurrentATR())
+	}
+	if engine.indicatorsFor("MES") == shared || engine.indicatorsFor("MNQ") == shared {
+		t.Error("symbols other than the primary should not use the shared calculator")
+	}
+
+	// A symbol without its own config gets a calculator from the defaults
+	// rather than the primary symbol's
+	cfg.Symbol, cfg.Calculators = "MNQ", nil
+	cfg.Symbols = []string{"MES"}
+	engine = NewEngine(cfg, brk, riskEngine, newMockStrategy("test"), shared, alerting.NewMockAlerter(), nil)
+	for i := 0; i < 20; i++ {
+		if err := engine.processMarketEvent(ctx, bar("MES", i, 5000, 2)); err != nil {
+			t.Fatalf("processMarketEvent(MES) error = %v", err)
+		}
+	}
+	if engine.indicatorsFor("MNQ") != shared {
+		t.Error("the primary symbol should use the calculator passed to NewEngine")
+	}
+	if !shared.CurrentATR().IsZero() {
+		t.Errorf("shared calculator ATR = %s, want untouched by MES bars", shared.CurrentATR())
+	}
+	if got := engine.indicatorsFor("MES").CurrentATR(); !got.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("MES ATR = %s, want 2 from its own default calculator", got)
+	}
+}
+
+func TestEngine_WarmUp(t *testing.T) {
+	cfg := Config{Symbol: "MES"}
+	calculator := observer.NewCalculator(observer.CalculatorConfig{ATRPeriod: 5, StdDevPeriod: 5})
+	riskEngine := risk.NewEngine(risk.DefaultConfig(), decimal.NewFromInt(10000), nil)
+	strat := newMockStrategy("test")
+	engine := NewEngine(cfg, paper.NewBroker(paper.DefaultConfig(), nil), riskEngine, strat, calculator, alerting.NewMockAlerter(), nil)
+
+	if got := engine.WarmUp(nil); got != 0 {
+		t.Errorf("WarmUp(nil) = %d, want 0", got)
+	}
+
+	start := time.Now().Add(-time.Hour)
+	bars := make([]types.MarketEvent, 10)
+	for i := range bars {
+		bars[i] = types.MarketEvent{
+			Symbol:    "MES",
+			Timestamp: start.Add(time.Duration(i) * 5 * time.Minute),
+			Open:      decimal.NewFromInt(5000),
+			High:      decimal.NewFromInt(5002),
+			Low:       decimal.NewFromInt(4998),
+			Close:     decimal.NewFromInt(5000),
+		}
+	}
+
+	if got := engine.WarmUp(bars); got != len(bars) {
+		t.Errorf("WarmUp() = %d, want %d", got, len(bars))
+	}
+	if atr := calculator.CurrentATR(); !atr.Equal(decimal.NewFromInt(4)) {
+		t.Errorf("ATR after warm-up = %s, want 4", atr)
+	}
+	if strat.callCount != 0 {
+		t.Errorf("strategy saw %d warm-up bars, want 0", strat.callCount)
+	}
+}
@@ -3,6 +3,8 @@ package engine
 import (
 	"context"
 	"errors"
+	"fmt"
+	"syscall"
 	"testing"
 	"time"
 
@@ -24,6 +26,11 @@ type mockFailingBroker struct {
 	subscribeErr       error
 	unsubscribeErr     error
 	placeOrderCallCount int
+	placeOrderBlock    chan struct{} // PlaceOrder hangs until closed, ignoring ctx
+	getPositionBlock   chan struct{} // GetPosition hangs until closed, ignoring ctx
+	placeOrderErrs     []error       // Per-call errors, used before placeOrderErr
+	placedIDs          []string      // ClientOrderID of every PlaceOrder call
+	positions          []broker.Position // Returned by GetPositions
 }
 
 func newMockFailingBroker() *mockFailingBroker {
@@ -55,10 +62,13 @@ func (m *mockFailingBroker) GetAccountSummary(ctx context.Context) (*broker.Acco
 }
 
 func (m *mockFailingBroker) GetPositions(ctx context.Context) ([]broker.Position, error) {
-	return nil, nil
+	return m.positions, nil
 }
 
 func (m *mockFailingBroker) GetPosition(ctx context.Context, symbol string) (*broker.Position, error) {
+	if m.getPositionBlock != nil {
+		<-m.getPositionBlock
+	}
 	return nil, nil
 }
 
@@ -75,6 +85,17 @@ func (m *mockFailingBroker) Shutdown(ctx context.Context) error {
 
 func (m *mockFailingBroker) PlaceOrder(ctx context.Context, order types.OrderIntent) (*broker.OrderResult, error) {
 	m.placeOrderCallCount++
+	m.placedIDs = append(m.placedIDs, order.ClientOrderID)
+	if m.placeOrderBlock != nil {
+		<-m.placeOrderBlock
+	}
+	if len(m.placeOrderErrs) > 0 {
+		err := m.placeOrderErrs[0]
+		m.placeOrderErrs = m.placeOrderErrs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
 	if m.placeOrderErr != nil {
 		return nil, m.placeOrderErr
 	}
@@ -89,6 +110,10 @@ func (m *mockFailingBroker) CancelOrder(ctx context.Context, orderID string) err
 	return m.cancelOrderErr
 }
 
+func (m *mockFailingBroker) ModifyOrder(ctx context.Context, orderID string, newPrice decimal.Decimal, newQty int) error {
+	return nil
+}
+
 func (m *mockFailingBroker) GetOpenOrders(ctx context.Context) ([]broker.Order, error) {
 	return nil, nil
 }
@@ -205,6 +230,175 @@ func TestEngine_Failure_OrderRejection(t *testing.T) {
 	}
 }
 
+// TestEngine_Failure_PlaceOrderHangs tests that a hung broker call times out
+// and the trading loop keeps processing market data.
+func TestEngine_Failure_PlaceOrderHangs(t *testing.T) {
+	brk := newMockFailingBroker()
+	brk.placeOrderBlock = make(chan struct{})
+	defer close(brk.placeOrderBlock)
+
+	riskEngine := risk.NewEngine(risk.DefaultConfig(), decimal.NewFromInt(10000), nil)
+	strat := newMockStrategy("test")
+	calc := observer.NewCalculator(observer.DefaultCalculatorConfig())
+	mockAlerter := alerting.NewMockAlerter()
+
+	cfg := Config{
+		Symbol:               "MES",
+		EquityUpdateInterval: 1 * time.Second,
+		OrderTimeout:         50 * time.Millisecond,
+	}
+
+	engine := NewEngine(cfg, brk, riskEngine, strat, calc, mockAlerter, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := engine.Start(ctx); err != nil {
+		t.Fatalf("failed to start engine: %v", err)
+	}
+	defer engine.Stop(ctx)
+
+	strat.AddSignal(types.Signal{
+		ID:        "hang-signal",
+		Symbol:    "MES",
+		Direction: types.SideLong,
+		StopTicks: 10,
+	})
+
+	event := types.MarketEvent{
+		Symbol:    "MES",
+		Timestamp: time.Now(),
+		Open:      decimal.NewFromInt(5000),
+		High:      decimal.NewFromInt(5010),
+		Low:       decimal.NewFromInt(4990),
+		Close:     decimal.NewFromInt(5005),
+	}
+	brk.SendEvent(event)
+
+	// The next bar is processed once the hung order times out
+	brk.SendEvent(event)
+	deadline := time.Now().Add(2 * time.Second)
+	for strat.CallCount() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if strat.CallCount() < 2 {
+		t.Fatal("trading loop blocked by hung PlaceOrder")
+	}
+
+	if !mockAlerter.HasAlertContaining("ORDER TIMEOUT") {
+		t.Error("expected order timeout alert")
+	}
+}
+
+// TestEngine_Failure_ConfirmationPositionHangs tests that entry confirmation
+// gives up on a hung position lookup instead of blocking the trading loop.
+func TestEngine_Failure_ConfirmationPositionHangs(t *testing.T) {
+	brk := newMockFailingBroker()
+	brk.getPositionBlock = make(chan struct{})
+	defer close(brk.getPositionBlock)
+
+	riskEngine := risk.NewEngine(risk.DefaultConfig(), decimal.NewFromInt(10000), nil)
+	cfg := Config{
+		Symbol:           "MES",
+		OrderTimeout:     50 * time.Millisecond,
+		ConfirmationBars: 2,
+	}
+	engine := NewEngine(cfg, brk, riskEngine, newMockStrategy("test"), observer.NewCalculator(observer.DefaultCalculatorConfig()), alerting.NewMockAlerter(), nil)
+
+	signal := types.Signal{ID: "confirm-1", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+	done := make(chan bool, 1)
+	go func() {
+		done <- engine.confirmSignal(context.Background(), signal, types.MarketEvent{Symbol: "MES"})
+	}()
+
+	select {
+	case confirmed := <-done:
+		if confirmed {
+			t.Error("first signal should wait for confirmation")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("confirmSignal blocked on a hung GetPosition")
+	}
+}
+
+// TestEngine_PlaceOrderRetries tests that transient broker errors are
+// retried with the same client order ID and rejections are not.
+func TestEngine_PlaceOrderRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		errs       []error
+		wantCalls  int
+		wantErr    error
+		wantStatus broker.OrderStatus
+	}{
+		{"transient then ok", []error{broker.ErrRateLimited}, 2, nil, broker.OrderStatusPending},
+		{"connection reset", []error{fmt.Errorf("write: %w", syscall.ECONNRESET), broker.ErrNotConnected}, 3, nil, broker.OrderStatusPending},
+		{"rejection not retried", []error{fmt.Errorf("%w: insufficient margin", types.ErrOrderRejected)}, 1, types.ErrOrderRejected, ""},
+		{"retries exhausted", []error{broker.ErrNotConnected, broker.ErrNotConnected, broker.ErrNotConnected}, 3, broker.ErrNotConnected, ""},
+		{"earlier attempt landed", []error{types.ErrConnectionLost, types.ErrDuplicateOrder}, 2, nil, broker.OrderStatusSubmitted},
+		{"duplicate on first attempt", []error{types.ErrDuplicateOrder}, 1, types.ErrDuplicateOrder, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			brk := newMockFailingBroker()
+			brk.placeOrderErrs = tt.errs
+
+			riskEngine := risk.NewEngine(risk.DefaultConfig(), decimal.NewFromInt(10000), nil)
+			cfg := Config{Symbol: "MES", MaxRetries: 2, RetryDelay: time.Millisecond}
+			engine := NewEngine(cfg, brk, riskEngine, newMockStrategy("test"), observer.NewCalculator(observer.DefaultCalculatorConfig()), alerting.NewMockAlerter(), nil)
+
+			result, err := engine.placeOrder(context.Background(), types.OrderIntent{ClientOrderID: "retry-1", Symbol: "MES", Side: types.SideLong, Contracts: 1})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil || result.Status != tt.wantStatus {
+				t.Errorf("result = %+v, err = %v, want status %s", result, err, tt.wantStatus)
+			}
+
+			if len(brk.placedIDs) != tt.wantCalls {
+				t.Errorf("PlaceOrder calls = %d, want %d", len(brk.placedIDs), tt.wantCalls)
+			}
+			for _, id := range brk.placedIDs {
+				if id != "retry-1" {
+					t.Errorf("retry used ClientOrderID %q, want retry-1", id)
+				}
+			}
+		})
+	}
+}
+
+// TestCallBroker_Timeout tests that callBroker reports a missed deadline as
+// ErrOrderTimeout even when the call ignores its context.
+func TestCallBroker_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	start := time.Now()
+	_, err := callBroker(context.Background(), 20*time.Millisecond, "test", func(context.Context) (int, error) {
+		<-release
+		return 1, nil
+	})
+	if !errors.Is(err, types.ErrOrderTimeout) {
+		t.Errorf("err = %v, want ErrOrderTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("callBroker returned after %s", elapsed)
+	}
+
+	// Cancellation of the parent context is not a timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = callBroker(ctx, time.Second, "test", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) || errors.Is(err, types.ErrOrderTimeout) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
 // TestEngine_Failure_MarketDataFeedError tests handling of market data subscription failure (FAIL-03).
 func TestEngine_Failure_MarketDataFeedError(t *testing.T) {
 	brk := newMockFailingBroker()
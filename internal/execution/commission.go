@@ -0,0 +1,118 @@
+package execution
+
+import (
+	"sync"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// CommissionModel calculates the commission charged for one side of a fill.
+// A negative commission is a rebate credited to the account, as venues pay
+// makers for resting liquidity; it flows through NetPL = GrossPL - Commission.
+// Executors charge resting limit fills their maker model when one is set,
+// so only those fills earn a rebate.
+type CommissionModel interface {
+	Commission(symbol string, contracts int, price decimal.Decimal) decimal.Decimal
+}
+
+// FlatCommission charges a fixed amount per contract per side.
+type FlatCommission struct {
+	PerContract decimal.Decimal
+}
+
+// NewFlatCommission creates a flat per-contract commission model.
+func NewFlatCommission(perContract decimal.Decimal) FlatCommission {
+	return FlatCommission{PerContract: perContract}
+}
+
+// Commission returns PerContract * contracts.
+func (f FlatCommission) Commission(symbol string, contracts int, price decimal.Decimal) decimal.Decimal {
+	return f.PerContract.Mul(decimal.NewFromInt(int64(contracts)))
+}
+
+// CommissionTier is one band of a tiered commission schedule.
+type CommissionTier struct {
+	UpToContracts int             // Cumulative volume this tier applies up to (0 = unlimited)
+	PerContract   decimal.Decimal // Broker commission per contract per side
+}
+
+// TieredCommission charges a per-contract rate that drops as cumulative
+// volume grows (e.g. IBKR tiered pricing), plus per-instrument exchange and
+// regulatory fees from types.InstrumentSpec.
+//
+// Commission counts the contracts toward the volume tiers, so it must be
+// called exactly once per fill. Call ResetVolume at the start of each billing period.
+type TieredCommission struct {
+	tiers []CommissionTier
+
+	mu     sync.Mutex
+	volume int // Contracts filled this period
+}
+
+// NewTieredCommission creates a tiered commission model.
+// Tiers must be ordered by UpToContracts; the last tier should be unlimited.
+func NewTieredCommission(tiers []CommissionTier) *TieredCommission {
+	return &TieredCommission{tiers: tiers}
+}
+
+// Commission returns the tiered broker commission plus exchange fees.
+// An order that crosses a tier boundary is charged at each tier's rate.
+func (t *TieredCommission) Commission(symbol string, contracts int, price decimal.Decimal) decimal.Decimal {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	total := decimal.Zero
+	remaining := contracts
+	for _, tier := range t.tiers {
+		if remaining == 0 {
+			break
+		}
+		n := remaining
+		if tier.UpToContracts > 0 {
+			room := tier.UpToContracts - t.volume
+			if room <= 0 {
+				continue
+			}
+			if n > room {
+				n = room
+			}
+		}
+		total = total.Add(tier.PerContract.Mul(decimal.NewFromInt(int64(n))))
+		t.volume += n
+		remaining -= n
+	}
+
+	// Volume beyond the last tier is charged at the last tier's rate
+	if remaining > 0 && len(t.tiers) > 0 {
+		last := t.tiers[len(t.tiers)-1]
+		total = total.Add(last.PerContract.Mul(decimal.NewFromInt(int64(remaining))))
+		t.volume += remaining
+	}
+
+	if spec, ok := types.GetInstrumentSpec(symbol); ok {
+		total = total.Add(spec.ExchangeFee.Mul(decimal.NewFromInt(int64(contracts))))
+	}
+
+	return total
+}
+
+// Volume returns the contracts counted toward tiers this period.
+func (t *TieredCommission) Volume() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.volume
+}
+
+// Clone returns a model with the same tiers and zero volume, so parallel
+// simulations don't share volume counts.
+func (t *TieredCommission) Clone() *TieredCommission {
+	return NewTieredCommission(t.tiers)
+}
+
+// ResetVolume starts a new billing period.
+func (t *TieredCommission) ResetVolume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.volume = 0
+}
@@ -0,0 +1,181 @@
+package execution
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func TestFlatCommission(t *testing.T) {
+	model := NewFlatCommission(decimal.RequireFromString("0.62"))
+
+	got := model.Commission("MES", 3, decimal.NewFromInt(5000))
+	want := decimal.RequireFromString("1.86")
+	if !got.Equal(want) {
+		t.Errorf("Commission = %s, want %s", got, want)
+	}
+}
+
+func TestTieredCommission_CrossesTiers(t *testing.T) {
+	model := NewTieredCommission([]CommissionTier{
+		{UpToContracts: 10, PerContract: decimal.RequireFromString("0.25")},
+		{UpToContracts: 0, PerContract: decimal.RequireFromString("0.15")},
+	})
+
+	// 8 contracts in tier 1: 8*0.25 + 8*0.37 exchange fee
+	got := model.Commission("MES", 8, decimal.NewFromInt(5000))
+	want := decimal.RequireFromString("4.96")
+	if !got.Equal(want) {
+		t.Errorf("first Commission = %s, want %s", got, want)
+	}
+
+	// 2 left in tier 1, 3 in tier 2: 2*0.25 + 3*0.15 + 5*0.37
+	got = model.Commission("MES", 5, decimal.NewFromInt(5000))
+	want = decimal.RequireFromString("2.80")
+	if !got.Equal(want) {
+		t.Errorf("second Commission = %s, want %s", got, want)
+	}
+
+	if model.Volume() != 13 {
+		t.Errorf("Volume = %d, want 13", model.Volume())
+	}
+
+	model.ResetVolume()
+	got = model.Commission("MES", 1, decimal.NewFromInt(5000))
+	want = decimal.RequireFromString("0.62")
+	if !got.Equal(want) {
+		t.Errorf("Commission after reset = %s, want %s", got, want)
+	}
+}
+
+func TestTieredCommission_InstrumentExchangeFees(t *testing.T) {
+	model := NewTieredCommission([]CommissionTier{
+		{PerContract: decimal.RequireFromString("0.25")},
+	})
+
+	mes := model.Commission("MES", 1, decimal.NewFromInt(5000))
+	mgc := model.Commission("MGC", 1, decimal.NewFromInt(2000))
+
+	if !mes.Equal(decimal.RequireFromString("0.62")) {
+		t.Errorf("MES Commission = %s, want 0.62", mes)
+	}
+	if !mgc.Equal(decimal.RequireFromString("0.87")) {
+		t.Errorf("MGC Commission = %s, want 0.87", mgc)
+	}
+}
+
+func TestSimulatedExecutor_CommissionModel(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{
+		SlippageTicks:     0,
+		CommissionPerSide: decimal.RequireFromString("0.62"),
+		CommissionModel: NewTieredCommission([]CommissionTier{
+			{PerContract: decimal.RequireFromString("0.10")},
+		}),
+	})
+
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(5000)})
+	if _, err := exec.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "open", Symbol: "MES", Side: types.SideLong, Contracts: 2,
+	}); err != nil {
+		t.Fatalf("PlaceOrder open failed: %v", err)
+	}
+
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(5001)})
+	result, err := exec.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "close", Symbol: "MES", Side: types.SideShort, Contracts: 2,
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder close failed: %v", err)
+	}
+
+	// Model overrides the flat rate: 2 * (0.10 + 0.37)
+	wantCommission := decimal.RequireFromString("0.94")
+	if !result.Commission.Equal(wantCommission) {
+		t.Errorf("Commission = %s, want %s", result.Commission, wantCommission)
+	}
+
+	trades := exec.GetTrades()
+	if len(trades) != 1 {
+		t.Fatalf("trades = %d, want 1", len(trades))
+	}
+	// 1 point * $5 * 2 contracts - 0.94
+	wantNet := decimal.RequireFromString("9.06")
+	if !trades[0].NetPL.Equal(wantNet) {
+		t.Errorf("NetPL = %s, want %s", trades[0].NetPL, wantNet)
+	}
+}
+
+func TestSimulatedExecutor_MakerRebate(t *testing.T) {
+	// Takers pay $0.62 per contract per side; the venue pays $0.20 for
+	// resting liquidity
+	cfg := SimulatedConfig{
+		CommissionPerSide: decimal.RequireFromString("0.62"),
+		MakerCommission:   NewFlatCommission(decimal.RequireFromString("-0.20")),
+	}
+	open := func(t *testing.T, exec *SimulatedExecutor) {
+		t.Helper()
+		exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(5000),
+			High: decimal.NewFromInt(5000), Low: decimal.NewFromInt(5000)})
+		result, err := exec.PlaceOrder(context.Background(), types.OrderIntent{
+			ClientOrderID: "open", Symbol: "MES", Side: types.SideLong, Contracts: 2,
+			TakeProfit: decimal.NewFromInt(5010),
+		})
+		if err != nil {
+			t.Fatalf("PlaceOrder open failed: %v", err)
+		}
+		// A market entry takes liquidity
+		if want := decimal.RequireFromString("1.24"); !result.Commission.Equal(want) {
+			t.Errorf("entry Commission = %s, want %s", result.Commission, want)
+		}
+	}
+
+	t.Run("take profit earns the rebate", func(t *testing.T) {
+		exec := NewSimulatedExecutor(cfg)
+		open(t, exec)
+
+		fills := exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(5012),
+			High: decimal.NewFromInt(5012), Low: decimal.NewFromInt(5005)})
+		if len(fills) != 1 {
+			t.Fatalf("fills = %d, want the take profit", len(fills))
+		}
+		if want := decimal.RequireFromString("-0.40"); !fills[0].Commission.Equal(want) {
+			t.Errorf("take profit Commission = %s, want %s", fills[0].Commission, want)
+		}
+
+		trades := exec.GetTrades()
+		if len(trades) != 1 {
+			t.Fatalf("trades = %d, want 1", len(trades))
+		}
+		if !trades[0].NetPL.Equal(trades[0].GrossPL.Sub(trades[0].Commission)) {
+			t.Errorf("NetPL = %s, want GrossPL %s - Commission %s", trades[0].NetPL, trades[0].GrossPL, trades[0].Commission)
+		}
+		if !trades[0].NetPL.GreaterThan(trades[0].GrossPL.Sub(decimal.RequireFromString("1.24"))) {
+			t.Errorf("NetPL = %s, want the rebate to offset the entry commission", trades[0].NetPL)
+		}
+	})
+
+	t.Run("market exit pays the taker rate", func(t *testing.T) {
+		exec := NewSimulatedExecutor(cfg)
+		open(t, exec)
+
+		exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(5001),
+			High: decimal.NewFromInt(5001), Low: decimal.NewFromInt(5001)})
+		result, err := exec.PlaceOrder(context.Background(), types.OrderIntent{
+			ClientOrderID: "close", Symbol: "MES", Side: types.SideShort, Contracts: 2,
+		})
+		if err != nil {
+			t.Fatalf("PlaceOrder close failed: %v", err)
+		}
+		if want := decimal.RequireFromString("1.24"); !result.Commission.Equal(want) {
+			t.Errorf("market exit Commission = %s, want %s with no rebate", result.Commission, want)
+		}
+		for _, trade := range exec.GetTrades() {
+			if trade.Commission.IsNegative() {
+				t.Errorf("trade Commission = %s, want no rebate on market fills", trade.Commission)
+			}
+		}
+	})
+}
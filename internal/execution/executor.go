@@ -4,6 +4,7 @@ package execution
 import (
 	"context"
 
+	"github.com/shopspring/decimal"
 	"github.com/tathienbao/quant-bot/internal/types"
 )
 
@@ -15,6 +16,10 @@ type Executor interface {
 	// CancelOrder cancels a pending order.
 	CancelOrder(ctx context.Context, clientOrderID string) error
 
+	// ModifyOrder changes a pending order's price and quantity in place.
+	// A zero newPrice keeps the current price.
+	ModifyOrder(ctx context.Context, clientOrderID string, newPrice decimal.Decimal, newQty int) error
+
 	// GetPosition returns the current position for a symbol.
 	GetPosition(ctx context.Context, symbol string) (*types.Position, error)
 
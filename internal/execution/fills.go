@@ -0,0 +1,136 @@
+package execution
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// Fill and exit math shared by SimulatedExecutor and the paper broker, so a
+// backtest and a paper replay of the same bars realize the same P&L.
+
+// ApplySlippage moves price against an order: buys fill higher, sells lower.
+func ApplySlippage(side types.Side, price, slippage decimal.Decimal) decimal.Decimal {
+	if side == types.SideLong {
+		return price.Add(slippage)
+	}
+	return price.Sub(slippage)
+}
+
+// GrossPL returns the P&L before commission of closing contracts of a
+// position on side, using the instrument's point value. Unknown symbols
+// have no point value and yield zero.
+func GrossPL(symbol string, side types.Side, entry, exit decimal.Decimal, contracts int) decimal.Decimal {
+	spec, _ := types.GetInstrumentSpec(symbol)
+	pl := exit.Sub(entry).Mul(spec.PointValue).Mul(decimal.NewFromInt(int64(contracts)))
+	if side == types.SideShort {
+		return pl.Neg()
+	}
+	return pl
+}
+
+// Exit reasons reported by ExitRule.Check and recorded on trades.
+const (
+	ExitStopLoss   = "stop_loss"
+	ExitTakeProfit = "take_profit"
+
+	// ExitTimeLimit closes a position held for the maximum number of bars.
+	ExitTimeLimit = "time_exit"
+
+	// ExitBreakevenStop is a stop hit after it was moved to breakeven.
+	ExitBreakevenStop = "breakeven_stop"
+
+	// ExitScaleOut is a partial close at a scale-out tranche.
+	ExitScaleOut = "scale_out"
+
+	// ExitSignal is a close by an opposite order, usually from a strategy signal.
+	ExitSignal = "signal"
+
+	// ExitFlatten is a close by FlattenAll, e.g. the kill switch or shutdown.
+	ExitFlatten = "flatten"
+)
+
+// Exit is a triggered stop or take profit, before slippage.
+type Exit struct {
+	Price  decimal.Decimal
+	Reason string // ExitStopLoss or ExitTakeProfit
+}
+
+// ExitRule decides whether a bar triggers a position's stop or take profit
+// and at what price.
+type ExitRule struct {
+	AmbiguousBarPolicy AmbiguousBarPolicy // Resolves bars that reach both levels
+	GapFillAtOpen      bool               // Fill gapped stops at the bar's open
+}
+
+// Check returns the exit the bar triggers for a position on side with the
+// given stop and target. Zero levels are ignored.
+func (r ExitRule) Check(event types.MarketEvent, side types.Side, stop, target decimal.Decimal) (Exit, bool) {
+	stopHit := r.StopHit(event, side, stop)
+	tpHit := r.TargetHit(event, side, target)
+
+	switch {
+	case stopHit && tpHit && r.TargetFirst(event, stop, target):
+		return Exit{Price: target, Reason: ExitTakeProfit}, true
+	case stopHit:
+		return Exit{Price: r.StopFillPrice(event, side, stop), Reason: ExitStopLoss}, true
+	case tpHit:
+		return Exit{Price: target, Reason: ExitTakeProfit}, true
+	}
+	return Exit{}, false
+}
+
+// StopHit reports whether the bar trades through the stop.
+func (r ExitRule) StopHit(event types.MarketEvent, side types.Side, stop decimal.Decimal) bool {
+	if stop.IsZero() {
+		return false
+	}
+	if side == types.SideLong {
+		return event.Low.LessThanOrEqual(stop)
+	}
+	return event.High.GreaterThanOrEqual(stop)
+}
+
+// TargetHit reports whether the bar reaches the profit target.
+func (r ExitRule) TargetHit(event types.MarketEvent, side types.Side, target decimal.Decimal) bool {
+	if target.IsZero() {
+		return false
+	}
+	if side == types.SideLong {
+		return event.High.GreaterThanOrEqual(target)
+	}
+	return event.Low.LessThanOrEqual(target)
+}
+
+// TargetFirst resolves a bar that reaches both the stop and target using
+// the AmbiguousBarPolicy.
+func (r ExitRule) TargetFirst(event types.MarketEvent, stop, target decimal.Decimal) bool {
+	switch r.AmbiguousBarPolicy {
+	case AmbiguousTPFirst:
+		return true
+	case AmbiguousOpenProximity:
+		if event.Open.IsZero() || stop.IsZero() {
+			return false
+		}
+		toTarget := event.Open.Sub(target).Abs()
+		toStop := event.Open.Sub(stop).Abs()
+		return toTarget.LessThan(toStop)
+	default:
+		return false
+	}
+}
+
+// StopFillPrice returns the price a triggered stop fills at: the stop
+// itself, or the bar's open if GapFillAtOpen is set and the bar opened past
+// the stop.
+func (r ExitRule) StopFillPrice(event types.MarketEvent, side types.Side, stop decimal.Decimal) decimal.Decimal {
+	if !r.GapFillAtOpen || event.Open.IsZero() {
+		return stop
+	}
+	if side == types.SideLong && event.Open.LessThan(stop) {
+		return event.Open
+	}
+	if side == types.SideShort && event.Open.GreaterThan(stop) {
+		return event.Open
+	}
+	return stop
+}
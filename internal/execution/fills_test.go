@@ -0,0 +1,67 @@
+package execution
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func TestGrossPL(t *testing.T) {
+	entry := decimal.NewFromInt(5000)
+	exit := decimal.RequireFromString("5002.25")
+
+	// 2.25 points * $5 * 2 contracts
+	if got := GrossPL("MES", types.SideLong, entry, exit, 2); !got.Equal(decimal.RequireFromString("22.5")) {
+		t.Errorf("long GrossPL = %s, want 22.5", got)
+	}
+	if got := GrossPL("MES", types.SideShort, entry, exit, 2); !got.Equal(decimal.RequireFromString("-22.5")) {
+		t.Errorf("short GrossPL = %s, want -22.5", got)
+	}
+}
+
+func TestExitRule_Check(t *testing.T) {
+	stop := decimal.NewFromInt(4990)
+	target := decimal.NewFromInt(5020)
+	both := types.MarketEvent{
+		Open: decimal.NewFromInt(5015),
+		High: decimal.NewFromInt(5025),
+		Low:  decimal.NewFromInt(4985),
+	}
+
+	tests := []struct {
+		name       string
+		rule       ExitRule
+		event      types.MarketEvent
+		wantReason string
+		wantPrice  decimal.Decimal
+	}{
+		{"stop first", ExitRule{}, both, ExitStopLoss, stop},
+		{"tp first", ExitRule{AmbiguousBarPolicy: AmbiguousTPFirst}, both, ExitTakeProfit, target},
+		{"open proximity", ExitRule{AmbiguousBarPolicy: AmbiguousOpenProximity}, both, ExitTakeProfit, target},
+		{
+			"gap through stop",
+			ExitRule{GapFillAtOpen: true},
+			types.MarketEvent{Open: decimal.NewFromInt(4980), High: decimal.NewFromInt(4982), Low: decimal.NewFromInt(4975)},
+			ExitStopLoss,
+			decimal.NewFromInt(4980),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exit, ok := tt.rule.Check(tt.event, types.SideLong, stop, target)
+			if !ok {
+				t.Fatal("expected exit")
+			}
+			if exit.Reason != tt.wantReason || !exit.Price.Equal(tt.wantPrice) {
+				t.Errorf("exit = %s @ %s, want %s @ %s", exit.Reason, exit.Price, tt.wantReason, tt.wantPrice)
+			}
+		})
+	}
+
+	inside := types.MarketEvent{High: decimal.NewFromInt(5010), Low: decimal.NewFromInt(4995)}
+	if _, ok := (ExitRule{}).Check(inside, types.SideLong, stop, target); ok {
+		t.Error("bar inside the bracket should not exit")
+	}
+}
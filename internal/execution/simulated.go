@@ -3,19 +3,109 @@ package execution
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/broker"
 	"github.com/tathienbao/quant-bot/internal/types"
 )
 
+// AmbiguousBarPolicy decides which exit fills first when a single bar's
+// range contains both the stop and a profit target.
+type AmbiguousBarPolicy int
+
+const (
+	// AmbiguousStopFirst assumes the stop was hit first (pessimistic).
+	AmbiguousStopFirst AmbiguousBarPolicy = iota
+	// AmbiguousTPFirst assumes the target was hit first (optimistic).
+	AmbiguousTPFirst
+	// AmbiguousOpenProximity assumes the level nearer the bar's open was
+	// hit first. Ties and bars without an open fall back to the stop.
+	AmbiguousOpenProximity
+)
+
+// String returns the policy name.
+func (p AmbiguousBarPolicy) String() string {
+	switch p {
+	case AmbiguousStopFirst:
+		return "stop_first"
+	case AmbiguousTPFirst:
+		return "tp_first"
+	case AmbiguousOpenProximity:
+		return "open_proximity"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseAmbiguousBarPolicy parses a policy name; empty means stop_first.
+func ParseAmbiguousBarPolicy(name string) (AmbiguousBarPolicy, error) {
+	switch name {
+	case "", "stop_first":
+		return AmbiguousStopFirst, nil
+	case "tp_first":
+		return AmbiguousTPFirst, nil
+	case "open_proximity":
+		return AmbiguousOpenProximity, nil
+	default:
+		return AmbiguousStopFirst, fmt.Errorf("unknown ambiguous bar policy: %q", name)
+	}
+}
+
 // SimulatedConfig holds configuration for the simulated executor.
 type SimulatedConfig struct {
 	SlippageTicks    int             // Fixed slippage in ticks
 	CommissionPerSide decimal.Decimal // Commission per contract per side
 	FillDelayMs      int             // Simulated fill delay
+
+	// CommissionModel overrides CommissionPerSide when set
+	CommissionModel CommissionModel
+
+	// MakerCommission charges resting limit fills (take profits and
+	// scale-out targets), e.g. a negative rate for a maker rebate. Nil
+	// charges them like every other fill.
+	MakerCommission CommissionModel
+
+	// SlippageModel overrides SlippageTicks when set
+	SlippageModel SlippageModel
+
+	// EntrySlippageTicks and ExitSlippageTicks replace SlippageTicks (or the
+	// SlippageModel) for opening and closing fills when positive, so exits
+	// can be modeled worse than entries
+	EntrySlippageTicks int
+	ExitSlippageTicks  int
+
+	// StopSlippageTicks is added on top of exit slippage for stop and
+	// breakeven stop exits, which fill into adverse momentum
+	StopSlippageTicks int
+
+	// Breakeven stop: once price moves BreakevenTriggerTicks in favor, the
+	// stop moves to entry +/- BreakevenOffsetTicks (0 trigger = disabled)
+	BreakevenTriggerTicks int
+	BreakevenOffsetTicks  int
+
+	// AmbiguousBarPolicy resolves bars that reach both stop and target
+	AmbiguousBarPolicy AmbiguousBarPolicy
+
+	// GapFillAtOpen fills a stop at the bar's open when the bar opens beyond
+	// it. Off by default, stops fill at the stop price even through a gap,
+	// which overstates results on gappy data.
+	GapFillAtOpen bool
+
+	// FillNextBarOpen queues orders and fills them at the next bar's open
+	// instead of the current close, removing same-bar look-ahead
+	FillNextBarOpen bool
+
+	// MaxHoldBars closes a position at the bar's close once it has been
+	// open this many bars without reaching its stop or target (0 = off)
+	MaxHoldBars int
+
+	// MaxVolumeFraction caps an order at floor(bar volume * fraction)
+	// contracts; the rest of the order is rejected, not carried to the next
+	// bar. Zero disables the cap, as do bars without volume.
+	MaxVolumeFraction decimal.Decimal
 }
 
 // DefaultSimulatedConfig returns sensible defaults.
@@ -34,13 +124,17 @@ type SimulatedExecutor struct {
 	mu           sync.RWMutex
 	positions    map[string]*types.Position // symbol -> position
 	openOrders   map[string]*types.OrderIntent // clientOrderID -> order
+	pendingQueue []string // clientOrderIDs awaiting the next bar, in submission order
 	usedOrderIDs map[string]bool // Track all used client order IDs for idempotency
 	orderHistory []types.OrderResult
 	trades       []types.Trade
 
 	fillHandler FillHandler
+	notify      []types.OrderResult // Fills awaiting the fill handler, in fill order
+	idSeq       int64               // Sequence for order, position and trade IDs
 	currentTime time.Time
 	currentPrice map[string]decimal.Decimal // symbol -> current price
+	currentBar   map[string]types.MarketEvent // symbol -> latest bar (for slippage)
 }
 
 // NewSimulatedExecutor creates a new simulated executor.
@@ -53,91 +147,198 @@ func NewSimulatedExecutor(cfg SimulatedConfig) *SimulatedExecutor {
 		orderHistory: make([]types.OrderResult, 0),
 		trades:       make([]types.Trade, 0),
 		currentPrice: make(map[string]decimal.Decimal),
+		currentBar:   make(map[string]types.MarketEvent),
 	}
 }
 
 // SetFillHandler sets the callback for fill events.
+// The handler runs synchronously, in fill order, once the call that produced
+// the fills has released the executor's lock, so it may call back into the
+// executor and backtests stay reproducible.
 func (s *SimulatedExecutor) SetFillHandler(handler FillHandler) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.fillHandler = handler
 }
 
+// notifyFills delivers queued fills to the fill handler. Call it after s.mu
+// has been released.
+func (s *SimulatedExecutor) notifyFills() {
+	s.mu.Lock()
+	fills, handler := s.notify, s.fillHandler
+	s.notify = nil
+	s.mu.Unlock()
+
+	for _, fill := range fills {
+		handler(context.Background(), fill)
+	}
+}
+
+// nextID returns a sequential ID so identical runs produce identical records.
+func (s *SimulatedExecutor) nextID(prefix string) string {
+	s.idSeq++
+	return fmt.Sprintf("%s-%d", prefix, s.idSeq)
+}
+
 // UpdateMarket updates the current market price and time.
 // This is called by the backtest runner for each market event.
 func (s *SimulatedExecutor) UpdateMarket(event types.MarketEvent) []types.OrderResult {
+	defer s.notifyFills()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	s.currentTime = event.Timestamp
 	s.currentPrice[event.Symbol] = event.Close
+	s.currentBar[event.Symbol] = event
+
+	// Queued orders fill at this bar's open, before its range is checked
+	fills := s.fillPending(event)
 
 	// Check for stop loss / take profit fills
-	var fills []types.OrderResult
 	if pos, ok := s.positions[event.Symbol]; ok && pos.Contracts > 0 {
+		pos.BarsHeld++
 		fills = append(fills, s.checkExits(event, pos)...)
 	}
 
+	// Positions still open at the time limit close at this bar's close
+	if pos, ok := s.positions[event.Symbol]; ok && pos.Contracts > 0 && s.timeExpired(pos) {
+		fills = append(fills, s.closePosition(pos, event.Close, ExitTimeLimit))
+	}
+
+	// Stops move after exits are checked, so the new stop applies from the next bar
+	if pos, ok := s.positions[event.Symbol]; ok && pos.Contracts > 0 {
+		s.applyBreakeven(event, pos)
+	}
+
 	return fills
 }
 
-// checkExits checks if stop loss or take profit is hit.
-func (s *SimulatedExecutor) checkExits(event types.MarketEvent, pos *types.Position) []types.OrderResult {
-	var fills []types.OrderResult
+// applyBreakeven moves the stop to entry +/- offset once the bar reaches the
+// trigger. The stop is only ever tightened, never moved in the adverse direction.
+func (s *SimulatedExecutor) applyBreakeven(event types.MarketEvent, pos *types.Position) {
+	if s.cfg.BreakevenTriggerTicks <= 0 {
+		return
+	}
+
+	spec, ok := types.GetInstrumentSpec(pos.Symbol)
+	if !ok {
+		return
+	}
+
+	trigger := spec.TickSize.Mul(decimal.NewFromInt(int64(s.cfg.BreakevenTriggerTicks)))
+	offset := spec.TickSize.Mul(decimal.NewFromInt(int64(s.cfg.BreakevenOffsetTicks)))
 
 	switch pos.Side {
 	case types.SideLong:
-		// Long position: stop below entry, TP above
-		if !pos.StopLoss.IsZero() && event.Low.LessThanOrEqual(pos.StopLoss) {
-			fill := s.closePosition(pos, pos.StopLoss, "stop_loss")
-			fills = append(fills, fill)
-		} else if !pos.TakeProfit.IsZero() && event.High.GreaterThanOrEqual(pos.TakeProfit) {
-			fill := s.closePosition(pos, pos.TakeProfit, "take_profit")
-			fills = append(fills, fill)
+		if event.High.LessThan(pos.EntryPrice.Add(trigger)) {
+			return
+		}
+		newStop := pos.EntryPrice.Add(offset)
+		if pos.StopLoss.IsZero() || newStop.GreaterThan(pos.StopLoss) {
+			pos.StopLoss = newStop
 		}
 	case types.SideShort:
-		// Short position: stop above entry, TP below
-		if !pos.StopLoss.IsZero() && event.High.GreaterThanOrEqual(pos.StopLoss) {
-			fill := s.closePosition(pos, pos.StopLoss, "stop_loss")
-			fills = append(fills, fill)
-		} else if !pos.TakeProfit.IsZero() && event.Low.LessThanOrEqual(pos.TakeProfit) {
-			fill := s.closePosition(pos, pos.TakeProfit, "take_profit")
-			fills = append(fills, fill)
+		if event.Low.GreaterThan(pos.EntryPrice.Sub(trigger)) {
+			return
+		}
+		newStop := pos.EntryPrice.Sub(offset)
+		if pos.StopLoss.IsZero() || newStop.LessThan(pos.StopLoss) {
+			pos.StopLoss = newStop
 		}
 	}
+}
+
+// checkExits checks if stop loss or take profit is hit.
+func (s *SimulatedExecutor) checkExits(event types.MarketEvent, pos *types.Position) []types.OrderResult {
+	var fills []types.OrderResult
+
+	rule := s.exitRule()
+	stopHit := rule.StopHit(event, pos.Side, pos.StopLoss)
+
+	// Scale-out tranches fill before the stop unless the policy says otherwise
+	if len(pos.ScaleOuts) > 0 && (!stopHit || rule.TargetFirst(event, pos.StopLoss, pos.ScaleOuts[0].Price)) {
+		fills = append(fills, s.checkScaleOuts(event, pos)...)
+		if pos.Contracts == 0 {
+			return fills
+		}
+	}
+
+	// Long: stop below entry, TP above. Short: stop above entry, TP below.
+	if exit, ok := rule.Check(event, pos.Side, pos.StopLoss, pos.TakeProfit); ok {
+		// Only the breakeven rule moves a stop off its initial level
+		if exit.Reason == ExitStopLoss && !pos.StopLoss.Equal(pos.InitialStop) {
+			exit.Reason = ExitBreakevenStop
+		}
+		fills = append(fills, s.closePosition(pos, exit.Price, exit.Reason))
+	}
+
+	return fills
+}
+
+// timeExpired reports whether pos has been held for MaxHoldBars.
+func (s *SimulatedExecutor) timeExpired(pos *types.Position) bool {
+	return s.cfg.MaxHoldBars > 0 && pos.BarsHeld >= s.cfg.MaxHoldBars
+}
+
+// exitRule returns the stop/target rule for the configured policies.
+func (s *SimulatedExecutor) exitRule() ExitRule {
+	return ExitRule{
+		AmbiguousBarPolicy: s.cfg.AmbiguousBarPolicy,
+		GapFillAtOpen:      s.cfg.GapFillAtOpen,
+	}
+}
+
+// checkScaleOuts partially closes the position at each tranche the bar reaches.
+func (s *SimulatedExecutor) checkScaleOuts(event types.MarketEvent, pos *types.Position) []types.OrderResult {
+	var fills []types.OrderResult
+
+	for len(pos.ScaleOuts) > 0 && pos.Contracts > 0 {
+		target := pos.ScaleOuts[0]
+		hit := event.High.GreaterThanOrEqual(target.Price)
+		if pos.Side == types.SideShort {
+			hit = event.Low.LessThanOrEqual(target.Price)
+		}
+		if !hit {
+			break
+		}
+
+		pos.ScaleOuts = pos.ScaleOuts[1:]
+		contracts := min(target.Contracts, pos.Contracts)
+		fills = append(fills, s.closeContracts(pos, target.Price, contracts, ExitScaleOut))
+	}
 
 	return fills
 }
 
 // closePosition closes a position at the given price.
 func (s *SimulatedExecutor) closePosition(pos *types.Position, exitPrice decimal.Decimal, reason string) types.OrderResult {
+	return s.closeContracts(pos, exitPrice, pos.Contracts, reason)
+}
+
+// closeContracts closes some or all of a position at the given price and
+// records a trade for the closed contracts.
+func (s *SimulatedExecutor) closeContracts(pos *types.Position, exitPrice decimal.Decimal, contracts int, reason string) types.OrderResult {
 	spec, _ := types.GetInstrumentSpec(pos.Symbol)
 
+	// Trade is measured on the closed portion only
+	closed := *pos
+	closed.Contracts = contracts
+
 	// Apply slippage (against us)
-	slippageAmount := spec.TickSize.Mul(decimal.NewFromInt(int64(s.cfg.SlippageTicks)))
-	if pos.Side == types.SideLong {
-		exitPrice = exitPrice.Sub(slippageAmount) // Sell lower
-	} else {
-		exitPrice = exitPrice.Add(slippageAmount) // Buy higher
-	}
+	slippageAmount := s.slippage(pos.Symbol, contracts, reason)
+	exitPrice = ApplySlippage(pos.Side.Opposite(), exitPrice, slippageAmount)
 
-	// Calculate PnL
-	var grossPL decimal.Decimal
-	if pos.Side == types.SideLong {
-		grossPL = exitPrice.Sub(pos.EntryPrice).Mul(spec.PointValue).Mul(decimal.NewFromInt(int64(pos.Contracts)))
-	} else {
-		grossPL = pos.EntryPrice.Sub(exitPrice).Mul(spec.PointValue).Mul(decimal.NewFromInt(int64(pos.Contracts)))
-	}
+	grossPL := GrossPL(pos.Symbol, pos.Side, pos.EntryPrice, exitPrice, contracts)
 
-	commission := s.cfg.CommissionPerSide.Mul(decimal.NewFromInt(int64(pos.Contracts)))
+	commission := s.commission(pos.Symbol, contracts, exitPrice, reason == ExitTakeProfit || reason == ExitScaleOut)
 	netPL := grossPL.Sub(commission)
 
 	// Create trade record
 	trade := types.Trade{
-		ID:           uuid.New().String(),
+		ID:           s.nextID("SIM-TRD"),
 		Symbol:       pos.Symbol,
 		Side:         pos.Side,
-		Contracts:    pos.Contracts,
+		Contracts:    contracts,
 		EntryPrice:   pos.EntryPrice,
 		ExitPrice:    exitPrice,
 		EntryTime:    pos.EntryTime,
@@ -145,17 +346,27 @@ func (s *SimulatedExecutor) closePosition(pos *types.Position, exitPrice decimal
 		GrossPL:      grossPL,
 		Commission:   commission,
 		NetPL:        netPL,
+		RMultiple:    closed.RMultiple(netPL, spec.PointValue),
+		ExitReason:   reason,
 	}
 	s.trades = append(s.trades, trade)
 
-	// Clear position
-	delete(s.positions, pos.Symbol)
+	// Clear position once fully closed
+	pos.Contracts -= contracts
+	if pos.Contracts <= 0 {
+		delete(s.positions, pos.Symbol)
+	}
+
+	clientOrderID := reason + "-" + pos.ID
+	if reason == ExitScaleOut {
+		clientOrderID = fmt.Sprintf("%s_%d-%s", reason, len(s.trades), pos.ID) // Unique per fill
+	}
 
 	result := types.OrderResult{
-		OrderID:       uuid.New().String(),
-		ClientOrderID: reason + "-" + pos.ID,
+		OrderID:       s.nextID("SIM-ORD"),
+		ClientOrderID: clientOrderID,
 		Status:        types.OrderStatusFilled,
-		FilledQty:     pos.Contracts,
+		FilledQty:     contracts,
 		AvgFillPrice:  exitPrice,
 		Commission:    commission,
 		Slippage:      slippageAmount,
@@ -166,14 +377,53 @@ func (s *SimulatedExecutor) closePosition(pos *types.Position, exitPrice decimal
 
 	// Notify fill handler
 	if s.fillHandler != nil {
-		go s.fillHandler(context.Background(), result)
+		s.notify = append(s.notify, result)
 	}
 
 	return result
 }
 
+// slippage returns the adverse price amount for one side of a fill. An
+// empty reason marks an entry; otherwise it is the exit reason.
+func (s *SimulatedExecutor) slippage(symbol string, contracts int, reason string) decimal.Decimal {
+	event := s.currentBar[symbol]
+
+	ticks := s.cfg.ExitSlippageTicks
+	if reason == "" {
+		ticks = s.cfg.EntrySlippageTicks
+	}
+
+	var amount decimal.Decimal
+	switch {
+	case ticks > 0:
+		amount = NewFixedSlippage(ticks).Slippage(symbol, contracts, event)
+	case s.cfg.SlippageModel != nil:
+		amount = s.cfg.SlippageModel.Slippage(symbol, contracts, event)
+	default:
+		amount = NewFixedSlippage(s.cfg.SlippageTicks).Slippage(symbol, contracts, event)
+	}
+
+	if (reason == ExitStopLoss || reason == ExitBreakevenStop) && s.cfg.StopSlippageTicks > 0 {
+		amount = amount.Add(NewFixedSlippage(s.cfg.StopSlippageTicks).Slippage(symbol, contracts, event))
+	}
+	return amount
+}
+
+// commission returns the commission for one side of a fill. Resting limit
+// fills pay the maker rate when one is set; every other fill takes liquidity.
+func (s *SimulatedExecutor) commission(symbol string, contracts int, price decimal.Decimal, resting bool) decimal.Decimal {
+	if resting && s.cfg.MakerCommission != nil {
+		return s.cfg.MakerCommission.Commission(symbol, contracts, price)
+	}
+	if s.cfg.CommissionModel != nil {
+		return s.cfg.CommissionModel.Commission(symbol, contracts, price)
+	}
+	return s.cfg.CommissionPerSide.Mul(decimal.NewFromInt(int64(contracts)))
+}
+
 // PlaceOrder submits an order for execution.
 func (s *SimulatedExecutor) PlaceOrder(ctx context.Context, order types.OrderIntent) (*types.OrderResult, error) {
+	defer s.notifyFills()
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -183,57 +433,208 @@ func (s *SimulatedExecutor) PlaceOrder(ctx context.Context, order types.OrderInt
 	}
 	s.usedOrderIDs[order.ClientOrderID] = true
 
-	spec, ok := types.GetInstrumentSpec(order.Symbol)
-	if !ok {
+	if _, ok := types.GetInstrumentSpec(order.Symbol); !ok {
 		return nil, fmt.Errorf("unknown symbol: %s", order.Symbol)
 	}
 
+	if err := validateTranches(order.Tranches); err != nil {
+		return nil, err
+	}
+
 	// Get current price
 	currentPrice, ok := s.currentPrice[order.Symbol]
 	if !ok {
 		return nil, fmt.Errorf("no market data for symbol: %s", order.Symbol)
 	}
 
+	if s.cfg.FillNextBarOpen {
+		s.openOrders[order.ClientOrderID] = &order
+		s.pendingQueue = append(s.pendingQueue, order.ClientOrderID)
+		return &types.OrderResult{
+			ClientOrderID: order.ClientOrderID,
+			Status:        types.OrderStatusPending,
+		}, nil
+	}
+
+	return s.fillOrder(order, currentPrice)
+}
+
+// fillPending fills queued orders for the event's symbol at the bar's open
+// (or close when the bar has no open). Cancelled orders are dropped.
+func (s *SimulatedExecutor) fillPending(event types.MarketEvent) []types.OrderResult {
+	if len(s.pendingQueue) == 0 {
+		return nil
+	}
+
+	price := event.Open
+	if price.IsZero() {
+		price = event.Close
+	}
+
+	var fills []types.OrderResult
+	remaining := s.pendingQueue[:0]
+	for _, id := range s.pendingQueue {
+		order, ok := s.openOrders[id]
+		if !ok {
+			continue // Cancelled
+		}
+		if order.Symbol != event.Symbol {
+			remaining = append(remaining, id)
+			continue
+		}
+
+		delete(s.openOrders, id)
+		result, err := s.fillOrder(*order, price)
+		if err != nil {
+			continue
+		}
+		fills = append(fills, *result)
+	}
+	s.pendingQueue = remaining
+
+	return fills
+}
+
+// fillOrder fills an order at basePrice plus slippage, opening or closing
+// a position.
+func (s *SimulatedExecutor) fillOrder(order types.OrderIntent, basePrice decimal.Decimal) (*types.OrderResult, error) {
+	// A reduce-only order needs a position to close and closes no more than
+	// it holds; any other opposite order closes the whole position. Closes
+	// never flip here.
+	existingPos, hasPosition := s.positions[order.Symbol]
+	closing := hasPosition && existingPos.Side == order.Side.Opposite()
+	if order.ReduceOnly {
+		var held int
+		var heldSide types.Side
+		if hasPosition {
+			held, heldSide = existingPos.Contracts, existingPos.Side
+		}
+		contracts, err := order.ReduceOnlyContracts(heldSide, held)
+		if err != nil {
+			return nil, err
+		}
+		order.Contracts = contracts
+	} else if closing {
+		order.Contracts = existingPos.Contracts
+	}
+
+	// Fill no more than the bar's volume allows
+	limit, capped := s.volumeLimit(order.Symbol)
+	capped = capped && order.Contracts > limit
+	if capped {
+		if limit == 0 {
+			return nil, fmt.Errorf("%w: bar volume allows no contracts", types.ErrInvalidOrderSize)
+		}
+		order.Contracts = limit
+	}
+
 	// Calculate fill price with slippage
-	slippageAmount := spec.TickSize.Mul(decimal.NewFromInt(int64(s.cfg.SlippageTicks)))
-	var fillPrice decimal.Decimal
-	if order.Side == types.SideLong {
-		fillPrice = currentPrice.Add(slippageAmount) // Buy higher
-	} else {
-		fillPrice = currentPrice.Sub(slippageAmount) // Sell lower
+	reason := ""
+	if closing {
+		reason = ExitSignal
 	}
+	slippageAmount := s.slippage(order.Symbol, order.Contracts, reason)
+	fillPrice := ApplySlippage(order.Side, basePrice, slippageAmount)
 
 	// Calculate commission
-	commission := s.cfg.CommissionPerSide.Mul(decimal.NewFromInt(int64(order.Contracts)))
+	commission := s.commission(order.Symbol, order.Contracts, fillPrice, false)
 
-	// Check if we're closing an existing position
-	existingPos, hasPosition := s.positions[order.Symbol]
-	if hasPosition && existingPos.Side == order.Side.Opposite() {
-		// Closing position
-		return s.handleCloseOrder(order, existingPos, fillPrice, commission, slippageAmount)
+	var result *types.OrderResult
+	if closing {
+		// Closing contracts; a partial or capped close leaves the rest open
+		result = s.handleCloseOrder(order, existingPos, fillPrice, commission, slippageAmount)
+	} else {
+		result = s.handleOpenOrder(order, fillPrice, commission, slippageAmount)
+	}
+	if capped {
+		result.Status = types.OrderStatusPartialFill
 	}
 
-	// Opening new position
-	return s.handleOpenOrder(order, fillPrice, commission, slippageAmount)
+	s.orderHistory = append(s.orderHistory, *result)
+
+	// Notify fill handler
+	if s.fillHandler != nil {
+		s.notify = append(s.notify, *result)
+	}
+
+	return result, nil
+}
+
+// volumeLimit returns the most contracts the current bar's volume allows
+// under MaxVolumeFraction, and false when there is no limit.
+func (s *SimulatedExecutor) volumeLimit(symbol string) (int, bool) {
+	volume := s.currentBar[symbol].Volume
+	if !s.cfg.MaxVolumeFraction.IsPositive() || volume <= 0 {
+		return 0, false
+	}
+	return int(s.cfg.MaxVolumeFraction.Mul(decimal.NewFromInt(volume)).IntPart()), true
+}
+
+// validateTranches checks that tranche offsets are positive and fractions
+// sum to at most 1.
+func validateTranches(tranches []types.TakeProfitTranche) error {
+	total := decimal.Zero
+	for i, t := range tranches {
+		if !t.Offset.IsPositive() {
+			return fmt.Errorf("%w: tranche %d offset must be positive", types.ErrInvalidPrice, i)
+		}
+		if !t.Fraction.IsPositive() || t.Fraction.GreaterThan(decimal.NewFromInt(1)) {
+			return fmt.Errorf("%w: tranche %d fraction must be in (0, 1]", types.ErrInvalidOrderSize, i)
+		}
+		total = total.Add(t.Fraction)
+	}
+	if total.GreaterThan(decimal.NewFromInt(1)) {
+		return fmt.Errorf("%w: tranche fractions sum to %s", types.ErrInvalidOrderSize, total)
+	}
+	return nil
+}
+
+// resolveScaleOuts converts tranches into target prices and contract counts,
+// nearest target first. Contracts round down; tranches that round to zero
+// contracts are dropped and their share stays with the runner.
+func resolveScaleOuts(side types.Side, entry decimal.Decimal, contracts int, tranches []types.TakeProfitTranche) []types.ScaleOutTarget {
+	if len(tranches) == 0 {
+		return nil
+	}
+
+	sorted := make([]types.TakeProfitTranche, len(tranches))
+	copy(sorted, tranches)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Offset.LessThan(sorted[j].Offset) })
+
+	targets := make([]types.ScaleOutTarget, 0, len(sorted))
+	for _, t := range sorted {
+		n := int(t.Fraction.Mul(decimal.NewFromInt(int64(contracts))).IntPart())
+		if n <= 0 {
+			continue
+		}
+		price := entry.Add(t.Offset)
+		if side == types.SideShort {
+			price = entry.Sub(t.Offset)
+		}
+		targets = append(targets, types.ScaleOutTarget{Price: price, Contracts: n})
+	}
+	return targets
 }
 
 // handleOpenOrder handles opening a new position.
-func (s *SimulatedExecutor) handleOpenOrder(order types.OrderIntent, fillPrice, commission, slippage decimal.Decimal) (*types.OrderResult, error) {
+func (s *SimulatedExecutor) handleOpenOrder(order types.OrderIntent, fillPrice, commission, slippage decimal.Decimal) *types.OrderResult {
 	// Create position
 	pos := &types.Position{
-		ID:         uuid.New().String(),
-		Symbol:     order.Symbol,
-		Side:       order.Side,
-		Contracts:  order.Contracts,
-		EntryPrice: fillPrice,
-		EntryTime:  s.currentTime,
-		StopLoss:   order.StopLoss,
-		TakeProfit: order.TakeProfit,
+		ID:          s.nextID("SIM-POS"),
+		Symbol:      order.Symbol,
+		Side:        order.Side,
+		Contracts:   order.Contracts,
+		EntryPrice:  fillPrice,
+		EntryTime:   s.currentTime,
+		StopLoss:    order.StopLoss,
+		TakeProfit:  order.TakeProfit,
+		InitialStop: order.StopLoss,
+		ScaleOuts:   resolveScaleOuts(order.Side, fillPrice, order.Contracts, order.Tranches),
 	}
 	s.positions[order.Symbol] = pos
 
-	result := &types.OrderResult{
-		OrderID:       uuid.New().String(),
+	return &types.OrderResult{
+		OrderID:       s.nextID("SIM-ORD"),
 		ClientOrderID: order.ClientOrderID,
 		Status:        types.OrderStatusFilled,
 		FilledQty:     order.Contracts,
@@ -242,37 +643,26 @@ func (s *SimulatedExecutor) handleOpenOrder(order types.OrderIntent, fillPrice,
 		Slippage:      slippage,
 		FilledAt:      s.currentTime,
 	}
-
-	s.orderHistory = append(s.orderHistory, *result)
-
-	// Notify fill handler
-	if s.fillHandler != nil {
-		go s.fillHandler(context.Background(), *result)
-	}
-
-	return result, nil
 }
 
-// handleCloseOrder handles closing an existing position.
-func (s *SimulatedExecutor) handleCloseOrder(order types.OrderIntent, pos *types.Position, fillPrice, commission, slippage decimal.Decimal) (*types.OrderResult, error) {
+// handleCloseOrder closes the order's contracts of an existing position.
+func (s *SimulatedExecutor) handleCloseOrder(order types.OrderIntent, pos *types.Position, fillPrice, commission, slippage decimal.Decimal) *types.OrderResult {
 	spec, _ := types.GetInstrumentSpec(order.Symbol)
+	contracts := order.Contracts
 
-	// Calculate PnL
-	var grossPL decimal.Decimal
-	if pos.Side == types.SideLong {
-		grossPL = fillPrice.Sub(pos.EntryPrice).Mul(spec.PointValue).Mul(decimal.NewFromInt(int64(pos.Contracts)))
-	} else {
-		grossPL = pos.EntryPrice.Sub(fillPrice).Mul(spec.PointValue).Mul(decimal.NewFromInt(int64(pos.Contracts)))
-	}
+	// Trade is measured on the closed portion only
+	closed := *pos
+	closed.Contracts = contracts
 
+	grossPL := GrossPL(pos.Symbol, pos.Side, pos.EntryPrice, fillPrice, contracts)
 	netPL := grossPL.Sub(commission)
 
 	// Create trade record
 	trade := types.Trade{
-		ID:         uuid.New().String(),
+		ID:         s.nextID("SIM-TRD"),
 		Symbol:     pos.Symbol,
 		Side:       pos.Side,
-		Contracts:  pos.Contracts,
+		Contracts:  contracts,
 		EntryPrice: pos.EntryPrice,
 		ExitPrice:  fillPrice,
 		EntryTime:  pos.EntryTime,
@@ -280,15 +670,20 @@ func (s *SimulatedExecutor) handleCloseOrder(order types.OrderIntent, pos *types
 		GrossPL:    grossPL,
 		Commission: commission,
 		NetPL:      netPL,
+		RMultiple:  closed.RMultiple(netPL, spec.PointValue),
 		SignalID:   order.SignalID,
+		ExitReason: ExitSignal,
 	}
 	s.trades = append(s.trades, trade)
 
-	// Clear position
-	delete(s.positions, order.Symbol)
+	// Clear position once fully closed
+	pos.Contracts -= contracts
+	if pos.Contracts <= 0 {
+		delete(s.positions, order.Symbol)
+	}
 
-	result := &types.OrderResult{
-		OrderID:       uuid.New().String(),
+	return &types.OrderResult{
+		OrderID:       s.nextID("SIM-ORD"),
 		ClientOrderID: order.ClientOrderID,
 		Status:        types.OrderStatusFilled,
 		FilledQty:     order.Contracts,
@@ -297,15 +692,32 @@ func (s *SimulatedExecutor) handleCloseOrder(order types.OrderIntent, pos *types
 		Slippage:      slippage,
 		FilledAt:      s.currentTime,
 	}
+}
 
-	s.orderHistory = append(s.orderHistory, *result)
+// FlattenAll closes every open position at the current price.
+// Returns the resulting fills.
+func (s *SimulatedExecutor) FlattenAll(ctx context.Context) []types.OrderResult {
+	defer s.notifyFills()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// Notify fill handler
-	if s.fillHandler != nil {
-		go s.fillHandler(context.Background(), *result)
+	// Close in symbol order so fills and trades are reproducible
+	symbols := make([]string, 0, len(s.positions))
+	for symbol := range s.positions {
+		symbols = append(symbols, symbol)
 	}
+	sort.Strings(symbols)
 
-	return result, nil
+	var fills []types.OrderResult
+	for _, symbol := range symbols {
+		pos := s.positions[symbol]
+		price, ok := s.currentPrice[symbol]
+		if !ok {
+			price = pos.EntryPrice
+		}
+		fills = append(fills, s.closePosition(pos, price, ExitFlatten))
+	}
+	return fills
 }
 
 // CancelOrder cancels a pending order.
@@ -314,13 +726,35 @@ func (s *SimulatedExecutor) CancelOrder(ctx context.Context, clientOrderID strin
 	defer s.mu.Unlock()
 
 	if _, exists := s.openOrders[clientOrderID]; !exists {
-		return fmt.Errorf("order not found: %s", clientOrderID)
+		return fmt.Errorf("%w: %s", broker.ErrOrderNotFound, clientOrderID)
 	}
 
 	delete(s.openOrders, clientOrderID)
 	return nil
 }
 
+// ModifyOrder changes a pending order's quantity and price. The order keeps
+// its place in the queue and fills with the new quantity on the next bar.
+func (s *SimulatedExecutor) ModifyOrder(ctx context.Context, clientOrderID string, newPrice decimal.Decimal, newQty int) error {
+	if newQty <= 0 {
+		return fmt.Errorf("modify order %s: quantity must be positive, got %d", clientOrderID, newQty)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, exists := s.openOrders[clientOrderID]
+	if !exists {
+		return fmt.Errorf("%w: %s", broker.ErrOrderNotFound, clientOrderID)
+	}
+
+	order.Contracts = newQty
+	if newPrice.IsPositive() {
+		order.EntryPrice = newPrice
+	}
+	return nil
+}
+
 // GetPosition returns the current position for a symbol.
 func (s *SimulatedExecutor) GetPosition(ctx context.Context, symbol string) (*types.Position, error) {
 	s.mu.RLock()
@@ -333,13 +767,8 @@ func (s *SimulatedExecutor) GetPosition(ctx context.Context, symbol string) (*ty
 
 	// Update unrealized PL
 	if currentPrice, ok := s.currentPrice[symbol]; ok {
-		spec, _ := types.GetInstrumentSpec(symbol)
 		posCopy := *pos
-		if pos.Side == types.SideLong {
-			posCopy.UnrealizedPL = currentPrice.Sub(pos.EntryPrice).Mul(spec.PointValue).Mul(decimal.NewFromInt(int64(pos.Contracts)))
-		} else {
-			posCopy.UnrealizedPL = pos.EntryPrice.Sub(currentPrice).Mul(spec.PointValue).Mul(decimal.NewFromInt(int64(pos.Contracts)))
-		}
+		posCopy.UnrealizedPL = GrossPL(symbol, pos.Side, pos.EntryPrice, currentPrice, pos.Contracts)
 		return &posCopy, nil
 	}
 
@@ -393,8 +822,17 @@ func (s *SimulatedExecutor) Reset() {
 
 	s.positions = make(map[string]*types.Position)
 	s.openOrders = make(map[string]*types.OrderIntent)
+	s.pendingQueue = nil
 	s.usedOrderIDs = make(map[string]bool)
 	s.orderHistory = make([]types.OrderResult, 0)
 	s.trades = make([]types.Trade, 0)
 	s.currentPrice = make(map[string]decimal.Decimal)
+	s.currentBar = make(map[string]types.MarketEvent)
+	s.notify = nil
+	s.idSeq = 0
+
+	// Start tiered commission volume over for the new run
+	if tiered, ok := s.cfg.CommissionModel.(*TieredCommission); ok {
+		tiered.ResetVolume()
+	}
 }
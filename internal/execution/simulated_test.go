@@ -2,10 +2,13 @@ package execution
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/broker"
 	"github.com/tathienbao/quant-bot/internal/types"
 )
 
@@ -494,6 +497,69 @@ func TestSimulatedExecutor_GapThroughStop(t *testing.T) {
 	}
 }
 
+// TestSimulatedExecutor_GapFillAtOpen tests filling gapped stops at the open.
+func TestSimulatedExecutor_GapFillAtOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		side     types.Side
+		stop     decimal.Decimal
+		bar      types.MarketEvent
+		wantExit decimal.Decimal
+	}{
+		{
+			name:     "long gaps below stop",
+			side:     types.SideLong,
+			stop:     decimal.NewFromInt(4990),
+			bar:      types.MarketEvent{Open: decimal.NewFromInt(4980), High: decimal.NewFromInt(4985), Low: decimal.NewFromInt(4975), Close: decimal.NewFromInt(4978)},
+			wantExit: decimal.NewFromInt(4980),
+		},
+		{
+			name:     "long trades through stop without gap",
+			side:     types.SideLong,
+			stop:     decimal.NewFromInt(4990),
+			bar:      types.MarketEvent{Open: decimal.NewFromInt(4998), High: decimal.NewFromInt(4999), Low: decimal.NewFromInt(4985), Close: decimal.NewFromInt(4986)},
+			wantExit: decimal.NewFromInt(4990),
+		},
+		{
+			name:     "short gaps above stop",
+			side:     types.SideShort,
+			stop:     decimal.NewFromInt(5010),
+			bar:      types.MarketEvent{Open: decimal.NewFromInt(5020), High: decimal.NewFromInt(5025), Low: decimal.NewFromInt(5015), Close: decimal.NewFromInt(5022)},
+			wantExit: decimal.NewFromInt(5020),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := NewSimulatedExecutor(SimulatedConfig{
+				CommissionPerSide: decimal.Zero,
+				GapFillAtOpen:     true,
+			})
+
+			exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+			_, _ = exec.PlaceOrder(context.Background(), types.OrderIntent{
+				ClientOrderID: "gap-open",
+				Symbol:        "MES",
+				Side:          tt.side,
+				Contracts:     1,
+				StopLoss:      tt.stop,
+			})
+
+			bar := tt.bar
+			bar.Symbol = "MES"
+			exec.UpdateMarket(bar)
+
+			trades := exec.GetTrades()
+			if len(trades) != 1 {
+				t.Fatalf("expected 1 trade, got %d", len(trades))
+			}
+			if !trades[0].ExitPrice.Equal(tt.wantExit) {
+				t.Errorf("ExitPrice = %s, want %s", trades[0].ExitPrice, tt.wantExit)
+			}
+		})
+	}
+}
+
 // TestSimulatedExecutor_StopPriorityOverTP tests stop priority when both hit (GAP-02).
 func TestSimulatedExecutor_StopPriorityOverTP(t *testing.T) {
 	exec := NewSimulatedExecutor(SimulatedConfig{
@@ -904,3 +970,909 @@ func TestSimulatedExecutor_PnL_ScratchTrade(t *testing.T) {
 		t.Errorf("Scratch NetPL: got %s, want %s (PL-04)", trades[0].NetPL, expectedNet)
 	}
 }
+
+func TestSimulatedExecutor_FlattenAll(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{
+		SlippageTicks:     0,
+		CommissionPerSide: decimal.Zero,
+	})
+
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(5000)})
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MGC", Timestamp: time.Now(), Close: decimal.NewFromInt(2000)})
+	exec.PlaceOrder(context.Background(), types.OrderIntent{ClientOrderID: "mes", Symbol: "MES", Side: types.SideLong, Contracts: 1})
+	exec.PlaceOrder(context.Background(), types.OrderIntent{ClientOrderID: "mgc", Symbol: "MGC", Side: types.SideShort, Contracts: 1})
+
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(5004)})
+
+	fills := exec.FlattenAll(context.Background())
+	if len(fills) != 2 {
+		t.Fatalf("fills = %d, want 2", len(fills))
+	}
+	if len(exec.GetPositions()) != 0 {
+		t.Error("expected no positions after FlattenAll")
+	}
+
+	trades := exec.GetTrades()
+	if len(trades) != 2 {
+		t.Fatalf("trades = %d, want 2", len(trades))
+	}
+	for _, trade := range trades {
+		if trade.Symbol == "MES" && !trade.ExitPrice.Equal(decimal.NewFromInt(5004)) {
+			t.Errorf("MES ExitPrice = %s, want 5004", trade.ExitPrice)
+		}
+	}
+}
+
+// TestSimulatedExecutor_RMultiple_Winner tests a 2R take-profit exit.
+func TestSimulatedExecutor_RMultiple_Winner(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{
+		SlippageTicks:     0,
+		CommissionPerSide: decimal.Zero,
+	})
+
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+
+	// Risk 10 points, target 20 points
+	_, _ = exec.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "r-win",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     2,
+		StopLoss:      decimal.NewFromInt(4990),
+		TakeProfit:    decimal.NewFromInt(5020),
+	})
+
+	exec.UpdateMarket(types.MarketEvent{
+		Symbol: "MES",
+		Close:  decimal.NewFromInt(5022),
+		High:   decimal.NewFromInt(5025),
+		Low:    decimal.NewFromInt(5005),
+	})
+
+	trades := exec.GetTrades()
+	if len(trades) != 1 {
+		t.Fatalf("expected 1 trade, got %d", len(trades))
+	}
+
+	// $200 profit / ($50 risk * 2 contracts) = 2R
+	if !trades[0].RMultiple.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("RMultiple = %s, want 2", trades[0].RMultiple)
+	}
+}
+
+// TestSimulatedExecutor_RMultiple_Loser tests a -1R stop-loss exit.
+func TestSimulatedExecutor_RMultiple_Loser(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{
+		SlippageTicks:     0,
+		CommissionPerSide: decimal.Zero,
+	})
+
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+
+	_, _ = exec.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "r-loss",
+		Symbol:        "MES",
+		Side:          types.SideShort,
+		Contracts:     1,
+		StopLoss:      decimal.NewFromInt(5010),
+		TakeProfit:    decimal.NewFromInt(4980),
+	})
+
+	exec.UpdateMarket(types.MarketEvent{
+		Symbol: "MES",
+		Close:  decimal.NewFromInt(5008),
+		High:   decimal.NewFromInt(5012),
+		Low:    decimal.NewFromInt(4995),
+	})
+
+	trades := exec.GetTrades()
+	if len(trades) != 1 {
+		t.Fatalf("expected 1 trade, got %d", len(trades))
+	}
+
+	if !trades[0].RMultiple.Equal(decimal.NewFromInt(-1)) {
+		t.Errorf("RMultiple = %s, want -1", trades[0].RMultiple)
+	}
+}
+
+// TestSimulatedExecutor_Breakeven_Long tests moving a long stop to breakeven.
+func TestSimulatedExecutor_Breakeven_Long(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{
+		CommissionPerSide:     decimal.Zero,
+		BreakevenTriggerTicks: 8, // 2 points on MES
+		BreakevenOffsetTicks:  1,
+	})
+
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	_, _ = exec.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "be-long",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     1,
+		StopLoss:      decimal.NewFromInt(4990),
+		TakeProfit:    decimal.NewFromInt(5020),
+	})
+
+	// Below trigger: stop unchanged
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5001), High: decimal.RequireFromString("5001.75"), Low: decimal.NewFromInt(4999)})
+	pos, _ := exec.GetPosition(context.Background(), "MES")
+	if !pos.StopLoss.Equal(decimal.NewFromInt(4990)) {
+		t.Errorf("StopLoss = %s, want 4990 before trigger", pos.StopLoss)
+	}
+
+	// Trigger reached: stop to entry + 1 tick
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5002), High: decimal.NewFromInt(5003), Low: decimal.NewFromInt(5001)})
+	pos, _ = exec.GetPosition(context.Background(), "MES")
+	if !pos.StopLoss.Equal(decimal.RequireFromString("5000.25")) {
+		t.Errorf("StopLoss = %s, want 5000.25", pos.StopLoss)
+	}
+	if !pos.InitialStop.Equal(decimal.NewFromInt(4990)) {
+		t.Errorf("InitialStop = %s, want 4990", pos.InitialStop)
+	}
+
+	// Pullback hits the breakeven stop
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(4998), High: decimal.NewFromInt(5001), Low: decimal.NewFromInt(4997)})
+	trades := exec.GetTrades()
+	if len(trades) != 1 {
+		t.Fatalf("expected 1 trade, got %d", len(trades))
+	}
+	if !trades[0].ExitPrice.Equal(decimal.RequireFromString("5000.25")) {
+		t.Errorf("ExitPrice = %s, want 5000.25", trades[0].ExitPrice)
+	}
+}
+
+// TestSimulatedExecutor_Breakeven_Short tests moving a short stop to breakeven.
+func TestSimulatedExecutor_Breakeven_Short(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{
+		CommissionPerSide:     decimal.Zero,
+		BreakevenTriggerTicks: 8,
+		BreakevenOffsetTicks:  0,
+	})
+
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	_, _ = exec.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "be-short",
+		Symbol:        "MES",
+		Side:          types.SideShort,
+		Contracts:     1,
+		StopLoss:      decimal.NewFromInt(5010),
+		TakeProfit:    decimal.NewFromInt(4980),
+	})
+
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(4998), High: decimal.NewFromInt(4999), Low: decimal.NewFromInt(4997)})
+	pos, _ := exec.GetPosition(context.Background(), "MES")
+	if !pos.StopLoss.Equal(decimal.NewFromInt(5000)) {
+		t.Errorf("StopLoss = %s, want 5000 (entry)", pos.StopLoss)
+	}
+}
+
+// TestSimulatedExecutor_Breakeven_NeverAdverse tests that a tighter stop is kept.
+func TestSimulatedExecutor_Breakeven_NeverAdverse(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{
+		CommissionPerSide:     decimal.Zero,
+		BreakevenTriggerTicks: 8,
+		BreakevenOffsetTicks:  0,
+	})
+
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	// Stop already above entry (e.g. a trailing stop)
+	_, _ = exec.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "be-tight",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     1,
+		StopLoss:      decimal.NewFromInt(5001),
+	})
+
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5004), High: decimal.NewFromInt(5005), Low: decimal.NewFromInt(5003)})
+	pos, _ := exec.GetPosition(context.Background(), "MES")
+	if !pos.StopLoss.Equal(decimal.NewFromInt(5001)) {
+		t.Errorf("StopLoss = %s, want 5001 (never loosened)", pos.StopLoss)
+	}
+}
+
+// TestSimulatedExecutor_ScaleOut tests partial exits at multiple targets with a runner.
+func TestSimulatedExecutor_ScaleOut(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{
+		SlippageTicks:     0,
+		CommissionPerSide: decimal.Zero,
+	})
+
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+
+	// Half at +10, a quarter at +20, a quarter runs on the stop
+	_, err := exec.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "scale-long",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     4,
+		StopLoss:      decimal.NewFromInt(4990),
+		Tranches: []types.TakeProfitTranche{
+			{Offset: decimal.NewFromInt(20), Fraction: decimal.RequireFromString("0.25")},
+			{Offset: decimal.NewFromInt(10), Fraction: decimal.RequireFromString("0.5")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+
+	// First target only
+	fills := exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5010), High: decimal.NewFromInt(5012), Low: decimal.NewFromInt(5002)})
+	if len(fills) != 1 || fills[0].FilledQty != 2 {
+		t.Fatalf("fills = %+v, want one fill of 2 contracts", fills)
+	}
+	pos, _ := exec.GetPosition(context.Background(), "MES")
+	if pos == nil || pos.Contracts != 2 {
+		t.Fatalf("expected 2 contracts remaining, got %+v", pos)
+	}
+
+	// Second target
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5020), High: decimal.NewFromInt(5021), Low: decimal.NewFromInt(5011)})
+	pos, _ = exec.GetPosition(context.Background(), "MES")
+	if pos == nil || pos.Contracts != 1 {
+		t.Fatalf("expected runner of 1 contract, got %+v", pos)
+	}
+
+	// Runner exits on the stop
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(4988), High: decimal.NewFromInt(5015), Low: decimal.NewFromInt(4985)})
+	if pos, _ := exec.GetPosition(context.Background(), "MES"); pos != nil {
+		t.Errorf("expected position closed, got %d contracts", pos.Contracts)
+	}
+
+	trades := exec.GetTrades()
+	if len(trades) != 3 {
+		t.Fatalf("expected 3 trades, got %d", len(trades))
+	}
+	wantPL := []int64{100, 100, -50} // 2x10pts, 1x20pts, 1x-10pts at $5/pt
+	for i, want := range wantPL {
+		if !trades[i].NetPL.Equal(decimal.NewFromInt(want)) {
+			t.Errorf("trade %d NetPL = %s, want %d", i, trades[i].NetPL, want)
+		}
+	}
+	if !trades[2].RMultiple.Equal(decimal.NewFromInt(-1)) {
+		t.Errorf("runner RMultiple = %s, want -1", trades[2].RMultiple)
+	}
+	for i, want := range []string{ExitScaleOut, ExitScaleOut, ExitStopLoss} {
+		if trades[i].ExitReason != want {
+			t.Errorf("trade %d ExitReason = %q, want %q", i, trades[i].ExitReason, want)
+		}
+	}
+}
+
+// TestSimulatedExecutor_ScaleOut_Short tests tranches on a short in a single bar.
+func TestSimulatedExecutor_ScaleOut_Short(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{
+		SlippageTicks:     0,
+		CommissionPerSide: decimal.Zero,
+	})
+
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	_, _ = exec.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "scale-short",
+		Symbol:        "MES",
+		Side:          types.SideShort,
+		Contracts:     2,
+		StopLoss:      decimal.NewFromInt(5010),
+		Tranches: []types.TakeProfitTranche{
+			{Offset: decimal.NewFromInt(5), Fraction: decimal.RequireFromString("0.5")},
+			{Offset: decimal.NewFromInt(10), Fraction: decimal.RequireFromString("0.5")},
+		},
+	})
+
+	// Both targets in one bar close the whole position
+	fills := exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(4990), High: decimal.NewFromInt(5001), Low: decimal.NewFromInt(4989)})
+	if len(fills) != 2 {
+		t.Fatalf("expected 2 fills, got %d", len(fills))
+	}
+	if fills[0].ClientOrderID == fills[1].ClientOrderID {
+		t.Error("scale-out fills should have distinct client order IDs")
+	}
+	if pos, _ := exec.GetPosition(context.Background(), "MES"); pos != nil {
+		t.Errorf("expected position closed, got %d contracts", pos.Contracts)
+	}
+}
+
+// TestSimulatedExecutor_ScaleOut_InvalidTranches tests tranche validation.
+func TestSimulatedExecutor_ScaleOut_InvalidTranches(t *testing.T) {
+	exec := NewSimulatedExecutor(DefaultSimulatedConfig())
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+
+	_, err := exec.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "scale-bad",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     2,
+		Tranches: []types.TakeProfitTranche{
+			{Offset: decimal.NewFromInt(5), Fraction: decimal.RequireFromString("0.6")},
+			{Offset: decimal.NewFromInt(10), Fraction: decimal.RequireFromString("0.6")},
+		},
+	})
+	if !errors.Is(err, types.ErrInvalidOrderSize) {
+		t.Errorf("PlaceOrder() error = %v, want ErrInvalidOrderSize", err)
+	}
+}
+
+// TestSimulatedExecutor_AmbiguousBarPolicy tests resolving bars that hit both stop and TP.
+func TestSimulatedExecutor_AmbiguousBarPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy AmbiguousBarPolicy
+		open   decimal.Decimal
+		wantTP bool
+	}{
+		{"stop first", AmbiguousStopFirst, decimal.NewFromInt(5008), false},
+		{"tp first", AmbiguousTPFirst, decimal.NewFromInt(4996), true},
+		{"open near target", AmbiguousOpenProximity, decimal.NewFromInt(5008), true},
+		{"open near stop", AmbiguousOpenProximity, decimal.NewFromInt(4997), false},
+		{"no open falls back to stop", AmbiguousOpenProximity, decimal.Zero, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := NewSimulatedExecutor(SimulatedConfig{
+				CommissionPerSide:  decimal.Zero,
+				AmbiguousBarPolicy: tt.policy,
+			})
+
+			exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+			_, _ = exec.PlaceOrder(context.Background(), types.OrderIntent{
+				ClientOrderID: "ambiguous",
+				Symbol:        "MES",
+				Side:          types.SideLong,
+				Contracts:     1,
+				StopLoss:      decimal.NewFromInt(4995),
+				TakeProfit:    decimal.NewFromInt(5010),
+			})
+
+			exec.UpdateMarket(types.MarketEvent{
+				Symbol: "MES",
+				Open:   tt.open,
+				High:   decimal.NewFromInt(5015),
+				Low:    decimal.NewFromInt(4990),
+				Close:  decimal.NewFromInt(5005),
+			})
+
+			trades := exec.GetTrades()
+			if len(trades) != 1 {
+				t.Fatalf("expected 1 trade, got %d", len(trades))
+			}
+			if gotTP := trades[0].GrossPL.IsPositive(); gotTP != tt.wantTP {
+				t.Errorf("take profit filled = %v, want %v (GrossPL %s)", gotTP, tt.wantTP, trades[0].GrossPL)
+			}
+		})
+	}
+}
+
+// TestSimulatedExecutor_AmbiguousBarPolicy_Short tests open proximity on a short.
+func TestSimulatedExecutor_AmbiguousBarPolicy_Short(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{
+		CommissionPerSide:  decimal.Zero,
+		AmbiguousBarPolicy: AmbiguousOpenProximity,
+	})
+
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	_, _ = exec.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "ambiguous-short",
+		Symbol:        "MES",
+		Side:          types.SideShort,
+		Contracts:     1,
+		StopLoss:      decimal.NewFromInt(5010),
+		TakeProfit:    decimal.NewFromInt(4990),
+	})
+
+	// Opens near the target: optimistic fill
+	exec.UpdateMarket(types.MarketEvent{
+		Symbol: "MES",
+		Open:   decimal.NewFromInt(4992),
+		High:   decimal.NewFromInt(5012),
+		Low:    decimal.NewFromInt(4988),
+		Close:  decimal.NewFromInt(5000),
+	})
+
+	trades := exec.GetTrades()
+	if len(trades) != 1 {
+		t.Fatalf("expected 1 trade, got %d", len(trades))
+	}
+	if !trades[0].ExitPrice.Equal(decimal.NewFromInt(4990)) {
+		t.Errorf("ExitPrice = %s, want 4990 (take profit)", trades[0].ExitPrice)
+	}
+}
+
+func TestSimulatedExecutor_MaxHoldBars(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{
+		SlippageTicks:     1,
+		CommissionPerSide: decimal.Zero,
+		MaxHoldBars:       3,
+	})
+
+	bar := func(closePrice int64) types.MarketEvent {
+		return types.MarketEvent{
+			Symbol:    "MES",
+			Timestamp: time.Now(),
+			Close:     decimal.NewFromInt(closePrice),
+			High:      decimal.NewFromInt(closePrice + 2),
+			Low:       decimal.NewFromInt(closePrice - 2),
+		}
+	}
+
+	exec.UpdateMarket(bar(5000))
+	_, err := exec.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "time-limit",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     1,
+		StopLoss:      decimal.NewFromInt(4980),
+		TakeProfit:    decimal.NewFromInt(5030),
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder failed: %v", err)
+	}
+
+	// Two bars drift inside the bracket
+	for _, price := range []int64{5004, 5006} {
+		if fills := exec.UpdateMarket(bar(price)); len(fills) != 0 {
+			t.Fatalf("bar at %d: expected no fills, got %d", price, len(fills))
+		}
+	}
+
+	// Third bar reaches the limit before the stop or target
+	fills := exec.UpdateMarket(bar(5008))
+	if len(fills) != 1 {
+		t.Fatalf("Expected 1 time exit fill, got %d", len(fills))
+	}
+	if !strings.HasPrefix(fills[0].ClientOrderID, ExitTimeLimit) {
+		t.Errorf("ClientOrderID = %q, want %s prefix", fills[0].ClientOrderID, ExitTimeLimit)
+	}
+
+	// Closed at the bar's close less one tick of slippage: 7.75 points * $5
+	trades := exec.GetTrades()
+	if len(trades) != 1 {
+		t.Fatalf("Expected 1 trade, got %d", len(trades))
+	}
+	if !trades[0].ExitPrice.Equal(decimal.RequireFromString("5007.75")) {
+		t.Errorf("ExitPrice = %s, want 5007.75", trades[0].ExitPrice)
+	}
+	if len(exec.GetPositions()) != 0 {
+		t.Error("expected no open position after the time exit")
+	}
+}
+
+func TestParseAmbiguousBarPolicy(t *testing.T) {
+	for _, name := range []string{"", "stop_first", "tp_first", "open_proximity"} {
+		policy, err := ParseAmbiguousBarPolicy(name)
+		if err != nil {
+			t.Errorf("ParseAmbiguousBarPolicy(%q) error = %v", name, err)
+		}
+		if name != "" && policy.String() != name {
+			t.Errorf("ParseAmbiguousBarPolicy(%q).String() = %s", name, policy)
+		}
+	}
+	if _, err := ParseAmbiguousBarPolicy("coin_flip"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
+
+// TestSimulatedExecutor_FillNextBarOpen compares same-bar close and next-bar open fills.
+func TestSimulatedExecutor_FillNextBarOpen(t *testing.T) {
+	signalBar := types.MarketEvent{Symbol: "MES", Open: decimal.NewFromInt(4995), High: decimal.NewFromInt(5002), Low: decimal.NewFromInt(4994), Close: decimal.NewFromInt(5000)}
+	nextBar := types.MarketEvent{Symbol: "MES", Open: decimal.NewFromInt(5004), High: decimal.NewFromInt(5008), Low: decimal.NewFromInt(5001), Close: decimal.NewFromInt(5006)}
+	order := types.OrderIntent{ClientOrderID: "next-open", Symbol: "MES", Side: types.SideLong, Contracts: 1}
+
+	// Default: fills immediately at the signal bar's close
+	sameBar := NewSimulatedExecutor(SimulatedConfig{CommissionPerSide: decimal.Zero})
+	sameBar.UpdateMarket(signalBar)
+	result, err := sameBar.PlaceOrder(context.Background(), order)
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	if result.Status != types.OrderStatusFilled || !result.AvgFillPrice.Equal(decimal.NewFromInt(5000)) {
+		t.Errorf("same-bar fill = %s @ %s, want FILLED @ 5000", result.Status, result.AvgFillPrice)
+	}
+
+	// Next-bar mode: pending until the next bar, then fills at its open plus slippage
+	nextOpen := NewSimulatedExecutor(SimulatedConfig{SlippageTicks: 1, CommissionPerSide: decimal.Zero, FillNextBarOpen: true})
+	nextOpen.UpdateMarket(signalBar)
+	result, err = nextOpen.PlaceOrder(context.Background(), order)
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	if result.Status != types.OrderStatusPending {
+		t.Errorf("Status = %s, want PENDING", result.Status)
+	}
+	if pos, _ := nextOpen.GetPosition(context.Background(), "MES"); pos != nil {
+		t.Fatal("position should not open before the next bar")
+	}
+
+	fills := nextOpen.UpdateMarket(nextBar)
+	if len(fills) != 1 {
+		t.Fatalf("expected 1 fill on next bar, got %d", len(fills))
+	}
+	want := decimal.RequireFromString("5004.25") // Open + 1 tick
+	if !fills[0].AvgFillPrice.Equal(want) {
+		t.Errorf("AvgFillPrice = %s, want %s", fills[0].AvgFillPrice, want)
+	}
+	pos, _ := nextOpen.GetPosition(context.Background(), "MES")
+	if pos == nil || !pos.EntryTime.Equal(nextBar.Timestamp) {
+		t.Errorf("expected position opened on the next bar, got %+v", pos)
+	}
+}
+
+// TestSimulatedExecutor_FillNextBarOpen_Cancel tests cancelling a queued order.
+func TestSimulatedExecutor_FillNextBarOpen_Cancel(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{CommissionPerSide: decimal.Zero, FillNextBarOpen: true})
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+
+	_, _ = exec.PlaceOrder(context.Background(), types.OrderIntent{ClientOrderID: "queued", Symbol: "MES", Side: types.SideLong, Contracts: 1})
+	if orders, _ := exec.GetOpenOrders(context.Background()); len(orders) != 1 {
+		t.Fatalf("expected 1 open order, got %d", len(orders))
+	}
+	if err := exec.CancelOrder(context.Background(), "queued"); err != nil {
+		t.Fatalf("CancelOrder() error = %v", err)
+	}
+
+	if fills := exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Open: decimal.NewFromInt(5001), Close: decimal.NewFromInt(5002)}); len(fills) != 0 {
+		t.Errorf("cancelled order should not fill, got %d fills", len(fills))
+	}
+}
+
+// TestSimulatedExecutor_FillNextBarOpen_Modify tests resizing a queued order.
+func TestSimulatedExecutor_FillNextBarOpen_Modify(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{CommissionPerSide: decimal.Zero, FillNextBarOpen: true})
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+
+	_, _ = exec.PlaceOrder(context.Background(), types.OrderIntent{ClientOrderID: "queued", Symbol: "MES", Side: types.SideLong, Contracts: 1})
+	if err := exec.ModifyOrder(context.Background(), "queued", decimal.NewFromInt(4999), 3); err != nil {
+		t.Fatalf("ModifyOrder() error = %v", err)
+	}
+	if err := exec.ModifyOrder(context.Background(), "missing", decimal.Zero, 1); !errors.Is(err, broker.ErrOrderNotFound) {
+		t.Errorf("ModifyOrder(missing) error = %v, want ErrOrderNotFound", err)
+	}
+
+	fills := exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Open: decimal.NewFromInt(5001), Close: decimal.NewFromInt(5002)})
+	if len(fills) != 1 || fills[0].FilledQty != 3 {
+		t.Fatalf("fills = %+v, want one fill of 3", fills)
+	}
+	if err := exec.ModifyOrder(context.Background(), "queued", decimal.Zero, 1); err == nil {
+		t.Error("expected error modifying a filled order")
+	}
+}
+
+// TestSimulatedExecutor_FillHandlerSynchronous tests fills are delivered in order before the call returns.
+func TestSimulatedExecutor_FillHandlerSynchronous(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{CommissionPerSide: decimal.Zero})
+
+	var seen []string
+	exec.SetFillHandler(func(ctx context.Context, result types.OrderResult) {
+		// Re-entering the executor must not deadlock
+		_, _ = exec.GetPosition(ctx, "MES")
+		seen = append(seen, result.ClientOrderID)
+	})
+
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+	_, _ = exec.PlaceOrder(context.Background(), types.OrderIntent{ClientOrderID: "open", Symbol: "MES", Side: types.SideLong, Contracts: 1})
+	_, _ = exec.PlaceOrder(context.Background(), types.OrderIntent{ClientOrderID: "close", Symbol: "MES", Side: types.SideShort, Contracts: 1})
+
+	if len(seen) != 2 || seen[0] != "open" || seen[1] != "close" {
+		t.Errorf("fills seen = %v, want [open close]", seen)
+	}
+}
+
+func TestSimulatedExecutor_ReduceOnly(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{SlippageTicks: 0, CommissionPerSide: decimal.NewFromInt(1)})
+	ctx := context.Background()
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(5000)})
+
+	_, err := exec.PlaceOrder(ctx, types.OrderIntent{
+		ClientOrderID: "reduce-flat", Symbol: "MES", Side: types.SideShort, Contracts: 1, ReduceOnly: true,
+	})
+	if !errors.Is(err, types.ErrNotReducing) {
+		t.Fatalf("reduce-only while flat: err = %v, want ErrNotReducing", err)
+	}
+	if pos, _ := exec.GetPosition(ctx, "MES"); pos != nil {
+		t.Fatalf("reduce-only opened %+v", pos)
+	}
+
+	if _, err := exec.PlaceOrder(ctx, types.OrderIntent{
+		ClientOrderID: "open", Symbol: "MES", Side: types.SideLong, Contracts: 1,
+	}); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	// Larger than the 1-lot: closes it without going short, and fills and
+	// pays commission on the 1 contract closed
+	result, err := exec.PlaceOrder(ctx, types.OrderIntent{
+		ClientOrderID: "reduce", Symbol: "MES", Side: types.SideShort, Contracts: 3, ReduceOnly: true,
+	})
+	if err != nil {
+		t.Fatalf("reduce-only close failed: %v", err)
+	}
+	if result.FilledQty != 1 || !result.Commission.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("result = %d filled, commission %s; want 1 and 1", result.FilledQty, result.Commission)
+	}
+	if pos, _ := exec.GetPosition(ctx, "MES"); pos != nil {
+		t.Errorf("position = %+v, want flat", pos)
+	}
+	if trades := exec.GetTrades(); len(trades) != 1 || trades[0].Contracts != 1 {
+		t.Errorf("trades = %+v, want one 1-contract trade", trades)
+	}
+}
+
+func TestSimulatedExecutor_ReduceOnlyPartial(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{SlippageTicks: 0, CommissionPerSide: decimal.NewFromInt(1)})
+	ctx := context.Background()
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Timestamp: time.Now(), Close: decimal.NewFromInt(5000)})
+
+	if _, err := exec.PlaceOrder(ctx, types.OrderIntent{
+		ClientOrderID: "open", Symbol: "MES", Side: types.SideLong, Contracts: 3,
+	}); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	// Closes 1 of the 3 contracts
+	result, err := exec.PlaceOrder(ctx, types.OrderIntent{
+		ClientOrderID: "reduce", Symbol: "MES", Side: types.SideShort, Contracts: 1, ReduceOnly: true,
+	})
+	if err != nil {
+		t.Fatalf("reduce-only close failed: %v", err)
+	}
+	if result.FilledQty != 1 || !result.Commission.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("result = %d filled, commission %s; want 1 and 1", result.FilledQty, result.Commission)
+	}
+	if pos, _ := exec.GetPosition(ctx, "MES"); pos == nil || pos.Side != types.SideLong || pos.Contracts != 2 {
+		t.Errorf("position = %+v, want LONG 2", pos)
+	}
+	if trades := exec.GetTrades(); len(trades) != 1 || trades[0].Contracts != 1 {
+		t.Errorf("trades = %+v, want one 1-contract trade", trades)
+	}
+}
+
+func TestSimulatedExecutor_ExitReason(t *testing.T) {
+	bar := func(close, high, low int64) types.MarketEvent {
+		return types.MarketEvent{
+			Symbol: "MES",
+			Close:  decimal.NewFromInt(close),
+			High:   decimal.NewFromInt(high),
+			Low:    decimal.NewFromInt(low),
+		}
+	}
+
+	tests := []struct {
+		name  string
+		cfg   SimulatedConfig
+		bars  []types.MarketEvent
+		close func(exec *SimulatedExecutor)
+		want  string
+	}{
+		{"stop", SimulatedConfig{}, []types.MarketEvent{bar(4989, 5001, 4988)}, nil, ExitStopLoss},
+		{"take profit", SimulatedConfig{}, []types.MarketEvent{bar(5020, 5021, 4999)}, nil, ExitTakeProfit},
+		{
+			"breakeven stop",
+			SimulatedConfig{BreakevenTriggerTicks: 8},
+			[]types.MarketEvent{bar(5002, 5003, 5001), bar(4998, 5001, 4997)},
+			nil,
+			ExitBreakevenStop,
+		},
+		{"time exit", SimulatedConfig{MaxHoldBars: 1}, []types.MarketEvent{bar(5001, 5002, 4999)}, nil, ExitTimeLimit},
+		{
+			"flatten",
+			SimulatedConfig{},
+			nil,
+			func(exec *SimulatedExecutor) { exec.FlattenAll(context.Background()) },
+			ExitFlatten,
+		},
+		{
+			"signal",
+			SimulatedConfig{},
+			nil,
+			func(exec *SimulatedExecutor) {
+				_, _ = exec.PlaceOrder(context.Background(), types.OrderIntent{ClientOrderID: "exit", Symbol: "MES", Side: types.SideShort, Contracts: 1})
+			},
+			ExitSignal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := NewSimulatedExecutor(tt.cfg)
+			exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+			_, err := exec.PlaceOrder(context.Background(), types.OrderIntent{
+				ClientOrderID: "entry",
+				Symbol:        "MES",
+				Side:          types.SideLong,
+				Contracts:     1,
+				StopLoss:      decimal.NewFromInt(4990),
+				TakeProfit:    decimal.NewFromInt(5020),
+			})
+			if err != nil {
+				t.Fatalf("PlaceOrder() error = %v", err)
+			}
+
+			for _, event := range tt.bars {
+				exec.UpdateMarket(event)
+			}
+			if tt.close != nil {
+				tt.close(exec)
+			}
+
+			trades := exec.GetTrades()
+			if len(trades) != 1 {
+				t.Fatalf("trades = %d, want 1", len(trades))
+			}
+			if trades[0].ExitReason != tt.want {
+				t.Errorf("ExitReason = %q, want %q", trades[0].ExitReason, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimulatedExecutor_MaxVolumeFraction(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{
+		CommissionPerSide: decimal.Zero,
+		MaxVolumeFraction: decimal.RequireFromString("0.1"),
+	})
+	ctx := context.Background()
+
+	// 10% of 35 contracts of volume rounds down to 3
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000), Volume: 35})
+	result, err := exec.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: "big", Symbol: "MES", Side: types.SideLong, Contracts: 20})
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+	if result.Status != types.OrderStatusPartialFill || result.FilledQty != 3 {
+		t.Errorf("result = %s %d contracts, want PARTIAL_FILL of 3", result.Status, result.FilledQty)
+	}
+	if pos, _ := exec.GetPosition(ctx, "MES"); pos == nil || pos.Contracts != 3 {
+		t.Fatalf("position = %+v, want 3 contracts", pos)
+	}
+
+	// A capped close takes 2 of the 3 and leaves the rest open
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5010), Volume: 20})
+	if _, err := exec.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: "exit", Symbol: "MES", Side: types.SideShort, Contracts: 3}); err != nil {
+		t.Fatalf("close PlaceOrder() error = %v", err)
+	}
+	if pos, _ := exec.GetPosition(ctx, "MES"); pos == nil || pos.Contracts != 1 {
+		t.Errorf("position after capped close = %+v, want 1 contract", pos)
+	}
+	trades := exec.GetTrades()
+	if len(trades) != 1 || trades[0].Contracts != 2 || !trades[0].NetPL.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("trades = %+v, want one 2-contract trade netting 100", trades)
+	}
+
+	// Too little volume for a single contract
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MGC", Close: decimal.NewFromInt(2000), Volume: 5})
+	_, err = exec.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: "thin", Symbol: "MGC", Side: types.SideLong, Contracts: 1})
+	if !errors.Is(err, types.ErrInvalidOrderSize) {
+		t.Errorf("thin bar error = %v, want ErrInvalidOrderSize", err)
+	}
+
+	// Bars without volume aren't capped
+	exec.UpdateMarket(types.MarketEvent{Symbol: "MGC", Close: decimal.NewFromInt(2000)})
+	result, err = exec.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: "no-volume", Symbol: "MGC", Side: types.SideLong, Contracts: 4})
+	if err != nil || result.Status != types.OrderStatusFilled || result.FilledQty != 4 {
+		t.Errorf("no-volume result = %+v, %v; want 4 contracts filled", result, err)
+	}
+}
+
+func TestSimulatedExecutor_CloseQuantity(t *testing.T) {
+	tests := []struct {
+		name       string
+		held       int
+		close      int
+		volume     int64
+		wantFilled int
+		wantLeft   int
+	}{
+		{name: "capped partial close", held: 3, close: 3, volume: 20, wantFilled: 2, wantLeft: 1},
+		{name: "cap above position", held: 1, close: 3, volume: 20, wantFilled: 1},
+		{name: "order above position", held: 1, close: 3, wantFilled: 1},
+		{name: "order below position", held: 3, close: 1, wantFilled: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := NewSimulatedExecutor(SimulatedConfig{
+				CommissionPerSide: decimal.NewFromInt(1),
+				MaxVolumeFraction: decimal.RequireFromString("0.1"),
+			})
+			ctx := context.Background()
+
+			exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+			if _, err := exec.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: "open", Symbol: "MES", Side: types.SideLong, Contracts: tt.held}); err != nil {
+				t.Fatalf("open PlaceOrder() error = %v", err)
+			}
+
+			exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5010), Volume: tt.volume})
+			result, err := exec.PlaceOrder(ctx, types.OrderIntent{ClientOrderID: "exit", Symbol: "MES", Side: types.SideShort, Contracts: tt.close})
+			if err != nil {
+				t.Fatalf("close PlaceOrder() error = %v", err)
+			}
+
+			// Fill, commission and trade all count the contracts actually closed
+			want := decimal.NewFromInt(int64(tt.wantFilled))
+			if result.FilledQty != tt.wantFilled || !result.Commission.Equal(want) {
+				t.Errorf("result = %d filled, commission %s; want %d and %s", result.FilledQty, result.Commission, tt.wantFilled, want)
+			}
+			trades := exec.GetTrades()
+			if len(trades) != 1 || trades[0].Contracts != tt.wantFilled || !trades[0].Commission.Equal(want) {
+				t.Errorf("trades = %+v, want one %d-contract trade", trades, tt.wantFilled)
+			}
+
+			pos, _ := exec.GetPosition(ctx, "MES")
+			switch {
+			case tt.wantLeft == 0 && pos != nil:
+				t.Errorf("position = %+v, want flat", pos)
+			case tt.wantLeft > 0 && (pos == nil || pos.Contracts != tt.wantLeft):
+				t.Errorf("position = %+v, want %d contracts", pos, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func TestSimulatedExecutor_SlippageAsymmetry(t *testing.T) {
+	cfg := SimulatedConfig{
+		SlippageTicks:     1,
+		ExitSlippageTicks: 2,
+		StopSlippageTicks: 2,
+		CommissionPerSide: decimal.Zero,
+	}
+
+	tests := []struct {
+		name         string
+		bar          types.MarketEvent
+		wantReason   string
+		wantSlippage string
+		wantExit     string
+	}{
+		{
+			"stop",
+			types.MarketEvent{Symbol: "MES", High: decimal.NewFromInt(5001), Low: decimal.NewFromInt(4990), Close: decimal.NewFromInt(4991)},
+			ExitStopLoss,
+			"1",    // 2 exit + 2 stop ticks
+			"4994", // 4995 - 1.00
+		},
+		{
+			"take profit",
+			types.MarketEvent{Symbol: "MES", High: decimal.NewFromInt(5015), Low: decimal.NewFromInt(4999), Close: decimal.NewFromInt(5012)},
+			ExitTakeProfit,
+			"0.5",    // 2 exit ticks
+			"5009.5", // 5010 - 0.50
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := NewSimulatedExecutor(cfg)
+			exec.UpdateMarket(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)})
+			entry, err := exec.PlaceOrder(context.Background(), types.OrderIntent{
+				ClientOrderID: "entry",
+				Symbol:        "MES",
+				Side:          types.SideLong,
+				Contracts:     1,
+				StopLoss:      decimal.NewFromInt(4995),
+				TakeProfit:    decimal.NewFromInt(5010),
+			})
+			if err != nil {
+				t.Fatalf("PlaceOrder() error = %v", err)
+			}
+			if !entry.Slippage.Equal(decimal.RequireFromString("0.25")) {
+				t.Errorf("entry slippage = %s, want 0.25", entry.Slippage)
+			}
+
+			fills := exec.UpdateMarket(tt.bar)
+			if len(fills) != 1 {
+				t.Fatalf("fills = %d, want 1", len(fills))
+			}
+			if !fills[0].Slippage.Equal(decimal.RequireFromString(tt.wantSlippage)) {
+				t.Errorf("exit slippage = %s, want %s", fills[0].Slippage, tt.wantSlippage)
+			}
+
+			trade := exec.GetTrades()[0]
+			if trade.ExitReason != tt.wantReason {
+				t.Errorf("ExitReason = %q, want %q", trade.ExitReason, tt.wantReason)
+			}
+			if !trade.ExitPrice.Equal(decimal.RequireFromString(tt.wantExit)) {
+				t.Errorf("ExitPrice = %s, want %s", trade.ExitPrice, tt.wantExit)
+			}
+		})
+	}
+}
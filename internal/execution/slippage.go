@@ -0,0 +1,54 @@
+package execution
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// SlippageModel estimates adverse price movement on a fill, as a positive
+// price amount applied against the order side.
+type SlippageModel interface {
+	Slippage(symbol string, contracts int, event types.MarketEvent) decimal.Decimal
+}
+
+// FixedSlippage applies a constant number of ticks to every fill.
+type FixedSlippage struct {
+	Ticks int
+}
+
+// NewFixedSlippage creates a fixed-tick slippage model.
+func NewFixedSlippage(ticks int) FixedSlippage {
+	return FixedSlippage{Ticks: ticks}
+}
+
+// Slippage returns Ticks * tick size.
+func (f FixedSlippage) Slippage(symbol string, contracts int, event types.MarketEvent) decimal.Decimal {
+	spec, _ := types.GetInstrumentSpec(symbol)
+	return spec.TickSize.Mul(decimal.NewFromInt(int64(f.Ticks)))
+}
+
+// ATRSlippage scales slippage with the bar's ATR so fills in fast markets
+// are penalized more. The result is rounded up to whole ticks and never
+// drops below MinTicks (also used while ATR is still warming up).
+type ATRSlippage struct {
+	Fraction decimal.Decimal // Slippage as a fraction of ATR (e.g., 0.05 = 5% of ATR)
+	MinTicks int             // Floor in ticks
+}
+
+// NewATRSlippage creates an ATR-proportional slippage model.
+func NewATRSlippage(fraction decimal.Decimal, minTicks int) ATRSlippage {
+	return ATRSlippage{Fraction: fraction, MinTicks: minTicks}
+}
+
+// Slippage returns max(MinTicks, ceil(ATR * Fraction / tick)) ticks as a price amount.
+func (a ATRSlippage) Slippage(symbol string, contracts int, event types.MarketEvent) decimal.Decimal {
+	spec, _ := types.GetInstrumentSpec(symbol)
+	floor := spec.TickSize.Mul(decimal.NewFromInt(int64(a.MinTicks)))
+
+	if event.ATR.IsZero() || spec.TickSize.IsZero() {
+		return floor
+	}
+
+	ticks := event.ATR.Mul(a.Fraction).Div(spec.TickSize).Ceil()
+	return decimal.Max(floor, ticks.Mul(spec.TickSize))
+}
@@ -0,0 +1,72 @@
+package execution
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func TestFixedSlippage(t *testing.T) {
+	model := NewFixedSlippage(2)
+
+	got := model.Slippage("MES", 1, types.MarketEvent{ATR: decimal.NewFromInt(20)})
+	want := decimal.RequireFromString("0.5")
+	if !got.Equal(want) {
+		t.Errorf("Slippage = %s, want %s (ATR must not matter)", got, want)
+	}
+}
+
+func TestATRSlippage(t *testing.T) {
+	model := NewATRSlippage(decimal.RequireFromString("0.05"), 1)
+
+	tests := []struct {
+		name string
+		atr  decimal.Decimal
+		want decimal.Decimal
+	}{
+		{"calm market uses floor", decimal.NewFromInt(4), decimal.RequireFromString("0.25")},
+		{"volatile market scales with ATR", decimal.NewFromInt(20), decimal.RequireFromString("1.0")},
+		{"rounds up to whole ticks", decimal.NewFromInt(11), decimal.RequireFromString("0.75")},
+		{"no ATR uses floor", decimal.Zero, decimal.RequireFromString("0.25")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := model.Slippage("MES", 1, types.MarketEvent{ATR: tt.atr})
+			if !got.Equal(tt.want) {
+				t.Errorf("Slippage = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimulatedExecutor_ATRSlippage(t *testing.T) {
+	exec := NewSimulatedExecutor(SimulatedConfig{
+		SlippageTicks:     1,
+		CommissionPerSide: decimal.Zero,
+		SlippageModel:     NewATRSlippage(decimal.RequireFromString("0.05"), 1),
+	})
+
+	// Volatile bar: 5% of ATR 20 = 1 point slippage instead of 1 tick
+	exec.UpdateMarket(types.MarketEvent{
+		Symbol:    "MES",
+		Timestamp: time.Now(),
+		Close:     decimal.NewFromInt(5000),
+		ATR:       decimal.NewFromInt(20),
+	})
+
+	result, err := exec.PlaceOrder(context.Background(), types.OrderIntent{
+		ClientOrderID: "atr-slip", Symbol: "MES", Side: types.SideLong, Contracts: 1,
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder failed: %v", err)
+	}
+
+	want := decimal.NewFromInt(5001)
+	if !result.AvgFillPrice.Equal(want) {
+		t.Errorf("AvgFillPrice = %s, want %s", result.AvgFillPrice, want)
+	}
+}
@@ -0,0 +1,62 @@
+// Package logging builds the bot's slog logger, writing to stdout or to a
+// size-rotated file.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// Config holds log output settings.
+type Config struct {
+	Format     string // json | text ("" = json)
+	Level      slog.Level
+	Path       string        // Log file; empty logs to stdout
+	MaxSize    int64         // Rotate once the file reaches this many bytes (0 = never)
+	MaxAge     time.Duration // Delete rotated files older than this (0 = keep)
+	MaxBackups int           // Keep at most this many rotated files (0 = keep all)
+}
+
+// ValidFormat reports whether format names a supported handler.
+func ValidFormat(format string) bool {
+	switch format {
+	case "", "json", "text":
+		return true
+	default:
+		return false
+	}
+}
+
+// New returns a logger writing to cfg.Path, or to stdout when no path is
+// set. Close the returned closer on shutdown to flush the file.
+func New(cfg Config, stdout io.Writer) (*slog.Logger, io.Closer, error) {
+	if !ValidFormat(cfg.Format) {
+		return nil, nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	var out io.Writer = stdout
+	var closer io.Closer = nopCloser{}
+	if cfg.Path != "" {
+		file, err := NewRotatingFile(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closer = file, file
+	}
+
+	opts := &slog.HandlerOptions{Level: cfg.Level}
+	var handler slog.Handler
+	if cfg.Format == "text" {
+		handler = slog.NewTextHandler(out, opts)
+	} else {
+		handler = slog.NewJSONHandler(out, opts)
+	}
+	return slog.New(handler), closer, nil
+}
+
+// nopCloser closes nothing; stdout stays open.
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNew_Stdout(t *testing.T) {
+	var out bytes.Buffer
+
+	logger, closer, err := New(Config{Format: "text"}, &out)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	logger.Info("hello", "key", "value")
+	if err := closer.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "msg=hello key=value") {
+		t.Errorf("text output = %q", out.String())
+	}
+
+	out.Reset()
+	logger, _, _ = New(Config{}, &out)
+	logger.Debug("hidden")
+	logger.Info("hello")
+	if !strings.HasPrefix(out.String(), "{") || strings.Contains(out.String(), "hidden") {
+		t.Errorf("default should be JSON at info level, got %q", out.String())
+	}
+
+	if _, _, err := New(Config{Format: "xml"}, &out); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestNew_File(t *testing.T) {
+	var out bytes.Buffer
+	path := filepath.Join(t.TempDir(), "logs", "bot.log")
+
+	logger, closer, err := New(Config{Path: path, Level: slog.LevelDebug}, &out)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	logger.Debug("to file")
+	if err := closer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read log file: %v", err)
+	}
+	if !strings.Contains(string(data), `"msg":"to file"`) {
+		t.Errorf("log file = %q", data)
+	}
+	if out.Len() != 0 {
+		t.Errorf("stdout should be unused with a path, got %q", out.String())
+	}
+}
@@ -0,0 +1,208 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat stamps rotated files: bot-2024-01-02T15-04-05.000.log.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile is an io.WriteCloser that appends to a file and moves it
+// aside once it reaches MaxSize, keeping at most MaxBackups rotated files
+// no older than MaxAge.
+type RotatingFile struct {
+	cfg Config
+	now func() time.Time
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewRotatingFile opens (or creates) cfg.Path for appending.
+func NewRotatingFile(cfg Config) (*RotatingFile, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("log path is required")
+	}
+
+	f := &RotatingFile{cfg: cfg, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open opens the log file, creating its directory if needed.
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.cfg.Path), 0o755); err != nil {
+		return fmt.Errorf("create log dir: %w", err)
+	}
+
+	file, err := os.OpenFile(f.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if p would push the file past MaxSize.
+// A single write larger than MaxSize still goes into one file.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, fmt.Errorf("log file closed")
+	}
+
+	if f.cfg.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.cfg.MaxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate renames the current file with a timestamp, starts a new one and
+// prunes old backups. If the rename or the new file fails, the path is
+// reopened for appending so a transient error doesn't stop all logging.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("close log file: %w", err)
+	}
+	f.file = nil
+
+	if err := os.Rename(f.cfg.Path, f.backupName(f.now())); err != nil {
+		return f.reopen(fmt.Errorf("rotate log file: %w", err))
+	}
+	if err := f.open(); err != nil {
+		return f.reopen(err)
+	}
+	return f.prune()
+}
+
+// reopen retries opening the log file after a failed rotation and returns
+// the rotation error, joined with the open error if the retry fails too.
+func (f *RotatingFile) reopen(err error) error {
+	if openErr := f.open(); openErr != nil {
+		return errors.Join(err, openErr)
+	}
+	return err
+}
+
+// backupName returns the rotated file name for t.
+func (f *RotatingFile) backupName(t time.Time) string {
+	dir, prefix, ext := f.nameParts()
+	return filepath.Join(dir, prefix+t.UTC().Format(backupTimeFormat)+ext)
+}
+
+// nameParts splits the path into directory, backup prefix and extension.
+func (f *RotatingFile) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(f.cfg.Path)
+	base := filepath.Base(f.cfg.Path)
+	ext = filepath.Ext(base)
+	return dir, strings.TrimSuffix(base, ext) + "-", ext
+}
+
+// prune deletes backups beyond MaxBackups (newest kept) and older than
+// MaxAge. Zero limits keep everything.
+func (f *RotatingFile) prune() error {
+	if f.cfg.MaxBackups <= 0 && f.cfg.MaxAge <= 0 {
+		return nil
+	}
+
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+
+	cutoff := f.now().Add(-f.cfg.MaxAge)
+	for i, b := range backups {
+		tooMany := f.cfg.MaxBackups > 0 && i >= f.cfg.MaxBackups
+		tooOld := f.cfg.MaxAge > 0 && b.rotatedAt.Before(cutoff)
+		if tooMany || tooOld {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("remove old log file: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// backup is a rotated log file.
+type backup struct {
+	path      string
+	rotatedAt time.Time
+}
+
+// backups lists rotated files for this log, newest first.
+func (f *RotatingFile) backups() ([]backup, error) {
+	dir, prefix, ext := f.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("list log dir: %w", err)
+	}
+
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		t, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue // Not one of ours
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), rotatedAt: t})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].rotatedAt.After(backups[j].rotatedAt) })
+	return backups, nil
+}
+
+// Sync flushes the file to disk.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+// Close flushes and closes the file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	syncErr := f.file.Sync()
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return err
+	}
+	return syncErr
+}
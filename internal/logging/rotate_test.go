@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile_RotatesAtMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bot.log")
+
+	f, err := NewRotatingFile(Config{Path: path, MaxSize: 20})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	f.now = func() time.Time { return now }
+
+	for _, line := range []string{"first line 1234\n", "second line 123\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	current, _ := os.ReadFile(path)
+	if string(current) != "second line 123\n" {
+		t.Errorf("current file = %q, want only the second line", current)
+	}
+	rotated, err := os.ReadFile(filepath.Join(dir, "bot-2024-01-02T15-04-05.000.log"))
+	if err != nil {
+		t.Fatalf("rotated file: %v", err)
+	}
+	if string(rotated) != "first line 1234\n" {
+		t.Errorf("rotated file = %q, want the first line", rotated)
+	}
+
+	if _, err := f.Write([]byte("late")); err == nil {
+		t.Error("expected error writing after Close")
+	}
+}
+
+func TestRotatingFile_KeepsWritingAfterFailedRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bot.log")
+
+	f, err := NewRotatingFile(Config{Path: path, MaxSize: 20})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	f.now = func() time.Time { return now }
+
+	// A non-empty directory in the backup's place makes the rename fail
+	blocker := filepath.Join(dir, "bot-2024-01-02T15-04-05.000.log")
+	if err := os.MkdirAll(filepath.Join(blocker, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Write([]byte("first line 1234\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := f.Write([]byte("second line 123\n")); err == nil {
+		t.Fatal("expected the rotation to fail")
+	}
+
+	// Once the rename works again, writing and rotating resume
+	if err := os.RemoveAll(blocker); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("second line 123\n")); err != nil {
+		t.Fatalf("Write() after failed rotation error = %v", err)
+	}
+
+	current, _ := os.ReadFile(path)
+	if string(current) != "second line 123\n" {
+		t.Errorf("current file = %q, want only the second line", current)
+	}
+	rotated, _ := os.ReadFile(blocker)
+	if string(rotated) != "first line 1234\n" {
+		t.Errorf("rotated file = %q, want the first line", rotated)
+	}
+}
+
+func TestRotatingFile_PrunesBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bot.log")
+
+	f, err := NewRotatingFile(Config{Path: path, MaxSize: 5, MaxBackups: 2, MaxAge: 48 * time.Hour})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	// A stale backup from last week and an unrelated file
+	if err := os.WriteFile(filepath.Join(dir, "bot-2024-01-01T00-00-00.000.log"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bot-notes.log"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+	for i := 0; i < 5; i++ {
+		now = now.Add(time.Minute)
+		if _, err := f.Write([]byte("12345")); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	var backups []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "bot-2024") {
+			backups = append(backups, e.Name())
+		}
+	}
+	want := []string{"bot-2024-01-08T12-04-00.000.log", "bot-2024-01-08T12-05-00.000.log"}
+	if strings.Join(backups, ",") != strings.Join(want, ",") {
+		t.Errorf("backups = %v, want %v", backups, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bot-notes.log")); err != nil {
+		t.Error("files that aren't backups should be left alone")
+	}
+}
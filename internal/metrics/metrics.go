@@ -51,6 +51,26 @@ var (
 		},
 		[]string{"symbol", "side"},
 	)
+
+	// ProfitFactor tracks gross profit / gross loss of trades closed live.
+	ProfitFactor = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "quantbot",
+			Subsystem: "trading",
+			Name:      "profit_factor",
+			Help:      "Gross profit / gross loss of closed trades (0 until a losing trade)",
+		},
+	)
+
+	// Expectancy tracks the expected P&L per closed trade.
+	Expectancy = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "quantbot",
+			Subsystem: "trading",
+			Name:      "expectancy",
+			Help:      "Expected profit/loss per closed trade in USD",
+		},
+	)
 )
 
 // Account metrics
@@ -176,6 +196,18 @@ var (
 		},
 		[]string{"strategy"},
 	)
+
+	// EventLatency tracks the engine's end-to-end processing time per market
+	// event: indicators, strategy, risk checks and any orders placed.
+	EventLatency = promauto.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "quantbot",
+			Subsystem: "latency",
+			Name:      "event_seconds",
+			Help:      "Market event processing latency in seconds",
+			Buckets:   []float64{0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5},
+		},
+	)
 )
 
 // System metrics
@@ -190,6 +222,17 @@ var (
 		},
 	)
 
+	// DataLagSeconds tracks how far the latest bar's close trails wall-clock
+	// time. Negative values mean the local clock is behind the feed.
+	DataLagSeconds = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "quantbot",
+			Subsystem: "system",
+			Name:      "data_lag_seconds",
+			Help:      "Seconds between the latest bar close and wall-clock time",
+		},
+	)
+
 	// DataFeedConnected indicates if data feed is connected.
 	DataFeedConnected = promauto.NewGauge(
 		prometheus.GaugeOpts{
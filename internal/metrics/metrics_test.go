@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/shopspring/decimal"
 )
 
@@ -26,6 +27,19 @@ func TestRecorder_RecordTrade(t *testing.T) {
 	r.RecordTrade("MES", "short", false)
 }
 
+func TestRecorder_RecordTradeStats(t *testing.T) {
+	r := NewRecorder()
+
+	r.RecordTradeStats(decimal.RequireFromString("1.5"), decimal.NewFromInt(-10))
+
+	if got := testutil.ToFloat64(ProfitFactor); got != 1.5 {
+		t.Errorf("profit_factor = %v, want 1.5", got)
+	}
+	if got := testutil.ToFloat64(Expectancy); got != -10 {
+		t.Errorf("expectancy = %v, want -10", got)
+	}
+}
+
 func TestRecorder_RecordPosition(t *testing.T) {
 	r := NewRecorder()
 
@@ -64,6 +78,7 @@ func TestRecorder_RecordLatency(t *testing.T) {
 	r.RecordOrderLatency(100 * time.Millisecond)
 	r.RecordDataFeedLatency(5 * time.Millisecond)
 	r.RecordStrategyLatency("breakout", 500 * time.Microsecond)
+	r.RecordEventLatency(50 * time.Microsecond)
 }
 
 func TestRecorder_RecordHeartbeat(t *testing.T) {
@@ -120,7 +135,9 @@ func TestMetricsRegistered(t *testing.T) {
 		OrderLatency,
 		DataFeedLatency,
 		StrategyLatency,
+		EventLatency,
 		HeartbeatTimestamp,
+		DataLagSeconds,
 		DataFeedConnected,
 		BrokerConnected,
 		UptimeSeconds,
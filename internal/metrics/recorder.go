@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
 )
 
 // Recorder provides methods for recording metrics.
@@ -28,6 +29,13 @@ func (r *Recorder) RecordTrade(symbol, side string, profitable bool) {
 	TradesTotal.WithLabelValues(symbol, side, outcome).Inc()
 }
 
+// RecordTradeStats records the running profit factor and expectancy of
+// closed trades.
+func (r *Recorder) RecordTradeStats(profitFactor, expectancy decimal.Decimal) {
+	ProfitFactor.Set(profitFactor.InexactFloat64())
+	Expectancy.Set(expectancy.InexactFloat64())
+}
+
 // RecordPositionOpened records a position being opened.
 func (r *Recorder) RecordPositionOpened(symbol, side string, contracts int) {
 	PositionsOpen.WithLabelValues(symbol).Inc()
@@ -71,9 +79,10 @@ func (r *Recorder) RecordSignal(strategy, side string) {
 	SignalsGenerated.WithLabelValues(strategy, side).Inc()
 }
 
-// RecordSignalRejected records a signal being rejected.
-func (r *Recorder) RecordSignalRejected(reason string) {
-	SignalsRejected.WithLabelValues(reason).Inc()
+// RecordSignalRejected records a signal being rejected. The reason is a
+// fixed code so the label set stays bounded.
+func (r *Recorder) RecordSignalRejected(reason types.RejectReason) {
+	SignalsRejected.WithLabelValues(string(reason)).Inc()
 }
 
 // RecordOrderLatency records order execution latency.
@@ -91,11 +100,21 @@ func (r *Recorder) RecordStrategyLatency(strategy string, duration time.Duration
 	StrategyLatency.WithLabelValues(strategy).Observe(duration.Seconds())
 }
 
+// RecordEventLatency records the processing time of one market event.
+func (r *Recorder) RecordEventLatency(duration time.Duration) {
+	EventLatency.Observe(duration.Seconds())
+}
+
 // RecordHeartbeat records a heartbeat.
 func (r *Recorder) RecordHeartbeat() {
 	HeartbeatTimestamp.Set(float64(time.Now().Unix()))
 }
 
+// RecordDataLag records how far the latest bar trails wall-clock time.
+func (r *Recorder) RecordDataLag(lag time.Duration) {
+	DataLagSeconds.Set(lag.Seconds())
+}
+
 // RecordDataFeedStatus records data feed connection status.
 func (r *Recorder) RecordDataFeedStatus(connected bool) {
 	if connected {
@@ -143,3 +162,8 @@ func (t *Timer) ObserveOrder() {
 func (t *Timer) ObserveStrategy(strategy string) {
 	StrategyLatency.WithLabelValues(strategy).Observe(t.Elapsed().Seconds())
 }
+
+// ObserveEvent observes the elapsed time as market event latency.
+func (t *Timer) ObserveEvent() {
+	EventLatency.Observe(t.Elapsed().Seconds())
+}
@@ -3,9 +3,10 @@ package metrics
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -14,14 +15,20 @@ import (
 
 // ServerConfig holds configuration for the metrics server.
 type ServerConfig struct {
+	Host        string // Interface to listen on ("" = all interfaces)
 	Port        int
 	MetricsPath string
 	HealthPath  string
+	StreamPath  string // WebSocket state stream for dashboards ("" = disabled)
+
+	// Browser origins allowed to open the stream besides its own host
+	StreamOrigins []string
 }
 
 // DefaultServerConfig returns default server configuration.
 func DefaultServerConfig() ServerConfig {
 	return ServerConfig{
+		Host:        "127.0.0.1",
 		Port:        9090,
 		MetricsPath: "/metrics",
 		HealthPath:  "/health",
@@ -51,6 +58,7 @@ type Server struct {
 	httpServer *http.Server
 	startTime  time.Time
 	logger     *slog.Logger
+	hub        *Hub // nil when the state stream is disabled
 
 	mu       sync.RWMutex
 	checkers map[string]HealthChecker
@@ -74,9 +82,13 @@ func NewServer(cfg ServerConfig, logger *slog.Logger) *Server {
 	mux.HandleFunc(cfg.HealthPath, s.healthHandler)
 	mux.HandleFunc("/ready", s.readyHandler)
 	mux.HandleFunc("/live", s.liveHandler)
+	if cfg.StreamPath != "" {
+		s.hub = NewHub(logger, cfg.StreamOrigins...)
+		mux.Handle(cfg.StreamPath, s.hub)
+	}
 
 	s.httpServer = &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.Port),
+		Addr:         net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
 		Handler:      mux,
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 10 * time.Second,
@@ -96,9 +108,10 @@ func (s *Server) RegisterHealthCheck(name string, checker HealthChecker) {
 // Start starts the metrics server.
 func (s *Server) Start() error {
 	s.logger.Info("starting metrics server",
-		"port", s.cfg.Port,
+		"addr", s.httpServer.Addr,
 		"metrics_path", s.cfg.MetricsPath,
 		"health_path", s.cfg.HealthPath,
+		"stream_path", s.cfg.StreamPath,
 	)
 
 	go func() {
@@ -110,9 +123,17 @@ func (s *Server) Start() error {
 	return nil
 }
 
-// Shutdown gracefully shuts down the server.
+// Hub returns the state stream's broadcast hub, or nil when the stream is
+// disabled.
+func (s *Server) Hub() *Hub {
+	return s.hub
+}
+
+// Shutdown gracefully shuts down the server. Stream clients are hijacked
+// connections the HTTP server no longer tracks, so the hub closes them.
 func (s *Server) Shutdown(ctx context.Context) error {
 	s.logger.Info("shutting down metrics server")
+	s.hub.Close()
 	return s.httpServer.Shutdown(ctx)
 }
 
@@ -128,10 +149,16 @@ func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 	checks := make(map[string]Check)
 	overallStatus := "healthy"
 
+	// Any check short of healthy fails the endpoint; the body tells a
+	// degraded bot from an unhealthy one
 	for name, checker := range checkers {
 		check := checker()
 		checks[name] = check
-		if check.Status != "healthy" {
+		switch {
+		case check.Status == "healthy":
+		case check.Status == "degraded" && overallStatus == "healthy":
+			overallStatus = "degraded"
+		case check.Status != "degraded":
 			overallStatus = "unhealthy"
 		}
 	}
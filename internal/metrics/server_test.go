@@ -12,6 +12,9 @@ import (
 func TestDefaultServerConfig(t *testing.T) {
 	cfg := DefaultServerConfig()
 
+	if cfg.Host != "127.0.0.1" {
+		t.Errorf("Host = %s, want 127.0.0.1", cfg.Host)
+	}
 	if cfg.Port != 9090 {
 		t.Errorf("Port = %d, want 9090", cfg.Port)
 	}
@@ -86,6 +89,42 @@ func TestServer_HealthHandler_Unhealthy(t *testing.T) {
 	}
 }
 
+func TestServer_HealthHandler_Degraded(t *testing.T) {
+	server := NewServer(DefaultServerConfig(), nil)
+
+	// Degraded checks fail the probe and are named in the body
+	server.RegisterHealthCheck("healthy", func() Check {
+		return Check{Status: "healthy"}
+	})
+	server.RegisterHealthCheck("slow", func() Check {
+		return Check{Status: "degraded", Message: "market data stale"}
+	})
+
+	w := httptest.NewRecorder()
+	server.healthHandler(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status code = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	var status HealthStatus
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if status.Status != "degraded" {
+		t.Errorf("status = %s, want degraded", status.Status)
+	}
+
+	// One unhealthy check outranks it
+	server.RegisterHealthCheck("broker", func() Check {
+		return Check{Status: "unhealthy", Message: "broker disconnected"}
+	})
+	w = httptest.NewRecorder()
+	server.healthHandler(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status code = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
+
 func TestServer_ReadyHandler(t *testing.T) {
 	cfg := DefaultServerConfig()
 	server := NewServer(cfg, nil)
@@ -0,0 +1,285 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StreamClientBuffer is how many updates a dashboard client may fall
+// behind before it is disconnected as a slow consumer.
+const StreamClientBuffer = 16
+
+// streamWriteTimeout bounds a single write to a dashboard client.
+const streamWriteTimeout = 5 * time.Second
+
+// WebSocket close codes sent to dashboard clients.
+const (
+	closeNormal          = 1000
+	closeGoingAway       = 1001
+	closeProtocolError   = 1002
+	closePolicyViolation = 1008
+)
+
+// StateUpdate is the live trading state pushed to dashboard clients.
+type StateUpdate struct {
+	Time          time.Time       `json:"time"`
+	Equity        decimal.Decimal `json:"equity"`
+	HighWaterMark decimal.Decimal `json:"high_water_mark"`
+	Drawdown      decimal.Decimal `json:"drawdown"`
+	DailyPL       decimal.Decimal `json:"daily_pl"`
+	SafeMode      bool            `json:"safe_mode"`
+	Positions     []PositionState `json:"positions"`
+	LastSignal    *SignalState    `json:"last_signal,omitempty"`
+}
+
+// PositionState is an open position in a StateUpdate.
+type PositionState struct {
+	Symbol        string          `json:"symbol"`
+	Side          string          `json:"side"`
+	Contracts     int             `json:"contracts"`
+	AvgCost       decimal.Decimal `json:"avg_cost"`
+	MarketPrice   decimal.Decimal `json:"market_price"`
+	UnrealizedPnL decimal.Decimal `json:"unrealized_pnl"`
+}
+
+// SignalState is the latest strategy signal in a StateUpdate.
+type SignalState struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	Symbol    string    `json:"symbol"`
+	Direction string    `json:"direction"`
+	Strategy  string    `json:"strategy"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// Hub broadcasts state updates to WebSocket clients. Updates that only
+// differ in Time are not re-sent, and a client joining gets the latest
+// state straight away. A nil Hub ignores updates, so streaming is optional.
+type Hub struct {
+	logger  *slog.Logger
+	origins []string // Browser origins allowed besides the server's own host
+
+	mu      sync.Mutex
+	clients map[*streamClient]struct{}
+	last    []byte // Latest message, sent to new clients
+	lastKey []byte // Latest message without its time, for change detection
+	closed  bool
+
+	wg sync.WaitGroup // Client writers, waited for by Close
+}
+
+// streamClient is one connected dashboard.
+type streamClient struct {
+	conn   net.Conn
+	send   chan []byte
+	done   chan struct{}
+	once   sync.Once
+	code   int        // Close code, set before done is closed
+	wmu    sync.Mutex // Serializes frame writes
+	remote string
+}
+
+// NewHub creates a broadcast hub. Browsers may connect from pages served
+// by the stream's own host or from one of origins, e.g.
+// "https://dashboard.example.com"; other cross-origin requests are refused.
+func NewHub(logger *slog.Logger, origins ...string) *Hub {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Hub{
+		logger:  logger,
+		origins: origins,
+		clients: make(map[*streamClient]struct{}),
+	}
+}
+
+// Publish sends update to every client if the state changed since the
+// last update. Clients whose buffer is full are disconnected rather than
+// slowing down the caller.
+func (h *Hub) Publish(update StateUpdate) {
+	if h == nil {
+		return
+	}
+
+	msg, err := json.Marshal(update)
+	if err != nil {
+		h.logger.Warn("failed to encode state update", "err", err)
+		return
+	}
+	update.Time = time.Time{}
+	key, _ := json.Marshal(update)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed || bytes.Equal(key, h.lastKey) {
+		return
+	}
+	h.last, h.lastKey = msg, key
+
+	for c := range h.clients {
+		select {
+		case c.send <- msg:
+		default:
+			h.logger.Warn("dashboard client too slow, disconnecting", "remote", c.remote)
+			h.removeLocked(c, closePolicyViolation)
+		}
+	}
+}
+
+// Clients returns the number of connected clients.
+func (h *Hub) Clients() int {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// Close disconnects every client with a going-away close frame and refuses
+// new ones. It returns once the close frames have been written.
+func (h *Hub) Close() {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	h.closed = true
+	for c := range h.clients {
+		h.removeLocked(c, closeGoingAway)
+	}
+	h.mu.Unlock()
+
+	h.wg.Wait()
+}
+
+// ServeHTTP upgrades the request to a WebSocket and streams updates until
+// the client leaves, falls behind or the hub closes.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !originAllowed(r, h.origins) {
+		h.logger.Warn("dashboard stream origin refused", "remote", r.RemoteAddr, "origin", r.Header.Get("Origin"))
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	conn, rw, err := upgradeWebSocket(w, r)
+	if err != nil {
+		h.logger.Debug("dashboard stream upgrade failed", "remote", r.RemoteAddr, "err", err)
+		return
+	}
+	// The server's read/write timeouts would otherwise end the stream
+	_ = conn.SetDeadline(time.Time{})
+
+	c := &streamClient{
+		conn:   conn,
+		send:   make(chan []byte, StreamClientBuffer),
+		done:   make(chan struct{}),
+		remote: r.RemoteAddr,
+	}
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		_ = c.writeFrame(opClose, closePayload(closeGoingAway))
+		_ = conn.Close()
+		return
+	}
+	h.clients[c] = struct{}{}
+	if h.last != nil {
+		c.send <- h.last
+	}
+	clients := len(h.clients)
+	h.wg.Add(1)
+	h.mu.Unlock()
+
+	h.logger.Info("dashboard client connected", "remote", c.remote, "clients", clients)
+
+	go h.readLoop(c, rw.Reader)
+	h.writeLoop(c)
+}
+
+// writeLoop sends queued updates until the client is removed, then sends
+// a close frame and closes the connection.
+func (h *Hub) writeLoop(c *streamClient) {
+	defer h.wg.Done()
+	defer func() { _ = c.conn.Close() }()
+
+	for {
+		select {
+		case msg := <-c.send:
+			if err := c.writeFrame(opText, msg); err != nil {
+				h.logger.Info("dashboard client write failed", "remote", c.remote, "err", err)
+				h.remove(c, closeGoingAway)
+				return
+			}
+		case <-c.done:
+			_ = c.writeFrame(opClose, closePayload(c.code))
+			h.logger.Info("dashboard client disconnected", "remote", c.remote, "code", c.code)
+			return
+		}
+	}
+}
+
+// readLoop answers pings and handles the client's close. Any read error,
+// including the connection closing, removes the client; an unmasked frame
+// is a protocol error.
+func (h *Hub) readLoop(c *streamClient, r io.Reader) {
+	for {
+		opcode, payload, err := readFrame(r)
+		if errors.Is(err, errUnmaskedFrame) {
+			h.logger.Warn("dashboard client sent an unmasked frame", "remote", c.remote)
+			h.remove(c, closeProtocolError)
+			return
+		}
+		if err != nil {
+			h.remove(c, closeGoingAway)
+			return
+		}
+		switch opcode {
+		case opPing:
+			_ = c.writeFrame(opPong, payload[:min(len(payload), maxControlPayload)])
+		case opClose:
+			h.remove(c, closeNormal)
+			return
+		}
+	}
+}
+
+// remove disconnects a client.
+func (h *Hub) remove(c *streamClient, code int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(c, code)
+}
+
+// removeLocked disconnects a client. Must be called with h.mu held.
+func (h *Hub) removeLocked(c *streamClient, code int) {
+	delete(h.clients, c)
+	c.once.Do(func() {
+		c.code = code
+		close(c.done)
+	})
+}
+
+// writeFrame writes one frame with a deadline.
+func (c *streamClient) writeFrame(opcode byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	return writeFrame(c.conn, opcode, payload)
+}
+
+// closePayload encodes a close frame's status code.
+func closePayload(code int) []byte {
+	return binary.BigEndian.AppendUint16(nil, uint16(code))
+}
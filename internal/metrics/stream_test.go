@@ -0,0 +1,334 @@
+package metrics
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// dialStream opens a WebSocket connection to srv and returns a reader for
+// server frames. headers are extra request header lines.
+func dialStream(t *testing.T, srv *httptest.Server, headers ...string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	req := "GET /stream HTTP/1.1\r\n" +
+		"Host: localhost\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+		"Sec-WebSocket-Version: 13\r\n"
+	for _, h := range headers {
+		req += h + "\r\n"
+	}
+	req += "\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatalf("write handshake: %v", err)
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatalf("read handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("handshake status = %d, want 101", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Sec-WebSocket-Accept = %q", got)
+	}
+	return conn, r
+}
+
+// writeClientFrame writes one masked frame, as a browser sends it.
+func writeClientFrame(w io.Writer, opcode byte, payload []byte) error {
+	mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+	frame := []byte{0x80 | opcode, 0x80 | byte(len(payload))}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := w.Write(frame)
+	return err
+}
+
+// readServerFrame reads one unmasked server frame.
+func readServerFrame(r io.Reader) (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	length := int(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = int(binary.BigEndian.Uint64(ext[:]))
+	}
+	payload := make([]byte, length)
+	_, err := io.ReadFull(r, payload)
+	return head[0] & 0x0F, payload, err
+}
+
+// readUpdate reads one text frame and decodes it.
+func readUpdate(t *testing.T, r *bufio.Reader) StateUpdate {
+	t.Helper()
+	opcode, payload, err := readServerFrame(r)
+	if err != nil {
+		t.Fatalf("readServerFrame: %v", err)
+	}
+	if opcode != opText {
+		t.Fatalf("opcode = %#x, want text", opcode)
+	}
+	var update StateUpdate
+	if err := json.Unmarshal(payload, &update); err != nil {
+		t.Fatalf("decode update: %v", err)
+	}
+	return update
+}
+
+func waitForClients(t *testing.T, hub *Hub, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.Clients() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Clients() = %d, want %d", hub.Clients(), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWebsocketAccept(t *testing.T) {
+	// Example from RFC 6455 section 1.3
+	if got := websocketAccept("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("websocketAccept() = %q", got)
+	}
+}
+
+func TestHub_StreamsChangedState(t *testing.T) {
+	hub := NewHub(nil)
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+	defer hub.Close()
+
+	_, r := dialStream(t, srv)
+	waitForClients(t, hub, 1)
+
+	update := StateUpdate{
+		Time:      time.Now(),
+		Equity:    decimal.NewFromInt(10000),
+		Positions: []PositionState{{Symbol: "MES", Side: "LONG", Contracts: 1}},
+	}
+	hub.Publish(update)
+	got := readUpdate(t, r)
+	if !got.Equity.Equal(update.Equity) || len(got.Positions) != 1 || got.Positions[0].Symbol != "MES" {
+		t.Fatalf("update = %+v", got)
+	}
+
+	// Only the time changed: not re-sent. The next frame is the safe-mode change.
+	update.Time = update.Time.Add(time.Minute)
+	hub.Publish(update)
+	update.SafeMode = true
+	hub.Publish(update)
+	if got := readUpdate(t, r); !got.SafeMode {
+		t.Errorf("expected the safe mode update, got %+v", got)
+	}
+
+	// A new client starts with the latest state
+	_, r2 := dialStream(t, srv)
+	if got := readUpdate(t, r2); !got.SafeMode || !got.Equity.Equal(update.Equity) {
+		t.Errorf("new client got %+v, want the latest state", got)
+	}
+}
+
+func TestHub_PingAndClientClose(t *testing.T) {
+	hub := NewHub(nil)
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+	defer hub.Close()
+
+	conn, r := dialStream(t, srv)
+	waitForClients(t, hub, 1)
+
+	if err := writeClientFrame(conn, opPing, []byte("hi")); err != nil {
+		t.Fatalf("write ping: %v", err)
+	}
+	opcode, payload, err := readServerFrame(r)
+	if err != nil || opcode != opPong || string(payload) != "hi" {
+		t.Fatalf("got opcode %#x payload %q err %v, want pong", opcode, payload, err)
+	}
+
+	if err := writeClientFrame(conn, opClose, closePayload(closeNormal)); err != nil {
+		t.Fatalf("write close: %v", err)
+	}
+	opcode, payload, err = readServerFrame(r)
+	if err != nil || opcode != opClose || binary.BigEndian.Uint16(payload) != closeNormal {
+		t.Fatalf("got opcode %#x payload %v err %v, want close 1000", opcode, payload, err)
+	}
+	waitForClients(t, hub, 0)
+}
+
+func TestHub_SlowConsumerDisconnected(t *testing.T) {
+	hub := NewHub(nil)
+
+	// A client that never drains its queue
+	slow := &streamClient{send: make(chan []byte, StreamClientBuffer), done: make(chan struct{})}
+	hub.clients[slow] = struct{}{}
+
+	for i := 0; i <= StreamClientBuffer; i++ {
+		hub.Publish(StateUpdate{Equity: decimal.NewFromInt(int64(10000 + i))})
+	}
+
+	select {
+	case <-slow.done:
+	default:
+		t.Fatal("slow client should have been disconnected")
+	}
+	if slow.code != closePolicyViolation {
+		t.Errorf("close code = %d, want %d", slow.code, closePolicyViolation)
+	}
+	if hub.Clients() != 0 {
+		t.Errorf("Clients() = %d, want 0", hub.Clients())
+	}
+}
+
+func TestHub_CloseDisconnectsClients(t *testing.T) {
+	hub := NewHub(nil)
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+
+	_, r := dialStream(t, srv)
+	waitForClients(t, hub, 1)
+
+	hub.Close()
+	opcode, payload, err := readServerFrame(r)
+	if err != nil || opcode != opClose || binary.BigEndian.Uint16(payload) != closeGoingAway {
+		t.Fatalf("got opcode %#x payload %v err %v, want close 1001", opcode, payload, err)
+	}
+
+	// New clients are turned away once closed
+	_, r2 := dialStream(t, srv)
+	if opcode, _, err := readServerFrame(r2); err != nil || opcode != opClose {
+		t.Errorf("got opcode %#x err %v, want close", opcode, err)
+	}
+
+	// Publishing after close is a no-op
+	hub.Publish(StateUpdate{Equity: decimal.NewFromInt(1)})
+}
+
+func TestHub_RejectsPlainHTTP(t *testing.T) {
+	hub := NewHub(nil)
+	w := httptest.NewRecorder()
+	hub.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+	if w.Code != http.StatusUpgradeRequired {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUpgradeRequired)
+	}
+}
+
+func TestHub_RejectsUnmaskedFrames(t *testing.T) {
+	hub := NewHub(nil)
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+	defer hub.Close()
+
+	conn, r := dialStream(t, srv)
+	waitForClients(t, hub, 1)
+
+	if err := writeFrame(conn, opPing, []byte("hi")); err != nil {
+		t.Fatalf("write ping: %v", err)
+	}
+	opcode, payload, err := readServerFrame(r)
+	if err != nil || opcode != opClose || binary.BigEndian.Uint16(payload) != closeProtocolError {
+		t.Fatalf("got opcode %#x payload %v err %v, want close 1002", opcode, payload, err)
+	}
+	waitForClients(t, hub, 0)
+}
+
+func TestHub_CheckOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  string
+		allowed []string
+		want    bool
+	}{
+		{"no origin", "", nil, true},
+		{"same host", "http://localhost", nil, true},
+		{"other site", "https://evil.example", nil, false},
+		{"allow-listed", "https://dash.example.com", []string{"https://dash.example.com"}, true},
+		{"not allow-listed", "https://evil.example", []string{"https://dash.example.com"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+			req.Host = "localhost"
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+			req.Header.Set("Sec-WebSocket-Version", "13")
+			req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			if got := originAllowed(req, tt.allowed); got != tt.want {
+				t.Errorf("originAllowed() = %v, want %v", got, tt.want)
+			}
+			if !tt.want {
+				w := httptest.NewRecorder()
+				NewHub(nil, tt.allowed...).ServeHTTP(w, req)
+				if w.Code != http.StatusForbidden {
+					t.Errorf("status = %d, want 403", w.Code)
+				}
+			}
+		})
+	}
+
+	// An allowed origin completes the handshake end to end
+	hub := NewHub(nil, "https://dash.example.com")
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+	defer hub.Close()
+	dialStream(t, srv, "Origin: https://dash.example.com")
+	waitForClients(t, hub, 1)
+}
+
+func TestServer_StreamOptional(t *testing.T) {
+	if hub := NewServer(DefaultServerConfig(), nil).Hub(); hub != nil {
+		t.Error("stream should be disabled without a StreamPath")
+	}
+
+	// A nil hub ignores updates
+	var hub *Hub
+	hub.Publish(StateUpdate{})
+	hub.Close()
+
+	cfg := DefaultServerConfig()
+	cfg.StreamPath = "/stream"
+	if NewServer(cfg, nil).Hub() == nil {
+		t.Error("expected a hub when StreamPath is set")
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"bufio"
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -20,6 +23,14 @@ type BacktestFeed struct {
 	symbol   string
 	events   []types.MarketEvent
 	loaded   bool
+	rowErr   error // Malformed row that truncated the data
+
+	backAdjust bool         // Remove roll gaps on load
+	skipBad    bool         // Skip bars with inconsistent OHLC instead of stopping
+	logger     *slog.Logger // Warns about skipped bars
+
+	errMu sync.Mutex
+	err   error // Reported via Err once the stream ends
 }
 
 // NewBacktestFeed creates a new backtest feed from a CSV file.
@@ -32,20 +43,43 @@ func NewBacktestFeed(filePath, symbol string) *BacktestFeed {
 	}
 }
 
+// SetBackAdjust enables back-adjusting quarterly roll gaps when the file is
+// loaded. Use it for continuous, unadjusted index futures series.
+func (f *BacktestFeed) SetBackAdjust(enabled bool) {
+	f.backAdjust = enabled
+	f.loaded = false
+}
+
+// SetSkipInvalidBars makes the feed drop bars that fail OHLC validation,
+// logging a warning with the line number, instead of ending the stream at
+// the first one. Rows that cannot be parsed still end the stream.
+func (f *BacktestFeed) SetSkipInvalidBars(skip bool, logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	f.skipBad = skip
+	f.logger = logger
+	f.loaded = false
+}
+
 // Subscribe starts sending historical market events.
 // The channel will close when all data has been sent or context is cancelled.
+// If the file has a malformed row, the rows before it are sent and Err
+// reports the row once the channel closes.
 func (f *BacktestFeed) Subscribe(ctx context.Context, symbol string) (<-chan types.MarketEvent, error) {
 	if !f.loaded {
 		if err := f.load(); err != nil {
 			return nil, err
 		}
 	}
+	f.setErr(nil)
 
 	ch := make(chan types.MarketEvent, 100)
+	events, rowErr := f.events, f.rowErr
 
 	go func() {
 		defer close(ch)
-		for _, event := range f.events {
+		for _, event := range events {
 			// Empty symbol means match all, otherwise filter by symbol
 			if symbol != "" && event.Symbol != symbol {
 				continue
@@ -56,14 +90,30 @@ func (f *BacktestFeed) Subscribe(ctx context.Context, symbol string) (<-chan typ
 			case ch <- event:
 			}
 		}
+		f.setErr(rowErr)
 	}()
 
 	return ch, nil
 }
 
+// Err returns the malformed-row error that ended the last stream early,
+// or nil if every row was sent.
+func (f *BacktestFeed) Err() error {
+	f.errMu.Lock()
+	defer f.errMu.Unlock()
+	return f.err
+}
+
+func (f *BacktestFeed) setErr(err error) {
+	f.errMu.Lock()
+	f.err = err
+	f.errMu.Unlock()
+}
+
 // Close releases resources.
 func (f *BacktestFeed) Close() error {
 	f.events = nil
+	f.rowErr = nil
 	f.loaded = false
 	return nil
 }
@@ -81,12 +131,25 @@ func (f *BacktestFeed) load() error {
 	}
 	defer func() { _ = file.Close() }()
 
-	events, err := ParseCSV(file, f.symbol)
-	if err != nil {
+	var skipBar func(line int, err error)
+	if f.skipBad {
+		skipBar = func(line int, err error) {
+			f.logger.Warn("skipping invalid bar", "file", f.filePath, "line", line, "err", err)
+		}
+	}
+
+	// Keep the rows before a malformed one; Subscribe reports it at the end
+	events, err := parseCSV(file, f.symbol, true, skipBar)
+	if err != nil && !errors.Is(err, types.ErrInvalidData) {
 		return fmt.Errorf("parse csv: %w", err)
 	}
 
+	if f.backAdjust {
+		events = BackAdjust(events, DetectRolls(events))
+	}
+
 	f.events = events
+	f.rowErr = err
 	f.loaded = true
 	return nil
 }
@@ -96,11 +159,38 @@ func (f *BacktestFeed) EventCount() int {
 	return len(f.events)
 }
 
+// EstimatedBars loads the file if needed and returns its bar count.
+// Subscribe reuses the loaded bars, so the file is read once. A file that
+// can't be loaded gives (0, false); Subscribe reports the error.
+func (f *BacktestFeed) EstimatedBars() (int, bool) {
+	if !f.loaded {
+		if err := f.load(); err != nil {
+			return 0, false
+		}
+	}
+	return len(f.events), true
+}
+
 // ParseCSV parses market data from a CSV reader.
 // Supports formats:
 // - timestamp,open,high,low,close,volume
 // - timestamp,open,high,low,close,volume (with header row)
 func ParseCSV(r io.Reader, symbol string) ([]types.MarketEvent, error) {
+	return parseCSV(r, symbol, false, nil)
+}
+
+// ParseCSVStrict is like ParseCSV but stops at the first malformed row
+// instead of skipping it. It returns the events before that row along
+// with an error wrapping types.ErrInvalidData that names the line. Bars
+// whose prices fail ValidateBar count as malformed.
+func ParseCSVStrict(r io.Reader, symbol string) ([]types.MarketEvent, error) {
+	return parseCSV(r, symbol, true, nil)
+}
+
+// parseCSV reads bars, skipping or stopping at malformed rows. If skipBar
+// is set, bars that parse but fail ValidateBar are passed to it and
+// skipped even in strict mode.
+func parseCSV(r io.Reader, symbol string, strict bool, skipBar func(line int, err error)) ([]types.MarketEvent, error) {
 	reader := csv.NewReader(bufio.NewReader(r))
 	reader.TrimLeadingSpace = true
 
@@ -113,6 +203,9 @@ func ParseCSV(r io.Reader, symbol string) ([]types.MarketEvent, error) {
 			break
 		}
 		if err != nil {
+			if strict {
+				return events, fmt.Errorf("%w: %v", types.ErrInvalidData, err)
+			}
 			return nil, fmt.Errorf("line %d: %w", lineNum, err)
 		}
 		lineNum++
@@ -123,15 +216,34 @@ func ParseCSV(r io.Reader, symbol string) ([]types.MarketEvent, error) {
 		}
 
 		if len(record) < 5 {
+			if strict {
+				line, _ := reader.FieldPos(0)
+				return events, fmt.Errorf("%w: line %d: expected at least 5 columns, got %d", types.ErrInvalidData, line, len(record))
+			}
 			continue // Skip invalid rows
 		}
 
 		event, err := parseRecord(record, symbol)
 		if err != nil {
+			if strict {
+				line, _ := reader.FieldPos(0)
+				return events, fmt.Errorf("%w: line %d: %v", types.ErrInvalidData, line, err)
+			}
 			// Skip invalid rows instead of failing
 			continue
 		}
 
+		if err := ValidateBar(event); err != nil {
+			line, _ := reader.FieldPos(0)
+			switch {
+			case skipBar != nil:
+				skipBar(line, err)
+			case strict:
+				return events, fmt.Errorf("%w: line %d: %v", types.ErrInvalidData, line, err)
+			}
+			continue
+		}
+
 		events = append(events, event)
 	}
 
@@ -179,9 +291,39 @@ func parseRecord(record []string, symbol string) (types.MarketEvent, error) {
 		}
 	}
 
+	// Parse bid/ask (optional, written by MarketRecorder)
+	if len(record) > 7 {
+		if bid, err := decimal.NewFromString(record[6]); err == nil {
+			event.Bid = bid
+		}
+		if ask, err := decimal.NewFromString(record[7]); err == nil {
+			event.Ask = ask
+		}
+	}
+
 	return event, nil
 }
 
+// ValidateBar checks that a bar's prices are all positive and that open
+// and close lie within the high-low range. Stop and target checks assume
+// both, so a close-only tick with zero open, high and low is invalid too.
+func ValidateBar(event types.MarketEvent) error {
+	if !event.Open.IsPositive() || !event.High.IsPositive() || !event.Low.IsPositive() || !event.Close.IsPositive() {
+		return fmt.Errorf("prices must be positive: open %s high %s low %s close %s",
+			event.Open, event.High, event.Low, event.Close)
+	}
+	if event.High.LessThan(event.Low) {
+		return fmt.Errorf("high %s below low %s", event.High, event.Low)
+	}
+	if event.Open.LessThan(event.Low) || event.Open.GreaterThan(event.High) {
+		return fmt.Errorf("open %s outside low %s - high %s", event.Open, event.Low, event.High)
+	}
+	if event.Close.LessThan(event.Low) || event.Close.GreaterThan(event.High) {
+		return fmt.Errorf("close %s outside low %s - high %s", event.Close, event.Low, event.High)
+	}
+	return nil
+}
+
 // parseTimestamp tries multiple timestamp formats.
 func parseTimestamp(s string) (time.Time, error) {
 	// Try Unix timestamp first
@@ -191,6 +333,7 @@ func parseTimestamp(s string) (time.Time, error) {
 
 	// Try common date formats
 	formats := []string{
+		time.RFC3339Nano, // Market recordings
 		"2006-01-02 15:04:05",
 		"2006-01-02T15:04:05",
 		"2006-01-02T15:04:05Z",
@@ -277,6 +420,11 @@ func (f *MemoryFeed) Name() string {
 	return "memory"
 }
 
+// EstimatedBars returns the number of events held.
+func (f *MemoryFeed) EstimatedBars() (int, bool) {
+	return len(f.events), true
+}
+
 // AddEvent adds an event to the feed.
 func (f *MemoryFeed) AddEvent(event types.MarketEvent) {
 	f.events = append(f.events, event)
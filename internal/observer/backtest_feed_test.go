@@ -2,6 +2,8 @@ package observer
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -123,6 +125,43 @@ func TestBacktestFeed_EventCount(t *testing.T) {
 	}
 }
 
+// TestBacktestFeed_EstimatedBars tests the bar estimate loads the file once.
+func TestBacktestFeed_EstimatedBars(t *testing.T) {
+	path := createTempCSV(t, `timestamp,open,high,low,close,volume
+2024-01-01 09:30:00,5000.25,5010.50,4990.00,5005.75,1000
+2024-01-01 09:35:00,5005.75,5015.00,5000.00,5010.25,1200
+2024-01-01 09:40:00,5010.25,5012.00,5001.00,5003.50,900
+`)
+	defer os.Remove(path)
+
+	feed := NewBacktestFeed(path, "MES")
+	bars, ok := EstimateBars(feed)
+	if !ok || bars != 3 {
+		t.Fatalf("EstimateBars() = %d, %v; want 3, true", bars, ok)
+	}
+
+	// Subscribe sends the bars already loaded, even if the file is gone
+	os.Remove(path)
+	ch, err := feed.Subscribe(context.Background(), "MES")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	count := 0
+	for range ch {
+		count++
+	}
+	if count != 3 {
+		t.Errorf("received %d bars, want 3", count)
+	}
+
+	if _, ok := NewBacktestFeed("missing.csv", "MES").EstimatedBars(); ok {
+		t.Error("expected no estimate for a missing file")
+	}
+	if _, ok := EstimateBars(newMockFeed(nil)); ok {
+		t.Error("expected no estimate for a feed without BarEstimator")
+	}
+}
+
 // TestParseCSV_ValidData tests CSV parsing (MD-01 related).
 func TestParseCSV_ValidData(t *testing.T) {
 	csvData := `timestamp,open,high,low,close,volume
@@ -318,6 +357,134 @@ func TestBacktestFeed_Subscribe_ContextCancelled(t *testing.T) {
 	}
 }
 
+// TestParseCSVStrict_StopsAtMalformedRow tests that strict parsing reports the bad line.
+func TestParseCSVStrict_StopsAtMalformedRow(t *testing.T) {
+	csvData := `timestamp,open,high,low,close,volume
+2024-01-01 09:30:00,5000,5010,4990,5005,1000
+2024-01-01 09:35:00,5005,5015,5000,5010,1200
+2024-01-01 09:40:00,5010,bad,5005,5015,900
+2024-01-01 09:45:00,5015,5025,5010,5020,1100
+`
+	events, err := ParseCSVStrict(strings.NewReader(csvData), "MES")
+	if !errors.Is(err, types.ErrInvalidData) {
+		t.Fatalf("err = %v, want ErrInvalidData", err)
+	}
+	if !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("err = %v, want it to name line 4", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("expected 2 events before the bad row, got %d", len(events))
+	}
+}
+
+// TestBacktestFeed_Err tests that a malformed row is reported after the stream ends.
+func TestBacktestFeed_Err(t *testing.T) {
+	tmpFile := createTempCSV(t, `timestamp,open,high,low,close,volume
+2024-01-01 09:30:00,5000,5010,4990,5005,1000
+2024-01-01 09:35:00,5005,5015,5000
+2024-01-01 09:40:00,5010,5020,5005,5015,900
+`)
+	defer os.Remove(tmpFile)
+
+	feed := NewBacktestFeed(tmpFile, "MES")
+	ch, err := feed.Subscribe(context.Background(), "MES")
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	received := 0
+	for range ch {
+		received++
+	}
+
+	if received != 1 {
+		t.Errorf("expected 1 event before the bad row, got %d", received)
+	}
+	if err := feed.Err(); !errors.Is(err, types.ErrInvalidData) {
+		t.Errorf("Err() = %v, want ErrInvalidData", err)
+	}
+}
+
+// TestBacktestFeed_InvalidBars tests that a bar with inconsistent OHLC ends
+// the stream by default and is skipped when SetSkipInvalidBars is on.
+func TestBacktestFeed_InvalidBars(t *testing.T) {
+	tmpFile := createTempCSV(t, `timestamp,open,high,low,close,volume
+2024-01-01 09:30:00,5000,5010,4990,5005,1000
+2024-01-01 09:35:00,5005,4995,5000,5010,1200
+2024-01-01 09:40:00,5010,5020,5005,5015,900
+`)
+	defer os.Remove(tmpFile)
+
+	tests := []struct {
+		name         string
+		skip         bool
+		wantReceived int
+		wantErr      bool
+	}{
+		{"error", false, 1, true},
+		{"skip with warning", true, 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := NewBacktestFeed(tmpFile, "MES")
+			feed.SetSkipInvalidBars(tt.skip, slog.New(slog.DiscardHandler))
+
+			ch, err := feed.Subscribe(context.Background(), "MES")
+			if err != nil {
+				t.Fatalf("failed to subscribe: %v", err)
+			}
+			received := 0
+			for range ch {
+				received++
+			}
+
+			if received != tt.wantReceived {
+				t.Errorf("received %d events, want %d", received, tt.wantReceived)
+			}
+			err = feed.Err()
+			if tt.wantErr && (!errors.Is(err, types.ErrInvalidData) || !strings.Contains(err.Error(), "line 3")) {
+				t.Errorf("Err() = %v, want ErrInvalidData naming line 3", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Err() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestValidateBar(t *testing.T) {
+	bar := func(o, h, l, c int64) types.MarketEvent {
+		return types.MarketEvent{
+			Open:  decimal.NewFromInt(o),
+			High:  decimal.NewFromInt(h),
+			Low:   decimal.NewFromInt(l),
+			Close: decimal.NewFromInt(c),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		event   types.MarketEvent
+		wantErr bool
+	}{
+		{"valid", bar(5000, 5010, 4990, 5005), false},
+		{"high below low", bar(5000, 4990, 5010, 5000), true},
+		{"close above high", bar(5000, 5010, 4990, 5011), true},
+		{"open below low", bar(4989, 5010, 4990, 5000), true},
+		{"non-positive price", bar(5000, 5010, -1, 5000), true},
+		{"zero open", bar(0, 5010, 4990, 5000), true},
+		{"close-only tick", types.MarketEvent{Close: decimal.NewFromInt(5000)}, true},
+		{"size-only tick", types.MarketEvent{Volume: 10}, true},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateBar(tt.event); (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateBar() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
 // Helper to create temp CSV file.
 func createTempCSV(t *testing.T, content string) string {
 	t.Helper()
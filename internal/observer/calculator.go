@@ -11,6 +11,10 @@ type CalculatorConfig struct {
 	ATRPeriod    int // Period for ATR calculation
 	StdDevPeriod int // Period for StdDev calculation
 	SMAPeriod    int // Period for SMA calculation (optional)
+	VWAPPeriod   int // Period for VWAP calculation (optional)
+
+	// How VWAP treats bars with zero volume (default: skip the bar)
+	ZeroVolumePolicy indicator.ZeroVolumePolicy
 }
 
 // DefaultCalculatorConfig returns sensible defaults.
@@ -19,6 +23,7 @@ func DefaultCalculatorConfig() CalculatorConfig {
 		ATRPeriod:    14,
 		StdDevPeriod: 20,
 		SMAPeriod:    20,
+		VWAPPeriod:   20,
 	}
 }
 
@@ -28,8 +33,15 @@ type Calculator struct {
 	atr    *indicator.ATR
 	stddev *indicator.StdDev
 	sma    *indicator.SMA
+	vwap   *indicator.VWAP
 }
 
+// Calculator implements Indicators and IndicatorCalculator.
+var (
+	_ Indicators          = (*Calculator)(nil)
+	_ IndicatorCalculator = (*Calculator)(nil)
+)
+
 // NewCalculator creates a new indicator calculator.
 func NewCalculator(cfg CalculatorConfig) *Calculator {
 	return &Calculator{
@@ -37,6 +49,7 @@ func NewCalculator(cfg CalculatorConfig) *Calculator {
 		atr:    indicator.NewATR(cfg.ATRPeriod),
 		stddev: indicator.NewStdDev(cfg.StdDevPeriod),
 		sma:    indicator.NewSMA(cfg.SMAPeriod),
+		vwap:   indicator.NewVWAP(cfg.VWAPPeriod, cfg.ZeroVolumePolicy),
 	}
 }
 
@@ -52,6 +65,9 @@ func (c *Calculator) OnBar(event types.MarketEvent) types.MarketEvent {
 	// Update SMA with close price
 	c.sma.Update(event.Close)
 
+	// Update VWAP with typical price and volume
+	c.vwap.Update(event.High, event.Low, event.Close, event.Volume)
+
 	// Enrich event with indicators
 	event.ATR = atr
 	event.StdDev = stddev
@@ -64,6 +80,7 @@ func (c *Calculator) Reset() {
 	c.atr.Reset()
 	c.stddev.Reset()
 	c.sma.Reset()
+	c.vwap.Reset()
 }
 
 // Ready returns true if all indicators have enough data.
@@ -85,3 +102,8 @@ func (c *Calculator) CurrentStdDev() decimal.Decimal {
 func (c *Calculator) CurrentSMA() decimal.Decimal {
 	return c.sma.Current()
 }
+
+// CurrentVWAP returns the current VWAP value.
+func (c *Calculator) CurrentVWAP() decimal.Decimal {
+	return c.vwap.Current()
+}
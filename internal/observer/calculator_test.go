@@ -6,6 +6,7 @@ import (
 
 	"github.com/shopspring/decimal"
 	"github.com/tathienbao/quant-bot/internal/types"
+	"github.com/tathienbao/quant-bot/pkg/indicator"
 )
 
 // TestNewCalculator tests calculator constructor.
@@ -223,6 +224,44 @@ func TestCalculator_CurrentSMA(t *testing.T) {
 	}
 }
 
+// TestCalculator_CurrentVWAP_ZeroVolume tests the per-calculator zero-volume policy.
+func TestCalculator_CurrentVWAP_ZeroVolume(t *testing.T) {
+	events := []types.MarketEvent{
+		{High: decimal.NewFromInt(100), Low: decimal.NewFromInt(100), Close: decimal.NewFromInt(100), Volume: 100},
+		{High: decimal.NewFromInt(110), Low: decimal.NewFromInt(110), Close: decimal.NewFromInt(110), Volume: 0},
+		{High: decimal.NewFromInt(120), Low: decimal.NewFromInt(120), Close: decimal.NewFromInt(120), Volume: 0},
+	}
+
+	tests := []struct {
+		name   string
+		period int
+		policy indicator.ZeroVolumePolicy
+		want   decimal.Decimal
+	}{
+		// Zero-volume bars ignored: only the 100 bar counts
+		{"skip", 1, indicator.ZeroVolumeSkip, decimal.NewFromInt(100)},
+		// Last two bars weighted 1 each: (110 + 120) / 2
+		{"unit", 2, indicator.ZeroVolumeUnit, decimal.NewFromInt(115)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultCalculatorConfig()
+			cfg.VWAPPeriod = tt.period
+			cfg.ZeroVolumePolicy = tt.policy
+			calc := NewCalculator(cfg)
+
+			for _, event := range events {
+				calc.OnBar(event)
+			}
+
+			if got := calc.CurrentVWAP(); !got.Equal(tt.want) {
+				t.Errorf("expected VWAP=%s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
 // TestDefaultCalculatorConfig tests default configuration.
 func TestDefaultCalculatorConfig(t *testing.T) {
 	cfg := DefaultCalculatorConfig()
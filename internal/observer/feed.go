@@ -4,6 +4,7 @@ package observer
 import (
 	"context"
 
+	"github.com/shopspring/decimal"
 	"github.com/tathienbao/quant-bot/internal/types"
 )
 
@@ -22,6 +23,62 @@ type MarketDataFeed interface {
 	Name() string
 }
 
+// ErrorReporter is implemented by feeds that can stop early on bad data.
+// Err returns the error that ended the stream, or nil if the feed finished
+// normally. It is only meaningful once the event channel has been closed.
+type ErrorReporter interface {
+	Err() error
+}
+
+// BarEstimator is implemented by feeds that know how many bars they will
+// send, so progress can be shown without reading the data twice.
+// EstimatedBars returns false when the feed can't tell.
+type BarEstimator interface {
+	EstimatedBars() (int, bool)
+}
+
+// EstimateBars returns the feed's bar estimate, or (0, false) when the
+// feed doesn't implement BarEstimator or can't estimate.
+func EstimateBars(feed MarketDataFeed) (int, bool) {
+	estimator, ok := feed.(BarEstimator)
+	if !ok {
+		return 0, false
+	}
+	return estimator.EstimatedBars()
+}
+
+// LastBars reads feed to the end and returns its last n events for symbol,
+// oldest first (all of them when n is 0). It fails if the feed stopped on
+// bad data.
+func LastBars(ctx context.Context, feed MarketDataFeed, symbol string, n int) ([]types.MarketEvent, error) {
+	events, err := feed.Subscribe(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	var bars []types.MarketEvent
+	for event := range events {
+		bars = append(bars, event)
+		if n > 0 && len(bars) > 2*n {
+			// Keep memory bounded on long files
+			bars = append(bars[:0], bars[len(bars)-n:]...)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if reporter, ok := feed.(ErrorReporter); ok {
+		if err := reporter.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if n > 0 && len(bars) > n {
+		bars = bars[len(bars)-n:]
+	}
+	return bars, nil
+}
+
 // IndicatorCalculator calculates technical indicators on market data.
 type IndicatorCalculator interface {
 	// OnBar processes a new bar and updates indicators.
@@ -32,6 +89,21 @@ type IndicatorCalculator interface {
 	Reset()
 }
 
+// Indicators updates indicators bar by bar and exposes their latest
+// values. The engine depends on it rather than on Calculator so tests can
+// supply fixed values without warming up real indicators.
+type Indicators interface {
+	// OnBar processes a new bar and updates indicators.
+	// Returns the updated MarketEvent with calculated indicators.
+	OnBar(event types.MarketEvent) types.MarketEvent
+
+	// CurrentATR returns the latest ATR value.
+	CurrentATR() decimal.Decimal
+
+	// CurrentVWAP returns the latest VWAP value.
+	CurrentVWAP() decimal.Decimal
+}
+
 // Observer combines a data feed with indicator calculations.
 type Observer struct {
 	feed       MarketDataFeed
@@ -223,3 +223,33 @@ func TestObserver_Subscribe_WithEnrichment(t *testing.T) {
 		t.Errorf("expected ATR=10, got %s", event.ATR.String())
 	}
 }
+
+func TestLastBars(t *testing.T) {
+	start := time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+	events := make([]types.MarketEvent, 25)
+	for i := range events {
+		events[i] = types.MarketEvent{
+			Symbol:    "MES",
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Close:     decimal.NewFromInt(int64(5000 + i)),
+		}
+	}
+
+	bars, err := LastBars(context.Background(), NewMemoryFeed(events, "MES"), "MES", 4)
+	if err != nil {
+		t.Fatalf("LastBars() error = %v", err)
+	}
+	if len(bars) != 4 {
+		t.Fatalf("len = %d, want 4", len(bars))
+	}
+	for i, bar := range bars {
+		if want := events[21+i].Timestamp; !bar.Timestamp.Equal(want) {
+			t.Errorf("bars[%d] at %s, want %s", i, bar.Timestamp, want)
+		}
+	}
+
+	all, err := LastBars(context.Background(), NewMemoryFeed(events, "MES"), "MES", 0)
+	if err != nil || len(all) != len(events) {
+		t.Errorf("LastBars(n=0) = %d bars, %v; want all %d", len(all), err, len(events))
+	}
+}
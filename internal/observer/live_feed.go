@@ -0,0 +1,247 @@
+package observer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// TickSource provides raw tick updates for a symbol.
+// broker.Broker implementations (e.g. the IBKR client) satisfy this interface.
+type TickSource interface {
+	SubscribeMarketData(ctx context.Context, symbol string) (<-chan types.MarketEvent, error)
+	UnsubscribeMarketData(symbol string) error
+}
+
+// BarAggregator builds fixed-timeframe OHLCV bars from ticks.
+//
+// Ticks are MarketEvents as published by live brokers: a non-zero Close is a
+// last-trade price, a non-zero Volume is the cumulative session volume.
+// Session high/low ticks (Close zero) are ignored since they don't describe
+// the current bar. Bars are aligned to clock boundaries of the timeframe.
+type BarAggregator struct {
+	symbol    string
+	timeframe time.Duration
+
+	bar        types.MarketEvent
+	barStart   time.Time
+	hasBar     bool
+	closedAt   time.Time       // End of the last flushed bar period
+	lastVolume int64           // Last cumulative volume seen
+	barVolume  int64           // Cumulative volume at bar start
+	bid, ask   decimal.Decimal // Latest quote seen (zero if the feed has none)
+}
+
+// NewBarAggregator creates a bar aggregator for a symbol and timeframe.
+func NewBarAggregator(symbol string, timeframe time.Duration) *BarAggregator {
+	return &BarAggregator{
+		symbol:    symbol,
+		timeframe: timeframe,
+	}
+}
+
+// OnTick processes a tick. It returns the completed bar and true when the
+// tick belongs to a new bar period.
+func (a *BarAggregator) OnTick(tick types.MarketEvent) (types.MarketEvent, bool) {
+	if tick.Volume > 0 {
+		a.lastVolume = tick.Volume
+	}
+	if tick.Bid.IsPositive() {
+		a.bid = tick.Bid
+	}
+	if tick.Ask.IsPositive() {
+		a.ask = tick.Ask
+	}
+
+	if tick.Close.IsZero() {
+		if a.hasBar {
+			a.bar.Volume = a.volumeSinceBarStart()
+			a.bar.Bid, a.bar.Ask = a.bid, a.ask
+		}
+		return types.MarketEvent{}, false
+	}
+
+	// Late tick for a period that was already flushed on time
+	if tick.Timestamp.Before(a.closedAt) {
+		return types.MarketEvent{}, false
+	}
+
+	start := tick.Timestamp.Truncate(a.timeframe)
+
+	var completed types.MarketEvent
+	var ok bool
+	if a.hasBar && start.After(a.barStart) {
+		completed, ok = a.bar, true
+		a.hasBar = false
+	}
+
+	price := tick.Close
+	if !a.hasBar {
+		a.bar = types.MarketEvent{
+			Symbol:    a.symbol,
+			Timestamp: start,
+			Open:      price,
+			High:      price,
+			Low:       price,
+			Close:     price,
+		}
+		a.barStart = start
+		a.barVolume = a.lastVolume
+		a.hasBar = true
+	} else {
+		a.bar.High = decimal.Max(a.bar.High, price)
+		a.bar.Low = decimal.Min(a.bar.Low, price)
+		a.bar.Close = price
+	}
+	a.bar.Volume = a.volumeSinceBarStart()
+	a.bar.Bid, a.bar.Ask = a.bid, a.ask
+
+	return completed, ok
+}
+
+// FlushIfDue returns the in-progress bar and true if its period has ended at now.
+// This closes bars on time even when no new tick arrives.
+func (a *BarAggregator) FlushIfDue(now time.Time) (types.MarketEvent, bool) {
+	if !a.hasBar || now.Before(a.barStart.Add(a.timeframe)) {
+		return types.MarketEvent{}, false
+	}
+	return a.Flush()
+}
+
+// Flush returns the in-progress bar, if any, and starts a fresh period.
+func (a *BarAggregator) Flush() (types.MarketEvent, bool) {
+	if !a.hasBar {
+		return types.MarketEvent{}, false
+	}
+	a.hasBar = false
+	a.closedAt = a.barStart.Add(a.timeframe)
+	return a.bar, true
+}
+
+// volumeSinceBarStart returns the volume traded since the bar opened.
+func (a *BarAggregator) volumeSinceBarStart() int64 {
+	if a.lastVolume < a.barVolume {
+		// Session volume reset (new trading day)
+		a.barVolume = 0
+	}
+	return a.lastVolume - a.barVolume
+}
+
+// LiveFeed aggregates ticks from a live TickSource into bars.
+type LiveFeed struct {
+	source    TickSource
+	timeframe time.Duration
+	logger    *slog.Logger
+
+	mu      sync.Mutex
+	symbols []string
+
+	now func() time.Time // Wall clock used to close bars on time
+}
+
+// NewLiveFeed creates a bar feed over a live tick source.
+func NewLiveFeed(source TickSource, timeframe time.Duration, logger *slog.Logger) *LiveFeed {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &LiveFeed{
+		source:    source,
+		timeframe: timeframe,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Subscribe starts aggregating ticks for a symbol into bars.
+func (f *LiveFeed) Subscribe(ctx context.Context, symbol string) (<-chan types.MarketEvent, error) {
+	if f.timeframe <= 0 {
+		return nil, fmt.Errorf("%w: live feed timeframe must be positive", types.ErrInvalidTimeframe)
+	}
+
+	ticks, err := f.source.SubscribeMarketData(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe ticks: %w", err)
+	}
+
+	f.mu.Lock()
+	f.symbols = append(f.symbols, symbol)
+	f.mu.Unlock()
+
+	bars := make(chan types.MarketEvent, 100)
+	agg := NewBarAggregator(symbol, f.timeframe)
+
+	go func() {
+		defer close(bars)
+
+		flushTicker := time.NewTicker(time.Second)
+		defer flushTicker.Stop()
+
+		emit := func(bar types.MarketEvent) bool {
+			select {
+			case bars <- bar:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-flushTicker.C:
+				if bar, ok := agg.FlushIfDue(f.now()); ok {
+					if !emit(bar) {
+						return
+					}
+				}
+			case tick, ok := <-ticks:
+				if !ok {
+					// Source ended - emit the partial bar so it isn't lost
+					if bar, ok := agg.Flush(); ok {
+						emit(bar)
+					}
+					return
+				}
+				if bar, ok := agg.OnTick(tick); ok {
+					if !emit(bar) {
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	f.logger.Info("live feed subscribed",
+		"symbol", symbol,
+		"timeframe", f.timeframe,
+	)
+
+	return bars, nil
+}
+
+// Close unsubscribes all symbols from the tick source.
+func (f *LiveFeed) Close() error {
+	f.mu.Lock()
+	symbols := f.symbols
+	f.symbols = nil
+	f.mu.Unlock()
+
+	var firstErr error
+	for _, symbol := range symbols {
+		if err := f.source.UnsubscribeMarketData(symbol); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Name returns the feed name.
+func (f *LiveFeed) Name() string {
+	return "live"
+}
@@ -0,0 +1,236 @@
+package observer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/broker/paper"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// fakeTickSource implements TickSource for testing.
+type fakeTickSource struct {
+	mu           sync.Mutex
+	ch           chan types.MarketEvent
+	unsubscribed []string
+}
+
+func newFakeTickSource() *fakeTickSource {
+	return &fakeTickSource{ch: make(chan types.MarketEvent, 100)}
+}
+
+func (f *fakeTickSource) SubscribeMarketData(ctx context.Context, symbol string) (<-chan types.MarketEvent, error) {
+	return f.ch, nil
+}
+
+func (f *fakeTickSource) UnsubscribeMarketData(symbol string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.unsubscribed = append(f.unsubscribed, symbol)
+	return nil
+}
+
+func (f *fakeTickSource) send(ts time.Time, price int64, volume int64) {
+	f.ch <- types.MarketEvent{Symbol: "MES", Timestamp: ts, Close: decimal.NewFromInt(price)}
+	if volume > 0 {
+		f.ch <- types.MarketEvent{Symbol: "MES", Timestamp: ts, Volume: volume}
+	}
+}
+
+func TestBarAggregator_BuildsOHLCV(t *testing.T) {
+	agg := NewBarAggregator("MES", 5*time.Minute)
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	ticks := []struct {
+		offset time.Duration
+		price  int64
+		volume int64
+	}{
+		{10 * time.Second, 5000, 1000},
+		{1 * time.Minute, 5004, 1020},
+		{3 * time.Minute, 4998, 1040},
+		{4*time.Minute + 59*time.Second, 5001, 1050},
+	}
+
+	for _, tk := range ticks {
+		ts := base.Add(tk.offset)
+		if _, ok := agg.OnTick(types.MarketEvent{Timestamp: ts, Volume: tk.volume}); ok {
+			t.Fatal("volume tick should not complete a bar")
+		}
+		if _, ok := agg.OnTick(types.MarketEvent{Timestamp: ts, Close: decimal.NewFromInt(tk.price)}); ok {
+			t.Fatalf("tick at %s should not complete a bar", tk.offset)
+		}
+	}
+
+	// First tick of the next period closes the bar
+	bar, ok := agg.OnTick(types.MarketEvent{Timestamp: base.Add(5 * time.Minute), Close: decimal.NewFromInt(5002)})
+	if !ok {
+		t.Fatal("expected completed bar")
+	}
+
+	if bar.Symbol != "MES" {
+		t.Errorf("Symbol = %s, want MES", bar.Symbol)
+	}
+	if !bar.Timestamp.Equal(base) {
+		t.Errorf("Timestamp = %v, want %v", bar.Timestamp, base)
+	}
+	if !bar.Open.Equal(decimal.NewFromInt(5000)) {
+		t.Errorf("Open = %s, want 5000", bar.Open)
+	}
+	if !bar.High.Equal(decimal.NewFromInt(5004)) {
+		t.Errorf("High = %s, want 5004", bar.High)
+	}
+	if !bar.Low.Equal(decimal.NewFromInt(4998)) {
+		t.Errorf("Low = %s, want 4998", bar.Low)
+	}
+	if !bar.Close.Equal(decimal.NewFromInt(5001)) {
+		t.Errorf("Close = %s, want 5001", bar.Close)
+	}
+	if bar.Volume != 50 {
+		t.Errorf("Volume = %d, want 50", bar.Volume)
+	}
+}
+
+func TestBarAggregator_FlushIfDue(t *testing.T) {
+	agg := NewBarAggregator("MES", time.Minute)
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	agg.OnTick(types.MarketEvent{Timestamp: base.Add(5 * time.Second), Close: decimal.NewFromInt(5000)})
+
+	if _, ok := agg.FlushIfDue(base.Add(30 * time.Second)); ok {
+		t.Error("bar should not flush before its period ends")
+	}
+
+	bar, ok := agg.FlushIfDue(base.Add(time.Minute))
+	if !ok {
+		t.Fatal("expected bar to flush at period end")
+	}
+	if !bar.Close.Equal(decimal.NewFromInt(5000)) {
+		t.Errorf("Close = %s, want 5000", bar.Close)
+	}
+
+	// Late tick for the flushed period is dropped
+	if _, ok := agg.OnTick(types.MarketEvent{Timestamp: base.Add(59 * time.Second), Close: decimal.NewFromInt(5010)}); ok {
+		t.Error("late tick should not complete a bar")
+	}
+	if _, ok := agg.Flush(); ok {
+		t.Error("late tick should not start a new bar")
+	}
+}
+
+func TestBarAggregator_CarriesLatestQuote(t *testing.T) {
+	agg := NewBarAggregator("MES", time.Minute)
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	agg.OnTick(types.MarketEvent{
+		Timestamp: base,
+		Close:     decimal.NewFromInt(5000),
+		Bid:       decimal.RequireFromString("4999.75"),
+		Ask:       decimal.NewFromInt(5000),
+	})
+	// Volume tick with a newer quote
+	agg.OnTick(types.MarketEvent{
+		Timestamp: base.Add(time.Second),
+		Volume:    10,
+		Bid:       decimal.NewFromInt(5000),
+		Ask:       decimal.RequireFromString("5000.25"),
+	})
+
+	bar, ok := agg.Flush()
+	if !ok {
+		t.Fatal("expected bar")
+	}
+	if !bar.Bid.Equal(decimal.NewFromInt(5000)) || !bar.Ask.Equal(decimal.RequireFromString("5000.25")) {
+		t.Errorf("quote = %s/%s, want 5000/5000.25", bar.Bid, bar.Ask)
+	}
+}
+
+func TestLiveFeed_BarsDrivePaperFills(t *testing.T) {
+	source := newFakeTickSource()
+	feed := NewLiveFeed(source, time.Minute, nil)
+	feed.now = func() time.Time { return time.Time{} } // Close bars on ticks only
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bars, err := feed.Subscribe(ctx, "MES")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	cfg := paper.DefaultConfig()
+	cfg.SlippageTicks = 0
+	cfg.FillDelay = 10 * time.Millisecond
+	pb := paper.NewBroker(cfg, nil)
+	pb.Connect(ctx)
+
+	strategyBars, _ := pb.SubscribeMarketData(ctx, "MES")
+
+	streamed := make(chan int, 1)
+	go func() { streamed <- pb.StreamMarketData(ctx, bars) }()
+
+	// Two one-minute bars of live ticks, then a tick that opens a third
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	source.send(base.Add(5*time.Second), 5000, 0)
+	source.send(base.Add(40*time.Second), 5003, 0)
+	source.send(base.Add(65*time.Second), 5010, 0)
+	source.send(base.Add(100*time.Second), 5012, 0)
+	source.send(base.Add(125*time.Second), 5020, 0)
+
+	// Strategy side sees the aggregated bars via the paper broker
+	for i, wantClose := range []int64{5003, 5012} {
+		select {
+		case bar := <-strategyBars:
+			if !bar.Close.Equal(decimal.NewFromInt(wantClose)) {
+				t.Errorf("bar %d Close = %s, want %d", i, bar.Close, wantClose)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for bar %d", i)
+		}
+	}
+
+	// Order fills at the latest live bar close
+	_, err = pb.PlaceOrder(ctx, types.OrderIntent{
+		ClientOrderID: "live-1",
+		Symbol:        "MES",
+		Side:          types.SideLong,
+		Contracts:     1,
+	})
+	if err != nil {
+		t.Fatalf("PlaceOrder() error = %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	pos, err := pb.GetPosition(ctx, "MES")
+	if err != nil {
+		t.Fatalf("GetPosition() error = %v", err)
+	}
+	if pos == nil {
+		t.Fatal("expected position after live-data fill")
+	}
+	if !pos.AvgCost.Equal(decimal.NewFromInt(5012)) {
+		t.Errorf("AvgCost = %s, want 5012", pos.AvgCost)
+	}
+
+	// Source ends: partial bar is flushed and the stream stops
+	close(source.ch)
+	select {
+	case n := <-streamed:
+		if n != 3 {
+			t.Errorf("bars streamed = %d, want 3", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream did not stop after source closed")
+	}
+
+	if err := feed.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if len(source.unsubscribed) != 1 || source.unsubscribed[0] != "MES" {
+		t.Errorf("unsubscribed = %v, want [MES]", source.unsubscribed)
+	}
+}
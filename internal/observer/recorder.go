@@ -0,0 +1,247 @@
+package observer
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// recorderFlushInterval bounds how much buffered data a crash can lose.
+const recorderFlushInterval = time.Second
+
+// MarketRecorder appends completed bars to a CSV file in the format read by
+// ParseCSV, so a live session can be replayed through BacktestFeed.
+// Timestamps are written in RFC 3339 with nanoseconds.
+// The file holds one symbol; the symbol is supplied again when replaying.
+type MarketRecorder struct {
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	file    *os.File
+	buf     *bufio.Writer
+	csv     *csv.Writer
+	records int64
+	closed  bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewMarketRecorder opens path for appending, writing a header if the file
+// is new. Buffered data is flushed every second and on Close.
+func NewMarketRecorder(path string, logger *slog.Logger) (*MarketRecorder, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create record dir: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open record file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("stat record file: %w", err)
+	}
+
+	buf := bufio.NewWriterSize(file, 64*1024)
+	r := &MarketRecorder{
+		logger: logger,
+		file:   file,
+		buf:    buf,
+		csv:    csv.NewWriter(buf),
+		done:   make(chan struct{}),
+	}
+
+	if info.Size() == 0 {
+		if err := r.csv.Write([]string{"timestamp", "open", "high", "low", "close", "volume", "bid", "ask"}); err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("write header: %w", err)
+		}
+	}
+
+	r.wg.Add(1)
+	go r.flushLoop()
+
+	return r, nil
+}
+
+// Record appends one bar. It never drops bars: a slow disk slows the
+// caller instead. Bars that wouldn't pass ParseCSVStrict on replay, such
+// as raw ticks without a full positive OHLC, are refused.
+func (r *MarketRecorder) Record(event types.MarketEvent) error {
+	if err := ValidateBar(event); err != nil {
+		return fmt.Errorf("record market event at %s: %w", event.Timestamp, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return fmt.Errorf("market recorder closed")
+	}
+
+	err := r.csv.Write([]string{
+		event.Timestamp.UTC().Format(time.RFC3339Nano),
+		event.Open.String(),
+		event.High.String(),
+		event.Low.String(),
+		event.Close.String(),
+		strconv.FormatInt(event.Volume, 10),
+		event.Bid.String(),
+		event.Ask.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("record market event: %w", err)
+	}
+	r.records++
+	return nil
+}
+
+// Flush writes buffered events to the file.
+func (r *MarketRecorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.flushLocked()
+}
+
+func (r *MarketRecorder) flushLocked() error {
+	if r.file == nil {
+		return nil
+	}
+	r.csv.Flush()
+	if err := r.csv.Error(); err != nil {
+		return fmt.Errorf("flush market recorder: %w", err)
+	}
+	if err := r.buf.Flush(); err != nil {
+		return fmt.Errorf("flush market recorder: %w", err)
+	}
+	return nil
+}
+
+// flushLoop periodically flushes buffered events until Close.
+func (r *MarketRecorder) flushLoop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(recorderFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			if err := r.Flush(); err != nil {
+				r.logger.Error("failed to flush market recording", "err", err)
+			}
+		}
+	}
+}
+
+// Close flushes remaining events and closes the file.
+func (r *MarketRecorder) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.done)
+	r.mu.Unlock()
+	r.wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	flushErr := r.flushLocked()
+	closeErr := r.file.Close()
+	r.file = nil
+
+	r.logger.Info("market recording closed", "events", r.records)
+
+	if flushErr != nil {
+		return flushErr
+	}
+	return closeErr
+}
+
+// RecordingFeed wraps a MarketDataFeed and records every bar it delivers
+// before passing it on.
+type RecordingFeed struct {
+	feed     MarketDataFeed
+	recorder *MarketRecorder
+	logger   *slog.Logger
+}
+
+// NewRecordingFeed creates a feed that tees bars to recorder.
+func NewRecordingFeed(feed MarketDataFeed, recorder *MarketRecorder, logger *slog.Logger) *RecordingFeed {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &RecordingFeed{
+		feed:     feed,
+		recorder: recorder,
+		logger:   logger,
+	}
+}
+
+// Subscribe subscribes to the wrapped feed. The returned channel closes
+// when the feed's channel closes or ctx is done.
+func (f *RecordingFeed) Subscribe(ctx context.Context, symbol string) (<-chan types.MarketEvent, error) {
+	in, err := f.feed.Subscribe(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan types.MarketEvent, cap(in))
+	go func() {
+		defer close(out)
+
+		for {
+			var event types.MarketEvent
+			var ok bool
+			select {
+			case event, ok = <-in:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+
+			if err := f.recorder.Record(event); err != nil {
+				f.logger.Error("failed to record market event", "symbol", symbol, "err", err)
+			}
+
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// Close closes the wrapped feed. The recorder is closed by its owner.
+func (f *RecordingFeed) Close() error {
+	return f.feed.Close()
+}
+
+// Name returns the wrapped feed's name.
+func (f *RecordingFeed) Name() string {
+	return f.feed.Name()
+}
@@ -0,0 +1,126 @@
+package observer
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// recordBars streams events through a RecordingFeed into path and returns
+// the bars it forwarded.
+func recordBars(t *testing.T, path string, feed MarketDataFeed) []types.MarketEvent {
+	t.Helper()
+
+	recorder, err := NewMarketRecorder(path, nil)
+	if err != nil {
+		t.Fatalf("NewMarketRecorder() error = %v", err)
+	}
+
+	out, err := NewRecordingFeed(feed, recorder, nil).Subscribe(context.Background(), "MES")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	var forwarded []types.MarketEvent
+	for bar := range out {
+		forwarded = append(forwarded, bar)
+	}
+
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return forwarded
+}
+
+// replayBars reads a recording back through BacktestFeed, as `run --data` does.
+func replayBars(t *testing.T, path string) []types.MarketEvent {
+	t.Helper()
+
+	feed := NewBacktestFeed(path, "MES")
+	ch, err := feed.Subscribe(context.Background(), "MES")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	var events []types.MarketEvent
+	for event := range ch {
+		events = append(events, event)
+	}
+	if err := feed.Err(); err != nil {
+		t.Fatalf("replay error = %v", err)
+	}
+	return events
+}
+
+func TestRecordingFeed_RecordsAndReplays(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bars.csv")
+
+	// Live ticks, including size-only ticks with no price, over three minutes
+	source := newFakeTickSource()
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 30; i++ {
+		source.send(base.Add(time.Duration(i)*6*time.Second), 5000+int64(i%7), int64(100+i))
+	}
+	close(source.ch)
+
+	forwarded := recordBars(t, path, NewLiveFeed(source, time.Minute, nil))
+	if len(forwarded) != 3 {
+		t.Fatalf("forwarded = %d bars, want 3", len(forwarded))
+	}
+
+	replayed := replayBars(t, path)
+	if len(replayed) != len(forwarded) {
+		t.Fatalf("replayed = %d bars, want %d", len(replayed), len(forwarded))
+	}
+	for i, bar := range forwarded {
+		got := replayed[i]
+		if !got.Timestamp.Equal(bar.Timestamp) || !got.Open.Equal(bar.Open) || !got.High.Equal(bar.High) ||
+			!got.Low.Equal(bar.Low) || !got.Close.Equal(bar.Close) || got.Volume != bar.Volume {
+			t.Errorf("bar %d replayed as %+v, want %+v", i, got, bar)
+		}
+	}
+}
+
+func TestMarketRecorder_RefusesIncompleteBars(t *testing.T) {
+	recorder, err := NewMarketRecorder(filepath.Join(t.TempDir(), "bars.csv"), nil)
+	if err != nil {
+		t.Fatalf("NewMarketRecorder() error = %v", err)
+	}
+	defer recorder.Close()
+
+	ts := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	for name, event := range map[string]types.MarketEvent{
+		"last price tick": {Timestamp: ts, Close: decimal.NewFromInt(5000)},
+		"size tick":       {Timestamp: ts, Volume: 10},
+	} {
+		if err := recorder.Record(event); err == nil {
+			t.Errorf("%s: Record() should fail", name)
+		}
+	}
+
+	bar := types.MarketEvent{Timestamp: ts, Open: decimal.NewFromInt(5000), High: decimal.NewFromInt(5001), Low: decimal.NewFromInt(4999), Close: decimal.NewFromInt(5000)}
+	if err := recorder.Record(bar); err != nil {
+		t.Errorf("Record(bar) error = %v", err)
+	}
+}
+
+func TestMarketRecorder_AppendsWithoutDuplicateHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bars.csv")
+
+	for i := 0; i < 2; i++ {
+		bar := types.MarketEvent{
+			Timestamp: time.Date(2024, 1, 2, 10, i, 0, 0, time.UTC),
+			Open:      decimal.NewFromInt(5000),
+			High:      decimal.NewFromInt(5000),
+			Low:       decimal.NewFromInt(5000),
+			Close:     decimal.NewFromInt(5000),
+		}
+		recordBars(t, path, NewMemoryFeed([]types.MarketEvent{bar}, "MES"))
+	}
+
+	if events := replayBars(t, path); len(events) != 2 {
+		t.Errorf("events = %d, want 2", len(events))
+	}
+}
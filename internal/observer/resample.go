@@ -0,0 +1,112 @@
+package observer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// ResampleResult holds resampled bars and what was done to get them.
+type ResampleResult struct {
+	Bars           []types.MarketEvent
+	Gaps           int  // Output bars built from fewer base bars than the period holds
+	DroppedPartial bool // The trailing period was incomplete and left out
+}
+
+// Resample aggregates bars of the from timeframe into bars of the to
+// timeframe: open of the first bar, high max, low min, close of the last
+// bar and summed volume. Output bars are aligned to clock boundaries of to
+// and stamped with their start time, like TimeframeAggregator.
+//
+// Unlike TimeframeAggregator, which runs live and drops any incomplete
+// period, Resample works on a whole file: periods with missing base bars
+// (a data gap, or the session opening midway) are still aggregated and
+// counted in Gaps. Only the trailing period, which may still have been
+// forming when the data was saved, is dropped when incomplete unless
+// keepPartial is set.
+func Resample(bars []types.MarketEvent, from, to time.Duration, keepPartial bool) (ResampleResult, error) {
+	if from <= 0 || to <= from || to%from != 0 {
+		return ResampleResult{}, fmt.Errorf("%w: cannot resample %s bars to %s", types.ErrInvalidTimeframe, from, to)
+	}
+
+	sorted := make([]types.MarketEvent, len(bars))
+	copy(sorted, bars)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+
+	var result ResampleResult
+	perPeriod := int(to / from)
+	var bar types.MarketEvent
+	count := 0
+
+	flush := func() {
+		if count < perPeriod {
+			result.Gaps++
+		}
+		result.Bars = append(result.Bars, bar)
+	}
+
+	for _, b := range sorted {
+		start := b.Timestamp.Truncate(to)
+		if count > 0 && start.Equal(bar.Timestamp) {
+			bar.High = decimal.Max(bar.High, b.High)
+			bar.Low = decimal.Min(bar.Low, b.Low)
+			bar.Close = b.Close
+			bar.Volume += b.Volume
+			count++
+			continue
+		}
+		if count > 0 {
+			flush()
+		}
+		bar = types.MarketEvent{
+			Symbol:    b.Symbol,
+			Timestamp: start,
+			Open:      b.Open,
+			High:      b.High,
+			Low:       b.Low,
+			Close:     b.Close,
+			Volume:    b.Volume,
+		}
+		count = 1
+	}
+
+	if count > 0 {
+		last := sorted[len(sorted)-1]
+		if !keepPartial && last.Timestamp.Add(from).Before(bar.Timestamp.Add(to)) {
+			result.DroppedPartial = true
+		} else {
+			flush()
+		}
+	}
+
+	return result, nil
+}
+
+// WriteCSV writes bars in the timestamp,open,high,low,close,volume layout
+// ParseCSV reads, with UTC RFC 3339 timestamps.
+func WriteCSV(w io.Writer, bars []types.MarketEvent) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"timestamp", "open", "high", "low", "close", "volume"}); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	for _, b := range bars {
+		err := cw.Write([]string{
+			b.Timestamp.UTC().Format(time.RFC3339),
+			b.Open.String(),
+			b.High.String(),
+			b.Low.String(),
+			b.Close.String(),
+			fmt.Sprintf("%d", b.Volume),
+		})
+		if err != nil {
+			return fmt.Errorf("write bar %s: %w", b.Timestamp, err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,116 @@
+package observer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func TestResample_FiveMinuteBars(t *testing.T) {
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	bars := []types.MarketEvent{
+		m5Bar(base, 100, 102, 99, 101),
+		m5Bar(base.Add(1*time.Minute), 101, 106, 100, 105),
+		m5Bar(base.Add(2*time.Minute), 105, 105, 97, 98),
+		m5Bar(base.Add(3*time.Minute), 98, 103, 98, 102),
+		m5Bar(base.Add(4*time.Minute), 102, 104, 101, 103),
+	}
+
+	result, err := Resample(bars, time.Minute, 5*time.Minute, false)
+	if err != nil {
+		t.Fatalf("Resample() error = %v", err)
+	}
+	if len(result.Bars) != 1 {
+		t.Fatalf("got %d bars, want 1", len(result.Bars))
+	}
+
+	bar := result.Bars[0]
+	if !bar.Timestamp.Equal(base) {
+		t.Errorf("Timestamp = %v, want %v", bar.Timestamp, base)
+	}
+	if !bar.Open.Equal(decimal.NewFromInt(100)) || !bar.High.Equal(decimal.NewFromInt(106)) ||
+		!bar.Low.Equal(decimal.NewFromInt(97)) || !bar.Close.Equal(decimal.NewFromInt(103)) {
+		t.Errorf("OHLC = %s/%s/%s/%s, want 100/106/97/103", bar.Open, bar.High, bar.Low, bar.Close)
+	}
+	if bar.Volume != 50 {
+		t.Errorf("Volume = %d, want 50", bar.Volume)
+	}
+	if result.Gaps != 0 || result.DroppedPartial {
+		t.Errorf("gaps/dropped = %d/%v, want 0/false", result.Gaps, result.DroppedPartial)
+	}
+}
+
+func TestResample_GapsAndPartialTrailingBar(t *testing.T) {
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	var bars []types.MarketEvent
+	for i := range 13 {
+		if i == 7 {
+			continue // Missing 10:07 bar
+		}
+		bars = append(bars, m5Bar(base.Add(time.Duration(i)*time.Minute), 100, 101, 99, 100))
+	}
+
+	result, err := Resample(bars, time.Minute, 5*time.Minute, false)
+	if err != nil {
+		t.Fatalf("Resample() error = %v", err)
+	}
+	if len(result.Bars) != 2 || !result.DroppedPartial {
+		t.Fatalf("got %d bars, dropped = %v; want 2 bars and the 10:10 bar dropped", len(result.Bars), result.DroppedPartial)
+	}
+	if result.Gaps != 1 || result.Bars[1].Volume != 40 {
+		t.Errorf("gaps = %d, 10:05 volume = %d; want 1 gap and 4 bars' volume", result.Gaps, result.Bars[1].Volume)
+	}
+
+	result, err = Resample(bars, time.Minute, 5*time.Minute, true)
+	if err != nil {
+		t.Fatalf("Resample(keepPartial) error = %v", err)
+	}
+	if len(result.Bars) != 3 || result.DroppedPartial {
+		t.Errorf("keepPartial: got %d bars, dropped = %v; want 3 bars", len(result.Bars), result.DroppedPartial)
+	}
+}
+
+func TestResample_InvalidTimeframes(t *testing.T) {
+	tests := []struct{ from, to time.Duration }{
+		{5 * time.Minute, time.Minute},
+		{5 * time.Minute, 5 * time.Minute},
+		{15 * time.Minute, 20 * time.Minute},
+		{0, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if _, err := Resample(nil, tt.from, tt.to, false); !errors.Is(err, types.ErrInvalidTimeframe) {
+			t.Errorf("Resample(%s -> %s) error = %v, want ErrInvalidTimeframe", tt.from, tt.to, err)
+		}
+	}
+}
+
+func TestWriteCSV_RoundTrip(t *testing.T) {
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	bars := []types.MarketEvent{
+		m5Bar(base, 100, 105, 99, 104),
+		m5Bar(base.Add(5*time.Minute), 104, 110, 103, 108),
+	}
+	bars[1].Close = decimal.RequireFromString("108.25")
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, bars); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	parsed, err := ParseCSVStrict(&buf, "MES")
+	if err != nil {
+		t.Fatalf("ParseCSVStrict() error = %v", err)
+	}
+
+	if len(parsed) != len(bars) {
+		t.Fatalf("parsed %d bars, want %d", len(parsed), len(bars))
+	}
+	for i := range bars {
+		if !parsed[i].Timestamp.Equal(bars[i].Timestamp) || !parsed[i].Close.Equal(bars[i].Close) || parsed[i].Volume != bars[i].Volume {
+			t.Errorf("bar %d = %+v, want %+v", i, parsed[i], bars[i])
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package observer
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/broker"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// Roll is a switch from one quarterly contract to the next in a continuous
+// price series.
+type Roll struct {
+	Date   time.Time       // Timestamp of the first bar on the new contract
+	From   string          // Expiring contract (YYYYMM)
+	To     string          // New front month (YYYYMM)
+	Offset decimal.Decimal // New contract price minus old at the roll
+}
+
+// DetectRolls finds the roll points in a continuous, unadjusted series using
+// the CME roll calendar. Each roll's offset is the gap between the last close
+// on the old contract and the first open on the new one, so it also includes
+// whatever the market moved across that bar boundary.
+func DetectRolls(events []types.MarketEvent) []Roll {
+	var rolls []Roll
+	for i := 1; i < len(events); i++ {
+		prev, cur := events[i-1], events[i]
+		from := broker.GetFrontMonthExpiry(prev.Timestamp)
+		to := broker.GetFrontMonthExpiry(cur.Timestamp)
+		if from == to {
+			continue
+		}
+
+		open := cur.Open
+		if open.IsZero() {
+			open = cur.Close
+		}
+		rolls = append(rolls, Roll{
+			Date:   cur.Timestamp,
+			From:   from,
+			To:     to,
+			Offset: open.Sub(prev.Close),
+		})
+	}
+	return rolls
+}
+
+// RollAdjustment returns the contract active at t and the back-adjustment
+// offset for prices at t: the sum of the roll gaps after t. Adding the offset
+// puts a price on the scale of the last contract in rolls.
+func RollAdjustment(rolls []Roll, t time.Time) (string, decimal.Decimal) {
+	offset := decimal.Zero
+	for _, roll := range rolls {
+		if t.Before(roll.Date) {
+			offset = offset.Add(roll.Offset)
+		}
+	}
+	return broker.GetFrontMonthExpiry(t), offset
+}
+
+// BackAdjust returns a copy of events with each bar's OHLC shifted by its
+// roll adjustment, removing the phantom gaps at rolls (the Panama method).
+// Bars on the last contract are unchanged; earlier history may differ from
+// the prices actually traded at the time.
+func BackAdjust(events []types.MarketEvent, rolls []Roll) []types.MarketEvent {
+	adjusted := make([]types.MarketEvent, len(events))
+	for i, event := range events {
+		_, offset := RollAdjustment(rolls, event.Timestamp)
+		if !offset.IsZero() {
+			if !event.Open.IsZero() {
+				event.Open = event.Open.Add(offset)
+			}
+			event.High = event.High.Add(offset)
+			event.Low = event.Low.Add(offset)
+			event.Close = event.Close.Add(offset)
+		}
+		adjusted[i] = event
+	}
+	return adjusted
+}
@@ -0,0 +1,84 @@
+package observer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// rollSeries builds daily bars across the March 2025 roll (Thu Mar 13) with a
+// 20 point jump onto the June contract.
+func rollSeries() []types.MarketEvent {
+	bar := func(day int, price int64) types.MarketEvent {
+		p := decimal.NewFromInt(price)
+		return types.MarketEvent{
+			Symbol:    "MES",
+			Timestamp: time.Date(2025, 3, day, 14, 0, 0, 0, time.UTC),
+			Open:      p,
+			High:      p.Add(decimal.NewFromInt(2)),
+			Low:       p.Sub(decimal.NewFromInt(2)),
+			Close:     p,
+		}
+	}
+	return []types.MarketEvent{
+		bar(11, 5000),
+		bar(12, 5001),
+		bar(13, 5021), // June contract trades 20 points higher
+		bar(14, 5022),
+	}
+}
+
+func TestDetectRolls(t *testing.T) {
+	rolls := DetectRolls(rollSeries())
+	if len(rolls) != 1 {
+		t.Fatalf("rolls = %d, want 1", len(rolls))
+	}
+
+	roll := rolls[0]
+	if roll.From != "202503" || roll.To != "202506" {
+		t.Errorf("roll = %s -> %s, want 202503 -> 202506", roll.From, roll.To)
+	}
+	if roll.Date.Day() != 13 {
+		t.Errorf("roll date = %v, want Mar 13", roll.Date)
+	}
+	if !roll.Offset.Equal(decimal.NewFromInt(20)) {
+		t.Errorf("Offset = %s, want 20", roll.Offset)
+	}
+}
+
+func TestRollAdjustment(t *testing.T) {
+	rolls := DetectRolls(rollSeries())
+
+	contract, offset := RollAdjustment(rolls, time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC))
+	if contract != "202503" || !offset.Equal(decimal.NewFromInt(20)) {
+		t.Errorf("before roll = %s %s, want 202503 20", contract, offset)
+	}
+
+	contract, offset = RollAdjustment(rolls, time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC))
+	if contract != "202506" || !offset.IsZero() {
+		t.Errorf("after roll = %s %s, want 202506 0", contract, offset)
+	}
+}
+
+func TestBackAdjust(t *testing.T) {
+	events := rollSeries()
+	adjusted := BackAdjust(events, DetectRolls(events))
+
+	// Pre-roll bars shift onto the June scale, leaving a 1 point move
+	if !adjusted[1].Close.Equal(decimal.NewFromInt(5021)) {
+		t.Errorf("pre-roll Close = %s, want 5021", adjusted[1].Close)
+	}
+	if gap := adjusted[2].Open.Sub(adjusted[1].Close); !gap.IsZero() {
+		t.Errorf("gap at roll = %s, want 0", gap)
+	}
+
+	// Current contract untouched; input not modified
+	if !adjusted[3].Close.Equal(events[3].Close) {
+		t.Errorf("post-roll Close = %s, want %s", adjusted[3].Close, events[3].Close)
+	}
+	if !events[0].Close.Equal(decimal.NewFromInt(5000)) {
+		t.Error("BackAdjust modified its input")
+	}
+}
@@ -0,0 +1,57 @@
+package observer
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// SpreadEstimator gives OHLC bars without quotes an approximate bid/ask, so
+// spread-dependent logic (the spread guard, bid/ask fills, mid entries) can
+// run in backtests. The spread is a fraction of ATR: an approximation from
+// bar ranges, not a measured book. Real spreads widen around news and the
+// session open in ways the ATR lags.
+type SpreadEstimator struct {
+	ATRFraction decimal.Decimal // Spread as a fraction of ATR (e.g., 0.02 = 2% of ATR)
+	MinTicks    int             // Floor in ticks, also used while ATR warms up
+}
+
+// NewSpreadEstimator creates an ATR-proportional spread estimator.
+func NewSpreadEstimator(atrFraction decimal.Decimal, minTicks int) *SpreadEstimator {
+	return &SpreadEstimator{ATRFraction: atrFraction, MinTicks: minTicks}
+}
+
+// Estimate returns max(MinTicks, ceil(ATR * ATRFraction / tick)) ticks as a
+// price amount.
+func (s *SpreadEstimator) Estimate(event types.MarketEvent) decimal.Decimal {
+	spec, _ := types.GetInstrumentSpec(event.Symbol)
+	floor := spec.TickSize.Mul(decimal.NewFromInt(int64(s.MinTicks)))
+
+	if event.ATR.IsZero() || spec.TickSize.IsZero() {
+		return floor
+	}
+
+	ticks := event.ATR.Mul(s.ATRFraction).Div(spec.TickSize).Ceil()
+	return decimal.Max(floor, ticks.Mul(spec.TickSize))
+}
+
+// Apply sets Bid and Ask around the close, on the tick grid, from the
+// estimated spread. Bars that already carry quotes are left alone.
+func (s *SpreadEstimator) Apply(event types.MarketEvent) types.MarketEvent {
+	if event.Bid.IsPositive() && event.Ask.IsPositive() {
+		return event
+	}
+	spread := s.Estimate(event)
+	if !spread.IsPositive() {
+		return event
+	}
+
+	// Split the spread around the close; an odd tick count goes to the ask
+	spec, _ := types.GetInstrumentSpec(event.Symbol)
+	below := spread.Div(decimal.NewFromInt(2))
+	if spec.TickSize.IsPositive() {
+		below = below.Div(spec.TickSize).Floor().Mul(spec.TickSize)
+	}
+	event.Bid = event.Close.Sub(below)
+	event.Ask = event.Bid.Add(spread)
+	return event
+}
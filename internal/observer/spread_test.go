@@ -0,0 +1,49 @@
+package observer
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func TestSpreadEstimator_TracksATR(t *testing.T) {
+	est := NewSpreadEstimator(decimal.RequireFromString("0.1"), 1)
+
+	tests := []struct {
+		atr       string
+		wantTicks int64
+	}{
+		{"0", 1},  // Warming up: the floor
+		{"2", 1},  // 0.2 pts rounds up to 1 tick
+		{"5", 2},  // 0.5 pts = 2 ticks
+		{"10", 4}, // 1 pt = 4 ticks
+		{"20", 8}, // Twice the ATR, twice the spread
+		{"11", 5}, // 1.1 pts rounds up to 5 ticks
+	}
+
+	for _, tt := range tests {
+		event := types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000), ATR: decimal.RequireFromString(tt.atr)}
+		got := est.Estimate(event)
+		want := decimal.RequireFromString("0.25").Mul(decimal.NewFromInt(tt.wantTicks))
+		if !got.Equal(want) {
+			t.Errorf("Estimate(ATR %s) = %s, want %s", tt.atr, got, want)
+		}
+	}
+}
+
+func TestSpreadEstimator_Apply(t *testing.T) {
+	est := NewSpreadEstimator(decimal.RequireFromString("0.1"), 1)
+
+	// 3 ticks: 1 below the close, 2 above
+	event := est.Apply(types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000), ATR: decimal.RequireFromString("7.5")})
+	if !event.Bid.Equal(decimal.RequireFromString("4999.75")) || !event.Ask.Equal(decimal.RequireFromString("5000.5")) {
+		t.Errorf("Bid/Ask = %s/%s, want 4999.75/5000.5", event.Bid, event.Ask)
+	}
+
+	// Real quotes win over the estimate
+	quoted := types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000), Bid: decimal.NewFromInt(4999), Ask: decimal.NewFromInt(5001)}
+	if got := est.Apply(quoted); !got.Bid.Equal(quoted.Bid) || !got.Ask.Equal(quoted.Ask) {
+		t.Errorf("Apply changed real quotes to %s/%s", got.Bid, got.Ask)
+	}
+}
@@ -0,0 +1,102 @@
+package observer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func collectSynthetic(t *testing.T, cfg SyntheticConfig) []types.MarketEvent {
+	t.Helper()
+	ch, err := NewSyntheticFeed(cfg).Subscribe(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	var events []types.MarketEvent
+	for event := range ch {
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestSyntheticFeed_Deterministic(t *testing.T) {
+	cfg := DefaultSyntheticConfig()
+	cfg.Bars = 500
+	cfg.Seed = 42
+
+	first := collectSynthetic(t, cfg)
+	second := collectSynthetic(t, cfg)
+	if len(first) != 500 || len(second) != 500 {
+		t.Fatalf("got %d and %d bars, want 500", len(first), len(second))
+	}
+	for i := range first {
+		if !first[i].Close.Equal(second[i].Close) || !first[i].High.Equal(second[i].High) {
+			t.Fatalf("bar %d differs between runs with the same seed", i)
+		}
+	}
+
+	cfg.Seed = 43
+	other := collectSynthetic(t, cfg)
+	same := 0
+	for i := range first {
+		if first[i].Close.Equal(other[i].Close) {
+			same++
+		}
+	}
+	if same == len(first) {
+		t.Error("a different seed should give a different series")
+	}
+}
+
+func TestSyntheticFeed_ValidBars(t *testing.T) {
+	cfg := DefaultSyntheticConfig()
+	cfg.Bars = 1000
+	cfg.Volatility = 0.005
+
+	spec, _ := types.GetInstrumentSpec("MES")
+	events := collectSynthetic(t, cfg)
+	for i, event := range events {
+		if err := ValidateBar(event); err != nil {
+			t.Fatalf("bar %d: %v", i, err)
+		}
+		if !event.Close.Mod(spec.TickSize).IsZero() {
+			t.Fatalf("bar %d close %s is not on the tick grid", i, event.Close)
+		}
+		if i > 0 {
+			if !event.Open.Equal(events[i-1].Close) {
+				t.Fatalf("bar %d opens at %s, want previous close %s", i, event.Open, events[i-1].Close)
+			}
+			if got := event.Timestamp.Sub(events[i-1].Timestamp); got != cfg.Interval {
+				t.Fatalf("bar %d spacing = %s, want %s", i, got, cfg.Interval)
+			}
+		}
+	}
+}
+
+func TestSyntheticFeed_Drift(t *testing.T) {
+	cfg := DefaultSyntheticConfig()
+	cfg.Bars = 2000
+	cfg.Drift = 0.001
+
+	events := collectSynthetic(t, cfg)
+	// 2000 bars at 0.1% each is roughly a 7x move; noise can't undo that
+	doubled := cfg.StartPrice.Mul(decimal.NewFromInt(2))
+	if last := events[len(events)-1].Close; !last.GreaterThan(doubled) {
+		t.Errorf("final close %s, want an uptrend above %s", last, doubled)
+	}
+}
+
+func TestSyntheticFeed_SymbolFilter(t *testing.T) {
+	cfg := DefaultSyntheticConfig()
+	cfg.Bars = 10
+
+	ch, err := NewSyntheticFeed(cfg).Subscribe(context.Background(), "MGC")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	for event := range ch {
+		t.Fatalf("unexpected event for another symbol: %+v", event)
+	}
+}
@@ -0,0 +1,112 @@
+package observer
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// TimeframeAggregator rolls base-timeframe bars into a higher timeframe,
+// e.g. M5 bars into M15 bars.
+//
+// Higher bars are aligned to clock boundaries of the higher timeframe and
+// stamped with their start time, like BarAggregator. A higher bar is emitted
+// with the base bar that closes its period, so strategies see it on the same
+// bar the period ends. Partial higher bars are discarded rather than
+// emitted as if they were complete: a period that bars skip past (a session
+// break or data gap), or one joined midway after Reset or a session open.
+type TimeframeAggregator struct {
+	base      time.Duration
+	timeframe time.Duration
+
+	bar      types.MarketEvent
+	barStart time.Time
+	hasBar   bool
+	partial  bool // First base bar came after the period start
+
+	last    types.MarketEvent // Last completed higher bar
+	hasLast bool
+	dropped int
+}
+
+// NewTimeframeAggregator creates an aggregator that rolls bars of the base
+// timeframe into bars of timeframe. timeframe should be a multiple of base.
+func NewTimeframeAggregator(base, timeframe time.Duration) *TimeframeAggregator {
+	return &TimeframeAggregator{
+		base:      base,
+		timeframe: timeframe,
+	}
+}
+
+// OnBar adds a base bar. It returns the completed higher bar and true when
+// bar closes a higher-timeframe period.
+func (a *TimeframeAggregator) OnBar(bar types.MarketEvent) (types.MarketEvent, bool) {
+	start := bar.Timestamp.Truncate(a.timeframe)
+
+	if a.hasBar {
+		switch {
+		case start.Before(a.barStart):
+			// Late bar for a period already started
+			return types.MarketEvent{}, false
+		case start.After(a.barStart):
+			// The previous period never completed
+			a.hasBar = false
+			a.dropped++
+		}
+	}
+
+	if !a.hasBar {
+		a.bar = types.MarketEvent{
+			Symbol:    bar.Symbol,
+			Timestamp: start,
+			Open:      bar.Open,
+			High:      bar.High,
+			Low:       bar.Low,
+			Close:     bar.Close,
+			Volume:    bar.Volume,
+		}
+		a.barStart = start
+		a.hasBar = true
+		a.partial = bar.Timestamp.After(start)
+	} else {
+		a.bar.High = decimal.Max(a.bar.High, bar.High)
+		a.bar.Low = decimal.Min(a.bar.Low, bar.Low)
+		a.bar.Close = bar.Close
+		a.bar.Volume += bar.Volume
+	}
+	a.bar.Bid, a.bar.Ask = bar.Bid, bar.Ask
+
+	if bar.Timestamp.Add(a.base).Before(start.Add(a.timeframe)) {
+		return types.MarketEvent{}, false
+	}
+
+	a.hasBar = false
+	if a.partial {
+		a.dropped++
+		return types.MarketEvent{}, false
+	}
+	a.last, a.hasLast = a.bar, true
+	return a.bar, true
+}
+
+// Last returns the most recently completed higher bar.
+func (a *TimeframeAggregator) Last() (types.MarketEvent, bool) {
+	return a.last, a.hasLast
+}
+
+// Dropped returns how many partial higher bars were discarded.
+func (a *TimeframeAggregator) Dropped() int {
+	return a.dropped
+}
+
+// Reset discards the partial and last completed higher bars.
+func (a *TimeframeAggregator) Reset() {
+	a.bar = types.MarketEvent{}
+	a.barStart = time.Time{}
+	a.hasBar = false
+	a.partial = false
+	a.last = types.MarketEvent{}
+	a.hasLast = false
+	a.dropped = 0
+}
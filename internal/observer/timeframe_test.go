@@ -0,0 +1,100 @@
+package observer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func m5Bar(ts time.Time, open, high, low, close int64) types.MarketEvent {
+	return types.MarketEvent{
+		Symbol:    "MES",
+		Timestamp: ts,
+		Open:      decimal.NewFromInt(open),
+		High:      decimal.NewFromInt(high),
+		Low:       decimal.NewFromInt(low),
+		Close:     decimal.NewFromInt(close),
+		Volume:    10,
+	}
+}
+
+func TestTimeframeAggregator_EmitsEveryThirdBar(t *testing.T) {
+	agg := NewTimeframeAggregator(5*time.Minute, 15*time.Minute)
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	if _, ok := agg.OnBar(m5Bar(base, 100, 105, 99, 104)); ok {
+		t.Fatal("first bar should not complete M15")
+	}
+	if _, ok := agg.OnBar(m5Bar(base.Add(5*time.Minute), 104, 110, 103, 108)); ok {
+		t.Fatal("second bar should not complete M15")
+	}
+	bar, ok := agg.OnBar(m5Bar(base.Add(10*time.Minute), 108, 109, 95, 97))
+	if !ok {
+		t.Fatal("third bar should complete M15")
+	}
+
+	if !bar.Timestamp.Equal(base) {
+		t.Errorf("Timestamp = %v, want %v", bar.Timestamp, base)
+	}
+	if bar.Open.IntPart() != 100 || bar.High.IntPart() != 110 || bar.Low.IntPart() != 95 || bar.Close.IntPart() != 97 {
+		t.Errorf("OHLC = %s/%s/%s/%s, want 100/110/95/97", bar.Open, bar.High, bar.Low, bar.Close)
+	}
+	if bar.Volume != 30 {
+		t.Errorf("Volume = %d, want 30", bar.Volume)
+	}
+
+	last, ok := agg.Last()
+	if !ok || !last.Timestamp.Equal(base) {
+		t.Errorf("Last() = %v, %v", last.Timestamp, ok)
+	}
+}
+
+func TestTimeframeAggregator_DropsPartialAcrossGap(t *testing.T) {
+	agg := NewTimeframeAggregator(5*time.Minute, 15*time.Minute)
+	// Session ends two bars into an M15 period
+	close := time.Date(2024, 1, 2, 15, 45, 0, 0, time.UTC)
+	agg.OnBar(m5Bar(close, 100, 101, 99, 100))
+	agg.OnBar(m5Bar(close.Add(5*time.Minute), 100, 101, 99, 100))
+
+	// Next session starts an hour later
+	open := close.Add(75 * time.Minute)
+	agg.OnBar(m5Bar(open, 200, 201, 199, 200))
+	agg.OnBar(m5Bar(open.Add(5*time.Minute), 200, 202, 199, 201))
+	bar, ok := agg.OnBar(m5Bar(open.Add(10*time.Minute), 201, 203, 198, 202))
+	if !ok {
+		t.Fatal("expected M15 bar after gap")
+	}
+	if bar.Open.IntPart() != 200 || bar.Low.IntPart() != 198 {
+		t.Errorf("bar mixed in pre-gap data: open %s low %s", bar.Open, bar.Low)
+	}
+	if agg.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, want 1", agg.Dropped())
+	}
+}
+
+func TestTimeframeAggregator_Reset(t *testing.T) {
+	agg := NewTimeframeAggregator(5*time.Minute, 15*time.Minute)
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	agg.OnBar(m5Bar(base, 100, 101, 99, 100))
+	agg.OnBar(m5Bar(base.Add(5*time.Minute), 100, 101, 99, 100))
+
+	agg.Reset()
+	if _, ok := agg.Last(); ok {
+		t.Error("Last() should be empty after Reset")
+	}
+
+	// The rest of the interrupted period is partial and must not emit
+	if _, ok := agg.OnBar(m5Bar(base.Add(10*time.Minute), 300, 301, 299, 300)); ok {
+		t.Error("partial period after Reset should not emit")
+	}
+
+	next := base.Add(15 * time.Minute)
+	agg.OnBar(m5Bar(next, 400, 401, 399, 400))
+	agg.OnBar(m5Bar(next.Add(5*time.Minute), 400, 401, 399, 400))
+	bar, ok := agg.OnBar(m5Bar(next.Add(10*time.Minute), 400, 402, 398, 401))
+	if !ok || bar.Open.IntPart() != 400 {
+		t.Errorf("next full period = %s, %v, want open 400", bar.Open, ok)
+	}
+}
@@ -35,6 +35,10 @@ type Repository interface {
 	SaveState(ctx context.Context, state BotState) error
 	GetState(ctx context.Context) (*BotState, error)
 
+	// Backtest run log
+	SaveBacktestRun(ctx context.Context, run BacktestRun) error
+	GetBacktestRuns(ctx context.Context) ([]BacktestRun, error)
+
 	// Lifecycle
 	Close() error
 	Migrate(ctx context.Context) error
@@ -70,6 +74,21 @@ type OrderRecord struct {
 	StrategyName    string
 }
 
+// BacktestRun is the summary of one backtest, kept so parameter changes
+// can be compared across runs.
+type BacktestRun struct {
+	ID          int64
+	RunAt       time.Time
+	Strategy    string
+	DataFile    string
+	ConfigHash  string          // Fingerprint of the settings that affect results
+	TotalReturn decimal.Decimal // As ratio
+	MaxDrawdown decimal.Decimal // As ratio
+	SharpeRatio decimal.Decimal
+	TotalTrades int
+	WinRate     decimal.Decimal // As ratio
+}
+
 // BotState represents the overall bot state for recovery.
 type BotState struct {
 	ID              int64
@@ -3,13 +3,13 @@ package persistence
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/mattn/go-sqlite3"
 	"github.com/shopspring/decimal"
 	"github.com/tathienbao/quant-bot/internal/types"
-
-	_ "github.com/mattn/go-sqlite3" // SQLite driver
 )
 
 // SQLiteRepository implements Repository using SQLite.
@@ -89,6 +89,7 @@ func (r *SQLiteRepository) Migrate(ctx context.Context) error {
 			r_multiple TEXT,
 			signal_id TEXT,
 			strategy_name TEXT,
+			exit_reason TEXT NOT NULL DEFAULT '',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`,
 		`CREATE INDEX IF NOT EXISTS idx_trades_symbol ON trades(symbol)`,
@@ -126,6 +127,19 @@ func (r *SQLiteRepository) Migrate(ctx context.Context) error {
 			losing_trades INTEGER NOT NULL DEFAULT 0,
 			total_pl TEXT NOT NULL DEFAULT '0'
 		)`,
+
+		`CREATE TABLE IF NOT EXISTS backtest_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			run_at DATETIME NOT NULL,
+			strategy TEXT NOT NULL,
+			data_file TEXT NOT NULL,
+			config_hash TEXT NOT NULL,
+			total_return TEXT NOT NULL,
+			max_drawdown TEXT NOT NULL,
+			sharpe_ratio TEXT NOT NULL,
+			total_trades INTEGER NOT NULL,
+			win_rate TEXT NOT NULL
+		)`,
 	}
 
 	for _, migration := range migrations {
@@ -134,6 +148,45 @@ func (r *SQLiteRepository) Migrate(ctx context.Context) error {
 		}
 	}
 
+	// Columns added after a table's first release; CREATE TABLE IF NOT EXISTS
+	// leaves existing databases without them
+	if err := r.addColumn(ctx, "trades", "exit_reason", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// addColumn adds a column to an existing table unless it is already there.
+func (r *SQLiteRepository) addColumn(ctx context.Context, table, column, definition string) error {
+	rows, err := r.db.QueryContext(ctx, "PRAGMA table_info("+table+")")
+	if err != nil {
+		return fmt.Errorf("inspect %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var (
+			cid          int
+			name, typ    string
+			notNull, pk  int
+			defaultValue sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &defaultValue, &pk); err != nil {
+			return fmt.Errorf("scan %s columns: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("inspect %s: %w", table, err)
+	}
+
+	query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)
+	if _, err := r.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("add %s.%s: %w", table, column, err)
+	}
 	return nil
 }
 
@@ -281,7 +334,13 @@ func (r *SQLiteRepository) GetOpenPositions(ctx context.Context) ([]types.Positi
 func (r *SQLiteRepository) ClosePosition(ctx context.Context, positionID string, exitPrice decimal.Decimal, exitTime time.Time) error {
 	query := `UPDATE positions SET is_open = 0, exit_price = ?, exit_time = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
 
-	_, err := r.db.ExecContext(ctx, query, exitPrice.String(), exitTime, positionID)
+	// An unknown exit price is stored as NULL rather than a zero price
+	var exit any
+	if exitPrice.IsPositive() {
+		exit = exitPrice.String()
+	}
+
+	_, err := r.db.ExecContext(ctx, query, exit, exitTime, positionID)
 	if err != nil {
 		return fmt.Errorf("close position: %w", err)
 	}
@@ -292,8 +351,8 @@ func (r *SQLiteRepository) ClosePosition(ctx context.Context, positionID string,
 // SaveTrade saves a completed trade.
 func (r *SQLiteRepository) SaveTrade(ctx context.Context, trade types.Trade) error {
 	query := `INSERT INTO trades
-		(id, symbol, side, contracts, entry_price, exit_price, entry_time, exit_time, gross_pl, commission, net_pl, r_multiple, signal_id, strategy_name)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		(id, symbol, side, contracts, entry_price, exit_price, entry_time, exit_time, gross_pl, commission, net_pl, r_multiple, signal_id, strategy_name, exit_reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	_, err := r.db.ExecContext(ctx, query,
 		trade.ID,
@@ -310,6 +369,7 @@ func (r *SQLiteRepository) SaveTrade(ctx context.Context, trade types.Trade) err
 		trade.RMultiple.String(),
 		trade.SignalID,
 		trade.StrategyName,
+		trade.ExitReason,
 	)
 	if err != nil {
 		return fmt.Errorf("insert trade: %w", err)
@@ -320,7 +380,7 @@ func (r *SQLiteRepository) SaveTrade(ctx context.Context, trade types.Trade) err
 
 // GetTrades returns trades in a time range.
 func (r *SQLiteRepository) GetTrades(ctx context.Context, from, to time.Time) ([]types.Trade, error) {
-	query := `SELECT id, symbol, side, contracts, entry_price, exit_price, entry_time, exit_time, gross_pl, commission, net_pl, r_multiple, signal_id, strategy_name
+	query := `SELECT id, symbol, side, contracts, entry_price, exit_price, entry_time, exit_time, gross_pl, commission, net_pl, r_multiple, signal_id, strategy_name, exit_reason
 		FROM trades WHERE exit_time BETWEEN ? AND ? ORDER BY exit_time DESC`
 
 	rows, err := r.db.QueryContext(ctx, query, from, to)
@@ -334,7 +394,7 @@ func (r *SQLiteRepository) GetTrades(ctx context.Context, from, to time.Time) ([
 
 // GetTradesBySymbol returns trades for a symbol.
 func (r *SQLiteRepository) GetTradesBySymbol(ctx context.Context, symbol string, limit int) ([]types.Trade, error) {
-	query := `SELECT id, symbol, side, contracts, entry_price, exit_price, entry_time, exit_time, gross_pl, commission, net_pl, r_multiple, signal_id, strategy_name
+	query := `SELECT id, symbol, side, contracts, entry_price, exit_price, entry_time, exit_time, gross_pl, commission, net_pl, r_multiple, signal_id, strategy_name, exit_reason
 		FROM trades WHERE symbol = ? ORDER BY exit_time DESC LIMIT ?`
 
 	rows, err := r.db.QueryContext(ctx, query, symbol, limit)
@@ -351,9 +411,9 @@ func (r *SQLiteRepository) scanTrades(rows *sql.Rows) ([]types.Trade, error) {
 	for rows.Next() {
 		var t types.Trade
 		var entryPrice, exitPrice, grossPL, commission, netPL, rMultiple string
-		var signalID, strategyName sql.NullString
+		var signalID, strategyName, exitReason sql.NullString
 
-		if err := rows.Scan(&t.ID, &t.Symbol, &t.Side, &t.Contracts, &entryPrice, &exitPrice, &t.EntryTime, &t.ExitTime, &grossPL, &commission, &netPL, &rMultiple, &signalID, &strategyName); err != nil {
+		if err := rows.Scan(&t.ID, &t.Symbol, &t.Side, &t.Contracts, &entryPrice, &exitPrice, &t.EntryTime, &t.ExitTime, &grossPL, &commission, &netPL, &rMultiple, &signalID, &strategyName, &exitReason); err != nil {
 			return nil, fmt.Errorf("scan row: %w", err)
 		}
 
@@ -365,6 +425,7 @@ func (r *SQLiteRepository) scanTrades(rows *sql.Rows) ([]types.Trade, error) {
 		t.RMultiple, _ = decimal.NewFromString(rMultiple)
 		t.SignalID = signalID.String
 		t.StrategyName = strategyName.String
+		t.ExitReason = exitReason.String
 
 		trades = append(trades, t)
 	}
@@ -372,7 +433,8 @@ func (r *SQLiteRepository) scanTrades(rows *sql.Rows) ([]types.Trade, error) {
 	return trades, rows.Err()
 }
 
-// SaveOrder saves an order.
+// SaveOrder saves an order. A client order ID saved before is refused with
+// types.ErrDuplicateOrder.
 func (r *SQLiteRepository) SaveOrder(ctx context.Context, order OrderRecord) error {
 	query := `INSERT INTO orders
 		(client_order_id, symbol, side, contracts, entry_price, stop_loss, take_profit, status, signal_id, strategy_name)
@@ -390,6 +452,10 @@ func (r *SQLiteRepository) SaveOrder(ctx context.Context, order OrderRecord) err
 		order.SignalID,
 		order.StrategyName,
 	)
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return fmt.Errorf("%w: %s", types.ErrDuplicateOrder, order.ClientOrderID)
+	}
 	if err != nil {
 		return fmt.Errorf("insert order: %w", err)
 	}
@@ -520,6 +586,61 @@ func (r *SQLiteRepository) GetState(ctx context.Context) (*BotState, error) {
 	return &state, nil
 }
 
+// SaveBacktestRun records a backtest summary.
+func (r *SQLiteRepository) SaveBacktestRun(ctx context.Context, run BacktestRun) error {
+	query := `INSERT INTO backtest_runs
+		(run_at, strategy, data_file, config_hash, total_return, max_drawdown, sharpe_ratio, total_trades, win_rate)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		run.RunAt,
+		run.Strategy,
+		run.DataFile,
+		run.ConfigHash,
+		run.TotalReturn.String(),
+		run.MaxDrawdown.String(),
+		run.SharpeRatio.String(),
+		run.TotalTrades,
+		run.WinRate.String(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert backtest run: %w", err)
+	}
+
+	return nil
+}
+
+// GetBacktestRuns returns all recorded backtests, newest first.
+func (r *SQLiteRepository) GetBacktestRuns(ctx context.Context) ([]BacktestRun, error) {
+	query := `SELECT id, run_at, strategy, data_file, config_hash, total_return, max_drawdown, sharpe_ratio, total_trades, win_rate
+		FROM backtest_runs ORDER BY run_at DESC, id DESC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query backtest runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var runs []BacktestRun
+	for rows.Next() {
+		var run BacktestRun
+		var totalReturn, maxDrawdown, sharpe, winRate string
+
+		if err := rows.Scan(&run.ID, &run.RunAt, &run.Strategy, &run.DataFile, &run.ConfigHash, &totalReturn, &maxDrawdown, &sharpe, &run.TotalTrades, &winRate); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		run.TotalReturn, _ = decimal.NewFromString(totalReturn)
+		run.MaxDrawdown, _ = decimal.NewFromString(maxDrawdown)
+		run.SharpeRatio, _ = decimal.NewFromString(sharpe)
+		run.WinRate, _ = decimal.NewFromString(winRate)
+
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
 // Close closes the database connection.
 func (r *SQLiteRepository) Close() error {
 	return r.db.Close()
@@ -2,6 +2,8 @@ package persistence
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"os"
 	"testing"
 	"time"
@@ -184,6 +186,7 @@ func TestSQLiteRepository_Trade(t *testing.T) {
 		Commission:   decimal.RequireFromString("1.24"),
 		NetPL:        decimal.RequireFromString("48.76"),
 		StrategyName: "breakout",
+		ExitReason:   "take_profit",
 	}
 
 	err := repo.SaveTrade(ctx, trade)
@@ -207,6 +210,9 @@ func TestSQLiteRepository_Trade(t *testing.T) {
 	if !trades[0].NetPL.Equal(trade.NetPL) {
 		t.Errorf("net PL = %s, want %s", trades[0].NetPL, trade.NetPL)
 	}
+	if trades[0].ExitReason != trade.ExitReason {
+		t.Errorf("exit reason = %q, want %q", trades[0].ExitReason, trade.ExitReason)
+	}
 
 	// Get by symbol
 	trades, err = repo.GetTradesBySymbol(ctx, "MES", 10)
@@ -272,6 +278,11 @@ func TestSQLiteRepository_Order(t *testing.T) {
 	if len(orders) != 0 {
 		t.Errorf("pending orders after fill = %d, want 0", len(orders))
 	}
+
+	// The client order ID stays taken
+	if err := repo.SaveOrder(ctx, order); !errors.Is(err, types.ErrDuplicateOrder) {
+		t.Errorf("save order again error = %v, want ErrDuplicateOrder", err)
+	}
 }
 
 func TestSQLiteRepository_BotState(t *testing.T) {
@@ -347,6 +358,47 @@ func TestSQLiteRepository_BotState(t *testing.T) {
 	}
 }
 
+func TestSQLiteRepository_BacktestRuns(t *testing.T) {
+	repo, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	start := time.Now().Truncate(time.Second)
+
+	for i, ret := range []string{"0.05", "-0.02"} {
+		err := repo.SaveBacktestRun(ctx, BacktestRun{
+			RunAt:       start.Add(time.Duration(i) * time.Minute),
+			Strategy:    "grid",
+			DataFile:    "data/MES_5m.csv",
+			ConfigHash:  "abc123",
+			TotalReturn: decimal.RequireFromString(ret),
+			MaxDrawdown: decimal.RequireFromString("0.03"),
+			SharpeRatio: decimal.RequireFromString("1.2"),
+			TotalTrades: 40 + i,
+			WinRate:     decimal.RequireFromString("0.6"),
+		})
+		if err != nil {
+			t.Fatalf("save backtest run: %v", err)
+		}
+	}
+
+	runs, err := repo.GetBacktestRuns(ctx)
+	if err != nil {
+		t.Fatalf("get backtest runs: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("runs = %d, want 2", len(runs))
+	}
+
+	// Newest first
+	if runs[0].TotalTrades != 41 || !runs[0].TotalReturn.Equal(decimal.RequireFromString("-0.02")) {
+		t.Errorf("first run = %+v, want the later run", runs[0])
+	}
+	if runs[1].ConfigHash != "abc123" || !runs[1].SharpeRatio.Equal(decimal.RequireFromString("1.2")) {
+		t.Errorf("second run = %+v", runs[1])
+	}
+}
+
 func TestSQLiteRepository_NoData(t *testing.T) {
 	repo, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -389,3 +441,77 @@ func TestSQLiteRepository_NoData(t *testing.T) {
 		t.Errorf("pending orders = %d, want 0", len(orders))
 	}
 }
+
+func TestSQLiteRepository_Trade_LegacySchema(t *testing.T) {
+	f, err := os.CreateTemp("", "quant-bot-test-*.db")
+	if err != nil {
+		t.Fatalf("create temp file: %v", err)
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	// A database from before exit_reason existed, with one trade in it
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	now := time.Now().Truncate(time.Second)
+	_, err = db.Exec(`CREATE TABLE trades (
+		id TEXT PRIMARY KEY,
+		symbol TEXT NOT NULL,
+		side INTEGER NOT NULL,
+		contracts INTEGER NOT NULL,
+		entry_price TEXT NOT NULL,
+		exit_price TEXT NOT NULL,
+		entry_time DATETIME NOT NULL,
+		exit_time DATETIME NOT NULL,
+		gross_pl TEXT NOT NULL,
+		commission TEXT NOT NULL,
+		net_pl TEXT NOT NULL,
+		r_multiple TEXT,
+		signal_id TEXT,
+		strategy_name TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		t.Fatalf("create legacy schema: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO trades (id, symbol, side, contracts, entry_price, exit_price, entry_time, exit_time, gross_pl, commission, net_pl, r_multiple)
+		VALUES ('old', 'MES', 1, 1, '5000', '4990', ?, ?, '-50', '0', '-50', '-1')`, now.Add(-time.Hour), now)
+	if err != nil {
+		t.Fatalf("insert legacy trade: %v", err)
+	}
+	db.Close()
+
+	repo, err := NewSQLiteRepository(path)
+	if err != nil {
+		t.Fatalf("open legacy database: %v", err)
+	}
+	defer repo.Close()
+
+	// Migrating twice must not try to add the column again
+	ctx := context.Background()
+	if err := repo.Migrate(ctx); err != nil {
+		t.Fatalf("second migrate: %v", err)
+	}
+
+	if err := repo.SaveTrade(ctx, types.Trade{ID: "new", Symbol: "MES", EntryTime: now, ExitTime: now, ExitReason: "stop_loss"}); err != nil {
+		t.Fatalf("save trade: %v", err)
+	}
+
+	trades, err := repo.GetTradesBySymbol(ctx, "MES", 10)
+	if err != nil {
+		t.Fatalf("get trades: %v", err)
+	}
+	reasons := make(map[string]string)
+	for _, trade := range trades {
+		reasons[trade.ID] = trade.ExitReason
+	}
+	if reason, ok := reasons["old"]; !ok || reason != "" {
+		t.Errorf("legacy trade reason = %q (found %v), want empty", reason, ok)
+	}
+	if reasons["new"] != "stop_loss" {
+		t.Errorf("new trade reason = %q, want stop_loss", reasons["new"])
+	}
+}
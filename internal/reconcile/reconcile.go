@@ -0,0 +1,238 @@
+// Package reconcile compares persisted positions against the broker on startup.
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/alerting"
+	"github.com/tathienbao/quant-bot/internal/broker"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// PositionStore is the persisted side of reconciliation.
+// persistence.Repository satisfies this interface. ClosePosition receives a
+// zero exitPrice when the exit price is unknown.
+type PositionStore interface {
+	GetOpenPositions(ctx context.Context) ([]types.Position, error)
+	SavePosition(ctx context.Context, position types.Position) error
+	ClosePosition(ctx context.Context, positionID string, exitPrice decimal.Decimal, exitTime time.Time) error
+}
+
+// PositionSource reports the positions the broker actually holds.
+// broker.Broker satisfies this interface.
+type PositionSource interface {
+	GetPositions(ctx context.Context) ([]broker.Position, error)
+}
+
+// PriceSource reports the last price seen for a symbol.
+// Position sources that also implement it price stale positions.
+type PriceSource interface {
+	LastPrice(symbol string) (decimal.Decimal, bool)
+}
+
+// PositionTracker receives reconciled positions.
+// risk.Engine satisfies this interface.
+type PositionTracker interface {
+	UpdatePosition(position *types.Position)
+}
+
+// Kind classifies a reconciliation discrepancy.
+type Kind string
+
+const (
+	// KindStale is a persisted position the broker no longer holds.
+	// It is closed in the store.
+	KindStale Kind = "stale"
+	// KindUnknown is a broker position missing from the store.
+	// It is adopted into the store and risk engine.
+	KindUnknown Kind = "unknown"
+	// KindMismatch is a position whose side or size differs.
+	// The broker is treated as the source of truth.
+	KindMismatch Kind = "mismatch"
+)
+
+// Discrepancy describes one difference between store and broker.
+type Discrepancy struct {
+	Kind      Kind
+	Symbol    string
+	Persisted *types.Position  // nil for KindUnknown
+	Broker    *broker.Position // nil for KindStale
+	// ExitPrice is the price a KindStale position was closed at; zero when
+	// no price was known and no P&L could be recorded.
+	ExitPrice decimal.Decimal
+}
+
+// Result summarizes a reconciliation run.
+type Result struct {
+	Matched       int
+	Discrepancies []Discrepancy
+}
+
+// Reconciler resolves persisted positions against broker positions.
+type Reconciler struct {
+	store   PositionStore
+	source  PositionSource
+	tracker PositionTracker
+	alerter alerting.Alerter
+	logger  *slog.Logger
+
+	now func() time.Time
+}
+
+// NewReconciler creates a new position reconciler.
+// tracker and alerter are optional.
+func NewReconciler(store PositionStore, source PositionSource, tracker PositionTracker, alerter alerting.Alerter, logger *slog.Logger) *Reconciler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Reconciler{
+		store:   store,
+		source:  source,
+		tracker: tracker,
+		alerter: alerter,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Run compares persisted open positions with the broker and resolves
+// mismatches: stale store positions are closed, unknown broker positions are
+// adopted, and size/side mismatches take the broker's view. Every
+// discrepancy is logged and alerted.
+func (r *Reconciler) Run(ctx context.Context) (*Result, error) {
+	persisted, err := r.store.GetOpenPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get persisted positions: %w", err)
+	}
+
+	held, err := r.source.GetPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get broker positions: %w", err)
+	}
+
+	brokerBySymbol := make(map[string]broker.Position, len(held))
+	for _, pos := range held {
+		if pos.Contracts != 0 {
+			brokerBySymbol[pos.Symbol] = pos
+		}
+	}
+
+	result := &Result{}
+	seen := make(map[string]bool, len(persisted))
+
+	for i := range persisted {
+		stored := persisted[i]
+		seen[stored.Symbol] = true
+
+		actual, ok := brokerBySymbol[stored.Symbol]
+		switch {
+		case !ok:
+			exitPrice := r.lastPrice(stored.Symbol)
+			if err := r.store.ClosePosition(ctx, stored.ID, exitPrice, r.now()); err != nil {
+				return result, fmt.Errorf("close stale position %s: %w", stored.Symbol, err)
+			}
+			r.report(ctx, result, Discrepancy{Kind: KindStale, Symbol: stored.Symbol, Persisted: &stored, ExitPrice: exitPrice})
+
+		case actual.Side != stored.Side || actual.Contracts != stored.Contracts:
+			updated := stored
+			updated.Side = actual.Side
+			updated.Contracts = actual.Contracts
+			if err := r.store.SavePosition(ctx, updated); err != nil {
+				return result, fmt.Errorf("update position %s: %w", stored.Symbol, err)
+			}
+			r.track(&updated)
+			r.report(ctx, result, Discrepancy{Kind: KindMismatch, Symbol: stored.Symbol, Persisted: &stored, Broker: &actual})
+
+		default:
+			r.track(&stored)
+			result.Matched++
+		}
+	}
+
+	for symbol, actual := range brokerBySymbol {
+		if seen[symbol] {
+			continue
+		}
+		actual := actual
+
+		adopted := types.Position{
+			ID:         fmt.Sprintf("adopted-%s-%d", symbol, r.now().UnixNano()),
+			Symbol:     symbol,
+			Side:       actual.Side,
+			Contracts:  actual.Contracts,
+			EntryPrice: actual.AvgCost,
+			EntryTime:  r.now(),
+		}
+		if err := r.store.SavePosition(ctx, adopted); err != nil {
+			return result, fmt.Errorf("adopt position %s: %w", symbol, err)
+		}
+		r.track(&adopted)
+		r.report(ctx, result, Discrepancy{Kind: KindUnknown, Symbol: symbol, Broker: &actual})
+	}
+
+	r.logger.Info("position reconciliation complete",
+		"matched", result.Matched,
+		"discrepancies", len(result.Discrepancies),
+	)
+
+	return result, nil
+}
+
+// track passes a reconciled position to the risk engine.
+func (r *Reconciler) track(pos *types.Position) {
+	if r.tracker != nil {
+		r.tracker.UpdatePosition(pos)
+	}
+}
+
+// lastPrice returns the source's last price for symbol, or zero when the
+// source has none.
+func (r *Reconciler) lastPrice(symbol string) decimal.Decimal {
+	prices, ok := r.source.(PriceSource)
+	if !ok {
+		return decimal.Zero
+	}
+	if price, ok := prices.LastPrice(symbol); ok && price.IsPositive() {
+		return price
+	}
+	return decimal.Zero
+}
+
+// report records, logs and alerts a discrepancy.
+func (r *Reconciler) report(ctx context.Context, result *Result, d Discrepancy) {
+	result.Discrepancies = append(result.Discrepancies, d)
+
+	fields := []any{"symbol", d.Symbol, "kind", string(d.Kind)}
+	if d.Persisted != nil {
+		fields = append(fields, "persisted", fmt.Sprintf("%s %d", d.Persisted.Side, d.Persisted.Contracts))
+	}
+	if d.Broker != nil {
+		fields = append(fields, "broker", fmt.Sprintf("%s %d", d.Broker.Side, d.Broker.Contracts))
+	}
+	if d.Kind == KindStale {
+		if d.ExitPrice.IsPositive() {
+			fields = append(fields, "exit_price", d.ExitPrice.String())
+		} else {
+			fields = append(fields, "exit_price", "unknown", "pnl", "not recorded")
+		}
+	}
+
+	r.logger.Warn("position discrepancy resolved", fields...)
+
+	// Positions the bot didn't know about carry unmanaged risk, and a stale
+	// position with no exit price leaves its P&L for the operator to book
+	severity := alerting.SeverityHigh
+	if d.Kind == KindStale && d.ExitPrice.IsPositive() {
+		severity = alerting.SeverityWarning
+	}
+
+	if r.alerter != nil {
+		if err := r.alerter.Alert(ctx, severity, "Position discrepancy on startup", fields...); err != nil {
+			r.logger.Warn("failed to send reconciliation alert", "err", err)
+		}
+	}
+}
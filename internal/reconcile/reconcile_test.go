@@ -0,0 +1,209 @@
+package reconcile
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/alerting"
+	"github.com/tathienbao/quant-bot/internal/broker"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// mockStore is an in-memory PositionStore.
+type mockStore struct {
+	open       map[string]types.Position // id -> position
+	closed     []string
+	exitPrices map[string]decimal.Decimal // id -> exit price
+}
+
+func newMockStore(positions ...types.Position) *mockStore {
+	s := &mockStore{open: make(map[string]types.Position), exitPrices: make(map[string]decimal.Decimal)}
+	for _, p := range positions {
+		s.open[p.ID] = p
+	}
+	return s
+}
+
+func (s *mockStore) GetOpenPositions(ctx context.Context) ([]types.Position, error) {
+	positions := make([]types.Position, 0, len(s.open))
+	for _, p := range s.open {
+		positions = append(positions, p)
+	}
+	return positions, nil
+}
+
+func (s *mockStore) SavePosition(ctx context.Context, position types.Position) error {
+	s.open[position.ID] = position
+	return nil
+}
+
+func (s *mockStore) ClosePosition(ctx context.Context, positionID string, exitPrice decimal.Decimal, exitTime time.Time) error {
+	delete(s.open, positionID)
+	s.closed = append(s.closed, positionID)
+	s.exitPrices[positionID] = exitPrice
+	return nil
+}
+
+func (s *mockStore) bySymbol(symbol string) (types.Position, bool) {
+	for _, p := range s.open {
+		if p.Symbol == symbol {
+			return p, true
+		}
+	}
+	return types.Position{}, false
+}
+
+// mockSource reports fixed broker positions.
+type mockSource struct {
+	positions []broker.Position
+}
+
+func (m *mockSource) GetPositions(ctx context.Context) ([]broker.Position, error) {
+	return m.positions, nil
+}
+
+// pricedSource is a mockSource that also reports last prices.
+type pricedSource struct {
+	mockSource
+	prices map[string]decimal.Decimal
+}
+
+func (m *pricedSource) LastPrice(symbol string) (decimal.Decimal, bool) {
+	price, ok := m.prices[symbol]
+	return price, ok
+}
+
+// mockTracker records positions passed to the risk engine.
+type mockTracker struct {
+	positions map[string]types.Position
+}
+
+func (m *mockTracker) UpdatePosition(position *types.Position) {
+	m.positions[position.Symbol] = *position
+}
+
+func TestReconciler_Run(t *testing.T) {
+	store := newMockStore(
+		types.Position{ID: "db-mes", Symbol: "MES", Side: types.SideLong, Contracts: 2, EntryPrice: decimal.NewFromInt(5000)},
+		types.Position{ID: "db-mgc", Symbol: "MGC", Side: types.SideShort, Contracts: 1, EntryPrice: decimal.NewFromInt(2000)},
+		types.Position{ID: "db-m2k", Symbol: "M2K", Side: types.SideLong, Contracts: 1, EntryPrice: decimal.NewFromInt(2100)},
+	)
+	source := &mockSource{positions: []broker.Position{
+		// MES matches, MGC size differs, M2K closed while offline, MNQ unknown
+		{Symbol: "MES", Side: types.SideLong, Contracts: 2, AvgCost: decimal.NewFromInt(5000)},
+		{Symbol: "MGC", Side: types.SideShort, Contracts: 3, AvgCost: decimal.NewFromInt(2000)},
+		{Symbol: "MNQ", Side: types.SideShort, Contracts: 1, AvgCost: decimal.NewFromInt(18000)},
+	}}
+	tracker := &mockTracker{positions: make(map[string]types.Position)}
+	alerter := alerting.NewMockAlerter()
+
+	result, err := NewReconciler(store, source, tracker, alerter, nil).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if result.Matched != 1 {
+		t.Errorf("Matched = %d, want 1", result.Matched)
+	}
+	kinds := make(map[string]Kind)
+	for _, d := range result.Discrepancies {
+		kinds[d.Symbol] = d.Kind
+	}
+	want := map[string]Kind{"MGC": KindMismatch, "M2K": KindStale, "MNQ": KindUnknown}
+	for symbol, kind := range want {
+		if kinds[symbol] != kind {
+			t.Errorf("%s discrepancy = %q, want %q", symbol, kinds[symbol], kind)
+		}
+	}
+
+	// Stale DB position closed
+	if len(store.closed) != 1 || store.closed[0] != "db-m2k" {
+		t.Errorf("closed = %v, want [db-m2k]", store.closed)
+	}
+
+	// Mismatch takes the broker's size
+	if mgc, ok := store.bySymbol("MGC"); !ok || mgc.Contracts != 3 {
+		t.Errorf("MGC persisted contracts = %d, want 3", mgc.Contracts)
+	}
+
+	// Unknown broker position adopted into store and risk engine
+	if mnq, ok := store.bySymbol("MNQ"); !ok || mnq.Side != types.SideShort {
+		t.Error("expected MNQ to be adopted into the store")
+	}
+	for _, symbol := range []string{"MES", "MGC", "MNQ"} {
+		if _, ok := tracker.positions[symbol]; !ok {
+			t.Errorf("expected %s to be tracked by the risk engine", symbol)
+		}
+	}
+	if _, ok := tracker.positions["M2K"]; ok {
+		t.Error("stale position should not be tracked")
+	}
+
+	if alerter.Count() != 3 {
+		t.Errorf("alerts = %d, want 3", alerter.Count())
+	}
+	if !alerter.HasAlertWithSeverity(alerting.SeverityHigh) {
+		t.Error("expected high severity alert for unknown/mismatched positions")
+	}
+}
+
+func TestReconciler_Run_InSync(t *testing.T) {
+	store := newMockStore(types.Position{ID: "db-mes", Symbol: "MES", Side: types.SideLong, Contracts: 1})
+	source := &mockSource{positions: []broker.Position{{Symbol: "MES", Side: types.SideLong, Contracts: 1}}}
+	alerter := alerting.NewMockAlerter()
+
+	result, err := NewReconciler(store, source, nil, alerter, nil).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(result.Discrepancies) != 0 {
+		t.Errorf("Discrepancies = %d, want 0", len(result.Discrepancies))
+	}
+	if alerter.Count() != 0 {
+		t.Errorf("alerts = %d, want 0 when in sync", alerter.Count())
+	}
+}
+
+func TestReconciler_Run_StaleExitPrice(t *testing.T) {
+	stale := types.Position{ID: "db-m2k", Symbol: "M2K", Side: types.SideLong, Contracts: 1, EntryPrice: decimal.NewFromInt(2100)}
+
+	t.Run("last price known", func(t *testing.T) {
+		store := newMockStore(stale)
+		source := &pricedSource{prices: map[string]decimal.Decimal{"M2K": decimal.NewFromInt(2110)}}
+		alerter := alerting.NewMockAlerter()
+
+		result, err := NewReconciler(store, source, nil, alerter, nil).Run(context.Background())
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+
+		if got := store.exitPrices["db-m2k"]; !got.Equal(decimal.NewFromInt(2110)) {
+			t.Errorf("exit price = %s, want 2110", got)
+		}
+		if len(result.Discrepancies) != 1 || !result.Discrepancies[0].ExitPrice.Equal(decimal.NewFromInt(2110)) {
+			t.Errorf("Discrepancies = %+v, want one stale at 2110", result.Discrepancies)
+		}
+		if alerter.HasAlertWithSeverity(alerting.SeverityHigh) {
+			t.Error("priced stale position should alert as a warning")
+		}
+	})
+
+	t.Run("no price", func(t *testing.T) {
+		store := newMockStore(stale)
+		alerter := alerting.NewMockAlerter()
+
+		if _, err := NewReconciler(store, &mockSource{}, nil, alerter, nil).Run(context.Background()); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+
+		if got := store.exitPrices["db-m2k"]; !got.IsZero() {
+			t.Errorf("exit price = %s, want zero (unknown)", got)
+		}
+		if !alerter.HasAlertWithSeverity(alerting.SeverityHigh) {
+			t.Error("expected high severity alert when no exit price is known")
+		}
+	})
+}
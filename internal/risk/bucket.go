@@ -0,0 +1,140 @@
+package risk
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// BucketConfig gives one strategy its own drawdown budget inside the
+// account. The bucket's equity starts at Allocation and moves with the
+// realized P&L recorded for the strategy.
+type BucketConfig struct {
+	Name           string          // Strategy name, matched against Signal.StrategyName
+	Allocation     decimal.Decimal // Capital the bucket's drawdown is measured against
+	MaxDrawdownPct decimal.Decimal // e.g., 0.10 stops the strategy at 10% below its peak
+}
+
+// bucket tracks one strategy's equity and kill switch.
+type bucket struct {
+	cfg        BucketConfig
+	hwm        *HighWaterMarkTracker
+	safeMode   bool
+	safeModeAt time.Time
+}
+
+// BucketSnapshot is the state of one risk bucket.
+type BucketSnapshot struct {
+	Name          string
+	Equity        decimal.Decimal
+	HighWaterMark decimal.Decimal
+	Drawdown      decimal.Decimal // As ratio (0.15 = 15%)
+	MaxDrawdown   decimal.Decimal
+	SafeMode      bool
+}
+
+// newBuckets creates the trackers for the configured buckets.
+func newBuckets(configs []BucketConfig) map[string]*bucket {
+	buckets := make(map[string]*bucket, len(configs))
+	for _, cfg := range configs {
+		buckets[cfg.Name] = &bucket{cfg: cfg, hwm: NewHighWaterMarkTracker(cfg.Allocation)}
+	}
+	return buckets
+}
+
+// checkBucketLocked rejects signals from a strategy whose bucket has hit
+// its drawdown limit. Strategies without a bucket only face the global cap.
+func (e *Engine) checkBucketLocked(strategyName string) error {
+	b, ok := e.buckets[strategyName]
+	if !ok {
+		return nil
+	}
+	if !b.safeMode && b.hwm.Drawdown().GreaterThanOrEqual(b.cfg.MaxDrawdownPct) {
+		e.enterBucketSafeModeLocked(b)
+	}
+	if b.safeMode {
+		return fmt.Errorf("%w: bucket %s", types.ErrKillSwitchActive, strategyName)
+	}
+	return nil
+}
+
+// RecordBucketPnL applies realized P&L to the named strategy's bucket and
+// stops the strategy once its drawdown limit is reached. P&L for a strategy
+// without a bucket is ignored.
+func (e *Engine) RecordBucketPnL(strategyName string, pnl decimal.Decimal) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	b, ok := e.buckets[strategyName]
+	if !ok {
+		return
+	}
+	b.hwm.Update(b.hwm.Current().Add(pnl))
+	if b.hwm.Drawdown().GreaterThanOrEqual(b.cfg.MaxDrawdownPct) {
+		e.enterBucketSafeModeLocked(b)
+	}
+}
+
+// IsBucketInSafeMode returns true if the strategy's bucket has stopped it.
+func (e *Engine) IsBucketInSafeMode(strategyName string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	b, ok := e.buckets[strategyName]
+	return ok && b.safeMode
+}
+
+// ExitBucketSafeMode lets a stopped strategy trade again (manual reset).
+// The bucket keeps its peak, so it stops again on further losses.
+func (e *Engine) ExitBucketSafeMode(strategyName string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if b, ok := e.buckets[strategyName]; ok && b.safeMode {
+		b.safeMode = false
+		e.logger.Warn("bucket safe mode exited manually", "bucket", strategyName)
+	}
+}
+
+// BucketSnapshots returns the state of every bucket, sorted by name.
+func (e *Engine) BucketSnapshots() []BucketSnapshot {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	snapshots := make([]BucketSnapshot, 0, len(e.buckets))
+	for name, b := range e.buckets {
+		current, peak, drawdown := b.hwm.Snapshot()
+		snapshots = append(snapshots, BucketSnapshot{
+			Name:          name,
+			Equity:        current,
+			HighWaterMark: peak,
+			Drawdown:      drawdown,
+			MaxDrawdown:   b.cfg.MaxDrawdownPct,
+			SafeMode:      b.safeMode,
+		})
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Name < snapshots[j].Name })
+	return snapshots
+}
+
+// enterBucketSafeModeLocked stops one strategy. Must be called with lock held.
+func (e *Engine) enterBucketSafeModeLocked(b *bucket) {
+	if b.safeMode {
+		return
+	}
+
+	b.safeMode = true
+	b.safeModeAt = time.Now()
+
+	current, peak, drawdown := b.hwm.Snapshot()
+	e.logger.Error("BUCKET KILL SWITCH ACTIVATED - strategy stopped",
+		"bucket", b.cfg.Name,
+		"equity", current,
+		"peak", peak,
+		"drawdown", drawdown,
+		"max_drawdown", b.cfg.MaxDrawdownPct,
+	)
+}
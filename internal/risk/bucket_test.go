@@ -0,0 +1,86 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func TestEngine_RiskBuckets(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Buckets = []BucketConfig{
+		{Name: "grid", Allocation: decimal.NewFromInt(5000), MaxDrawdownPct: decimal.RequireFromString("0.10")},
+		{Name: "breakout", Allocation: decimal.NewFromInt(5000), MaxDrawdownPct: decimal.RequireFromString("0.10")},
+	}
+	engine := NewEngine(cfg, decimal.NewFromInt(10000), nil)
+
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)}
+	signal := func(strategyName string) types.Signal {
+		return types.Signal{ID: "sig-" + strategyName, Symbol: "MES", Direction: types.SideLong, StopTicks: 10, StrategyName: strategyName}
+	}
+
+	// Grid makes $200 then loses $500: 500/5200 = 9.6% below its peak, still trading
+	engine.RecordBucketPnL("grid", decimal.NewFromInt(200))
+	engine.RecordBucketPnL("grid", decimal.NewFromInt(-500))
+	if _, err := engine.ValidateAndSize(context.Background(), signal("grid"), event); err != nil {
+		t.Fatalf("grid within its budget: ValidateAndSize() error = %v", err)
+	}
+
+	// Another $20 loss reaches 10%
+	engine.RecordBucketPnL("grid", decimal.NewFromInt(-20))
+	if !engine.IsBucketInSafeMode("grid") {
+		t.Fatal("grid bucket should be in safe mode")
+	}
+	if _, err := engine.ValidateAndSize(context.Background(), signal("grid"), event); !errors.Is(err, types.ErrKillSwitchActive) {
+		t.Errorf("grid over budget: err = %v, want ErrKillSwitchActive", err)
+	}
+
+	// Other strategies and unbucketed ones keep trading; the account isn't stopped
+	for _, name := range []string{"breakout", "meanrev"} {
+		if _, err := engine.ValidateAndSize(context.Background(), signal(name), event); err != nil {
+			t.Errorf("%s: ValidateAndSize() error = %v", name, err)
+		}
+	}
+	if engine.IsInSafeMode() {
+		t.Error("a bucket breach should not enter global safe mode")
+	}
+
+	snapshots := engine.BucketSnapshots()
+	if len(snapshots) != 2 || snapshots[0].Name != "breakout" || snapshots[1].Name != "grid" {
+		t.Fatalf("BucketSnapshots() = %+v, want breakout and grid", snapshots)
+	}
+	grid := snapshots[1]
+	if !grid.SafeMode || !grid.Equity.Equal(decimal.NewFromInt(4680)) || !grid.HighWaterMark.Equal(decimal.NewFromInt(5200)) {
+		t.Errorf("grid snapshot = %+v, want safe mode at 4680 from a 5200 peak", grid)
+	}
+
+	// Manual reset lets the strategy trade again
+	engine.ExitBucketSafeMode("grid")
+	if engine.IsBucketInSafeMode("grid") {
+		t.Error("grid bucket should leave safe mode after a manual reset")
+	}
+}
+
+func TestEngine_RiskBucketsGlobalCap(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Buckets = []BucketConfig{
+		{Name: "grid", Allocation: decimal.NewFromInt(10000), MaxDrawdownPct: decimal.RequireFromString("0.50")},
+	}
+	engine := NewEngine(cfg, decimal.NewFromInt(10000), nil)
+
+	// The account hits its 20% cap long before the bucket's 50%
+	engine.RecordBucketPnL("grid", decimal.NewFromInt(-2500))
+	engine.UpdateEquity(decimal.NewFromInt(7500))
+
+	signal := types.Signal{ID: "sig-cap", Symbol: "MES", Direction: types.SideLong, StopTicks: 10, StrategyName: "grid"}
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)}
+	if _, err := engine.ValidateAndSize(context.Background(), signal, event); !errors.Is(err, types.ErrKillSwitchActive) {
+		t.Errorf("err = %v, want ErrKillSwitchActive from the global cap", err)
+	}
+	if engine.IsBucketInSafeMode("grid") {
+		t.Error("bucket should not be in safe mode below its own limit")
+	}
+}
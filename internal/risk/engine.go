@@ -2,6 +2,7 @@ package risk
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -15,38 +16,114 @@ import (
 // Config holds the risk engine configuration.
 type Config struct {
 	MaxGlobalDrawdownPct    decimal.Decimal // e.g., 0.20 for 20%
+	DrawdownWarnPct         decimal.Decimal // Share of MaxGlobalDrawdownPct that raises a warning, e.g. 0.75 (0 = off)
 	RiskPerTradePct         decimal.Decimal // e.g., 0.01 for 1%
 	MaxExposurePerSymbolPct decimal.Decimal // e.g., 0.50 for 50%
 	MaxTotalExposurePct     decimal.Decimal // e.g., 1.00 for 100%
 	StopLossATRMultiple     decimal.Decimal // e.g., 2.0
 	TakeProfitATRMultiple   decimal.Decimal // e.g., 3.0
+	MinStopTicks            int             // Floor for the stop distance; tighter stops are widened (0 = off)
+	MaxStopTicks            int             // Ceiling for the stop distance; wider stops are rejected (0 = off)
+	MaxContractsPerOrder    int             // Hard cap on contracts per order (0 = unlimited)
+	MaxOpenPositions        int             // Cap on symbols with an open position (0 = unlimited)
+	MaxMiniEquivalent       decimal.Decimal // Cap on contracts across a micro/mini pair, in minis (e.g. 2 = 2 ES or 20 MES; 0 = unlimited)
+	SignalValidity          time.Duration   // Default order expiry when the signal sets none (0 = 5m)
+	AllowLong               bool            // Accept long signals
+	AllowShort              bool            // Accept short signals
+
+	// Sizing: risk-based by default, or a fixed contract count or notional
+	SizingMode     SizingMode      // How contracts are counted (default: risk based)
+	FixedContracts int             // Contracts per order with SizingFixedContracts
+	FixedNotional  decimal.Decimal // USD notional per order with SizingFixedNotional
+
+	// Drawdown scaling: risk less per trade as the kill switch gets closer
+	RiskScalingCurve RiskScalingCurve // Multiplies RiskPerTradePct (nil = constant risk)
+
+	// Cooldown after a manual ExitSafeMode, against revenge trading
+	PostKillSwitchCooldown   time.Duration   // How long reduced risk lasts after leaving safe mode (0 = off)
+	PostKillSwitchRiskFactor decimal.Decimal // Multiplies RiskPerTradePct during the cooldown (0 = block new entries)
+
+	// Free margin: equity less the margin held by open positions
+	MarginPolicy MarginPolicy // What to do with orders free margin can't cover (default: unchecked)
+
+	// Position tracking: which contracts a partial close removes
+	CostBasis CostBasis // Entry price left after a reduction (default: average cost)
+
+	// Entry price: what orders are sized and bracketed from
+	EntryPriceSource EntryPriceSource // Signal bar close, next bar open or quote mid (default: close)
+
+	// Per-strategy drawdown budgets; MaxGlobalDrawdownPct still caps the account
+	Buckets []BucketConfig
+
+	// Scale-out targets; contracts they leave run to TakeProfit or the stop (nil = single exit)
+	TakeProfitTranches []TrancheConfig
+
+	// Cost filter: reject targets that barely cover trading costs
+	MinNetProfitPerContract decimal.Decimal // Min take-profit gain per contract after round-trip costs (0 = disabled)
+	CommissionPerSide       decimal.Decimal // Estimated commission per contract per side
+	ItemizedFees            bool            // CommissionPerSide excludes exchange fees, as with tiered pricing
+	SlippageTicks           int             // Estimated slippage per side in ticks
+
+	// Daily limits (prop-firm style)
+	MaxDailyLossPct      decimal.Decimal // e.g., 0.03 for 3% of session-start equity (0 = disabled)
+	DailyProfitTargetPct decimal.Decimal // e.g., 0.02 stops new entries after +2% on the day (0 = disabled)
+	SessionLocation      *time.Location  // Market timezone for the trading day boundary (nil = UTC)
+	SessionStartTime     time.Duration   // Trading day start as offset from midnight (e.g., 17h for CME)
+}
+
+// TrancheConfig closes Fraction of an order's contracts at ATRMultiple,
+// measured like TakeProfitATRMultiple.
+type TrancheConfig struct {
+	ATRMultiple decimal.Decimal // e.g., 1.5
+	Fraction    decimal.Decimal // e.g., 0.5 closes half the contracts
 }
 
 // DefaultConfig returns a conservative default configuration.
 func DefaultConfig() Config {
 	return Config{
 		MaxGlobalDrawdownPct:    decimal.RequireFromString("0.20"),
+		DrawdownWarnPct:         decimal.RequireFromString("0.75"),
 		RiskPerTradePct:         decimal.RequireFromString("0.01"),
 		MaxExposurePerSymbolPct: decimal.RequireFromString("0.50"),
 		MaxTotalExposurePct:     decimal.RequireFromString("1.00"),
 		StopLossATRMultiple:     decimal.RequireFromString("2.0"),
 		TakeProfitATRMultiple:   decimal.RequireFromString("3.0"),
+		SignalValidity:          DefaultSignalValidity,
+		AllowLong:               true,
+		AllowShort:              true,
 	}
 }
 
+// DefaultSignalValidity is the order expiry used when neither the signal
+// nor the config specifies one.
+const DefaultSignalValidity = 5 * time.Minute
+
 // Engine is the main risk management engine.
 // It validates signals, calculates position sizes, and enforces risk limits.
 // Thread-safe for concurrent access.
 type Engine struct {
 	mu sync.RWMutex
 
-	cfg       Config
-	hwm       *HighWaterMarkTracker
-	sizers    map[string]*PositionSizer // symbol -> sizer
-	positions map[string]*types.Position // symbol -> position
-
-	safeMode   bool
-	safeModeAt time.Time
+	cfg        Config
+	hwm        *HighWaterMarkTracker
+	sizers     map[string]*PositionSizer  // symbol -> sizer
+	positions  map[string]*types.Position // symbol -> position
+	lots       map[string][]lot           // symbol -> open contracts by entry price, oldest first
+	marginUsed map[string]decimal.Decimal // symbol -> margin held by the position
+	buckets    map[string]*bucket         // strategy name -> drawdown budget
+
+	safeMode      bool
+	safeModeAt    time.Time
+	drawdownWarn  bool             // Drawdown reached the warning level; cleared on recovery below it
+	cooldownUntil time.Time        // End of the post kill switch cooldown (zero = none)
+	now           func() time.Time // Wall clock for safe mode and cooldown; replaced in tests
+
+	// Daily session tracking
+	sessionStart       time.Time       // Start of the current trading day
+	sessionStartEquity decimal.Decimal // Equity when the trading day started
+	sessionPL          decimal.Decimal // Realized P&L for the current trading day
+	dailyLossHit       bool
+	dailyTargetHit     bool
 
 	logger *slog.Logger
 }
@@ -58,11 +135,15 @@ func NewEngine(cfg Config, initialEquity decimal.Decimal, logger *slog.Logger) *
 	}
 
 	return &Engine{
-		cfg:       cfg,
-		hwm:       NewHighWaterMarkTracker(initialEquity),
-		sizers:    make(map[string]*PositionSizer),
-		positions: make(map[string]*types.Position),
-		logger:    logger,
+		cfg:        cfg,
+		hwm:        NewHighWaterMarkTracker(initialEquity),
+		sizers:     make(map[string]*PositionSizer),
+		positions:  make(map[string]*types.Position),
+		lots:       make(map[string][]lot),
+		marginUsed: make(map[string]decimal.Decimal),
+		buckets:    newBuckets(cfg.Buckets),
+		now:        time.Now,
+		logger:     logger,
 	}
 }
 
@@ -87,6 +168,14 @@ func (e *Engine) ValidateAndSize(ctx context.Context, signal types.Signal, marke
 		)
 		return nil, types.ErrKillSwitchActive
 	}
+	if err := e.checkCooldownLocked(); err != nil {
+		e.logger.Warn("signal rejected: kill switch cooldown active",
+			"signal_id", signal.ID,
+			"symbol", signal.Symbol,
+			"remaining", e.cooldownRemainingLocked(),
+		)
+		return nil, err
+	}
 
 	// Check drawdown - if already in drawdown territory, enter safe mode first
 	drawdown := e.hwm.Drawdown()
@@ -97,12 +186,163 @@ func (e *Engine) ValidateAndSize(ctx context.Context, signal types.Signal, marke
 		return nil, types.ErrKillSwitchActive
 	}
 
+	// The strategy's own drawdown budget
+	if err := e.checkBucketLocked(signal.StrategyName); err != nil {
+		e.logger.Warn("signal rejected: bucket safe mode active",
+			"signal_id", signal.ID,
+			"bucket", signal.StrategyName,
+		)
+		return nil, err
+	}
+
+	// Direction filter applies before any sizing
+	if err := e.checkDirection(signal.Direction); err != nil {
+		e.logger.Info("signal rejected: direction not allowed",
+			"signal_id", signal.ID,
+			"symbol", signal.Symbol,
+			"direction", signal.Direction,
+		)
+		return nil, err
+	}
+
+	if err := e.checkOpenPositions(signal.Symbol); err != nil {
+		e.logger.Info("signal rejected: max open positions reached",
+			"signal_id", signal.ID,
+			"symbol", signal.Symbol,
+			"open_positions", len(e.positions),
+		)
+		return nil, err
+	}
+
+	// Check daily loss limit (resets at the session boundary)
+	now := marketEvent.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+	e.rollSessionLocked(now)
+	if e.dailyLossHit {
+		e.logger.Warn("signal rejected: daily loss limit reached",
+			"signal_id", signal.ID,
+			"symbol", signal.Symbol,
+			"daily_pl", e.sessionPL,
+		)
+		return nil, fmt.Errorf("%w: session P&L %s", types.ErrDailyLossLimit, e.sessionPL.StringFixed(2))
+	}
+	if e.dailyTargetHit {
+		e.logger.Info("signal rejected: daily profit target reached",
+			"signal_id", signal.ID,
+			"symbol", signal.Symbol,
+			"daily_pl", e.sessionPL,
+		)
+		return nil, fmt.Errorf("%w: session P&L %s", types.ErrDailyTargetReached, e.sessionPL.StringFixed(2))
+	}
+
 	// Get or create sizer for symbol
 	sizer, err := e.getOrCreateSizer(signal.Symbol)
 	if err != nil {
 		return nil, fmt.Errorf("create sizer: %w", err)
 	}
 
+	intent, err := e.sizeLocked(signal, marketEvent, sizer, e.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.Info("order intent created",
+		"order_id", intent.ID,
+		"client_order_id", intent.ClientOrderID,
+		"symbol", intent.Symbol,
+		"side", intent.Side,
+		"contracts", intent.Contracts,
+		"entry", intent.EntryPrice,
+		"stop_loss", intent.StopLoss,
+		"take_profit", intent.TakeProfit,
+		"risk_amount", intent.RiskAmount,
+	)
+
+	return intent, nil
+}
+
+// PreviewSize returns the order intent ValidateAndSize would produce for a
+// hypothetical signal at current equity, without placing anything. It is
+// read-only: a breached drawdown is reported but doesn't enter safe mode,
+// and session limits are evaluated as if the session rolled at the event time.
+func (e *Engine) PreviewSize(signal types.Signal, marketEvent types.MarketEvent) (*types.OrderIntent, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.safeMode || e.hwm.Drawdown().GreaterThanOrEqual(e.cfg.MaxGlobalDrawdownPct) {
+		return nil, types.ErrKillSwitchActive
+	}
+	if err := e.checkCooldownLocked(); err != nil {
+		return nil, err
+	}
+	if b, ok := e.buckets[signal.StrategyName]; ok && (b.safeMode || b.hwm.Drawdown().GreaterThanOrEqual(b.cfg.MaxDrawdownPct)) {
+		return nil, fmt.Errorf("%w: bucket %s", types.ErrKillSwitchActive, signal.StrategyName)
+	}
+
+	if err := e.checkDirection(signal.Direction); err != nil {
+		return nil, err
+	}
+	if err := e.checkOpenPositions(signal.Symbol); err != nil {
+		return nil, err
+	}
+
+	// A new session would clear the daily flags
+	now := marketEvent.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if !e.sessionStartFor(now).After(e.sessionStart) {
+		if e.dailyLossHit {
+			return nil, fmt.Errorf("%w: session P&L %s", types.ErrDailyLossLimit, e.sessionPL.StringFixed(2))
+		}
+		if e.dailyTargetHit {
+			return nil, fmt.Errorf("%w: session P&L %s", types.ErrDailyTargetReached, e.sessionPL.StringFixed(2))
+		}
+	}
+
+	sizer, ok := e.sizers[signal.Symbol]
+	if !ok {
+		var err error
+		if sizer, err = NewPositionSizerForSymbol(signal.Symbol); err != nil {
+			return nil, fmt.Errorf("create sizer: %w", err)
+		}
+	}
+
+	return e.sizeLocked(signal, marketEvent, sizer, slog.New(slog.DiscardHandler))
+}
+
+// checkDirection rejects sides disabled by AllowLong/AllowShort.
+func (e *Engine) checkDirection(side types.Side) error {
+	switch {
+	case side == types.SideLong && !e.cfg.AllowLong:
+		return fmt.Errorf("%w: long entries disabled", types.ErrDirectionNotAllowed)
+	case side == types.SideShort && !e.cfg.AllowShort:
+		return fmt.Errorf("%w: short entries disabled", types.ErrDirectionNotAllowed)
+	}
+	return nil
+}
+
+// checkOpenPositions rejects an entry in a new symbol once MaxOpenPositions
+// symbols are open. Adding to an existing position is always allowed here;
+// the exposure limits still apply. Caller must hold e.mu.
+func (e *Engine) checkOpenPositions(symbol string) error {
+	if e.cfg.MaxOpenPositions <= 0 {
+		return nil
+	}
+	if _, ok := e.positions[symbol]; ok {
+		return nil
+	}
+	if len(e.positions) >= e.cfg.MaxOpenPositions {
+		return fmt.Errorf("%w: %d open, max %d", types.ErrMaxPositionsReached, len(e.positions), e.cfg.MaxOpenPositions)
+	}
+	return nil
+}
+
+// sizeLocked computes the stop, size, exposure and target checks for a
+// signal and builds its order intent. Caller must hold e.mu (read or write).
+func (e *Engine) sizeLocked(signal types.Signal, marketEvent types.MarketEvent, sizer *PositionSizer, logger *slog.Logger) (*types.OrderIntent, error) {
 	// Get instrument spec
 	spec, ok := types.GetInstrumentSpec(signal.Symbol)
 	if !ok {
@@ -114,34 +354,119 @@ func (e *Engine) ValidateAndSize(ctx context.Context, signal types.Signal, marke
 	if stopTicks <= 0 {
 		// Use ATR-based stop if not specified
 		if marketEvent.ATR.IsZero() {
-			return nil, fmt.Errorf("no stop distance and ATR unavailable")
+			return nil, types.Reject(types.RejectNoStop, fmt.Errorf("no stop distance and ATR unavailable"))
 		}
 		atrStop := marketEvent.ATR.Mul(e.cfg.StopLossATRMultiple)
 		stopTicks = int(atrStop.Div(spec.TickSize).Ceil().IntPart())
 	}
 
+	// Quiet markets give tiny stops and huge positions that noise stops out
+	if e.cfg.MinStopTicks > 0 && stopTicks < e.cfg.MinStopTicks {
+		logger.Info("stop distance clamped to minimum",
+			"signal_id", signal.ID,
+			"stop_ticks", stopTicks,
+			"min_stop_ticks", e.cfg.MinStopTicks,
+		)
+		stopTicks = e.cfg.MinStopTicks
+	}
+	if e.cfg.MaxStopTicks > 0 && stopTicks > e.cfg.MaxStopTicks {
+		logger.Info("signal rejected: stop too wide",
+			"signal_id", signal.ID,
+			"stop_ticks", stopTicks,
+			"max_stop_ticks", e.cfg.MaxStopTicks,
+		)
+		return nil, fmt.Errorf("%w: %d ticks > max %d", types.ErrStopTooWide, stopTicks, e.cfg.MaxStopTicks)
+	}
+
 	// Calculate position size
+	entry := e.entryPrice(marketEvent)
 	equity := e.hwm.Current()
-	result := sizer.CalculateWithDetails(
-		equity,
-		e.cfg.RiskPerTradePct,
-		stopTicks,
-		marketEvent.Close,
-		signal.Direction,
-		spec.TickSize,
-	)
+	// Drawdown and cooldown scaling apply to every sizing mode
+	riskScale := decimal.NewFromInt(1)
+	if e.cfg.RiskScalingCurve != nil {
+		drawdown := e.hwm.Drawdown()
+		riskScale = riskScale.Mul(e.cfg.RiskScalingCurve.Scale(drawdown, e.cfg.MaxGlobalDrawdownPct))
+		if !riskScale.Equal(decimal.NewFromInt(1)) {
+			logger.Debug("risk per trade scaled for drawdown",
+				"signal_id", signal.ID,
+				"drawdown", drawdown,
+				"risk_scale", riskScale,
+			)
+		}
+	}
+	if remaining := e.cooldownRemainingLocked(); remaining > 0 {
+		riskScale = riskScale.Mul(e.cfg.PostKillSwitchRiskFactor)
+		logger.Debug("risk per trade scaled for kill switch cooldown",
+			"signal_id", signal.ID,
+			"remaining", remaining,
+			"risk_scale", riskScale,
+		)
+	}
+	riskPct := e.cfg.RiskPerTradePct.Mul(riskScale)
+	var result SizeResult
+	if e.cfg.SizingMode == SizingRiskBased {
+		result = sizer.CalculateWithDetails(
+			equity,
+			riskPct,
+			stopTicks,
+			entry,
+			signal.Direction,
+			spec.TickSize,
+		)
+	} else {
+		result = e.fixedSize(stopTicks, entry, signal.Direction, spec, riskScale)
+	}
 
 	if !result.Valid {
-		e.logger.Info("signal rejected: position sizing failed",
+		logger.Info("signal rejected: position sizing failed",
 			"signal_id", signal.ID,
 			"reason", result.RejectReason,
 		)
 		return nil, fmt.Errorf("%w: %s", types.ErrInsufficientEquity, result.RejectReason)
 	}
 
+	// Cap size regardless of what the stop allows; micros have thin books
+	if e.cfg.MaxContractsPerOrder > 0 && result.Contracts > e.cfg.MaxContractsPerOrder {
+		capped := sizer.AdjustForMaxSize(result.Contracts, e.cfg.MaxContractsPerOrder)
+		logger.Info("position size capped",
+			"signal_id", signal.ID,
+			"contracts", result.Contracts,
+			"max_contracts", capped,
+		)
+		result.RiskAmount = spec.TickValue.Mul(decimal.NewFromInt(int64(stopTicks))).Mul(decimal.NewFromInt(int64(capped)))
+		result.Contracts = capped
+	}
+
+	// Don't open what the account couldn't fund next to its open positions
+	fitted, err := e.fitFreeMarginLocked(signal.Direction, result.Contracts, spec)
+	if err != nil {
+		logger.Info("signal rejected: insufficient margin",
+			"signal_id", signal.ID,
+			"error", err,
+		)
+		return nil, err
+	}
+	if fitted < result.Contracts {
+		logger.Info("position size reduced to free margin",
+			"signal_id", signal.ID,
+			"contracts", result.Contracts,
+			"fitted_contracts", fitted,
+		)
+		result.RiskAmount = spec.TickValue.Mul(decimal.NewFromInt(int64(stopTicks))).Mul(decimal.NewFromInt(int64(fitted)))
+		result.Contracts = fitted
+	}
+
 	// Check exposure limits
-	if err := e.checkExposureLimits(signal.Symbol, result.Contracts, marketEvent.Close, spec); err != nil {
-		e.logger.Info("signal rejected: exposure limit",
+	if err := e.checkExposureLimits(signal.Symbol, signal.Direction, result.Contracts, entry, spec); err != nil {
+		logger.Info("signal rejected: exposure limit",
+			"signal_id", signal.ID,
+			"error", err,
+		)
+		return nil, err
+	}
+
+	if err := e.checkMiniEquivalent(signal.Symbol, signal.Direction, result.Contracts); err != nil {
+		logger.Info("signal rejected: mini-equivalent limit",
 			"signal_id", signal.ID,
 			"error", err,
 		)
@@ -153,42 +478,78 @@ func (e *Engine) ValidateAndSize(ctx context.Context, signal types.Signal, marke
 	tpDistance := spec.TickSize.Mul(decimal.NewFromInt(int64(stopTicks))).Mul(e.cfg.TakeProfitATRMultiple.Div(e.cfg.StopLossATRMultiple))
 	switch signal.Direction {
 	case types.SideLong:
-		takeProfit = marketEvent.Close.Add(tpDistance)
+		takeProfit = entry.Add(tpDistance)
 	case types.SideShort:
-		takeProfit = marketEvent.Close.Sub(tpDistance)
+		takeProfit = entry.Sub(tpDistance)
+	}
+
+	// Check target covers round-trip costs with the required margin
+	if e.cfg.MinNetProfitPerContract.IsPositive() {
+		grossTarget := tpDistance.Mul(spec.PointValue)
+		commission := e.cfg.CommissionPerSide
+		if e.cfg.ItemizedFees {
+			commission = commission.Add(spec.ExchangeFee)
+		}
+		netTarget := grossTarget.Sub(spec.RoundTripCost(commission, e.cfg.SlippageTicks))
+		if netTarget.LessThan(e.cfg.MinNetProfitPerContract) {
+			logger.Info("signal rejected: target too small for costs",
+				"signal_id", signal.ID,
+				"gross_target", grossTarget,
+				"net_target", netTarget,
+				"min_net", e.cfg.MinNetProfitPerContract,
+			)
+			return nil, fmt.Errorf("%w: net %s < min %s per contract",
+				types.ErrTargetBelowCosts, netTarget.StringFixed(2), e.cfg.MinNetProfitPerContract.StringFixed(2))
+		}
+	}
+
+	// Order validity: signal override, then config default
+	validity := signal.ValidFor
+	if validity <= 0 {
+		validity = e.cfg.SignalValidity
+	}
+	if validity <= 0 {
+		validity = DefaultSignalValidity
 	}
 
 	// Create order intent
+	createdAt := time.Now()
 	intent := &types.OrderIntent{
 		ID:              uuid.New().String(),
-		ClientOrderID:   generateClientOrderID(),
-		Timestamp:       time.Now(),
+		ClientOrderID:   ClientOrderID(signal.ID, 0),
+		Timestamp:       createdAt,
 		Symbol:          signal.Symbol,
 		Side:            signal.Direction,
 		Contracts:       result.Contracts,
-		EntryPrice:      marketEvent.Close,
+		EntryPrice:      entry,
 		StopLoss:        result.StopLoss,
 		TakeProfit:      takeProfit,
 		RiskAmount:      result.RiskAmount,
 		SignalID:        signal.ID,
-		ExpiresAt:       time.Now().Add(5 * time.Minute),
+		ExpiresAt:       createdAt.Add(validity),
+		Tranches:        e.tranches(stopTicks, spec.TickSize),
 	}
 
-	e.logger.Info("order intent created",
-		"order_id", intent.ID,
-		"client_order_id", intent.ClientOrderID,
-		"symbol", intent.Symbol,
-		"side", intent.Side,
-		"contracts", intent.Contracts,
-		"entry", intent.EntryPrice,
-		"stop_loss", intent.StopLoss,
-		"take_profit", intent.TakeProfit,
-		"risk_amount", intent.RiskAmount,
-	)
-
 	return intent, nil
 }
 
+// tranches converts the configured scale-out targets into price offsets
+// from entry for a stop stopTicks away.
+func (e *Engine) tranches(stopTicks int, tickSize decimal.Decimal) []types.TakeProfitTranche {
+	if len(e.cfg.TakeProfitTranches) == 0 {
+		return nil
+	}
+	stopDistance := tickSize.Mul(decimal.NewFromInt(int64(stopTicks)))
+	tranches := make([]types.TakeProfitTranche, len(e.cfg.TakeProfitTranches))
+	for i, t := range e.cfg.TakeProfitTranches {
+		tranches[i] = types.TakeProfitTranche{
+			Offset:   stopDistance.Mul(t.ATRMultiple.Div(e.cfg.StopLossATRMultiple)),
+			Fraction: t.Fraction,
+		}
+	}
+	return tranches
+}
+
 // UpdateEquity updates the current equity and checks for drawdown limits.
 func (e *Engine) UpdateEquity(equity decimal.Decimal) {
 	e.mu.Lock()
@@ -206,19 +567,95 @@ func (e *Engine) UpdateEquity(equity decimal.Decimal) {
 	drawdown := e.hwm.Drawdown()
 	if drawdown.GreaterThanOrEqual(e.cfg.MaxGlobalDrawdownPct) {
 		e.enterSafeModeLocked("max drawdown exceeded")
+		return
 	}
+	e.checkDrawdownWarningLocked(drawdown)
 }
 
-// UpdatePosition updates or creates a position.
-func (e *Engine) UpdatePosition(position *types.Position) {
+// checkDrawdownWarningLocked flags the drawdown warning once drawdown
+// reaches DrawdownWarnPct of the limit, and clears it once drawdown
+// recovers below that level so the next approach warns again.
+func (e *Engine) checkDrawdownWarningLocked(drawdown decimal.Decimal) {
+	if !e.cfg.DrawdownWarnPct.IsPositive() {
+		return
+	}
+
+	level := e.cfg.MaxGlobalDrawdownPct.Mul(e.cfg.DrawdownWarnPct)
+	if drawdown.LessThan(level) {
+		e.drawdownWarn = false
+		return
+	}
+	if !e.drawdownWarn {
+		e.drawdownWarn = true
+		e.logger.Warn("drawdown approaching the kill switch limit",
+			"drawdown", drawdown,
+			"warn_level", level,
+			"limit", e.cfg.MaxGlobalDrawdownPct,
+		)
+	}
+}
+
+// IsDrawdownWarning returns true while drawdown is at or past the warning
+// level set by DrawdownWarnPct.
+func (e *Engine) IsDrawdownWarning() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.drawdownWarn
+}
+
+// RecordRealizedPnL adds realized P&L from a closed trade to the trading day
+// containing at, and flags the daily loss limit or profit target once reached.
+func (e *Engine) RecordRealizedPnL(pnl decimal.Decimal, at time.Time) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	if position.Contracts == 0 {
-		delete(e.positions, position.Symbol)
-	} else {
-		e.positions[position.Symbol] = position
+	e.rollSessionLocked(at)
+	e.sessionPL = e.sessionPL.Add(pnl)
+
+	if !e.dailyLossHit && e.cfg.MaxDailyLossPct.IsPositive() {
+		limit := e.sessionStartEquity.Mul(e.cfg.MaxDailyLossPct)
+		if e.sessionPL.Neg().GreaterThanOrEqual(limit) {
+			e.dailyLossHit = true
+			e.logger.Error("DAILY LOSS LIMIT REACHED - no new trades until next session",
+				"daily_pl", e.sessionPL,
+				"limit", limit,
+				"session_start", e.sessionStart,
+			)
+		}
 	}
+
+	if !e.dailyTargetHit && e.cfg.DailyProfitTargetPct.IsPositive() {
+		target := e.sessionStartEquity.Mul(e.cfg.DailyProfitTargetPct)
+		if e.sessionPL.GreaterThanOrEqual(target) {
+			e.dailyTargetHit = true
+			e.logger.Info("daily profit target reached - no new trades until next session",
+				"daily_pl", e.sessionPL,
+				"target", target,
+				"session_start", e.sessionStart,
+			)
+		}
+	}
+}
+
+// DailyPnL returns the realized P&L of the current trading day.
+func (e *Engine) DailyPnL() decimal.Decimal {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.sessionPL
+}
+
+// IsDailyLossLimitHit returns true if the daily loss limit blocks new trades.
+func (e *Engine) IsDailyLossLimitHit() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.dailyLossHit
+}
+
+// IsDailyTargetReached returns true if the daily profit target blocks new trades.
+func (e *Engine) IsDailyTargetReached() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.dailyTargetHit
 }
 
 // GetPosition returns the current position for a symbol.
@@ -244,17 +681,51 @@ func (e *Engine) EnterSafeMode(reason string) {
 	e.enterSafeModeLocked(reason)
 }
 
-// ExitSafeMode exits safe mode (manual reset).
+// ExitSafeMode exits safe mode (manual reset). With a PostKillSwitchCooldown
+// configured, new entries then trade at reduced risk (or are blocked) until
+// the cooldown elapses.
 func (e *Engine) ExitSafeMode() {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	if e.safeMode {
 		e.safeMode = false
-		e.logger.Warn("safe mode exited manually")
+		if e.cfg.PostKillSwitchCooldown > 0 {
+			e.cooldownUntil = e.now().Add(e.cfg.PostKillSwitchCooldown)
+		}
+		e.logger.Warn("safe mode exited manually",
+			"cooldown", e.cfg.PostKillSwitchCooldown,
+			"risk_factor", e.cfg.PostKillSwitchRiskFactor,
+		)
 	}
 }
 
+// CooldownRemaining returns how long the post kill switch cooldown has
+// left (0 when none is running).
+func (e *Engine) CooldownRemaining() time.Duration {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.cooldownRemainingLocked()
+}
+
+// cooldownRemainingLocked returns the cooldown left. Caller must hold e.mu.
+func (e *Engine) cooldownRemainingLocked() time.Duration {
+	if e.cooldownUntil.IsZero() {
+		return 0
+	}
+	return max(e.cooldownUntil.Sub(e.now()), 0)
+}
+
+// checkCooldownLocked blocks entries during a cooldown whose risk factor
+// is zero. Caller must hold e.mu.
+func (e *Engine) checkCooldownLocked() error {
+	remaining := e.cooldownRemainingLocked()
+	if remaining <= 0 || e.cfg.PostKillSwitchRiskFactor.IsPositive() {
+		return nil
+	}
+	return fmt.Errorf("%w: %s left", types.ErrKillSwitchCooldown, remaining.Round(time.Second))
+}
+
 // GetSnapshot returns the current state.
 func (e *Engine) GetSnapshot() types.EquitySnapshot {
 	e.mu.RLock()
@@ -268,6 +739,9 @@ func (e *Engine) GetSnapshot() types.EquitySnapshot {
 		HighWaterMark: peak,
 		Drawdown:      drawdown,
 		OpenPositions: len(e.positions),
+		DailyPL:       e.sessionPL,
+
+		CooldownRemaining: e.cooldownRemainingLocked(),
 	}
 }
 
@@ -302,7 +776,7 @@ func (e *Engine) enterSafeModeLocked(reason string) {
 	}
 
 	e.safeMode = true
-	e.safeModeAt = time.Now()
+	e.safeModeAt = e.now()
 
 	current, peak, drawdown := e.hwm.Snapshot()
 
@@ -314,6 +788,50 @@ func (e *Engine) enterSafeModeLocked(reason string) {
 	)
 }
 
+// rollSessionLocked starts a new trading day if t is past the current session.
+// Must be called with lock held.
+func (e *Engine) rollSessionLocked(t time.Time) {
+	start := e.sessionStartFor(t)
+	if !start.After(e.sessionStart) {
+		return
+	}
+
+	if !e.sessionStart.IsZero() {
+		e.logger.Info("new trading session",
+			"session_start", start,
+			"previous_pl", e.sessionPL,
+		)
+	}
+
+	e.sessionStart = start
+	e.sessionStartEquity = e.hwm.Current()
+	e.sessionPL = decimal.Zero
+	e.dailyLossHit = false
+	e.dailyTargetHit = false
+}
+
+// sessionStartFor returns the start of the trading day containing t,
+// evaluated in the configured market timezone.
+func (e *Engine) sessionStartFor(t time.Time) time.Time {
+	return SessionStart(t, e.cfg.SessionLocation, e.cfg.SessionStartTime)
+}
+
+// SessionStart returns the start of the trading day containing t, for a day
+// that starts offset after midnight in loc (nil = UTC).
+func SessionStart(t time.Time, loc *time.Location, offset time.Duration) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	local := t.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	start := midnight.Add(offset)
+	if local.Before(start) {
+		start = midnight.AddDate(0, 0, -1).Add(offset)
+	}
+	return start
+}
+
 // getOrCreateSizer returns the sizer for a symbol, creating if needed.
 func (e *Engine) getOrCreateSizer(symbol string) (*PositionSizer, error) {
 	if sizer, ok := e.sizers[symbol]; ok {
@@ -331,7 +849,7 @@ func (e *Engine) getOrCreateSizer(symbol string) (*PositionSizer, error) {
 
 // checkExposureLimits checks if adding a position would exceed limits.
 // Uses margin-based exposure (more appropriate for futures) rather than notional.
-func (e *Engine) checkExposureLimits(symbol string, contracts int, price decimal.Decimal, spec types.InstrumentSpec) error {
+func (e *Engine) checkExposureLimits(symbol string, side types.Side, contracts int, price decimal.Decimal, spec types.InstrumentSpec) error {
 	equity := e.hwm.Current()
 
 	// Calculate new position margin requirement
@@ -345,11 +863,11 @@ func (e *Engine) checkExposureLimits(symbol string, contracts int, price decimal
 	// Check per-symbol exposure (margin-based)
 	maxSymbolExposure := equity.Mul(e.cfg.MaxExposurePerSymbolPct)
 
-	// Add existing position margin for this symbol
-	symbolMargin := newMargin
-	if pos, ok := e.positions[symbol]; ok {
-		existingMargin := marginPerContract.Mul(decimal.NewFromInt(int64(pos.Contracts)))
-		symbolMargin = symbolMargin.Add(existingMargin)
+	// Add existing position margin for this symbol; an order against the
+	// position nets off it instead
+	symbolMargin := newMargin.Add(e.marginUsed[symbol])
+	if pos, ok := e.positions[symbol]; ok && pos.Side == side.Opposite() {
+		symbolMargin = newMargin.Sub(e.marginUsed[symbol]).Abs()
 	}
 
 	if symbolMargin.GreaterThan(maxSymbolExposure) {
@@ -361,20 +879,11 @@ func (e *Engine) checkExposureLimits(symbol string, contracts int, price decimal
 	maxTotalExposure := equity.Mul(e.cfg.MaxTotalExposurePct)
 	totalMargin := symbolMargin
 
-	for sym, pos := range e.positions {
+	for sym, margin := range e.marginUsed {
 		if sym == symbol {
 			continue // Already counted above
 		}
-		symSpec, ok := types.GetInstrumentSpec(sym)
-		if !ok {
-			continue
-		}
-		symMarginPerContract := symSpec.MarginIntra
-		if symMarginPerContract.IsZero() {
-			symMarginPerContract = symSpec.MarginInitial
-		}
-		posMargin := symMarginPerContract.Mul(decimal.NewFromInt(int64(pos.Contracts)))
-		totalMargin = totalMargin.Add(posMargin)
+		totalMargin = totalMargin.Add(margin)
 	}
 
 	if totalMargin.GreaterThan(maxTotalExposure) {
@@ -385,6 +894,77 @@ func (e *Engine) checkExposureLimits(symbol string, contracts int, price decimal
 	return nil
 }
 
+// checkMiniEquivalent caps contracts held across a micro/mini pair, counted
+// in full-size contracts, so MES and ES positions share one limit. An
+// order against the symbol's own position nets off it. Caller must hold
+// e.mu.
+func (e *Engine) checkMiniEquivalent(symbol string, side types.Side, contracts int) error {
+	if !e.cfg.MaxMiniEquivalent.IsPositive() {
+		return nil
+	}
+
+	net := contracts
+	if pos, ok := e.positions[symbol]; ok {
+		if pos.Side == side.Opposite() {
+			net = max(contracts-pos.Contracts, pos.Contracts-contracts)
+		} else {
+			net += pos.Contracts
+		}
+	}
+
+	mini, total := types.MiniEquivalent(symbol, net)
+	for sym, pos := range e.positions {
+		if sym == symbol {
+			continue // Counted above
+		}
+		if held, minis := types.MiniEquivalent(sym, pos.Contracts); held == mini {
+			total = total.Add(minis)
+		}
+	}
+
+	if total.GreaterThan(e.cfg.MaxMiniEquivalent) {
+		return fmt.Errorf("%w: %s-equivalent contracts would be %s, limit %s",
+			types.ErrExposureLimitExceeded, mini, total, e.cfg.MaxMiniEquivalent)
+	}
+	return nil
+}
+
+// ClientOrderID derives the client order ID for a signal's attempt-th
+// submission. The same signal and attempt always give the same ID, so with
+// the engine's order store (which keeps every ID sent) an order sent just
+// before a crash is refused as a duplicate when the restarted bot
+// resubmits the signal, instead of opening a second position. Signals
+// without an ID get a random one.
+func ClientOrderID(signalID string, attempt int) string {
+	if signalID == "" {
+		return generateClientOrderID()
+	}
+	sum := sha256.Sum256([]byte(signalID))
+	return fmt.Sprintf("sig-%x-%d", sum[:8], attempt)
+}
+
+// ExitIntent builds the reduce-only order that carries out a flat (exit)
+// signal against a position of held contracts on side. The signal's
+// Contracts close part of the position; zero closes all of it. Exits skip
+// sizing: they only ever reduce risk.
+func ExitIntent(signal types.Signal, side types.Side, held int, price decimal.Decimal) types.OrderIntent {
+	contracts := held
+	if signal.Contracts > 0 {
+		contracts = min(signal.Contracts, held)
+	}
+	return types.OrderIntent{
+		ID:            uuid.New().String(),
+		ClientOrderID: ClientOrderID(signal.ID, 0),
+		Timestamp:     signal.Timestamp,
+		Symbol:        signal.Symbol,
+		Side:          side.Opposite(),
+		Contracts:     contracts,
+		EntryPrice:    price,
+		SignalID:      signal.ID,
+		ReduceOnly:    true,
+	}
+}
+
 // generateClientOrderID creates a unique client order ID for idempotency.
 func generateClientOrderID() string {
 	return fmt.Sprintf("%s-%s",
@@ -122,6 +122,39 @@ func TestEngine_ValidateAndSize_ShortPosition(t *testing.T) {
 	}
 }
 
+func TestEngine_ValidateAndSize_Tranches(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TakeProfitTranches = []TrancheConfig{
+		{ATRMultiple: decimal.RequireFromString("1.0"), Fraction: decimal.RequireFromString("0.5")},
+	}
+	engine := NewEngine(cfg, decimal.RequireFromString("10000"), nil)
+
+	signal := types.Signal{
+		ID:        "sig-tranche",
+		Symbol:    "MES",
+		Direction: types.SideLong,
+		StopTicks: 10,
+	}
+	marketEvent := types.MarketEvent{
+		Symbol: "MES",
+		Close:  decimal.RequireFromString("5000"),
+		ATR:    decimal.RequireFromString("2.5"),
+	}
+
+	intent, err := engine.ValidateAndSize(context.Background(), signal, marketEvent)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// 1 ATR is half the 2 ATR stop: 5 ticks of 0.25
+	if len(intent.Tranches) != 1 {
+		t.Fatalf("Tranches = %d, want 1", len(intent.Tranches))
+	}
+	if got := intent.Tranches[0]; !got.Offset.Equal(decimal.RequireFromString("1.25")) || !got.Fraction.Equal(decimal.RequireFromString("0.5")) {
+		t.Errorf("Tranche = %+v, want offset 1.25, fraction 0.5", got)
+	}
+}
+
 func TestEngine_ValidateAndSize_SafeMode(t *testing.T) {
 	cfg := DefaultConfig()
 	engine := NewEngine(cfg, decimal.RequireFromString("10000"), nil)
@@ -392,6 +425,58 @@ func TestEngine_ExposureLimits(t *testing.T) {
 	}
 }
 
+func TestEngine_MarginPolicy(t *testing.T) {
+	signal := types.Signal{ID: "sig-margin", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+
+	// $100 risk / $12.50 per contract = 8 MES wanted at $50 intraday margin each
+	tests := []struct {
+		name      string
+		policy    MarginPolicy
+		mgcHeld   int // $550 intraday margin each
+		wantCount int
+		wantErr   error
+	}{
+		{"unchecked ignores held margin", MarginUnchecked, 18, 8, nil},
+		{"enough free margin", MarginReject, 10, 8, nil},
+		{"reject near full account", MarginReject, 18, 0, types.ErrInsufficientMargin},
+		{"downsize near full account", MarginDownsize, 18, 2, nil}, // $100 free
+		{"downsize with no free margin", MarginDownsize, 19, 0, types.ErrInsufficientMargin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.MaxExposurePerSymbolPct = decimal.NewFromInt(100)
+			cfg.MaxTotalExposurePct = decimal.NewFromInt(100)
+			cfg.MarginPolicy = tt.policy
+			engine := NewEngine(cfg, decimal.RequireFromString("10000"), nil)
+			engine.UpdatePosition(&types.Position{Symbol: "MGC", Side: types.SideLong, Contracts: tt.mgcHeld})
+
+			intent, err := engine.ValidateAndSize(context.Background(), signal, event)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if types.ReasonFor(err) != types.RejectMargin {
+					t.Errorf("reason = %s, want %s", types.ReasonFor(err), types.RejectMargin)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateAndSize() error = %v", err)
+			}
+			if intent.Contracts != tt.wantCount {
+				t.Errorf("Contracts = %d, want %d", intent.Contracts, tt.wantCount)
+			}
+			wantRisk := decimal.RequireFromString("12.5").Mul(decimal.NewFromInt(int64(tt.wantCount)))
+			if !intent.RiskAmount.Equal(wantRisk) {
+				t.Errorf("RiskAmount = %s, want %s", intent.RiskAmount, wantRisk)
+			}
+		})
+	}
+}
+
 func TestEngine_Shutdown(t *testing.T) {
 	cfg := DefaultConfig()
 	engine := NewEngine(cfg, decimal.RequireFromString("10000"), nil)
@@ -441,6 +526,147 @@ func TestEngine_ATRBasedStop(t *testing.T) {
 	}
 }
 
+func TestEngine_RiskScalingCurve(t *testing.T) {
+	signal := types.Signal{ID: "sig-scale", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+
+	linear := LinearRiskScaling{Floor: decimal.RequireFromString("0.25")}
+
+	// 10 ticks * $1.25 = $12.50 risk per contract, 1% of equity scaled by the curve
+	tests := []struct {
+		name      string
+		curve     RiskScalingCurve
+		equity    string
+		wantCount int
+	}{
+		{"no drawdown", linear, "100000", 80},          // $1000 * 1
+		{"10% drawdown", linear, "90000", 45},          // $900 * 0.625
+		{"near limit", linear, "81000", 18},            // $810 * 0.2875
+		{"near limit without curve", nil, "81000", 64}, // $810
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.MaxExposurePerSymbolPct = decimal.NewFromInt(100)
+			cfg.MaxTotalExposurePct = decimal.NewFromInt(100)
+			cfg.RiskScalingCurve = tt.curve
+			engine := NewEngine(cfg, decimal.RequireFromString("100000"), nil)
+			engine.UpdateEquity(decimal.RequireFromString(tt.equity))
+
+			intent, err := engine.ValidateAndSize(context.Background(), signal, event)
+			if err != nil {
+				t.Fatalf("ValidateAndSize() error = %v", err)
+			}
+			if intent.Contracts != tt.wantCount {
+				t.Errorf("Contracts = %d, want %d", intent.Contracts, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestEngine_MaxMiniEquivalent(t *testing.T) {
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+	signal := func(symbol string) types.Signal {
+		return types.Signal{ID: "sig-mini", Symbol: symbol, Direction: types.SideLong, StopTicks: 10}
+	}
+
+	cfg := DefaultConfig()
+	cfg.MaxExposurePerSymbolPct = decimal.NewFromInt(100)
+	cfg.MaxTotalExposurePct = decimal.NewFromInt(100)
+	cfg.MaxContractsPerOrder = 5
+	cfg.MaxMiniEquivalent = decimal.NewFromInt(2)
+	engine := NewEngine(cfg, decimal.RequireFromString("100000"), nil)
+
+	// 1 ES + 10 MES = 2 ES-equivalent, the cap
+	engine.UpdatePosition(&types.Position{Symbol: "ES", Side: types.SideLong, Contracts: 1, EntryPrice: decimal.NewFromInt(5000)})
+	engine.UpdatePosition(&types.Position{Symbol: "MES", Side: types.SideLong, Contracts: 10, EntryPrice: decimal.NewFromInt(5000)})
+
+	if _, err := engine.ValidateAndSize(context.Background(), signal("MES"), event); !errors.Is(err, types.ErrExposureLimitExceeded) {
+		t.Errorf("MES at the cap: error = %v, want ErrExposureLimitExceeded", err)
+	}
+
+	// 5 MES off frees half an ES: 5 more MES fit, another ES doesn't
+	engine.UpdatePosition(&types.Position{Symbol: "MES", Side: types.SideLong, Contracts: 5})
+	if intent, err := engine.ValidateAndSize(context.Background(), signal("MES"), event); err != nil || intent.Contracts != 5 {
+		t.Errorf("MES with room for 5: intent = %+v, error = %v; want 5 contracts", intent, err)
+	}
+	if _, err := engine.ValidateAndSize(context.Background(), signal("ES"), event); !errors.Is(err, types.ErrExposureLimitExceeded) {
+		t.Errorf("ES beyond the cap: error = %v, want ErrExposureLimitExceeded", err)
+	}
+
+	// Gold is a different pair and has its own room
+	if _, err := engine.ValidateAndSize(context.Background(), signal("MGC"), types.MarketEvent{Symbol: "MGC", Close: decimal.NewFromInt(2000)}); err != nil {
+		t.Errorf("MGC error = %v, want the MES/ES cap not to apply", err)
+	}
+}
+
+func TestEngine_PostKillSwitchCooldown(t *testing.T) {
+	signal := types.Signal{ID: "sig-cool", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+	exitedAt := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	// 10 ticks * $1.25 = $12.50 risk per contract; 1% of $100000 = 80 contracts
+	tests := []struct {
+		name      string
+		factor    string
+		elapsed   time.Duration
+		wantCount int
+		wantErr   error
+	}{
+		{"half risk during cooldown", "0.5", 0, 40, nil},
+		{"half risk just before the end", "0.5", time.Hour - time.Second, 40, nil},
+		{"full risk once elapsed", "0.5", time.Hour, 80, nil},
+		{"zero factor blocks", "0", 30 * time.Minute, 0, types.ErrKillSwitchCooldown},
+		{"zero factor unblocks once elapsed", "0", time.Hour, 80, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.MaxExposurePerSymbolPct = decimal.NewFromInt(100)
+			cfg.MaxTotalExposurePct = decimal.NewFromInt(100)
+			cfg.PostKillSwitchCooldown = time.Hour
+			cfg.PostKillSwitchRiskFactor = decimal.RequireFromString(tt.factor)
+			engine := NewEngine(cfg, decimal.RequireFromString("100000"), nil)
+
+			now := exitedAt
+			engine.now = func() time.Time { return now }
+			engine.EnterSafeMode("test")
+			engine.ExitSafeMode()
+			now = exitedAt.Add(tt.elapsed)
+
+			if got, want := engine.GetSnapshot().CooldownRemaining, time.Hour-tt.elapsed; got != want {
+				t.Errorf("CooldownRemaining = %s, want %s", got, want)
+			}
+
+			intent, err := engine.ValidateAndSize(context.Background(), signal, event)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ValidateAndSize() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateAndSize() error = %v", err)
+			}
+			if intent.Contracts != tt.wantCount {
+				t.Errorf("Contracts = %d, want %d", intent.Contracts, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestEngine_PostKillSwitchCooldown_Disabled(t *testing.T) {
+	engine := NewEngine(DefaultConfig(), decimal.RequireFromString("10000"), nil)
+	engine.EnterSafeMode("test")
+	engine.ExitSafeMode()
+
+	if got := engine.CooldownRemaining(); got != 0 {
+		t.Errorf("CooldownRemaining = %s, want 0 without a configured cooldown", got)
+	}
+}
+
 func TestDefaultConfig(t *testing.T) {
 	cfg := DefaultConfig()
 
@@ -520,6 +746,66 @@ func TestEngine_KillSwitch_ExtremeGap(t *testing.T) {
 	}
 }
 
+// TestEngine_DrawdownWarning tests the warning fires at 75% of the limit,
+// stays off at the limit itself and re-arms after recovery.
+func TestEngine_DrawdownWarning(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxGlobalDrawdownPct = decimal.RequireFromString("0.20")
+	cfg.DrawdownWarnPct = decimal.RequireFromString("0.75") // Warn at 15%
+	engine := NewEngine(cfg, decimal.RequireFromString("10000"), nil)
+
+	steps := []struct {
+		equity   string
+		wantWarn bool
+		wantSafe bool
+	}{
+		{"8501", false, false}, // 14.99%: just below the warning level
+		{"8500", true, false},  // 15%: exactly at the warning level
+		{"8001", true, false},  // 19.99%: still below the limit
+		{"9000", false, false}, // Recovered to 10%: warning re-arms
+		{"8400", true, false},  // 16%: warns again
+		{"8000", true, true},   // 20%: kill switch
+	}
+
+	for _, step := range steps {
+		engine.UpdateEquity(decimal.RequireFromString(step.equity))
+		if got := engine.IsDrawdownWarning(); got != step.wantWarn {
+			t.Errorf("equity %s: IsDrawdownWarning = %v, want %v", step.equity, got, step.wantWarn)
+		}
+		if got := engine.IsInSafeMode(); got != step.wantSafe {
+			t.Errorf("equity %s: IsInSafeMode = %v, want %v", step.equity, got, step.wantSafe)
+		}
+	}
+}
+
+// TestEngine_DrawdownWarning_StraightToLimit tests a gap through the
+// warning level to the limit trips the kill switch without a warning.
+func TestEngine_DrawdownWarning_StraightToLimit(t *testing.T) {
+	engine := NewEngine(DefaultConfig(), decimal.RequireFromString("10000"), nil)
+
+	engine.UpdateEquity(decimal.RequireFromString("8000"))
+
+	if !engine.IsInSafeMode() {
+		t.Error("expected safe mode at the limit")
+	}
+	if engine.IsDrawdownWarning() {
+		t.Error("warning should not fire when drawdown jumps to the limit")
+	}
+}
+
+// TestEngine_DrawdownWarning_Disabled tests a zero DrawdownWarnPct never warns.
+func TestEngine_DrawdownWarning_Disabled(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DrawdownWarnPct = decimal.Zero
+	engine := NewEngine(cfg, decimal.RequireFromString("10000"), nil)
+
+	engine.UpdateEquity(decimal.RequireFromString("8100"))
+
+	if engine.IsDrawdownWarning() {
+		t.Error("warning should be off when DrawdownWarnPct is 0")
+	}
+}
+
 // TestEngine_CurrentEquity tests current equity retrieval.
 func TestEngine_CurrentEquity(t *testing.T) {
 	cfg := DefaultConfig()
@@ -652,3 +938,473 @@ func TestEngine_ValidateAndSize_StopDirectionShort(t *testing.T) {
 		t.Errorf("SHORT tp (%s) should be < entry (%s)", intent.TakeProfit, intent.EntryPrice)
 	}
 }
+
+func newDailyLossEngine(t *testing.T) *Engine {
+	t.Helper()
+	loc, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.MaxDailyLossPct = decimal.RequireFromString("0.03")
+	cfg.SessionLocation = loc
+	cfg.SessionStartTime = 17 * time.Hour
+	return NewEngine(cfg, decimal.RequireFromString("10000"), nil)
+}
+
+func TestEngine_DailyLossLimit_RejectsSignals(t *testing.T) {
+	engine := newDailyLossEngine(t)
+	loc := engine.cfg.SessionLocation
+
+	// 10:00 CT Tuesday - session started Monday 17:00 CT
+	now := time.Date(2024, 1, 9, 10, 0, 0, 0, loc)
+	signal := types.Signal{ID: "sig-dl", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+	event := types.MarketEvent{Symbol: "MES", Timestamp: now, Close: decimal.RequireFromString("5000")}
+
+	// Loss just under the 3% limit ($300)
+	engine.RecordRealizedPnL(decimal.RequireFromString("-299"), now)
+	if _, err := engine.ValidateAndSize(context.Background(), signal, event); err != nil {
+		t.Fatalf("Unexpected error below limit: %v", err)
+	}
+
+	// Crossing the limit blocks new trades
+	engine.RecordRealizedPnL(decimal.RequireFromString("-1"), now.Add(time.Minute))
+	if !engine.IsDailyLossLimitHit() {
+		t.Fatal("Daily loss limit should be hit at -300")
+	}
+
+	_, err := engine.ValidateAndSize(context.Background(), signal, event)
+	if !errors.Is(err, types.ErrDailyLossLimit) {
+		t.Errorf("Expected ErrDailyLossLimit, got %v", err)
+	}
+
+	// Daily loss limit is not the kill switch
+	if engine.IsInSafeMode() {
+		t.Error("Daily loss limit should not activate safe mode")
+	}
+
+	if !engine.GetSnapshot().DailyPL.Equal(decimal.RequireFromString("-300")) {
+		t.Errorf("DailyPL = %s, want -300", engine.GetSnapshot().DailyPL)
+	}
+}
+
+func TestEngine_DailyLossLimit_ResetsAtSessionStart(t *testing.T) {
+	engine := newDailyLossEngine(t)
+	loc := engine.cfg.SessionLocation
+
+	signal := types.Signal{ID: "sig-dl", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+
+	engine.RecordRealizedPnL(decimal.RequireFromString("-500"), time.Date(2024, 1, 9, 10, 0, 0, 0, loc))
+
+	// 16:59 CT is still the same trading day
+	before := types.MarketEvent{Symbol: "MES", Timestamp: time.Date(2024, 1, 9, 16, 59, 0, 0, loc), Close: decimal.RequireFromString("5000")}
+	if _, err := engine.ValidateAndSize(context.Background(), signal, before); !errors.Is(err, types.ErrDailyLossLimit) {
+		t.Fatalf("Expected ErrDailyLossLimit before session start, got %v", err)
+	}
+
+	// 17:00 CT starts a new trading day
+	after := types.MarketEvent{Symbol: "MES", Timestamp: time.Date(2024, 1, 9, 17, 0, 0, 0, loc), Close: decimal.RequireFromString("5000")}
+	if _, err := engine.ValidateAndSize(context.Background(), signal, after); err != nil {
+		t.Fatalf("Unexpected error after session reset: %v", err)
+	}
+
+	if engine.IsDailyLossLimitHit() {
+		t.Error("Daily loss limit should reset at session start")
+	}
+	if !engine.DailyPnL().IsZero() {
+		t.Errorf("DailyPnL = %s, want 0 after reset", engine.DailyPnL())
+	}
+}
+
+func TestEngine_DailyLossLimit_UsesMarketTimezone(t *testing.T) {
+	engine := newDailyLossEngine(t)
+
+	// 22:30 UTC = 16:30 CT (winter): still the trading day that started 17:00 CT yesterday
+	engine.RecordRealizedPnL(decimal.RequireFromString("-400"), time.Date(2024, 1, 9, 22, 30, 0, 0, time.UTC))
+	if !engine.IsDailyLossLimitHit() {
+		t.Fatal("Daily loss limit should be hit")
+	}
+
+	// 23:05 UTC = 17:05 CT: new trading day, even though the UTC date is unchanged
+	engine.RecordRealizedPnL(decimal.Zero, time.Date(2024, 1, 9, 23, 5, 0, 0, time.UTC))
+	if engine.IsDailyLossLimitHit() {
+		t.Error("Daily loss limit should reset at 17:00 CT")
+	}
+}
+
+func TestEngine_DailyLossLimit_DisabledByDefault(t *testing.T) {
+	engine := NewEngine(DefaultConfig(), decimal.RequireFromString("10000"), nil)
+
+	engine.RecordRealizedPnL(decimal.RequireFromString("-1000"), time.Now())
+	if engine.IsDailyLossLimitHit() {
+		t.Error("Daily loss limit should be disabled when MaxDailyLossPct is zero")
+	}
+}
+
+func TestEngine_DailyProfitTarget(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.DailyProfitTargetPct = decimal.RequireFromString("0.02")
+	engine := NewEngine(cfg, decimal.RequireFromString("10000"), nil)
+
+	day := time.Date(2024, 1, 9, 10, 0, 0, 0, time.UTC)
+	signal := types.Signal{ID: "sig-pt", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+	event := types.MarketEvent{Symbol: "MES", Timestamp: day, Close: decimal.RequireFromString("5000")}
+
+	// Just under the 2% target ($200)
+	engine.RecordRealizedPnL(decimal.RequireFromString("199"), day)
+	if _, err := engine.ValidateAndSize(context.Background(), signal, event); err != nil {
+		t.Fatalf("Unexpected error below target: %v", err)
+	}
+
+	engine.RecordRealizedPnL(decimal.RequireFromString("1"), day)
+	if !engine.IsDailyTargetReached() {
+		t.Fatal("Daily target should be reached at +200")
+	}
+
+	_, err := engine.ValidateAndSize(context.Background(), signal, event)
+	if !errors.Is(err, types.ErrDailyTargetReached) {
+		t.Errorf("Expected ErrDailyTargetReached, got %v", err)
+	}
+
+	// Next trading day (midnight UTC session start) trades again
+	next := event
+	next.Timestamp = day.Add(24 * time.Hour)
+	if _, err := engine.ValidateAndSize(context.Background(), signal, next); err != nil {
+		t.Errorf("Unexpected error on next day: %v", err)
+	}
+}
+
+func TestEngine_DailyProfitTarget_DisabledByDefault(t *testing.T) {
+	engine := NewEngine(DefaultConfig(), decimal.RequireFromString("10000"), nil)
+
+	engine.RecordRealizedPnL(decimal.RequireFromString("5000"), time.Now())
+	if engine.IsDailyTargetReached() {
+		t.Error("Daily target should be disabled when DailyProfitTargetPct is zero")
+	}
+}
+
+func TestEngine_ValidateAndSize_SignalValidFor(t *testing.T) {
+	engine := NewEngine(DefaultConfig(), decimal.RequireFromString("10000"), nil)
+
+	signal := types.Signal{
+		ID:        "sig-scalp",
+		Symbol:    "MES",
+		Direction: types.SideLong,
+		StopTicks: 10,
+		ValidFor:  30 * time.Second,
+	}
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+
+	intent, err := engine.ValidateAndSize(context.Background(), signal, event)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := intent.ExpiresAt.Sub(intent.Timestamp); got != 30*time.Second {
+		t.Errorf("Expiry = %v, want 30s", got)
+	}
+}
+
+func TestEngine_ValidateAndSize_DefaultValidity(t *testing.T) {
+	tests := []struct {
+		name     string
+		validity time.Duration
+		want     time.Duration
+	}{
+		{"configured default", 2 * time.Minute, 2 * time.Minute},
+		{"unset falls back to 5m", 0, 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.SignalValidity = tt.validity
+			engine := NewEngine(cfg, decimal.RequireFromString("10000"), nil)
+
+			signal := types.Signal{ID: "sig-default", Symbol: "MES", Direction: types.SideShort, StopTicks: 10}
+			event := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+
+			intent, err := engine.ValidateAndSize(context.Background(), signal, event)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if got := intent.ExpiresAt.Sub(intent.Timestamp); got != tt.want {
+				t.Errorf("Expiry = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngine_MinNetProfit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MinNetProfitPerContract = decimal.RequireFromString("10")
+	cfg.CommissionPerSide = decimal.RequireFromString("0.62")
+	cfg.SlippageTicks = 1
+	engine := NewEngine(cfg, decimal.RequireFromString("10000"), nil)
+
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+
+	// Tight target: 4-tick stop -> 1.5 pt target = $7.50 gross,
+	// minus round trip 2 * (0.62 + 1.25) = $3.74 -> $3.76 net < $10
+	tight := types.Signal{ID: "sig-tight", Symbol: "MES", Direction: types.SideLong, StopTicks: 4}
+	_, err := engine.ValidateAndSize(context.Background(), tight, event)
+	if !errors.Is(err, types.ErrTargetBelowCosts) {
+		t.Errorf("Expected ErrTargetBelowCosts, got %v", err)
+	}
+
+	// Wide target: 20-tick stop -> 7.5 pt target = $37.50 gross -> $33.76 net
+	wide := types.Signal{ID: "sig-wide", Symbol: "MES", Direction: types.SideLong, StopTicks: 20}
+	intent, err := engine.ValidateAndSize(context.Background(), wide, event)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if intent.Contracts <= 0 {
+		t.Errorf("Contracts = %d, want > 0", intent.Contracts)
+	}
+}
+
+func TestEngine_MinNetProfit_ItemizedFees(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MinNetProfitPerContract = decimal.RequireFromString("3.50")
+	cfg.CommissionPerSide = decimal.RequireFromString("0.62")
+	cfg.SlippageTicks = 1
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+	signal := types.Signal{ID: "sig-tight", Symbol: "MES", Direction: types.SideLong, StopTicks: 4}
+
+	// All-in commission: $7.50 - $3.74 = $3.76 net clears $3.50
+	if _, err := NewEngine(cfg, decimal.RequireFromString("10000"), nil).ValidateAndSize(context.Background(), signal, event); err != nil {
+		t.Errorf("all-in commission: unexpected error %v", err)
+	}
+
+	// Itemized: exchange fees add 2 * 0.37 -> $3.02 net
+	cfg.ItemizedFees = true
+	if _, err := NewEngine(cfg, decimal.RequireFromString("10000"), nil).ValidateAndSize(context.Background(), signal, event); !errors.Is(err, types.ErrTargetBelowCosts) {
+		t.Errorf("itemized fees: expected ErrTargetBelowCosts, got %v", err)
+	}
+}
+
+func TestEngine_MinNetProfit_DisabledByDefault(t *testing.T) {
+	engine := NewEngine(DefaultConfig(), decimal.RequireFromString("10000"), nil)
+
+	signal := types.Signal{ID: "sig-tight", Symbol: "MES", Direction: types.SideLong, StopTicks: 4}
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+
+	if _, err := engine.ValidateAndSize(context.Background(), signal, event); errors.Is(err, types.ErrTargetBelowCosts) {
+		t.Error("Cost filter should be disabled when MinNetProfitPerContract is zero")
+	}
+}
+
+func TestEngine_MinStopTicks(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MinStopTicks = 8
+	engine := NewEngine(cfg, decimal.RequireFromString("10000"), nil)
+
+	// Quiet market: ATR 0.25 * 2.0 = 0.5 pt = 2 ticks, clamped to 8 ticks (2 pts)
+	signal := types.Signal{ID: "sig-quiet", Symbol: "MES", Direction: types.SideLong}
+	event := types.MarketEvent{
+		Symbol: "MES",
+		Close:  decimal.RequireFromString("5000"),
+		ATR:    decimal.RequireFromString("0.25"),
+	}
+
+	intent, err := engine.ValidateAndSize(context.Background(), signal, event)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := decimal.RequireFromString("4998"); !intent.StopLoss.Equal(want) {
+		t.Errorf("StopLoss = %s, want %s (clamped to 8 ticks)", intent.StopLoss, want)
+	}
+
+	// $100 risk / (8 ticks * $1.25) = 10 contracts instead of 40
+	if intent.Contracts != 10 {
+		t.Errorf("Contracts = %d, want 10", intent.Contracts)
+	}
+
+	// Take profit scales from the clamped stop: 2 pts * 3.0/2.0 = 3 pts
+	if want := decimal.RequireFromString("5003"); !intent.TakeProfit.Equal(want) {
+		t.Errorf("TakeProfit = %s, want %s", intent.TakeProfit, want)
+	}
+}
+
+func TestEngine_MaxStopTicks(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxStopTicks = 40
+	engine := NewEngine(cfg, decimal.RequireFromString("10000"), nil)
+
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+
+	wide := types.Signal{ID: "sig-wide", Symbol: "MES", Direction: types.SideLong, StopTicks: 100}
+	if _, err := engine.ValidateAndSize(context.Background(), wide, event); !errors.Is(err, types.ErrStopTooWide) {
+		t.Errorf("Expected ErrStopTooWide, got %v", err)
+	}
+
+	ok := types.Signal{ID: "sig-ok", Symbol: "MES", Direction: types.SideLong, StopTicks: 40}
+	if _, err := engine.ValidateAndSize(context.Background(), ok, event); err != nil {
+		t.Errorf("Stop at the ceiling should pass, got %v", err)
+	}
+}
+
+func TestEngine_AllowShortDisabled(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AllowShort = false
+	engine := NewEngine(cfg, decimal.RequireFromString("10000"), nil)
+
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+
+	short := types.Signal{ID: "sig-short", Symbol: "MES", Direction: types.SideShort, StopTicks: 10}
+	if _, err := engine.ValidateAndSize(context.Background(), short, event); !errors.Is(err, types.ErrDirectionNotAllowed) {
+		t.Errorf("Expected ErrDirectionNotAllowed, got %v", err)
+	}
+	if _, err := engine.PreviewSize(short, event); !errors.Is(err, types.ErrDirectionNotAllowed) {
+		t.Errorf("PreviewSize: expected ErrDirectionNotAllowed, got %v", err)
+	}
+
+	long := types.Signal{ID: "sig-long", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+	if _, err := engine.ValidateAndSize(context.Background(), long, event); err != nil {
+		t.Errorf("Long signal should pass, got %v", err)
+	}
+}
+
+func TestEngine_MaxOpenPositions(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxOpenPositions = 1
+	cfg.MaxTotalExposurePct = decimal.RequireFromString("2.00")
+	engine := NewEngine(cfg, decimal.RequireFromString("100000"), nil)
+
+	engine.UpdatePosition(&types.Position{Symbol: "MES", Side: types.SideLong, Contracts: 1})
+
+	mgc := types.Signal{ID: "sig-mgc", Symbol: "MGC", Direction: types.SideLong, StopTicks: 10}
+	mgcEvent := types.MarketEvent{Symbol: "MGC", Close: decimal.RequireFromString("2000")}
+	if _, err := engine.ValidateAndSize(context.Background(), mgc, mgcEvent); !errors.Is(err, types.ErrMaxPositionsReached) {
+		t.Errorf("Expected ErrMaxPositionsReached, got %v", err)
+	}
+
+	// Adding to the open symbol is not a new position
+	mes := types.Signal{ID: "sig-mes", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+	mesEvent := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+	if _, err := engine.ValidateAndSize(context.Background(), mes, mesEvent); errors.Is(err, types.ErrMaxPositionsReached) {
+		t.Errorf("Adding to an open position should not hit the cap, got %v", err)
+	}
+
+	engine.UpdatePosition(&types.Position{Symbol: "MES", Contracts: 0})
+	if _, err := engine.ValidateAndSize(context.Background(), mgc, mgcEvent); errors.Is(err, types.ErrMaxPositionsReached) {
+		t.Errorf("Cap should free up after close, got %v", err)
+	}
+}
+
+func TestEngine_MaxContractsPerOrder(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxContractsPerOrder = 5
+	engine := NewEngine(cfg, decimal.RequireFromString("100000"), nil)
+
+	// $1000 risk / (4 ticks * $1.25) = 200 contracts uncapped
+	signal := types.Signal{ID: "sig-big", Symbol: "MES", Direction: types.SideLong, StopTicks: 4}
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+
+	intent, err := engine.ValidateAndSize(context.Background(), signal, event)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if intent.Contracts != 5 {
+		t.Errorf("Contracts = %d, want 5", intent.Contracts)
+	}
+	// 5 contracts * 4 ticks * $1.25
+	if want := decimal.RequireFromString("25"); !intent.RiskAmount.Equal(want) {
+		t.Errorf("RiskAmount = %s, want %s", intent.RiskAmount, want)
+	}
+}
+
+func TestEngine_MaxContractsPerOrder_DisabledByDefault(t *testing.T) {
+	engine := NewEngine(DefaultConfig(), decimal.RequireFromString("100000"), nil)
+
+	signal := types.Signal{ID: "sig-big", Symbol: "MES", Direction: types.SideLong, StopTicks: 20}
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+
+	intent, err := engine.ValidateAndSize(context.Background(), signal, event)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// $1000 / (20 * $1.25) = 40 contracts
+	if intent.Contracts != 40 {
+		t.Errorf("Contracts = %d, want 40", intent.Contracts)
+	}
+}
+
+func TestEngine_PreviewSize(t *testing.T) {
+	engine := NewEngine(DefaultConfig(), decimal.RequireFromString("100000"), nil)
+
+	signal := types.Signal{ID: "sig-preview", Symbol: "MES", Direction: types.SideLong, StopTicks: 20}
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+
+	preview, err := engine.PreviewSize(signal, event)
+	if err != nil {
+		t.Fatalf("PreviewSize() error = %v", err)
+	}
+	intent, err := engine.ValidateAndSize(context.Background(), signal, event)
+	if err != nil {
+		t.Fatalf("ValidateAndSize() error = %v", err)
+	}
+
+	if preview.Contracts != intent.Contracts || !preview.StopLoss.Equal(intent.StopLoss) || !preview.RiskAmount.Equal(intent.RiskAmount) {
+		t.Errorf("preview = %d @ %s risk %s, want %d @ %s risk %s",
+			preview.Contracts, preview.StopLoss, preview.RiskAmount,
+			intent.Contracts, intent.StopLoss, intent.RiskAmount)
+	}
+}
+
+func TestEngine_PreviewSize_ReadOnly(t *testing.T) {
+	engine := NewEngine(DefaultConfig(), decimal.RequireFromString("10000"), nil)
+
+	// Breach max drawdown without going through UpdateEquity's kill switch
+	engine.hwm.Update(decimal.RequireFromString("7000"))
+
+	signal := types.Signal{ID: "sig-preview", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.RequireFromString("5000")}
+
+	if _, err := engine.PreviewSize(signal, event); !errors.Is(err, types.ErrKillSwitchActive) {
+		t.Errorf("PreviewSize() error = %v, want ErrKillSwitchActive", err)
+	}
+	if engine.IsInSafeMode() {
+		t.Error("PreviewSize should not enter safe mode")
+	}
+	if len(engine.sizers) != 0 {
+		t.Errorf("PreviewSize cached %d sizers, want 0", len(engine.sizers))
+	}
+}
+
+func TestClientOrderID_Deterministic(t *testing.T) {
+	id := ClientOrderID("breakout-MES-1704207600000000000-LONG", 0)
+	if again := ClientOrderID("breakout-MES-1704207600000000000-LONG", 0); again != id {
+		t.Errorf("regenerated ID = %q, want %q", again, id)
+	}
+	if retry := ClientOrderID("breakout-MES-1704207600000000000-LONG", 1); retry == id {
+		t.Errorf("attempt 1 ID = %q, want it to differ from attempt 0", retry)
+	}
+	if other := ClientOrderID("breakout-MES-1704207660000000000-LONG", 0); other == id {
+		t.Errorf("another signal's ID = %q, want it to differ", other)
+	}
+	if ClientOrderID("", 0) == ClientOrderID("", 0) {
+		t.Error("signals without an ID should get unique IDs")
+	}
+}
+
+func TestEngine_ValidateAndSize_RestartSafeClientOrderID(t *testing.T) {
+	signal := types.Signal{ID: "sig-001", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000), ATR: decimal.RequireFromString("2.5")}
+
+	// A fresh engine stands in for the bot after a restart
+	var ids []string
+	for range 2 {
+		engine := NewEngine(DefaultConfig(), decimal.NewFromInt(10000), nil)
+		intent, err := engine.ValidateAndSize(context.Background(), signal, event)
+		if err != nil {
+			t.Fatalf("ValidateAndSize() error = %v", err)
+		}
+		ids = append(ids, intent.ClientOrderID)
+	}
+	if ids[0] != ids[1] {
+		t.Errorf("ClientOrderID after restart = %q, want %q", ids[1], ids[0])
+	}
+}
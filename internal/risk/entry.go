@@ -0,0 +1,63 @@
+package risk
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// EntryPriceSource decides the price an order is sized, bracketed and
+// expected to fill at. Each choice carries its own backtest bias.
+type EntryPriceSource int
+
+const (
+	// EntryAtClose uses the signal bar's close. The close is only known once
+	// the bar has ended, so assuming a fill there is look-ahead: optimistic
+	// whenever price moves between the close and the order reaching market.
+	EntryAtClose EntryPriceSource = iota
+	// EntryAtNextOpen fills at the next bar's open, which the strategy could
+	// actually reach. The open isn't known when the order is sized, so size
+	// and brackets still come from the close; gaps make the real risk differ.
+	EntryAtNextOpen
+	// EntryAtMid uses the bid/ask mid when the feed has quotes, else the
+	// close. It removes the last-trade bounce of the close but still
+	// assumes a fill inside the spread unless slippage covers it.
+	EntryAtMid
+)
+
+// String returns the config name of the entry price source.
+func (s EntryPriceSource) String() string {
+	switch s {
+	case EntryAtNextOpen:
+		return "next_open"
+	case EntryAtMid:
+		return "mid"
+	default:
+		return "close"
+	}
+}
+
+// ParseEntryPriceSource parses an entry price source name; empty means close.
+func ParseEntryPriceSource(name string) (EntryPriceSource, error) {
+	switch name {
+	case "", "close":
+		return EntryAtClose, nil
+	case "next_open":
+		return EntryAtNextOpen, nil
+	case "mid":
+		return EntryAtMid, nil
+	default:
+		return EntryAtClose, fmt.Errorf("unknown entry price source %q", name)
+	}
+}
+
+// entryPrice returns the reference entry price for an order placed on
+// marketEvent. The next open is filled by the executor, so the close is
+// the best estimate of it here.
+func (e *Engine) entryPrice(marketEvent types.MarketEvent) decimal.Decimal {
+	if e.cfg.EntryPriceSource == EntryAtMid && marketEvent.Bid.IsPositive() && marketEvent.Ask.IsPositive() {
+		return marketEvent.Bid.Add(marketEvent.Ask).Div(decimal.NewFromInt(2))
+	}
+	return marketEvent.Close
+}
@@ -0,0 +1,70 @@
+package risk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func TestParseEntryPriceSource(t *testing.T) {
+	for name, want := range map[string]EntryPriceSource{"": EntryAtClose, "close": EntryAtClose, "next_open": EntryAtNextOpen, "mid": EntryAtMid} {
+		got, err := ParseEntryPriceSource(name)
+		if err != nil || got != want {
+			t.Errorf("ParseEntryPriceSource(%q) = %s, %v; want %s", name, got, err, want)
+		}
+	}
+	if _, err := ParseEntryPriceSource("vwap"); err == nil {
+		t.Error("expected error for unknown source")
+	}
+}
+
+func TestEngine_EntryPriceSource(t *testing.T) {
+	quoted := types.MarketEvent{
+		Symbol: "MES",
+		Close:  decimal.RequireFromString("5000"),
+		Bid:    decimal.RequireFromString("5001"),
+		Ask:    decimal.RequireFromString("5001.5"),
+	}
+	unquoted := quoted
+	unquoted.Bid, unquoted.Ask = decimal.Zero, decimal.Zero
+
+	tests := []struct {
+		name      string
+		source    EntryPriceSource
+		event     types.MarketEvent
+		wantEntry string
+	}{
+		{"close ignores quotes", EntryAtClose, quoted, "5000"},
+		{"next open is estimated from the close", EntryAtNextOpen, quoted, "5000"},
+		{"mid uses the quotes", EntryAtMid, quoted, "5001.25"},
+		{"mid falls back to the close", EntryAtMid, unquoted, "5000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.EntryPriceSource = tt.source
+			engine := NewEngine(cfg, decimal.NewFromInt(10000), nil)
+
+			signal := types.Signal{ID: "sig", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+			intent, err := engine.ValidateAndSize(context.Background(), signal, tt.event)
+			if err != nil {
+				t.Fatalf("ValidateAndSize failed: %v", err)
+			}
+
+			entry := decimal.RequireFromString(tt.wantEntry)
+			if !intent.EntryPrice.Equal(entry) {
+				t.Errorf("EntryPrice = %s, want %s", intent.EntryPrice, entry)
+			}
+			// Brackets hang off the same price: 10 ticks stop, 15 ticks target
+			if want := entry.Sub(decimal.RequireFromString("2.5")); !intent.StopLoss.Equal(want) {
+				t.Errorf("StopLoss = %s, want %s", intent.StopLoss, want)
+			}
+			if want := entry.Add(decimal.RequireFromString("3.75")); !intent.TakeProfit.Equal(want) {
+				t.Errorf("TakeProfit = %s, want %s", intent.TakeProfit, want)
+			}
+		})
+	}
+}
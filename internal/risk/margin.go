@@ -0,0 +1,95 @@
+package risk
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// MarginPolicy decides what happens to an order that needs more margin
+// than the account has free once open positions are accounted for.
+type MarginPolicy int
+
+const (
+	// MarginUnchecked only applies the exposure percentages.
+	MarginUnchecked MarginPolicy = iota
+	// MarginReject rejects the order.
+	MarginReject
+	// MarginDownsize shrinks the order to what free margin covers.
+	MarginDownsize
+)
+
+// String returns the config name of the policy.
+func (p MarginPolicy) String() string {
+	switch p {
+	case MarginReject:
+		return "reject"
+	case MarginDownsize:
+		return "downsize"
+	default:
+		return "off"
+	}
+}
+
+// ParseMarginPolicy parses a policy name; empty means off.
+func ParseMarginPolicy(name string) (MarginPolicy, error) {
+	switch name {
+	case "", "off":
+		return MarginUnchecked, nil
+	case "reject":
+		return MarginReject, nil
+	case "downsize":
+		return MarginDownsize, nil
+	default:
+		return MarginUnchecked, fmt.Errorf("unknown margin policy %q", name)
+	}
+}
+
+// contractMargin returns the margin one contract ties up, preferring the
+// intraday rate.
+func contractMargin(spec types.InstrumentSpec) decimal.Decimal {
+	if spec.MarginIntra.IsZero() {
+		return spec.MarginInitial
+	}
+	return spec.MarginIntra
+}
+
+// freeMarginLocked returns equity less the margin held by open positions.
+func (e *Engine) freeMarginLocked() decimal.Decimal {
+	free := e.hwm.Current()
+	for _, margin := range e.marginUsed {
+		free = free.Sub(margin)
+	}
+	return free
+}
+
+// fitFreeMarginLocked returns how many of contracts on side free margin
+// covers under the configured policy, or an error wrapping
+// ErrInsufficientMargin. An order against the held position releases that
+// position's margin first.
+func (e *Engine) fitFreeMarginLocked(side types.Side, contracts int, spec types.InstrumentSpec) (int, error) {
+	perContract := contractMargin(spec)
+	if e.cfg.MarginPolicy == MarginUnchecked || !perContract.IsPositive() {
+		return contracts, nil
+	}
+
+	free := e.freeMarginLocked()
+	if pos, ok := e.positions[spec.Symbol]; ok && pos.Side == side.Opposite() {
+		free = free.Add(e.marginUsed[spec.Symbol])
+	}
+	needed := perContract.Mul(decimal.NewFromInt(int64(contracts)))
+	if needed.LessThanOrEqual(free) {
+		return contracts, nil
+	}
+
+	affordable := 0
+	if free.IsPositive() {
+		affordable = int(free.Div(perContract).IntPart())
+	}
+	if e.cfg.MarginPolicy == MarginReject || affordable < 1 {
+		return 0, fmt.Errorf("%w: order margin %s exceeds free margin %s",
+			types.ErrInsufficientMargin, needed.StringFixed(2), free.StringFixed(2))
+	}
+	return affordable, nil
+}
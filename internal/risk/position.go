@@ -0,0 +1,148 @@
+package risk
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// CostBasis decides which contracts a partial close takes off a tracked
+// position, and so the entry price of the contracts left open.
+type CostBasis int
+
+const (
+	// CostAverage leaves the average entry price unchanged on a reduction.
+	CostAverage CostBasis = iota
+	// CostFIFO closes the oldest contracts first; the rest keep their prices.
+	CostFIFO
+)
+
+// String returns the config name of the cost basis.
+func (c CostBasis) String() string {
+	if c == CostFIFO {
+		return "fifo"
+	}
+	return "average"
+}
+
+// ParseCostBasis parses a cost basis name; empty means average.
+func ParseCostBasis(name string) (CostBasis, error) {
+	switch name {
+	case "", "average":
+		return CostAverage, nil
+	case "fifo":
+		return CostFIFO, nil
+	default:
+		return CostAverage, fmt.Errorf("unknown partial close accounting %q", name)
+	}
+}
+
+// lot is a block of contracts opened at one price.
+type lot struct {
+	contracts int
+	price     decimal.Decimal
+}
+
+// UpdatePosition records a position as the broker now reports it. A report
+// with more contracts than tracked adds a lot at the price implied by the
+// reported average cost; one with fewer closes contracts under the
+// configured CostBasis. The tracked entry price is the blended cost of the
+// remaining lots, and the symbol's margin is recomputed. Zero contracts
+// removes the position.
+func (e *Engine) UpdatePosition(position *types.Position) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	symbol := position.Symbol
+	if position.Contracts <= 0 {
+		delete(e.positions, symbol)
+		delete(e.lots, symbol)
+		delete(e.marginUsed, symbol)
+		return
+	}
+
+	pos := *position
+	tracked, ok := e.positions[symbol]
+	switch {
+	case !ok || tracked.Side != pos.Side:
+		// New or flipped position
+		e.lots[symbol] = []lot{{contracts: pos.Contracts, price: pos.EntryPrice}}
+	case pos.Contracts > tracked.Contracts:
+		added := pos.Contracts - tracked.Contracts
+		e.lots[symbol] = append(e.lots[symbol], lot{contracts: added, price: addedPrice(tracked, &pos)})
+	case pos.Contracts < tracked.Contracts:
+		e.lots[symbol] = e.closeLots(e.lots[symbol], tracked.Contracts-pos.Contracts)
+	}
+
+	if cost := lotCost(e.lots[symbol]); !cost.IsZero() {
+		pos.EntryPrice = cost
+	}
+	e.positions[symbol] = &pos
+	e.marginUsed[symbol] = positionMargin(symbol, pos.Contracts)
+}
+
+// MarginUsed returns the margin held by the tracked position in symbol.
+func (e *Engine) MarginUsed(symbol string) decimal.Decimal {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.marginUsed[symbol]
+}
+
+// addedPrice returns the price of the contracts a report added: the one
+// that blends with the tracked cost into the reported average cost. A
+// report without a cost adds at the tracked cost.
+func addedPrice(tracked, reported *types.Position) decimal.Decimal {
+	if reported.EntryPrice.IsZero() {
+		return tracked.EntryPrice
+	}
+	added := decimal.NewFromInt(int64(reported.Contracts - tracked.Contracts))
+	total := reported.EntryPrice.Mul(decimal.NewFromInt(int64(reported.Contracts)))
+	held := tracked.EntryPrice.Mul(decimal.NewFromInt(int64(tracked.Contracts)))
+	return total.Sub(held).Div(added)
+}
+
+// closeLots takes n contracts off lots under the configured cost basis.
+func (e *Engine) closeLots(lots []lot, n int) []lot {
+	if e.cfg.CostBasis != CostFIFO {
+		// One lot at the average cost keeps that cost for what remains
+		held := 0
+		for _, l := range lots {
+			held += l.contracts
+		}
+		return []lot{{contracts: held - n, price: lotCost(lots)}}
+	}
+
+	remaining := make([]lot, 0, len(lots))
+	for _, l := range lots {
+		take := min(n, l.contracts)
+		n -= take
+		if l.contracts > take {
+			remaining = append(remaining, lot{contracts: l.contracts - take, price: l.price})
+		}
+	}
+	return remaining
+}
+
+// lotCost returns the contract-weighted average price of lots.
+func lotCost(lots []lot) decimal.Decimal {
+	contracts := 0
+	cost := decimal.Zero
+	for _, l := range lots {
+		contracts += l.contracts
+		cost = cost.Add(l.price.Mul(decimal.NewFromInt(int64(l.contracts))))
+	}
+	if contracts == 0 {
+		return decimal.Zero
+	}
+	return cost.Div(decimal.NewFromInt(int64(contracts)))
+}
+
+// positionMargin returns the margin contracts of symbol tie up.
+func positionMargin(symbol string, contracts int) decimal.Decimal {
+	spec, ok := types.GetInstrumentSpec(symbol)
+	if !ok {
+		return decimal.Zero
+	}
+	return contractMargin(spec).Mul(decimal.NewFromInt(int64(contracts)))
+}
@@ -0,0 +1,107 @@
+package risk
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func TestParseCostBasis(t *testing.T) {
+	for name, want := range map[string]CostBasis{"": CostAverage, "average": CostAverage, "fifo": CostFIFO} {
+		got, err := ParseCostBasis(name)
+		if err != nil || got != want {
+			t.Errorf("ParseCostBasis(%q) = %s, %v; want %s", name, got, err, want)
+		}
+	}
+	if _, err := ParseCostBasis("lifo"); err == nil {
+		t.Error("expected error for unknown accounting")
+	}
+}
+
+func TestEngine_UpdatePosition_PartialClose(t *testing.T) {
+	mesMargin := contractMargin(types.InstrumentMES)
+
+	tests := []struct {
+		name      string
+		basis     CostBasis
+		wantEntry string
+	}{
+		// 1 @ 5000 then 2 @ 5015 blend to 3 @ 5010
+		{"average keeps the blended cost", CostAverage, "5010"},
+		{"fifo closes the 5000 lot first", CostFIFO, "5015"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.CostBasis = tt.basis
+			engine := NewEngine(cfg, decimal.NewFromInt(10000), nil)
+
+			report := func(contracts int, entry string) {
+				engine.UpdatePosition(&types.Position{
+					Symbol:     "MES",
+					Side:       types.SideLong,
+					Contracts:  contracts,
+					EntryPrice: decimal.RequireFromString(entry),
+				})
+			}
+
+			report(1, "5000")
+			report(3, "5010") // Broker average after adding 2 @ 5015
+
+			pos, _ := engine.GetPosition("MES")
+			if pos.Contracts != 3 || !pos.EntryPrice.Equal(decimal.NewFromInt(5010)) {
+				t.Fatalf("after add: %d @ %s, want 3 @ 5010", pos.Contracts, pos.EntryPrice)
+			}
+			if got := engine.MarginUsed("MES"); !got.Equal(mesMargin.Mul(decimal.NewFromInt(3))) {
+				t.Errorf("MarginUsed after add = %s, want 3 contracts", got)
+			}
+
+			// The broker reports the 3-lot reduced to a 1-lot
+			report(1, "0")
+
+			pos, _ = engine.GetPosition("MES")
+			if pos.Contracts != 1 || !pos.EntryPrice.Equal(decimal.RequireFromString(tt.wantEntry)) {
+				t.Errorf("after reduce: %d @ %s, want 1 @ %s", pos.Contracts, pos.EntryPrice, tt.wantEntry)
+			}
+			if got := engine.MarginUsed("MES"); !got.Equal(mesMargin) {
+				t.Errorf("MarginUsed after reduce = %s, want %s", got, mesMargin)
+			}
+
+			report(0, "0")
+			if _, ok := engine.GetPosition("MES"); ok {
+				t.Error("position should be gone after closing")
+			}
+			if got := engine.MarginUsed("MES"); !got.IsZero() {
+				t.Errorf("MarginUsed after close = %s, want 0", got)
+			}
+		})
+	}
+}
+
+func TestEngine_UpdatePosition_Flip(t *testing.T) {
+	engine := NewEngine(DefaultConfig(), decimal.NewFromInt(10000), nil)
+
+	engine.UpdatePosition(&types.Position{Symbol: "MES", Side: types.SideLong, Contracts: 3, EntryPrice: decimal.NewFromInt(5000)})
+	engine.UpdatePosition(&types.Position{Symbol: "MES", Side: types.SideShort, Contracts: 1, EntryPrice: decimal.NewFromInt(4990)})
+
+	pos, _ := engine.GetPosition("MES")
+	if pos.Side != types.SideShort || pos.Contracts != 1 || !pos.EntryPrice.Equal(decimal.NewFromInt(4990)) {
+		t.Errorf("flipped position = %s %d @ %s, want SHORT 1 @ 4990", pos.Side, pos.Contracts, pos.EntryPrice)
+	}
+}
+
+func TestEngine_UpdatePosition_ReducedExposure(t *testing.T) {
+	engine := NewEngine(DefaultConfig(), decimal.NewFromInt(10000), nil)
+
+	// 3 MGC contracts hold most of the account
+	engine.UpdatePosition(&types.Position{Symbol: "MGC", Side: types.SideLong, Contracts: 3, EntryPrice: decimal.NewFromInt(2000)})
+	held := engine.freeMarginLocked()
+
+	engine.UpdatePosition(&types.Position{Symbol: "MGC", Side: types.SideLong, Contracts: 1})
+	freed := engine.freeMarginLocked().Sub(held)
+	if want := contractMargin(types.InstrumentMGC).Mul(decimal.NewFromInt(2)); !freed.Equal(want) {
+		t.Errorf("margin freed by the reduction = %s, want %s", freed, want)
+	}
+}
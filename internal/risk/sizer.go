@@ -176,3 +176,27 @@ func (p *PositionSizer) AdjustForMaxSize(calculated int, maxAllowed int) int {
 	}
 	return calculated
 }
+
+// RiskScalingCurve adjusts risk per trade for the current drawdown.
+type RiskScalingCurve interface {
+	// Scale returns the fraction of RiskPerTradePct to risk at drawdown,
+	// where maxDrawdown is the kill-switch level. Both are ratios.
+	Scale(drawdown, maxDrawdown decimal.Decimal) decimal.Decimal
+}
+
+// LinearRiskScaling cuts risk linearly from full size at zero drawdown to
+// Floor as drawdown reaches the kill-switch level, so losses slow down
+// before the account is halted.
+type LinearRiskScaling struct {
+	Floor decimal.Decimal // Fraction of risk kept at max drawdown, e.g. 0.25
+}
+
+// Scale implements RiskScalingCurve.
+func (l LinearRiskScaling) Scale(drawdown, maxDrawdown decimal.Decimal) decimal.Decimal {
+	if !maxDrawdown.IsPositive() || !drawdown.IsPositive() {
+		return decimal.NewFromInt(1)
+	}
+	progress := decimal.Min(drawdown.Div(maxDrawdown), decimal.NewFromInt(1))
+	cut := decimal.NewFromInt(1).Sub(l.Floor).Mul(progress)
+	return decimal.NewFromInt(1).Sub(cut)
+}
@@ -310,6 +310,29 @@ func TestPositionSizer_AdjustForMaxSize(t *testing.T) {
 	}
 }
 
+func TestLinearRiskScaling_Scale(t *testing.T) {
+	curve := LinearRiskScaling{Floor: decimal.RequireFromString("0.25")}
+	maxDD := decimal.RequireFromString("0.20")
+
+	tests := []struct {
+		drawdown string
+		want     string
+	}{
+		{"0", "1"},
+		{"0.10", "0.625"},
+		{"0.19", "0.2875"},
+		{"0.20", "0.25"},
+		{"0.30", "0.25"}, // Past the limit stays at the floor
+	}
+
+	for _, tt := range tests {
+		got := curve.Scale(decimal.RequireFromString(tt.drawdown), maxDD)
+		if !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("Scale(%s) = %s, want %s", tt.drawdown, got, tt.want)
+		}
+	}
+}
+
 func TestNewPositionSizerForSymbol(t *testing.T) {
 	tests := []struct {
 		symbol    string
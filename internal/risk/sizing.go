@@ -0,0 +1,81 @@
+package risk
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// SizingMode decides how many contracts an approved signal trades. Every
+// mode still goes through the contract cap, margin and exposure checks.
+type SizingMode int
+
+const (
+	// SizingRiskBased risks RiskPerTradePct of equity over the stop distance.
+	SizingRiskBased SizingMode = iota
+	// SizingFixedContracts always trades FixedContracts.
+	SizingFixedContracts
+	// SizingFixedNotional trades as many contracts as FixedNotional dollars
+	// of notional value buys at the entry price.
+	SizingFixedNotional
+)
+
+// String returns the config name of the sizing mode.
+func (m SizingMode) String() string {
+	switch m {
+	case SizingFixedContracts:
+		return "fixed_contracts"
+	case SizingFixedNotional:
+		return "fixed_notional"
+	default:
+		return "risk_based"
+	}
+}
+
+// ParseSizingMode parses a sizing mode name; empty means risk based.
+func ParseSizingMode(name string) (SizingMode, error) {
+	switch name {
+	case "", "risk_based":
+		return SizingRiskBased, nil
+	case "fixed_contracts":
+		return SizingFixedContracts, nil
+	case "fixed_notional":
+		return SizingFixedNotional, nil
+	default:
+		return SizingRiskBased, fmt.Errorf("unknown sizing mode %q", name)
+	}
+}
+
+// fixedSize sizes an order for the fixed sizing modes. The stop still
+// comes from stopTicks, so brackets and RiskAmount match the risk-based
+// mode. riskScale is the drawdown and cooldown scaling the risk-based mode
+// applies to risk per trade; here it scales the count, rounded down.
+func (e *Engine) fixedSize(stopTicks int, entry decimal.Decimal, side types.Side, spec types.InstrumentSpec, riskScale decimal.Decimal) SizeResult {
+	var result SizeResult
+
+	contracts := e.cfg.FixedContracts
+	if e.cfg.SizingMode == SizingFixedNotional {
+		perContract := entry.Mul(spec.PointValue)
+		if !perContract.IsPositive() {
+			result.RejectReason = "no notional value at the entry price"
+			return result
+		}
+		contracts = int(e.cfg.FixedNotional.Div(perContract).IntPart())
+	}
+	contracts = int(decimal.NewFromInt(int64(contracts)).Mul(riskScale).IntPart())
+	if contracts < 1 {
+		result.RejectReason = fmt.Sprintf("%s sizing gives less than 1 contract", e.cfg.SizingMode)
+		return result
+	}
+
+	stopDistance := spec.TickSize.Mul(decimal.NewFromInt(int64(stopTicks)))
+	result.StopLoss = entry.Sub(stopDistance)
+	if side == types.SideShort {
+		result.StopLoss = entry.Add(stopDistance)
+	}
+	result.RiskAmount = spec.TickValue.Mul(decimal.NewFromInt(int64(stopTicks))).Mul(decimal.NewFromInt(int64(contracts)))
+	result.Contracts = contracts
+	result.Valid = true
+	return result
+}
@@ -0,0 +1,143 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func TestParseSizingMode(t *testing.T) {
+	for name, want := range map[string]SizingMode{
+		"":                SizingRiskBased,
+		"risk_based":      SizingRiskBased,
+		"fixed_contracts": SizingFixedContracts,
+		"fixed_notional":  SizingFixedNotional,
+	} {
+		got, err := ParseSizingMode(name)
+		if err != nil || got != want {
+			t.Errorf("ParseSizingMode(%q) = %s, %v; want %s", name, got, err, want)
+		}
+	}
+	if _, err := ParseSizingMode("kelly"); err == nil {
+		t.Error("expected error for unknown sizing mode")
+	}
+}
+
+func TestEngine_ValidateAndSize_SizingModes(t *testing.T) {
+	// MES at 5000 with a 10-tick stop: $12.50 risk and $25,000 notional per contract
+	signal := types.Signal{ID: "sig-size", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000), ATR: decimal.RequireFromString("2.5")}
+
+	tests := []struct {
+		name          string
+		mode          SizingMode
+		contracts     int
+		notional      string
+		maxPerOrder   int
+		wantContracts int
+		wantErr       error
+	}{
+		{"risk based: 1% of 100k over $12.50", SizingRiskBased, 0, "0", 0, 80, nil},
+		{"fixed contracts", SizingFixedContracts, 3, "0", 0, 3, nil},
+		{"fixed contracts still capped", SizingFixedContracts, 5, "0", 2, 2, nil},
+		{"fixed notional rounds down", SizingFixedNotional, 0, "60000", 0, 2, nil},
+		{"fixed notional below one contract", SizingFixedNotional, 0, "20000", 0, 0, types.ErrInsufficientEquity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.MaxExposurePerSymbolPct = decimal.NewFromInt(1)
+			cfg.SizingMode = tt.mode
+			cfg.FixedContracts = tt.contracts
+			cfg.FixedNotional = decimal.RequireFromString(tt.notional)
+			cfg.MaxContractsPerOrder = tt.maxPerOrder
+			engine := NewEngine(cfg, decimal.NewFromInt(100000), nil)
+
+			intent, err := engine.ValidateAndSize(context.Background(), signal, event)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ValidateAndSize() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateAndSize() error = %v", err)
+			}
+			if intent.Contracts != tt.wantContracts {
+				t.Errorf("Contracts = %d, want %d", intent.Contracts, tt.wantContracts)
+			}
+			if !intent.StopLoss.Equal(decimal.RequireFromString("4997.5")) {
+				t.Errorf("StopLoss = %s, want 4997.5", intent.StopLoss)
+			}
+			wantRisk := decimal.RequireFromString("12.5").Mul(decimal.NewFromInt(int64(tt.wantContracts)))
+			if !intent.RiskAmount.Equal(wantRisk) {
+				t.Errorf("RiskAmount = %s, want %s", intent.RiskAmount, wantRisk)
+			}
+		})
+	}
+}
+
+func TestEngine_FixedSizing_RiskScaling(t *testing.T) {
+	signal := types.Signal{ID: "sig-fixed-scale", Symbol: "MES", Direction: types.SideLong, StopTicks: 10}
+	event := types.MarketEvent{Symbol: "MES", Close: decimal.NewFromInt(5000)}
+	exitedAt := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		factor    string // Kill switch cooldown risk factor
+		elapsed   time.Duration
+		floor     string // Drawdown risk floor; equity 90000 is a 10% drawdown
+		wantCount int
+		wantErr   error
+	}{
+		{"half count during cooldown", "0.5", 0, "", 2, nil},
+		{"full count once elapsed", "0.5", time.Hour, "", 5, nil},
+		{"below one contract rejected", "0.1", 0, "", 0, types.ErrInsufficientEquity},
+		{"zero factor blocks", "0", 0, "", 0, types.ErrKillSwitchCooldown},
+		{"drawdown scaling", "0.5", time.Hour, "0.25", 3, nil}, // 5 * 0.625
+		{"both scalings", "0.5", 0, "0.25", 1, nil},            // 5 * 0.625 * 0.5
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.SizingMode = SizingFixedContracts
+			cfg.FixedContracts = 5
+			cfg.MaxExposurePerSymbolPct = decimal.NewFromInt(1)
+			cfg.PostKillSwitchCooldown = time.Hour
+			cfg.PostKillSwitchRiskFactor = decimal.RequireFromString(tt.factor)
+			if tt.floor != "" {
+				cfg.RiskScalingCurve = LinearRiskScaling{Floor: decimal.RequireFromString(tt.floor)}
+			}
+			engine := NewEngine(cfg, decimal.NewFromInt(100000), nil)
+
+			now := exitedAt
+			engine.now = func() time.Time { return now }
+			engine.EnterSafeMode("test")
+			engine.ExitSafeMode()
+			now = exitedAt.Add(tt.elapsed)
+			if tt.floor != "" {
+				engine.UpdateEquity(decimal.NewFromInt(90000))
+			}
+
+			intent, err := engine.ValidateAndSize(context.Background(), signal, event)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ValidateAndSize() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateAndSize() error = %v", err)
+			}
+			if intent.Contracts != tt.wantCount {
+				t.Errorf("Contracts = %d, want %d", intent.Contracts, tt.wantCount)
+			}
+		})
+	}
+}
@@ -48,6 +48,7 @@ func DefaultBreakoutConfig() BreakoutConfig {
 // Generates LONG when price breaks above the highest high of N bars.
 // Generates SHORT when price breaks below the lowest low of N bars.
 type Breakout struct {
+	Base
 	cfg BreakoutConfig
 
 	highs           []decimal.Decimal
@@ -70,15 +71,7 @@ func NewBreakout(cfg BreakoutConfig) *Breakout {
 
 // OnMarketEvent processes a market event and generates signals.
 func (b *Breakout) OnMarketEvent(ctx context.Context, event types.MarketEvent) []types.Signal {
-	// Update high/low history
-	b.highs = append(b.highs, event.High)
-	b.lows = append(b.lows, event.Low)
-
-	// Trim to lookback period
-	if len(b.highs) > b.cfg.LookbackBars {
-		b.highs = b.highs[1:]
-		b.lows = b.lows[1:]
-	}
+	b.addBar(event)
 
 	// Need enough history
 	if len(b.highs) < b.cfg.LookbackBars {
@@ -136,6 +129,21 @@ func (b *Breakout) OnMarketEvent(ctx context.Context, event types.MarketEvent) [
 	return signals
 }
 
+// WarmUp adds the bar to the range history without trading on it.
+func (b *Breakout) WarmUp(event types.MarketEvent) {
+	b.addBar(event)
+}
+
+// addBar updates the high/low history, trimmed to the lookback period.
+func (b *Breakout) addBar(event types.MarketEvent) {
+	b.highs = append(b.highs, event.High)
+	b.lows = append(b.lows, event.Low)
+	if len(b.highs) > b.cfg.LookbackBars {
+		b.highs = b.highs[1:]
+		b.lows = b.lows[1:]
+	}
+}
+
 // Name returns the strategy name.
 func (b *Breakout) Name() string {
 	return "breakout"
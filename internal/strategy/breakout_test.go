@@ -53,6 +53,40 @@ func TestBreakout_LongSignalOnBreakoutAbove(t *testing.T) {
 	}
 }
 
+func TestBreakout_SignalIDStableAcrossRestart(t *testing.T) {
+	baseTime := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	bars := []types.MarketEvent{
+		createOHLCEvent(100, 105, 95, 100),
+		createOHLCEvent(100, 103, 97, 100),
+		createOHLCEvent(100, 104, 96, 100),
+		createOHLCEvent(106, 108, 105, 107),
+	}
+	for i := range bars {
+		bars[i].Timestamp = baseTime.Add(time.Duration(i) * time.Minute)
+	}
+
+	// A fresh strategy replaying the same bars stands in for a restart
+	run := func() types.Signal {
+		cfg := DefaultBreakoutConfig()
+		cfg.LookbackBars = 3
+		cfg.BreakoutBuffer = decimal.Zero
+		strategy := NewBreakout(cfg)
+		var signals []types.Signal
+		for _, bar := range bars {
+			signals = strategy.OnMarketEvent(context.Background(), bar)
+		}
+		if len(signals) != 1 {
+			t.Fatalf("Expected 1 signal, got %d", len(signals))
+		}
+		return signals[0]
+	}
+
+	first, second := run(), run()
+	if first.ID == "" || first.ID != second.ID {
+		t.Errorf("signal IDs = %q and %q, want the same non-empty ID", first.ID, second.ID)
+	}
+}
+
 func TestBreakout_ShortSignalOnBreakoutBelow(t *testing.T) {
 	cfg := DefaultBreakoutConfig()
 	cfg.LookbackBars = 3
@@ -0,0 +1,74 @@
+package strategy
+
+import (
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// Base provides no-op defaults for the optional Strategy methods. Embed it
+// in strategies that don't use them.
+type Base struct{}
+
+// OnTradeClosed ignores closed trades.
+func (Base) OnTradeClosed(types.Trade) {}
+
+// Cooldown blocks new entries for a number of bars after an entry signal,
+// and optionally for longer after a losing trade closes. Call Tick once per
+// bar before checking Active.
+type Cooldown struct {
+	bars     int // Bars blocked after an entry signal (0 = off)
+	lossBars int // Bars blocked after a losing trade (0 = off)
+
+	bar    int
+	resume int // First bar on which entries are allowed again
+}
+
+// NewCooldown creates a cooldown of bars after each entry and lossBars
+// after each losing trade.
+func NewCooldown(bars, lossBars int) *Cooldown {
+	return &Cooldown{
+		bars:     bars,
+		lossBars: lossBars,
+	}
+}
+
+// Tick advances the cooldown by one bar.
+func (c *Cooldown) Tick() {
+	c.bar++
+}
+
+// Active reports whether entries are blocked on the current bar.
+func (c *Cooldown) Active() bool {
+	return c.bar < c.resume
+}
+
+// Remaining returns how many bars after the current one entries stay blocked.
+func (c *Cooldown) Remaining() int {
+	return max(0, c.resume-c.bar-1)
+}
+
+// Trigger starts the entry cooldown after a signal on the current bar.
+func (c *Cooldown) Trigger() {
+	c.extend(c.bars)
+}
+
+// OnTradeClosed starts the loss cooldown if the trade lost money. A loss
+// never shortens a cooldown already running.
+func (c *Cooldown) OnTradeClosed(trade types.Trade) {
+	if trade.NetPL.IsNegative() {
+		c.extend(c.lossBars)
+	}
+}
+
+// extend blocks entries for the next n bars.
+func (c *Cooldown) extend(n int) {
+	if n <= 0 {
+		return
+	}
+	c.resume = max(c.resume, c.bar+n+1)
+}
+
+// Reset clears the cooldown.
+func (c *Cooldown) Reset() {
+	c.bar = 0
+	c.resume = 0
+}
@@ -0,0 +1,56 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func TestCooldown_AfterEntry(t *testing.T) {
+	c := NewCooldown(2, 0)
+
+	c.Tick()
+	if c.Active() {
+		t.Fatal("cooldown should start inactive")
+	}
+	c.Trigger()
+
+	// Blocks the next two bars
+	for bar := 1; bar <= 2; bar++ {
+		c.Tick()
+		if !c.Active() {
+			t.Errorf("bar +%d: expected cooldown active", bar)
+		}
+	}
+	c.Tick()
+	if c.Active() {
+		t.Error("cooldown should end after 2 bars")
+	}
+}
+
+func TestCooldown_ExtendsAfterLoss(t *testing.T) {
+	c := NewCooldown(1, 5)
+	c.Tick()
+
+	c.OnTradeClosed(types.Trade{NetPL: decimal.NewFromInt(50)})
+	if c.Active() {
+		t.Error("winning trade should not start a cooldown")
+	}
+
+	c.OnTradeClosed(types.Trade{NetPL: decimal.NewFromInt(-50)})
+	if got := c.Remaining(); got != 5 {
+		t.Errorf("Remaining() = %d, want 5", got)
+	}
+
+	// A later entry cooldown doesn't shorten the loss cooldown
+	c.Trigger()
+	if got := c.Remaining(); got != 5 {
+		t.Errorf("Remaining() after Trigger = %d, want 5", got)
+	}
+
+	c.Reset()
+	if c.Active() {
+		t.Error("Reset should clear the cooldown")
+	}
+}
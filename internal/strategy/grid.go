@@ -56,6 +56,13 @@ type GridConfig struct {
 	// Risk parameters
 	StopLossPct   decimal.Decimal // Stop loss as % of entry (e.g., 0.005 = 0.5%)
 	MinMovePoints decimal.Decimal // Minimum price move to trigger grid entry
+
+	// Level aging
+	MaxLevelAgeBars int // Force exit of a grid level after this many bars (0 = disabled)
+
+	// Entry throttling
+	CooldownBars     int // Bars to skip after an entry signal (0 = disabled)
+	LossCooldownBars int // Bars to skip after a losing trade (0 = disabled)
 }
 
 // OriginalGridConfig returns the high-frequency grid parameters.
@@ -109,11 +116,26 @@ type Grid struct {
 	swingHigh     decimal.Decimal
 	swingLow      decimal.Decimal
 	lastGridLevel int // Current grid level (0 = no position, 1-N = grid levels)
-	lastSignalBar int // Bar index of last signal (prevent rapid firing)
 	barCount      int
+	cooldown      *Cooldown
 
 	// Track active grid direction
 	gridDirection types.Side // LONG grid (buying dips) or SHORT grid (selling rallies)
+
+	// Open grid levels, oldest first (only tracked when MaxLevelAgeBars > 0).
+	// Entries wait in pending, keyed by signal ID, until their order fills.
+	levels  []GridLevel
+	pending map[string]GridLevel
+	closing int // Contracts of expired levels whose exit hasn't closed yet
+}
+
+// GridLevel is an open grid level tracked for age-based exits.
+type GridLevel struct {
+	Level     int        // Grid level number (1-N)
+	Direction types.Side // Direction of the level's entry
+	EntryBar  int        // Bar index when the level's entry filled
+	SignalID  string     // ID of the entry signal
+	Contracts int        // Contracts filled for the level
 }
 
 // NewGrid creates a new grid strategy with default (original) config.
@@ -122,26 +144,28 @@ func NewGrid(cfg GridConfig) *Grid {
 		cfg:   cfg,
 		highs: make([]decimal.Decimal, 0, cfg.LookbackBars),
 		lows:  make([]decimal.Decimal, 0, cfg.LookbackBars),
+
+		cooldown: NewCooldown(cfg.CooldownBars, cfg.LossCooldownBars),
+		pending:  make(map[string]GridLevel),
 	}
 }
 
 // OnMarketEvent processes a market event and generates signals.
 func (g *Grid) OnMarketEvent(ctx context.Context, event types.MarketEvent) []types.Signal {
 	g.barCount++
+	g.cooldown.Tick()
 
-	// Update price history
-	g.highs = append(g.highs, event.High)
-	g.lows = append(g.lows, event.Low)
-
-	// Trim to lookback period
-	if len(g.highs) > g.cfg.LookbackBars {
-		g.highs = g.highs[1:]
-		g.lows = g.lows[1:]
+	// Force exit of the oldest level once it exceeds the max age
+	var signals []types.Signal
+	if exit, ok := g.checkLevelAge(event); ok {
+		signals = append(signals, exit)
 	}
 
+	g.addBar(event)
+
 	// Need enough history
 	if len(g.highs) < g.cfg.LookbackBars {
-		return nil
+		return signals
 	}
 
 	// Calculate swing high/low
@@ -151,17 +175,16 @@ func (g *Grid) OnMarketEvent(ctx context.Context, event types.MarketEvent) []typ
 	// Calculate the swing range
 	swingRange := g.swingHigh.Sub(g.swingLow)
 	if swingRange.LessThan(g.cfg.MinMovePoints) {
-		return nil // Range too small, no grid opportunity
+		return signals // Range too small, no grid opportunity
 	}
 
 	// Calculate grid spacing in points
 	gridSpacing := event.Close.Mul(g.cfg.GridSpacingPct)
-
-	var signals []types.Signal
+	cooling := g.cooldown.Active()
 
 	// Check for LONG grid opportunity (price dropped from high)
 	dropFromHigh := g.swingHigh.Sub(event.Close)
-	if dropFromHigh.GreaterThan(g.cfg.MinMovePoints) {
+	if dropFromHigh.GreaterThan(g.cfg.MinMovePoints) && !cooling {
 		// Calculate which grid level we're at
 		gridLevel := int(dropFromHigh.Div(gridSpacing).IntPart()) + 1
 
@@ -179,8 +202,10 @@ func (g *Grid) OnMarketEvent(ctx context.Context, event types.MarketEvent) []typ
 			tickSize := getTickSize(event.Symbol)
 			stopTicks := int(stopDistance.Div(tickSize).Ceil().IntPart())
 
+			// IDs come from the bar, not barCount, so a restarted grid
+			// replaying the bar gets the same ID and client order ID
 			signal := types.Signal{
-				ID:           fmt.Sprintf("grid-long-%d-%d", g.barCount, gridLevel),
+				ID:           fmt.Sprintf("grid-long-%s-%d-%d", event.Symbol, event.Timestamp.UnixNano(), gridLevel),
 				Timestamp:    event.Timestamp,
 				Symbol:       event.Symbol,
 				StrategyName: g.Name(),
@@ -192,12 +217,14 @@ func (g *Grid) OnMarketEvent(ctx context.Context, event types.MarketEvent) []typ
 			signals = append(signals, signal)
 			g.lastGridLevel = gridLevel
 			g.gridDirection = types.SideLong
+			g.trackLevel(signal, gridLevel)
+			g.cooldown.Trigger()
 		}
 	}
 
 	// Check for SHORT grid opportunity (price spiked from low)
 	riseFromLow := event.Close.Sub(g.swingLow)
-	if riseFromLow.GreaterThan(g.cfg.MinMovePoints) && g.gridDirection != types.SideLong {
+	if riseFromLow.GreaterThan(g.cfg.MinMovePoints) && g.gridDirection != types.SideLong && !cooling {
 		// Calculate which grid level we're at
 		gridLevel := int(riseFromLow.Div(gridSpacing).IntPart()) + 1
 
@@ -216,7 +243,7 @@ func (g *Grid) OnMarketEvent(ctx context.Context, event types.MarketEvent) []typ
 			stopTicks := int(stopDistance.Div(tickSize).Ceil().IntPart())
 
 			signal := types.Signal{
-				ID:           fmt.Sprintf("grid-short-%d-%d", g.barCount, gridLevel),
+				ID:           fmt.Sprintf("grid-short-%s-%d-%d", event.Symbol, event.Timestamp.UnixNano(), gridLevel),
 				Timestamp:    event.Timestamp,
 				Symbol:       event.Symbol,
 				StrategyName: g.Name(),
@@ -228,6 +255,8 @@ func (g *Grid) OnMarketEvent(ctx context.Context, event types.MarketEvent) []typ
 			signals = append(signals, signal)
 			g.lastGridLevel = gridLevel
 			g.gridDirection = types.SideShort
+			g.trackLevel(signal, gridLevel)
+			g.cooldown.Trigger()
 		}
 	}
 
@@ -242,6 +271,21 @@ func (g *Grid) OnMarketEvent(ctx context.Context, event types.MarketEvent) []typ
 	return signals
 }
 
+// WarmUp adds the bar to the swing history without trading on it.
+func (g *Grid) WarmUp(event types.MarketEvent) {
+	g.addBar(event)
+}
+
+// addBar updates the price history, trimmed to the lookback period.
+func (g *Grid) addBar(event types.MarketEvent) {
+	g.highs = append(g.highs, event.High)
+	g.lows = append(g.lows, event.Low)
+	if len(g.highs) > g.cfg.LookbackBars {
+		g.highs = g.highs[1:]
+		g.lows = g.lows[1:]
+	}
+}
+
 // Name returns the strategy name.
 func (g *Grid) Name() string {
 	return "grid"
@@ -254,9 +298,115 @@ func (g *Grid) Reset() {
 	g.swingHigh = decimal.Zero
 	g.swingLow = decimal.Zero
 	g.lastGridLevel = 0
-	g.lastSignalBar = 0
 	g.barCount = 0
+	g.cooldown.Reset()
 	g.gridDirection = types.SideFlat
+	g.levels = g.levels[:0]
+	clear(g.pending)
+	g.closing = 0
+}
+
+// OnTradeClosed extends the cooldown after a losing trade and stops
+// tracking the levels the trade closed.
+func (g *Grid) OnTradeClosed(trade types.Trade) {
+	g.cooldown.OnTradeClosed(trade)
+	g.dropClosed(trade.Contracts)
+}
+
+// dropClosed removes contracts closed by a trade from the tracked levels.
+// Contracts of expired levels, already dropped when their exit was sent,
+// are settled first; the rest close levels oldest first, as a stop or take
+// profit on the whole position closes every level.
+func (g *Grid) dropClosed(contracts int) {
+	settled := min(contracts, g.closing)
+	g.closing -= settled
+	contracts -= settled
+
+	for contracts > 0 && len(g.levels) > 0 {
+		if g.levels[0].Contracts > contracts {
+			g.levels[0].Contracts -= contracts
+			return
+		}
+		contracts -= g.levels[0].Contracts
+		g.levels = g.levels[1:]
+	}
+}
+
+// OnEntryFilled starts tracking a grid level once its entry order fills.
+func (g *Grid) OnEntryFilled(signal types.Signal, contracts int) {
+	level, ok := g.pending[signal.ID]
+	if !ok {
+		return
+	}
+	delete(g.pending, signal.ID)
+	if contracts <= 0 {
+		return
+	}
+	level.EntryBar = g.barCount
+	level.Contracts = contracts
+	g.levels = append(g.levels, level)
+}
+
+// OpenLevels returns the grid levels currently tracked for age-based exits, oldest first.
+func (g *Grid) OpenLevels() []GridLevel {
+	levels := make([]GridLevel, len(g.levels))
+	copy(levels, g.levels)
+	return levels
+}
+
+// trackLevel records a grid level entry signal. The level's age is only
+// tracked once OnEntryFilled confirms the fill.
+func (g *Grid) trackLevel(signal types.Signal, level int) {
+	if g.cfg.MaxLevelAgeBars <= 0 {
+		return
+	}
+	g.pending[signal.ID] = GridLevel{
+		Level:     level,
+		Direction: signal.Direction,
+		EntryBar:  g.barCount,
+		SignalID:  signal.ID,
+	}
+}
+
+// checkLevelAge returns a flat signal closing the oldest level's contracts
+// if it has been held longer than MaxLevelAgeBars. Only one level is closed
+// per bar so newer levels keep running.
+func (g *Grid) checkLevelAge(event types.MarketEvent) (types.Signal, bool) {
+	if g.cfg.MaxLevelAgeBars <= 0 {
+		return types.Signal{}, false
+	}
+
+	// Entries that haven't filled by the time they'd expire never will
+	for id, level := range g.pending {
+		if g.barCount-level.EntryBar > g.cfg.MaxLevelAgeBars {
+			delete(g.pending, id)
+		}
+	}
+
+	if len(g.levels) == 0 {
+		return types.Signal{}, false
+	}
+
+	oldest := g.levels[0]
+	age := g.barCount - oldest.EntryBar
+	if age <= g.cfg.MaxLevelAgeBars {
+		return types.Signal{}, false
+	}
+
+	// lastGridLevel is left untouched so the expired level isn't re-entered
+	// immediately; the normal midpoint reset re-arms the grid.
+	g.levels = g.levels[1:]
+	g.closing += oldest.Contracts
+
+	return types.Signal{
+		ID:           fmt.Sprintf("grid-exit-%s-%d-%d", event.Symbol, event.Timestamp.UnixNano(), oldest.Level),
+		Timestamp:    event.Timestamp,
+		Symbol:       event.Symbol,
+		StrategyName: g.Name(),
+		Direction:    types.SideFlat,
+		Contracts:    oldest.Contracts,
+		Reason:       fmt.Sprintf("grid L%d max age: held %d bars (entry %s)", oldest.Level, age, oldest.SignalID),
+	}, true
 }
 
 // calculateHigh returns the highest value in the slice.
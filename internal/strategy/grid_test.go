@@ -0,0 +1,268 @@
+package strategy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// newAgingGrid returns a grid with small parameters so that levels are
+// entered quickly on hand-crafted bars.
+func newAgingGrid(maxAge int) *Grid {
+	cfg := DefaultGridConfig()
+	cfg.LookbackBars = 10
+	cfg.GridSpacingPct = decimal.RequireFromString("0.02")
+	cfg.MinMovePoints = decimal.NewFromInt(1)
+	cfg.MaxGridLevels = 5
+	cfg.MaxLevelAgeBars = maxAge
+	return NewGrid(cfg)
+}
+
+// enterTwoLevels builds a 100-110 range and drops into grid levels 4 (bar 10)
+// and 5 (bar 11), filling 2 contracts for each entry on its signal bar.
+func enterTwoLevels(t *testing.T, g *Grid) {
+	t.Helper()
+	signalTwoLevels(t, g, func(signal types.Signal) { g.OnEntryFilled(signal, 2) })
+}
+
+// signalTwoLevels drives the enterTwoLevels bars, passing each entry
+// signal to fill, and returns the signals.
+func signalTwoLevels(t *testing.T, g *Grid, fill func(types.Signal)) []types.Signal {
+	t.Helper()
+	ctx := context.Background()
+
+	for i := 0; i < 9; i++ {
+		g.OnMarketEvent(ctx, createOHLCEvent(105, 110, 100, 105))
+	}
+
+	first := g.OnMarketEvent(ctx, createOHLCEvent(103, 103, 101, 102))
+	if len(first) != 1 || first[0].Direction != types.SideLong {
+		t.Fatalf("bar 10: expected 1 long signal, got %v", first)
+	}
+	fill(first[0])
+
+	second := g.OnMarketEvent(ctx, createOHLCEvent(102, 102, 100, 101))
+	if len(second) != 1 || second[0].Direction != types.SideLong {
+		t.Fatalf("bar 11: expected 1 long signal, got %v", second)
+	}
+	fill(second[0])
+	return []types.Signal{first[0], second[0]}
+}
+
+func TestGrid_MaxLevelAge_ClosesOldestLevel(t *testing.T) {
+	g := newAgingGrid(3)
+	ctx := context.Background()
+
+	enterTwoLevels(t, g)
+
+	if levels := g.OpenLevels(); len(levels) != 2 {
+		t.Fatalf("OpenLevels = %d, want 2", len(levels))
+	}
+
+	// Bars 12-13: both levels within max age
+	for bar := 12; bar <= 13; bar++ {
+		signals := g.OnMarketEvent(ctx, createOHLCEvent(101, 101, 101, 101))
+		if len(signals) != 0 {
+			t.Fatalf("bar %d: expected no signals, got %d", bar, len(signals))
+		}
+	}
+
+	// Bar 14: level 4 (entered bar 10) exceeds max age of 3 bars
+	signals := g.OnMarketEvent(ctx, createOHLCEvent(101, 101, 101, 101))
+	if len(signals) != 1 {
+		t.Fatalf("bar 14: expected 1 exit signal, got %d", len(signals))
+	}
+	if signals[0].Direction != types.SideFlat {
+		t.Errorf("Direction = %v, want FLAT", signals[0].Direction)
+	}
+	if signals[0].StrategyName != "grid" {
+		t.Errorf("StrategyName = %s, want grid", signals[0].StrategyName)
+	}
+	if signals[0].Contracts != 2 {
+		t.Errorf("Contracts = %d, want the level's 2 filled contracts", signals[0].Contracts)
+	}
+
+	levels := g.OpenLevels()
+	if len(levels) != 1 {
+		t.Fatalf("OpenLevels = %d, want 1", len(levels))
+	}
+	if levels[0].Level != 5 {
+		t.Errorf("remaining level = %d, want 5", levels[0].Level)
+	}
+	if levels[0].EntryBar != 11 {
+		t.Errorf("remaining EntryBar = %d, want 11", levels[0].EntryBar)
+	}
+
+	// Bar 15: level 5 expires too
+	signals = g.OnMarketEvent(ctx, createOHLCEvent(101, 101, 101, 101))
+	if len(signals) != 1 || signals[0].Direction != types.SideFlat {
+		t.Fatalf("bar 15: expected 1 exit signal, got %v", signals)
+	}
+	if len(g.OpenLevels()) != 0 {
+		t.Errorf("OpenLevels = %d, want 0", len(g.OpenLevels()))
+	}
+}
+
+func TestGrid_MaxLevelAge_StoppedOutLevelsNotExited(t *testing.T) {
+	g := newAgingGrid(3)
+	ctx := context.Background()
+
+	enterTwoLevels(t, g)
+
+	// The stop closes the whole position, both levels included
+	g.OnTradeClosed(types.Trade{Symbol: "MES", Side: types.SideLong, Contracts: 4, NetPL: decimal.NewFromInt(-50), ExitReason: "stop_loss"})
+	if len(g.OpenLevels()) != 0 {
+		t.Fatalf("OpenLevels = %d after the stop, want 0", len(g.OpenLevels()))
+	}
+
+	for bar := 12; bar <= 20; bar++ {
+		for _, s := range g.OnMarketEvent(ctx, createOHLCEvent(101, 101, 101, 101)) {
+			if s.Direction == types.SideFlat {
+				t.Fatalf("bar %d: unexpected exit %s for a stopped-out level", bar, s.ID)
+			}
+		}
+	}
+}
+
+func TestGrid_MaxLevelAge_ExitCloseKeepsNewerLevel(t *testing.T) {
+	g := newAgingGrid(3)
+	ctx := context.Background()
+
+	enterTwoLevels(t, g)
+	var exit types.Signal
+	for i := 0; i < 3 && exit.ID == ""; i++ {
+		for _, s := range g.OnMarketEvent(ctx, createOHLCEvent(101, 101, 101, 101)) {
+			if s.Direction == types.SideFlat {
+				exit = s
+			}
+		}
+	}
+	if exit.ID == "" {
+		t.Fatal("expected level 4 to expire")
+	}
+
+	// The expired level's own close doesn't drop level 5
+	g.OnTradeClosed(types.Trade{Symbol: "MES", Side: types.SideLong, Contracts: exit.Contracts, SignalID: exit.ID})
+	levels := g.OpenLevels()
+	if len(levels) != 1 || levels[0].Level != 5 || levels[0].Contracts != 2 {
+		t.Fatalf("OpenLevels = %+v, want level 5 with 2 contracts", levels)
+	}
+
+	// A partial take profit trims the remaining level
+	g.OnTradeClosed(types.Trade{Symbol: "MES", Side: types.SideLong, Contracts: 1, ExitReason: "take_profit"})
+	if levels := g.OpenLevels(); len(levels) != 1 || levels[0].Contracts != 1 {
+		t.Fatalf("OpenLevels = %+v, want level 5 with 1 contract", levels)
+	}
+}
+
+func TestGrid_MaxLevelAge_IgnoresUnfilledEntries(t *testing.T) {
+	g := newAgingGrid(3)
+	ctx := context.Background()
+
+	// Only the level 5 entry fills
+	signals := signalTwoLevels(t, g, func(types.Signal) {})
+	g.OnEntryFilled(signals[1], 1)
+
+	levels := g.OpenLevels()
+	if len(levels) != 1 || levels[0].Level != 5 {
+		t.Fatalf("OpenLevels = %+v, want only level 5", levels)
+	}
+
+	// Level 4 never filled, so only level 5 is closed when it expires
+	var exits []types.Signal
+	for i := 0; i < 6; i++ {
+		for _, s := range g.OnMarketEvent(ctx, createOHLCEvent(101, 101, 101, 101)) {
+			if s.Direction == types.SideFlat {
+				exits = append(exits, s)
+			}
+		}
+	}
+	if len(exits) != 1 || exits[0].Contracts != 1 {
+		t.Fatalf("exits = %+v, want one exit of 1 contract", exits)
+	}
+
+	// A fill reported after the entry expired is not tracked
+	g.OnEntryFilled(signals[0], 1)
+	if len(g.OpenLevels()) != 0 {
+		t.Errorf("OpenLevels = %d after a late fill, want 0", len(g.OpenLevels()))
+	}
+}
+
+func TestGrid_MaxLevelAge_DisabledByDefault(t *testing.T) {
+	g := newAgingGrid(0)
+	ctx := context.Background()
+
+	enterTwoLevels(t, g)
+
+	for i := 0; i < 20; i++ {
+		for _, s := range g.OnMarketEvent(ctx, createOHLCEvent(101, 101, 101, 101)) {
+			if s.Direction == types.SideFlat {
+				t.Fatal("Should not force exits when MaxLevelAgeBars is 0")
+			}
+		}
+	}
+
+	if len(g.OpenLevels()) != 0 {
+		t.Errorf("OpenLevels = %d, want 0 when disabled", len(g.OpenLevels()))
+	}
+}
+
+func TestGrid_Reset_ClearsLevels(t *testing.T) {
+	g := newAgingGrid(3)
+
+	enterTwoLevels(t, g)
+	g.Reset()
+
+	if len(g.OpenLevels()) != 0 {
+		t.Errorf("OpenLevels = %d after Reset, want 0", len(g.OpenLevels()))
+	}
+}
+
+func TestGrid_WarmUp(t *testing.T) {
+	g := newAgingGrid(3)
+
+	// Warm-up bars fill the swing history but never trade, even the drop
+	for i := 0; i < 9; i++ {
+		WarmUp(g, createOHLCEvent(105, 110, 100, 105))
+	}
+	WarmUp(g, createOHLCEvent(103, 103, 101, 102))
+	if len(g.OpenLevels()) != 0 || g.lastGridLevel != 0 {
+		t.Fatalf("warm-up entered level %d with %d open levels", g.lastGridLevel, len(g.OpenLevels()))
+	}
+
+	// The first live bar trades on the warmed-up range
+	signals := g.OnMarketEvent(context.Background(), createOHLCEvent(102, 102, 100, 101))
+	if len(signals) != 1 || signals[0].Direction != types.SideLong {
+		t.Errorf("first live bar: expected 1 long signal, got %v", signals)
+	}
+}
+
+func TestGrid_SignalIDsFollowTheBar(t *testing.T) {
+	base := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	bar := func(i int, open, high, low, close int64) types.MarketEvent {
+		event := createOHLCEvent(open, high, low, close)
+		event.Timestamp = base.Add(time.Duration(i) * time.Minute)
+		return event
+	}
+
+	// A restarted grid warms up on less history, so its bar count differs,
+	// but the bar that signals is the same one
+	entryID := func(history int) string {
+		g := newAgingGrid(0)
+		for i := 20 - history; i < 20; i++ {
+			g.OnMarketEvent(context.Background(), bar(i, 105, 110, 100, 105))
+		}
+		signals := g.OnMarketEvent(context.Background(), bar(20, 103, 103, 101, 102))
+		if len(signals) != 1 {
+			t.Fatalf("history %d: expected 1 signal, got %v", history, signals)
+		}
+		return signals[0].ID
+	}
+
+	if first, restarted := entryID(15), entryID(9); first != restarted {
+		t.Errorf("signal ID after restart = %s, want %s", restarted, first)
+	}
+}
@@ -34,6 +34,9 @@ type MeanRevConfig struct {
 	EntryStdDev     decimal.Decimal // Number of StdDevs from mean to enter (e.g., 2.0)
 	ATRMultiplier   decimal.Decimal // ATR multiplier for stop loss
 	MinStdDev       decimal.Decimal // Minimum StdDev to generate signal
+
+	CooldownBars     int // Bars to skip after an entry signal (0 = disabled)
+	LossCooldownBars int // Bars to skip after a losing trade (0 = disabled)
 }
 
 // DefaultMeanRevConfig returns sensible defaults.
@@ -55,6 +58,7 @@ type MeanReversion struct {
 	sma    *indicator.SMA
 	stddev *indicator.StdDev
 
+	cooldown       *Cooldown
 	lastSignalUp   bool // Prevent repeated signals
 	lastSignalDown bool
 }
@@ -65,11 +69,15 @@ func NewMeanReversion(cfg MeanRevConfig) *MeanReversion {
 		cfg:    cfg,
 		sma:    indicator.NewSMA(cfg.SMAPeriod),
 		stddev: indicator.NewStdDev(cfg.StdDevPeriod),
+
+		cooldown: NewCooldown(cfg.CooldownBars, cfg.LossCooldownBars),
 	}
 }
 
 // OnMarketEvent processes a market event and generates signals.
 func (m *MeanReversion) OnMarketEvent(ctx context.Context, event types.MarketEvent) []types.Signal {
+	m.cooldown.Tick()
+
 	// Get current mean and stddev BEFORE updating (for signal generation)
 	prevMean := m.sma.Current()
 	prevStdDev := m.stddev.Current()
@@ -95,9 +103,10 @@ func (m *MeanReversion) OnMarketEvent(ctx context.Context, event types.MarketEve
 	lowerBand := prevMean.Sub(deviation)
 
 	var signals []types.Signal
+	cooling := m.cooldown.Active()
 
 	// Check for mean reversion signals
-	if event.Close.LessThan(lowerBand) && !m.lastSignalDown {
+	if event.Close.LessThan(lowerBand) && !m.lastSignalDown && !cooling {
 		// Price below lower band - LONG signal (expect reversion up)
 		signal := NewSignalBuilder(m.Name(), event).
 			Long().
@@ -108,7 +117,8 @@ func (m *MeanReversion) OnMarketEvent(ctx context.Context, event types.MarketEve
 		signals = append(signals, signal)
 		m.lastSignalDown = true
 		m.lastSignalUp = false
-	} else if event.Close.GreaterThan(upperBand) && !m.lastSignalUp {
+		m.cooldown.Trigger()
+	} else if event.Close.GreaterThan(upperBand) && !m.lastSignalUp && !cooling {
 		// Price above upper band - SHORT signal (expect reversion down)
 		signal := NewSignalBuilder(m.Name(), event).
 			Short().
@@ -119,6 +129,7 @@ func (m *MeanReversion) OnMarketEvent(ctx context.Context, event types.MarketEve
 		signals = append(signals, signal)
 		m.lastSignalUp = true
 		m.lastSignalDown = false
+		m.cooldown.Trigger()
 	} else if event.Close.GreaterThan(lowerBand) && event.Close.LessThan(upperBand) {
 		// Price back within bands - reset signal flags
 		m.lastSignalUp = false
@@ -128,6 +139,12 @@ func (m *MeanReversion) OnMarketEvent(ctx context.Context, event types.MarketEve
 	return signals
 }
 
+// WarmUp updates the mean and deviation without trading on the bar.
+func (m *MeanReversion) WarmUp(event types.MarketEvent) {
+	m.sma.Update(event.Close)
+	m.stddev.Update(event.Close)
+}
+
 // Name returns the strategy name.
 func (m *MeanReversion) Name() string {
 	return "meanrev"
@@ -137,10 +154,16 @@ func (m *MeanReversion) Name() string {
 func (m *MeanReversion) Reset() {
 	m.sma.Reset()
 	m.stddev.Reset()
+	m.cooldown.Reset()
 	m.lastSignalUp = false
 	m.lastSignalDown = false
 }
 
+// OnTradeClosed extends the cooldown after a losing trade.
+func (m *MeanReversion) OnTradeClosed(trade types.Trade) {
+	m.cooldown.OnTradeClosed(trade)
+}
+
 // CurrentMean returns the current SMA value.
 func (m *MeanReversion) CurrentMean() decimal.Decimal {
 	return m.sma.Current()
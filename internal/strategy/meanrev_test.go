@@ -249,3 +249,32 @@ func createMREvent(close decimal.Decimal) types.MarketEvent {
 		Close:     close,
 	}
 }
+
+func TestMeanReversion_LossCooldown(t *testing.T) {
+	cfg := DefaultMeanRevConfig()
+	cfg.SMAPeriod = 3
+	cfg.StdDevPeriod = 3
+	cfg.EntryStdDev = decimal.NewFromInt(2)
+	cfg.LossCooldownBars = 3
+	strategy := NewMeanReversion(cfg)
+
+	for _, c := range []int64{98, 100, 102} {
+		strategy.OnMarketEvent(context.Background(), createMREvent(decimal.NewFromInt(c)))
+	}
+
+	strategy.OnTradeClosed(types.Trade{NetPL: decimal.NewFromInt(-100)})
+
+	// Price far below the band would normally be a long entry
+	signals := strategy.OnMarketEvent(context.Background(), createMREvent(decimal.NewFromInt(90)))
+	if len(signals) != 0 {
+		t.Errorf("signals during loss cooldown = %d, want 0", len(signals))
+	}
+
+	strategy.OnMarketEvent(context.Background(), createMREvent(decimal.NewFromInt(100)))
+	strategy.OnMarketEvent(context.Background(), createMREvent(decimal.NewFromInt(100)))
+
+	signals = strategy.OnMarketEvent(context.Background(), createMREvent(decimal.NewFromInt(80)))
+	if len(signals) != 1 || signals[0].Direction != types.SideLong {
+		t.Errorf("signals after cooldown = %v, want one LONG", signals)
+	}
+}
@@ -0,0 +1,130 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/observer"
+	"github.com/tathienbao/quant-bot/internal/types"
+	"github.com/tathienbao/quant-bot/pkg/indicator"
+)
+
+// MTFConfig holds configuration for the multi-timeframe mean reversion strategy.
+type MTFConfig struct {
+	BaseTimeframe   time.Duration // Timeframe of the bars the engine feeds
+	HigherTimeframe time.Duration // Timeframe of the trend filter
+	TrendEMAPeriod  int           // EMA period on higher-timeframe closes
+	MeanRev         MeanRevConfig // Entry rules on the base timeframe
+}
+
+// DefaultMTFConfig returns an M15 trend filter over M5 mean reversion entries.
+func DefaultMTFConfig() MTFConfig {
+	return MTFConfig{
+		BaseTimeframe:   5 * time.Minute,
+		HigherTimeframe: 15 * time.Minute,
+		TrendEMAPeriod:  20,
+		MeanRev:         DefaultMeanRevConfig(),
+	}
+}
+
+// MTFMeanReversion takes mean reversion entries on the base timeframe only
+// in the direction of the higher-timeframe trend: longs while the
+// higher-timeframe EMA is rising, shorts while it is falling.
+type MTFMeanReversion struct {
+	cfg     MTFConfig
+	higher  *observer.TimeframeAggregator
+	ema     *indicator.EMA
+	meanrev *MeanReversion
+
+	trend types.Side // Direction of the last EMA move (flat until known)
+}
+
+// NewMTFMeanReversion creates a new multi-timeframe mean reversion strategy.
+func NewMTFMeanReversion(cfg MTFConfig) *MTFMeanReversion {
+	return &MTFMeanReversion{
+		cfg:     cfg,
+		higher:  observer.NewTimeframeAggregator(cfg.BaseTimeframe, cfg.HigherTimeframe),
+		ema:     indicator.NewEMA(cfg.TrendEMAPeriod),
+		meanrev: NewMeanReversion(cfg.MeanRev),
+	}
+}
+
+// OnMarketEvent processes a market event and generates signals.
+func (m *MTFMeanReversion) OnMarketEvent(ctx context.Context, event types.MarketEvent) []types.Signal {
+	if bar, ok := m.higher.OnBar(event); ok {
+		m.updateTrend(bar)
+	}
+
+	// Always feed the entry strategy so its bands stay current
+	candidates := m.meanrev.OnMarketEvent(ctx, event)
+	if m.trend == types.SideFlat {
+		return nil
+	}
+
+	var signals []types.Signal
+	for _, signal := range candidates {
+		if signal.Direction != m.trend {
+			continue
+		}
+		signal.StrategyName = m.Name()
+		signal.Reason = fmt.Sprintf("%s with %s trend", signal.Reason, m.cfg.HigherTimeframe)
+		signals = append(signals, signal)
+	}
+	return signals
+}
+
+// updateTrend updates the EMA with a completed higher-timeframe bar.
+func (m *MTFMeanReversion) updateTrend(bar types.MarketEvent) {
+	prev := m.ema.Current()
+	wasReady := m.ema.Ready()
+	current := m.ema.Update(bar.Close)
+	if !wasReady {
+		return
+	}
+
+	switch current.Cmp(prev) {
+	case 1:
+		m.trend = types.SideLong
+	case -1:
+		m.trend = types.SideShort
+	}
+}
+
+// WarmUp updates the trend and the entry strategy's bands without trading
+// on the bar.
+func (m *MTFMeanReversion) WarmUp(event types.MarketEvent) {
+	if bar, ok := m.higher.OnBar(event); ok {
+		m.updateTrend(bar)
+	}
+	m.meanrev.WarmUp(event)
+}
+
+// OnTradeClosed passes the trade to the entry strategy's cooldown.
+func (m *MTFMeanReversion) OnTradeClosed(trade types.Trade) {
+	m.meanrev.OnTradeClosed(trade)
+}
+
+// Name returns the strategy name.
+func (m *MTFMeanReversion) Name() string {
+	return "mtf-meanrev"
+}
+
+// Reset clears all state.
+func (m *MTFMeanReversion) Reset() {
+	m.higher.Reset()
+	m.ema.Reset()
+	m.meanrev.Reset()
+	m.trend = types.SideFlat
+}
+
+// Trend returns the current higher-timeframe trend direction.
+func (m *MTFMeanReversion) Trend() types.Side {
+	return m.trend
+}
+
+// TrendEMA returns the current higher-timeframe EMA value.
+func (m *MTFMeanReversion) TrendEMA() decimal.Decimal {
+	return m.ema.Current()
+}
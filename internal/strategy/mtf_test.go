@@ -0,0 +1,88 @@
+package strategy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func testMTFConfig() MTFConfig {
+	cfg := DefaultMTFConfig()
+	cfg.TrendEMAPeriod = 2
+	cfg.MeanRev.SMAPeriod = 3
+	cfg.MeanRev.StdDevPeriod = 3
+	cfg.MeanRev.EntryStdDev = decimal.NewFromInt(2)
+	return cfg
+}
+
+// feedMTF feeds M5 bars with the given closes starting at 10:00 and
+// returns all signals.
+func feedMTF(s *MTFMeanReversion, start time.Time, closes ...int64) []types.Signal {
+	var signals []types.Signal
+	for i, c := range closes {
+		event := createMREvent(decimal.NewFromInt(c))
+		event.Timestamp = start.Add(time.Duration(i) * 5 * time.Minute)
+		signals = append(signals, s.OnMarketEvent(context.Background(), event)...)
+	}
+	return signals
+}
+
+func TestMTFMeanReversion_NoSignalsBeforeTrend(t *testing.T) {
+	s := NewMTFMeanReversion(testMTFConfig())
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	// Two M15 bars: EMA(2) is seeded but has no direction yet
+	signals := feedMTF(s, start, 100, 100, 100, 100, 100, 80)
+	if len(signals) != 0 {
+		t.Errorf("signals = %d, want 0 before trend is known", len(signals))
+	}
+	if s.Trend() != types.SideFlat {
+		t.Errorf("Trend() = %v, want FLAT", s.Trend())
+	}
+}
+
+func TestMTFMeanReversion_FiltersAgainstTrend(t *testing.T) {
+	s := NewMTFMeanReversion(testMTFConfig())
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	// Steadily rising closes: the M15 EMA turns up, and every M5 bar closes
+	// above the upper band, which would be a short for plain mean reversion
+	var closes []int64
+	for i := int64(0); i < 12; i++ {
+		closes = append(closes, 100+i)
+	}
+	signals := feedMTF(s, start, closes...)
+	if len(signals) != 0 {
+		t.Errorf("signals = %d, want counter-trend shorts filtered", len(signals))
+	}
+	if s.Trend() != types.SideLong {
+		t.Fatalf("Trend() = %v, want LONG", s.Trend())
+	}
+
+	// A dip below the lower band is taken with the trend
+	signals = feedMTF(s, start.Add(60*time.Minute), 90)
+	if len(signals) != 1 {
+		t.Fatalf("signals = %d, want 1", len(signals))
+	}
+	if signals[0].Direction != types.SideLong {
+		t.Errorf("Direction = %v, want LONG", signals[0].Direction)
+	}
+	if signals[0].StrategyName != "mtf-meanrev" {
+		t.Errorf("StrategyName = %s, want mtf-meanrev", signals[0].StrategyName)
+	}
+}
+
+func TestMTFMeanReversion_Reset(t *testing.T) {
+	s := NewMTFMeanReversion(testMTFConfig())
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	feedMTF(s, start, 100, 101, 102, 103, 104, 105, 106, 107, 108, 109, 110, 111)
+
+	s.Reset()
+
+	if s.Trend() != types.SideFlat || !s.TrendEMA().IsZero() {
+		t.Errorf("after Reset: trend = %v, ema = %s", s.Trend(), s.TrendEMA())
+	}
+}
@@ -0,0 +1,273 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/config"
+)
+
+// Constructor builds a strategy from the bot's configuration.
+type Constructor func(cfg *config.Config) Strategy
+
+// Tuner builds a strategy like a Constructor with sweep parameters applied
+// on top. Parameter names match the strategy's config fields in snake_case;
+// unknown names are an error.
+type Tuner func(cfg *config.Config, params map[string]float64) (Strategy, error)
+
+// Entry describes a selectable strategy. Return and WinRate are the
+// reference backtest results shown in the selection menu.
+type Entry struct {
+	Name        string
+	Description string
+	Return      string
+	WinRate     string
+	Recommended bool
+	New         Constructor
+	Tune        Tuner // Optional; nil = no parameters to sweep
+}
+
+// Registry holds strategies by name, in registration order.
+type Registry struct {
+	mu      sync.RWMutex
+	entries []Entry
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a strategy. Names must be unique.
+func (r *Registry) Register(entry Entry) error {
+	if entry.Name == "" || entry.New == nil {
+		return fmt.Errorf("strategy entry needs a name and constructor")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.entries {
+		if e.Name == entry.Name {
+			return fmt.Errorf("strategy %q already registered", entry.Name)
+		}
+	}
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+// Entries returns the registered strategies in registration order.
+func (r *Registry) Entries() []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]Entry, len(r.entries))
+	copy(entries, r.entries)
+	return entries
+}
+
+// New builds the strategy registered as name.
+func (r *Registry) New(name string, cfg *config.Config) (Strategy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, e := range r.entries {
+		if e.Name == name {
+			return e.New(cfg), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown strategy: %s", name)
+}
+
+// NewWithParams builds the strategy registered as name with params applied
+// on top of its configuration. No params is the same as New.
+func (r *Registry) NewWithParams(name string, cfg *config.Config, params map[string]float64) (Strategy, error) {
+	if len(params) == 0 {
+		return r.New(name, cfg)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, e := range r.entries {
+		if e.Name != name {
+			continue
+		}
+		if e.Tune == nil {
+			return nil, fmt.Errorf("strategy %s has no tunable parameters", name)
+		}
+		return e.Tune(cfg, params)
+	}
+	return nil, fmt.Errorf("unknown strategy: %s", name)
+}
+
+var defaultRegistry = NewRegistry()
+
+// Register adds a strategy to the default registry used by the bot's
+// commands. It panics on an invalid or duplicate entry, so it is meant to
+// be called from init.
+func Register(entry Entry) {
+	if err := defaultRegistry.Register(entry); err != nil {
+		panic(err)
+	}
+}
+
+// Registered returns the strategies in the default registry.
+func Registered() []Entry {
+	return defaultRegistry.Entries()
+}
+
+// New builds a strategy from the default registry.
+func New(name string, cfg *config.Config) (Strategy, error) {
+	return defaultRegistry.New(name, cfg)
+}
+
+// NewWithParams builds a strategy from the default registry with sweep
+// parameters applied.
+func NewWithParams(name string, cfg *config.Config, params map[string]float64) (Strategy, error) {
+	return defaultRegistry.NewWithParams(name, cfg, params)
+}
+
+// applyParams calls the setter for each parameter, rejecting unknown names.
+func applyParams(params map[string]float64, setters map[string]func(float64)) error {
+	for name, v := range params {
+		set, ok := setters[name]
+		if !ok {
+			valid := make([]string, 0, len(setters))
+			for n := range setters {
+				valid = append(valid, n)
+			}
+			sort.Strings(valid)
+			return fmt.Errorf("unknown parameter %q (valid: %s)", name, strings.Join(valid, ", "))
+		}
+		set(v)
+	}
+	return nil
+}
+
+// tuneGrid returns a Tuner for a grid strategy starting from base.
+func tuneGrid(base func() GridConfig) Tuner {
+	return func(_ *config.Config, params map[string]float64) (Strategy, error) {
+		c := base()
+		err := applyParams(params, map[string]func(float64){
+			"grid_spacing_pct":   func(v float64) { c.GridSpacingPct = decimal.NewFromFloat(v) },
+			"rebound_pct":        func(v float64) { c.ReboundPct = decimal.NewFromFloat(v) },
+			"max_grid_levels":    func(v float64) { c.MaxGridLevels = int(v) },
+			"lookback_bars":      func(v float64) { c.LookbackBars = int(v) },
+			"stop_loss_pct":      func(v float64) { c.StopLossPct = decimal.NewFromFloat(v) },
+			"min_move_points":    func(v float64) { c.MinMovePoints = decimal.NewFromFloat(v) },
+			"max_level_age_bars": func(v float64) { c.MaxLevelAgeBars = int(v) },
+			"cooldown_bars":      func(v float64) { c.CooldownBars = int(v) },
+			"loss_cooldown_bars": func(v float64) { c.LossCooldownBars = int(v) },
+		})
+		return NewGrid(c), err
+	}
+}
+
+// breakoutConfig is the breakout strategy's configuration for cfg.
+func breakoutConfig(cfg *config.Config) BreakoutConfig {
+	return BreakoutConfig{
+		LookbackBars:   cfg.Risk.VolatilityLookbackBars,
+		ATRMultiplier:  decimal.NewFromFloat(cfg.Risk.StopLossATRMultiple),
+		BreakoutBuffer: decimal.Zero,
+	}
+}
+
+// tuneBreakout builds a breakout strategy with params applied.
+func tuneBreakout(cfg *config.Config, params map[string]float64) (Strategy, error) {
+	c := breakoutConfig(cfg)
+	err := applyParams(params, map[string]func(float64){
+		"lookback_bars":   func(v float64) { c.LookbackBars = int(v) },
+		"atr_multiplier":  func(v float64) { c.ATRMultiplier = decimal.NewFromFloat(v) },
+		"min_atr":         func(v float64) { c.MinATR = decimal.NewFromFloat(v) },
+		"breakout_buffer": func(v float64) { c.BreakoutBuffer = decimal.NewFromFloat(v) },
+	})
+	return NewBreakout(c), err
+}
+
+// meanRevConfig is the mean reversion strategy's configuration for cfg.
+func meanRevConfig(cfg *config.Config) MeanRevConfig {
+	return MeanRevConfig{
+		SMAPeriod:     20,
+		StdDevPeriod:  20,
+		EntryStdDev:   decimal.RequireFromString("2.0"),
+		ATRMultiplier: decimal.NewFromFloat(cfg.Risk.StopLossATRMultiple),
+	}
+}
+
+// tuneMeanRev builds a mean reversion strategy with params applied.
+func tuneMeanRev(cfg *config.Config, params map[string]float64) (Strategy, error) {
+	c := meanRevConfig(cfg)
+	err := applyParams(params, map[string]func(float64){
+		"sma_period":     func(v float64) { c.SMAPeriod = int(v) },
+		"stddev_period":  func(v float64) { c.StdDevPeriod = int(v) },
+		"entry_stddev":   func(v float64) { c.EntryStdDev = decimal.NewFromFloat(v) },
+		"atr_multiplier": func(v float64) { c.ATRMultiplier = decimal.NewFromFloat(v) },
+		"min_stddev":     func(v float64) { c.MinStdDev = decimal.NewFromFloat(v) },
+
+		"cooldown_bars":      func(v float64) { c.CooldownBars = int(v) },
+		"loss_cooldown_bars": func(v float64) { c.LossCooldownBars = int(v) },
+	})
+	return NewMeanReversion(c), err
+}
+
+// Built-in strategies, in menu order.
+func init() {
+	Register(Entry{
+		Name:        "grid",
+		Description: "Grid/Rebound - High Frequency (max return)",
+		Return:      "+51.94%",
+		WinRate:     "91.05%",
+		Recommended: true,
+		New: func(*config.Config) Strategy {
+			return NewGrid(OriginalGridConfig())
+		},
+		Tune: tuneGrid(OriginalGridConfig),
+	})
+	Register(Entry{
+		Name:        "grid-conservative",
+		Description: "Grid/Rebound - Conservative (low risk)",
+		Return:      "+33.54%",
+		WinRate:     "85.41%",
+		New: func(*config.Config) Strategy {
+			return NewGrid(ConservativeGridConfig())
+		},
+		Tune: tuneGrid(ConservativeGridConfig),
+	})
+	Register(Entry{
+		Name:        "breakout",
+		Description: "Range Breakout (không khuyến nghị)",
+		Return:      "-11.59%",
+		WinRate:     "0%",
+		New: func(cfg *config.Config) Strategy {
+			return NewBreakout(breakoutConfig(cfg))
+		},
+		Tune: tuneBreakout,
+	})
+	Register(Entry{
+		Name:        "meanrev",
+		Description: "Mean Reversion (không khuyến nghị)",
+		Return:      "-3.62%",
+		WinRate:     "20%",
+		New: func(cfg *config.Config) Strategy {
+			return NewMeanReversion(meanRevConfig(cfg))
+		},
+		Tune: tuneMeanRev,
+	})
+	Register(Entry{
+		Name:        "mtf-meanrev",
+		Description: "Mean Reversion M5 + M15 EMA trend filter",
+		Return:      "n/a",
+		WinRate:     "n/a",
+		New: func(cfg *config.Config) Strategy {
+			mtfCfg := DefaultMTFConfig()
+			mtfCfg.BaseTimeframe = cfg.TimeframeDuration()
+			mtfCfg.HigherTimeframe = 3 * mtfCfg.BaseTimeframe // M15 over the default M5
+			mtfCfg.MeanRev.ATRMultiplier = decimal.NewFromFloat(cfg.Risk.StopLossATRMultiple)
+			return NewMTFMeanReversion(mtfCfg)
+		},
+	})
+}
@@ -0,0 +1,89 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/config"
+)
+
+func TestRegistry_RegisterAndNew(t *testing.T) {
+	r := NewRegistry()
+	entry := Entry{
+		Name: "grid",
+		New: func(*config.Config) Strategy {
+			return NewGrid(OriginalGridConfig())
+		},
+	}
+
+	if err := r.Register(entry); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := r.Register(entry); err == nil {
+		t.Error("expected error registering a duplicate name")
+	}
+	if err := r.Register(Entry{Name: "nil-constructor"}); err == nil {
+		t.Error("expected error registering an entry without a constructor")
+	}
+
+	strat, err := r.New("grid", &config.Config{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if strat == nil {
+		t.Fatal("New() returned nil strategy")
+	}
+	if _, err := r.New("missing", &config.Config{}); err == nil {
+		t.Error("expected error for unknown strategy")
+	}
+}
+
+func TestRegistered_BuiltinStrategies(t *testing.T) {
+	cfg := &config.Config{Risk: config.RiskConfig{VolatilityLookbackBars: 20, StopLossATRMultiple: 2}}
+
+	entries := Registered()
+	if len(entries) == 0 || entries[0].Name != "grid" || !entries[0].Recommended {
+		t.Fatalf("first registered strategy should be the recommended grid, got %+v", entries)
+	}
+
+	for _, e := range entries {
+		strat, err := New(e.Name, cfg)
+		if err != nil {
+			t.Errorf("New(%q) error = %v", e.Name, err)
+			continue
+		}
+		if strat == nil {
+			t.Errorf("New(%q) returned nil strategy", e.Name)
+		}
+	}
+}
+
+func TestRegistered_NewWithParams(t *testing.T) {
+	cfg := &config.Config{Risk: config.RiskConfig{VolatilityLookbackBars: 20, StopLossATRMultiple: 2}}
+
+	strat, err := NewWithParams("grid", cfg, map[string]float64{"grid_spacing_pct": 0.005, "max_grid_levels": 3})
+	if err != nil {
+		t.Fatalf("NewWithParams() error = %v", err)
+	}
+	grid, ok := strat.(*Grid)
+	if !ok {
+		t.Fatalf("NewWithParams(grid) = %T, want *Grid", strat)
+	}
+	if !grid.cfg.GridSpacingPct.Equal(decimal.RequireFromString("0.005")) || grid.cfg.MaxGridLevels != 3 {
+		t.Errorf("grid config = %+v, want the swept parameters", grid.cfg)
+	}
+	// Unswept fields keep the registered configuration
+	if !grid.cfg.ReboundPct.Equal(OriginalGridConfig().ReboundPct) {
+		t.Errorf("ReboundPct = %s, want the original grid's", grid.cfg.ReboundPct)
+	}
+
+	if _, err := NewWithParams("grid", cfg, map[string]float64{"no_such_param": 1}); err == nil {
+		t.Error("expected error for an unknown parameter")
+	}
+	if _, err := NewWithParams("mtf-meanrev", cfg, map[string]float64{"sma_period": 10}); err == nil {
+		t.Error("expected error sweeping a strategy without tunable parameters")
+	}
+	if _, err := NewWithParams("mtf-meanrev", cfg, nil); err != nil {
+		t.Errorf("NewWithParams() without params error = %v", err)
+	}
+}
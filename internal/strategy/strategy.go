@@ -3,8 +3,9 @@ package strategy
 
 import (
 	"context"
+	"fmt"
+	"time"
 
-	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/tathienbao/quant-bot/internal/types"
 )
@@ -17,6 +18,10 @@ type Strategy interface {
 	// Returns nil or empty slice if no signal is generated.
 	OnMarketEvent(ctx context.Context, event types.MarketEvent) []types.Signal
 
+	// OnTradeClosed is called when a trade closes, after its fills have been
+	// applied. Strategies that don't track outcomes embed Base.
+	OnTradeClosed(trade types.Trade)
+
 	// Name returns the strategy identifier.
 	Name() string
 
@@ -24,6 +29,29 @@ type Strategy interface {
 	Reset()
 }
 
+// EntryObserver is implemented by strategies that track their entries
+// individually. OnEntryFilled is called once an entry signal's order has
+// filled, with the contracts filled; signals whose orders never fill are
+// not reported.
+type EntryObserver interface {
+	OnEntryFilled(signal types.Signal, contracts int)
+}
+
+// WarmUpper is implemented by strategies that build state from price
+// history. WarmUp takes a bar the strategy must not trade on: history and
+// indicators update, but no signals, grid levels or cooldowns result.
+type WarmUpper interface {
+	WarmUp(event types.MarketEvent)
+}
+
+// WarmUp passes a warm-up bar to s if it implements WarmUpper. Other
+// strategies don't see warm-up bars.
+func WarmUp(s Strategy, event types.MarketEvent) {
+	if w, ok := s.(WarmUpper); ok {
+		w.WarmUp(event)
+	}
+}
+
 // SignalBuilder helps construct signals with consistent defaults.
 type SignalBuilder struct {
 	signal types.Signal
@@ -33,7 +61,6 @@ type SignalBuilder struct {
 func NewSignalBuilder(strategyName string, event types.MarketEvent) *SignalBuilder {
 	return &SignalBuilder{
 		signal: types.Signal{
-			ID:           uuid.New().String(),
 			Timestamp:    event.Timestamp,
 			Symbol:       event.Symbol,
 			StrategyName: strategyName,
@@ -77,6 +104,12 @@ func (b *SignalBuilder) WithReason(reason string) *SignalBuilder {
 	return b
 }
 
+// WithValidFor sets how long the resulting order stays valid.
+func (b *SignalBuilder) WithValidFor(d time.Duration) *SignalBuilder {
+	b.signal.ValidFor = d
+	return b
+}
+
 // WithATRStop sets the stop distance based on ATR.
 func (b *SignalBuilder) WithATRStop(atr decimal.Decimal, multiplier decimal.Decimal, tickSize decimal.Decimal) *SignalBuilder {
 	if atr.IsZero() || tickSize.IsZero() {
@@ -87,9 +120,13 @@ func (b *SignalBuilder) WithATRStop(atr decimal.Decimal, multiplier decimal.Deci
 	return b
 }
 
-// Build returns the constructed signal.
+// Build returns the constructed signal. Its ID is derived from the
+// strategy, bar and direction, so replaying a bar after a restart yields the
+// same signal ID (and with it the same client order ID).
 func (b *SignalBuilder) Build() types.Signal {
-	return b.signal
+	s := b.signal
+	s.ID = fmt.Sprintf("%s-%s-%d-%s", s.StrategyName, s.Symbol, s.Timestamp.UnixNano(), s.Direction)
+	return s
 }
 
 // MultiStrategy combines multiple strategies.
@@ -123,6 +160,32 @@ func (m *MultiStrategy) OnMarketEvent(ctx context.Context, event types.MarketEve
 	return allSignals
 }
 
+// OnTradeClosed passes the trade to the sub-strategy that opened it, or to
+// all sub-strategies if the trade doesn't name one.
+func (m *MultiStrategy) OnTradeClosed(trade types.Trade) {
+	for _, s := range m.strategies {
+		if trade.StrategyName == "" || trade.StrategyName == s.Name() {
+			s.OnTradeClosed(trade)
+		}
+	}
+}
+
+// OnEntryFilled passes the fill to the sub-strategy that sent the signal.
+func (m *MultiStrategy) OnEntryFilled(signal types.Signal, contracts int) {
+	for _, s := range m.strategies {
+		if observer, ok := s.(EntryObserver); ok && signal.StrategyName == s.Name() {
+			observer.OnEntryFilled(signal, contracts)
+		}
+	}
+}
+
+// WarmUp passes the bar to every sub-strategy that warms up.
+func (m *MultiStrategy) WarmUp(event types.MarketEvent) {
+	for _, s := range m.strategies {
+		WarmUp(s, event)
+	}
+}
+
 // Name returns the multi-strategy name.
 func (m *MultiStrategy) Name() string {
 	return m.name
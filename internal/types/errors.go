@@ -1,20 +1,33 @@
 package types
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 // Sentinel errors for the trading system.
 var (
 	// Risk Engine errors
 	ErrKillSwitchActive      = errors.New("kill switch active: system in safe mode")
+	ErrKillSwitchCooldown    = errors.New("kill switch cooldown active")
 	ErrExposureLimitExceeded = errors.New("exposure limit exceeded")
 	ErrInsufficientEquity    = errors.New("insufficient equity for position size")
 	ErrMaxDrawdownExceeded   = errors.New("maximum drawdown exceeded")
+	ErrDailyLossLimit        = errors.New("daily loss limit reached")
+	ErrDailyTargetReached    = errors.New("daily profit target reached")
+	ErrTargetBelowCosts      = errors.New("expected profit below minimum after costs")
+	ErrStopTooWide           = errors.New("stop distance exceeds maximum")
+	ErrDirectionNotAllowed   = errors.New("trade direction not allowed")
+	ErrMaxPositionsReached   = errors.New("maximum open positions reached")
+	ErrInsufficientMargin    = errors.New("insufficient free margin")
 
 	// Order errors
 	ErrDuplicateOrder   = errors.New("duplicate order id")
 	ErrOrderTimeout     = errors.New("order timeout")
 	ErrOrderRejected    = errors.New("order rejected by broker")
 	ErrInvalidOrderSize = errors.New("invalid order size")
+	ErrSpreadTooWide    = errors.New("bid/ask spread too wide")
+	ErrNotReducing      = errors.New("reduce-only order has no opposite position to reduce")
 
 	// Data errors
 	ErrInvalidPrice     = errors.New("invalid price value")
@@ -35,3 +48,95 @@ var (
 	ErrInvalidSymbol    = errors.New("invalid symbol")
 	ErrInvalidTimeframe = errors.New("invalid timeframe")
 )
+
+// RejectReason is a stable code for why a signal was rejected. Unlike error
+// strings, the set of codes is fixed, so it is safe as a metric label.
+type RejectReason string
+
+// Signal rejection reasons.
+const (
+	RejectSafeMode             RejectReason = "safe_mode"
+	RejectInsufficientEquity   RejectReason = "insufficient_equity"
+	RejectExposure             RejectReason = "exposure"
+	RejectSpread               RejectReason = "spread"
+	RejectDailyLoss            RejectReason = "daily_loss"
+	RejectDailyTarget          RejectReason = "daily_target"
+	RejectBelowCosts           RejectReason = "below_costs"
+	RejectStopTooWide          RejectReason = "stop_too_wide"
+	RejectDirection            RejectReason = "direction"
+	RejectMaxPositions         RejectReason = "max_positions"
+	RejectMargin               RejectReason = "margin"
+	RejectWeakSignal           RejectReason = "weak_signal"
+	RejectNoStop               RejectReason = "no_stop"
+	RejectInvalidSymbol        RejectReason = "invalid_symbol"
+	RejectInvalidSize          RejectReason = "invalid_size"
+	RejectStaleData            RejectReason = "stale_data"
+	RejectOrderTimeout         RejectReason = "order_timeout"
+	RejectOrderRejected        RejectReason = "order_rejected"
+	RejectAwaitingConfirmation RejectReason = "awaiting_confirmation"
+	RejectCancelled            RejectReason = "cancelled"
+	RejectOther                RejectReason = "other"
+)
+
+// RejectionError is an error carrying a rejection reason code. It unwraps
+// to the underlying error, so errors.Is still matches the sentinels above.
+type RejectionError struct {
+	Reason RejectReason
+	Err    error
+}
+
+// Reject wraps err with a rejection reason code.
+func Reject(reason RejectReason, err error) error {
+	return &RejectionError{Reason: reason, Err: err}
+}
+
+func (e *RejectionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RejectionError) Unwrap() error {
+	return e.Err
+}
+
+// rejectReasons maps sentinel errors to their rejection codes.
+var rejectReasons = []struct {
+	err    error
+	reason RejectReason
+}{
+	{ErrKillSwitchActive, RejectSafeMode},
+	{ErrKillSwitchCooldown, RejectSafeMode},
+	{ErrMaxDrawdownExceeded, RejectSafeMode},
+	{ErrInsufficientEquity, RejectInsufficientEquity},
+	{ErrExposureLimitExceeded, RejectExposure},
+	{ErrSpreadTooWide, RejectSpread},
+	{ErrDailyLossLimit, RejectDailyLoss},
+	{ErrDailyTargetReached, RejectDailyTarget},
+	{ErrTargetBelowCosts, RejectBelowCosts},
+	{ErrStopTooWide, RejectStopTooWide},
+	{ErrDirectionNotAllowed, RejectDirection},
+	{ErrMaxPositionsReached, RejectMaxPositions},
+	{ErrInsufficientMargin, RejectMargin},
+	{ErrInvalidSymbol, RejectInvalidSymbol},
+	{ErrInvalidOrderSize, RejectInvalidSize},
+	{ErrStaleData, RejectStaleData},
+	{ErrOrderTimeout, RejectOrderTimeout},
+	{ErrOrderRejected, RejectOrderRejected},
+	{context.Canceled, RejectCancelled},
+	{context.DeadlineExceeded, RejectCancelled},
+}
+
+// ReasonFor returns the rejection reason code for err: the code of the
+// first RejectionError in its chain, else the code of a known sentinel,
+// else RejectOther.
+func ReasonFor(err error) RejectReason {
+	var rejection *RejectionError
+	if errors.As(err, &rejection) {
+		return rejection.Reason
+	}
+	for _, r := range rejectReasons {
+		if errors.Is(err, r.err) {
+			return r.reason
+		}
+	}
+	return RejectOther
+}
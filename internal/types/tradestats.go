@@ -0,0 +1,78 @@
+package types
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// TradeStats is a running tally of closed trades. Backtest metrics derive
+// their win/loss figures from it, and the live engine keeps one updated
+// trade by trade, so backtest and live numbers come from the same math.
+type TradeStats struct {
+	Trades      int
+	Wins        int
+	Losses      int
+	GrossProfit decimal.Decimal // Sum of winning trades' net P&L
+	GrossLoss   decimal.Decimal // Sum of losing trades' net P&L, as a positive amount
+}
+
+// NewTradeStats tallies trades.
+func NewTradeStats(trades []Trade) TradeStats {
+	var s TradeStats
+	for _, trade := range trades {
+		s.Add(trade.NetPL)
+	}
+	return s
+}
+
+// Add counts one closed trade with the given net P&L. Breakeven trades
+// count toward Trades only.
+func (s *TradeStats) Add(netPL decimal.Decimal) {
+	s.Trades++
+	switch {
+	case netPL.IsPositive():
+		s.Wins++
+		s.GrossProfit = s.GrossProfit.Add(netPL)
+	case netPL.IsNegative():
+		s.Losses++
+		s.GrossLoss = s.GrossLoss.Sub(netPL)
+	}
+}
+
+// WinRate returns the share of trades that made money.
+func (s TradeStats) WinRate() decimal.Decimal {
+	if s.Trades == 0 {
+		return decimal.Zero
+	}
+	return decimal.NewFromInt(int64(s.Wins)).Div(decimal.NewFromInt(int64(s.Trades)))
+}
+
+// ProfitFactor returns gross profit / gross loss (0 without losses).
+func (s TradeStats) ProfitFactor() decimal.Decimal {
+	if s.GrossLoss.IsZero() {
+		return decimal.Zero
+	}
+	return s.GrossProfit.Div(s.GrossLoss)
+}
+
+// AverageWin returns the average winning trade P&L.
+func (s TradeStats) AverageWin() decimal.Decimal {
+	if s.Wins == 0 {
+		return decimal.Zero
+	}
+	return s.GrossProfit.Div(decimal.NewFromInt(int64(s.Wins)))
+}
+
+// AverageLoss returns the average losing trade P&L (negative).
+func (s TradeStats) AverageLoss() decimal.Decimal {
+	if s.Losses == 0 {
+		return decimal.Zero
+	}
+	return s.GrossLoss.Neg().Div(decimal.NewFromInt(int64(s.Losses)))
+}
+
+// Expectancy returns the expected P&L per trade:
+// (WinRate * AvgWin) + ((1 - WinRate) * AvgLoss).
+func (s TradeStats) Expectancy() decimal.Decimal {
+	winRate := s.WinRate()
+	return winRate.Mul(s.AverageWin()).Add(decimal.NewFromInt(1).Sub(winRate).Mul(s.AverageLoss()))
+}
@@ -0,0 +1,42 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestTradeStats_Add(t *testing.T) {
+	trades := []Trade{
+		{NetPL: decimal.NewFromInt(300)},
+		{NetPL: decimal.NewFromInt(-100)},
+		{NetPL: decimal.Zero},
+		{NetPL: decimal.NewFromInt(100)},
+		{NetPL: decimal.NewFromInt(-200)},
+	}
+
+	// Fed one trade at a time, as the live engine does
+	var stats TradeStats
+	for _, trade := range trades {
+		stats.Add(trade.NetPL)
+	}
+
+	if stats.Trades != 5 || stats.Wins != 2 || stats.Losses != 2 {
+		t.Errorf("counts = %d trades, %d wins, %d losses; want 5, 2, 2", stats.Trades, stats.Wins, stats.Losses)
+	}
+	// 400 / 300
+	if want := decimal.NewFromInt(4).Div(decimal.NewFromInt(3)); !stats.ProfitFactor().Equal(want) {
+		t.Errorf("ProfitFactor = %s, want %s", stats.ProfitFactor(), want)
+	}
+	// 0.4 * 200 + 0.6 * -150
+	if want := decimal.NewFromInt(-10); !stats.Expectancy().Equal(want) {
+		t.Errorf("Expectancy = %s, want %s", stats.Expectancy(), want)
+	}
+}
+
+func TestTradeStats_Empty(t *testing.T) {
+	var stats TradeStats
+	if !stats.ProfitFactor().IsZero() || !stats.Expectancy().IsZero() || !stats.WinRate().IsZero() {
+		t.Errorf("empty stats = %s / %s / %s, want zeros", stats.ProfitFactor(), stats.Expectancy(), stats.WinRate())
+	}
+}
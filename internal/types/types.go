@@ -2,6 +2,8 @@
 package types
 
 import (
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -92,6 +94,8 @@ type MarketEvent struct {
 	Low       decimal.Decimal
 	Close     decimal.Decimal
 	Volume    int64
+	Bid       decimal.Decimal // Best bid when the feed provides quotes (zero otherwise)
+	Ask       decimal.Decimal // Best ask when the feed provides quotes (zero otherwise)
 	ATR       decimal.Decimal // Average True Range
 	StdDev    decimal.Decimal // Standard Deviation
 }
@@ -107,6 +111,15 @@ type Signal struct {
 	TakeProfitATR decimal.Decimal // Take profit as ATR multiple
 	Reason        string          // Why this signal was generated
 	StrategyName  string
+	ValidFor      time.Duration   // How long the resulting order stays valid (0 = engine default)
+	Contracts     int             // Flat signals: contracts to close (0 = whole position)
+}
+
+// WeakerThan reports whether an entry signal's Strength is below min.
+// Flat (exit) signals are never weak, so a strength filter can't keep a
+// position open.
+func (s Signal) WeakerThan(min decimal.Decimal) bool {
+	return s.Direction != SideFlat && s.Strength.LessThan(min)
 }
 
 // OrderIntent represents a validated order ready for execution.
@@ -123,6 +136,34 @@ type OrderIntent struct {
 	RiskAmount      decimal.Decimal // Actual $ at risk
 	SignalID        string          // Reference to originating signal
 	ExpiresAt       time.Time       // Order expiration
+	ReduceOnly      bool            // Only close contracts: never open, add to or flip a position
+
+	// Tranches scale out of the position at multiple targets. Fractions may
+	// sum to less than 1; the remainder runs until the stop (or TakeProfit).
+	Tranches []TakeProfitTranche
+}
+
+// ReduceOnlyContracts returns how many contracts of a reduce-only order may
+// fill against held contracts on heldSide: the order's size, capped at the
+// position. It fails with ErrNotReducing unless the position is on the
+// other side.
+func (o OrderIntent) ReduceOnlyContracts(heldSide Side, held int) (int, error) {
+	if held <= 0 || heldSide != o.Side.Opposite() {
+		return 0, fmt.Errorf("%w: %s %s", ErrNotReducing, o.Side, o.Symbol)
+	}
+	return min(o.Contracts, held), nil
+}
+
+// TakeProfitTranche closes part of a position at a distance from entry.
+type TakeProfitTranche struct {
+	Offset   decimal.Decimal // Price distance from entry in the position's favor
+	Fraction decimal.Decimal // Share of the original contracts to close (0-1]
+}
+
+// ScaleOutTarget is a resolved tranche on an open position.
+type ScaleOutTarget struct {
+	Price     decimal.Decimal
+	Contracts int
 }
 
 // OrderResult represents the result of an order execution.
@@ -148,10 +189,33 @@ type Position struct {
 	EntryTime    time.Time
 	StopLoss     decimal.Decimal
 	TakeProfit   decimal.Decimal
+	InitialStop  decimal.Decimal // Stop at entry; StopLoss may move later
+	ScaleOuts    []ScaleOutTarget // Pending partial exits, nearest first
+	BarsHeld     int              // Bars seen since entry, for time exits
 	UnrealizedPL decimal.Decimal
 	RealizedPL   decimal.Decimal
 }
 
+// InitialRisk returns the dollars at risk when the position was opened:
+// distance from entry to the original stop times point value times contracts.
+// Returns zero when no stop was set.
+func (p Position) InitialRisk(pointValue decimal.Decimal) decimal.Decimal {
+	if p.InitialStop.IsZero() {
+		return decimal.Zero
+	}
+	return p.EntryPrice.Sub(p.InitialStop).Abs().Mul(pointValue).Mul(decimal.NewFromInt(int64(p.Contracts)))
+}
+
+// RMultiple expresses a net P&L in units of the position's initial risk.
+// Returns zero when the initial risk is unknown.
+func (p Position) RMultiple(netPL, pointValue decimal.Decimal) decimal.Decimal {
+	risk := p.InitialRisk(pointValue)
+	if !risk.IsPositive() {
+		return decimal.Zero
+	}
+	return netPL.Div(risk)
+}
+
 // EquitySnapshot represents the account state at a point in time.
 type EquitySnapshot struct {
 	Timestamp     time.Time
@@ -160,6 +224,8 @@ type EquitySnapshot struct {
 	Drawdown      decimal.Decimal // As ratio (0.15 = 15%)
 	OpenPositions int
 	DailyPL       decimal.Decimal
+
+	CooldownRemaining time.Duration // Post kill switch cooldown left (0 = none)
 }
 
 // Trade represents a completed trade (for audit trail).
@@ -178,6 +244,8 @@ type Trade struct {
 	RMultiple     decimal.Decimal // Profit in terms of initial risk
 	SignalID      string
 	StrategyName  string
+	Overnight     bool            // Held through a session close (tagged by backtests that split sessions)
+	ExitReason    string          // What closed the trade (e.g. stop_loss, take_profit; "" = unknown)
 }
 
 // InstrumentSpec defines the specifications of a trading instrument.
@@ -188,6 +256,27 @@ type InstrumentSpec struct {
 	PointValue    decimal.Decimal // Dollar value per point
 	MarginInitial decimal.Decimal
 	MarginIntra   decimal.Decimal // Intraday margin
+	ExchangeFee   decimal.Decimal // Exchange + regulatory fees per contract per side
+	MiniSymbol    string          // Full-size sibling of a micro contract (e.g. ES for MES; "" = none)
+	MicrosPerMini int             // Micro contracts equal to one MiniSymbol contract (e.g. 10)
+}
+
+// RoundTripCost estimates the cost of entering and exiting one contract:
+// commission plus slippage ticks on both sides. commissionPerSide is all-in;
+// with itemized pricing the caller adds ExchangeFee to it.
+func (s InstrumentSpec) RoundTripCost(commissionPerSide decimal.Decimal, slippageTicks int) decimal.Decimal {
+	perSide := commissionPerSide.Add(s.TickValue.Mul(decimal.NewFromInt(int64(slippageTicks))))
+	return perSide.Mul(decimal.NewFromInt(2))
+}
+
+// PriceDecimals returns the decimals needed to show any price on the tick
+// grid: 2 for a 0.25 tick, 1 for 0.10, 4 for 0.0001.
+func (s InstrumentSpec) PriceDecimals() int32 {
+	var places int32
+	for !s.TickSize.Shift(places).IsInteger() && places < 10 {
+		places++
+	}
+	return places
 }
 
 // Common instrument specifications.
@@ -199,6 +288,19 @@ var (
 		PointValue:    decimal.RequireFromString("5.00"),
 		MarginInitial: decimal.RequireFromString("1500"),
 		MarginIntra:   decimal.RequireFromString("50"),
+		ExchangeFee:   decimal.RequireFromString("0.37"), // CME + NFA
+		MiniSymbol:    "ES",
+		MicrosPerMini: 10,
+	}
+
+	InstrumentES = InstrumentSpec{
+		Symbol:        "ES",
+		TickSize:      decimal.RequireFromString("0.25"),
+		TickValue:     decimal.RequireFromString("12.50"),
+		PointValue:    decimal.RequireFromString("50.00"),
+		MarginInitial: decimal.RequireFromString("15000"),
+		MarginIntra:   decimal.RequireFromString("500"),
+		ExchangeFee:   decimal.RequireFromString("1.40"), // CME + NFA
 	}
 
 	InstrumentMGC = InstrumentSpec{
@@ -208,17 +310,93 @@ var (
 		PointValue:    decimal.RequireFromString("10.00"),
 		MarginInitial: decimal.RequireFromString("1100"),
 		MarginIntra:   decimal.RequireFromString("550"),
+		ExchangeFee:   decimal.RequireFromString("0.62"), // COMEX + NFA
+		MiniSymbol:    "GC",
+		MicrosPerMini: 10,
 	}
 )
 
-// GetInstrumentSpec returns the specification for a symbol.
+var (
+	instrumentMu    sync.RWMutex
+	instrumentSpecs = map[string]InstrumentSpec{
+		"MES": InstrumentMES,
+		"ES":  InstrumentES,
+		"MGC": InstrumentMGC,
+	}
+)
+
+// GetInstrumentSpec returns the specification for a symbol, including any
+// registered overrides.
 func GetInstrumentSpec(symbol string) (InstrumentSpec, bool) {
-	switch symbol {
-	case "MES":
-		return InstrumentMES, true
-	case "MGC":
-		return InstrumentMGC, true
-	default:
-		return InstrumentSpec{}, false
+	instrumentMu.RLock()
+	defer instrumentMu.RUnlock()
+
+	spec, ok := instrumentSpecs[symbol]
+	return spec, ok
+}
+
+// RegisterInstrumentSpec adds a specification or replaces the existing one
+// for spec.Symbol. It is meant to be called once at startup, before any
+// component looks up specs.
+func RegisterInstrumentSpec(spec InstrumentSpec) error {
+	if err := spec.Validate(); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, spec.Symbol, err)
+	}
+
+	instrumentMu.Lock()
+	defer instrumentMu.Unlock()
+
+	instrumentSpecs[spec.Symbol] = spec
+	return nil
+}
+
+// Validate checks that the spec's values are positive and that tick value
+// equals tick size times point value.
+func (s InstrumentSpec) Validate() error {
+	if s.Symbol == "" {
+		return fmt.Errorf("symbol is required")
+	}
+	if !s.TickSize.IsPositive() || !s.TickValue.IsPositive() || !s.PointValue.IsPositive() {
+		return fmt.Errorf("tick size, tick value and point value must be positive")
+	}
+	if s.MarginInitial.IsNegative() || s.MarginIntra.IsNegative() || s.ExchangeFee.IsNegative() {
+		return fmt.Errorf("margins and exchange fee must not be negative")
+	}
+	if !s.TickSize.Mul(s.PointValue).Equal(s.TickValue) {
+		return fmt.Errorf("tick value %s must equal tick size %s * point value %s",
+			s.TickValue, s.TickSize, s.PointValue)
+	}
+	if s.MarginInitial.IsPositive() && s.MarginIntra.GreaterThan(s.MarginInitial) {
+		return fmt.Errorf("intraday margin %s exceeds initial margin %s", s.MarginIntra, s.MarginInitial)
+	}
+	if (s.MiniSymbol == "") != (s.MicrosPerMini == 0) || s.MicrosPerMini < 0 {
+		return fmt.Errorf("mini symbol and a positive micros per mini must be set together")
+	}
+	if s.MiniSymbol == s.Symbol {
+		return fmt.Errorf("mini symbol must differ from the symbol")
+	}
+	return nil
+}
+
+// MiniEquivalent returns the full-size contract symbol and the number of
+// its contracts that contracts of symbol amount to: MES 20 is ES 2. Minis
+// and unknown symbols are their own equivalent.
+func MiniEquivalent(symbol string, contracts int) (string, decimal.Decimal) {
+	spec, ok := GetInstrumentSpec(symbol)
+	if !ok || spec.MiniSymbol == "" {
+		return symbol, decimal.NewFromInt(int64(contracts))
+	}
+	return spec.MiniSymbol, decimal.NewFromInt(int64(contracts)).Div(decimal.NewFromInt(int64(spec.MicrosPerMini)))
+}
+
+// ConvertContracts converts contracts of symbol into contracts of its
+// micro/mini sibling to: MES 20 is ES 2, ES 1 is MES 10. Converting a
+// symbol to itself is a no-op.
+func ConvertContracts(symbol string, contracts int, to string) (decimal.Decimal, error) {
+	from, amount := MiniEquivalent(symbol, contracts)
+	target, perMini := MiniEquivalent(to, 1)
+	if from != target {
+		return decimal.Zero, fmt.Errorf("%w: %s and %s are not micro/mini siblings", ErrInvalidSymbol, symbol, to)
 	}
+	return amount.Div(perMini), nil
 }
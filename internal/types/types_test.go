@@ -1,6 +1,8 @@
 package types
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/shopspring/decimal"
@@ -185,3 +187,178 @@ func TestInstrumentSpec_TicksToPrice(t *testing.T) {
 		t.Errorf("10 ticks = %s, want 2.5", result.String())
 	}
 }
+
+func TestInstrumentSpec_PriceDecimals(t *testing.T) {
+	tests := []struct {
+		spec InstrumentSpec
+		want int32
+	}{
+		{InstrumentMES, 2},
+		{InstrumentMGC, 1},
+		{InstrumentSpec{Symbol: "6E", TickSize: decimal.RequireFromString("0.0001")}, 4},
+		{InstrumentSpec{Symbol: "ZB", TickSize: decimal.NewFromInt(1)}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.spec.PriceDecimals(); got != tt.want {
+			t.Errorf("%s PriceDecimals() = %d, want %d", tt.spec.Symbol, got, tt.want)
+		}
+	}
+}
+
+func TestInstrumentSpec_RoundTripCost(t *testing.T) {
+	mesSpec, ok := GetInstrumentSpec("MES")
+	if !ok {
+		t.Fatal("expected MES spec")
+	}
+
+	// 2 * (0.62 all-in commission + 1 tick * 1.25)
+	expected := decimal.RequireFromString("3.74")
+	result := mesSpec.RoundTripCost(decimal.RequireFromString("0.62"), 1)
+
+	if !result.Equal(expected) {
+		t.Errorf("RoundTripCost = %s, want %s", result, expected)
+	}
+
+	// Itemized pricing: 2 * (0.62 commission + 0.37 fees + 1 tick * 1.25)
+	expected = decimal.RequireFromString("4.48")
+	result = mesSpec.RoundTripCost(decimal.RequireFromString("0.62").Add(mesSpec.ExchangeFee), 1)
+
+	if !result.Equal(expected) {
+		t.Errorf("itemized RoundTripCost = %s, want %s", result, expected)
+	}
+}
+
+func TestInstrumentSpec_Validate(t *testing.T) {
+	if err := InstrumentMES.Validate(); err != nil {
+		t.Errorf("MES Validate() error = %v", err)
+	}
+	if err := InstrumentMGC.Validate(); err != nil {
+		t.Errorf("MGC Validate() error = %v", err)
+	}
+
+	inconsistent := InstrumentMES
+	inconsistent.TickValue = decimal.RequireFromString("2.50")
+	if err := inconsistent.Validate(); err == nil {
+		t.Error("expected error when tick value != tick size * point value")
+	}
+
+	zeroTick := InstrumentMES
+	zeroTick.TickSize = decimal.Zero
+	if err := zeroTick.Validate(); err == nil {
+		t.Error("expected error for zero tick size")
+	}
+
+	if err := InstrumentES.Validate(); err != nil {
+		t.Errorf("ES Validate() error = %v", err)
+	}
+	noRatio := InstrumentMES
+	noRatio.MicrosPerMini = 0
+	if err := noRatio.Validate(); err == nil {
+		t.Error("expected error for a mini symbol without a ratio")
+	}
+}
+
+func TestConvertContracts(t *testing.T) {
+	tests := []struct {
+		symbol    string
+		contracts int
+		to        string
+		want      string
+	}{
+		{"MES", 20, "ES", "2"},
+		{"MES", 5, "ES", "0.5"},
+		{"ES", 3, "MES", "30"},
+		{"MES", 7, "MES", "7"},
+		{"MGC", 10, "GC", "1"},
+	}
+	for _, tt := range tests {
+		got, err := ConvertContracts(tt.symbol, tt.contracts, tt.to)
+		if err != nil || !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("ConvertContracts(%s %d, %s) = %s, %v; want %s", tt.symbol, tt.contracts, tt.to, got, err, tt.want)
+		}
+	}
+
+	if _, err := ConvertContracts("MES", 10, "MGC"); !errors.Is(err, ErrInvalidSymbol) {
+		t.Errorf("ConvertContracts(MES, MGC) error = %v, want ErrInvalidSymbol", err)
+	}
+}
+
+func TestMiniEquivalent(t *testing.T) {
+	if mini, n := MiniEquivalent("MES", 15); mini != "ES" || !n.Equal(decimal.RequireFromString("1.5")) {
+		t.Errorf("MiniEquivalent(MES, 15) = %s %s, want ES 1.5", mini, n)
+	}
+	if mini, n := MiniEquivalent("ES", 2); mini != "ES" || !n.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("MiniEquivalent(ES, 2) = %s %s, want ES 2", mini, n)
+	}
+}
+
+func TestRegisterInstrumentSpec(t *testing.T) {
+	spec := InstrumentSpec{
+		Symbol:     "TESTX",
+		TickSize:   decimal.RequireFromString("0.5"),
+		TickValue:  decimal.RequireFromString("5"),
+		PointValue: decimal.RequireFromString("10"),
+	}
+	if err := RegisterInstrumentSpec(spec); err != nil {
+		t.Fatalf("RegisterInstrumentSpec() error = %v", err)
+	}
+
+	got, ok := GetInstrumentSpec("TESTX")
+	if !ok || !got.TickValue.Equal(spec.TickValue) {
+		t.Errorf("GetInstrumentSpec(TESTX) = %+v, %v", got, ok)
+	}
+
+	spec.Symbol = "TESTY"
+	spec.TickValue = decimal.RequireFromString("4")
+	if err := RegisterInstrumentSpec(spec); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("RegisterInstrumentSpec() error = %v, want ErrInvalidConfig", err)
+	}
+	if _, ok := GetInstrumentSpec("TESTY"); ok {
+		t.Error("invalid spec should not be registered")
+	}
+}
+
+func TestReasonFor(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want RejectReason
+	}{
+		{"sentinel", ErrKillSwitchActive, RejectSafeMode},
+		{"wrapped sentinel", fmt.Errorf("%w: net 1 < min 5", ErrTargetBelowCosts), RejectBelowCosts},
+		{"rejection error", Reject(RejectNoStop, errors.New("no stop distance")), RejectNoStop},
+		{"wrapped rejection", fmt.Errorf("size: %w", Reject(RejectSpread, errors.New("wide"))), RejectSpread},
+		{"unknown", errors.New("something odd"), RejectOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReasonFor(tt.err); got != tt.want {
+				t.Errorf("ReasonFor() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Rejection errors still match their sentinel
+	if err := Reject(RejectExposure, ErrExposureLimitExceeded); !errors.Is(err, ErrExposureLimitExceeded) {
+		t.Error("RejectionError should unwrap to its sentinel")
+	}
+}
+
+// TestOrderIntent_ReduceOnlyContracts tests the reduce-only cap.
+func TestOrderIntent_ReduceOnlyContracts(t *testing.T) {
+	sell := OrderIntent{Symbol: "MES", Side: SideShort, Contracts: 3}
+
+	if got, err := sell.ReduceOnlyContracts(SideLong, 1); err != nil || got != 1 {
+		t.Errorf("against 1 long = %d, %v; want 1 (capped)", got, err)
+	}
+	if got, err := sell.ReduceOnlyContracts(SideLong, 5); err != nil || got != 3 {
+		t.Errorf("against 5 long = %d, %v; want 3", got, err)
+	}
+	if _, err := sell.ReduceOnlyContracts(SideShort, 2); !errors.Is(err, ErrNotReducing) {
+		t.Errorf("against a short: err = %v, want ErrNotReducing", err)
+	}
+	if _, err := sell.ReduceOnlyContracts(SideLong, 0); !errors.Is(err, ErrNotReducing) {
+		t.Errorf("when flat: err = %v, want ErrNotReducing", err)
+	}
+}
@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+// DefaultPercentDecimals is the precision percentages print with unless
+// configured otherwise.
+const DefaultPercentDecimals = 2
+
+// AutoDecimals asks NewFormat for the default precision.
+const AutoDecimals int32 = -1
+
+// Format decides how many decimals prices and percentages are printed with.
+// Dollar amounts always print in cents.
+type Format struct {
+	PriceDecimals   int32
+	PercentDecimals int32
+}
+
+// NewFormat returns the format for symbol. A negative priceDecimals, such as
+// AutoDecimals, uses the instrument's tick precision and a negative
+// percentDecimals the default.
+func NewFormat(symbol string, priceDecimals, percentDecimals int32) Format {
+	if priceDecimals < 0 {
+		spec, _ := types.GetInstrumentSpec(symbol)
+		priceDecimals = spec.PriceDecimals()
+	}
+	if percentDecimals < 0 {
+		percentDecimals = DefaultPercentDecimals
+	}
+	return Format{PriceDecimals: priceDecimals, PercentDecimals: percentDecimals}
+}
+
+// Price formats a price, e.g. 5000.25.
+func (f Format) Price(price decimal.Decimal) string {
+	return price.StringFixed(f.PriceDecimals)
+}
+
+// Percent formats a ratio as a percentage, e.g. 0.0125 as 1.25%.
+func (f Format) Percent(ratio decimal.Decimal) string {
+	return ratio.Mul(decimal.NewFromInt(100)).StringFixed(f.PercentDecimals) + "%"
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/tathienbao/quant-bot/internal/types"
+)
+
+func TestFormat_Price(t *testing.T) {
+	eurofx := types.InstrumentSpec{
+		Symbol:     "M6E",
+		TickSize:   decimal.RequireFromString("0.0001"),
+		TickValue:  decimal.RequireFromString("1.25"),
+		PointValue: decimal.RequireFromString("12500"),
+	}
+	if err := types.RegisterInstrumentSpec(eurofx); err != nil {
+		t.Fatalf("RegisterInstrumentSpec() error = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		symbol        string
+		priceDecimals int32
+		price         string
+		want          string
+	}{
+		{"MES tick precision", "MES", AutoDecimals, "5000.25", "5000.25"},
+		{"MES whole price", "MES", AutoDecimals, "5000", "5000.00"},
+		{"MGC tick precision", "MGC", AutoDecimals, "2350.1", "2350.1"},
+		{"4 decimal instrument", "M6E", AutoDecimals, "1.0825", "1.0825"},
+		{"configured precision", "MES", 4, "5000.25", "5000.2500"},
+		{"zero decimals", "MES", 0, "5000.25", "5000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFormat(tt.symbol, tt.priceDecimals, AutoDecimals)
+			if got := f.Price(decimal.RequireFromString(tt.price)); got != tt.want {
+				t.Errorf("Price(%s) = %q, want %q", tt.price, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat_Percent(t *testing.T) {
+	ratio := decimal.RequireFromString("0.012345")
+
+	if got := NewFormat("MES", AutoDecimals, AutoDecimals).Percent(ratio); got != "1.23%" {
+		t.Errorf("Percent() default = %q, want 1.23%%", got)
+	}
+	if got := NewFormat("MES", AutoDecimals, 3).Percent(ratio); got != "1.235%" {
+		t.Errorf("Percent() with 3 decimals = %q, want 1.235%%", got)
+	}
+	if got := NewFormat("MES", AutoDecimals, 0).Percent(ratio); got != "1%" {
+		t.Errorf("Percent() with 0 decimals = %q, want 1%%", got)
+	}
+}
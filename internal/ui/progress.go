@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/term"
+)
+
+// ProgressInterval is how often ProgressPrinter writes a line.
+const ProgressInterval = 2 * time.Second
+
+// ProgressPrinter writes a one-line percentage and ETA for long backtests.
+// On a terminal it rewrites a single line; otherwise (CI logs, pipes) it
+// appends a new line each time.
+type ProgressPrinter struct {
+	out       io.Writer
+	tty       bool
+	totalBars int
+	interval  time.Duration
+
+	start     time.Time
+	lastPrint time.Time
+}
+
+// NewProgressPrinter creates a printer writing to stderr.
+func NewProgressPrinter(totalBars int) *ProgressPrinter {
+	return &ProgressPrinter{
+		out:       os.Stderr,
+		tty:       IsTerminal(os.Stderr),
+		totalBars: totalBars,
+		interval:  ProgressInterval,
+		start:     time.Now(),
+	}
+}
+
+// IsTerminal reports whether f is an interactive terminal.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// Update records progress at bar and prints if the interval has passed.
+func (p *ProgressPrinter) Update(bar int, equity decimal.Decimal) {
+	now := time.Now()
+	if now.Sub(p.lastPrint) < p.interval {
+		return
+	}
+	p.lastPrint = now
+	p.print(bar, equity, now)
+}
+
+// Finish prints the final state and ends the line.
+func (p *ProgressPrinter) Finish(bar int, equity decimal.Decimal) {
+	p.print(bar, equity, time.Now())
+	if p.tty {
+		_, _ = fmt.Fprintln(p.out)
+	}
+}
+
+func (p *ProgressPrinter) print(bar int, equity decimal.Decimal, now time.Time) {
+	elapsed := now.Sub(p.start)
+	line := fmt.Sprintf("bar %d | equity $%s | elapsed %s", bar, equity.StringFixed(2), elapsed.Round(time.Second))
+	if p.totalBars > 0 {
+		pct := float64(bar) / float64(p.totalBars) * 100
+		eta := "?"
+		if bar > 0 && bar < p.totalBars {
+			remaining := time.Duration(float64(elapsed) / float64(bar) * float64(p.totalBars-bar))
+			eta = remaining.Round(time.Second).String()
+		} else if bar >= p.totalBars {
+			eta = "0s"
+		}
+		line = fmt.Sprintf("%5.1f%% | bar %d/%d | equity $%s | ETA %s",
+			min(pct, 100), bar, p.totalBars, equity.StringFixed(2), eta)
+	}
+
+	if p.tty {
+		_, _ = fmt.Fprint(p.out, MoveToStart+ClearLine+line)
+	} else {
+		_, _ = fmt.Fprintln(p.out, line)
+	}
+}
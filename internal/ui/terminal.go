@@ -48,6 +48,9 @@ type BacktestUI struct {
 	winRate     decimal.Decimal
 	lastSignal  string
 
+	// Display precision of the price axis and percentages
+	format Format
+
 	// Terminal
 	width int
 }
@@ -72,10 +75,16 @@ func NewBacktestUI(totalBars int, startEquity decimal.Decimal) *BacktestUI {
 		totalBars:   totalBars,
 		startEquity: startEquity,
 		equity:      startEquity,
+		format:      Format{PriceDecimals: 1, PercentDecimals: 1},
 		width:       width,
 	}
 }
 
+// SetFormat sets the precision of the price axis and percentages.
+func (ui *BacktestUI) SetFormat(f Format) {
+	ui.format = f
+}
+
 // Start initializes the UI
 func (ui *BacktestUI) Start() {
 	fmt.Print(HideCursor)
@@ -108,39 +117,41 @@ func (ui *BacktestUI) UpdateStats(equity decimal.Decimal, trades int, winRate de
 
 // Render draws single-line progress (overwrites in place)
 func (ui *BacktestUI) Render() {
-	progress := float64(ui.currentBar) / float64(ui.totalBars)
-	if ui.totalBars == 0 {
-		progress = 0
-	}
-
 	// Calculate P&L
 	pnlPct := decimal.Zero
 	if !ui.startEquity.IsZero() {
-		pnlPct = ui.equity.Sub(ui.startEquity).Div(ui.startEquity).Mul(decimal.NewFromInt(100))
+		pnlPct = ui.equity.Sub(ui.startEquity).Div(ui.startEquity)
 	}
 	pnlColor := ColorGreen
 	pnlSign := "+"
 	if pnlPct.LessThan(decimal.Zero) {
 		pnlColor = ColorRed
-		pnlSign = ""
+		pnlSign = "-"
 	}
 
 	// Progress bar width
 	barWidth := 40
-	filled := int(progress * float64(barWidth))
-	if filled > barWidth {
-		filled = barWidth
+	var progressBar, position string
+	if ui.totalBars > 0 {
+		progress := float64(ui.currentBar) / float64(ui.totalBars)
+		filled := min(int(progress*float64(barWidth)), barWidth)
+		progressBar = strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+		position = fmt.Sprintf("%.1f%%", progress*100)
+	} else {
+		// Length unknown: a block sweeps the bar and the bar count is shown
+		pulse := ui.currentBar % barWidth
+		progressBar = strings.Repeat("░", pulse) + "█" + strings.Repeat("░", barWidth-pulse-1)
+		position = fmt.Sprintf("bar %d", ui.currentBar)
 	}
-	progressBar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
 
 	// Single line: progress + equity + trades
-	line := fmt.Sprintf("%s%s%s %.1f%% │ $%.0f (%s%s%.1f%%%s) │ Trades: %d │ Win: %.1f%%",
+	line := fmt.Sprintf("%s%s%s %s │ $%.0f (%s%s%s%s) │ Trades: %d │ Win: %s",
 		MoveToStart,
-		ColorCyan, progressBar, progress*100,
+		ColorCyan, progressBar, position,
 		ui.equity.InexactFloat64(),
-		pnlColor, pnlSign, pnlPct.Abs().InexactFloat64(), ColorReset,
+		pnlColor, pnlSign, ui.format.Percent(pnlPct.Abs()), ColorReset,
 		ui.trades,
-		ui.winRate.InexactFloat64(),
+		ui.format.Percent(ui.winRate.Div(decimal.NewFromInt(100))),
 	)
 
 	fmt.Print(ClearLine + line)
@@ -161,18 +172,20 @@ func (ui *BacktestUI) RenderFinal() {
 	// Print stats
 	pnlPct := decimal.Zero
 	if !ui.startEquity.IsZero() {
-		pnlPct = ui.equity.Sub(ui.startEquity).Div(ui.startEquity).Mul(decimal.NewFromInt(100))
+		pnlPct = ui.equity.Sub(ui.startEquity).Div(ui.startEquity)
 	}
 	pnlColor := ColorGreen
+	pnlSign := "+"
 	if pnlPct.LessThan(decimal.Zero) {
 		pnlColor = ColorRed
+		pnlSign = "-"
 	}
 
-	fmt.Printf("%sEquity:%s $%.0f (%s%+.2f%%%s) │ %sTrades:%s %d │ %sWin:%s %.1f%%\n",
+	fmt.Printf("%sEquity:%s $%.0f (%s%s%s%s) │ %sTrades:%s %d │ %sWin:%s %s\n",
 		ColorBold, ColorReset, ui.equity.InexactFloat64(),
-		pnlColor, pnlPct.InexactFloat64(), ColorReset,
+		pnlColor, pnlSign, ui.format.Percent(pnlPct.Abs()), ColorReset,
 		ColorBold, ColorReset, ui.trades,
-		ColorBold, ColorReset, ui.winRate.InexactFloat64())
+		ColorBold, ColorReset, ui.format.Percent(ui.winRate.Div(decimal.NewFromInt(100))))
 }
 
 // renderChart creates ASCII candlestick chart
@@ -254,6 +267,9 @@ func (ui *BacktestUI) renderChart() []string {
 		}
 	}
 
+	// Labels are as wide as the widest price at the configured precision
+	labelWidth := max(7, len(ui.format.Price(maxPrice)), len(ui.format.Price(minPrice)))
+
 	// Convert to strings
 	lines := make([]string, height)
 	for y := 0; y < height; y++ {
@@ -262,9 +278,9 @@ func (ui *BacktestUI) renderChart() []string {
 		// Price label
 		if y%(height/4) == 0 {
 			price := yToPrice(y, minPrice, priceRange, height)
-			sb.WriteString(fmt.Sprintf("%s%7.1f%s │", ColorDim, price.InexactFloat64(), ColorReset))
+			sb.WriteString(fmt.Sprintf("%s%*s%s │", ColorDim, labelWidth, ui.format.Price(price), ColorReset))
 		} else {
-			sb.WriteString(fmt.Sprintf("%s        │%s", ColorDim, ColorReset))
+			sb.WriteString(fmt.Sprintf("%s%s │%s", ColorDim, strings.Repeat(" ", labelWidth), ColorReset))
 		}
 
 		for x := 0; x < width; x++ {
@@ -0,0 +1,70 @@
+package indicator
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// EMA calculates Exponential Moving Average.
+// The first value is seeded with the SMA of the first period values.
+type EMA struct {
+	period     int
+	multiplier decimal.Decimal
+	current    decimal.Decimal
+	count      int
+	seedSum    decimal.Decimal
+}
+
+// NewEMA creates a new EMA calculator with the given period.
+func NewEMA(period int) *EMA {
+	if period < 1 {
+		period = 1
+	}
+	return &EMA{
+		period:     period,
+		multiplier: decimal.NewFromInt(2).Div(decimal.NewFromInt(int64(period + 1))),
+	}
+}
+
+// Update adds a new value and returns the current EMA.
+// Returns zero if not enough data points yet.
+func (e *EMA) Update(value decimal.Decimal) decimal.Decimal {
+	e.count++
+
+	if e.count < e.period {
+		e.seedSum = e.seedSum.Add(value)
+		return decimal.Zero
+	}
+	if e.count == e.period {
+		e.current = e.seedSum.Add(value).Div(decimal.NewFromInt(int64(e.period)))
+		return e.current
+	}
+
+	// EMA = (value - prev) * multiplier + prev
+	e.current = value.Sub(e.current).Mul(e.multiplier).Add(e.current)
+	return e.current
+}
+
+// Current returns the current EMA value without adding new data.
+func (e *EMA) Current() decimal.Decimal {
+	if !e.Ready() {
+		return decimal.Zero
+	}
+	return e.current
+}
+
+// Ready returns true if enough data points have been collected.
+func (e *EMA) Ready() bool {
+	return e.count >= e.period
+}
+
+// Period returns the EMA period.
+func (e *EMA) Period() int {
+	return e.period
+}
+
+// Reset clears all data.
+func (e *EMA) Reset() {
+	e.current = decimal.Zero
+	e.seedSum = decimal.Zero
+	e.count = 0
+}
@@ -0,0 +1,41 @@
+package indicator
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestEMA_SeedsWithSMA(t *testing.T) {
+	ema := NewEMA(3)
+
+	ema.Update(decimal.NewFromInt(10))
+	if ema.Ready() {
+		t.Error("EMA should not be ready before period values")
+	}
+	ema.Update(decimal.NewFromInt(20))
+	result := ema.Update(decimal.NewFromInt(30))
+
+	// Seed = SMA(3) of [10, 20, 30] = 20
+	if !result.Equal(decimal.NewFromInt(20)) {
+		t.Errorf("EMA seed = %s, want 20", result)
+	}
+
+	// Multiplier 2/(3+1) = 0.5: (40 - 20) * 0.5 + 20 = 30
+	result = ema.Update(decimal.NewFromInt(40))
+	if !result.Equal(decimal.NewFromInt(30)) {
+		t.Errorf("EMA = %s, want 30", result)
+	}
+}
+
+func TestEMA_Reset(t *testing.T) {
+	ema := NewEMA(2)
+	ema.Update(decimal.NewFromInt(10))
+	ema.Update(decimal.NewFromInt(20))
+
+	ema.Reset()
+
+	if ema.Ready() || !ema.Current().IsZero() {
+		t.Errorf("after Reset: ready = %v, current = %s", ema.Ready(), ema.Current())
+	}
+}
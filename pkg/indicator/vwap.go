@@ -0,0 +1,115 @@
+package indicator
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// ZeroVolumePolicy controls how volume-weighted indicators treat bars
+// with zero or missing volume (synthetic or illiquid feeds).
+type ZeroVolumePolicy int
+
+const (
+	// ZeroVolumeSkip ignores the bar for volume-weighted calculations.
+	ZeroVolumeSkip ZeroVolumePolicy = iota
+	// ZeroVolumeUnit treats the bar as having a volume of 1.
+	ZeroVolumeUnit
+)
+
+// String returns the policy name.
+func (p ZeroVolumePolicy) String() string {
+	switch p {
+	case ZeroVolumeSkip:
+		return "skip"
+	case ZeroVolumeUnit:
+		return "unit"
+	default:
+		return "unknown"
+	}
+}
+
+// vwapBar holds one bar's contribution to the VWAP window.
+type vwapBar struct {
+	priceVolume decimal.Decimal
+	volume      decimal.Decimal
+}
+
+// VWAP calculates rolling Volume Weighted Average Price using typical price (H+L+C)/3.
+type VWAP struct {
+	period int
+	policy ZeroVolumePolicy
+	bars   []vwapBar
+	sumPV  decimal.Decimal
+	sumVol decimal.Decimal
+}
+
+// NewVWAP creates a new VWAP calculator over the given number of bars.
+func NewVWAP(period int, policy ZeroVolumePolicy) *VWAP {
+	if period < 1 {
+		period = 1
+	}
+	return &VWAP{
+		period: period,
+		policy: policy,
+		bars:   make([]vwapBar, 0, period),
+		sumPV:  decimal.Zero,
+		sumVol: decimal.Zero,
+	}
+}
+
+// Update adds a new bar and returns the current VWAP.
+// Returns zero if not enough data points yet.
+func (v *VWAP) Update(high, low, close decimal.Decimal, volume int64) decimal.Decimal {
+	if volume <= 0 {
+		if v.policy == ZeroVolumeSkip {
+			return v.Current()
+		}
+		volume = 1
+	}
+
+	typical := high.Add(low).Add(close).Div(decimal.NewFromInt(3))
+	vol := decimal.NewFromInt(volume)
+	bar := vwapBar{priceVolume: typical.Mul(vol), volume: vol}
+
+	v.bars = append(v.bars, bar)
+	v.sumPV = v.sumPV.Add(bar.priceVolume)
+	v.sumVol = v.sumVol.Add(bar.volume)
+
+	if len(v.bars) > v.period {
+		// Remove oldest bar
+		v.sumPV = v.sumPV.Sub(v.bars[0].priceVolume)
+		v.sumVol = v.sumVol.Sub(v.bars[0].volume)
+		v.bars = v.bars[1:]
+	}
+
+	return v.Current()
+}
+
+// Current returns the current VWAP value without adding new data.
+func (v *VWAP) Current() decimal.Decimal {
+	if len(v.bars) < v.period || !v.sumVol.IsPositive() {
+		return decimal.Zero
+	}
+	return v.sumPV.Div(v.sumVol)
+}
+
+// Ready returns true if enough bars with volume have been collected.
+func (v *VWAP) Ready() bool {
+	return len(v.bars) >= v.period
+}
+
+// Period returns the VWAP period.
+func (v *VWAP) Period() int {
+	return v.period
+}
+
+// Policy returns the zero-volume policy.
+func (v *VWAP) Policy() ZeroVolumePolicy {
+	return v.policy
+}
+
+// Reset clears all data.
+func (v *VWAP) Reset() {
+	v.bars = v.bars[:0]
+	v.sumPV = decimal.Zero
+	v.sumVol = decimal.Zero
+}
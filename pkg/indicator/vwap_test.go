@@ -0,0 +1,76 @@
+package indicator
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestVWAP_Basic(t *testing.T) {
+	vwap := NewVWAP(2, ZeroVolumeSkip)
+
+	// Typical prices 100 and 110 (H=L=C), volumes 100 and 300
+	vwap.Update(decimal.NewFromInt(100), decimal.NewFromInt(100), decimal.NewFromInt(100), 100)
+	result := vwap.Update(decimal.NewFromInt(110), decimal.NewFromInt(110), decimal.NewFromInt(110), 300)
+
+	// (100*100 + 110*300) / 400 = 107.5
+	expected := decimal.NewFromFloat(107.5)
+	if !result.Equal(expected) {
+		t.Errorf("VWAP = %s, want %s", result, expected)
+	}
+}
+
+func TestVWAP_ZeroVolumeSkip(t *testing.T) {
+	vwap := NewVWAP(2, ZeroVolumeSkip)
+
+	// All-zero volume must not divide by zero or make the indicator ready
+	for i := 0; i < 5; i++ {
+		result := vwap.Update(decimal.NewFromInt(100), decimal.NewFromInt(100), decimal.NewFromInt(100), 0)
+		if !result.IsZero() {
+			t.Errorf("VWAP = %s, want 0 with no volume", result)
+		}
+	}
+	if vwap.Ready() {
+		t.Error("VWAP should not be ready from zero-volume bars")
+	}
+
+	vwap.Update(decimal.NewFromInt(100), decimal.NewFromInt(100), decimal.NewFromInt(100), 100)
+	vwap.Update(decimal.NewFromInt(110), decimal.NewFromInt(110), decimal.NewFromInt(110), 100)
+
+	// Zero-volume bar at a far price is ignored
+	result := vwap.Update(decimal.NewFromInt(500), decimal.NewFromInt(500), decimal.NewFromInt(500), 0)
+	expected := decimal.NewFromInt(105)
+	if !result.Equal(expected) {
+		t.Errorf("VWAP = %s, want %s", result, expected)
+	}
+}
+
+func TestVWAP_ZeroVolumeUnit(t *testing.T) {
+	vwap := NewVWAP(2, ZeroVolumeUnit)
+
+	vwap.Update(decimal.NewFromInt(100), decimal.NewFromInt(100), decimal.NewFromInt(100), 0)
+	result := vwap.Update(decimal.NewFromInt(110), decimal.NewFromInt(110), decimal.NewFromInt(110), 0)
+
+	// Both bars weighted by 1: plain average of typical prices
+	expected := decimal.NewFromInt(105)
+	if !result.Equal(expected) {
+		t.Errorf("VWAP = %s, want %s", result, expected)
+	}
+	if !vwap.Ready() {
+		t.Error("VWAP should be ready when zero volume counts as 1")
+	}
+}
+
+func TestVWAP_Reset(t *testing.T) {
+	vwap := NewVWAP(1, ZeroVolumeSkip)
+	vwap.Update(decimal.NewFromInt(100), decimal.NewFromInt(100), decimal.NewFromInt(100), 10)
+
+	vwap.Reset()
+
+	if vwap.Ready() {
+		t.Error("VWAP should not be ready after reset")
+	}
+	if !vwap.Current().IsZero() {
+		t.Errorf("VWAP = %s, want 0 after reset", vwap.Current())
+	}
+}